@@ -0,0 +1,1000 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ctl
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deepflowio/deepflow/cli/ctl/common"
+	"github.com/deepflowio/deepflow/cli/ctl/manifest"
+)
+
+func RegisterApplyCommand() *cobra.Command {
+	var filenames []string
+	var prune, dryRun bool
+	apply := &cobra.Command{
+		Use:   "apply",
+		Short: "apply manifest of domains, agent groups, data sources, plugins and policies",
+		Long: "objects are created or updated in dependency order, only fields in the manifest are changed.\n" +
+			"supported kinds: " + strings.Join(manifest.KindNames(), ", "),
+		Example: "deepflow-ctl apply -f deepflow/ --prune --dry-run",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := applyManifest(cmd, filenames, prune, dryRun); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
+	}
+	apply.Flags().StringSliceVarP(&filenames, "filename", "f", nil, "manifest file, directory of *.yaml or - for stdin, can be repeated")
+	apply.Flags().BoolVarP(&prune, "prune", "", false, "delete objects missing in the manifest, only kinds in the manifest are pruned")
+	apply.Flags().BoolVarP(&dryRun, "dry-run", "", false, "only print the plan")
+	apply.MarkFlagRequired("filename")
+	return apply
+}
+
+func RegisterDiffCommand() *cobra.Command {
+	var filenames []string
+	var prune bool
+	diff := &cobra.Command{
+		Use:     "diff",
+		Short:   "show drift between manifest and controller, exit with 1 if there is any",
+		Example: "deepflow-ctl diff -f deepflow/ --prune",
+		Run: func(cmd *cobra.Command, args []string) {
+			plan, err := planManifest(cmd, filenames, prune)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			plan.Print(os.Stdout)
+			if plan.HasChanges() {
+				os.Exit(1)
+			}
+		},
+	}
+	diff.Flags().StringSliceVarP(&filenames, "filename", "f", nil, "manifest file, directory of *.yaml or - for stdin, can be repeated")
+	diff.Flags().BoolVarP(&prune, "prune", "", false, "also show objects missing in the manifest, only kinds in the manifest are checked")
+	diff.MarkFlagRequired("filename")
+	return diff
+}
+
+func RegisterExportCommand() *cobra.Command {
+	var kinds []string
+	export := &cobra.Command{
+		Use:     "export",
+		Short:   "export controller configuration as manifest",
+		Long:    "supported kinds: " + strings.Join(manifest.KindNames(), ", "),
+		Example: "deepflow-ctl export --kind Domain --kind AgentGroupConfig > deepflow.yaml",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := exportManifest(cmd, kinds); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
+	}
+	export.Flags().StringSliceVarP(&kinds, "kind", "", nil, "kinds to export, all kinds by default")
+	return export
+}
+
+// loadManifest reads manifest files, relative plugin image paths are resolved against the manifest file
+func loadManifest(filenames []string) ([]*manifest.Object, error) {
+	var files []string
+	for _, filename := range filenames {
+		if filename == "-" {
+			files = append(files, filename)
+			continue
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, filename)
+			continue
+		}
+		entries, err := ioutil.ReadDir(filename)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, filepath.Join(filename, entry.Name()))
+			}
+		}
+	}
+
+	var objects []*manifest.Object
+	for _, file := range files {
+		var data []byte
+		var err error
+		dir := "."
+		if file == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(file)
+			dir = filepath.Dir(file)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fileObjects, err := manifest.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		for _, object := range fileObjects {
+			if image, ok := object.Spec["image"].(string); ok && object.Kind == manifest.KIND_PLUGIN && !filepath.IsAbs(image) {
+				object.Spec["image"] = filepath.Join(dir, image)
+			}
+		}
+		objects = append(objects, fileObjects...)
+	}
+	return objects, nil
+}
+
+// listManifestObjects returns current objects of the kinds
+func listManifestObjects(client *manifestClient, kinds []string) ([]*manifest.Object, error) {
+	var objects []*manifest.Object
+	for _, kind := range kinds {
+		kindObjects, err := manifestAdapters[kind].list(client)
+		if err != nil {
+			return nil, fmt.Errorf("list %s failed: %v", kind, err)
+		}
+		for _, object := range kindObjects {
+			if object.Spec, err = manifest.Normalize(object.Spec); err != nil {
+				return nil, err
+			}
+		}
+		objects = append(objects, kindObjects...)
+	}
+	return objects, nil
+}
+
+func computeManifestPlan(client *manifestClient, filenames []string, prune bool) (*manifest.Plan, error) {
+	desired, err := loadManifest(filenames)
+	if err != nil {
+		return nil, err
+	}
+	kindSet := make(map[string]bool)
+	for _, object := range desired {
+		kindSet[object.Kind] = true
+		if normalize := manifestAdapters[object.Kind].normalize; normalize != nil {
+			if err := normalize(client, object, desired); err != nil {
+				return nil, fmt.Errorf("%s: %v", object.Key(), err)
+			}
+		}
+	}
+	var kinds []string
+	for _, kind := range manifest.KindNames() {
+		if kindSet[kind] {
+			kinds = append(kinds, kind)
+		}
+	}
+	current, err := listManifestObjects(client, kinds)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.ComputePlan(desired, current, prune)
+}
+
+func planManifest(cmd *cobra.Command, filenames []string, prune bool) (*manifest.Plan, error) {
+	return computeManifestPlan(newManifestClient(cmd), filenames, prune)
+}
+
+func applyManifest(cmd *cobra.Command, filenames []string, prune, dryRun bool) error {
+	client := newManifestClient(cmd)
+	plan, err := computeManifestPlan(client, filenames, prune)
+	if err != nil {
+		return err
+	}
+	plan.Print(os.Stdout)
+	if dryRun || !plan.HasChanges() {
+		return nil
+	}
+
+	for _, change := range plan.Changes {
+		adapter := manifestAdapters[change.Object().Kind]
+		switch change.Action {
+		case manifest.ACTION_CREATE:
+			err = adapter.create(client, change.Desired)
+		case manifest.ACTION_UPDATE:
+			err = adapter.update(client, change.Desired, change.Current)
+		case manifest.ACTION_DELETE:
+			err = adapter.delete(client, change.Current)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s failed, later changes are not applied: %v", change.Action, change.Object().Key(), err)
+		}
+		fmt.Printf("%s %s done\n", change.Action, change.Object().Key())
+	}
+	return nil
+}
+
+func exportManifest(cmd *cobra.Command, kinds []string) error {
+	if len(kinds) == 0 {
+		kinds = manifest.KindNames()
+	}
+	for _, kind := range kinds {
+		if _, ok := manifestAdapters[kind]; !ok {
+			return fmt.Errorf("kind (%s) not supported, supported kinds: %s", kind, strings.Join(manifest.KindNames(), ", "))
+		}
+	}
+	objects, err := listManifestObjects(newManifestClient(cmd), kinds)
+	if err != nil {
+		return err
+	}
+	data, err := manifest.Marshal(objects)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+// manifestClient calls controller APIs for manifest adapters, lists are cached until the next write
+type manifestClient struct {
+	cmd   *cobra.Command
+	cache map[string]*simplejson.Json
+}
+
+func newManifestClient(cmd *cobra.Command) *manifestClient {
+	return &manifestClient{cmd: cmd, cache: make(map[string]*simplejson.Json)}
+}
+
+func (c *manifestClient) url(path string) string {
+	server := common.GetServerInfo(c.cmd)
+	return fmt.Sprintf("http://%s:%d%s", server.IP, server.Port, path)
+}
+
+func (c *manifestClient) options() []common.HTTPOption {
+	return []common.HTTPOption{common.WithTimeout(common.GetTimeout(c.cmd)), common.WithORGID(common.GetORGID(c.cmd))}
+}
+
+func (c *manifestClient) list(path string) (*simplejson.Json, error) {
+	if data, ok := c.cache[path]; ok {
+		return data, nil
+	}
+	response, err := common.CURLPerform("GET", c.url(path), nil, "", c.options()...)
+	if err != nil {
+		return nil, err
+	}
+	data := response.Get("DATA")
+	c.cache[path] = data
+	return data, nil
+}
+
+func (c *manifestClient) request(method, path string, body map[string]interface{}, strBody string) error {
+	c.cache = make(map[string]*simplejson.Json)
+	_, err := common.CURLPerform(method, c.url(path), body, strBody, c.options()...)
+	return err
+}
+
+// find returns the first item of the list whose field equals value, nil if not found
+func (c *manifestClient) find(path, field string, value interface{}) (*simplejson.Json, error) {
+	data, err := c.list(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := range data.MustArray() {
+		item := data.GetIndex(i)
+		if fmt.Sprint(item.Get(field).Interface()) == fmt.Sprint(value) {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *manifestClient) mustFind(kind, name string) (*simplejson.Json, error) {
+	item, err := c.find(manifestKindPaths[kind], manifestKindNameFields[kind], name)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, fmt.Errorf("%s/%s not found", kind, name)
+	}
+	return item, nil
+}
+
+func (c *manifestClient) lcuuid(kind, name string) (string, error) {
+	item, err := c.mustFind(kind, name)
+	if err != nil {
+		return "", err
+	}
+	return item.Get("LCUUID").MustString(), nil
+}
+
+// namesToIDs converts a list of names of the kind in spec to ids
+func (c *manifestClient) namesToIDs(kind string, names interface{}, idField string) ([]interface{}, error) {
+	list, ok := names.([]interface{})
+	if !ok && names != nil {
+		return nil, fmt.Errorf("%v is not a list of %s names", names, kind)
+	}
+	ids := make([]interface{}, 0, len(list))
+	for _, name := range list {
+		item, err := c.mustFind(kind, fmt.Sprint(name))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, item.Get(idField).Interface())
+	}
+	return ids, nil
+}
+
+func (c *manifestClient) idsToNames(kind string, ids []interface{}) []interface{} {
+	names := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		item, _ := c.find(manifestKindPaths[kind], "ID", id)
+		if item == nil {
+			names = append(names, fmt.Sprint(id))
+		} else {
+			names = append(names, item.Get(manifestKindNameFields[kind]).MustString())
+		}
+	}
+	return names
+}
+
+var manifestKindPaths = map[string]string{
+	manifest.KIND_DOMAIN:         "/v2/domains/",
+	manifest.KIND_SUB_DOMAIN:     "/v2/sub-domains/",
+	manifest.KIND_AGENT_GROUP:    "/v1/vtap-groups/",
+	manifest.KIND_DATA_SOURCE:    "/v1/data-sources/",
+	manifest.KIND_PLUGIN:         "/v1/plugin/",
+	manifest.KIND_RESOURCE_GROUP: "/v1/resource-groups/",
+	manifest.KIND_ACL:            "/v1/acls/",
+	manifest.KIND_PCAP_POLICY:    "/v1/pcap-policies/",
+	manifest.KIND_NPB_POLICY:     "/v1/npb-policies/",
+}
+
+var manifestKindNameFields = map[string]string{
+	manifest.KIND_DOMAIN:         "NAME",
+	manifest.KIND_SUB_DOMAIN:     "NAME",
+	manifest.KIND_AGENT_GROUP:    "NAME",
+	manifest.KIND_DATA_SOURCE:    "DISPLAY_NAME",
+	manifest.KIND_PLUGIN:         "NAME",
+	manifest.KIND_RESOURCE_GROUP: "NAME",
+	manifest.KIND_ACL:            "NAME",
+	manifest.KIND_PCAP_POLICY:    "NAME",
+	manifest.KIND_NPB_POLICY:     "NAME",
+}
+
+// pickSpec copies non-empty fields of an API item to spec, spec keys are API keys in lower case
+func pickSpec(item *simplejson.Json, keys ...string) map[string]interface{} {
+	spec := make(map[string]interface{})
+	for _, key := range keys {
+		value := item.Get(strings.ToUpper(key)).Interface()
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			if v == "" {
+				continue
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				continue
+			}
+		}
+		spec[key] = value
+	}
+	return spec
+}
+
+// apiBody converts spec to API body with upper case keys, skipping reference and local keys
+func apiBody(spec map[string]interface{}, skipKeys ...string) map[string]interface{} {
+	skip := make(map[string]bool, len(skipKeys))
+	for _, key := range skipKeys {
+		skip[key] = true
+	}
+	body := make(map[string]interface{}, len(spec))
+	for key, value := range spec {
+		if !skip[key] {
+			body[strings.ToUpper(key)] = value
+		}
+	}
+	return body
+}
+
+type manifestAdapter struct {
+	list func(c *manifestClient) ([]*manifest.Object, error)
+	// normalize converts a desired object into the form returned by list, e.g. resolving agent groups to agents,
+	// desired are all objects of the manifest
+	normalize func(c *manifestClient, object *manifest.Object, desired []*manifest.Object) error
+	create    func(c *manifestClient, object *manifest.Object) error
+	update    func(c *manifestClient, desired, current *manifest.Object) error
+	delete    func(c *manifestClient, object *manifest.Object) error
+}
+
+var manifestAdapters = map[string]*manifestAdapter{
+	manifest.KIND_DOMAIN: {
+		list:   listManifestDomains,
+		create: createManifestDomain,
+		update: func(c *manifestClient, desired, current *manifest.Object) error {
+			lcuuid, err := c.lcuuid(manifest.KIND_DOMAIN, desired.Name)
+			if err != nil {
+				return err
+			}
+			// config is replaced by the API, unchanged fields and masked secrets are sent back as they are
+			spec := manifest.MergeSpec(current.Spec, desired.Spec)
+			return c.request("PATCH", fmt.Sprintf("/v1/domains/%s/", lcuuid), apiBody(spec, "type"), "")
+		},
+		delete: deleteManifestObjectByLcuuid(manifest.KIND_DOMAIN, "/v1/domains/%s/"),
+	},
+	manifest.KIND_SUB_DOMAIN: {
+		list:   listManifestSubDomains,
+		create: createManifestSubDomain,
+		update: func(c *manifestClient, desired, current *manifest.Object) error {
+			lcuuid, err := c.lcuuid(manifest.KIND_SUB_DOMAIN, desired.Name)
+			if err != nil {
+				return err
+			}
+			spec := manifest.MergeSpec(current.Spec, desired.Spec)
+			return c.request("PATCH", fmt.Sprintf("/v2/sub-domains/%s/", lcuuid), apiBody(spec, "domain"), "")
+		},
+		delete: deleteManifestObjectByLcuuid(manifest.KIND_SUB_DOMAIN, "/v2/sub-domains/%s/"),
+	},
+	manifest.KIND_AGENT_GROUP: {
+		list: listManifestAgentGroups,
+		create: func(c *manifestClient, object *manifest.Object) error {
+			body := apiBody(object.Spec, "group_id")
+			body["NAME"] = object.Name
+			if groupID, ok := object.Spec["group_id"]; ok {
+				body["GROUP_ID"] = groupID
+			}
+			return c.request("POST", "/v1/vtap-groups/", body, "")
+		},
+		update: func(c *manifestClient, desired, current *manifest.Object) error {
+			lcuuid, err := c.lcuuid(manifest.KIND_AGENT_GROUP, desired.Name)
+			if err != nil {
+				return err
+			}
+			return c.request("PATCH", fmt.Sprintf("/v1/vtap-groups/%s/", lcuuid), apiBody(desired.Spec, "group_id"), "")
+		},
+		delete: deleteManifestObjectByLcuuid(manifest.KIND_AGENT_GROUP, "/v1/vtap-groups/%s/"),
+	},
+	manifest.KIND_AGENT_GROUP_CONFIG: {
+		list:   listManifestAgentGroupConfigs,
+		create: createManifestAgentGroupConfig,
+		update: updateManifestAgentGroupConfig,
+		delete: func(c *manifestClient, object *manifest.Object) error {
+			group, err := c.mustFind(manifest.KIND_AGENT_GROUP, object.Name)
+			if err != nil {
+				return err
+			}
+			return c.request("DELETE", "/v1/vtap-group-configuration/filter/?vtap_group_id="+group.Get("SHORT_UUID").MustString(), nil, "")
+		},
+	},
+	manifest.KIND_DATA_SOURCE: {
+		list:   listManifestDataSources,
+		create: createManifestDataSource,
+		update: func(c *manifestClient, desired, current *manifest.Object) error {
+			lcuuid, err := c.lcuuid(manifest.KIND_DATA_SOURCE, desired.Name)
+			if err != nil {
+				return err
+			}
+			body := map[string]interface{}{}
+			if retentionTime, ok := desired.Spec["retention_time"]; ok {
+				body["RETENTION_TIME"] = retentionTime
+			}
+			return c.request("PATCH", fmt.Sprintf("/v1/data-sources/%s/", lcuuid), body, "")
+		},
+		delete: deleteManifestObjectByLcuuid(manifest.KIND_DATA_SOURCE, "/v1/data-sources/%s/"),
+	},
+	manifest.KIND_PLUGIN: {
+		list:      listManifestPlugins,
+		normalize: normalizeManifestPlugin,
+		create:    saveManifestPlugin,
+		update: func(c *manifestClient, desired, current *manifest.Object) error {
+			return saveManifestPlugin(c, &manifest.Object{Kind: desired.Kind, Name: desired.Name, Spec: manifest.MergeSpec(current.Spec, desired.Spec)})
+		},
+		delete: func(c *manifestClient, object *manifest.Object) error {
+			return c.request("DELETE", fmt.Sprintf("/v1/plugin/%s/", object.Name), nil, "")
+		},
+	},
+	manifest.KIND_RESOURCE_GROUP: {
+		list: listManifestResourceGroups,
+		create: func(c *manifestClient, object *manifest.Object) error {
+			body, err := manifestResourceGroupBody(object)
+			if err != nil {
+				return err
+			}
+			return c.request("POST", "/v1/resource-groups/", body, "")
+		},
+		update: func(c *manifestClient, desired, current *manifest.Object) error {
+			lcuuid, err := c.lcuuid(manifest.KIND_RESOURCE_GROUP, desired.Name)
+			if err != nil {
+				return err
+			}
+			body := apiBody(desired.Spec, "type")
+			body["NAME"] = desired.Name
+			return c.request("PATCH", fmt.Sprintf("/v1/resource-groups/%s/", lcuuid), body, "")
+		},
+		delete: deleteManifestObjectByLcuuid(manifest.KIND_RESOURCE_GROUP, "/v1/resource-groups/%s/"),
+	},
+	manifest.KIND_ACL: {
+		list: listManifestACLs,
+		create: func(c *manifestClient, object *manifest.Object) error {
+			body, err := manifestACLBody(c, object)
+			if err != nil {
+				return err
+			}
+			return c.request("POST", "/v1/acls/", body, "")
+		},
+		update: func(c *manifestClient, desired, current *manifest.Object) error {
+			lcuuid, err := c.lcuuid(manifest.KIND_ACL, desired.Name)
+			if err != nil {
+				return err
+			}
+			body, err := manifestACLBody(c, desired)
+			if err != nil {
+				return err
+			}
+			return c.request("PATCH", fmt.Sprintf("/v1/acls/%s/", lcuuid), body, "")
+		},
+		delete: deleteManifestObjectByLcuuid(manifest.KIND_ACL, "/v1/acls/%s/"),
+	},
+	manifest.KIND_PCAP_POLICY: newManifestPolicyAdapter(manifest.KIND_PCAP_POLICY, "npb_tunnel_id", "direction", "distribute", "vni"),
+	manifest.KIND_NPB_POLICY:  newManifestPolicyAdapter(manifest.KIND_NPB_POLICY),
+}
+
+func deleteManifestObjectByLcuuid(kind, pathFormat string) func(c *manifestClient, object *manifest.Object) error {
+	return func(c *manifestClient, object *manifest.Object) error {
+		lcuuid, err := c.lcuuid(kind, object.Name)
+		if err != nil {
+			return err
+		}
+		return c.request("DELETE", fmt.Sprintf(pathFormat, lcuuid), nil, "")
+	}
+}
+
+func listManifestDomains(c *manifestClient) ([]*manifest.Object, error) {
+	data, err := c.list(manifestKindPaths[manifest.KIND_DOMAIN])
+	if err != nil {
+		return nil, err
+	}
+	var objects []*manifest.Object
+	for i := range data.MustArray() {
+		item := data.GetIndex(i)
+		spec := pickSpec(item, "config")
+		spec["type"] = common.DomainType(item.Get("TYPE").MustInt()).String()
+		objects = append(objects, &manifest.Object{Kind: manifest.KIND_DOMAIN, Name: item.Get("NAME").MustString(), Spec: spec})
+	}
+	return objects, nil
+}
+
+func createManifestDomain(c *manifestClient, object *manifest.Object) error {
+	body := apiBody(object.Spec)
+	body["NAME"] = object.Name
+	domainType := common.GetDomainTypeByName(fmt.Sprint(object.Spec["type"]))
+	if domainType == common.DOMAIN_TYPE_UNKNOWN {
+		return fmt.Errorf("domain type (%v) not supported", object.Spec["type"])
+	}
+	body["TYPE"] = int(domainType)
+	return c.request("POST", "/v1/domains/", body, "")
+}
+
+func listManifestSubDomains(c *manifestClient) ([]*manifest.Object, error) {
+	data, err := c.list(manifestKindPaths[manifest.KIND_SUB_DOMAIN])
+	if err != nil {
+		return nil, err
+	}
+	var objects []*manifest.Object
+	for i := range data.MustArray() {
+		item := data.GetIndex(i)
+		spec := pickSpec(item, "config")
+		spec["domain"] = item.Get("DOMAIN_NAME").MustString()
+		objects = append(objects, &manifest.Object{
+			Kind: manifest.KIND_SUB_DOMAIN,
+			Name: item.Get("NAME").MustString(),
+			Spec: spec,
+			// sub domains learned from agents are not managed by users
+			Protected: item.Get("CREATE_METHOD").MustInt() != common.CREATE_METHOD_USER_DEFINE,
+		})
+	}
+	return objects, nil
+}
+
+func createManifestSubDomain(c *manifestClient, object *manifest.Object) error {
+	domainLcuuid, err := c.lcuuid(manifest.KIND_DOMAIN, fmt.Sprint(object.Spec["domain"]))
+	if err != nil {
+		return err
+	}
+	body := apiBody(object.Spec)
+	body["NAME"] = object.Name
+	body["DOMAIN"] = domainLcuuid
+	return c.request("POST", "/v2/sub-domains/", body, "")
+}
+
+func listManifestAgentGroups(c *manifestClient) ([]*manifest.Object, error) {
+	data, err := c.list(manifestKindPaths[manifest.KIND_AGENT_GROUP])
+	if err != nil {
+		return nil, err
+	}
+	var objects []*manifest.Object
+	for i := range data.MustArray() {
+		item := data.GetIndex(i)
+		objects = append(objects, &manifest.Object{
+			Kind:      manifest.KIND_AGENT_GROUP,
+			Name:      item.Get("NAME").MustString(),
+			Spec:      map[string]interface{}{"group_id": item.Get("SHORT_UUID").MustString()},
+			Protected: item.Get("ID").MustInt() == common.DEFAULT_AGENT_GROUP_ID,
+		})
+	}
+	return objects, nil
+}
+
+func listManifestAgentGroupConfigs(c *manifestClient) ([]*manifest.Object, error) {
+	data, err := c.list("/v1/vtap-group-configuration/advanced/")
+	if err != nil {
+		return nil, err
+	}
+	var objects []*manifest.Object
+	for i := range data.MustArray() {
+		spec := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(data.GetIndex(i).MustString()), &spec); err != nil {
+			return nil, err
+		}
+		group, err := c.find(manifestKindPaths[manifest.KIND_AGENT_GROUP], "SHORT_UUID", spec["vtap_group_id"])
+		if err != nil {
+			return nil, err
+		}
+		if group == nil {
+			continue
+		}
+		delete(spec, "vtap_group_id")
+		objects = append(objects, &manifest.Object{Kind: manifest.KIND_AGENT_GROUP_CONFIG, Name: group.Get("NAME").MustString(), Spec: spec})
+	}
+	return objects, nil
+}
+
+// manifestAgentGroupConfigYaml returns config yaml of the agent group named by the object
+func manifestAgentGroupConfigYaml(c *manifestClient, name string, spec map[string]interface{}) (string, string, error) {
+	group, err := c.mustFind(manifest.KIND_AGENT_GROUP, name)
+	if err != nil {
+		return "", "", err
+	}
+	shortUUID := group.Get("SHORT_UUID").MustString()
+	config := manifest.MergeSpec(spec, map[string]interface{}{"vtap_group_id": shortUUID})
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", "", err
+	}
+	return shortUUID, string(data), nil
+}
+
+func createManifestAgentGroupConfig(c *manifestClient, object *manifest.Object) error {
+	_, config, err := manifestAgentGroupConfigYaml(c, object.Name, object.Spec)
+	if err != nil {
+		return err
+	}
+	return c.request("POST", "/v1/vtap-group-configuration/advanced/", nil, config)
+}
+
+func updateManifestAgentGroupConfig(c *manifestClient, desired, current *manifest.Object) error {
+	shortUUID, config, err := manifestAgentGroupConfigYaml(c, desired.Name, manifest.MergeSpec(current.Spec, desired.Spec))
+	if err != nil {
+		return err
+	}
+	data, err := c.list("/v1/vtap-group-configuration/?vtap_group_id=" + shortUUID)
+	if err != nil {
+		return err
+	}
+	if len(data.MustArray()) == 0 {
+		return fmt.Errorf("config of agent group (%s) not found", desired.Name)
+	}
+	return c.request("PATCH", fmt.Sprintf("/v1/vtap-group-configuration/advanced/%s/", data.GetIndex(0).Get("LCUUID").MustString()), nil, config)
+}
+
+func listManifestDataSources(c *manifestClient) ([]*manifest.Object, error) {
+	data, err := c.list(manifestKindPaths[manifest.KIND_DATA_SOURCE])
+	if err != nil {
+		return nil, err
+	}
+	var objects []*manifest.Object
+	for i := range data.MustArray() {
+		item := data.GetIndex(i)
+		spec := pickSpec(item, "data_table_collection", "interval", "retention_time", "summable_metrics_operator", "unsummable_metrics_operator")
+		if baseID := item.Get("BASE_DATA_SOURCE_ID").MustInt(); baseID != 0 {
+			spec["base_data_source"] = c.idsToNames(manifest.KIND_DATA_SOURCE, []interface{}{item.Get("BASE_DATA_SOURCE_ID").Interface()})[0]
+		}
+		objects = append(objects, &manifest.Object{
+			Kind:      manifest.KIND_DATA_SOURCE,
+			Name:      item.Get("DISPLAY_NAME").MustString(),
+			Spec:      spec,
+			Protected: item.Get("IS_DEFAULT").MustBool(),
+		})
+	}
+	return objects, nil
+}
+
+func createManifestDataSource(c *manifestClient, object *manifest.Object) error {
+	body := apiBody(object.Spec, "base_data_source")
+	body["DISPLAY_NAME"] = object.Name
+	ids, err := c.namesToIDs(manifest.KIND_DATA_SOURCE, []interface{}{object.Spec["base_data_source"]}, "ID")
+	if err != nil {
+		return err
+	}
+	body["BASE_DATA_SOURCE_ID"] = ids[0]
+	return c.request("POST", "/v1/data-sources/", body, "")
+}
+
+func listManifestPlugins(c *manifestClient) ([]*manifest.Object, error) {
+	data, err := c.list(manifestKindPaths[manifest.KIND_PLUGIN])
+	if err != nil {
+		return nil, err
+	}
+	var objects []*manifest.Object
+	for i := range data.MustArray() {
+		item := data.GetIndex(i)
+		spec := pickSpec(item, "image_md5")
+		spec["type"] = strings.ToLower(common.PluginType(item.Get("TYPE").MustInt()).String())
+		spec["user"] = strings.ToLower(common.PluginUser(item.Get("USER").MustInt()).String())
+		objects = append(objects, &manifest.Object{Kind: manifest.KIND_PLUGIN, Name: item.Get("NAME").MustString(), Spec: spec})
+	}
+	return objects, nil
+}
+
+// normalizeManifestPlugin compares the image file by md5, an exported plugin has image_md5 only
+func normalizeManifestPlugin(c *manifestClient, object *manifest.Object, desired []*manifest.Object) error {
+	image, ok := object.Spec["image"].(string)
+	if !ok {
+		return nil
+	}
+	data, err := ioutil.ReadFile(image)
+	if err != nil {
+		return err
+	}
+	object.Spec["image_md5"] = fmt.Sprintf("%x", md5.Sum(data))
+	return nil
+}
+
+func saveManifestPlugin(c *manifestClient, object *manifest.Object) error {
+	image, ok := object.Spec["image"].(string)
+	if !ok {
+		return errors.New("image is required to upload the plugin")
+	}
+	c.cache = make(map[string]*simplejson.Json)
+	return createPlugin(c.cmd, fmt.Sprint(object.Spec["type"]), image, object.Name, fmt.Sprint(object.Spec["user"]))
+}
+
+var manifestResourceGroupTypes = map[string]int{"vm": 1, "ip": 2}
+
+func listManifestResourceGroups(c *manifestClient) ([]*manifest.Object, error) {
+	data, err := c.list(manifestKindPaths[manifest.KIND_RESOURCE_GROUP])
+	if err != nil {
+		return nil, err
+	}
+	var objects []*manifest.Object
+	for i := range data.MustArray() {
+		item := data.GetIndex(i)
+		spec := pickSpec(item, "ips", "vm_ids")
+		if vpcID := item.Get("VPC_ID").MustInt(); vpcID != 0 {
+			spec["vpc_id"] = vpcID
+		}
+		for name, groupType := range manifestResourceGroupTypes {
+			if item.Get("TYPE").MustInt() == groupType {
+				spec["type"] = name
+			}
+		}
+		objects = append(objects, &manifest.Object{Kind: manifest.KIND_RESOURCE_GROUP, Name: item.Get("NAME").MustString(), Spec: spec})
+	}
+	return objects, nil
+}
+
+func manifestResourceGroupBody(object *manifest.Object) (map[string]interface{}, error) {
+	groupType, ok := manifestResourceGroupTypes[fmt.Sprint(object.Spec["type"])]
+	if !ok {
+		return nil, fmt.Errorf("resource group type (%v) must be vm or ip", object.Spec["type"])
+	}
+	body := apiBody(object.Spec)
+	body["NAME"] = object.Name
+	body["TYPE"] = groupType
+	return body, nil
+}
+
+func listManifestACLs(c *manifestClient) ([]*manifest.Object, error) {
+	data, err := c.list(manifestKindPaths[manifest.KIND_ACL])
+	if err != nil {
+		return nil, err
+	}
+	var objects []*manifest.Object
+	for i := range data.MustArray() {
+		item := data.GetIndex(i)
+		spec := pickSpec(item, "state", "application", "tap_type", "protocol", "src_ports", "dst_ports")
+		for _, key := range []string{"vpc_id", "vlan"} {
+			if value := item.Get(strings.ToUpper(key)).MustInt(); value != 0 {
+				spec[key] = value
+			}
+		}
+		if ids := item.Get("SRC_GROUP_IDS").MustArray(); len(ids) > 0 {
+			spec["src_groups"] = c.idsToNames(manifest.KIND_RESOURCE_GROUP, ids)
+		}
+		if ids := item.Get("DST_GROUP_IDS").MustArray(); len(ids) > 0 {
+			spec["dst_groups"] = c.idsToNames(manifest.KIND_RESOURCE_GROUP, ids)
+		}
+		objects = append(objects, &manifest.Object{Kind: manifest.KIND_ACL, Name: item.Get("NAME").MustString(), Spec: spec})
+	}
+	return objects, nil
+}
+
+func manifestACLBody(c *manifestClient, object *manifest.Object) (map[string]interface{}, error) {
+	body := apiBody(object.Spec, "src_groups", "dst_groups")
+	body["NAME"] = object.Name
+	for key, apiKey := range map[string]string{"src_groups": "SRC_GROUP_IDS", "dst_groups": "DST_GROUP_IDS"} {
+		if names, ok := object.Spec[key]; ok {
+			ids, err := c.namesToIDs(manifest.KIND_RESOURCE_GROUP, names, "ID")
+			if err != nil {
+				return nil, err
+			}
+			body[apiKey] = ids
+		}
+	}
+	return body, nil
+}
+
+// newManifestPolicyAdapter returns the adapter of pcap or npb policies, skipKeys are keys of the other policy
+func newManifestPolicyAdapter(kind string, skipKeys ...string) *manifestAdapter {
+	path := manifestKindPaths[kind]
+	body := func(c *manifestClient, object *manifest.Object) (map[string]interface{}, error) {
+		for _, key := range skipKeys {
+			if _, ok := object.Spec[key]; ok {
+				return nil, fmt.Errorf("%s is not a field of %s", key, kind)
+			}
+		}
+		body := apiBody(object.Spec, "acl", "agent_groups")
+		body["NAME"] = object.Name
+		if aclName, ok := object.Spec["acl"]; ok {
+			ids, err := c.namesToIDs(manifest.KIND_ACL, []interface{}{aclName}, "ID")
+			if err != nil {
+				return nil, err
+			}
+			body["ACL_ID"] = ids[0]
+		}
+		// agent groups created by the manifest exist now, agents of them are resolved by the controller
+		if groupNames, ok := object.Spec["agent_groups"]; ok {
+			ids, err := c.namesToIDs(manifest.KIND_AGENT_GROUP, groupNames, "SHORT_UUID")
+			if err != nil {
+				return nil, err
+			}
+			body["VTAP_GROUP_IDS"] = ids
+		}
+		return body, nil
+	}
+	return &manifestAdapter{
+		list: func(c *manifestClient) ([]*manifest.Object, error) {
+			data, err := c.list(path)
+			if err != nil {
+				return nil, err
+			}
+			var objects []*manifest.Object
+			for i := range data.MustArray() {
+				item := data.GetIndex(i)
+				spec := pickSpec(item, "state", "vtap_ids", "payload_slice", "npb_tunnel_id", "direction", "distribute", "vni")
+				for _, key := range skipKeys {
+					delete(spec, key)
+				}
+				spec["acl"] = c.idsToNames(manifest.KIND_ACL, []interface{}{item.Get("ACL_ID").Interface()})[0]
+				objects = append(objects, &manifest.Object{Kind: kind, Name: item.Get("NAME").MustString(), Spec: spec})
+			}
+			return objects, nil
+		},
+		normalize: normalizeManifestPolicy,
+		create: func(c *manifestClient, object *manifest.Object) error {
+			body, err := body(c, object)
+			if err != nil {
+				return err
+			}
+			return c.request("POST", path, body, "")
+		},
+		update: func(c *manifestClient, desired, current *manifest.Object) error {
+			lcuuid, err := c.lcuuid(kind, desired.Name)
+			if err != nil {
+				return err
+			}
+			body, err := body(c, desired)
+			if err != nil {
+				return err
+			}
+			return c.request("PATCH", path+lcuuid+"/", body, "")
+		},
+		delete: deleteManifestObjectByLcuuid(kind, path+"%s/"),
+	}
+}
+
+// normalizeManifestPolicy merges agents of agent_groups into vtap_ids, which is how the controller saves them.
+// Agent groups which are created by the manifest are kept in agent_groups, they are resolved when the policy is saved.
+func normalizeManifestPolicy(c *manifestClient, object *manifest.Object, desired []*manifest.Object) error {
+	groupNames, ok := object.Spec["agent_groups"]
+	if !ok {
+		return nil
+	}
+	names, ok := groupNames.([]interface{})
+	if !ok {
+		return fmt.Errorf("%v is not a list of %s names", groupNames, manifest.KIND_AGENT_GROUP)
+	}
+	newGroups := make(map[string]bool)
+	for _, o := range desired {
+		if o.Kind == manifest.KIND_AGENT_GROUP {
+			newGroups[o.Name] = true
+		}
+	}
+	groupLcuuids := make(map[string]bool)
+	var pendingGroups []interface{}
+	for _, name := range names {
+		group, err := c.find(manifestKindPaths[manifest.KIND_AGENT_GROUP], manifestKindNameFields[manifest.KIND_AGENT_GROUP], fmt.Sprint(name))
+		if err != nil {
+			return err
+		}
+		if group != nil {
+			groupLcuuids[group.Get("LCUUID").MustString()] = true
+		} else if newGroups[fmt.Sprint(name)] {
+			pendingGroups = append(pendingGroups, name)
+		} else {
+			return fmt.Errorf("%s/%s not found", manifest.KIND_AGENT_GROUP, name)
+		}
+	}
+	idSet := make(map[int]bool)
+	if vtapIDs, ok := object.Spec["vtap_ids"].([]interface{}); ok {
+		for _, id := range vtapIDs {
+			if v, ok := id.(float64); ok {
+				idSet[int(v)] = true
+			}
+		}
+	}
+	vtaps, err := c.list("/v1/vtaps/")
+	if err != nil {
+		return err
+	}
+	var groupVTapCount int
+	for i := range vtaps.MustArray() {
+		vtap := vtaps.GetIndex(i)
+		if groupLcuuids[vtap.Get("VTAP_GROUP_LCUUID").MustString()] {
+			idSet[vtap.Get("ID").MustInt()] = true
+			groupVTapCount++
+		}
+	}
+	// agents of new groups are unknown until they are created, the controller checks them
+	if groupVTapCount == 0 && len(idSet) == 0 && len(pendingGroups) == 0 {
+		return fmt.Errorf("agent groups %v have no agent", groupNames)
+	}
+	ids := make([]int, 0, len(idSet))
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	vtapIDs := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		vtapIDs = append(vtapIDs, float64(id))
+	}
+	delete(object.Spec, "agent_groups")
+	if len(pendingGroups) > 0 {
+		object.Spec["agent_groups"] = pendingGroups
+	}
+	if len(vtapIDs) > 0 {
+		object.Spec["vtap_ids"] = vtapIDs
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ctl
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// testController serves the controller APIs used by apply, vtaps of new agent groups are resolved like the controller
+type testController struct {
+	groups   []map[string]interface{}
+	vtaps    []map[string]interface{}
+	acls     []map[string]interface{}
+	policies []map[string]interface{}
+}
+
+func (c *testController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	var data interface{}
+	switch r.Method + " " + r.URL.Path {
+	case "GET /v1/vtap-groups/":
+		data = c.groups
+	case "POST /v1/vtap-groups/":
+		group := map[string]interface{}{"ID": len(c.groups) + 1, "NAME": body["NAME"], "SHORT_UUID": body["GROUP_ID"], "LCUUID": body["GROUP_ID"]}
+		c.groups = append(c.groups, group)
+		data = group
+	case "GET /v1/vtaps/":
+		data = c.vtaps
+	case "GET /v1/acls/":
+		data = c.acls
+	case "GET /v1/pcap-policies/":
+		data = c.policies
+	case "POST /v1/pcap-policies/":
+		c.policies = append(c.policies, body)
+		data = body
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"OPT_STATUS": "SUCCESS", "DATA": data})
+}
+
+func newTestApplyCommand(t *testing.T, handler http.Handler) *cobra.Command {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	apiPort, _ := strconv.Atoi(port)
+	cmd := &cobra.Command{}
+	cmd.Flags().String("ip", host, "")
+	cmd.Flags().Uint32("api-port", uint32(apiPort), "")
+	cmd.Flags().Uint32("org-id", 0, "")
+	cmd.Flags().Duration("timeout", 5*time.Second, "")
+	return cmd
+}
+
+func TestApplyPolicyOfNewAgentGroup(t *testing.T) {
+	controller := &testController{
+		groups: []map[string]interface{}{{"ID": 1, "NAME": "default", "SHORT_UUID": "g-default", "LCUUID": "lcuuid-default"}},
+		vtaps:  []map[string]interface{}{{"ID": 3, "VTAP_GROUP_LCUUID": "lcuuid-default"}},
+		acls:   []map[string]interface{}{{"ID": 7, "NAME": "db"}},
+	}
+	cmd := newTestApplyCommand(t, controller)
+	filename := filepath.Join(t.TempDir(), "deepflow.yaml")
+	manifest := `
+kind: AgentGroup
+name: prod
+spec:
+  group_id: g-prod
+---
+kind: PcapPolicy
+name: capture-db
+spec:
+  acl: db
+  agent_groups: [prod, default]
+`
+	if err := os.WriteFile(filename, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyManifest(cmd, []string{filename}, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(controller.groups) != 2 || controller.groups[1]["NAME"] != "prod" {
+		t.Fatalf("agent group prod is not created: %v", controller.groups)
+	}
+	if len(controller.policies) != 1 {
+		t.Fatalf("policy is not created: %v", controller.policies)
+	}
+	policy := controller.policies[0]
+	if !reflect.DeepEqual(policy["VTAP_GROUP_IDS"], []interface{}{"g-prod"}) || !reflect.DeepEqual(policy["VTAP_IDS"], []interface{}{float64(3)}) {
+		t.Errorf("policy agents: VTAP_GROUP_IDS %v, VTAP_IDS %v, want [g-prod], [3]", policy["VTAP_GROUP_IDS"], policy["VTAP_IDS"])
+	}
+	if policy["ACL_ID"] != float64(7) || policy["AGENT_GROUPS"] != nil {
+		t.Errorf("policy body: %v", policy)
+	}
+
+	// agent groups which are neither in the controller nor in the manifest are still rejected
+	os.WriteFile(filename, []byte("kind: PcapPolicy\nname: capture-db\nspec:\n  acl: db\n  agent_groups: [unknown]\n"), 0644)
+	if err := applyManifest(cmd, []string{filename}, false, true); err == nil {
+		t.Errorf("unknown agent group should be rejected")
+	}
+}
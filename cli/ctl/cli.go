@@ -69,6 +69,11 @@ func Execute(version string) {
 	root.AddCommand(RegisterPluginCommand())
 	root.AddCommand(RegisterPrometheusCommand())
 	root.AddCommand(RegisterPromQLCommand())
+	root.AddCommand(RegisterQueryCommand())
+	root.AddCommand(RegisterPolicyCommand())
+	root.AddCommand(RegisterApplyCommand())
+	root.AddCommand(RegisterDiffCommand())
+	root.AddCommand(RegisterExportCommand())
 
 	cmd.RegisterIngesterCommand(root)
 
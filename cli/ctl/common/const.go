@@ -22,6 +22,9 @@ const (
 	SUCCESS = "SUCCESS"
 
 	DEFAULT_ENCRYPTION_PASSWORD = "******"
+
+	DEFAULT_AGENT_GROUP_ID    = 1
+	CREATE_METHOD_USER_DEFINE = 1
 )
 
 var RESOURCE_TYPES = []string{
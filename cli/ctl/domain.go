@@ -88,7 +88,7 @@ func RegisterDomainCommand() *cobra.Command {
 		Use:     "example domain_type",
 		Short:   "example domain create yaml",
 		Long:    "supported types: " + strings.Trim(fmt.Sprint(common.DomainTypes), "[]"),
-		Example: "deepflow-ctl domain example agent_sync \nsupport example type: aliyun | aws | baidu_bce | filereader | agent_sync | \nhuawei | kubernetes | qingcloud | tencent | volcengine",
+		Example: "deepflow-ctl domain example agent_sync \nsupport example type: aliyun | aws | baidu_bce | filereader | agent_sync | \nhuawei | kubernetes | openstack | qingcloud | tencent | volcengine",
 		Run: func(cmd *cobra.Command, args []string) {
 			exampleDomainConfig(cmd, args)
 		},
@@ -303,6 +303,8 @@ func exampleDomainConfig(cmd *cobra.Command, args []string) {
 		fmt.Printf(string(example.YamlDomainTencent))
 	case common.DOMAIN_TYPE_HUAWEI:
 		fmt.Printf(string(example.YamlDomainHuawei))
+	case common.DOMAIN_TYPE_OPENSTACK:
+		fmt.Printf(string(example.YamlDomainOpenStack))
 	case common.DOMAIN_TYPE_QINGCLOUD:
 		fmt.Printf(string(example.YamlDomainQingCloud))
 	case common.DOMAIN_TYPE_BAIDU_BCE:
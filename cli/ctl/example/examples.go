@@ -39,6 +39,9 @@ var YamlDomainHuawei []byte
 //go:embed domain_kubernetes.yaml
 var YamlDomainKubernetes []byte
 
+//go:embed domain_openstack.yaml
+var YamlDomainOpenStack []byte
+
 //go:embed domain_qingcloud.yaml
 var YamlDomainQingCloud []byte
 
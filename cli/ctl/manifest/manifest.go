@@ -0,0 +1,413 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package manifest describes controller configuration declaratively and computes the plan to converge the
+// controller to a manifest. It knows nothing about the controller API, see ctl/apply.go for that.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deepflowio/deepflow/cli/ctl/common"
+)
+
+const (
+	KIND_DOMAIN             = "Domain"
+	KIND_SUB_DOMAIN         = "SubDomain"
+	KIND_AGENT_GROUP        = "AgentGroup"
+	KIND_AGENT_GROUP_CONFIG = "AgentGroupConfig"
+	KIND_DATA_SOURCE        = "DataSource"
+	KIND_PLUGIN             = "Plugin"
+	KIND_RESOURCE_GROUP     = "ResourceGroup"
+	KIND_ACL                = "ACL"
+	KIND_PCAP_POLICY        = "PcapPolicy"
+	KIND_NPB_POLICY         = "NpbPolicy"
+)
+
+type KindInfo struct {
+	Kind      string
+	Immutable []string // spec keys which can not be changed after creation
+	Local     []string // spec keys only used by the client, e.g. file paths, never compared with the controller
+}
+
+// Kinds are ordered by dependency: objects are created and updated in this order and pruned in reverse order
+var Kinds = []KindInfo{
+	{Kind: KIND_DOMAIN, Immutable: []string{"type"}},
+	{Kind: KIND_SUB_DOMAIN, Immutable: []string{"domain"}},
+	{Kind: KIND_AGENT_GROUP, Immutable: []string{"group_id"}},
+	{Kind: KIND_AGENT_GROUP_CONFIG},
+	{Kind: KIND_DATA_SOURCE, Immutable: []string{
+		"data_table_collection", "base_data_source", "interval", "summable_metrics_operator", "unsummable_metrics_operator",
+	}},
+	{Kind: KIND_PLUGIN, Local: []string{"image"}},
+	{Kind: KIND_RESOURCE_GROUP, Immutable: []string{"type"}},
+	{Kind: KIND_ACL},
+	{Kind: KIND_PCAP_POLICY},
+	{Kind: KIND_NPB_POLICY},
+}
+
+func GetKindInfo(kind string) (*KindInfo, bool) {
+	for i := range Kinds {
+		if Kinds[i].Kind == kind {
+			return &Kinds[i], true
+		}
+	}
+	return nil, false
+}
+
+func kindOrder(kind string) int {
+	for i := range Kinds {
+		if Kinds[i].Kind == kind {
+			return i
+		}
+	}
+	return len(Kinds)
+}
+
+func KindNames() []string {
+	names := make([]string, 0, len(Kinds))
+	for _, kind := range Kinds {
+		names = append(names, kind.Kind)
+	}
+	return names
+}
+
+// Object is one document of a manifest, names are unique in a kind and referenced by other objects
+type Object struct {
+	Kind      string                 `json:"kind"`
+	Name      string                 `json:"name"`
+	Spec      map[string]interface{} `json:"spec,omitempty"`
+	Protected bool                   `json:"-"` // built-in objects of the controller, never pruned
+}
+
+func (o *Object) Key() string {
+	return o.Kind + "/" + o.Name
+}
+
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// Parse parses yaml documents separated by ---, empty documents are skipped
+func Parse(data []byte) ([]*Object, error) {
+	var objects []*Object
+	for i, document := range documentSeparator.Split(string(data), -1) {
+		if strings.TrimSpace(document) == "" {
+			continue
+		}
+		jsonData, err := yaml.YAMLToJSON([]byte(document))
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %v", i+1, err)
+		}
+		if string(jsonData) == "null" {
+			continue
+		}
+		object := &Object{}
+		decoder := json.NewDecoder(bytes.NewReader(jsonData))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(object); err != nil {
+			return nil, fmt.Errorf("document %d: %v", i+1, err)
+		}
+		if _, ok := GetKindInfo(object.Kind); !ok {
+			return nil, fmt.Errorf("document %d: kind (%s) not supported, supported kinds: %s", i+1, object.Kind, strings.Join(KindNames(), ", "))
+		}
+		if object.Name == "" {
+			return nil, fmt.Errorf("document %d: %s name must not be empty", i+1, object.Kind)
+		}
+		if object.Spec == nil {
+			object.Spec = map[string]interface{}{}
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// Marshal writes objects as yaml documents in dependency order
+func Marshal(objects []*Object) ([]byte, error) {
+	Sort(objects)
+	var buf bytes.Buffer
+	for i, object := range objects {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		data, err := yaml.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// Sort sorts objects by kind order and name
+func Sort(objects []*Object) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		oi, oj := kindOrder(objects[i].Kind), kindOrder(objects[j].Kind)
+		if oi != oj {
+			return oi < oj
+		}
+		return objects[i].Name < objects[j].Name
+	})
+}
+
+// Normalize converts values to what they look like after a json round trip, e.g. all numbers to float64, so that
+// specs from manifests and from the controller are comparable
+func Normalize(spec map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	normalized := map[string]interface{}{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// MergeSpec returns current overwritten by desired, maps are merged recursively. It is the full spec to save
+// when the controller API replaces instead of patches a field.
+func MergeSpec(current, desired map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(current)+len(desired))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range desired {
+		desiredMap, ok1 := v.(map[string]interface{})
+		currentMap, ok2 := merged[k].(map[string]interface{})
+		if ok1 && ok2 {
+			merged[k] = MergeSpec(currentMap, desiredMap)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+type Action string
+
+const (
+	ACTION_CREATE Action = "create"
+	ACTION_UPDATE Action = "update"
+	ACTION_DELETE Action = "delete"
+)
+
+type FieldDiff struct {
+	Path    string
+	Current interface{}
+	Desired interface{}
+}
+
+type Change struct {
+	Action  Action
+	Desired *Object // nil for delete
+	Current *Object // nil for create
+	Diffs   []FieldDiff
+}
+
+func (c *Change) Object() *Object {
+	if c.Desired != nil {
+		return c.Desired
+	}
+	return c.Current
+}
+
+type Plan struct {
+	Changes   []*Change
+	Unchanged int
+}
+
+func (p *Plan) HasChanges() bool {
+	return len(p.Changes) > 0
+}
+
+// ComputePlan compares desired objects with current ones. Only fields in the desired spec are compared, fields
+// missing in the manifest keep their current values. With prune, current objects of kinds in the manifest which
+// are missing in the manifest are deleted.
+func ComputePlan(desired, current []*Object, prune bool) (*Plan, error) {
+	currentByKey := make(map[string]*Object, len(current))
+	for _, object := range current {
+		currentByKey[object.Key()] = object
+	}
+	desiredByKey := make(map[string]*Object, len(desired))
+	desiredKinds := make(map[string]bool)
+	for _, object := range desired {
+		if _, ok := desiredByKey[object.Key()]; ok {
+			return nil, fmt.Errorf("%s is defined more than once", object.Key())
+		}
+		desiredByKey[object.Key()] = object
+		desiredKinds[object.Kind] = true
+	}
+
+	plan := &Plan{}
+	var errs []string
+	for _, object := range desired {
+		currentObject, ok := currentByKey[object.Key()]
+		if !ok {
+			plan.Changes = append(plan.Changes, &Change{Action: ACTION_CREATE, Desired: object})
+			continue
+		}
+		kindInfo, _ := GetKindInfo(object.Kind)
+		diffs := DiffSpec(kindInfo, currentObject.Spec, object.Spec)
+		if len(diffs) == 0 {
+			plan.Unchanged++
+			continue
+		}
+		for _, diff := range diffs {
+			for _, immutable := range kindInfo.Immutable {
+				if diff.Path == immutable || strings.HasPrefix(diff.Path, immutable+".") {
+					errs = append(errs, fmt.Sprintf("%s: %s can not be changed (%v -> %v)", object.Key(), diff.Path, diff.Current, diff.Desired))
+				}
+			}
+		}
+		plan.Changes = append(plan.Changes, &Change{Action: ACTION_UPDATE, Desired: object, Current: currentObject, Diffs: diffs})
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+	if prune {
+		for _, object := range current {
+			if !desiredKinds[object.Kind] || object.Protected {
+				continue
+			}
+			if _, ok := desiredByKey[object.Key()]; !ok {
+				plan.Changes = append(plan.Changes, &Change{Action: ACTION_DELETE, Current: object})
+			}
+		}
+	}
+
+	// creates and updates follow dependencies, deletes go after them in reverse order
+	sort.SliceStable(plan.Changes, func(i, j int) bool {
+		ci, cj := plan.Changes[i], plan.Changes[j]
+		di, dj := ci.Action == ACTION_DELETE, cj.Action == ACTION_DELETE
+		if di != dj {
+			return dj
+		}
+		oi, oj := kindOrder(ci.Object().Kind), kindOrder(cj.Object().Kind)
+		if di {
+			return oi > oj
+		}
+		return oi < oj
+	})
+	return plan, nil
+}
+
+// DiffSpec returns fields of desired which differ from current, masked current values are not compared
+func DiffSpec(kindInfo *KindInfo, current, desired map[string]interface{}) []FieldDiff {
+	var diffs []FieldDiff
+	local := make(map[string]bool)
+	if kindInfo != nil {
+		for _, key := range kindInfo.Local {
+			local[key] = true
+		}
+	}
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		if !local[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		diffs = append(diffs, diffValue(key, current[key], desired[key])...)
+	}
+	return diffs
+}
+
+func diffValue(path string, current, desired interface{}) []FieldDiff {
+	// passwords and secret keys are masked by the controller, they can not be compared
+	if current == common.DEFAULT_ENCRYPTION_PASSWORD {
+		return nil
+	}
+	desiredMap, ok1 := desired.(map[string]interface{})
+	currentMap, ok2 := current.(map[string]interface{})
+	if ok1 && ok2 {
+		keys := make([]string, 0, len(desiredMap))
+		for key := range desiredMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var diffs []FieldDiff
+		for _, key := range keys {
+			diffs = append(diffs, diffValue(path+"."+key, currentMap[key], desiredMap[key])...)
+		}
+		return diffs
+	}
+	if isEmpty(current) && isEmpty(desired) {
+		return nil
+	}
+	if reflect.DeepEqual(current, desired) {
+		return nil
+	}
+	return []FieldDiff{{Path: path, Current: current, Desired: desired}}
+}
+
+// isEmpty treats null, "" and [] the same, the controller returns them for unset fields interchangeably
+func isEmpty(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case []interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+// Print writes the plan in a human readable form
+func (p *Plan) Print(w io.Writer) {
+	if !p.HasChanges() {
+		fmt.Fprintf(w, "no changes, %d objects up to date\n", p.Unchanged)
+		return
+	}
+	var creates, updates, deletes int
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ACTION_CREATE:
+			creates++
+			fmt.Fprintf(w, "+ %s\n", change.Object().Key())
+		case ACTION_UPDATE:
+			updates++
+			fmt.Fprintf(w, "~ %s\n", change.Object().Key())
+			for _, diff := range change.Diffs {
+				fmt.Fprintf(w, "    %s: %s -> %s\n", diff.Path, formatValue(diff.Current), formatValue(diff.Desired))
+			}
+		case ACTION_DELETE:
+			deletes++
+			fmt.Fprintf(w, "- %s\n", change.Object().Key())
+		}
+	}
+	fmt.Fprintf(w, "plan: %d to create, %d to update, %d to delete, %d unchanged\n", creates, updates, deletes, p.Unchanged)
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<unset>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manifest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testManifest = `
+kind: AgentGroup
+name: prod
+spec:
+  group_id: g-1yhIguXABC
+---
+kind: PcapPolicy
+name: capture-db
+spec:
+  acl: db
+  payload_slice: 1500
+  vtap_ids: [3, 1]
+---
+kind: Domain
+name: k8s
+spec:
+  type: kubernetes
+  config:
+    region_uuid: ffffffff-ffff-ffff-ffff-ffffffffffff
+    password: secret
+---
+`
+
+func TestParse(t *testing.T) {
+	objects, err := Parse([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 3 {
+		t.Fatalf("Parse() got %d objects, want 3", len(objects))
+	}
+	if v := objects[1].Spec["payload_slice"]; v != float64(1500) {
+		t.Errorf("numbers should be normalized to float64, got %T", v)
+	}
+
+	for _, bad := range []string{
+		"kind: Unknown\nname: a\n",
+		"kind: Domain\nspec: {}\n",
+		"kind: Domain\nname: a\nspce: {}\n",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
+
+func TestComputePlan(t *testing.T) {
+	desired, _ := Parse([]byte(testManifest))
+	current := []*Object{
+		{Kind: KIND_DOMAIN, Name: "k8s", Spec: map[string]interface{}{
+			"type": "kubernetes",
+			"config": map[string]interface{}{
+				"region_uuid": "ffffffff-ffff-ffff-ffff-ffffffffffff",
+				"password":    "******",
+				"extra":       "kept",
+			},
+		}},
+		{Kind: KIND_PCAP_POLICY, Name: "capture-db", Spec: map[string]interface{}{
+			"acl": "db", "payload_slice": float64(65535), "vtap_ids": []interface{}{float64(3), float64(1)},
+		}},
+		{Kind: KIND_PCAP_POLICY, Name: "old", Spec: map[string]interface{}{}},
+		{Kind: KIND_AGENT_GROUP, Name: "default", Spec: map[string]interface{}{}, Protected: true},
+		{Kind: KIND_DATA_SOURCE, Name: "1h", Spec: map[string]interface{}{}},
+	}
+
+	plan, err := ComputePlan(desired, current, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, change := range plan.Changes {
+		actions = append(actions, string(change.Action)+" "+change.Object().Key())
+	}
+	// creates and updates in dependency order, prune only kinds in the manifest and never protected objects
+	want := []string{"create AgentGroup/prod", "update PcapPolicy/capture-db", "delete PcapPolicy/old"}
+	if strings.Join(actions, ",") != strings.Join(want, ",") {
+		t.Errorf("ComputePlan() = %v, want %v", actions, want)
+	}
+	if plan.Unchanged != 1 {
+		t.Errorf("masked secrets and fields missing in manifest should not be compared, unchanged = %d", plan.Unchanged)
+	}
+	if diffs := plan.Changes[1].Diffs; len(diffs) != 1 || diffs[0].Path != "payload_slice" {
+		t.Errorf("diffs = %+v, want payload_slice only", diffs)
+	}
+
+	plan, _ = ComputePlan(desired, current, false)
+	for _, change := range plan.Changes {
+		if change.Action == ACTION_DELETE {
+			t.Errorf("%s should not be deleted without prune", change.Object().Key())
+		}
+	}
+
+	var buf bytes.Buffer
+	plan.Print(&buf)
+	if !strings.Contains(buf.String(), "payload_slice: 65535 -> 1500") {
+		t.Errorf("Print() = %s", buf.String())
+	}
+}
+
+func TestComputePlanErrors(t *testing.T) {
+	desired := []*Object{
+		{Kind: KIND_DOMAIN, Name: "k8s", Spec: map[string]interface{}{"type": "aws"}},
+	}
+	current := []*Object{
+		{Kind: KIND_DOMAIN, Name: "k8s", Spec: map[string]interface{}{"type": "kubernetes"}},
+	}
+	if _, err := ComputePlan(desired, current, false); err == nil {
+		t.Error("changing immutable field should fail")
+	}
+	if _, err := ComputePlan(append(desired, desired[0]), nil, false); err == nil {
+		t.Error("duplicated objects should fail")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	objects, _ := Parse([]byte(testManifest))
+	data, err := Marshal(objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 3 || parsed[0].Kind != KIND_DOMAIN || parsed[2].Kind != KIND_PCAP_POLICY {
+		t.Errorf("Marshal() should sort objects by kind order, got %s", data)
+	}
+}
+
+func TestMergeSpec(t *testing.T) {
+	merged := MergeSpec(
+		map[string]interface{}{"a": 1, "config": map[string]interface{}{"x": 1, "y": 2}},
+		map[string]interface{}{"config": map[string]interface{}{"y": 3}},
+	)
+	config := merged["config"].(map[string]interface{})
+	if merged["a"] != 1 || config["x"] != 1 || config["y"] != 3 {
+		t.Errorf("MergeSpec() = %v", merged)
+	}
+}
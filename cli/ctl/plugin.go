@@ -24,6 +24,7 @@ import (
 	"mime/multipart"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -37,7 +38,7 @@ func RegisterPluginCommand() *cobra.Command {
 		Use:   "plugin",
 		Short: "plugin operation commands",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Printf("please run with 'list | create | delete.'\n")
+			fmt.Printf("please run with 'list | create | delete | test.'\n")
 		},
 	}
 
@@ -84,9 +85,32 @@ func RegisterPluginCommand() *cobra.Command {
 		},
 	}
 
+	var testImage, testName, metaData, domain string
+	var changedOnly bool
+	test := &cobra.Command{
+		Use:   "test",
+		Short: "dry-run lua plugin against pod metadata without activating it",
+		Example: "deepflow-ctl plugin test --image /home/tom/hello.lua --metadata /home/tom/pods.json\n" +
+			"deepflow-ctl plugin test --image /home/tom/hello.lua --name hello --domain <domain lcuuid>\n" +
+			"(with --name, the activated plugin of the same name is replaced in the comparison)",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := testPlugin(cmd, testImage, testName, metaData, domain, changedOnly); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	test.Flags().StringVarP(&testImage, "image", "", "", "lua plugin to test")
+	test.Flags().StringVarP(&testName, "name", "", "", "name the plugin will be created with")
+	test.Flags().StringVarP(&metaData, "metadata", "", "", "json file of sample pods or pod metadata, object or array")
+	test.Flags().StringVarP(&domain, "domain", "", "", "lcuuid of kubernetes domain or sub_domain, test against its live pods")
+	test.Flags().BoolVarP(&changedOnly, "changed-only", "", false, "only show workloads whose grouping changes")
+	test.MarkFlagRequired("image")
+	test.MarkFlagsMutuallyExclusive("metadata", "domain")
+
 	plugin.AddCommand(create)
 	plugin.AddCommand(list)
 	plugin.AddCommand(delete)
+	plugin.AddCommand(test)
 	return plugin
 }
 
@@ -174,3 +198,95 @@ func deletePlugin(cmd *cobra.Command, args []string) error {
 	_, err := common.CURLPerform("DELETE", url, nil, "", []common.HTTPOption{common.WithTimeout(common.GetTimeout(cmd)), common.WithORGID(common.GetORGID(cmd))}...)
 	return err
 }
+
+func testPlugin(cmd *cobra.Command, image, name, metaData, domain string, changedOnly bool) error {
+	if metaData == "" && domain == "" {
+		return fmt.Errorf("must specify metadata or domain\nExample: %s", cmd.Example)
+	}
+	bodyBuf := &bytes.Buffer{}
+	bodyWriter := multipart.NewWriter(bodyBuf)
+	bodyWriter.WriteField("NAME", name)
+	bodyWriter.WriteField("DOMAIN", domain)
+	files := map[string]string{"IMAGE": image}
+	if metaData != "" {
+		files["METADATA"] = metaData
+	}
+	for field, file := range files {
+		fileWriter, err := bodyWriter.CreateFormFile(field, path.Base(file))
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fileWriter, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	contentType := bodyWriter.FormDataContentType()
+	bodyWriter.Close()
+
+	server := common.GetServerInfo(cmd)
+	url := fmt.Sprintf("http://%s:%d/v1/plugin/test/", server.IP, server.Port)
+	response, err := common.CURLPostFormData(url, contentType, bodyBuf, []common.HTTPOption{common.WithTimeout(common.GetTimeout(cmd)), common.WithORGID(common.GetORGID(cmd))}...)
+	if err != nil {
+		return err
+	}
+
+	data := response.Get("DATA")
+	if e := data.Get("ERROR").MustString(); e != "" {
+		fmt.Printf("ERROR: %s\n", e)
+	}
+	grouping := func(t, n string) string {
+		if t == "" && n == "" {
+			return "-"
+		}
+		return t + "/" + n
+	}
+	header := []string{"NAMESPACE", "NAME", "RESULT", "CURRENT", "EXPECTED", "CHANGED", "DURATION(us)", "ERROR"}
+	rows := [][]string{header}
+	workloads := data.Get("WORKLOADS")
+	for i := range workloads.MustArray() {
+		w := workloads.GetIndex(i)
+		changed := w.Get("CHANGED").MustBool()
+		if changedOnly && !changed {
+			continue
+		}
+		result := grouping(w.Get("TYPE").MustString(), w.Get("WORKLOAD_NAME").MustString())
+		if result != "-" && !w.Get("SUPPORTED").MustBool() {
+			result += "(unsupported)"
+		}
+		rows = append(rows, []string{
+			w.Get("NAMESPACE").MustString(),
+			w.Get("NAME").MustString(),
+			result,
+			grouping(w.Get("CURRENT_TYPE").MustString(), w.Get("CURRENT_WORKLOAD_NAME").MustString()),
+			grouping(w.Get("EXPECTED_TYPE").MustString(), w.Get("EXPECTED_WORKLOAD_NAME").MustString()),
+			strconv.FormatBool(changed),
+			strconv.FormatInt(w.Get("DURATION").MustInt64(), 10),
+			w.Get("ERROR").MustString(),
+		})
+	}
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, v := range row {
+			if len(v) > widths[i] {
+				widths[i] = len(v)
+			}
+		}
+	}
+	for _, row := range rows {
+		line := make([]string, len(row))
+		for i, v := range row {
+			line[i] = fmt.Sprintf("%-*s", widths[i], v)
+		}
+		fmt.Println(strings.TrimRight(strings.Join(line, " "), " "))
+	}
+	fmt.Printf("\ntotal: %d, matched: %d, changed: %d, failed: %d, duration: %dus\n",
+		data.Get("TOTAL").MustInt(), data.Get("MATCHED").MustInt(), data.Get("CHANGED").MustInt(),
+		data.Get("FAILED").MustInt(), data.Get("DURATION").MustInt64())
+	return nil
+}
@@ -0,0 +1,303 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"strings"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deepflowio/deepflow/cli/ctl/common"
+	"github.com/deepflowio/deepflow/cli/ctl/common/table"
+)
+
+type policyResource struct {
+	use     string
+	desc    string
+	path    string
+	columns []string
+}
+
+var policyResources = []policyResource{
+	{
+		use:     "pcap",
+		desc:    "pcap policy",
+		path:    "/v1/pcap-policies/",
+		columns: []string{"NAME", "ID", "STATE", "ACL_ID", "VTAP_IDS", "PAYLOAD_SLICE", "LCUUID"},
+	},
+	{
+		use:     "npb",
+		desc:    "npb policy",
+		path:    "/v1/npb-policies/",
+		columns: []string{"NAME", "ID", "STATE", "ACL_ID", "NPB_TUNNEL_ID", "VTAP_IDS", "DIRECTION", "DISTRIBUTE", "LCUUID"},
+	},
+	{
+		use:     "acl",
+		desc:    "acl of pcap and npb policies",
+		path:    "/v1/acls/",
+		columns: []string{"NAME", "ID", "STATE", "APPLICATION", "SRC_GROUP_IDS", "DST_GROUP_IDS", "PROTOCOL", "SRC_PORTS", "DST_PORTS", "LCUUID"},
+	},
+	{
+		use:     "resource-group",
+		desc:    "resource group of acls",
+		path:    "/v1/resource-groups/",
+		columns: []string{"NAME", "ID", "TYPE", "VPC_ID", "IPS", "VM_IDS", "LCUUID"},
+	},
+}
+
+func RegisterPolicyCommand() *cobra.Command {
+	policy := &cobra.Command{
+		Use:   "policy",
+		Short: "pcap and npb policy operation commands",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("please run with 'pcap | npb | acl | resource-group'.\n")
+		},
+	}
+	for i := range policyResources {
+		policy.AddCommand(registerPolicyResourceCommand(&policyResources[i]))
+	}
+	return policy
+}
+
+func registerPolicyResourceCommand(resource *policyResource) *cobra.Command {
+	resourceCmd := &cobra.Command{
+		Use:   resource.use,
+		Short: resource.desc + " operation commands",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("please run with 'list | create | update | delete'.\n")
+		},
+	}
+
+	var listOutput string
+	list := &cobra.Command{
+		Use:     "list [name]",
+		Short:   "list " + resource.desc,
+		Example: fmt.Sprintf("deepflow-ctl policy %s list", resource.use),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := listPolicyResource(cmd, resource, args, listOutput); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		},
+	}
+	list.Flags().StringVarP(&listOutput, "output", "o", "", "output format")
+
+	var createFilename string
+	var createDryRun bool
+	create := &cobra.Command{
+		Use:     "create",
+		Short:   "create " + resource.desc,
+		Example: fmt.Sprintf("deepflow-ctl policy %s create -f %s.yaml --dry-run", resource.use, resource.use),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := writePolicyResource(cmd, resource, "POST", "", createFilename, createDryRun); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		},
+	}
+	create.Flags().StringVarP(&createFilename, "filename", "f", "", "create "+resource.desc+" from file or stdin")
+	create.Flags().BoolVarP(&createDryRun, "dry-run", "", false, "only show agents affected by the change")
+	create.MarkFlagRequired("filename")
+
+	var updateFilename string
+	var updateDryRun bool
+	update := &cobra.Command{
+		Use:     "update name",
+		Short:   "update " + resource.desc + ", only fields in the file are changed",
+		Example: fmt.Sprintf("deepflow-ctl policy %s update <name> -f %s.yaml --dry-run", resource.use, resource.use),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				fmt.Fprintf(os.Stderr, "must specify name.\nExample: %s\n", cmd.Example)
+				return
+			}
+			if err := writePolicyResource(cmd, resource, "PATCH", args[0], updateFilename, updateDryRun); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		},
+	}
+	update.Flags().StringVarP(&updateFilename, "filename", "f", "", "update "+resource.desc+" from file or stdin")
+	update.Flags().BoolVarP(&updateDryRun, "dry-run", "", false, "only show agents affected by the change")
+	update.MarkFlagRequired("filename")
+
+	var deleteDryRun bool
+	delete := &cobra.Command{
+		Use:     "delete name",
+		Short:   "delete " + resource.desc,
+		Example: fmt.Sprintf("deepflow-ctl policy %s delete <name> --dry-run", resource.use),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				fmt.Fprintf(os.Stderr, "must specify name.\nExample: %s\n", cmd.Example)
+				return
+			}
+			if err := writePolicyResource(cmd, resource, "DELETE", args[0], "", deleteDryRun); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		},
+	}
+	delete.Flags().BoolVarP(&deleteDryRun, "dry-run", "", false, "only show agents affected by the change")
+
+	resourceCmd.AddCommand(list)
+	resourceCmd.AddCommand(create)
+	resourceCmd.AddCommand(update)
+	resourceCmd.AddCommand(delete)
+	return resourceCmd
+}
+
+func getPolicyResource(cmd *cobra.Command, resource *policyResource, name string) (*simplejson.Json, error) {
+	server := common.GetServerInfo(cmd)
+	url := fmt.Sprintf("http://%s:%d%s", server.IP, server.Port, resource.path)
+	if name != "" {
+		url += "?name=" + neturl.QueryEscape(name)
+	}
+	response, err := common.CURLPerform("GET", url, nil, "", []common.HTTPOption{common.WithTimeout(common.GetTimeout(cmd)), common.WithORGID(common.GetORGID(cmd))}...)
+	if err != nil {
+		return nil, err
+	}
+	return response.Get("DATA"), nil
+}
+
+func listPolicyResource(cmd *cobra.Command, resource *policyResource, args []string, output string) error {
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	data, err := getPolicyResource(cmd, resource, name)
+	if err != nil {
+		return err
+	}
+
+	if output == "yaml" {
+		jData, _ := data.MarshalJSON()
+		yData, _ := yaml.JSONToYAML(jData)
+		fmt.Printf(string(yData))
+		return nil
+	}
+	t := table.New()
+	t.SetHeader(resource.columns)
+	tableItems := [][]string{}
+	for i := range data.MustArray() {
+		d := data.GetIndex(i)
+		item := make([]string, 0, len(resource.columns))
+		for _, column := range resource.columns {
+			item = append(item, formatPolicyValue(d.Get(column).Interface()))
+		}
+		tableItems = append(tableItems, item)
+	}
+	t.AppendBulk(tableItems)
+	t.Render()
+	return nil
+}
+
+func formatPolicyValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		strs := make([]string, 0, len(value))
+		for _, item := range value {
+			strs = append(strs, fmt.Sprintf("%v", item))
+		}
+		return strings.Join(strs, ",")
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// writePolicyResource creates, updates or deletes the resource, update and delete find the resource by name
+func writePolicyResource(cmd *cobra.Command, resource *policyResource, method, name, filename string, dryRun bool) error {
+	var body map[string]interface{}
+	if filename != "" {
+		var err error
+		if body, err = formatBody(filename); err != nil {
+			return err
+		}
+	}
+
+	server := common.GetServerInfo(cmd)
+	url := fmt.Sprintf("http://%s:%d%s", server.IP, server.Port, resource.path)
+	if name != "" {
+		data, err := getPolicyResource(cmd, resource, name)
+		if err != nil {
+			return err
+		}
+		if len(data.MustArray()) == 0 {
+			return fmt.Errorf("%s (%s) not found", resource.desc, name)
+		}
+		url += data.GetIndex(0).Get("LCUUID").MustString() + "/"
+	}
+	if dryRun {
+		url += "?dry_run=true"
+	}
+
+	response, err := common.CURLPerform(method, url, body, "", []common.HTTPOption{common.WithTimeout(common.GetTimeout(cmd)), common.WithORGID(common.GetORGID(cmd))}...)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return printPolicyChangePreview(response.Get("DATA"))
+	}
+	respByte, err := response.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	formatStr, err := common.JsonFormat(respByte)
+	if err != nil {
+		return errors.New("format json str faild: " + err.Error())
+	}
+	fmt.Println(formatStr)
+	return nil
+}
+
+func printPolicyChangePreview(preview *simplejson.Json) error {
+	fmt.Printf("operation: %s (dry run, nothing is saved)\n", preview.Get("OPERATION").MustString())
+	if pcapPolicies := preview.Get("PCAP_POLICIES").MustStringArray(); len(pcapPolicies) > 0 {
+		fmt.Printf("affected pcap policies: %s\n", strings.Join(pcapPolicies, ", "))
+	}
+	if npbPolicies := preview.Get("NPB_POLICIES").MustStringArray(); len(npbPolicies) > 0 {
+		fmt.Printf("affected npb policies: %s\n", strings.Join(npbPolicies, ", "))
+	}
+	if preview.Get("ALL_VTAPS").MustBool() {
+		fmt.Println("policies shared by all agents are affected")
+	}
+	if preview.Get("INGESTER").MustBool() {
+		fmt.Println("policies of ingesters are affected")
+	}
+
+	vtaps := preview.Get("VTAPS")
+	if len(vtaps.MustArray()) == 0 {
+		fmt.Println("no agent is affected")
+		return nil
+	}
+	t := table.New()
+	t.SetHeader([]string{"ID", "NAME", "AGENT_GROUP_LCUUID"})
+	tableItems := [][]string{}
+	for i := range vtaps.MustArray() {
+		v := vtaps.GetIndex(i)
+		tableItems = append(tableItems, []string{
+			fmt.Sprintf("%d", v.Get("ID").MustInt()),
+			v.Get("NAME").MustString(),
+			v.Get("VTAP_GROUP_LCUUID").MustString(),
+		})
+	}
+	t.AppendBulk(tableItems)
+	t.Render()
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ctl
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/deepflowio/deepflow/cli/ctl/common"
+	"github.com/deepflowio/deepflow/cli/ctl/common/table"
+)
+
+func RegisterQueryCommand() *cobra.Command {
+	query := &cobra.Command{
+		Use:   "query",
+		Short: "querier running query operation commands",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("please run with 'list | kill'.\n")
+		},
+	}
+	query.PersistentFlags().Uint32P("querier-port", "", 30416, "deepflow-server querier node port")
+
+	list := &cobra.Command{
+		Use:     "list",
+		Short:   "list running queries",
+		Example: "deepflow-ctl query list",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := listQueries(cmd); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	kill := &cobra.Command{
+		Use:     "kill",
+		Short:   "kill running query",
+		Example: "deepflow-ctl query kill <query_uuid>\n(get query_uuid from command `deepflow-ctl query list`)",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := killQuery(cmd, args); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	query.AddCommand(list)
+	query.AddCommand(kill)
+	return query
+}
+
+func getQuerierURL(cmd *cobra.Command) string {
+	server := common.GetServerInfo(cmd)
+	querierPort, _ := cmd.Flags().GetUint32("querier-port")
+	return fmt.Sprintf("http://%s:%d", server.IP, querierPort)
+}
+
+func listQueries(cmd *cobra.Command) error {
+	url := getQuerierURL(cmd) + "/v1/queries"
+	response, err := common.CURLPerform("GET", url, nil, "", []common.HTTPOption{common.WithTimeout(common.GetTimeout(cmd)), common.WithORGID(common.GetORGID(cmd))}...)
+	if err != nil {
+		return err
+	}
+
+	t := table.New()
+	t.SetHeader([]string{"QUERY_UUID", "QUERY_ID", "ORG_ID", "USER_ID", "START_TIME", "ELAPSED(S)", "READ_ROWS", "SQL"})
+	data := response.Get("result")
+	tableItems := make([][]string, 0, len(data.MustArray()))
+	for i := range data.MustArray() {
+		d := data.GetIndex(i)
+		tableItems = append(tableItems, []string{
+			d.Get("query_uuid").MustString(),
+			d.Get("query_id").MustString(),
+			d.Get("org_id").MustString(),
+			d.Get("user_id").MustString(),
+			d.Get("start_time").MustString(),
+			fmt.Sprintf("%.3f", d.Get("elapsed").MustFloat64()),
+			strconv.FormatUint(d.Get("read_rows").MustUint64(), 10),
+			d.Get("sql").MustString(),
+		})
+	}
+	t.AppendBulk(tableItems)
+	t.Render()
+	return nil
+}
+
+func killQuery(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("must specify query_uuid\nExample: %s", cmd.Example)
+	} else if len(args) > 1 {
+		return fmt.Errorf("must specify one query_uuid\nExample: %s", cmd.Example)
+	}
+
+	url := getQuerierURL(cmd) + fmt.Sprintf("/v1/queries/%s", args[0])
+	response, err := common.CURLPerform("DELETE", url, nil, "", []common.HTTPOption{common.WithTimeout(common.GetTimeout(cmd)), common.WithORGID(common.GetORGID(cmd))}...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("killed query_ids: %v\n", response.Get("result").Get("query_ids").MustStringArray())
+	return nil
+}
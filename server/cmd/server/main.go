@@ -36,6 +36,7 @@ import (
 	"github.com/deepflowio/deepflow/server/ingester/ingesterctl"
 	"github.com/deepflowio/deepflow/server/libs/debug"
 	"github.com/deepflowio/deepflow/server/libs/logger"
+	"github.com/deepflowio/deepflow/server/libs/stats"
 	"github.com/deepflowio/deepflow/server/querier/querier"
 
 	logging "github.com/op/go-logging"
@@ -49,7 +50,8 @@ func execName() string {
 var log = logging.MustGetLogger(execName())
 
 const (
-	PROFILER_PORT = 9526
+	PROFILER_PORT            = 9526
+	PROMETHEUS_EXPORTER_PORT = 9527
 )
 
 var flagSet = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
@@ -93,6 +95,10 @@ func main() {
 		runtime.GOMAXPROCS(cfg.MaxCPUs)
 	}
 
+	if cfg.PrometheusExporter.Enabled {
+		stats.StartPrometheusServer(cfg.PrometheusExporter.ListenPort)
+	}
+
 	NewContinuousProfiler(&cfg.ContinuousProfile).Start(false)
 	NewFreeOSMemoryHandler(&cfg.FreeOSMemoryManager).Start(false)
 
@@ -33,8 +33,9 @@ var log = logging.MustGetLogger("server_common")
 const QUEUE_SIZE = 1 << 16
 
 type ControllerIngesterShared struct {
-	ResourceEventQueue *queue.OverwriteQueue
-	TraceTreeQueue     *queue.OverwriteQueue
+	ResourceEventQueue  *queue.OverwriteQueue
+	TraceTreeQueue      *queue.OverwriteQueue
+	SlowQueryEventQueue *queue.OverwriteQueue
 }
 
 func NewControllerIngesterShared() *ControllerIngesterShared {
@@ -47,6 +48,10 @@ func NewControllerIngesterShared() *ControllerIngesterShared {
 			"querier-to-ingester-trace_tree", QUEUE_SIZE,
 			queue.OptionFlushIndicator(time.Second*3),
 			queue.OptionRelease(func(p interface{}) { p.(*tracetree.TraceTree).Release() })),
+		SlowQueryEventQueue: queue.NewOverwriteQueue(
+			"querier-to-ingester-slow_query_event", QUEUE_SIZE,
+			queue.OptionFlushIndicator(time.Second*3),
+			queue.OptionRelease(func(p interface{}) { p.(*eventapi.SlowQueryEvent).Release() })),
 	}
 }
 
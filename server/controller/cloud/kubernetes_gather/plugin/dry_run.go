@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	simplejson "github.com/bitly/go-simplejson"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/deepflowio/deepflow/server/controller/common"
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+)
+
+// dry-run 整体执行超时, 避免有问题的脚本(如死循环)阻塞 API
+const DRY_RUN_TIMEOUT = 30 * time.Second
+
+// PodGroupNameToType 为插件返回的工作负载类型(忽略大小写)与 pod group 类型的对应关系, 不在其中的类型同步时会被忽略
+var PodGroupNameToType = map[string]int{
+	"deployment":            common.POD_GROUP_DEPLOYMENT,
+	"statefulset":           common.POD_GROUP_STATEFULSET,
+	"replicaset":            common.POD_GROUP_REPLICASET_CONTROLLER,
+	"daemonset":             common.POD_GROUP_DAEMON_SET,
+	"replicationcontroller": common.POD_GROUP_RC,
+	"cloneset":              common.POD_GROUP_CLONESET,
+}
+
+type DryRunWorkload struct {
+	Namespace string `json:"NAMESPACE"`
+	Name      string `json:"NAME"` // pod name
+	// 被测插件的返回值
+	Type         string `json:"TYPE"`
+	WorkloadName string `json:"WORKLOAD_NAME"`
+	Supported    bool   `json:"SUPPORTED"`
+	Error        string `json:"ERROR"`
+	Duration     int64  `json:"DURATION"` // us
+	// 当前已启用插件的分组结果, 以及启用被测插件后的分组结果
+	CurrentType          string `json:"CURRENT_TYPE"`
+	CurrentWorkloadName  string `json:"CURRENT_WORKLOAD_NAME"`
+	ExpectedType         string `json:"EXPECTED_TYPE"`
+	ExpectedWorkloadName string `json:"EXPECTED_WORKLOAD_NAME"`
+	Changed              bool   `json:"CHANGED"`
+}
+
+type DryRunResult struct {
+	Total     int              `json:"TOTAL"`
+	Matched   int              `json:"MATCHED"`
+	Changed   int              `json:"CHANGED"`
+	Failed    int              `json:"FAILED"`
+	Duration  int64            `json:"DURATION"` // us
+	Error     string           `json:"ERROR"`
+	Workloads []DryRunWorkload `json:"WORKLOADS"`
+}
+
+// DryRun runs the candidate lua plugin against pod metadatas in the same sandbox as GeneratePodGroup,
+// and compares the grouping of the current plugins with the grouping after the candidate is activated.
+// A plugin with the same name as the candidate is replaced, otherwise the candidate is appended.
+func DryRun(orgID int, plugins []mysqlmodel.Plugin, candidate mysqlmodel.Plugin, metaDatas []*simplejson.Json) *DryRunResult {
+	ctx, cancel := context.WithTimeout(context.Background(), DRY_RUN_TIMEOUT)
+	defer cancel()
+
+	start := time.Now()
+	result := &DryRunResult{Total: len(metaDatas), Workloads: []DryRunWorkload{}}
+	defer func() { result.Duration = time.Since(start).Microseconds() }()
+
+	L := lua.NewState()
+	defer L.Close()
+	L.SetContext(ctx)
+	if err := L.DoString(string(candidate.Image)); err != nil {
+		result.Error = fmt.Sprintf("lua script loading error: (%s)", err.Error())
+		return result
+	}
+	if L.GetGlobal("GetWorkloadTypeAndName").Type() != lua.LTFunction {
+		result.Error = "lua script function GetWorkloadTypeAndName not found"
+		return result
+	}
+
+	activated := make([]mysqlmodel.Plugin, 0, len(plugins)+1)
+	replaced := false
+	for _, p := range plugins {
+		if p.Name == candidate.Name {
+			p = candidate
+			replaced = true
+		}
+		activated = append(activated, p)
+	}
+	if !replaced {
+		activated = append(activated, candidate)
+	}
+
+	for _, metaData := range metaDatas {
+		w := DryRunWorkload{
+			Namespace: metaData.Get("namespace").MustString(),
+			Name:      metaData.Get("name").MustString(),
+		}
+		callStart := time.Now()
+		var err error
+		w.Type, w.WorkloadName, err = callLuaPlugin(L, metaData)
+		w.Duration = time.Since(callStart).Microseconds()
+		if err != nil {
+			w.Error = err.Error()
+			result.Failed++
+		} else if w.Type != "" && w.WorkloadName != "" {
+			_, w.Supported = PodGroupNameToType[strings.ToLower(w.Type)]
+			result.Matched++
+		}
+
+		if w.CurrentType, w.CurrentWorkloadName, err = generatePodGroup(ctx, orgID, plugins, metaData); err != nil {
+			w.CurrentType, w.CurrentWorkloadName = "", ""
+		}
+		if w.ExpectedType, w.ExpectedWorkloadName, err = generatePodGroup(ctx, orgID, activated, metaData); err != nil {
+			w.ExpectedType, w.ExpectedWorkloadName = "", ""
+			if w.Error == "" {
+				w.Error = err.Error()
+				result.Failed++
+			}
+		}
+		if w.CurrentType != w.ExpectedType || w.CurrentWorkloadName != w.ExpectedWorkloadName {
+			w.Changed = true
+			result.Changed++
+		}
+		result.Workloads = append(result.Workloads, w)
+
+		if ctx.Err() != nil {
+			result.Error = fmt.Sprintf("dry run timeout after %s, %d/%d workloads tested", DRY_RUN_TIMEOUT, len(result.Workloads), result.Total)
+			break
+		}
+	}
+	return result
+}
+
+// ParseMetaDatas parses sample metadata, which could be a pod, pod metadata or an array of them
+func ParseMetaDatas(data []byte) ([]*simplejson.Json, error) {
+	sample, err := simplejson.NewJson(data)
+	if err != nil {
+		return nil, fmt.Errorf("metadata json parse error: (%s)", err.Error())
+	}
+	var items []*simplejson.Json
+	if arr, err := sample.Array(); err == nil {
+		for i := range arr {
+			items = append(items, sample.GetIndex(i))
+		}
+	} else {
+		items = append(items, sample)
+	}
+
+	metaDatas := make([]*simplejson.Json, 0, len(items))
+	for _, item := range items {
+		if metaData, ok := item.CheckGet("metadata"); ok {
+			item = metaData
+		}
+		if _, err := item.Map(); err != nil {
+			return nil, errors.New("metadata must be a json object or an array of json objects")
+		}
+		metaDatas = append(metaDatas, item)
+	}
+	return metaDatas, nil
+}
+
+// PodMetaDatas returns metadatas of the pods that kubernetes_gather passes to lua plugins during sync
+func PodMetaDatas(k8sInfo map[string][]string) ([]*simplejson.Json, error) {
+	var metaDatas []*simplejson.Json
+	for _, p := range k8sInfo["*v1.Pod"] {
+		pData, err := simplejson.NewJson([]byte(p))
+		if err != nil {
+			return nil, fmt.Errorf("pod simplejson error: (%s)", err.Error())
+		}
+		metaData, ok := pData.CheckGet("metadata")
+		if !ok {
+			continue
+		}
+		if metaData.Get("uid").MustString() == "" || metaData.Get("name").MustString() == "" || metaData.Get("namespace").MustString() == "" {
+			continue
+		}
+		// InPlaceSet 的 pod 不经过插件
+		if metaData.Get("ownerReferences").GetIndex(0).Get("kind").MustString() == "InPlaceSet" {
+			continue
+		}
+		metaDatas = append(metaDatas, metaData)
+	}
+	return metaDatas, nil
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"testing"
+
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+)
+
+const appLabelPlugin = `
+function GetWorkloadTypeAndName(metaData)
+	local app = string.match(metaData, '"app":"([^"]+)"')
+	if app == nil then
+		return "", ""
+	end
+	return "Deployment", app
+end
+`
+
+const oldAppLabelPlugin = `
+function GetWorkloadTypeAndName(metaData)
+	local app = string.match(metaData, '"app":"([^"]+)"')
+	if app == nil then
+		return "", ""
+	end
+	return "Deployment", "old-" .. app
+end
+`
+
+const samplePods = `[
+	{"metadata": {"name": "web-1", "namespace": "default", "uid": "1", "labels": {"app": "web"}}},
+	{"name": "job-1", "namespace": "default", "uid": "2", "labels": {"job": "batch"}}
+]`
+
+func TestDryRun(t *testing.T) {
+	metaDatas, err := ParseMetaDatas([]byte(samplePods))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metaDatas) != 2 {
+		t.Fatalf("expected 2 metadatas, got %d", len(metaDatas))
+	}
+
+	plugins := []mysqlmodel.Plugin{{Name: "app", Image: []byte(oldAppLabelPlugin)}}
+	result := DryRun(1, plugins, mysqlmodel.Plugin{Name: "app", Image: []byte(appLabelPlugin)}, metaDatas)
+	if result.Error != "" {
+		t.Fatal(result.Error)
+	}
+	if result.Total != 2 || result.Matched != 1 || result.Changed != 1 || result.Failed != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	web := result.Workloads[0]
+	if web.Name != "web-1" || web.Type != "Deployment" || web.WorkloadName != "web" || !web.Supported {
+		t.Errorf("unexpected workload %+v", web)
+	}
+	if web.CurrentWorkloadName != "old-web" || web.ExpectedWorkloadName != "web" || !web.Changed {
+		t.Errorf("unexpected grouping diff %+v", web)
+	}
+	if job := result.Workloads[1]; job.Type != "" || job.Changed {
+		t.Errorf("unexpected workload %+v", job)
+	}
+}
+
+func TestDryRunScriptError(t *testing.T) {
+	metaDatas, _ := ParseMetaDatas([]byte(samplePods))
+
+	result := DryRun(1, nil, mysqlmodel.Plugin{Name: "broken", Image: []byte("function (")}, metaDatas)
+	if result.Error == "" {
+		t.Error("expected loading error")
+	}
+
+	result = DryRun(1, nil, mysqlmodel.Plugin{Name: "missing", Image: []byte("local a = 1")}, metaDatas)
+	if result.Error == "" {
+		t.Error("expected missing function error")
+	}
+
+	runtimeError := `function GetWorkloadTypeAndName(metaData) error("boom") end`
+	result = DryRun(1, nil, mysqlmodel.Plugin{Name: "runtime", Image: []byte(runtimeError)}, metaDatas)
+	if result.Error != "" || result.Failed != 2 || result.Workloads[0].Error == "" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestPodMetaDatas(t *testing.T) {
+	k8sInfo := map[string][]string{
+		"*v1.Pod": {
+			`{"metadata": {"name": "web-1", "namespace": "default", "uid": "1"}}`,
+			`{"metadata": {"name": "ips-1", "namespace": "default", "uid": "2", "ownerReferences": [{"kind": "InPlaceSet"}]}}`,
+			`{"metadata": {"name": "no-uid", "namespace": "default"}}`,
+		},
+	}
+	metaDatas, err := PodMetaDatas(k8sInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metaDatas) != 1 || metaDatas[0].Get("name").MustString() != "web-1" {
+		t.Errorf("unexpected metadatas %v", metaDatas)
+	}
+}
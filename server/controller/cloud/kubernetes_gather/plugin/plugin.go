@@ -17,6 +17,7 @@
 package plugin
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -33,12 +34,21 @@ import (
 var log = logger.MustGetLogger("cloud.kubernetes_gather.plugin")
 
 func GeneratePodGroup(orgID int, db *gorm.DB, metaData *simplejson.Json) (string, string, error) {
-	var plugins []mysqlmodel.Plugin
-	err := db.Where("type = ?", common.PLUGIN_TYPE_LUA).Find(&plugins).Error
+	plugins, err := LoadLuaPlugins(db)
 	if err != nil {
 		return "", "", err
 	}
+	return generatePodGroup(nil, orgID, plugins, metaData)
+}
 
+func LoadLuaPlugins(db *gorm.DB) ([]mysqlmodel.Plugin, error) {
+	var plugins []mysqlmodel.Plugin
+	err := db.Where("type = ?", common.PLUGIN_TYPE_LUA).Find(&plugins).Error
+	return plugins, err
+}
+
+// ctx 仅用于 dry-run 限制脚本执行时间, 同步流程中传 nil
+func generatePodGroup(ctx context.Context, orgID int, plugins []mysqlmodel.Plugin, metaData *simplejson.Json) (string, string, error) {
 	// TODO: convert to lua script
 	podGroupType, podGroupName := customSCIPodGroup(orgID, metaData)
 	if podGroupType != "" && podGroupName != "" {
@@ -47,39 +57,48 @@ func GeneratePodGroup(orgID int, db *gorm.DB, metaData *simplejson.Json) (string
 
 	L := lua.NewState()
 	defer L.Close()
+	if ctx != nil {
+		L.SetContext(ctx)
+	}
 	for _, plugin := range plugins {
 		if err := L.DoString(string(plugin.Image)); err != nil {
 			return "", "", fmt.Errorf("lua script loading error: (%s)", err.Error())
 		}
-		metaBytes, err := metaData.MarshalJSON()
+		podGroupType, podGroupName, err := callLuaPlugin(L, metaData)
 		if err != nil {
-			return "", "", fmt.Errorf("metaData marshal error: (%s)", err.Error())
-		}
-		err = L.CallByParam(lua.P{
-			Fn:      L.GetGlobal("GetWorkloadTypeAndName"),
-			NRet:    2,
-			Protect: true,
-		}, lua.LString(string(metaBytes)))
-		if err != nil {
-			return "", "", fmt.Errorf("lua script execution error: (%s)", err.Error())
-		}
-		loadType, ok := L.Get(-2).(lua.LString)
-		if !ok {
-			return "", "", errors.New("lua script get pod group type failed")
+			return "", "", err
 		}
-		podGroupType = string(loadType)
-
-		loadName, ok := L.Get(-1).(lua.LString)
-		if !ok {
-			return "", "", errors.New("lua script get pod group name failed")
-		}
-		podGroupName = string(loadName)
-
 		if podGroupType != "" && podGroupName != "" {
-			break
+			return podGroupType, podGroupName, nil
 		}
 	}
-	return podGroupType, podGroupName, nil
+	return "", "", nil
+}
+
+// callLuaPlugin calls GetWorkloadTypeAndName of the script loaded in L
+func callLuaPlugin(L *lua.LState, metaData *simplejson.Json) (string, string, error) {
+	metaBytes, err := metaData.MarshalJSON()
+	if err != nil {
+		return "", "", fmt.Errorf("metaData marshal error: (%s)", err.Error())
+	}
+	err = L.CallByParam(lua.P{
+		Fn:      L.GetGlobal("GetWorkloadTypeAndName"),
+		NRet:    2,
+		Protect: true,
+	}, lua.LString(string(metaBytes)))
+	if err != nil {
+		return "", "", fmt.Errorf("lua script execution error: (%s)", err.Error())
+	}
+	defer L.Pop(2)
+	loadType, ok := L.Get(-2).(lua.LString)
+	if !ok {
+		return "", "", errors.New("lua script get pod group type failed")
+	}
+	loadName, ok := L.Get(-1).(lua.LString)
+	if !ok {
+		return "", "", errors.New("lua script get pod group name failed")
+	}
+	return string(loadType), string(loadName), nil
 }
 
 func customSCIPodGroup(orgID int, metaData *simplejson.Json) (string, string) {
@@ -36,14 +36,7 @@ func (k *KubernetesGather) getPodGroups() (podGroups []model.PodGroup, err error
 	podControllers[2] = k.k8sInfo["*v1.DaemonSet"]
 	podControllers[3] = k.k8sInfo["*v1.CloneSet"]
 	podControllers[4] = k.k8sInfo["*v1.Pod"]
-	pgNameToTypeID := map[string]int{
-		"deployment":            common.POD_GROUP_DEPLOYMENT,
-		"statefulset":           common.POD_GROUP_STATEFULSET,
-		"replicaset":            common.POD_GROUP_REPLICASET_CONTROLLER,
-		"daemonset":             common.POD_GROUP_DAEMON_SET,
-		"replicationcontroller": common.POD_GROUP_RC,
-		"cloneset":              common.POD_GROUP_CLONESET,
-	}
+	pgNameToTypeID := plugin.PodGroupNameToType
 	for t, podController := range podControllers {
 		for _, c := range podController {
 			podTargetPorts := map[string]int{}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	"strings"
+
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+const INTERNAL_AZ_NAME = "internal"
+
+// getAZsAndHosts 获取 nova 可用区，all_projects 模式下（admin 角色）同时获取计算节点
+func (o *OpenStack) getAZsAndHosts(region, computeURL, token string) ([]model.AZ, []model.Host, error) {
+	log.Debug("get azs starting", logger.NewORGPrefix(o.orgID))
+	var azs []model.AZ
+	var hosts []model.Host
+
+	computeURL = strings.TrimSuffix(computeURL, "/")
+	azURL := computeURL + "/os-availability-zone"
+	if o.config.AllProjects {
+		azURL += "/detail"
+	}
+	jAZs, err := o.getRawData(azURL, token, "availabilityZoneInfo", false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostNameToAZLcuuid := make(map[string]string)
+	for _, jAZ := range jAZs {
+		name := jAZ.Get("zoneName").MustString()
+		if name == "" || name == INTERNAL_AZ_NAME {
+			continue
+		}
+		lcuuid := common.GenerateUUIDByOrgID(o.orgID, region+"_"+name+"_"+o.lcuuidGenerate)
+		azs = append(azs, model.AZ{
+			Lcuuid:       lcuuid,
+			Name:         name,
+			Label:        name,
+			RegionLcuuid: o.config.RegionLcuuid,
+		})
+		o.toolDataSet.azNameToAZLcuuid[name] = lcuuid
+		for hostName := range jAZ.Get("hosts").MustMap() {
+			hostNameToAZLcuuid[hostName] = lcuuid
+		}
+	}
+	log.Debug("get azs complete", logger.NewORGPrefix(o.orgID))
+
+	if !o.config.AllProjects {
+		return azs, hosts, nil
+	}
+
+	log.Debug("get hosts starting", logger.NewORGPrefix(o.orgID))
+	jHypervisors, err := o.getRawData(computeURL+"/os-hypervisors/detail", token, "hypervisors", false)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, jHypervisor := range jHypervisors {
+		if !cloudcommon.CheckJsonAttributes(jHypervisor, []string{"hypervisor_hostname", "host_ip"}) {
+			continue
+		}
+		hostname := jHypervisor.Get("hypervisor_hostname").MustString()
+		serviceHost := jHypervisor.Get("service").Get("host").MustString()
+		ip := jHypervisor.Get("host_ip").MustString()
+		o.toolDataSet.hostNameToIP[hostname] = ip
+		if serviceHost != "" {
+			o.toolDataSet.hostNameToIP[serviceHost] = ip
+		}
+
+		azLcuuid, ok := hostNameToAZLcuuid[serviceHost]
+		if !ok {
+			azLcuuid, ok = hostNameToAZLcuuid[hostname]
+		}
+		if !ok {
+			log.Infof("exclude host: %s, missing az info", hostname, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		hosts = append(hosts, model.Host{
+			Lcuuid:       common.GenerateUUIDByOrgID(o.orgID, region+"_"+hostname+"_"+o.lcuuidGenerate),
+			Name:         hostname,
+			IP:           ip,
+			Hostname:     hostname,
+			Type:         common.HOST_TYPE_VM,
+			HType:        common.HOST_HTYPE_KVM,
+			VCPUNum:      jHypervisor.Get("vcpus").MustInt(),
+			MemTotal:     jHypervisor.Get("memory_mb").MustInt(),
+			AZLcuuid:     azLcuuid,
+			RegionLcuuid: o.config.RegionLcuuid,
+		})
+		o.toolDataSet.azLcuuidToResourceNum[azLcuuid]++
+	}
+	log.Debug("get hosts complete", logger.NewORGPrefix(o.orgID))
+	return azs, hosts, nil
+}
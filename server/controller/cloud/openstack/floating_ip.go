@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+func (o *OpenStack) getFloatingIPs(networkURL, token string) ([]model.FloatingIP, error) {
+	log.Debug("get floating ips starting", logger.NewORGPrefix(o.orgID))
+	var floatingIPs []model.FloatingIP
+
+	jFIPs, err := o.getRawData(o.projectFilter(networkURL+"/floatingips"), token, "floatingips", true)
+	if err != nil {
+		return nil, err
+	}
+	for _, jFIP := range jFIPs {
+		if !cloudcommon.CheckJsonAttributes(jFIP, []string{"id", "floating_ip_address", "floating_network_id"}) {
+			continue
+		}
+		id := jFIP.Get("id").MustString()
+		portID := jFIP.Get("port_id").MustString()
+		port, ok := o.toolDataSet.portIDToPort[portID]
+		if !ok {
+			log.Debugf("exclude floating ip: %s, not associated", id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		ip := jFIP.Get("floating_ip_address").MustString()
+		o.toolDataSet.portIDToFloatingIP[portID] = ip
+
+		// 仅记录绑定到虚拟机的浮动 IP，负载均衡器的浮动 IP 在 getLBs 中处理
+		if !o.toolDataSet.vmIDs[port.deviceID] {
+			continue
+		}
+		vpcLcuuid, ok := o.toolDataSet.networkIDToVPCLcuuid[port.networkID]
+		if !ok {
+			log.Infof("exclude floating ip: %s, missing vpc info", id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		floatingIPs = append(floatingIPs, model.FloatingIP{
+			Lcuuid:        common.IDGenerateUUID(o.orgID, id),
+			IP:            ip,
+			VMLcuuid:      common.IDGenerateUUID(o.orgID, port.deviceID),
+			NetworkLcuuid: common.IDGenerateUUID(o.orgID, jFIP.Get("floating_network_id").MustString()),
+			VPCLcuuid:     vpcLcuuid,
+			RegionLcuuid:  o.config.RegionLcuuid,
+		})
+	}
+	log.Debug("get floating ips complete", logger.NewORGPrefix(o.orgID))
+	return floatingIPs, nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	"strings"
+
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+type lbInfo struct {
+	lcuuid    string
+	vpcLcuuid string
+	ips       []string
+}
+
+func (o *OpenStack) getLBs(lbURL, token string) ([]model.LB, []model.LBListener, []model.LBTargetServer, []model.VInterface, []model.IP, error) {
+	log.Debug("get lbs starting", logger.NewORGPrefix(o.orgID))
+	var lbs []model.LB
+	var listeners []model.LBListener
+	var targetServers []model.LBTargetServer
+	var vifs []model.VInterface
+	var ips []model.IP
+
+	jLBs, err := o.getRawData(o.projectFilter(lbURL+"/loadbalancers"), token, "loadbalancers", true)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	idToLB := make(map[string]lbInfo)
+	for _, jLB := range jLBs {
+		if !cloudcommon.CheckJsonAttributes(jLB, []string{"id", "name", "vip_address"}) {
+			continue
+		}
+		id := jLB.Get("id").MustString()
+		vpcLcuuid := o.getVPCLcuuid(jLB.Get("project_id").MustString())
+		if vpcLcuuid == "" {
+			log.Infof("exclude lb: %s, missing vpc info", id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		name := jLB.Get("name").MustString()
+		if name == "" {
+			name = id
+		}
+		lcuuid := common.IDGenerateUUID(o.orgID, id)
+		vip := jLB.Get("vip_address").MustString()
+		info := lbInfo{lcuuid: lcuuid, vpcLcuuid: vpcLcuuid, ips: []string{vip}}
+
+		lbModel := cloudcommon.LB_MODEL_INTERNAL
+		vipPortID := jLB.Get("vip_port_id").MustString()
+		if port, ok := o.toolDataSet.portIDToPort[vipPortID]; ok {
+			vif, vifIPs := o.formatVInterfaceAndIPs(port, common.VIF_TYPE_LAN, common.VIF_DEVICE_TYPE_LB, lcuuid, vpcLcuuid)
+			vifs = append(vifs, vif)
+			ips = append(ips, vifIPs...)
+
+			// vip 端口绑定了浮动 IP 时为外网负载均衡器
+			if fIP, ok := o.toolDataSet.portIDToFloatingIP[vipPortID]; ok {
+				lbModel = cloudcommon.LB_MODEL_EXTERNAL
+				info.ips = append(info.ips, fIP)
+				wanVIF := model.VInterface{
+					Lcuuid:        common.GenerateUUIDByOrgID(o.orgID, lcuuid+fIP),
+					Type:          common.VIF_TYPE_WAN,
+					Mac:           cloudcommon.GenerateWANVInterfaceMac(port.mac),
+					DeviceLcuuid:  lcuuid,
+					DeviceType:    common.VIF_DEVICE_TYPE_LB,
+					NetworkLcuuid: common.NETWORK_ISP_LCUUID,
+					VPCLcuuid:     vpcLcuuid,
+					RegionLcuuid:  o.config.RegionLcuuid,
+				}
+				vifs = append(vifs, wanVIF)
+				ips = append(ips, model.IP{
+					Lcuuid:           common.GenerateUUIDByOrgID(o.orgID, wanVIF.Lcuuid+fIP),
+					VInterfaceLcuuid: wanVIF.Lcuuid,
+					IP:               fIP,
+					RegionLcuuid:     o.config.RegionLcuuid,
+				})
+			}
+		}
+		lbs = append(lbs, model.LB{
+			Lcuuid:       lcuuid,
+			Name:         name,
+			Label:        id,
+			Model:        lbModel,
+			VIP:          strings.Join(info.ips, ","),
+			VPCLcuuid:    vpcLcuuid,
+			RegionLcuuid: o.config.RegionLcuuid,
+		})
+		idToLB[id] = info
+	}
+	log.Debug("get lbs complete", logger.NewORGPrefix(o.orgID))
+
+	log.Debug("get lb listeners starting", logger.NewORGPrefix(o.orgID))
+	jListeners, err := o.getRawData(o.projectFilter(lbURL+"/listeners"), token, "listeners", true)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	for _, jListener := range jListeners {
+		if !cloudcommon.CheckJsonAttributes(jListener, []string{"id", "protocol", "protocol_port", "loadbalancers"}) {
+			continue
+		}
+		id := jListener.Get("id").MustString()
+		lb, ok := idToLB[jListener.Get("loadbalancers").GetIndex(0).Get("id").MustString()]
+		if !ok {
+			log.Infof("exclude lb listener: %s, missing lb info", id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		name := jListener.Get("name").MustString()
+		if name == "" {
+			name = id
+		}
+		lcuuid := common.IDGenerateUUID(o.orgID, id)
+		protocol := jListener.Get("protocol").MustString()
+		listeners = append(listeners, model.LBListener{
+			Lcuuid:   lcuuid,
+			LBLcuuid: lb.lcuuid,
+			Name:     name,
+			Label:    id,
+			IPs:      strings.Join(lb.ips, ","),
+			Protocol: protocol,
+			Port:     jListener.Get("protocol_port").MustInt(),
+		})
+
+		poolID := jListener.Get("default_pool_id").MustString()
+		if poolID == "" {
+			continue
+		}
+		jMembers, err := o.getRawData(lbURL+"/pools/"+poolID+"/members", token, "members", true)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		for _, jMember := range jMembers {
+			if !cloudcommon.CheckJsonAttributes(jMember, []string{"id", "address", "protocol_port"}) {
+				continue
+			}
+			address := jMember.Get("address").MustString()
+			targetServer := model.LBTargetServer{
+				Lcuuid:           common.GenerateUUIDByOrgID(o.orgID, lcuuid+jMember.Get("id").MustString()),
+				LBLcuuid:         lb.lcuuid,
+				LBListenerLcuuid: lcuuid,
+				Type:             common.LB_SERVER_TYPE_IP,
+				IP:               address,
+				Protocol:         protocol,
+				Port:             jMember.Get("protocol_port").MustInt(),
+				VPCLcuuid:        lb.vpcLcuuid,
+			}
+			key := SubnetIPKey{SubnetLcuuid: common.IDGenerateUUID(o.orgID, jMember.Get("subnet_id").MustString()), IP: address}
+			if vmLcuuid, ok := o.toolDataSet.keyToVMLcuuid[key]; ok {
+				targetServer.Type = common.LB_SERVER_TYPE_VM
+				targetServer.VMLcuuid = vmLcuuid
+			}
+			targetServers = append(targetServers, targetServer)
+		}
+	}
+	log.Debug("get lb listeners complete", logger.NewORGPrefix(o.orgID))
+	return lbs, listeners, targetServers, vifs, ips, nil
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+// getNetworkVPCLcuuid 非 all_projects 模式下，其他项目共享的网络归属于认证项目的 vpc
+func (o *OpenStack) getNetworkVPCLcuuid(projectID string) string {
+	vpcLcuuid := o.getVPCLcuuid(projectID)
+	if vpcLcuuid == "" && !o.config.AllProjects && o.token != nil {
+		vpcLcuuid = o.getVPCLcuuid(o.token.projectID)
+	}
+	return vpcLcuuid
+}
+
+func (o *OpenStack) getNetworks(networkURL, token string) ([]model.Network, []model.Subnet, error) {
+	log.Debug("get networks starting", logger.NewORGPrefix(o.orgID))
+	var networks []model.Network
+	var subnets []model.Subnet
+
+	// 共享网络、外部网络不属于当前项目，不按项目过滤
+	jNetworks, err := o.getRawData(networkURL+"/networks", token, "networks", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, jNetwork := range jNetworks {
+		if !cloudcommon.CheckJsonAttributes(jNetwork, []string{"id", "name"}) {
+			continue
+		}
+		id := jNetwork.Get("id").MustString()
+		vpcLcuuid := o.getNetworkVPCLcuuid(jNetwork.Get("project_id").MustString())
+		if vpcLcuuid == "" {
+			log.Infof("exclude network: %s, missing vpc info", id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		name := jNetwork.Get("name").MustString()
+		if name == "" {
+			name = id
+		}
+		external := jNetwork.Get("router:external").MustBool()
+		netType := common.NETWORK_TYPE_LAN
+		if external {
+			netType = common.NETWORK_TYPE_WAN
+		}
+		var azLcuuid string
+		for _, azName := range jNetwork.Get("availability_zones").MustStringArray() {
+			if lcuuid, ok := o.toolDataSet.azNameToAZLcuuid[azName]; ok {
+				azLcuuid = lcuuid
+				break
+			}
+		}
+		networks = append(networks, model.Network{
+			Lcuuid:         common.IDGenerateUUID(o.orgID, id),
+			Name:           name,
+			Label:          id,
+			SegmentationID: jNetwork.Get("provider:segmentation_id").MustInt(1),
+			Shared:         jNetwork.Get("shared").MustBool(),
+			External:       external,
+			NetType:        netType,
+			VPCLcuuid:      vpcLcuuid,
+			AZLcuuid:       azLcuuid,
+			RegionLcuuid:   o.config.RegionLcuuid,
+		})
+		o.toolDataSet.networkIDToVPCLcuuid[id] = vpcLcuuid
+		o.toolDataSet.networkIDToAZLcuuid[id] = azLcuuid
+		o.toolDataSet.networkIDIsExternal[id] = external
+	}
+	log.Debug("get networks complete", logger.NewORGPrefix(o.orgID))
+
+	log.Debug("get subnets starting", logger.NewORGPrefix(o.orgID))
+	jSubnets, err := o.getRawData(networkURL+"/subnets", token, "subnets", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, jSubnet := range jSubnets {
+		if !cloudcommon.CheckJsonAttributes(jSubnet, []string{"id", "network_id", "cidr"}) {
+			continue
+		}
+		id := jSubnet.Get("id").MustString()
+		networkID := jSubnet.Get("network_id").MustString()
+		vpcLcuuid, ok := o.toolDataSet.networkIDToVPCLcuuid[networkID]
+		if !ok {
+			log.Infof("exclude subnet: %s, missing network info", id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		name := jSubnet.Get("name").MustString()
+		if name == "" {
+			name = id
+		}
+		subnets = append(subnets, model.Subnet{
+			Lcuuid:        common.IDGenerateUUID(o.orgID, id),
+			Name:          name,
+			Label:         id,
+			CIDR:          jSubnet.Get("cidr").MustString(),
+			GatewayIP:     jSubnet.Get("gateway_ip").MustString(),
+			NetworkLcuuid: common.IDGenerateUUID(o.orgID, networkID),
+			VPCLcuuid:     vpcLcuuid,
+		})
+		o.toolDataSet.subnetIDToNetworkID[id] = networkID
+	}
+	log.Debug("get subnets complete", logger.NewORGPrefix(o.orgID))
+	return networks, subnets, nil
+}
@@ -0,0 +1,275 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitly/go-simplejson"
+
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/config"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+	"github.com/deepflowio/deepflow/server/controller/statsd"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+var log = logger.MustGetLogger("cloud.openstack")
+
+const PAGE_LIMIT = 500
+
+type OpenStack struct {
+	orgID          int
+	teamID         int
+	lcuuid         string
+	lcuuidGenerate string
+	name           string
+	httpTimeout    int
+	config         *Config
+	token          *Token             // 缓存 keystone token，临近过期时重新认证
+	toolDataSet    *ToolDataSet       // 处理资源数据时，构建的需要提供给其他资源使用的工具数据
+	cloudStatsd    statsd.CloudStatsd // 性能监控
+	debugger       *cloudcommon.Debugger
+}
+
+func NewOpenStack(orgID int, domain mysqlmodel.Domain, globalCloudCfg config.CloudConfig) (*OpenStack, error) {
+	conf := &Config{}
+	err := conf.LoadFromString(orgID, domain.Config)
+	if err != nil {
+		return nil, err
+	}
+	return newOpenStack(orgID, domain, globalCloudCfg, conf), nil
+}
+
+func newOpenStack(orgID int, domain mysqlmodel.Domain, globalCloudCfg config.CloudConfig, conf *Config) *OpenStack {
+	return &OpenStack{
+		orgID:  orgID,
+		teamID: domain.TeamID,
+		lcuuid: domain.Lcuuid,
+		// TODO: display_name后期需要修改为uuid_generate
+		lcuuidGenerate: domain.DisplayName,
+		name:           domain.Name,
+		httpTimeout:    globalCloudCfg.HTTPTimeout,
+		config:         conf,
+		cloudStatsd:    statsd.NewCloudStatsd(),
+		debugger:       cloudcommon.NewDebugger(domain.Name),
+	}
+}
+
+func (o *OpenStack) ClearDebugLog() {
+	o.debugger.Clear()
+}
+
+func (o *OpenStack) CheckAuth() error {
+	token, err := o.createToken()
+	if err != nil {
+		return err
+	}
+	if len(o.getRegions(token)) == 0 {
+		return fmt.Errorf("no %s compute endpoint found in keystone catalog", o.config.EndpointInterface)
+	}
+	o.token = token
+	return nil
+}
+
+func (o *OpenStack) GetCloudData() (model.Resource, error) {
+	o.cloudStatsd = statsd.NewCloudStatsd()
+	resource, err := o.getResource()
+	if err != nil {
+		return resource, err
+	}
+
+	o.cloudStatsd.ResCount = statsd.GetResCount(resource)
+	statsd.MetaStatsd.RegisterStatsdTable(o)
+
+	o.debugger.Refresh()
+	return resource, nil
+}
+
+func (o *OpenStack) getResource() (model.Resource, error) {
+	o.toolDataSet = NewToolDataSet()
+	var resource model.Resource
+	token, err := o.getToken()
+	if err != nil {
+		return resource, err
+	}
+
+	regions := o.getRegions(token)
+	if len(regions) == 0 {
+		return resource, fmt.Errorf("no %s compute endpoint found in keystone catalog", o.config.EndpointInterface)
+	}
+
+	err = o.getProjects(token)
+	if err != nil {
+		return resource, err
+	}
+
+	var azs []model.AZ
+	for _, region := range regions {
+		log.Infof("region (%s) collect starting", region, logger.NewORGPrefix(o.orgID))
+		o.toolDataSet.resetRegion()
+		endpoints := token.catalog[region]
+
+		regionAZs, hosts, err := o.getAZsAndHosts(region, endpoints[SERVICE_TYPE_COMPUTE], token.token)
+		if err != nil {
+			return resource, err
+		}
+		azs = append(azs, regionAZs...)
+		resource.Hosts = append(resource.Hosts, hosts...)
+
+		networkURL, ok := endpoints[SERVICE_TYPE_NETWORK]
+		if !ok {
+			log.Infof("region (%s) has no network endpoint, skip", region, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		networkURL = strings.TrimSuffix(networkURL, "/") + "/v2.0"
+
+		networks, subnets, err := o.getNetworks(networkURL, token.token)
+		if err != nil {
+			return resource, err
+		}
+		resource.Networks = append(resource.Networks, networks...)
+		resource.Subnets = append(resource.Subnets, subnets...)
+
+		err = o.getSecurityGroups(networkURL, token.token)
+		if err != nil {
+			return resource, err
+		}
+
+		err = o.getPorts(networkURL, token.token)
+		if err != nil {
+			return resource, err
+		}
+
+		vms, err := o.getVMs(endpoints[SERVICE_TYPE_COMPUTE], token.token)
+		if err != nil {
+			return resource, err
+		}
+		resource.VMs = append(resource.VMs, vms...)
+
+		vrouters, routingTables, err := o.getVRouters(networkURL, token.token)
+		if err != nil {
+			return resource, err
+		}
+		resource.VRouters = append(resource.VRouters, vrouters...)
+		resource.RoutingTables = append(resource.RoutingTables, routingTables...)
+
+		dhcpPorts, vifs, ips := o.getVInterfacesAndIPs()
+		resource.DHCPPorts = append(resource.DHCPPorts, dhcpPorts...)
+		resource.VInterfaces = append(resource.VInterfaces, vifs...)
+		resource.IPs = append(resource.IPs, ips...)
+
+		fIPs, err := o.getFloatingIPs(networkURL, token.token)
+		if err != nil {
+			return resource, err
+		}
+		resource.FloatingIPs = append(resource.FloatingIPs, fIPs...)
+
+		lbURL, ok := endpoints[SERVICE_TYPE_LOAD_BALANCER]
+		if !ok {
+			log.Debugf("region (%s) has no load-balancer endpoint, skip lbs", region, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		lbs, listeners, targetServers, vifs, ips, err := o.getLBs(strings.TrimSuffix(lbURL, "/")+"/v2/lbaas", token.token)
+		if err != nil {
+			return resource, err
+		}
+		resource.LBs = append(resource.LBs, lbs...)
+		resource.LBListeners = append(resource.LBListeners, listeners...)
+		resource.LBTargetServers = append(resource.LBTargetServers, targetServers...)
+		resource.VInterfaces = append(resource.VInterfaces, vifs...)
+		resource.IPs = append(resource.IPs, ips...)
+		log.Infof("region (%s) collect complete", region, logger.NewORGPrefix(o.orgID))
+	}
+
+	resource.VPCs = o.getVPCs()
+	log.Debugf("az resource num info: %v", o.toolDataSet.azLcuuidToResourceNum, logger.NewORGPrefix(o.orgID))
+	resource.AZs = cloudcommon.EliminateEmptyAZs(azs, o.toolDataSet.azLcuuidToResourceNum)
+	return resource, nil
+}
+
+func (o *OpenStack) GetStatter() statsd.StatsdStatter {
+	globalTags := map[string]string{
+		"domain_name": o.name,
+		"domain":      o.lcuuid,
+		"platform":    common.OPENSTACK_EN,
+	}
+
+	return statsd.StatsdStatter{
+		OrgID:      o.orgID,
+		TeamID:     o.teamID,
+		GlobalTags: globalTags,
+		Element:    statsd.GetCloudStatsd(o.cloudStatsd),
+	}
+}
+
+// getRawData 获取 openstack 列表接口数据
+// paged 为 true 时使用 limit、marker 分页，返回数量少于 limit 时结束
+func (o *OpenStack) getRawData(url, token, resultKey string, paged bool) (jsonList []*simplejson.Json, err error) {
+	statsdAPIStartTime := time.Now()
+
+	sep := "?"
+	if strings.Contains(url, "?") {
+		sep = "&"
+	}
+	reqURL := url
+	var marker string
+	for {
+		if paged {
+			reqURL = fmt.Sprintf("%s%slimit=%d", url, sep, PAGE_LIMIT)
+			if marker != "" {
+				reqURL += "&marker=" + marker
+			}
+		}
+		resp, err := cloudcommon.RequestGet(reqURL, token, time.Duration(o.httpTimeout))
+		if err != nil {
+			return []*simplejson.Json{}, err
+		}
+		jData := resp.Get(resultKey)
+		curCount := len(jData.MustArray())
+		for i := 0; i < curCount; i++ {
+			jsonList = append(jsonList, jData.GetIndex(i))
+		}
+		if !paged || curCount < PAGE_LIMIT {
+			break
+		}
+		marker = jData.GetIndex(curCount - 1).Get("id").MustString()
+		if marker == "" {
+			break
+		}
+	}
+	o.cloudStatsd.RefreshAPIMoniter(resultKey, len(jsonList), statsdAPIStartTime)
+
+	o.debugger.WriteJson(resultKey, url, jsonList)
+	return
+}
+
+// 非 all_projects 模式下，neutron、octavia 接口按认证项目过滤
+func (o *OpenStack) projectFilter(url string) string {
+	if o.config.AllProjects || o.token == nil {
+		return url
+	}
+	sep := "?"
+	if strings.Contains(url, "?") {
+		sep = "&"
+	}
+	return url + sep + "project_id=" + o.token.projectID
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/config"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	mysqlcommon "github.com/deepflowio/deepflow/server/controller/db/mysql/common"
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+)
+
+const (
+	testToken    = "test-token"
+	testPassword = "secret"
+)
+
+var testProjectNameToID = map[string]string{
+	"admin": "p-admin",
+	"demo":  "p-demo",
+}
+
+// fixtureServer 使用 testdata 中录制的 API 响应模拟 keystone、nova、neutron 和 octavia，
+// 请求路径 /a/b 对应文件 testdata/a/b.json
+type fixtureServer struct {
+	*httptest.Server
+	mutex sync.Mutex
+	urls  []string
+}
+
+func newFixtureServer() *fixtureServer {
+	s := &fixtureServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *fixtureServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.urls = append(s.urls, r.URL.String())
+	s.mutex.Unlock()
+
+	data, err := os.ReadFile(filepath.Join("testdata", r.URL.Path+".json"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodPost && r.URL.Path == "/v3/auth/tokens" {
+		var body struct {
+			Auth struct {
+				Identity struct {
+					Password struct {
+						User struct {
+							Password string `json:"password"`
+						} `json:"user"`
+					} `json:"password"`
+				} `json:"identity"`
+				Scope struct {
+					Project struct {
+						Name string `json:"name"`
+					} `json:"project"`
+				} `json:"scope"`
+			} `json:"auth"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Auth.Identity.Password.User.Password != testPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		projectName := body.Auth.Scope.Project.Name
+		resp := strings.NewReplacer(
+			"{{endpoint}}", s.URL,
+			"{{project_id}}", testProjectNameToID[projectName],
+			"{{project_name}}", projectName,
+		).Replace(string(data))
+		w.Header().Set("X-Subject-Token", testToken)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(resp))
+		return
+	}
+	if r.Header.Get("X-Auth-Token") != testToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Write(data)
+}
+
+func (s *fixtureServer) requested(substr string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, url := range s.urls {
+		if strings.Contains(url, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestOpenStack(url string, conf Config) *OpenStack {
+	config.CONF = &config.CloudConfig{}
+	conf.AuthURL = url + "/v3"
+	conf.Username = "admin"
+	if conf.Password == "" {
+		conf.Password = testPassword
+	}
+	conf.UserDomainName = DEFAULT_DOMAIN_NAME
+	conf.ProjectDomainName = DEFAULT_DOMAIN_NAME
+	conf.EndpointInterface = DEFAULT_ENDPOINT_INTERFACE
+	conf.RegionLcuuid = common.DEFAULT_REGION
+	domain := mysqlmodel.Domain{Name: "test_openstack", DisplayName: "test_openstack"}
+	return newOpenStack(mysqlcommon.DEFAULT_ORG_ID, domain, config.CloudConfig{HTTPTimeout: 5}, &conf)
+}
+
+func TestCheckAuth(t *testing.T) {
+	Convey("TestCheckAuth", t, func() {
+		server := newFixtureServer()
+		defer server.Close()
+
+		Convey("valid credential should pass", func() {
+			o := newTestOpenStack(server.URL, Config{ProjectName: "admin"})
+			So(o.CheckAuth(), ShouldBeNil)
+			So(o.token.projectID, ShouldEqual, "p-admin")
+			So(o.getRegions(o.token), ShouldResemble, []string{"RegionOne", "RegionTwo"})
+		})
+
+		Convey("invalid password should fail", func() {
+			o := newTestOpenStack(server.URL, Config{ProjectName: "admin", Password: "wrong"})
+			So(o.CheckAuth(), ShouldNotBeNil)
+		})
+
+		Convey("excluded regions should be skipped", func() {
+			o := newTestOpenStack(server.URL, Config{ProjectName: "admin", ExcludeRegions: map[string]bool{"RegionOne": true, "RegionTwo": true}})
+			So(o.CheckAuth(), ShouldNotBeNil)
+		})
+	})
+}
+
+func findVM(vms []model.VM, lcuuid string) model.VM {
+	for _, vm := range vms {
+		if vm.Lcuuid == lcuuid {
+			return vm
+		}
+	}
+	return model.VM{}
+}
+
+func TestGetResourceAllProjects(t *testing.T) {
+	Convey("TestGetResourceAllProjects", t, func() {
+		server := newFixtureServer()
+		defer server.Close()
+		o := newTestOpenStack(server.URL, Config{ProjectName: "admin", AllProjects: true})
+
+		resource, err := o.getResource()
+		So(err, ShouldBeNil)
+
+		Convey("resource number should be equal", func() {
+			So(len(resource.AZs), ShouldEqual, 2)
+			So(len(resource.Hosts), ShouldEqual, 2)
+			So(len(resource.VPCs), ShouldEqual, 2)
+			So(len(resource.Networks), ShouldEqual, 3)
+			So(len(resource.Subnets), ShouldEqual, 3)
+			So(len(resource.VMs), ShouldEqual, 4)
+			So(len(resource.VRouters), ShouldEqual, 1)
+			So(len(resource.RoutingTables), ShouldEqual, 1)
+			So(len(resource.DHCPPorts), ShouldEqual, 1)
+			So(len(resource.VInterfaces), ShouldEqual, 8)
+			So(len(resource.IPs), ShouldEqual, 8)
+			So(len(resource.FloatingIPs), ShouldEqual, 1)
+			So(len(resource.LBs), ShouldEqual, 1)
+			So(len(resource.LBListeners), ShouldEqual, 1)
+			So(len(resource.LBTargetServers), ShouldEqual, 2)
+		})
+
+		Convey("vms should be mapped", func() {
+			vm := findVM(resource.VMs, common.IDGenerateUUID(o.orgID, "vm-1"))
+			So(vm.State, ShouldEqual, common.VM_STATE_RUNNING)
+			So(vm.LaunchServer, ShouldEqual, "10.0.0.11")
+			So(vm.VPCLcuuid, ShouldEqual, o.getVPCLcuuid("p-demo"))
+			So(vm.CloudTags, ShouldResemble, map[string]string{"env": "test", "security_groups": "default,web"})
+			So(vm.CreatedAt.IsZero(), ShouldBeFalse)
+			So(findVM(resource.VMs, common.IDGenerateUUID(o.orgID, "vm-2")).State, ShouldEqual, common.VM_STATE_STOPPED)
+			So(findVM(resource.VMs, common.IDGenerateUUID(o.orgID, "vm-3")).State, ShouldEqual, common.VM_STATE_EXCEPTION)
+			So(findVM(resource.VMs, common.IDGenerateUUID(o.orgID, "vm-4")).LaunchServer, ShouldEqual, "10.0.1.11")
+		})
+
+		Convey("lb should be external with vm target server", func() {
+			So(resource.LBs[0].Model, ShouldEqual, cloudcommon.LB_MODEL_EXTERNAL)
+			So(resource.LBs[0].VIP, ShouldEqual, "192.168.10.20,172.24.4.101")
+			targetTypes := map[int]string{}
+			for _, ts := range resource.LBTargetServers {
+				targetTypes[ts.Type] = ts.VMLcuuid
+			}
+			So(targetTypes[common.LB_SERVER_TYPE_VM], ShouldEqual, common.IDGenerateUUID(o.orgID, "vm-1"))
+			So(targetTypes, ShouldContainKey, common.LB_SERVER_TYPE_IP)
+		})
+
+		Convey("admin apis should be requested", func() {
+			So(server.requested("/servers/detail?all_tenants=true"), ShouldBeTrue)
+			So(server.requested("/os-hypervisors/detail"), ShouldBeTrue)
+			So(server.requested("project_id="), ShouldBeFalse)
+		})
+	})
+}
+
+func TestGetResourceSingleProject(t *testing.T) {
+	Convey("TestGetResourceSingleProject", t, func() {
+		server := newFixtureServer()
+		defer server.Close()
+		o := newTestOpenStack(server.URL, Config{ProjectName: "demo", IncludeRegions: map[string]bool{"RegionTwo": true}})
+
+		resource, err := o.getResource()
+		So(err, ShouldBeNil)
+
+		Convey("only included region should be collected", func() {
+			So(len(resource.AZs), ShouldEqual, 1)
+			So(len(resource.Hosts), ShouldEqual, 0)
+			So(len(resource.VPCs), ShouldEqual, 1)
+			So(resource.VPCs[0].Name, ShouldEqual, "demo")
+			So(len(resource.Networks), ShouldEqual, 1)
+			So(len(resource.VMs), ShouldEqual, 1)
+			So(len(resource.VInterfaces), ShouldEqual, 1)
+			So(len(resource.LBs), ShouldEqual, 0)
+		})
+
+		Convey("project scoped apis should be requested", func() {
+			So(server.requested("/r1/"), ShouldBeFalse)
+			So(server.requested("/ports?project_id=p-demo"), ShouldBeTrue)
+			So(server.requested("all_tenants"), ShouldBeFalse)
+			So(server.requested("/v3/projects"), ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+const (
+	SERVICE_TYPE_IDENTITY      = "identity"
+	SERVICE_TYPE_COMPUTE       = "compute"
+	SERVICE_TYPE_NETWORK       = "network"
+	SERVICE_TYPE_LOAD_BALANCER = "load-balancer"
+)
+
+type Token struct {
+	token     string
+	expiresAt time.Time
+	projectID string
+	// region -> service type -> endpoint url
+	catalog map[string]map[string]string
+}
+
+// token 离失效时间不足 5m 时视为过期
+func (t *Token) isExpired() bool {
+	return time.Now().Add(5 * time.Minute).After(t.expiresAt)
+}
+
+func (o *OpenStack) getToken() (*Token, error) {
+	if o.token != nil && !o.token.isExpired() {
+		return o.token, nil
+	}
+	token, err := o.createToken()
+	if err != nil {
+		return nil, err
+	}
+	o.token = token
+	return token, nil
+}
+
+// keystone v3 密码认证，scope 为配置中的项目，响应中的 catalog 用于获取各区域的服务地址
+func (o *OpenStack) createToken() (*Token, error) {
+	authBody := map[string]interface{}{
+		"auth": map[string]interface{}{
+			"identity": map[string]interface{}{
+				"methods": []string{"password"},
+				"password": map[string]interface{}{
+					"user": map[string]interface{}{
+						"domain": map[string]interface{}{
+							"name": o.config.UserDomainName,
+						},
+						"name":     o.config.Username,
+						"password": o.config.Password,
+					},
+				},
+			},
+			"scope": map[string]interface{}{
+				"project": map[string]interface{}{
+					"domain": map[string]interface{}{
+						"name": o.config.ProjectDomainName,
+					},
+					"name": o.config.ProjectName,
+				},
+			},
+		},
+	}
+	resp, err := cloudcommon.RequestPost(o.config.AuthURL+"/auth/tokens", time.Duration(o.httpTimeout), authBody)
+	if err != nil {
+		return nil, err
+	}
+	token := &Token{
+		token:     resp.Get("X-Subject-Token").MustString(),
+		projectID: resp.Get("token").Get("project").Get("id").MustString(),
+		catalog:   make(map[string]map[string]string),
+	}
+	if token.token == "" {
+		return nil, errors.New("keystone response missing X-Subject-Token")
+	}
+	token.expiresAt, err = time.Parse(time.RFC3339, resp.Get("token").Get("expires_at").MustString())
+	if err != nil {
+		return nil, fmt.Errorf("parse token expires_at failed: %s", err.Error())
+	}
+
+	jCatalog := resp.Get("token").Get("catalog")
+	for i := range jCatalog.MustArray() {
+		jService := jCatalog.GetIndex(i)
+		serviceType := jService.Get("type").MustString()
+		jEndpoints := jService.Get("endpoints")
+		for j := range jEndpoints.MustArray() {
+			jEndpoint := jEndpoints.GetIndex(j)
+			if jEndpoint.Get("interface").MustString() != o.config.EndpointInterface {
+				continue
+			}
+			region := jEndpoint.Get("region_id").MustString()
+			if region == "" {
+				region = jEndpoint.Get("region").MustString()
+			}
+			if _, ok := token.catalog[region]; !ok {
+				token.catalog[region] = make(map[string]string)
+			}
+			token.catalog[region][serviceType] = jEndpoint.Get("url").MustString()
+		}
+	}
+	return token, nil
+}
+
+// getRegions 返回 catalog 中提供计算服务且满足区域白名单、黑名单的区域
+func (o *OpenStack) getRegions(token *Token) []string {
+	var regions []string
+	for region, endpoints := range token.catalog {
+		if _, ok := endpoints[SERVICE_TYPE_COMPUTE]; !ok {
+			continue
+		}
+		if len(o.config.IncludeRegions) > 0 {
+			if _, ok := o.config.IncludeRegions[region]; !ok {
+				log.Infof("exclude region: %s, not included", region, logger.NewORGPrefix(o.orgID))
+				continue
+			}
+		}
+		if _, ok := o.config.ExcludeRegions[region]; ok {
+			log.Infof("exclude region: %s", region, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
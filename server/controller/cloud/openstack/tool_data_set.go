@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+type Project struct {
+	id   string
+	name string
+}
+
+type Port struct {
+	id          string
+	name        string
+	mac         string
+	networkID   string
+	deviceID    string
+	deviceOwner string
+	projectID   string
+	fixedIPs    []FixedIP
+	sgIDs       []string
+}
+
+type FixedIP struct {
+	subnetID string
+	ip       string
+}
+
+type SubnetIPKey struct {
+	SubnetLcuuid string
+	IP           string
+}
+
+type ToolDataSet struct {
+	projectIDToProject map[string]Project
+	// 有资源引用的项目，仅为这些项目生成 vpc
+	usedProjectIDs map[string]bool
+
+	azNameToAZLcuuid     map[string]string
+	hostNameToIP         map[string]string
+	networkIDToVPCLcuuid map[string]string
+	networkIDToAZLcuuid  map[string]string
+	networkIDIsExternal  map[string]bool
+	subnetIDToNetworkID  map[string]string
+	sgIDToName           map[string]string
+	ports                []Port
+	portIDToPort         map[string]Port
+	deviceIDToPorts      map[string][]Port
+	vmIDs                map[string]bool
+	routerIDs            map[string]bool
+	keyToVMLcuuid        map[SubnetIPKey]string
+	portIDToFloatingIP   map[string]string
+
+	azLcuuidToResourceNum map[string]int
+}
+
+func NewToolDataSet() *ToolDataSet {
+	t := &ToolDataSet{
+		projectIDToProject:    make(map[string]Project),
+		usedProjectIDs:        make(map[string]bool),
+		azLcuuidToResourceNum: make(map[string]int),
+	}
+	t.resetRegion()
+	return t
+}
+
+// resetRegion 清理区域内的工具数据，项目与资源计数跨区域共享
+func (t *ToolDataSet) resetRegion() {
+	t.azNameToAZLcuuid = make(map[string]string)
+	t.hostNameToIP = make(map[string]string)
+	t.networkIDToVPCLcuuid = make(map[string]string)
+	t.networkIDToAZLcuuid = make(map[string]string)
+	t.networkIDIsExternal = make(map[string]bool)
+	t.subnetIDToNetworkID = make(map[string]string)
+	t.sgIDToName = make(map[string]string)
+	t.ports = []Port{}
+	t.portIDToPort = make(map[string]Port)
+	t.deviceIDToPorts = make(map[string][]Port)
+	t.vmIDs = make(map[string]bool)
+	t.routerIDs = make(map[string]bool)
+	t.keyToVMLcuuid = make(map[SubnetIPKey]string)
+	t.portIDToFloatingIP = make(map[string]string)
+}
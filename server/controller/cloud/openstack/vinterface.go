@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	"strings"
+
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+const (
+	DEVICE_OWNER_COMPUTE_PREFIX = "compute:"
+	DEVICE_OWNER_DHCP           = "network:dhcp"
+	DEVICE_OWNER_ROUTER_GATEWAY = "network:router_gateway"
+)
+
+var ROUTER_INTERFACE_DEVICE_OWNERS = map[string]bool{
+	"network:router_interface":               true,
+	"network:router_interface_distributed":   true,
+	"network:ha_router_replicated_interface": true,
+}
+
+func (o *OpenStack) getSecurityGroups(networkURL, token string) error {
+	log.Debug("get security groups starting", logger.NewORGPrefix(o.orgID))
+	jSGs, err := o.getRawData(o.projectFilter(networkURL+"/security-groups"), token, "security_groups", true)
+	if err != nil {
+		return err
+	}
+	for _, jSG := range jSGs {
+		if !cloudcommon.CheckJsonAttributes(jSG, []string{"id", "name"}) {
+			continue
+		}
+		o.toolDataSet.sgIDToName[jSG.Get("id").MustString()] = jSG.Get("name").MustString()
+	}
+	log.Debug("get security groups complete", logger.NewORGPrefix(o.orgID))
+	return nil
+}
+
+// getPorts 缓存 neutron 端口，供虚拟机、路由器、负载均衡器及浮动 IP 使用
+func (o *OpenStack) getPorts(networkURL, token string) error {
+	log.Debug("get ports starting", logger.NewORGPrefix(o.orgID))
+	jPorts, err := o.getRawData(o.projectFilter(networkURL+"/ports"), token, "ports", true)
+	if err != nil {
+		return err
+	}
+	for _, jPort := range jPorts {
+		if !cloudcommon.CheckJsonAttributes(jPort, []string{"id", "mac_address", "network_id", "fixed_ips"}) {
+			continue
+		}
+		port := Port{
+			id:          jPort.Get("id").MustString(),
+			name:        jPort.Get("name").MustString(),
+			mac:         jPort.Get("mac_address").MustString(),
+			networkID:   jPort.Get("network_id").MustString(),
+			deviceID:    jPort.Get("device_id").MustString(),
+			deviceOwner: jPort.Get("device_owner").MustString(),
+			projectID:   jPort.Get("project_id").MustString(),
+			sgIDs:       jPort.Get("security_groups").MustStringArray(),
+		}
+		jFixedIPs := jPort.Get("fixed_ips")
+		for i := range jFixedIPs.MustArray() {
+			jFixedIP := jFixedIPs.GetIndex(i)
+			port.fixedIPs = append(port.fixedIPs, FixedIP{
+				subnetID: jFixedIP.Get("subnet_id").MustString(),
+				ip:       jFixedIP.Get("ip_address").MustString(),
+			})
+		}
+		o.toolDataSet.ports = append(o.toolDataSet.ports, port)
+		o.toolDataSet.portIDToPort[port.id] = port
+		if port.deviceID != "" {
+			o.toolDataSet.deviceIDToPorts[port.deviceID] = append(o.toolDataSet.deviceIDToPorts[port.deviceID], port)
+		}
+	}
+	log.Debug("get ports complete", logger.NewORGPrefix(o.orgID))
+	return nil
+}
+
+// getVInterfacesAndIPs 将虚拟机、路由器、dhcp 的端口转换为接口和 IP，负载均衡器的 vip 端口在 getLBs 中处理
+func (o *OpenStack) getVInterfacesAndIPs() ([]model.DHCPPort, []model.VInterface, []model.IP) {
+	log.Debug("get vinterfaces starting", logger.NewORGPrefix(o.orgID))
+	var dhcpPorts []model.DHCPPort
+	var vifs []model.VInterface
+	var ips []model.IP
+	for _, port := range o.toolDataSet.ports {
+		vpcLcuuid, ok := o.toolDataSet.networkIDToVPCLcuuid[port.networkID]
+		if !ok {
+			log.Infof("exclude vinterface: %s, missing network info", port.id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		vifType := common.VIF_TYPE_LAN
+		if o.toolDataSet.networkIDIsExternal[port.networkID] {
+			vifType = common.VIF_TYPE_WAN
+		}
+
+		var deviceType int
+		var deviceLcuuid string
+		switch {
+		case strings.HasPrefix(port.deviceOwner, DEVICE_OWNER_COMPUTE_PREFIX):
+			if !o.toolDataSet.vmIDs[port.deviceID] {
+				continue
+			}
+			deviceType = common.VIF_DEVICE_TYPE_VM
+			deviceLcuuid = common.IDGenerateUUID(o.orgID, port.deviceID)
+		case ROUTER_INTERFACE_DEVICE_OWNERS[port.deviceOwner] || port.deviceOwner == DEVICE_OWNER_ROUTER_GATEWAY:
+			if !o.toolDataSet.routerIDs[port.deviceID] {
+				continue
+			}
+			deviceType = common.VIF_DEVICE_TYPE_VROUTER
+			deviceLcuuid = common.IDGenerateUUID(o.orgID, port.deviceID)
+		case port.deviceOwner == DEVICE_OWNER_DHCP:
+			deviceType = common.VIF_DEVICE_TYPE_DHCP_PORT
+			deviceLcuuid = common.IDGenerateUUID(o.orgID, port.id)
+			azLcuuid := o.toolDataSet.networkIDToAZLcuuid[port.networkID]
+			dhcpPorts = append(dhcpPorts, model.DHCPPort{
+				Lcuuid:       deviceLcuuid,
+				Name:         "dhcp-" + port.networkID,
+				VPCLcuuid:    vpcLcuuid,
+				AZLcuuid:     azLcuuid,
+				RegionLcuuid: o.config.RegionLcuuid,
+			})
+			o.toolDataSet.azLcuuidToResourceNum[azLcuuid]++
+		default:
+			continue
+		}
+
+		vif, vifIPs := o.formatVInterfaceAndIPs(port, vifType, deviceType, deviceLcuuid, vpcLcuuid)
+		vifs = append(vifs, vif)
+		ips = append(ips, vifIPs...)
+		if deviceType == common.VIF_DEVICE_TYPE_VM {
+			for _, ip := range vifIPs {
+				o.toolDataSet.keyToVMLcuuid[SubnetIPKey{SubnetLcuuid: ip.SubnetLcuuid, IP: ip.IP}] = deviceLcuuid
+			}
+		}
+	}
+	log.Debug("get vinterfaces complete", logger.NewORGPrefix(o.orgID))
+	return dhcpPorts, vifs, ips
+}
+
+func (o *OpenStack) formatVInterfaceAndIPs(port Port, vifType, deviceType int, deviceLcuuid, vpcLcuuid string) (model.VInterface, []model.IP) {
+	vif := model.VInterface{
+		Lcuuid:        common.IDGenerateUUID(o.orgID, port.id),
+		Name:          port.name,
+		Type:          vifType,
+		Mac:           port.mac,
+		DeviceLcuuid:  deviceLcuuid,
+		DeviceType:    deviceType,
+		NetworkLcuuid: common.IDGenerateUUID(o.orgID, port.networkID),
+		VPCLcuuid:     vpcLcuuid,
+		RegionLcuuid:  o.config.RegionLcuuid,
+	}
+	var ips []model.IP
+	for _, fixedIP := range port.fixedIPs {
+		if fixedIP.ip == "" {
+			continue
+		}
+		ips = append(ips, model.IP{
+			Lcuuid:           common.GenerateUUIDByOrgID(o.orgID, port.id+fixedIP.ip),
+			VInterfaceLcuuid: vif.Lcuuid,
+			IP:               fixedIP.ip,
+			SubnetLcuuid:     common.IDGenerateUUID(o.orgID, fixedIP.subnetID),
+			RegionLcuuid:     o.config.RegionLcuuid,
+		})
+	}
+	return vif, ips
+}
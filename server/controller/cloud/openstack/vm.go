@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+var STATE_CONVERTION = map[string]int{
+	"ACTIVE":            common.VM_STATE_RUNNING,
+	"SHUTOFF":           common.VM_STATE_STOPPED,
+	"PAUSED":            common.VM_STATE_STOPPED,
+	"SUSPENDED":         common.VM_STATE_STOPPED,
+	"SHELVED":           common.VM_STATE_STOPPED,
+	"SHELVED_OFFLOADED": common.VM_STATE_STOPPED,
+}
+
+func (o *OpenStack) getVMs(computeURL, token string) ([]model.VM, error) {
+	log.Debug("get vms starting", logger.NewORGPrefix(o.orgID))
+	var vms []model.VM
+
+	url := strings.TrimSuffix(computeURL, "/") + "/servers/detail"
+	if o.config.AllProjects {
+		url += "?all_tenants=true"
+	}
+	jVMs, err := o.getRawData(url, token, "servers", true)
+	if err != nil {
+		return nil, err
+	}
+	for _, jVM := range jVMs {
+		if !cloudcommon.CheckJsonAttributes(jVM, []string{"id", "name", "status", "tenant_id"}) {
+			continue
+		}
+		id := jVM.Get("id").MustString()
+		vpcLcuuid := o.getVPCLcuuid(jVM.Get("tenant_id").MustString())
+		if vpcLcuuid == "" {
+			log.Infof("exclude vm: %s, missing vpc info", id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		azLcuuid, ok := o.toolDataSet.azNameToAZLcuuid[jVM.Get("OS-EXT-AZ:availability_zone").MustString()]
+		if !ok {
+			log.Infof("exclude vm: %s, missing az info", id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		launchServer, ok := o.toolDataSet.hostNameToIP[jVM.Get("OS-EXT-SRV-ATTR:host").MustString()]
+		if !ok {
+			launchServer = o.toolDataSet.hostNameToIP[jVM.Get("OS-EXT-SRV-ATTR:hypervisor_hostname").MustString()]
+		}
+		state, ok := STATE_CONVERTION[jVM.Get("status").MustString()]
+		if !ok {
+			state = common.VM_STATE_EXCEPTION
+		}
+		name := jVM.Get("name").MustString()
+		vm := model.VM{
+			Lcuuid:       common.IDGenerateUUID(o.orgID, id),
+			Name:         name,
+			Label:        id,
+			HType:        common.VM_HTYPE_VM_C,
+			State:        state,
+			LaunchServer: launchServer,
+			VPCLcuuid:    vpcLcuuid,
+			AZLcuuid:     azLcuuid,
+			RegionLcuuid: o.config.RegionLcuuid,
+			CloudTags:    o.formatVMCloudTags(id, jVM.Get("metadata").MustMap()),
+		}
+		created := jVM.Get("created").MustString()
+		if created != "" {
+			createdAt, err := time.Parse(time.RFC3339, created)
+			if err != nil {
+				log.Errorf("parse created failed: %s", created, logger.NewORGPrefix(o.orgID))
+			} else {
+				vm.CreatedAt = createdAt
+			}
+		}
+		vms = append(vms, vm)
+		o.toolDataSet.vmIDs[id] = true
+		o.toolDataSet.azLcuuidToResourceNum[azLcuuid]++
+	}
+	log.Debug("get vms complete", logger.NewORGPrefix(o.orgID))
+	return vms, nil
+}
+
+// formatVMCloudTags 使用虚拟机 metadata 作为云标签，并附加端口关联的安全组名称
+func (o *OpenStack) formatVMCloudTags(vmID string, metadata map[string]interface{}) map[string]string {
+	tags := make(map[string]string)
+	for key, value := range metadata {
+		if v, ok := value.(string); ok {
+			tags[key] = v
+		}
+	}
+	sgNames := map[string]bool{}
+	for _, port := range o.toolDataSet.deviceIDToPorts[vmID] {
+		for _, sgID := range port.sgIDs {
+			if name, ok := o.toolDataSet.sgIDToName[sgID]; ok {
+				sgNames[name] = true
+			}
+		}
+	}
+	if len(sgNames) > 0 {
+		var names []string
+		for name := range sgNames {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		tags["security_groups"] = strings.Join(names, ",")
+	}
+	return tags
+}
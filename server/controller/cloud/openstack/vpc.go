@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	"sort"
+
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+// openstack 没有 vpc 概念，每个项目（租户）对应一个 vpc
+func (o *OpenStack) getProjects(token *Token) error {
+	if !o.config.AllProjects {
+		o.toolDataSet.projectIDToProject[token.projectID] = Project{id: token.projectID, name: o.config.ProjectName}
+		return nil
+	}
+
+	jProjects, err := o.getRawData(o.config.AuthURL+"/projects", token.token, "projects", false)
+	if err != nil {
+		return err
+	}
+	for _, jProject := range jProjects {
+		if !cloudcommon.CheckJsonAttributes(jProject, []string{"id", "name"}) {
+			continue
+		}
+		id := jProject.Get("id").MustString()
+		o.toolDataSet.projectIDToProject[id] = Project{id: id, name: jProject.Get("name").MustString()}
+	}
+	return nil
+}
+
+// getVPCLcuuid 返回项目对应的 vpc lcuuid，项目未知时返回空
+func (o *OpenStack) getVPCLcuuid(projectID string) string {
+	if _, ok := o.toolDataSet.projectIDToProject[projectID]; !ok {
+		return ""
+	}
+	o.toolDataSet.usedProjectIDs[projectID] = true
+	return common.GenerateUUIDByOrgID(o.orgID, projectID+"_"+o.lcuuidGenerate)
+}
+
+func (o *OpenStack) getVPCs() []model.VPC {
+	log.Debug("get vpcs starting", logger.NewORGPrefix(o.orgID))
+	var vpcs []model.VPC
+	for projectID := range o.toolDataSet.usedProjectIDs {
+		project := o.toolDataSet.projectIDToProject[projectID]
+		vpcs = append(vpcs, model.VPC{
+			Lcuuid:       o.getVPCLcuuid(projectID),
+			Name:         project.name,
+			Label:        project.id,
+			RegionLcuuid: o.config.RegionLcuuid,
+		})
+	}
+	sort.Slice(vpcs, func(i, j int) bool { return vpcs[i].Lcuuid < vpcs[j].Lcuuid })
+	log.Debug("get vpcs complete", logger.NewORGPrefix(o.orgID))
+	return vpcs
+}
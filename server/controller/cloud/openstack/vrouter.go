@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openstack
+
+import (
+	cloudcommon "github.com/deepflowio/deepflow/server/controller/cloud/common"
+	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+func (o *OpenStack) getVRouters(networkURL, token string) ([]model.VRouter, []model.RoutingTable, error) {
+	log.Debug("get vrouters starting", logger.NewORGPrefix(o.orgID))
+	var vrouters []model.VRouter
+	var routingTables []model.RoutingTable
+
+	jRouters, err := o.getRawData(o.projectFilter(networkURL+"/routers"), token, "routers", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, jRouter := range jRouters {
+		if !cloudcommon.CheckJsonAttributes(jRouter, []string{"id", "name"}) {
+			continue
+		}
+		id := jRouter.Get("id").MustString()
+		vpcLcuuid := o.getVPCLcuuid(jRouter.Get("project_id").MustString())
+		if vpcLcuuid == "" {
+			log.Infof("exclude vrouter: %s, missing vpc info", id, logger.NewORGPrefix(o.orgID))
+			continue
+		}
+		name := jRouter.Get("name").MustString()
+		if name == "" {
+			name = id
+		}
+		lcuuid := common.IDGenerateUUID(o.orgID, id)
+		vrouters = append(vrouters, model.VRouter{
+			Lcuuid:       lcuuid,
+			Name:         name,
+			Label:        id,
+			VPCLcuuid:    vpcLcuuid,
+			RegionLcuuid: o.config.RegionLcuuid,
+		})
+		o.toolDataSet.routerIDs[id] = true
+
+		jRoutes := jRouter.Get("routes")
+		for i := range jRoutes.MustArray() {
+			jRoute := jRoutes.GetIndex(i)
+			destination := jRoute.Get("destination").MustString()
+			nexthop := jRoute.Get("nexthop").MustString()
+			if destination == "" || nexthop == "" {
+				continue
+			}
+			routingTables = append(routingTables, model.RoutingTable{
+				Lcuuid:        common.GenerateUUIDByOrgID(o.orgID, lcuuid+destination+nexthop),
+				VRouterLcuuid: lcuuid,
+				Destination:   destination,
+				NexthopType:   common.ROUTING_TABLE_TYPE_IP,
+				Nexthop:       nexthop,
+			})
+		}
+	}
+	log.Debug("get vrouters complete", logger.NewORGPrefix(o.orgID))
+	return vrouters, routingTables, nil
+}
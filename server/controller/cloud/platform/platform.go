@@ -29,6 +29,7 @@ import (
 	"github.com/deepflowio/deepflow/server/controller/cloud/huawei"
 	"github.com/deepflowio/deepflow/server/controller/cloud/kubernetes"
 	"github.com/deepflowio/deepflow/server/controller/cloud/model"
+	"github.com/deepflowio/deepflow/server/controller/cloud/openstack"
 	"github.com/deepflowio/deepflow/server/controller/cloud/qingcloud"
 	"github.com/deepflowio/deepflow/server/controller/cloud/tencent"
 	"github.com/deepflowio/deepflow/server/controller/cloud/volcengine"
@@ -71,6 +72,8 @@ func NewPlatform(domain mysqlmodel.Domain, cfg config.CloudConfig, db *mysql.DB)
 		platform, err = filereader.NewFileReader(db.ORGID, domain)
 	case common.VOLCENGINE:
 		platform, err = volcengine.NewVolcEngine(db.ORGID, domain, cfg)
+	case common.OPENSTACK:
+		platform, err = openstack.NewOpenStack(db.ORGID, domain, cfg)
 	// TODO: other platform
 	default:
 		return nil, errors.New(fmt.Sprintf("domain type (%d) not supported", domain.Type))
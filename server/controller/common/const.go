@@ -649,6 +649,11 @@ const (
 	PLUGIN_TYPE_LUA  = 3
 )
 
+const (
+	PLUGIN_USER_AGENT  = 1
+	PLUGIN_USER_SERVER = 2
+)
+
 var (
 	PluginTypeName = map[int]string{
 		PLUGIN_TYPE_WASM: "wasm",
@@ -26,6 +26,7 @@ import (
 
 	shared_common "github.com/deepflowio/deepflow/server/common"
 	"github.com/deepflowio/deepflow/server/controller/common"
+	crd "github.com/deepflowio/deepflow/server/controller/crd/config"
 	"github.com/deepflowio/deepflow/server/controller/db/clickhouse"
 	mysql "github.com/deepflowio/deepflow/server/controller/db/mysql/config"
 	"github.com/deepflowio/deepflow/server/controller/db/redis"
@@ -99,6 +100,7 @@ type ControllerConfig struct {
 	TagRecorderCfg tagrecorder.TagRecorderConfig `yaml:"tagrecorder"`
 	PrometheusCfg  prometheus.Config             `yaml:"prometheus"`
 	HTTPCfg        http.Config                   `yaml:"http"`
+	CRDCfg         crd.CRDConfig                 `yaml:"crd"`
 }
 
 type Config struct {
@@ -106,6 +108,10 @@ type Config struct {
 }
 
 func (c *Config) Validate() error {
+	if c.ControllerConfig.CRDCfg.ResyncInterval <= 0 {
+		log.Warningf("invalid crd resync_interval: %d, use default: %d", c.ControllerConfig.CRDCfg.ResyncInterval, crd.DefaultResyncInterval)
+	}
+	c.ControllerConfig.CRDCfg.Validate()
 	return nil
 }
 
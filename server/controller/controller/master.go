@@ -23,6 +23,8 @@ import (
 
 	"github.com/deepflowio/deepflow/server/controller/common"
 	"github.com/deepflowio/deepflow/server/controller/config"
+	"github.com/deepflowio/deepflow/server/controller/crd"
+	crdhandler "github.com/deepflowio/deepflow/server/controller/crd/handler"
 	"github.com/deepflowio/deepflow/server/controller/db/mysql/migrator"
 	"github.com/deepflowio/deepflow/server/controller/election"
 	"github.com/deepflowio/deepflow/server/controller/http"
@@ -88,6 +90,7 @@ func checkAndStartMasterFunctions(
 	// - prometheus encoder
 	// - prometheus app label layout updater
 	// - http resource refresh task manager
+	// - crd syncer
 
 	// 从区域控制器无需判断是否为master controller
 	if !IsMasterRegion(cfg) {
@@ -107,6 +110,11 @@ func checkAndStartMasterFunctions(
 
 	httpService := http.GetSingleton()
 
+	var crdSyncer *crd.Syncer
+	if cfg.CRDCfg.Enabled {
+		crdSyncer = crd.NewSyncer(cfg.CRDCfg, cfg.Kubeconfig, crdhandler.GetHandlers(cfg))
+	}
+
 	var sCtx context.Context
 	var sCancel context.CancelFunc
 
@@ -169,6 +177,11 @@ func checkAndStartMasterFunctions(
 					httpService.TaskManager.Start(sCtx, cfg.FPermit, cfg.RedisCfg)
 					deletedORGChecker.Start(sCtx)
 				}
+
+				// 同步 DeepFlow CR
+				if crdSyncer != nil {
+					crdSyncer.Start(sCtx)
+				}
 			} else if thisIsMasterController {
 				thisIsMasterController = false
 				log.Infof("I am not the master controller anymore, new master controller is %s", newMasterController)
@@ -183,6 +196,7 @@ func checkAndStartMasterFunctions(
 				// stop http task mananger
 				// stop resource cleaner
 				// stop delete org checker
+				// stop crd syncer
 				if sCancel != nil {
 					sCancel()
 				}
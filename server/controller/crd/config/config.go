@@ -0,0 +1,31 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+const DefaultResyncInterval = 60 // unit: second
+
+type CRDConfig struct {
+	Enabled        bool `default:"false" yaml:"enabled"`
+	ResyncInterval int  `default:"60" yaml:"resync_interval"` // unit: second
+}
+
+// Validate 将无效的全量同步间隔重置为默认值，避免创建 ticker 时 panic
+func (c *CRDConfig) Validate() {
+	if c.ResyncInterval <= 0 {
+		c.ResyncInterval = DefaultResyncInterval
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crd
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+var log = logger.MustGetLogger("crd")
+
+const (
+	GROUP     = "deepflow.io"
+	VERSION   = "v1alpha1"
+	FINALIZER = "deepflow.io/finalizer"
+
+	KIND_AGENT_GROUP        = "DeepFlowAgentGroup"
+	KIND_AGENT_GROUP_CONFIG = "DeepFlowAgentGroupConfig"
+	KIND_DOMAIN             = "DeepFlowDomain"
+	KIND_DATA_SOURCE        = "DeepFlowDataSource"
+
+	CONDITION_TYPE_READY = "Ready"
+
+	CONDITION_REASON_SYNCED        = "Synced"
+	CONDITION_REASON_SYNC_FAILED   = "SyncFailed"
+	CONDITION_REASON_DELETE_FAILED = "DeleteFailed"
+)
+
+// Kinds 按依赖顺序排列，全量同步时先同步被依赖的资源，如采集器组先于采集器组配置
+var Kinds = []string{KIND_AGENT_GROUP, KIND_AGENT_GROUP_CONFIG, KIND_DOMAIN, KIND_DATA_SOURCE}
+
+var kindToResource = map[string]string{
+	KIND_AGENT_GROUP:        "deepflowagentgroups",
+	KIND_AGENT_GROUP_CONFIG: "deepflowagentgroupconfigs",
+	KIND_DOMAIN:             "deepflowdomains",
+	KIND_DATA_SOURCE:        "deepflowdatasources",
+}
+
+func GroupVersionResource(kind string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: GROUP, Version: VERSION, Resource: kindToResource[kind]}
+}
+
+// Object 为 CR 中与 DeepFlow 配置同步相关的部分
+type Object struct {
+	Kind string
+	Name string
+	Spec map[string]interface{}
+	// 上次同步成功时记录在 status 中的 lcuuid，首次同步时为空
+	Lcuuid string
+	// GetSecret 读取 Secret 中解码后的数据，用于避免在 CR 中保存明文凭证
+	GetSecret func(namespace, name string) (map[string]string, error)
+}
+
+// DecodeSpec 将 spec 解码为 handler 自定义的结构体
+func (o *Object) DecodeSpec(v interface{}) error {
+	b, err := json.Marshal(o.Spec)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Handler 负责将一种 CR 同步到 DeepFlow 的配置中
+type Handler interface {
+	// Apply 按名称创建或更新（接管）DeepFlow 中的对象，返回其 lcuuid
+	Apply(obj *Object) (string, error)
+	// Delete 删除 DeepFlow 中的对象，对象已不存在时返回 nil
+	Delete(obj *Object) error
+}
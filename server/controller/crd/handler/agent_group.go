@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/controller/config"
+	"github.com/deepflowio/deepflow/server/controller/crd"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql"
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	"github.com/deepflowio/deepflow/server/controller/http/service"
+	"github.com/deepflowio/deepflow/server/controller/model"
+)
+
+// AgentGroupSpec 以 metadata.name 作为采集器组名称
+type AgentGroupSpec struct {
+	// 采集器组 ID（g-xxxxxxxxxx），仅创建时生效
+	GroupID string `json:"groupID"`
+	// 仅创建时生效，默认为默认团队
+	TeamID int `json:"teamID"`
+	// 组内采集器名称，不指定时不管理组内采集器
+	Agents *[]string `json:"agents"`
+}
+
+type AgentGroup struct {
+	cfg      *config.ControllerConfig
+	userInfo *httpcommon.UserInfo
+}
+
+func (a *AgentGroup) Apply(obj *crd.Object) (string, error) {
+	var spec AgentGroupSpec
+	if err := obj.DecodeSpec(&spec); err != nil {
+		return "", err
+	}
+	var vtapLcuuids []string
+	if spec.Agents != nil {
+		var err error
+		if vtapLcuuids, err = getVTapLcuuidsByName(a.userInfo.ORGID, *spec.Agents); err != nil {
+			return "", err
+		}
+	}
+
+	agentGroup := service.NewAgentGroup(a.userInfo, a.cfg)
+	groups, err := agentGroup.Get(map[string]interface{}{"name": obj.Name})
+	if err != nil {
+		return "", err
+	}
+	if len(groups) > 1 {
+		return "", fmt.Errorf("duplicate agent group (name: %s)", obj.Name)
+	}
+	if len(groups) == 0 {
+		teamID := spec.TeamID
+		if teamID == 0 {
+			teamID = common.DEFAULT_TEAM_ID
+		}
+		group, err := agentGroup.Create(model.VtapGroupCreate{
+			Name:        obj.Name,
+			GroupID:     spec.GroupID,
+			TeamID:      teamID,
+			VtapLcuuids: vtapLcuuids,
+		})
+		return group.Lcuuid, err
+	}
+
+	group := groups[0]
+	if spec.GroupID != "" && spec.GroupID != group.ShortUUID {
+		return "", fmt.Errorf("agent group (%s) groupID is immutable: %s -> %s", obj.Name, group.ShortUUID, spec.GroupID)
+	}
+	if spec.Agents == nil {
+		return group.Lcuuid, nil
+	}
+	lcuuids := make([]interface{}, 0, len(vtapLcuuids))
+	for _, lcuuid := range vtapLcuuids {
+		lcuuids = append(lcuuids, lcuuid)
+	}
+	_, err = agentGroup.Update(group.Lcuuid, map[string]interface{}{"VTAP_LCUUIDS": lcuuids}, a.cfg)
+	return group.Lcuuid, err
+}
+
+func (a *AgentGroup) Delete(obj *crd.Object) error {
+	groups, err := service.NewAgentGroup(a.userInfo, a.cfg).Get(map[string]interface{}{"name": obj.Name})
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if obj.Lcuuid != "" && group.Lcuuid != obj.Lcuuid {
+			continue
+		}
+		if _, err := service.NewAgentGroup(a.userInfo, a.cfg).Delete(group.Lcuuid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getVTapLcuuidsByName(orgID int, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return []string{}, nil
+	}
+	db, err := mysql.GetDB(orgID)
+	if err != nil {
+		return nil, err
+	}
+	var vtaps []mysqlmodel.VTap
+	if err := db.Where("name IN (?)", names).Find(&vtaps).Error; err != nil {
+		return nil, err
+	}
+	nameToLcuuid := make(map[string]string, len(vtaps))
+	for _, vtap := range vtaps {
+		nameToLcuuid[vtap.Name] = vtap.Lcuuid
+	}
+	lcuuids := make([]string, 0, len(names))
+	for _, name := range names {
+		lcuuid, ok := nameToLcuuid[name]
+		if !ok {
+			return nil, fmt.Errorf("agent (%s) not found", name)
+		}
+		lcuuids = append(lcuuids, lcuuid)
+	}
+	return lcuuids, nil
+}
+
+// getAgentGroupByName 按名称查找采集器组，名称不存在或重复时返回错误
+func getAgentGroupByName(orgID int, name string) (*mysqlmodel.VTapGroup, error) {
+	db, err := mysql.GetDB(orgID)
+	if err != nil {
+		return nil, err
+	}
+	var groups []mysqlmodel.VTapGroup
+	if err := db.Where("name = ?", name).Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("agent group (%s) not found", name)
+	}
+	if len(groups) > 1 {
+		return nil, fmt.Errorf("duplicate agent group (name: %s)", name)
+	}
+	return &groups[0], nil
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/deepflowio/deepflow/server/agent_config"
+	"github.com/deepflowio/deepflow/server/controller/config"
+	"github.com/deepflowio/deepflow/server/controller/crd"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	"github.com/deepflowio/deepflow/server/controller/http/service"
+)
+
+type AgentGroupConfigSpec struct {
+	// 采集器组名称
+	AgentGroup string `json:"agentGroup"`
+	// 采集器组配置，格式与 deepflow-ctl agent-group-config 使用的 yaml 一致，不需要指定 vtap_group_id
+	Config map[string]interface{} `json:"config"`
+}
+
+type AgentGroupConfig struct {
+	cfg      *config.ControllerConfig
+	userInfo *httpcommon.UserInfo
+}
+
+func (a *AgentGroupConfig) Apply(obj *crd.Object) (string, error) {
+	var spec AgentGroupConfigSpec
+	if err := obj.DecodeSpec(&spec); err != nil {
+		return "", err
+	}
+	if spec.AgentGroup == "" {
+		return "", fmt.Errorf("agentGroup is required")
+	}
+	group, err := getAgentGroupByName(a.userInfo.ORGID, spec.AgentGroup)
+	if err != nil {
+		return "", err
+	}
+	groupConfig, err := decodeAgentGroupConfig(spec.Config)
+	if err != nil {
+		return "", err
+	}
+	groupConfig.VTapGroupID = &group.ShortUUID
+
+	lcuuid, err := getAgentGroupConfigLcuuid(a.userInfo.ORGID, group.Lcuuid)
+	if err != nil {
+		return "", err
+	}
+	if lcuuid != "" {
+		_, err = service.UpdateVTapGroupAdvancedConfig(a.userInfo.ORGID, lcuuid, groupConfig)
+		return lcuuid, err
+	}
+	if _, err = service.CreateVTapGroupAdvancedConfig(a.userInfo.ORGID, groupConfig); err != nil {
+		return "", err
+	}
+	return getAgentGroupConfigLcuuid(a.userInfo.ORGID, group.Lcuuid)
+}
+
+func (a *AgentGroupConfig) Delete(obj *crd.Object) error {
+	var spec AgentGroupConfigSpec
+	if err := obj.DecodeSpec(&spec); err != nil {
+		return err
+	}
+	db, err := mysql.GetDB(a.userInfo.ORGID)
+	if err != nil {
+		return err
+	}
+	// 采集器组已删除时其配置随之删除
+	group, err := getAgentGroupByName(a.userInfo.ORGID, spec.AgentGroup)
+	if err != nil {
+		if obj.Lcuuid != "" {
+			return db.Where("lcuuid = ?", obj.Lcuuid).Delete(&agent_config.AgentGroupConfigModel{}).Error
+		}
+		return nil
+	}
+	lcuuid, err := getAgentGroupConfigLcuuid(a.userInfo.ORGID, group.Lcuuid)
+	if err != nil || lcuuid == "" {
+		return err
+	}
+	_, err = service.DeleteVTapGroupConfigByFilter(a.userInfo.ORGID, map[string]string{"vtap_group_id": group.ShortUUID})
+	return err
+}
+
+// decodeAgentGroupConfig 校验并转换采集器组配置，未知字段返回错误
+func decodeAgentGroupConfig(config map[string]interface{}) (*agent_config.AgentGroupConfig, error) {
+	groupConfig := &agent_config.AgentGroupConfig{}
+	if len(config) == 0 {
+		return groupConfig, nil
+	}
+	b, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(b, groupConfig); err != nil {
+		return nil, fmt.Errorf("invalid agent group config: %s", err.Error())
+	}
+	return groupConfig, nil
+}
+
+func getAgentGroupConfigLcuuid(orgID int, groupLcuuid string) (string, error) {
+	db, err := mysql.GetDB(orgID)
+	if err != nil {
+		return "", err
+	}
+	var configs []agent_config.AgentGroupConfigModel
+	if err := db.Where("vtap_group_lcuuid = ?", groupLcuuid).Find(&configs).Error; err != nil {
+		return "", err
+	}
+	if len(configs) == 0 || configs[0].Lcuuid == nil {
+		return "", nil
+	}
+	return *configs[0].Lcuuid, nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/deepflowio/deepflow/server/controller/config"
+	"github.com/deepflowio/deepflow/server/controller/crd"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	"github.com/deepflowio/deepflow/server/controller/http/service"
+	"github.com/deepflowio/deepflow/server/controller/model"
+)
+
+// DataSourceSpec 以 dataTableCollection 和 interval 唯一确定数据源，可用于接管默认数据源以修改保留时长
+type DataSourceSpec struct {
+	// 默认为 metadata.name
+	DisplayName         string `json:"displayName"`
+	DataTableCollection string `json:"dataTableCollection"`
+	Interval            int    `json:"interval"`
+	RetentionTime       int    `json:"retentionTime"`
+	// 以下字段仅创建时生效，baseDataSource 为基础数据源的展示名称
+	BaseDataSource            string `json:"baseDataSource"`
+	SummableMetricsOperator   string `json:"summableMetricsOperator"`
+	UnSummableMetricsOperator string `json:"unsummableMetricsOperator"`
+}
+
+type DataSource struct {
+	cfg      *config.ControllerConfig
+	userInfo *httpcommon.UserInfo
+}
+
+func (d *DataSource) Apply(obj *crd.Object) (string, error) {
+	var spec DataSourceSpec
+	if err := obj.DecodeSpec(&spec); err != nil {
+		return "", err
+	}
+	if spec.DataTableCollection == "" || spec.Interval == 0 {
+		return "", fmt.Errorf("dataTableCollection and interval are required")
+	}
+	if spec.DisplayName == "" {
+		spec.DisplayName = obj.Name
+	}
+	dataSourceService := service.NewDataSource(d.userInfo, d.cfg)
+	dataSources, err := dataSourceService.GetDataSources(d.userInfo.ORGID, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for _, dataSource := range dataSources {
+		if dataSource.DataTableCollection != spec.DataTableCollection || dataSource.Interval != spec.Interval {
+			continue
+		}
+		dataSourceUpdate := model.DataSourceUpdate{}
+		if spec.RetentionTime != 0 && spec.RetentionTime != dataSource.RetentionTime {
+			dataSourceUpdate.RetentionTime = &spec.RetentionTime
+		}
+		// 默认数据源的名称不可修改
+		if !dataSource.IsDefault && spec.DisplayName != dataSource.DisplayName {
+			dataSourceUpdate.DisplayName = &spec.DisplayName
+		}
+		if dataSourceUpdate.RetentionTime == nil && dataSourceUpdate.DisplayName == nil {
+			return dataSource.Lcuuid, nil
+		}
+		_, err := dataSourceService.UpdateDataSource(d.userInfo.ORGID, dataSource.Lcuuid, dataSourceUpdate)
+		return dataSource.Lcuuid, err
+	}
+
+	baseDataSourceID := 0
+	for _, dataSource := range dataSources {
+		if dataSource.DisplayName == spec.BaseDataSource {
+			baseDataSourceID = dataSource.ID
+			break
+		}
+	}
+	if baseDataSourceID == 0 {
+		return "", fmt.Errorf("base data source (%s) not found", spec.BaseDataSource)
+	}
+	dataSource, err := dataSourceService.CreateDataSource(d.userInfo.ORGID, &model.DataSourceCreate{
+		DisplayName:               spec.DisplayName,
+		DataTableCollection:       spec.DataTableCollection,
+		BaseDataSourceID:          baseDataSourceID,
+		Interval:                  spec.Interval,
+		RetentionTime:             spec.RetentionTime,
+		SummableMetricsOperator:   spec.SummableMetricsOperator,
+		UnSummableMetricsOperator: spec.UnSummableMetricsOperator,
+	})
+	return dataSource.Lcuuid, err
+}
+
+func (d *DataSource) Delete(obj *crd.Object) error {
+	var spec DataSourceSpec
+	if err := obj.DecodeSpec(&spec); err != nil {
+		return err
+	}
+	dataSourceService := service.NewDataSource(d.userInfo, d.cfg)
+	dataSources, err := dataSourceService.GetDataSources(d.userInfo.ORGID, nil, nil)
+	if err != nil {
+		return err
+	}
+	for _, dataSource := range dataSources {
+		if dataSource.DataTableCollection != spec.DataTableCollection || dataSource.Interval != spec.Interval {
+			continue
+		}
+		// 默认数据源不可删除，删除 CR 时仅解除管理
+		if dataSource.IsDefault {
+			log.Infof("data source (%s) is default, skip deleting", dataSource.DisplayName)
+			return nil
+		}
+		_, err := dataSourceService.DeleteDataSource(d.userInfo.ORGID, dataSource.Lcuuid)
+		return err
+	}
+	return nil
+}
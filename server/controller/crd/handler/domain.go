@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/controller/config"
+	"github.com/deepflowio/deepflow/server/controller/crd"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql"
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	"github.com/deepflowio/deepflow/server/controller/http/service/resource"
+	"github.com/deepflowio/deepflow/server/controller/model"
+)
+
+// DomainSpec 以 metadata.name 作为云平台名称
+type DomainSpec struct {
+	// 云平台类型，与 API 中的 TYPE 一致，不可修改
+	Type int `json:"type"`
+	// 仅创建时生效，默认为默认团队
+	TeamID int `json:"teamID"`
+	// 仅创建时生效
+	ClusterID    string `json:"clusterID"`
+	IconID       *int   `json:"iconID"`
+	ControllerIP string `json:"controllerIP"`
+	// 不指定时不修改云平台的启用状态
+	Enabled *bool                  `json:"enabled"`
+	Config  map[string]interface{} `json:"config"`
+	// Secret 中的各 key 合并到 config 中并覆盖同名配置，用于保存 AK/SK、密码等凭证
+	SecretRef *SecretReference `json:"secretRef"`
+}
+
+type SecretReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type Domain struct {
+	cfg      *config.ControllerConfig
+	userInfo *httpcommon.UserInfo
+	store    domainStore
+}
+
+func newDomain(cfg *config.ControllerConfig, userInfo *httpcommon.UserInfo) *Domain {
+	return &Domain{cfg: cfg, userInfo: userInfo, store: &mysqlDomainStore{cfg: cfg, userInfo: userInfo}}
+}
+
+// domainStore 封装云平台的查询及 API 中的创建、更新逻辑
+type domainStore interface {
+	find(name string) ([]mysqlmodel.Domain, error)
+	create(domainCreate model.DomainCreate) (string, error)
+	update(lcuuid string, domainUpdate map[string]interface{}) error
+}
+
+type mysqlDomainStore struct {
+	cfg      *config.ControllerConfig
+	userInfo *httpcommon.UserInfo
+}
+
+func (s *mysqlDomainStore) find(name string) ([]mysqlmodel.Domain, error) {
+	db, err := mysql.GetDB(s.userInfo.ORGID)
+	if err != nil {
+		return nil, err
+	}
+	var domains []mysqlmodel.Domain
+	err = db.Where("binary name = ?", name).Find(&domains).Error
+	return domains, err
+}
+
+func (s *mysqlDomainStore) create(domainCreate model.DomainCreate) (string, error) {
+	db, err := mysql.GetDB(s.userInfo.ORGID)
+	if err != nil {
+		return "", err
+	}
+	domain, err := resource.CreateDomain(domainCreate, s.userInfo, db, s.cfg)
+	if err != nil {
+		return "", err
+	}
+	return domain.Lcuuid, nil
+}
+
+func (s *mysqlDomainStore) update(lcuuid string, domainUpdate map[string]interface{}) error {
+	db, err := mysql.GetDB(s.userInfo.ORGID)
+	if err != nil {
+		return err
+	}
+	_, err = resource.UpdateDomain(lcuuid, domainUpdate, s.userInfo, s.cfg, db)
+	return err
+}
+
+func (d *Domain) Apply(obj *crd.Object) (string, error) {
+	var spec DomainSpec
+	if err := obj.DecodeSpec(&spec); err != nil {
+		return "", err
+	}
+	if spec.Type == 0 {
+		return "", fmt.Errorf("type is required")
+	}
+	domainConfig, err := d.getConfig(obj, &spec)
+	if err != nil {
+		return "", err
+	}
+	domains, err := d.store.find(obj.Name)
+	if err != nil {
+		return "", err
+	}
+	if len(domains) > 1 {
+		return "", fmt.Errorf("duplicate domain (name: %s)", obj.Name)
+	}
+
+	if len(domains) == 0 {
+		domainCreate := model.DomainCreate{
+			Name:                obj.Name,
+			Type:                spec.Type,
+			TeamID:              spec.TeamID,
+			KubernetesClusterID: spec.ClusterID,
+			ControllerIP:        spec.ControllerIP,
+			Config:              domainConfig,
+		}
+		if domainCreate.TeamID == 0 {
+			domainCreate.TeamID = common.DEFAULT_TEAM_ID
+		}
+		if spec.IconID != nil {
+			domainCreate.IconID = *spec.IconID
+		} else {
+			domainCreate.IconID = common.DomainTypeToIconID[spec.Type]
+		}
+		lcuuid, err := d.store.create(domainCreate)
+		if err != nil {
+			return "", err
+		}
+		if spec.Enabled != nil && !*spec.Enabled {
+			err = d.store.update(lcuuid, map[string]interface{}{"ENABLED": common.DOMAIN_ENABLED_FALSE})
+		}
+		return lcuuid, err
+	}
+
+	domain := domains[0]
+	if domain.Type != spec.Type {
+		return "", fmt.Errorf("domain (%s) type is immutable: %d -> %d", obj.Name, domain.Type, spec.Type)
+	}
+	if spec.ClusterID != "" && domain.ClusterID != spec.ClusterID {
+		return "", fmt.Errorf("domain (%s) clusterID is immutable: %s -> %s", obj.Name, domain.ClusterID, spec.ClusterID)
+	}
+	domainUpdate := map[string]interface{}{}
+	if domainConfig != nil {
+		domainUpdate["CONFIG"] = domainConfig
+	}
+	if spec.ControllerIP != "" {
+		domainUpdate["CONTROLLER_IP"] = spec.ControllerIP
+	}
+	if spec.IconID != nil {
+		domainUpdate["ICON_ID"] = *spec.IconID
+	}
+	if spec.Enabled != nil {
+		if *spec.Enabled {
+			domainUpdate["ENABLED"] = common.DOMAIN_ENABLED_TRUE
+		} else {
+			domainUpdate["ENABLED"] = common.DOMAIN_ENABLED_FALSE
+		}
+	}
+	if len(domainUpdate) == 0 {
+		return domain.Lcuuid, nil
+	}
+	return domain.Lcuuid, d.store.update(domain.Lcuuid, domainUpdate)
+}
+
+// getConfig 返回合并 secretRef 后的云平台配置
+func (d *Domain) getConfig(obj *crd.Object, spec *DomainSpec) (map[string]interface{}, error) {
+	if spec.SecretRef == nil {
+		return spec.Config, nil
+	}
+	if spec.SecretRef.Namespace == "" || spec.SecretRef.Name == "" {
+		return nil, fmt.Errorf("namespace and name of secretRef are required")
+	}
+	if obj.GetSecret == nil {
+		return nil, fmt.Errorf("secret (%s/%s) is not readable", spec.SecretRef.Namespace, spec.SecretRef.Name)
+	}
+	secret, err := obj.GetSecret(spec.SecretRef.Namespace, spec.SecretRef.Name)
+	if err != nil {
+		return nil, fmt.Errorf("get secret (%s/%s) failed: %s", spec.SecretRef.Namespace, spec.SecretRef.Name, err.Error())
+	}
+	domainConfig := make(map[string]interface{}, len(spec.Config)+len(secret))
+	for k, v := range spec.Config {
+		domainConfig[k] = v
+	}
+	for k, v := range secret {
+		domainConfig[k] = v
+	}
+	return domainConfig, nil
+}
+
+func (d *Domain) Delete(obj *crd.Object) error {
+	db, err := mysql.GetDB(d.userInfo.ORGID)
+	if err != nil {
+		return err
+	}
+	nameOrUUID := obj.Lcuuid
+	if nameOrUUID == "" {
+		nameOrUUID = obj.Name
+	}
+	var count int64
+	db.Model(&mysqlmodel.Domain{}).Where("lcuuid = ? OR binary name = ?", nameOrUUID, nameOrUUID).Count(&count)
+	if count == 0 {
+		return nil
+	}
+	_, err = resource.DeleteDomainByNameOrUUID(nameOrUUID, db, d.userInfo, d.cfg)
+	return err
+}
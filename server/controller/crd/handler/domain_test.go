@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/controller/crd"
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+	"github.com/deepflowio/deepflow/server/controller/model"
+)
+
+type fakeDomainStore struct {
+	domains []mysqlmodel.Domain
+	created []model.DomainCreate
+	updated []map[string]interface{}
+}
+
+func (s *fakeDomainStore) find(name string) ([]mysqlmodel.Domain, error) {
+	var domains []mysqlmodel.Domain
+	for _, domain := range s.domains {
+		if domain.Name == name {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+func (s *fakeDomainStore) create(domainCreate model.DomainCreate) (string, error) {
+	s.created = append(s.created, domainCreate)
+	lcuuid := fmt.Sprintf("lcuuid-%d", len(s.domains)+1)
+	s.domains = append(s.domains, mysqlmodel.Domain{
+		Base: mysqlmodel.Base{Lcuuid: lcuuid}, Name: domainCreate.Name, Type: domainCreate.Type,
+		ClusterID: domainCreate.KubernetesClusterID,
+	})
+	return lcuuid, nil
+}
+
+func (s *fakeDomainStore) update(lcuuid string, domainUpdate map[string]interface{}) error {
+	s.updated = append(s.updated, domainUpdate)
+	return nil
+}
+
+func newDomainObject(spec map[string]interface{}, secret map[string]string) *crd.Object {
+	return &crd.Object{
+		Kind: crd.KIND_DOMAIN,
+		Name: "aliyun",
+		Spec: spec,
+		GetSecret: func(namespace, name string) (map[string]string, error) {
+			if namespace != "deepflow" || name != "aliyun" {
+				return nil, fmt.Errorf("secret %s/%s not found", namespace, name)
+			}
+			return secret, nil
+		},
+	}
+}
+
+func TestDomainApply(t *testing.T) {
+	store := &fakeDomainStore{}
+	d := &Domain{store: store}
+	spec := map[string]interface{}{
+		"type":      int64(common.ALIYUN),
+		"enabled":   false,
+		"config":    map[string]interface{}{"region_uuid": "r-1", "secret_key": "plaintext"},
+		"secretRef": map[string]interface{}{"namespace": "deepflow", "name": "aliyun"},
+	}
+	obj := newDomainObject(spec, map[string]string{"secret_id": "ak", "secret_key": "sk"})
+
+	// 创建时合并 secret，并按 enabled 禁用云平台
+	lcuuid, err := d.Apply(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lcuuid != "lcuuid-1" || len(store.created) != 1 {
+		t.Fatalf("lcuuid = %s, created = %v", lcuuid, store.created)
+	}
+	created := store.created[0]
+	wantConfig := map[string]interface{}{"region_uuid": "r-1", "secret_id": "ak", "secret_key": "sk"}
+	if created.Name != "aliyun" || created.TeamID != common.DEFAULT_TEAM_ID ||
+		created.IconID != common.DomainTypeToIconID[common.ALIYUN] || !reflect.DeepEqual(created.Config, wantConfig) {
+		t.Errorf("unexpected domain create: %+v", created)
+	}
+	if len(store.updated) != 1 || store.updated[0]["ENABLED"] != common.DOMAIN_ENABLED_FALSE {
+		t.Errorf("unexpected domain update: %v", store.updated)
+	}
+
+	// 已存在同名云平台时更新
+	spec["enabled"] = true
+	spec["controllerIP"] = "10.1.1.1"
+	if lcuuid, err = d.Apply(obj); err != nil {
+		t.Fatal(err)
+	}
+	if lcuuid != "lcuuid-1" || len(store.created) != 1 || len(store.updated) != 2 {
+		t.Fatalf("lcuuid = %s, created = %v, updated = %v", lcuuid, store.created, store.updated)
+	}
+	wantUpdate := map[string]interface{}{
+		"CONFIG":        wantConfig,
+		"CONTROLLER_IP": "10.1.1.1",
+		"ENABLED":       common.DOMAIN_ENABLED_TRUE,
+	}
+	if !reflect.DeepEqual(store.updated[1], wantUpdate) {
+		t.Errorf("domain update = %v, want %v", store.updated[1], wantUpdate)
+	}
+
+	// 类型不可修改
+	spec["type"] = int64(common.ALIYUN + 1)
+	if _, err = d.Apply(obj); err == nil {
+		t.Errorf("type should be immutable")
+	}
+	if len(store.updated) != 2 {
+		t.Errorf("domain should not be updated if the type is changed")
+	}
+}
+
+func TestDomainApplySecretNotFound(t *testing.T) {
+	store := &fakeDomainStore{}
+	d := &Domain{store: store}
+	obj := newDomainObject(map[string]interface{}{
+		"type":      int64(common.ALIYUN),
+		"secretRef": map[string]interface{}{"namespace": "deepflow", "name": "not-exist"},
+	}, nil)
+	if _, err := d.Apply(obj); err == nil {
+		t.Errorf("domain should not be synced without the secret")
+	}
+	if len(store.created) != 0 {
+		t.Errorf("domain should not be created without the secret")
+	}
+}
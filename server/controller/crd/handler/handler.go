@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/controller/config"
+	"github.com/deepflowio/deepflow/server/controller/crd"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	"github.com/deepflowio/deepflow/server/libs/logger"
+)
+
+var log = logger.MustGetLogger("crd.handler")
+
+// GetHandlers 返回各 CR 对应的 handler，CR 为集群级资源，统一同步到默认组织
+func GetHandlers(cfg *config.ControllerConfig) map[string]crd.Handler {
+	userInfo := httpcommon.NewUserInfo(common.USER_TYPE_SUPER_ADMIN, common.USER_ID_SUPER_ADMIN, common.DEFAULT_ORG_ID)
+	return map[string]crd.Handler{
+		crd.KIND_AGENT_GROUP:        &AgentGroup{cfg: cfg, userInfo: userInfo},
+		crd.KIND_AGENT_GROUP_CONFIG: &AgentGroupConfig{cfg: cfg, userInfo: userInfo},
+		crd.KIND_DOMAIN:             newDomain(cfg, userInfo),
+		crd.KIND_DATA_SOURCE:        &DataSource{cfg: cfg, userInfo: userInfo},
+	}
+}
@@ -0,0 +1,339 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/deepflowio/deepflow/server/controller/crd/config"
+)
+
+const (
+	queueLen           = 1024
+	watchRetryInterval = 10 * time.Second
+)
+
+var secretGVR = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+type objectKey struct {
+	kind string
+	name string
+}
+
+// Syncer 由 master controller 启动，监听 DeepFlow CR 并同步到采集器组、采集器组配置、云平台及数据源，
+// 同步结果写回 CR 的 status
+type Syncer struct {
+	cfg        config.CRDConfig
+	kubeconfig string
+	client     dynamic.Interface
+	handlers   map[string]Handler
+	queue      chan objectKey
+	now        func() time.Time
+}
+
+func NewSyncer(cfg config.CRDConfig, kubeconfig string, handlers map[string]Handler) *Syncer {
+	cfg.Validate()
+	return &Syncer{
+		cfg:        cfg,
+		kubeconfig: kubeconfig,
+		handlers:   handlers,
+		queue:      make(chan objectKey, queueLen),
+		now:        time.Now,
+	}
+}
+
+func newSyncerWithClient(cfg config.CRDConfig, client dynamic.Interface, handlers map[string]Handler) *Syncer {
+	s := NewSyncer(cfg, "", handlers)
+	s.client = client
+	return s
+}
+
+func buildClient(kubeconfig string) (dynamic.Interface, error) {
+	var restCfg *rest.Config
+	var err error
+	if kubeconfig != "" {
+		restCfg, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		restCfg, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(restCfg)
+}
+
+func (s *Syncer) Start(sCtx context.Context) {
+	if s.client == nil {
+		client, err := buildClient(s.kubeconfig)
+		if err != nil {
+			log.Errorf("build kubernetes client failed: %s", err.Error())
+			return
+		}
+		s.client = client
+	}
+	log.Info("crd syncer started")
+
+	go s.work(sCtx)
+	for _, kind := range Kinds {
+		if _, ok := s.handlers[kind]; ok {
+			go s.watch(sCtx, kind)
+		}
+	}
+	go func() {
+		s.resync(sCtx)
+		ticker := time.NewTicker(time.Duration(s.cfg.ResyncInterval) * time.Second)
+		defer ticker.Stop()
+	LOOP:
+		for {
+			select {
+			case <-ticker.C:
+				s.resync(sCtx)
+			case <-sCtx.Done():
+				break LOOP
+			}
+		}
+		log.Info("crd syncer stopped")
+	}()
+}
+
+func (s *Syncer) enqueue(kind, name string) {
+	select {
+	case s.queue <- objectKey{kind: kind, name: name}:
+	default:
+		// 队列已满时丢弃，由下次全量同步补偿
+		log.Warningf("crd sync queue is full, drop %s (%s)", kind, name)
+	}
+}
+
+func (s *Syncer) work(sCtx context.Context) {
+	for {
+		select {
+		case key := <-s.queue:
+			s.reconcile(sCtx, key.kind, key.name, false)
+		case <-sCtx.Done():
+			return
+		}
+	}
+}
+
+func (s *Syncer) watch(sCtx context.Context, kind string) {
+	gvr := GroupVersionResource(kind)
+	for {
+		w, err := s.client.Resource(gvr).Watch(sCtx, metav1.ListOptions{})
+		if err != nil {
+			log.Errorf("watch %s failed: %s", kind, err.Error())
+		} else {
+			for event := range w.ResultChan() {
+				if event.Type == watch.Error {
+					continue
+				}
+				if u, ok := event.Object.(*unstructured.Unstructured); ok {
+					s.enqueue(kind, u.GetName())
+				}
+			}
+			w.Stop()
+		}
+		select {
+		case <-sCtx.Done():
+			return
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+// resync 按依赖顺序同步全部 CR，用于补偿丢失的 watch 事件、重试失败的同步，
+// 并覆盖通过 API 或 deepflow-ctl 对 CR 所管理对象的修改
+func (s *Syncer) resync(sCtx context.Context) {
+	for _, kind := range Kinds {
+		if _, ok := s.handlers[kind]; !ok {
+			continue
+		}
+		list, err := s.client.Resource(GroupVersionResource(kind)).List(sCtx, metav1.ListOptions{})
+		if err != nil {
+			log.Errorf("list %s failed: %s", kind, err.Error())
+			continue
+		}
+		for i := range list.Items {
+			s.reconcile(sCtx, kind, list.Items[i].GetName(), true)
+		}
+	}
+}
+
+// reconcile 在 force 为 false 时跳过已同步的 generation，仅用于 watch 事件触发的同步
+func (s *Syncer) reconcile(ctx context.Context, kind, name string, force bool) {
+	handler, ok := s.handlers[kind]
+	if !ok {
+		return
+	}
+	resource := s.client.Resource(GroupVersionResource(kind))
+	u, err := resource.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Errorf("get %s (%s) failed: %s", kind, name, err.Error())
+		}
+		return
+	}
+	obj := &Object{Kind: kind, Name: name, GetSecret: s.getSecret}
+	obj.Spec, _, _ = unstructured.NestedMap(u.Object, "spec")
+	obj.Lcuuid, _, _ = unstructured.NestedString(u.Object, "status", "lcuuid")
+
+	if u.GetDeletionTimestamp() != nil {
+		if !hasFinalizer(u) {
+			return
+		}
+		if err := handler.Delete(obj); err != nil {
+			log.Errorf("delete %s (%s) failed: %s", kind, name, err.Error())
+			s.updateStatus(ctx, u, obj.Lcuuid, CONDITION_REASON_DELETE_FAILED, err)
+			return
+		}
+		log.Infof("delete %s (%s) lcuuid: %s", kind, name, obj.Lcuuid)
+		removeFinalizer(u)
+		if _, err := resource.Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+			log.Errorf("remove finalizer of %s (%s) failed: %s", kind, name, err.Error())
+		}
+		return
+	}
+
+	if !hasFinalizer(u) {
+		u.SetFinalizers(append(u.GetFinalizers(), FINALIZER))
+		if u, err = resource.Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+			log.Errorf("add finalizer to %s (%s) failed: %s", kind, name, err.Error())
+			return
+		}
+	}
+	if !force && isSynced(u) {
+		return
+	}
+	lcuuid, err := handler.Apply(obj)
+	if err != nil {
+		log.Errorf("sync %s (%s) failed: %s", kind, name, err.Error())
+		s.updateStatus(ctx, u, obj.Lcuuid, CONDITION_REASON_SYNC_FAILED, err)
+		return
+	}
+	log.Infof("sync %s (%s) lcuuid: %s", kind, name, lcuuid)
+	s.updateStatus(ctx, u, lcuuid, CONDITION_REASON_SYNCED, nil)
+}
+
+// isSynced 当前 generation 已同步成功时无需再次同步
+func isSynced(u *unstructured.Unstructured) bool {
+	observed, _, _ := unstructured.NestedInt64(u.Object, "status", "observedGeneration")
+	if observed != u.GetGeneration() {
+		return false
+	}
+	condition := getCondition(u, CONDITION_TYPE_READY)
+	return condition != nil && condition["status"] == string(metav1.ConditionTrue)
+}
+
+// getSecret 读取 Secret 的 data 并做 base64 解码
+func (s *Syncer) getSecret(namespace, name string) (map[string]string, error) {
+	u, err := s.client.Resource(secretGVR).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := unstructured.NestedStringMap(u.Object, "data")
+	if err != nil {
+		return nil, err
+	}
+	secret := make(map[string]string, len(data))
+	for k, v := range data {
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("decode key %s of secret %s/%s failed: %s", k, namespace, name, err.Error())
+		}
+		secret[k] = string(b)
+	}
+	return secret, nil
+}
+
+func (s *Syncer) updateStatus(ctx context.Context, u *unstructured.Unstructured, lcuuid, reason string, syncErr error) {
+	status := string(metav1.ConditionTrue)
+	message := ""
+	if syncErr != nil {
+		status = string(metav1.ConditionFalse)
+		message = syncErr.Error()
+	}
+	now := s.now().UTC().Format(time.RFC3339)
+	transitionTime := now
+	if old := getCondition(u, CONDITION_TYPE_READY); old != nil && old["status"] == status {
+		if t, ok := old["lastTransitionTime"].(string); ok {
+			transitionTime = t
+		}
+	}
+	condition := map[string]interface{}{
+		"type":               CONDITION_TYPE_READY,
+		"status":             status,
+		"reason":             reason,
+		"message":            message,
+		"lastTransitionTime": transitionTime,
+	}
+	u = u.DeepCopy()
+	statusMap := map[string]interface{}{
+		"observedGeneration": u.GetGeneration(),
+		"lcuuid":             lcuuid,
+		"lastSyncTime":       now,
+		"conditions":         []interface{}{condition},
+	}
+	if err := unstructured.SetNestedMap(u.Object, statusMap, "status"); err != nil {
+		log.Errorf("set status of %s (%s) failed: %s", u.GetKind(), u.GetName(), err.Error())
+		return
+	}
+	gvr := GroupVersionResource(u.GetKind())
+	if _, err := s.client.Resource(gvr).UpdateStatus(ctx, u, metav1.UpdateOptions{}); err != nil {
+		log.Errorf("update status of %s (%s) failed: %s", u.GetKind(), u.GetName(), err.Error())
+	}
+}
+
+func getCondition(u *unstructured.Unstructured, conditionType string) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok && m["type"] == conditionType {
+			return m
+		}
+	}
+	return nil
+}
+
+func hasFinalizer(u *unstructured.Unstructured) bool {
+	for _, f := range u.GetFinalizers() {
+		if f == FINALIZER {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(u *unstructured.Unstructured) {
+	var finalizers []string
+	for _, f := range u.GetFinalizers() {
+		if f != FINALIZER {
+			finalizers = append(finalizers, f)
+		}
+	}
+	u.SetFinalizers(finalizers)
+}
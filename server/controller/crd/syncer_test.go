@@ -0,0 +1,215 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crd
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/deepflowio/deepflow/server/controller/crd/config"
+)
+
+type fakeHandler struct {
+	applied  []string
+	deleted  []string
+	applyErr error
+	lcuuid   string
+}
+
+func (h *fakeHandler) Apply(obj *Object) (string, error) {
+	h.applied = append(h.applied, obj.Name)
+	if h.applyErr != nil {
+		return "", h.applyErr
+	}
+	return h.lcuuid, nil
+}
+
+func (h *fakeHandler) Delete(obj *Object) error {
+	h.deleted = append(h.deleted, obj.Name+"/"+obj.Lcuuid)
+	return nil
+}
+
+func newObject(kind, name string, generation int64, spec map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	u.SetAPIVersion(GROUP + "/" + VERSION)
+	u.SetKind(kind)
+	u.SetName(name)
+	u.SetGeneration(generation)
+	return u
+}
+
+func newTestSyncer(handler Handler, objects ...runtime.Object) *Syncer {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, kind := range Kinds {
+		listKinds[GroupVersionResource(kind)] = kind + "List"
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	s := newSyncerWithClient(config.CRDConfig{ResyncInterval: 60}, client, map[string]Handler{KIND_AGENT_GROUP: handler})
+	s.now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func (s *Syncer) get(t *testing.T, kind, name string) *unstructured.Unstructured {
+	u, err := s.client.Resource(GroupVersionResource(kind)).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get %s (%s) failed: %v", kind, name, err)
+	}
+	return u
+}
+
+func TestReconcileApply(t *testing.T) {
+	h := &fakeHandler{lcuuid: "lcuuid-1"}
+	s := newTestSyncer(h, newObject(KIND_AGENT_GROUP, "g1", 1, map[string]interface{}{"groupID": "g-1"}))
+	ctx := context.Background()
+
+	s.resync(ctx)
+	u := s.get(t, KIND_AGENT_GROUP, "g1")
+	if !hasFinalizer(u) {
+		t.Errorf("finalizer not added: %v", u.GetFinalizers())
+	}
+	if lcuuid, _, _ := unstructured.NestedString(u.Object, "status", "lcuuid"); lcuuid != "lcuuid-1" {
+		t.Errorf("status.lcuuid = %q", lcuuid)
+	}
+	condition := getCondition(u, CONDITION_TYPE_READY)
+	if condition == nil || condition["status"] != "True" || condition["reason"] != CONDITION_REASON_SYNCED {
+		t.Errorf("unexpected condition: %v", condition)
+	}
+
+	// watch 事件不再重复同步已同步的 generation
+	s.reconcile(ctx, KIND_AGENT_GROUP, "g1", false)
+	if len(h.applied) != 1 {
+		t.Errorf("applied %d times, want 1", len(h.applied))
+	}
+
+	// 全量同步时重新同步，以覆盖通过 API 的修改
+	s.resync(ctx)
+	if len(h.applied) != 2 {
+		t.Errorf("applied %d times, want 2", len(h.applied))
+	}
+
+	// spec 变化后重新同步
+	u = s.get(t, KIND_AGENT_GROUP, "g1")
+	u.SetGeneration(2)
+	if _, err := s.client.Resource(GroupVersionResource(KIND_AGENT_GROUP)).Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	s.reconcile(ctx, KIND_AGENT_GROUP, "g1", false)
+	if len(h.applied) != 3 {
+		t.Errorf("applied %d times, want 3", len(h.applied))
+	}
+}
+
+func TestReconcileApplyFailed(t *testing.T) {
+	h := &fakeHandler{applyErr: errors.New("vtap_group count exceeds")}
+	s := newTestSyncer(h, newObject(KIND_AGENT_GROUP, "g1", 1, nil))
+	ctx := context.Background()
+
+	s.reconcile(ctx, KIND_AGENT_GROUP, "g1", false)
+	condition := getCondition(s.get(t, KIND_AGENT_GROUP, "g1"), CONDITION_TYPE_READY)
+	if condition == nil || condition["status"] != "False" || condition["reason"] != CONDITION_REASON_SYNC_FAILED ||
+		condition["message"] != "vtap_group count exceeds" {
+		t.Errorf("unexpected condition: %v", condition)
+	}
+
+	// 失败的对象在下次同步时重试，恢复后 lastTransitionTime 更新
+	h.applyErr = nil
+	s.now = func() time.Time { return time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC) }
+	s.reconcile(ctx, KIND_AGENT_GROUP, "g1", false)
+	condition = getCondition(s.get(t, KIND_AGENT_GROUP, "g1"), CONDITION_TYPE_READY)
+	if condition == nil || condition["status"] != "True" || condition["lastTransitionTime"] != "2024-01-01T00:01:00Z" {
+		t.Errorf("unexpected condition: %v", condition)
+	}
+	if len(h.applied) != 2 {
+		t.Errorf("applied %d times, want 2", len(h.applied))
+	}
+}
+
+func TestReconcileDelete(t *testing.T) {
+	h := &fakeHandler{}
+	u := newObject(KIND_AGENT_GROUP, "g1", 1, nil)
+	u.SetFinalizers([]string{FINALIZER, "other"})
+	deletedAt := metav1.NewTime(time.Now())
+	u.SetDeletionTimestamp(&deletedAt)
+	unstructured.SetNestedField(u.Object, "lcuuid-1", "status", "lcuuid")
+	s := newTestSyncer(h, u)
+
+	s.reconcile(context.Background(), KIND_AGENT_GROUP, "g1", false)
+	if len(h.deleted) != 1 || h.deleted[0] != "g1/lcuuid-1" {
+		t.Errorf("deleted = %v", h.deleted)
+	}
+	if finalizers := s.get(t, KIND_AGENT_GROUP, "g1").GetFinalizers(); len(finalizers) != 1 || finalizers[0] != "other" {
+		t.Errorf("finalizers = %v", finalizers)
+	}
+	if len(h.applied) != 0 {
+		t.Errorf("deleting object should not be applied")
+	}
+}
+
+func TestGetSecret(t *testing.T) {
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"data": map[string]interface{}{"secret_key": base64.StdEncoding.EncodeToString([]byte("sk"))},
+	}}
+	secret.SetAPIVersion("v1")
+	secret.SetKind("Secret")
+	secret.SetNamespace("deepflow")
+	secret.SetName("aliyun")
+	s := newTestSyncer(&fakeHandler{}, secret)
+
+	data, err := s.getSecret("deepflow", "aliyun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 || data["secret_key"] != "sk" {
+		t.Errorf("unexpected secret data: %v", data)
+	}
+	if _, err := s.getSecret("deepflow", "not-exist"); err == nil {
+		t.Errorf("get not existing secret should fail")
+	}
+}
+
+func TestNewSyncerResyncInterval(t *testing.T) {
+	for _, interval := range []int{0, -1} {
+		s := NewSyncer(config.CRDConfig{ResyncInterval: interval}, "", nil)
+		if s.cfg.ResyncInterval != config.DefaultResyncInterval {
+			t.Errorf("resync interval %d is reset to %d, want %d", interval, s.cfg.ResyncInterval, config.DefaultResyncInterval)
+		}
+	}
+}
+
+func TestObjectDecodeSpec(t *testing.T) {
+	obj := &Object{Spec: map[string]interface{}{"name": "d1", "type": int64(11), "config": map[string]interface{}{"a": "b"}}}
+	var spec struct {
+		Name   string                 `json:"name"`
+		Type   int                    `json:"type"`
+		Config map[string]interface{} `json:"config"`
+	}
+	if err := obj.DecodeSpec(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Name != "d1" || spec.Type != 11 || spec.Config["a"] != "b" {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/deepflowio/deepflow/server/controller/db/mysql/config"
+)
+
+const (
+	DB_TYPE_MYSQL      = "mysql"
+	DB_TYPE_POSTGRESQL = "postgresql"
+	DB_TYPE_SQLITE     = "sqlite"
+
+	// PostgreSQL always needs a database to connect to, use the built-in one when not using the configured database
+	POSTGRESQL_MAINTENANCE_DATABASE = "postgres"
+	SQLITE_FILE_SUFFIX              = ".db"
+)
+
+// GetDBType returns the configured database type, empty type is considered MySQL for compatibility.
+func GetDBType(cfg config.MySqlConfig) string {
+	if cfg.Type == "" {
+		return DB_TYPE_MYSQL
+	}
+	return cfg.Type
+}
+
+// GetSQLiteFile returns the file path of the configured SQLite database, each database is stored in a separate file.
+func GetSQLiteFile(cfg config.MySqlConfig) string {
+	return filepath.Join(cfg.SQLiteDir, cfg.Database+SQLITE_FILE_SUFFIX)
+}
+
+// GetDialector returns the gorm dialector of the configured database type.
+// SQLite has no server to connect to without a database, so useDatabase is always true for SQLite.
+func GetDialector(cfg config.MySqlConfig, useDatabase bool, timeout uint32, multiStatements bool) (gorm.Dialector, error) {
+	switch GetDBType(cfg) {
+	case DB_TYPE_MYSQL:
+		connector, err := GetConnector(cfg, useDatabase, timeout, multiStatements)
+		if err != nil {
+			return nil, err
+		}
+		return GetMySQLDialector(connector), nil
+	case DB_TYPE_POSTGRESQL:
+		database := POSTGRESQL_MAINTENANCE_DATABASE
+		if useDatabase {
+			database = cfg.Database
+		}
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.UserName, cfg.UserPassword),
+			Host:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+			Path:     database,
+			RawQuery: fmt.Sprintf("sslmode=disable&connect_timeout=%d", timeout),
+		}
+		// simple protocol allows executing multiple statements in one call, which is needed when migrating
+		return postgres.New(postgres.Config{DSN: dsn.String(), PreferSimpleProtocol: multiStatements}), nil
+	case DB_TYPE_SQLITE:
+		if err := os.MkdirAll(cfg.SQLiteDir, 0755); err != nil {
+			log.Errorf("failed to create sqlite dir %s: %s", cfg.SQLiteDir, err.Error())
+			return nil, err
+		}
+		return sqlite.Open(fmt.Sprintf("%s?_busy_timeout=%d&_journal_mode=WAL", GetSQLiteFile(cfg), timeout*1000)), nil
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", cfg.Type)
+	}
+}
@@ -34,11 +34,11 @@ import (
 )
 
 func GetSession(cfg config.MySqlConfig) (*gorm.DB, error) {
-	connector, err := GetConnector(cfg, true, cfg.TimeOut, false)
+	dialector, err := GetDialector(cfg, true, cfg.TimeOut, false)
 	if err != nil {
 		return nil, err
 	}
-	return InitSession(cfg, connector)
+	return InitSession(cfg, dialector)
 }
 
 func GetConnector(cfg config.MySqlConfig, useDatabase bool, timeout uint32, multiStatements bool) (driver.Connector, error) {
@@ -74,15 +74,19 @@ func GetConnector(cfg config.MySqlConfig, useDatabase bool, timeout uint32, mult
 	return connector, nil
 }
 
-func InitSession(cfg config.MySqlConfig, connector driver.Connector) (*gorm.DB, error) {
-	db, err := gorm.Open(mysql.New(mysql.Config{
+func GetMySQLDialector(connector driver.Connector) gorm.Dialector {
+	return mysql.New(mysql.Config{
 		Conn:                      sql.OpenDB(connector),
 		DefaultStringSize:         256,   // string 类型字段的默认长度
 		DisableDatetimePrecision:  true,  // 禁用 datetime 精度，MySQL 5.6 之前的数据库不支持
 		DontSupportRenameIndex:    true,  // 重命名索引时采用删除并新建的方式，MySQL 5.7 之前的数据库和 MariaDB 不支持重命名索引
 		DontSupportRenameColumn:   true,  // 用 `change` 重命名列，MySQL 8 之前的数据库和 MariaDB 不支持重命名列
 		SkipInitializeWithVersion: false, // 根据当前 MySQL 版本自动配置
-	}), &gorm.Config{
+	})
+}
+
+func InitSession(cfg config.MySqlConfig, dialector gorm.Dialector) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{SingularTable: true}, // 设置全局表名禁用复数
 		Logger: logger.New(
 			l.New(os.Stdout, "\r\n", l.LstdFlags), // io writer
@@ -97,7 +101,7 @@ func InitSession(cfg config.MySqlConfig, connector driver.Connector) (*gorm.DB,
 		log.Errorf("failed to initialize session: %v", err.Error())
 		return nil, err
 	}
-	log.Infof("%s, initialized mysql session successfully", cfg.Database)
+	log.Infof("%s, initialized %s session successfully", cfg.Database, GetDBType(cfg))
 
 	sqlDB, _ := db.DB()
 	// 限制最大空闲连接数、最大连接数和连接的生命周期
@@ -17,6 +17,8 @@
 package config
 
 type MySqlConfig struct {
+	Type                   string `default:"mysql" yaml:"type"` // mysql, postgresql, sqlite
+	SQLiteDir              string `default:"/var/lib/deepflow" yaml:"sqlite-dir"`
 	Database               string `default:"deepflow" yaml:"database"`
 	Host                   string `default:"mysql" yaml:"host"`
 	Port                   uint32 `default:"30130" yaml:"port"`
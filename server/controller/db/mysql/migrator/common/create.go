@@ -17,8 +17,6 @@
 package common
 
 import (
-	"fmt"
-
 	"github.com/op/go-logging"
 )
 
@@ -26,13 +24,11 @@ var log = logging.MustGetLogger("db.mysql.migrator.common")
 
 func CreateDatabase(dc *DBConfig) error {
 	log.Infof(LogDBName(dc.Config.Database, "create database"))
-	return dc.DB.Exec(fmt.Sprintf("CREATE DATABASE %s", dc.Config.Database)).Error
+	return execCreateDatabase(dc)
 }
 
 func CreateDatabaseIfNotExists(dc *DBConfig) (bool, error) {
-	var databaseName string
-	dc.DB.Raw(fmt.Sprintf("SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME='%s'", dc.Config.Database)).Scan(&databaseName)
-	if databaseName == dc.Config.Database {
+	if exists, _ := CheckDatabaseExists(dc); exists {
 		return true, nil
 	} else {
 		err := CreateDatabase(dc)
@@ -67,19 +67,23 @@ func LogDBName(databaseName string, format string, a ...any) string {
 	return fmt.Sprintf("[DB-%s] ", databaseName) + fmt.Sprintf(format, a...)
 }
 
+// GetSessionWithoutName returns nil session for SQLite, whose databases are managed as files
 func GetSessionWithoutName(cfg config.MySqlConfig) (*gorm.DB, error) {
-	connector, err := common.GetConnector(cfg, false, cfg.TimeOut, false)
+	if common.GetDBType(cfg) == common.DB_TYPE_SQLITE {
+		return nil, nil
+	}
+	dialector, err := common.GetDialector(cfg, false, cfg.TimeOut, false)
 	if err != nil {
 		return nil, err
 	}
-	return common.InitSession(cfg, connector)
+	return common.InitSession(cfg, dialector)
 }
 
 func GetSessionWithName(cfg config.MySqlConfig) (*gorm.DB, error) {
 	// set multiStatements=true in dsn only when migrating MySQL
-	connector, err := common.GetConnector(cfg, true, cfg.TimeOut*2, true)
+	dialector, err := common.GetDialector(cfg, true, cfg.TimeOut*2, true)
 	if err != nil {
 		return nil, err
 	}
-	return common.InitSession(cfg, connector)
+	return common.InitSession(cfg, dialector)
 }
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/deepflowio/deepflow/server/controller/db/mysql/common"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql/migrator/schema"
+)
+
+// GetSchemaDir returns the directory of schema scripts of the configured database type,
+// MySQL scripts are placed in schemaDir, scripts of other database types are placed in the sub directory named after the type.
+func GetSchemaDir(dc *DBConfig, schemaDir string) string {
+	dbType := common.GetDBType(dc.Config)
+	if dbType == common.DB_TYPE_MYSQL {
+		return schemaDir
+	}
+	return filepath.Join(schemaDir, dbType)
+}
+
+func CheckDatabaseExists(dc *DBConfig) (bool, error) {
+	var databaseName string
+	var err error
+	switch common.GetDBType(dc.Config) {
+	case common.DB_TYPE_SQLITE:
+		_, err = os.Stat(common.GetSQLiteFile(dc.Config))
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return err == nil, err
+	case common.DB_TYPE_POSTGRESQL:
+		err = dc.DB.Raw("SELECT datname FROM pg_database WHERE datname = ?", dc.Config.Database).Scan(&databaseName).Error
+	default:
+		err = dc.DB.Raw(fmt.Sprintf("SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME='%s'", dc.Config.Database)).Scan(&databaseName).Error
+	}
+	return databaseName == dc.Config.Database, err
+}
+
+func execCreateDatabase(dc *DBConfig) error {
+	switch common.GetDBType(dc.Config) {
+	case common.DB_TYPE_SQLITE:
+		if err := os.MkdirAll(dc.Config.SQLiteDir, 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(common.GetSQLiteFile(dc.Config), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	case common.DB_TYPE_POSTGRESQL:
+		// names of non-default organization databases start with digits, must be quoted
+		return dc.DB.Exec(fmt.Sprintf(`CREATE DATABASE "%s"`, dc.Config.Database)).Error
+	default:
+		return dc.DB.Exec(fmt.Sprintf("CREATE DATABASE %s", dc.Config.Database)).Error
+	}
+}
+
+func execDropDatabase(dc *DBConfig) error {
+	switch common.GetDBType(dc.Config) {
+	case common.DB_TYPE_SQLITE:
+		file := common.GetSQLiteFile(dc.Config)
+		for _, f := range []string{file, file + "-wal", file + "-shm"} {
+			if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	case common.DB_TYPE_POSTGRESQL:
+		return dc.DB.Exec(fmt.Sprintf(`DROP DATABASE "%s"`, dc.Config.Database)).Error
+	default:
+		return dc.DB.Exec(fmt.Sprintf("DROP DATABASE %s", dc.Config.Database)).Error
+	}
+}
+
+func checkTableExists(dc *DBConfig, tableName string) (bool, error) {
+	var table string
+	var err error
+	switch common.GetDBType(dc.Config) {
+	case common.DB_TYPE_SQLITE:
+		err = dc.DB.Raw("SELECT name FROM sqlite_master WHERE type='table' AND name=?", tableName).Scan(&table).Error
+	case common.DB_TYPE_POSTGRESQL:
+		err = dc.DB.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema=current_schema() AND table_name=?", tableName).Scan(&table).Error
+	default:
+		err = dc.DB.Raw(fmt.Sprintf("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA='%s' AND TABLE_NAME='%s'", dc.Config.Database, tableName)).Scan(&table).Error
+	}
+	return table == tableName, err
+}
+
+func getCreateDBVersionTableSQL(dc *DBConfig) string {
+	switch common.GetDBType(dc.Config) {
+	case common.DB_TYPE_SQLITE:
+		return schema.CREATE_TABLE_DB_VERSION_SQLITE
+	case common.DB_TYPE_POSTGRESQL:
+		return schema.CREATE_TABLE_DB_VERSION_POSTGRESQL
+	default:
+		return schema.CREATE_TABLE_DB_VERSION
+	}
+}
+
+// getIssuePreamble returns the statements executed before each issue, only MySQL issues use session variables.
+func getIssuePreamble(dc *DBConfig) string {
+	if common.GetDBType(dc.Config) != common.DB_TYPE_MYSQL {
+		return ""
+	}
+	return fmt.Sprintf("SET @defaultDatabaseName='%s';\n", "deepflow") // TODO: remove hard code
+}
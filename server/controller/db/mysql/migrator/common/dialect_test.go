@@ -0,0 +1,276 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/deepflowio/deepflow/server/controller/db/mysql/common"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql/config"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql/migrator/schema"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+)
+
+const (
+	testSchemaDir = "../schema/rawsql"
+	// schemas of other database types were translated from MySQL schema at this version,
+	// every MySQL issue after it must have the same issue for other database types.
+	dialectBaseVersion = "6.6.1.14"
+)
+
+var createTableRegexp = regexp.MustCompile("(?i)CREATE TABLE IF NOT EXISTS [`\"]?(\\w+)[`\"]?")
+
+// getTestModels returns models of CE tables, tables only in enterprise edition (org, team, user, etc.) are not included.
+func getTestModels() []interface{} {
+	return []interface{}{
+		// recorder
+		&model.Domain{}, &model.SubDomain{}, &model.Region{}, &model.AZ{}, &model.Host{}, &model.VM{}, &model.VMPodNodeConnection{},
+		&model.VPC{}, &model.Network{}, &model.Subnet{}, &model.VRouter{}, &model.RoutingTable{}, &model.DHCPPort{},
+		&model.VInterface{}, &model.LANIP{}, &model.WANIP{}, &model.FloatingIP{}, &model.NATGateway{}, &model.NATRule{},
+		&model.NATVMConnection{}, &model.LB{}, &model.LBListener{}, &model.LBTargetServer{}, &model.LBVMConnection{},
+		&model.PeerConnection{}, &model.CEN{}, &model.RDSInstance{}, &model.RedisInstance{}, &model.VIP{},
+		&model.PodCluster{}, &model.PodNamespace{}, &model.PodNode{}, &model.PodIngress{}, &model.PodIngressRule{},
+		&model.PodIngressRuleBackend{}, &model.PodService{}, &model.PodServicePort{}, &model.PodGroup{}, &model.PodGroupPort{},
+		&model.PodReplicaSet{}, &model.Pod{}, &model.Process{}, &model.ResourceEvent{},
+		&model.DomainAdditionalResource{},
+		// tagrecorder
+		&model.ChRegion{}, &model.ChAZ{}, &model.ChVPC{}, &model.ChDevice{}, &model.ChVTapPort{}, &model.ChIPRelation{},
+		&model.ChIPResource{}, &model.ChNetwork{}, &model.ChPod{}, &model.ChPodCluster{}, &model.ChPodGroup{},
+		&model.ChPodNamespace{}, &model.ChPodNode{}, &model.ChVTap{}, &model.ChTapType{}, &model.ChLBListener{},
+		&model.ChPodIngress{}, &model.ChPodK8sLabel{}, &model.ChPodK8sLabels{}, &model.ChPodServiceK8sLabel{},
+		&model.ChPodServiceK8sLabels{}, &model.ChStringEnum{}, &model.ChIntEnum{}, &model.ChNodeType{},
+		&model.ChChostCloudTag{}, &model.ChPodNSCloudTag{}, &model.ChChostCloudTags{}, &model.ChPodNSCloudTags{},
+		&model.ChOSAppTag{}, &model.ChOSAppTags{}, &model.ChGProcess{}, &model.ChPodK8sAnnotation{},
+		&model.ChPodK8sAnnotations{}, &model.ChPodServiceK8sAnnotation{}, &model.ChPodServiceK8sAnnotations{},
+		&model.ChPodK8sEnv{}, &model.ChPodK8sEnvs{}, &model.ChPrometheusLabelName{}, &model.ChPrometheusMetricName{},
+		&model.ChPrometheusMetricAPPLabelLayout{}, &model.ChAPPLabel{}, &model.ChTargetLabel{},
+		&model.ChPrometheusTargetLabelLayout{}, &model.ChPodService{}, &model.ChChost{}, &model.ChPolicy{},
+		&model.ChNpbTunnel{}, &model.ChAlarmPolicy{}, &model.ChUser{},
+		// trisolaris
+		&model.VTap{}, &model.VTapGroup{}, &model.VTapRepo{}, &model.Controller{}, &model.AZControllerConnection{},
+		&model.Analyzer{}, &model.AZAnalyzerConnection{}, &model.KubernetesCluster{}, &model.ACL{}, &model.GroupACL{},
+		&model.PolicyACLGroup{}, &model.NpbPolicy{}, &model.NpbTunnel{}, &model.PcapPolicy{}, &model.ResourceGroupExtraInfo{},
+		&model.Plugin{}, &model.TapType{}, &model.SysConfiguration{}, &model.DataSource{},
+		&model.ResourceVersion{}, &model.LicenseFuncLog{}, &model.AlarmPolicy{}, &model.SLO{},
+		&model.PrometheusMetricName{}, &model.PrometheusLabelName{}, &model.PrometheusLabelValue{}, &model.PrometheusLabel{},
+		&model.PrometheusMetricLabelName{}, &model.PrometheusMetricTarget{}, &model.PrometheusMetricAPPLabelLayout{},
+	}
+}
+
+func newTestSQLiteConfig(t *testing.T, database string) config.MySqlConfig {
+	return config.MySqlConfig{
+		Type:         common.DB_TYPE_SQLITE,
+		SQLiteDir:    t.TempDir(),
+		Database:     database,
+		TimeOut:      30,
+		MaxOpenConns: 10,
+		MaxIdleConns: 5,
+	}
+}
+
+func newTestSQLiteDB(t *testing.T, database string) *DBConfig {
+	cfg := newTestSQLiteConfig(t, database)
+	dc := NewDBConfig(nil, cfg)
+	existed, err := CreateDatabaseIfNotExists(dc)
+	if err != nil || existed {
+		t.Fatalf("failed to create database, existed: %v, err: %v", existed, err)
+	}
+	db, err := GetSessionWithName(cfg)
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	dc.SetDB(db)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := InitTables(dc, testSchemaDir); err != nil {
+		t.Fatalf("failed to initialize tables: %v", err)
+	}
+	if err := InsertDBVersion(dc, schema.DB_VERSION_TABLE, schema.DB_VERSION_EXPECTED); err != nil {
+		t.Fatalf("failed to insert db version: %v", err)
+	}
+	return dc
+}
+
+func getCreatedTables(t *testing.T, file string) []string {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", file, err)
+	}
+	var tables []string
+	for _, m := range createTableRegexp.FindAllStringSubmatch(string(content), -1) {
+		tables = append(tables, m[1])
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+func TestGetSchemaDir(t *testing.T) {
+	cases := map[string]string{
+		"":                        schema.FILE_DIR,
+		common.DB_TYPE_MYSQL:      schema.FILE_DIR,
+		common.DB_TYPE_POSTGRESQL: schema.FILE_DIR + "/postgresql",
+		common.DB_TYPE_SQLITE:     schema.FILE_DIR + "/sqlite",
+	}
+	for dbType, want := range cases {
+		dc := NewDBConfig(nil, config.MySqlConfig{Type: dbType})
+		if got := GetSchemaDir(dc, schema.FILE_DIR); got != want {
+			t.Errorf("GetSchemaDir(%q) = %s, want %s", dbType, got, want)
+		}
+	}
+}
+
+func TestDialectSchemaParity(t *testing.T) {
+	mysqlTables := getCreatedTables(t, filepath.Join(testSchemaDir, "init.sql"))
+	mysqlDefaultTables := getCreatedTables(t, filepath.Join(testSchemaDir, "default_init.sql"))
+	issues, err := os.ReadDir(filepath.Join(testSchemaDir, "issu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueVersions := getAscSortedNextVersions(issues, dialectBaseVersion)
+
+	for _, dbType := range []string{common.DB_TYPE_POSTGRESQL, common.DB_TYPE_SQLITE} {
+		dir := filepath.Join(testSchemaDir, dbType)
+		if tables := getCreatedTables(t, filepath.Join(dir, "init.sql")); strings.Join(tables, ",") != strings.Join(mysqlTables, ",") {
+			t.Errorf("%s init.sql tables %v, mysql tables %v", dbType, tables, mysqlTables)
+		}
+		if tables := getCreatedTables(t, filepath.Join(dir, "default_init.sql")); strings.Join(tables, ",") != strings.Join(mysqlDefaultTables, ",") {
+			t.Errorf("%s default_init.sql tables %v, mysql tables %v", dbType, tables, mysqlDefaultTables)
+		}
+		for _, v := range issueVersions {
+			if _, err := os.Stat(filepath.Join(dir, "issu", v+".sql")); err != nil {
+				t.Errorf("%s issue of version %s is missing: %v", dbType, v, err)
+			}
+		}
+	}
+}
+
+func TestSQLiteMigration(t *testing.T) {
+	dc := newTestSQLiteDB(t, "deepflow")
+
+	if err := CheckCEDBVersion(dc); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := CheckCEDBVersionTableExists(dc); err != nil || !exists {
+		t.Fatalf("db_version table exists: %v, err: %v", exists, err)
+	}
+	if exists, err := CheckDatabaseExists(dc); err != nil || !exists {
+		t.Fatalf("database exists: %v, err: %v", exists, err)
+	}
+	if err := ExecuteIssues(dc, schema.DB_VERSION_EXPECTED, testSchemaDir); err != nil {
+		t.Fatal(err)
+	}
+
+	var vtapGroups []model.VTapGroup
+	if err := dc.DB.Find(&vtapGroups).Error; err != nil || len(vtapGroups) != 1 || !strings.HasPrefix(vtapGroups[0].ShortUUID, "g-") {
+		t.Fatalf("default vtap group: %+v, err: %v", vtapGroups, err)
+	}
+	var dataSourceCount int64
+	if err := dc.DB.Model(&model.DataSource{}).Count(&dataSourceCount).Error; err != nil || dataSourceCount == 0 {
+		t.Fatalf("data source count: %d, err: %v", dataSourceCount, err)
+	}
+
+	if err := DropDatabase(dc); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := CheckDatabaseExists(dc); exists {
+		t.Fatal("database still exists after dropped")
+	}
+}
+
+func TestSQLiteNonDefaultORGMigration(t *testing.T) {
+	defaultDC := newTestSQLiteDB(t, "deepflow")
+	dc := newTestSQLiteDB(t, common.ORGIDToDatabaseName(2))
+
+	// default_init.sql is only executed in default organization database
+	var defaultCount, count int64
+	defaultDC.DB.Model(&model.AlarmPolicy{}).Count(&defaultCount)
+	if err := dc.DB.Model(&model.AlarmPolicy{}).Count(&count).Error; err != nil || count >= defaultCount {
+		t.Fatalf("alarm policy count: %d, default org alarm policy count: %d, err: %v", count, defaultCount, err)
+	}
+}
+
+// TestSQLiteModelParity checks that models used by recorder, tagrecorder and trisolaris are queryable on schema initialized by scripts.
+func TestSQLiteModelParity(t *testing.T) {
+	dc := newTestSQLiteDB(t, "deepflow")
+
+	for _, m := range getTestModels() {
+		stmt := dc.DB.Model(m).Statement
+		if err := stmt.Parse(m); err != nil {
+			t.Fatalf("failed to parse model %T: %v", m, err)
+		}
+		if !dc.DB.Migrator().HasTable(m) {
+			t.Errorf("table %s of model %T does not exist", stmt.Schema.Table, m)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !dc.DB.Migrator().HasColumn(m, field.DBName) {
+				t.Errorf("column %s.%s of model %T does not exist", stmt.Schema.Table, field.DBName, m)
+			}
+		}
+		if err := dc.DB.Model(m).Limit(1).Find(m).Error; err != nil {
+			t.Errorf("failed to query model %T: %v", m, err)
+		}
+	}
+
+	// upsert used by tagrecorder
+	chAZs := []model.ChAZ{{ID: 1, Name: "az"}}
+	for i := 0; i < 2; i++ {
+		if err := dc.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&chAZs).Error; err != nil {
+			t.Fatalf("failed to upsert ch_az: %v", err)
+		}
+	}
+	chAZs[0].Name = "az (deleted)"
+	if err := dc.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name"}),
+	}).Create(&chAZs).Error; err != nil {
+		t.Fatalf("failed to update ch_az on conflict: %v", err)
+	}
+	var chAZ model.ChAZ
+	if err := dc.DB.Where("id = ?", 1).First(&chAZ).Error; err != nil || chAZ.Name != "az (deleted)" {
+		t.Fatalf("ch_az: %+v, err: %v", chAZ, err)
+	}
+
+	// soft delete used by recorder
+	az := model.AZ{Name: "az"}
+	az.Lcuuid = "az-lcuuid"
+	if err := dc.DB.Create(&az).Error; err != nil {
+		t.Fatalf("failed to create az: %v", err)
+	}
+	if err := dc.DB.Delete(&az).Error; err != nil {
+		t.Fatalf("failed to soft delete az: %v", err)
+	}
+	var count int64
+	dc.DB.Model(&model.AZ{}).Where("lcuuid = ?", az.Lcuuid).Count(&count)
+	if count != 0 {
+		t.Fatalf("soft deleted az is still queried")
+	}
+	dc.DB.Unscoped().Model(&model.AZ{}).Where("lcuuid = ?", az.Lcuuid).Count(&count)
+	if count != 1 {
+		t.Fatalf("soft deleted az count: %d", count)
+	}
+}
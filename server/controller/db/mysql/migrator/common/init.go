@@ -49,6 +49,7 @@ func InitCETables(dc *DBConfig) error {
 }
 
 func InitTables(dc *DBConfig, schemaDir string) error {
+	schemaDir = GetSchemaDir(dc, schemaDir)
 	log.Info(LogDBName(dc.Config.Database, "initialize %s tables", schemaDir)) // TODO
 
 	// 先初始化所有组织需要的 CE 表，再判断数据库是否是 default 组织，如果是 default 组织，初始化仅 default 组织所需数据。
@@ -34,6 +34,7 @@ func ExecuteCEIssues(dc *DBConfig, curVersion string) error {
 }
 
 func ExecuteIssues(dc *DBConfig, curVersion string, schemaDir string) error {
+	schemaDir = GetSchemaDir(dc, schemaDir)
 	issus, err := os.ReadDir(fmt.Sprintf("%s/issu", schemaDir))
 	if err != nil {
 		log.Error(LogDBName(dc.Config.Database, "failed to read %s: %s", schemaDir, err.Error()))
@@ -65,7 +66,7 @@ func executeIssue(dc *DBConfig, nextVersion string, schemaDir string) error {
 		return nil
 	}
 
-	strSQL := fmt.Sprintf("SET @defaultDatabaseName='%s';\n", "deepflow") + string(byteSQL) // TODO: remove hard code
+	strSQL := getIssuePreamble(dc) + string(byteSQL)
 	err = dc.DB.Exec(strSQL).Error
 	if err != nil {
 		log.Error(LogDBName(dc.Config.Database, "failed to execute %s issue (version: %s): %s", schemaDir, nextVersion, err.Error()))
@@ -89,6 +90,9 @@ func executeScript(dc *DBConfig, nextVersion string) error { // TODO ce
 func getAscSortedNextVersions(files []fs.DirEntry, curVersion string) []string {
 	vs := []string{}
 	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".sql" {
+			continue
+		}
 		vs = append(vs, trimFilenameExt(f.Name()))
 	}
 	// asc sort: split version by ".", compare each number from first to end
@@ -35,7 +35,7 @@ func CheckCEDBVersionTableExists(dc *DBConfig) (bool, error) {
 }
 
 func CreateCEDBVersionTable(dc *DBConfig) error {
-	return CreateTable(dc, schema.CREATE_TABLE_DB_VERSION)
+	return CreateTable(dc, getCreateDBVersionTableSQL(dc))
 }
 
 func CheckDBVersion(dc *DBConfig, tableName string, expectedVersion string) error {
@@ -62,14 +62,12 @@ func GetDBVersion(dc *DBConfig, tableName string) (string, error) {
 }
 
 func CheckTableExists(dc *DBConfig, tableName string) (bool, error) {
-	var table string
-	err := dc.DB.Raw(fmt.Sprintf("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA='%s' AND TABLE_NAME='%s'", dc.Config.Database, tableName)).Scan(&table).Error
-
+	exists, err := checkTableExists(dc, tableName)
 	if err != nil {
 		log.Error(LogDBName(dc.Config.Database, "failed to check table %s exists: %s", tableName, err.Error()))
 		return false, err
 	}
-	return table == tableName, nil
+	return exists, nil
 }
 
 func CreateTable(dc *DBConfig, sql string) error {
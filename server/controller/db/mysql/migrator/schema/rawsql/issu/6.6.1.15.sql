@@ -0,0 +1,22 @@
+-- modify start, add upgrade sql
+CREATE TABLE IF NOT EXISTS slo (
+    id                      INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name                    VARCHAR(256) NOT NULL,
+    description             TEXT,
+    sli_type                VARCHAR(64) NOT NULL DEFAULT 'availability' COMMENT 'availability, latency',
+    sli_source              VARCHAR(64) NOT NULL DEFAULT 'deepflow' COMMENT 'deepflow: DeepFlow SQL, promql: PromQL',
+    db                      VARCHAR(64) DEFAULT '' COMMENT 'database of DeepFlow SQL',
+    good_query              TEXT NOT NULL,
+    total_query             TEXT NOT NULL,
+    target                  DOUBLE NOT NULL COMMENT 'unit: %',
+    time_window             INTEGER NOT NULL DEFAULT 2592000 COMMENT 'unit: s',
+    created_at              DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at              DATETIME NOT NULL ON UPDATE CURRENT_TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    lcuuid                  CHAR(64) DEFAULT '',
+    UNIQUE INDEX name_index(name),
+    UNIQUE INDEX lcuuid_index(lcuuid)
+)ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COMMENT='service level objectives';
+
+-- update db_version to latest, remeber update DB_VERSION_EXPECT in migrate/init.go
+UPDATE db_version SET version='6.6.1.15';
+-- modify end
@@ -0,0 +1,2275 @@
+-- translated from ../init.sql, keep tables and initial data in sync with it
+
+CREATE TABLE IF NOT EXISTS db_version (
+    version CHAR(64) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS plugin (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    type INTEGER NOT NULL,
+    "user" INTEGER NOT NULL DEFAULT 1,
+    image BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS vtap_repo (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(512),
+    arch VARCHAR(256) DEFAULT '',
+    os VARCHAR(256) DEFAULT '',
+    branch VARCHAR(256) DEFAULT '',
+    rev_count VARCHAR(256) DEFAULT '',
+    commit_id VARCHAR(256) DEFAULT '',
+    image BYTEA,
+    k8s_image VARCHAR(512) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS resource_event (
+    id SERIAL PRIMARY KEY,
+    domain CHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    resource_lcuuid CHAR(64) DEFAULT '',
+    content TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS domain_additional_resource (
+    id SERIAL PRIMARY KEY,
+    domain CHAR(64) DEFAULT '',
+    content TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    compressed_content BYTEA
+);
+
+CREATE TABLE IF NOT EXISTS process (
+    id SERIAL PRIMARY KEY,
+    name TEXT,
+    vtap_id INTEGER NOT NULL DEFAULT 0,
+    pid INTEGER NOT NULL,
+    devicetype INTEGER,
+    deviceid INTEGER,
+    pod_node_id INTEGER,
+    vm_id INTEGER,
+    epc_id INTEGER,
+    process_name TEXT,
+    command_line TEXT,
+    user_name VARCHAR(256) DEFAULT '',
+    start_time TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    os_app_tags TEXT,
+    netns_id BIGINT DEFAULT 0,
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    container_id CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS host_device (
+    id SERIAL,
+    type INTEGER,
+    state INTEGER,
+    name VARCHAR(256) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    description VARCHAR(256) DEFAULT '',
+    ip CHAR(64) DEFAULT '',
+    hostname CHAR(64) DEFAULT '',
+    htype INTEGER,
+    create_method INTEGER DEFAULT 0,
+    user_name VARCHAR(64) DEFAULT '',
+    user_passwd VARCHAR(64) DEFAULT '',
+    vcpu_num INTEGER DEFAULT 0,
+    mem_total INTEGER DEFAULT 0,
+    rack VARCHAR(64),
+    rackid INTEGER,
+    topped INTEGER DEFAULT 0,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    extra_info TEXT,
+    lcuuid CHAR(64) DEFAULT '',
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    PRIMARY KEY (id, domain)
+);
+
+CREATE TABLE IF NOT EXISTS third_party_device (
+    id SERIAL,
+    epc_id INTEGER DEFAULT 0,
+    vm_id INTEGER,
+    curr_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sys_uptime CHAR(32),
+    type INTEGER,
+    state INTEGER,
+    errno INTEGER DEFAULT 0,
+    name VARCHAR(256),
+    label CHAR(64),
+    poolid INTEGER DEFAULT 0,
+    community VARCHAR(256),
+    mgmt_ip CHAR(64),
+    data_ip CHAR(64),
+    ctrl_ip CHAR(64),
+    ctrl_mac CHAR(32),
+    data1_mac CHAR(32),
+    data2_mac CHAR(32),
+    data3_mac CHAR(32),
+    launch_server CHAR(64),
+    user_name VARCHAR(64),
+    user_passwd VARCHAR(64),
+    vnc_port INTEGER DEFAULT 0,
+    brand VARCHAR(64),
+    sys_os VARCHAR(64),
+    mem_size INTEGER,
+    mem_used INTEGER,
+    mem_usage VARCHAR(32),
+    mem_data VARCHAR(256),
+    cpu_type VARCHAR(128),
+    cpu_num INTEGER,
+    cpu_data VARCHAR(256),
+    disk_size INTEGER,
+    dsk_num INTEGER,
+    disk_info VARCHAR(1024),
+    nic_num INTEGER,
+    nic_data VARCHAR(256),
+    rack_name VARCHAR(256),
+    userid INTEGER,
+    domain CHAR(64),
+    region CHAR(64),
+    lcuuid CHAR(64),
+    order_id INTEGER,
+    product_specification_lcuuid CHAR(64),
+    role INTEGER DEFAULT 1,
+    create_time TIMESTAMP,
+    gateway CHAR(64) DEFAULT '',
+    raid_support CHAR(64) DEFAULT '',
+    PRIMARY KEY (id, domain)
+);
+
+CREATE TABLE IF NOT EXISTS vnet (
+    id SERIAL,
+    state INTEGER NOT NULL,
+    name VARCHAR(256) DEFAULT '',
+    label CHAR(64) DEFAULT '',
+    description VARCHAR(256) DEFAULT '',
+    epc_id INTEGER DEFAULT 0,
+    gw_launch_server CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    az CHAR(64) DEFAULT '',
+    userid INTEGER,
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    PRIMARY KEY (id, domain)
+);
+CREATE INDEX IF NOT EXISTS vnet_state_server_index ON vnet (state, gw_launch_server);
+SELECT setval(pg_get_serial_sequence('vnet', 'id'), 256, false);
+
+DELETE FROM vnet;
+
+CREATE TABLE IF NOT EXISTS routing_table (
+    id SERIAL PRIMARY KEY,
+    vnet_id INTEGER,
+    destination TEXT,
+    nexthop_type TEXT,
+    nexthop TEXT,
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS vl2 (
+    id SERIAL,
+    state INTEGER NOT NULL,
+    net_type INTEGER DEFAULT 4,
+    name VARCHAR(256) NOT NULL,
+    create_method INTEGER DEFAULT 0,
+    label VARCHAR(64) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    description VARCHAR(256) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    az CHAR(64) DEFAULT '',
+    isp INTEGER DEFAULT 0,
+    userid INTEGER DEFAULT 0,
+    epc_id INTEGER DEFAULT 0,
+    segmentation_id INTEGER DEFAULT 0,
+    tunnel_id INTEGER DEFAULT 0,
+    shared INTEGER DEFAULT 0,
+    topped INTEGER DEFAULT 0,
+    is_vip INTEGER DEFAULT 0,
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    PRIMARY KEY (id, domain),
+    UNIQUE (lcuuid)
+);
+SELECT setval(pg_get_serial_sequence('vl2', 'id'), 4096, false);
+
+DELETE FROM vl2;
+
+CREATE TABLE IF NOT EXISTS vl2_net (
+    id SERIAL,
+    prefix CHAR(64) DEFAULT '',
+    netmask CHAR(64) DEFAULT '',
+    vl2id INTEGER DEFAULT 0,
+    net_index INTEGER DEFAULT 0,
+    name VARCHAR(256) DEFAULT '',
+    label VARCHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+DELETE FROM vl2_net;
+
+CREATE TABLE IF NOT EXISTS vm (
+    id SERIAL,
+    state INTEGER NOT NULL,
+    name VARCHAR(256) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    label CHAR(64) DEFAULT '',
+    ip CHAR(64) DEFAULT '',
+    hostname CHAR(64) DEFAULT '',
+    create_method INTEGER DEFAULT 0,
+    htype INTEGER DEFAULT 1,
+    launch_server CHAR(64) DEFAULT '',
+    host_id INTEGER DEFAULT 0,
+    cloud_tags TEXT,
+    epc_id INTEGER DEFAULT 0,
+    domain CHAR(64) DEFAULT '',
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    userid INTEGER,
+    uid CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    PRIMARY KEY (id, domain)
+);
+CREATE INDEX IF NOT EXISTS vm_state_server_index ON vm (state, launch_server);
+
+DELETE FROM vm;
+
+CREATE TABLE IF NOT EXISTS vinterface (
+    id SERIAL,
+    name CHAR(64) DEFAULT '',
+    ifindex INTEGER NOT NULL,
+    state INTEGER NOT NULL,
+    create_method INTEGER DEFAULT 0,
+    iftype INTEGER DEFAULT 0,
+    mac CHAR(32) DEFAULT '',
+    vmac CHAR(32) DEFAULT '',
+    tap_mac CHAR(32) DEFAULT '',
+    subnetid INTEGER DEFAULT 0,
+    vlantag INTEGER DEFAULT 0,
+    devicetype INTEGER,
+    deviceid INTEGER,
+    netns_id BIGINT DEFAULT 0,
+    vtap_id INTEGER DEFAULT 0,
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, domain)
+);
+CREATE INDEX IF NOT EXISTS vinterface_mac_index ON vinterface (mac);
+
+DELETE FROM vinterface;
+
+CREATE TABLE IF NOT EXISTS vinterface_ip (
+    id SERIAL,
+    ip CHAR(64) DEFAULT '',
+    netmask CHAR(64) DEFAULT '',
+    gateway CHAR(64) DEFAULT '',
+    create_method INTEGER DEFAULT 0,
+    vl2id INTEGER DEFAULT 0,
+    vl2_net_id INTEGER DEFAULT 0,
+    net_index INTEGER DEFAULT 0,
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    vifid INTEGER DEFAULT 0,
+    isp INTEGER DEFAULT 0,
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+DELETE FROM vinterface_ip;
+
+CREATE TABLE IF NOT EXISTS vip (
+    id SERIAL PRIMARY KEY,
+    lcuuid CHAR(64),
+    ip CHAR(64),
+    domain CHAR(64) DEFAULT '',
+    vtap_id INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ip_resource (
+    id SERIAL,
+    ip CHAR(64) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    netmask INTEGER,
+    gateway CHAR(64) DEFAULT '',
+    create_method INTEGER DEFAULT 0,
+    userid INTEGER DEFAULT 0,
+    isp INTEGER,
+    vifid INTEGER DEFAULT 0,
+    vl2_net_id INTEGER DEFAULT 0,
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, domain)
+);
+
+DELETE FROM ip_resource;
+
+CREATE TABLE IF NOT EXISTS floatingip (
+    id SERIAL,
+    domain CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    epc_id INTEGER DEFAULT 0,
+    vl2_id INTEGER,
+    vm_id INTEGER,
+    ip CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, domain)
+);
+
+CREATE TABLE IF NOT EXISTS dhcp_port (
+    id SERIAL,
+    name VARCHAR(256) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    az CHAR(64) DEFAULT '',
+    userid INTEGER DEFAULT 0,
+    epc_id INTEGER DEFAULT 0,
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    PRIMARY KEY (id, domain)
+);
+
+CREATE TABLE IF NOT EXISTS az (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(64) DEFAULT '',
+    create_method INTEGER DEFAULT 0,
+    label VARCHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '' UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+INSERT INTO az (id, name, lcuuid, region, domain) VALUES (1, '系统默认', 'ffffffff-ffff-ffff-ffff-ffffffffffff', 'ffffffff-ffff-ffff-ffff-ffffffffffff', 'ffffffff-ffff-ffff-ffff-ffffffffffff');
+
+CREATE TABLE IF NOT EXISTS domain (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER DEFAULT 1,
+    user_id INTEGER DEFAULT 1,
+    name VARCHAR(64),
+    icon_id INTEGER,
+    display_name VARCHAR(64) DEFAULT '',
+    cluster_id CHAR(64),
+    ip VARCHAR(64),
+    role INTEGER DEFAULT 0,
+    type INTEGER DEFAULT 0,
+    public_ip VARCHAR(64) DEFAULT NULL,
+    config TEXT,
+    error_msg TEXT,
+    enabled INTEGER NOT NULL DEFAULT '1',
+    state INTEGER NOT NULL DEFAULT '1',
+    controller_ip CHAR(64),
+    lcuuid CHAR(64) DEFAULT '',
+    synced_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (lcuuid)
+);
+
+CREATE TABLE IF NOT EXISTS sub_domain (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER DEFAULT 1,
+    user_id INTEGER DEFAULT 1,
+    domain CHAR(64) DEFAULT '',
+    name VARCHAR(64) DEFAULT '',
+    display_name VARCHAR(64) DEFAULT '',
+    create_method INTEGER DEFAULT 0,
+    cluster_id CHAR(64) DEFAULT '',
+    config TEXT,
+    error_msg TEXT,
+    enabled INTEGER NOT NULL DEFAULT '1',
+    state INTEGER NOT NULL DEFAULT '1',
+    lcuuid CHAR(64) DEFAULT '',
+    synced_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (lcuuid)
+);
+
+CREATE TABLE IF NOT EXISTS region (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(64) DEFAULT '',
+    create_method INTEGER DEFAULT 0,
+    label VARCHAR(64) DEFAULT '',
+    longitude DOUBLE PRECISION,
+    latitude DOUBLE PRECISION,
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (lcuuid)
+);
+
+INSERT INTO region (id, name, lcuuid) VALUES (1, '系统默认', 'ffffffff-ffff-ffff-ffff-ffffffffffff');
+
+CREATE TABLE IF NOT EXISTS az_analyzer_connection (
+    id SERIAL PRIMARY KEY,
+    az CHAR(64) DEFAULT 'ALL',
+    region CHAR(64) DEFAULT 'ffffffff-ffff-ffff-ffff-ffffffffffff',
+    analyzer_ip CHAR(64),
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS az_controller_connection (
+    id SERIAL PRIMARY KEY,
+    az CHAR(64) DEFAULT 'ALL',
+    region CHAR(64) DEFAULT 'ffffffff-ffff-ffff-ffff-ffffffffffff',
+    controller_ip CHAR(64),
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS sys_configuration (
+    id SERIAL PRIMARY KEY,
+    param_name CHAR(64) NOT NULL,
+    value VARCHAR(256),
+    comments TEXT,
+    lcuuid CHAR(64)
+);
+
+INSERT INTO sys_configuration (id, param_name, value, comments, lcuuid) VALUES (1, 'cloud_sync_timer', '60', 'unit: s', (gen_random_uuid()::text));
+
+INSERT INTO sys_configuration (id, param_name, value, comments, lcuuid) VALUES (2, 'pcap_data_retention', '3', 'unit: day', (gen_random_uuid()::text));
+
+INSERT INTO sys_configuration (id, param_name, value, comments, lcuuid) VALUES (3, 'system_data_retention', '7', 'unit: day', (gen_random_uuid()::text));
+
+INSERT INTO sys_configuration (id, param_name, value, comments, lcuuid) VALUES (4, 'ntp_servers', '0.cn.pool.ntp.org', '', (gen_random_uuid()::text));
+
+CREATE TABLE IF NOT EXISTS epc (
+    id SERIAL PRIMARY KEY,
+    userid INTEGER DEFAULT 0,
+    name VARCHAR(256) DEFAULT '',
+    create_method INTEGER DEFAULT 0,
+    label VARCHAR(64) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    az CHAR(64) DEFAULT '',
+    order_id INTEGER DEFAULT 0,
+    tunnel_id INTEGER DEFAULT 0,
+    operationid INTEGER DEFAULT 0,
+    mode INTEGER DEFAULT 2,
+    topped INTEGER DEFAULT 0,
+    cidr CHAR(64) DEFAULT '',
+    uid CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '' UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS peer_connection (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    label CHAR(64) DEFAULT '',
+    local_epc_id INTEGER DEFAULT 0,
+    remote_epc_id INTEGER DEFAULT 0,
+    local_region_id INTEGER DEFAULT 0,
+    remote_region_id INTEGER DEFAULT 0,
+    create_method INTEGER DEFAULT 0,
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS cen (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    label CHAR(64) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    epc_ids TEXT,
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS nat_gateway (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    label CHAR(64) DEFAULT '',
+    floating_ips TEXT,
+    epc_id INTEGER DEFAULT 0,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    uid CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS nat_rule (
+    id SERIAL PRIMARY KEY,
+    nat_id INTEGER DEFAULT 0,
+    type CHAR(16) DEFAULT '',
+    protocol CHAR(64) DEFAULT '',
+    floating_ip CHAR(64) DEFAULT '',
+    floating_ip_port INTEGER DEFAULT NULL,
+    fixed_ip CHAR(64) DEFAULT '',
+    fixed_ip_port INTEGER DEFAULT NULL,
+    port_id INTEGER DEFAULT NULL,
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS nat_vm_connection (
+    id SERIAL PRIMARY KEY,
+    nat_id INTEGER,
+    vm_id INTEGER,
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS redis_instance (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    label CHAR(64) DEFAULT '',
+    state SMALLINT NOT NULL DEFAULT 0,
+    domain CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    az CHAR(64) DEFAULT '',
+    epc_id INTEGER DEFAULT 0,
+    version CHAR(64) DEFAULT '',
+    internal_host VARCHAR(128) DEFAULT '',
+    public_host VARCHAR(128) DEFAULT '',
+    uid CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS rds_instance (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    label CHAR(64) DEFAULT '',
+    state SMALLINT NOT NULL DEFAULT 0,
+    domain CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    az CHAR(64) DEFAULT '',
+    epc_id INTEGER DEFAULT 0,
+    type INTEGER DEFAULT 0,
+    version CHAR(64) DEFAULT '',
+    series SMALLINT NOT NULL DEFAULT 0,
+    model SMALLINT NOT NULL DEFAULT 0,
+    uid CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS lb (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    label CHAR(64) DEFAULT '',
+    model INTEGER DEFAULT 0,
+    vip TEXT,
+    epc_id INTEGER DEFAULT 0,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    uid CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS lb_listener (
+    id SERIAL PRIMARY KEY,
+    lb_id INTEGER DEFAULT 0,
+    name VARCHAR(256) DEFAULT '',
+    ips TEXT,
+    snat_ips TEXT,
+    label CHAR(64) DEFAULT '',
+    port INTEGER DEFAULT NULL,
+    protocol CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS lb_target_server (
+    id SERIAL PRIMARY KEY,
+    lb_id INTEGER DEFAULT 0,
+    lb_listener_id INTEGER DEFAULT 0,
+    epc_id INTEGER DEFAULT 0,
+    type INTEGER DEFAULT 0,
+    ip CHAR(64) DEFAULT '',
+    vm_id INTEGER DEFAULT 0,
+    port INTEGER DEFAULT NULL,
+    protocol CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS lb_vm_connection (
+    id SERIAL PRIMARY KEY,
+    lb_id INTEGER,
+    vm_id INTEGER,
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS vm_pod_node_connection (
+    id SERIAL PRIMARY KEY,
+    vm_id INTEGER,
+    pod_node_id INTEGER,
+    domain CHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pod_cluster (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    cluster_name VARCHAR(256) DEFAULT '',
+    version VARCHAR(256) DEFAULT '',
+    epc_id INTEGER,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pod_node (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    type INTEGER DEFAULT NULL,
+    server_type INTEGER DEFAULT NULL,
+    state INTEGER DEFAULT 1,
+    ip CHAR(64) DEFAULT '',
+    hostname CHAR(64) DEFAULT '',
+    vcpu_num INTEGER DEFAULT 0,
+    mem_total INTEGER DEFAULT 0,
+    pod_cluster_id INTEGER,
+    region CHAR(64) DEFAULT '',
+    az CHAR(64) DEFAULT '',
+    epc_id INTEGER DEFAULT NULL,
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pod (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    label TEXT,
+    annotation TEXT,
+    env TEXT,
+    container_ids TEXT,
+    state INTEGER NOT NULL,
+    pod_rs_id INTEGER DEFAULT NULL,
+    pod_group_id INTEGER DEFAULT NULL,
+    pod_service_id INTEGER DEFAULT 0,
+    pod_namespace_id INTEGER DEFAULT NULL,
+    pod_node_id INTEGER DEFAULT NULL,
+    pod_cluster_id INTEGER DEFAULT NULL,
+    epc_id INTEGER DEFAULT NULL,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pod_rs (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    label TEXT,
+    pod_num INTEGER DEFAULT 1,
+    pod_group_id INTEGER DEFAULT NULL,
+    pod_namespace_id INTEGER DEFAULT NULL,
+    pod_cluster_id INTEGER DEFAULT NULL,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pod_group (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    type INTEGER DEFAULT NULL,
+    pod_num INTEGER DEFAULT 1,
+    label TEXT,
+    pod_namespace_id INTEGER DEFAULT NULL,
+    pod_cluster_id INTEGER DEFAULT NULL,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pod_namespace (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    cloud_tags TEXT,
+    pod_cluster_id INTEGER DEFAULT NULL,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pod_service (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    label TEXT,
+    annotation TEXT,
+    alias CHAR(64) DEFAULT '',
+    type INTEGER DEFAULT NULL,
+    selector TEXT,
+    external_ip TEXT,
+    service_cluster_ip CHAR(64) DEFAULT '',
+    pod_ingress_id INTEGER DEFAULT NULL,
+    pod_namespace_id INTEGER DEFAULT NULL,
+    pod_cluster_id INTEGER DEFAULT NULL,
+    epc_id INTEGER DEFAULT NULL,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pod_service_port (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    protocol CHAR(64) DEFAULT '',
+    port INTEGER,
+    target_port INTEGER,
+    node_port INTEGER,
+    pod_service_id INTEGER DEFAULT NULL,
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pod_group_port (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    protocol CHAR(64) DEFAULT '',
+    port INTEGER,
+    pod_group_id INTEGER DEFAULT NULL,
+    pod_service_id INTEGER DEFAULT NULL,
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pod_ingress (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    alias CHAR(64) DEFAULT '',
+    pod_namespace_id INTEGER DEFAULT NULL,
+    pod_cluster_id INTEGER DEFAULT NULL,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pod_ingress_rule (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) DEFAULT '',
+    protocol CHAR(64) DEFAULT '',
+    host TEXT,
+    pod_ingress_id INTEGER DEFAULT NULL,
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pod_ingress_rule_backend (
+    id SERIAL PRIMARY KEY,
+    path TEXT,
+    port INTEGER,
+    pod_service_id INTEGER DEFAULT NULL,
+    pod_ingress_rule_id INTEGER DEFAULT NULL,
+    pod_ingress_id INTEGER DEFAULT NULL,
+    sub_domain CHAR(64) DEFAULT '',
+    domain CHAR(64) DEFAULT '',
+    lcuuid CHAR(64) DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS report (
+    id SERIAL,
+    title VARCHAR(200) NOT NULL DEFAULT '',
+    begin_at TIMESTAMP DEFAULT NULL,
+    end_at TIMESTAMP DEFAULT NULL,
+    policy_id BIGINT NOT NULL DEFAULT '0',
+    content TEXT,
+    lcuuid VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS report_lcuuid ON report (lcuuid);
+CREATE INDEX IF NOT EXISTS report_policy_id ON report (policy_id);
+
+CREATE TABLE IF NOT EXISTS vtap (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    raw_hostname VARCHAR(256),
+    state INTEGER DEFAULT 1,
+    enable INTEGER DEFAULT 1,
+    type INTEGER DEFAULT 0,
+    ctrl_ip CHAR(64) NOT NULL,
+    ctrl_mac CHAR(64),
+    tap_mac CHAR(64),
+    analyzer_ip CHAR(64) NOT NULL,
+    cur_analyzer_ip CHAR(64) NOT NULL,
+    controller_ip CHAR(64) NOT NULL,
+    cur_controller_ip CHAR(64) NOT NULL,
+    launch_server CHAR(64) NOT NULL,
+    launch_server_id INTEGER,
+    az CHAR(64) DEFAULT '',
+    region CHAR(64) DEFAULT '',
+    revision VARCHAR(256),
+    synced_controller_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    synced_analyzer_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    boot_time INTEGER DEFAULT 0,
+    exceptions BIGINT DEFAULT 0,
+    vtap_lcuuid CHAR(64) DEFAULT NULL,
+    vtap_group_lcuuid CHAR(64) DEFAULT NULL,
+    cpu_num INTEGER DEFAULT 0,
+    memory_size BIGINT DEFAULT 0,
+    arch VARCHAR(256),
+    os VARCHAR(256),
+    kernel_version VARCHAR(256),
+    process_name VARCHAR(256),
+    current_k8s_image VARCHAR(512),
+    license_type INTEGER,
+    license_functions CHAR(64),
+    enable_features CHAR(64) DEFAULT NULL,
+    disable_features CHAR(64) DEFAULT NULL,
+    follow_group_features CHAR(64) DEFAULT NULL,
+    tap_mode INTEGER,
+    team_id INTEGER,
+    expected_revision TEXT,
+    upgrade_package TEXT,
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS vtap_group (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER DEFAULT 1,
+    user_id INTEGER DEFAULT 1,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lcuuid CHAR(64),
+    license_functions CHAR(64),
+    short_uuid CHAR(32)
+);
+
+CREATE TABLE IF NOT EXISTS acl (
+    id SERIAL PRIMARY KEY,
+    business_id INTEGER NOT NULL,
+    name CHAR(64),
+    team_id INTEGER DEFAULT 1,
+    type INTEGER DEFAULT 2,
+    tap_type INTEGER DEFAULT 3,
+    state INTEGER DEFAULT 1,
+    applications CHAR(64) NOT NULL,
+    epc_id INTEGER,
+    src_group_ids TEXT,
+    dst_group_ids TEXT,
+    protocol INTEGER,
+    src_ports TEXT,
+    dst_ports TEXT,
+    vlan INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS resource_group (
+    id SERIAL,
+    team_id INTEGER DEFAULT 1,
+    business_id INTEGER NOT NULL,
+    lcuuid VARCHAR(64) NOT NULL,
+    name VARCHAR(200) NOT NULL DEFAULT '',
+    type INTEGER NOT NULL,
+    ip_type INTEGER,
+    ips TEXT,
+    vm_ids TEXT,
+    vl2_ids TEXT,
+    epc_id INTEGER,
+    pod_cluster_id INTEGER,
+    extra_info_ids TEXT,
+    lb_id INTEGER,
+    lb_listener_id INTEGER,
+    icon_id INTEGER DEFAULT -2,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS resource_group_extra_info (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER DEFAULT 1,
+    resource_type INTEGER NOT NULL,
+    resource_id INTEGER NOT NULL,
+    resource_sub_type INTEGER,
+    pod_namespace_id INTEGER,
+    resource_name VARCHAR(256) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS npb_policy (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER DEFAULT 1,
+    team_id INTEGER DEFAULT 1,
+    name CHAR(64),
+    state INTEGER DEFAULT 1,
+    business_id INTEGER NOT NULL,
+    direction SMALLINT DEFAULT 1,
+    vni INTEGER,
+    npb_tunnel_id INTEGER,
+    distribute SMALLINT DEFAULT 1,
+    payload_slice INTEGER DEFAULT NULL,
+    acl_id INTEGER,
+    policy_acl_group_id INTEGER,
+    vtap_ids TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS pcap_policy (
+    id SERIAL PRIMARY KEY,
+    name CHAR(64),
+    state INTEGER DEFAULT 1,
+    business_id INTEGER NOT NULL,
+    acl_id INTEGER,
+    vtap_ids TEXT,
+    payload_slice INTEGER,
+    policy_acl_group_id INTEGER,
+    user_id INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lcuuid CHAR(64),
+    team_id INTEGER DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS group_acl (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER DEFAULT 1,
+    group_id INTEGER NOT NULL,
+    acl_id INTEGER NOT NULL,
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS alarm_policy (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER DEFAULT 1,
+    sub_view_id INTEGER,
+    sub_view_type SMALLINT DEFAULT 0,
+    sub_view_name TEXT,
+    sub_view_url TEXT,
+    sub_view_params TEXT,
+    sub_view_metrics TEXT,
+    sub_view_extra TEXT,
+    user_id INTEGER,
+    name CHAR(128) NOT NULL,
+    level SMALLINT NOT NULL,
+    state SMALLINT DEFAULT 1,
+    app_type SMALLINT NOT NULL,
+    sub_type SMALLINT DEFAULT 1,
+    deleted SMALLINT DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    contrast_type SMALLINT NOT NULL DEFAULT 1,
+    target_line_uid TEXT,
+    target_line_name TEXT,
+    target_field TEXT,
+    data_level CHAR(64) NOT NULL DEFAULT '1m',
+    upper_threshold DOUBLE PRECISION,
+    lower_threshold DOUBLE PRECISION,
+    agg SMALLINT DEFAULT 0,
+    delay SMALLINT DEFAULT 1,
+    threshold_critical TEXT,
+    threshold_error TEXT,
+    threshold_warning TEXT,
+    trigger_nodata_event SMALLINT,
+    query_url TEXT,
+    query_params TEXT,
+    query_conditions TEXT,
+    tag_conditions TEXT,
+    monitoring_frequency CHAR(64) DEFAULT '1m',
+    monitoring_interval CHAR(64) DEFAULT '1m',
+    trigger_info_event INTEGER DEFAULT 0,
+    trigger_recovery_event INTEGER DEFAULT 1,
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS alarm_event (
+    id SERIAL PRIMARY KEY,
+    status CHAR(64),
+    timestamp TIMESTAMP,
+    end_time BIGINT,
+    policy_id INTEGER,
+    policy_name TEXT,
+    policy_level INTEGER,
+    policy_app_type SMALLINT,
+    policy_sub_type SMALLINT,
+    policy_contrast_type SMALLINT,
+    policy_data_level CHAR(64),
+    policy_target_uid TEXT,
+    policy_target_name TEXT,
+    policy_go_to TEXT,
+    policy_target_field TEXT,
+    policy_endpoints TEXT,
+    sub_view_id INTEGER,
+    sub_view_name TEXT,
+    trigger_condition TEXT,
+    trigger_value INTEGER,
+    end_value TEXT,
+    value_unit CHAR(64),
+    endpoint_results TEXT,
+    event_level INTEGER,
+    lcuuid CHAR(64)
+);
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_error, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: 采集器', '', '/v1/alarm/vtap-lost/', '{}', '[{"OPERATOR": {"return_field": "sysalarm_value", "return_field_description": "最近 1 分钟失联次数", "return_field_unit": " 次"}}]', '采集器失联', 1, 1, 1, 20, 1, '', '', '{"displayName":"sysalarm_value", "unit": "次"}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_critical, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: 采集器', '', '/v1/alarm/vtap-exception/', '{}', '[{"OPERATOR": {"return_field": "sysalarm_value", "return_field_description": "最近 1 分钟异常状态个数", "return_field_unit": " 个"}}]', '采集器异常', 1, 1, 1, 20, 1, '', '', '{"displayName":"sysalarm_value", "unit": "个"}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, monitoring_interval, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_monitor","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.max_millicpus_ratio","METRIC_NAME":"metrics.max_millicpus_ratio","isTimeUnit":false,"type":1,"unit":"","checked":true,"operatorLv2":[{"operateLabel":"Math","mathOperator":"*","operatorValue":100}],"_key":"38813299-6cca-9b7f-4a08-5861fa7d6ee3","perOperator":"","operatorLv1":"Min","percentile":null,"markLine":null,"METRIC_LABEL":"cpu_usage","ORIGIN_METRIC_LABEL":"Math(Min(metrics.max_millicpus_ratio)*100)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_monitor","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_monitor","interval":60,"fill": "none","window_size":5,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Min(`metrics.max_millicpus_ratio`)*100 AS `cpu_usage`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Min(`metrics.max_millicpus_ratio`)*100 AS `cpu_usage`"]}]}', '[{"METRIC_LABEL":"cpu_usage","return_field_description":"持续 5 分钟 (CPU用量/阈值)","unit":"%"}]', '采集器 CPU 超限', 0, 1, 1, 21, 1, '', '', '{"displayName":"cpu_usage", "unit": "%"}', '{"OP":">=","VALUE":70}', '5m', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, monitoring_interval, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_monitor","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.max_memory_ratio","METRIC_NAME":"metrics.max_memory_ratio","isTimeUnit":false,"type":1,"unit":"","checked":true,"operatorLv2":[{"operateLabel":"Math","mathOperator":"*","operatorValue":100}],"_key":"38813299-6cca-9b7f-4a08-5861fa7d6ee3","perOperator":"","operatorLv1":"Min","percentile":null,"markLine":null,"METRIC_LABEL":"used_bytes","ORIGIN_METRIC_LABEL":"Math(Min(metrics.max_memory_ratio)*100)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_monitor","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_monitor","interval":60,"fill": "none","window_size":5,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Min(`metrics.max_memory_ratio`)*100 AS `used_bytes`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Min(`metrics.max_memory_ratio`)*100 AS `used_bytes`"]}]}', '[{"METRIC_LABEL":"used_bytes","return_field_description":"持续 5 分钟 (内存用量/阈值)","unit":"%"}]', '采集器内存超限', 0, 1, 1, 21, 1, '', '', '{"displayName":"used_bytes", "unit": "%"}', '{"OP":">=","VALUE":70}', '5m', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, agg, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: host', '', '/v1/stats/querier/UniversalPromHistory', '{"DATABASE":"","PROM_SQL":"delta(min(deepflow_tenant__deepflow_agent_monitor__create_time)by(host)[1m:10s])","interval":60,"metric":"process_start_time_delta","time_tag":"toi"}', '[{"METRIC_LABEL":"process_start","return_field_description":"最近 1 分钟进程启动时间变化","unit":" 毫秒"}]', '采集器重启', 0, 1, 1, 20, 1, '', '', '{"displayName":"process_start_time_delta", "unit": "毫秒"}', 1, '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, agg, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: *', '', '/v1/alarm/policy-event/', '{}', '[{"OPERATOR": {"return_field": "sysalarm_value", "return_field_description": "最近 1 分钟无效策略自动删除条数", "return_field_unit": "次"}}]', '无效策略自动删除', 0, 1, 1, 22, 1, '', '', '{"displayName":"sysalarm_value", "unit": "次"}', 1, '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_error, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: *', '', '/v1/alarm/platform-event/', '{}', '[{"OPERATOR": {"return_field": "sysalarm_value", "return_field_description": "最近 1 分钟云资源同步异常次数", "return_field_unit": "次"}}]', '云资源同步异常', 1, 1, 1, 23, 1, '', '', '{"displayName":"sysalarm_value", "unit": "次"}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_log_counter","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.warning","METRIC_NAME":"metrics.warning","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.error","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"log_counter_warning","checked":true,"percentile":null,"_key":"50d7a2a2-a14d-d202-1f3d-85fe7b9efac3","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.warning)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_log_counter","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_log_counter","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.warning`) AS `log_counter_warning`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.warning`) AS `log_counter_warning`"]}]}', '[{"METRIC_LABEL":"log_counter_warning","return_field_description":"最近 1 分钟 WARN 日志总条数","unit":" 条"}]', '采集器 WARN 日志过多', 0, 1, 1, 20, 1, '', '', '{"displayName":"log_counter_warning", "unit": "条"}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_error, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_log_counter","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.error","METRIC_NAME":"metrics.error","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.error","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"log_counter_error","checked":true,"percentile":null,"_key":"50d7a2a2-a14d-d202-1f3d-85fe7b9efac3","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.error)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_log_counter","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_log_counter","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.error`) AS `log_counter_error`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.error`) AS `log_counter_error`"]}]}', '[{"METRIC_LABEL":"log_counter_error","return_field_description":"最近 1 分钟 ERR 日志总条数","unit":" 条"}]', '采集器 ERR 日志过多', 1, 1, 1, 20, 1, '', '', '{"displayName":"log_counter_error", "unit": "条"}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_error, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.cluster_id', '[{"type":"deepflow","tableName":"controller_genesis_k8sinfo_delay","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.avg","METRIC_NAME":"metrics.avg","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.avg","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Last","perOperator":"","METRIC_LABEL":"delay","checked":true,"percentile":null,"_key":"8e92e913-a37f-ef34-8a4d-9169b96c6087","markLine":null,"ORIGIN_METRIC_LABEL":"Last(metrics.avg)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"controller_genesis_k8sinfo_delay","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"controller_genesis_k8sinfo_delay","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Last(`metrics.avg`) AS `delay`","WHERE":"1=1","GROUP_BY":"`tag.cluster_id`","METRICS":["Last(`metrics.avg`) AS `delay`"]}]}', '[{"METRIC_LABEL":"delay","return_field_description":"资源同步滞后时间","unit":" 秒"}]', 'K8s 资源同步滞后', 1, 1, 1, 23, 1, '', '', '{"displayName":"delay", "unit": "秒"}', '{"OP":">=","VALUE":600}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_dispatcher","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.kernel_drops","METRIC_NAME":"metrics.kernel_drops","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.err","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"dispatcher.metrics.kernel_drops","checked":true,"percentile":null,"_key":"96fd254b-e6c1-4cc1-69fa-da5f4dd927ed","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.kernel_drops)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_dispatcher","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_dispatcher","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.kernel_drops`) AS `dispatcher.metrics.kernel_drops`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.kernel_drops`) AS `dispatcher.metrics.kernel_drops`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 dispatcher.metrics.kernel_drops","unit":""}]', '采集器数据丢失 (dispatcher.metrics.kernel_drops)', 0, 1, 1, 21, 1, '', '', '{"displayName":"dispatcher.metrics.kernel_drops", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host, tag.module', '[{"type":"deepflow","tableName":"deepflow_agent_queue","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.overwritten","METRIC_NAME":"metrics.overwritten","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.in","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"queue.metrics.overwritten","checked":true,"percentile":null,"_key":"d61628e5-df0b-9337-6ee6-a3316a047e24","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.overwritten)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_queue","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host","tag.module"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host","tag.module"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_queue","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.overwritten`) AS `queue.metrics.overwritten`","WHERE":"1=1","GROUP_BY":"`tag.host`, `tag.module`","METRICS":["Sum(`metrics.overwritten`) AS `queue.metrics.overwritten`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 queue.metrics.overwritten","unit":""}]', '采集器数据丢失 (queue.metrics.overwritten)', 0, 1, 1, 21, 1, '', '', '{"displayName":"queue.metrics.overwritten", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_l7_session_aggr","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.throttle-drop","METRIC_NAME":"metrics.throttle-drop","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.cached","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"l7_session_aggr.metrics.throttle-drop","checked":true,"percentile":null,"_key":"c511eb55-3d46-c7a2-bfed-ebb42d02493c","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.throttle-drop)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_l7_session_aggr","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_l7_session_aggr","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.throttle-drop`) AS `l7_session_aggr.metrics.throttle-drop`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.throttle-drop`) AS `l7_session_aggr.metrics.throttle-drop`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 l7_session_aggr.metrics.throttle-drop","unit":""}]', '采集器数据丢失 (l7_session_aggr.metrics.throttle-drop)', 0, 1, 1, 21, 1, '', '', '{"displayName":"l7_session_aggr.metrics.throttle-drop", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_flow_aggr","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.drop-in-throttle","METRIC_NAME":"metrics.drop-in-throttle","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.drop-before-window","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"flow_aggr.metrics.drop-in-throttle","checked":true,"percentile":null,"_key":"e395cbb3-d5a2-283b-1b0a-834977bb6393","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.drop-in-throttle)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_flow_aggr","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_flow_aggr","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.drop-in-throttle`) AS `flow_aggr.metrics.drop-in-throttle`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.drop-in-throttle`) AS `flow_aggr.metrics.drop-in-throttle`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 flow_aggr.metrics.drop-in-throttle","unit":""}]', '采集器数据丢失 (flow_aggr.metrics.drop-in-throttle)', 0, 1, 1, 21, 1, '', '', '{"displayName":"flow_aggr.metrics.drop-in-throttle", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_ebpf_collector","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.kern_lost","METRIC_NAME":"metrics.kern_lost","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.boot_time_update_diff","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"ebpf_collector.metrics.kern_lost","checked":true,"percentile":null,"_key":"8f28cb9b-ec39-d605-c056-53b0f2788c13","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.kern_lost)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_ebpf_collector","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_ebpf_collector","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.kern_lost`) AS `ebpf_collector.metrics.kern_lost`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.kern_lost`) AS `ebpf_collector.metrics.kern_lost`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 ebpf_collector.metrics.kern_lost","unit":""}]', '采集器数据丢失 (ebpf_collector.metrics.kern_lost)', 0, 1, 1, 21, 1, '', '', '{"displayName":"ebpf_collector.metrics.kern_lost", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_ebpf_collector","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.user_enqueue_lost","METRIC_NAME":"metrics.user_enqueue_lost","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.boot_time_update_diff","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"ebpf_collector.metrics.user_enqueue_lost","checked":true,"percentile":null,"_key":"8f28cb9b-ec39-d605-c056-53b0f2788c13","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.user_enqueue_lost)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_ebpf_collector","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_ebpf_collector","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.user_enqueue_lost`) AS `ebpf_collector.metrics.user_enqueue_lost`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.kernuser_enqueue_lost_lost`) AS `ebpf_collector.metrics.user_enqueue_lost`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 ebpf_collector.metrics.user_enqueue_lost","unit":""}]', '采集器数据丢失 (ebpf_collector.metrics.user_enqueue_lost)', 0, 1, 1, 21, 1, '', '', '{"displayName":"ebpf_collector.metrics.user_enqueue_lost", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_dispatcher","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.invalid_packets","METRIC_NAME":"metrics.invalid_packets","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.err","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"dispatcher.metrics.invalid_packets","checked":true,"percentile":null,"_key":"41f6303b-f31e-8b7e-83c8-67a8edf735af","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.invalid_packets)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_dispatcher","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_dispatcher","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.invalid_packets`) AS `dispatcher.metrics.invalid_packets`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.invalid_packets`) AS `dispatcher.metrics.invalid_packets`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 dispatcher.metrics.invalid_packets","unit":""}]', '采集器数据丢失 (dispatcher.metrics.invalid_packets)', 0, 1, 1, 21, 1, '', '', '{"displayName":"dispatcher.metrics.invalid_packets", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_dispatcher","dbName":"deepflow_tenant","dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_dispatcher","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]},"metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.err","METRIC_NAME":"metrics.err","isTimeUnit":false,"type":1,"unit":"","checked":true,"operatorLv2":[],"_key":"6fb3545a-74eb-ac62-4e84-622c0265a840","perOperator":"","operatorLv1":"Sum","percentile":null,"markLine":null,"METRIC_LABEL":"dispatcher.metrics.err","ORIGIN_METRIC_LABEL":"Sum(metrics.err)"}]}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_dispatcher","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.err`) AS `dispatcher.metrics.err`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.err`) AS `dispatcher.metrics.err`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 dispatcher.metrics.err","unit":""}]', '采集器数据丢失 (dispatcher.metrics.err)', 0, 1, 1, 21, 1, '', '', '{"displayName":"dispatcher.metrics.err", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_flow_map","dbName":"deepflow_tenant","dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_flow_map","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]},"metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.drop_by_window","METRIC_NAME":"metrics.drop_by_window","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.closed","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"flow_map.metrics.drop_by_window","checked":true,"percentile":null,"_key":"629edc91-d806-d7ac-bdea-517f46ad6530","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.drop_by_window)"}]}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_flow_map","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.drop_by_window`) AS `flow_map.metrics.drop_by_window`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.drop_by_window`) AS `flow_map.metrics.drop_by_window`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 flow_map.metrics.drop_by_window","unit":""}]', '采集器数据丢失 (flow_map.metrics.drop_by_window)', 0, 1, 1, 21, 1, '', '', '{"displayName":"flow_map.metrics.drop_by_window", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_flow_map","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.drop_by_capacity","METRIC_NAME":"metrics.drop_by_capacity","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.closed","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"flow_map.metrics.drop_by_capacity","checked":true,"percentile":null,"_key":"988eb89d-d8cd-6827-d359-86b6c29fdbb6","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.drop_by_capacity)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_flow_map","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_flow_map","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.drop_by_capacity`) AS `flow_map.metrics.drop_by_capacity`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.drop_by_capacity`) AS `flow_map.metrics.drop_by_capacity`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 flow_map.metrics.drop_by_capacity","unit":""}]', '采集器数据丢失 (flow_map.metrics.drop_by_capacity)', 0, 1, 1, 21, 1, '', '', '{"displayName":"flow_map.metrics.drop_by_capacity", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_flow_aggr","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.drop-before-window","METRIC_NAME":"metrics.drop-before-window","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.drop-before-window","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"flow_aggr.metrics.drop-before-window","checked":true,"percentile":null,"_key":"d5ebf837-b5b6-e853-7933-e09506a781ff","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.drop-before-window)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_flow_aggr","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_flow_aggr","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.drop-before-window`) AS `flow_aggr.metrics.drop-before-window`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.drop-before-window`) AS `flow_aggr.metrics.drop-before-window`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 flow_aggr.metrics.drop-before-window","unit":""}]', '采集器数据丢失 (flow_aggr.metrics.drop-before-window)', 0, 1, 1, 21, 1, '', '', '{"displayName":"flow_aggr.metrics.drop-before-window", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_quadruple_generator","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.drop-before-window","METRIC_NAME":"metrics.drop-before-window","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.drop-before-window","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"quadruple_generator.metrics.drop-before-window","checked":true,"percentile":null,"_key":"79facee8-3875-df77-e375-2f7f955b0035","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.drop-before-window)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_quadruple_generator","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_quadruple_generator","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.drop-before-window`) AS `quadruple_generator.metrics.drop-before-window`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.drop-before-window`) AS `quadruple_generator.metrics.drop-before-window`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 quadruple_generator.metrics.drop_before_window","unit":""}]', '采集器数据丢失 (quadruple_generator.metrics.drop-before-window)', 0, 1, 1, 21, 1, '', '', '{"displayName":"quadruple_generator.metrics.drop-before-window", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_collector","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.drop-before-window","METRIC_NAME":"metrics.drop-before-window","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.drop-before-window","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"collector.metrics.drop-before-window","checked":true,"percentile":null,"_key":"e63575a2-333a-b612-0b57-684387f80431","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.drop-before-window)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_collector","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_collector","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.drop-before-window`) AS `collector.metrics.drop-before-window`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.drop-before-window`) AS `collector.metrics.drop-before-window`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 collector.metrics.drop_before_window","unit":""}]', '采集器数据丢失 (collector.metrics.drop-before-window)', 0, 1, 1, 21, 1, '', '', '{"displayName":"collector.metrics.drop-before-window", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_collector","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.drop-inactive","METRIC_NAME":"metrics.drop-inactive","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.drop-before-window","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"collector.metrics.drop-inactive","checked":true,"percentile":null,"_key":"e63575a2-333a-b612-0b57-684387f80431","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.drop-inactive)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_collector","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_collector","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.drop-inactive`) AS `collector.metrics.drop-inactive`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.drop-inactive`) AS `collector.metrics.drop-inactive`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 collector.metrics.drop-inactive","unit":""}]', '采集器数据丢失 (collector.metrics.drop-inactive)', 0, 1, 1, 21, 1, '', '', '{"displayName":"collector.metrics.drop-inactive", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+INSERT INTO alarm_policy (user_id, sub_view_type, tag_conditions, query_conditions, query_url, query_params, sub_view_metrics, name, level, state, app_type, sub_type, contrast_type, target_line_uid, target_line_name, target_field, threshold_warning, lcuuid) VALUES (1, 1, '过滤项: N/A | 分组项: tag.host', '[{"type":"deepflow","tableName":"deepflow_agent_collect_sender","dbName":"deepflow_tenant","metrics":[{"description":"","typeName":"counter","METRIC_CATEGORY":"metrics","METRIC":"metrics.dropped","METRIC_NAME":"metrics.dropped","isTimeUnit":false,"type":1,"unit":"","cascaderLabel":"metrics.dropped","display_name":"--","hasDerivative":false,"isPrometheus":false,"operatorLv2":[],"operatorLv1":"Sum","perOperator":"","METRIC_LABEL":"collect_sender.metrics.dropped","checked":true,"percentile":null,"_key":"7848fead-8554-591f-b0da-dec4180fa576","markLine":null,"ORIGIN_METRIC_LABEL":"Sum(metrics.dropped)"}],"dataSource":"","condition":{"dbName":"deepflow_tenant","tableName":"deepflow_agent_collect_sender","type":"simplified","RESOURCE_SETS":[{"id":"R1","condition":[],"groupBy":["_","tag.host"],"groupInfo":{"mainGroupInfo":["_"],"otherGroupInfo":["tag.host"]},"inputMode":"free"}]}}]', '/v1/stats/querier/UniversalHistory', '{"DATABASE":"deepflow_tenant","TABLE":"deepflow_agent_collect_sender","interval":60,"fill": "none","window_size":1,"QUERIES":[{"QUERY_ID":"R1","SELECT":"Sum(`metrics.dropped`) AS `collect_sender.metrics.dropped`","WHERE":"1=1","GROUP_BY":"`tag.host`","METRICS":["Sum(`metrics.dropped`) AS `collect_sender.metrics.dropped`"]}]}', '[{"METRIC_LABEL":"drop_packets","return_field_description":"最近 1 分钟 collect_sender.metrics.dropped","unit":""}]', '采集器数据丢失 (collect_sender.metrics.dropped)', 0, 1, 1, 21, 1, '', '', '{"displayName":"collect_sender.metrics.dropped", "unit": ""}', '{"OP":">=","VALUE":1}', (gen_random_uuid()::text));
+
+CREATE TABLE IF NOT EXISTS report_policy (
+    id SERIAL PRIMARY KEY,
+    name CHAR(64) NOT NULL,
+    view_id INTEGER NOT NULL,
+    user_id INTEGER,
+    data_level VARCHAR(64) NOT NULL DEFAULT '1m',
+    report_format SMALLINT DEFAULT 1,
+    report_type SMALLINT DEFAULT 1,
+    interval VARCHAR(64) NOT NULL DEFAULT '1h',
+    state SMALLINT DEFAULT 1,
+    push_type SMALLINT DEFAULT 1,
+    push_email TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    begin_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lcuuid CHAR(64) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS policy_acl_group (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER DEFAULT 1,
+    acl_ids TEXT NOT NULL,
+    count INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS vtap_group_configuration (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER DEFAULT 1,
+    team_id INTEGER DEFAULT 1,
+    max_collect_pps INTEGER DEFAULT NULL,
+    max_npb_bps BIGINT DEFAULT NULL,
+    max_cpus INTEGER DEFAULT NULL,
+    max_millicpus INTEGER DEFAULT NULL,
+    max_memory INTEGER DEFAULT NULL,
+    platform_sync_interval INTEGER DEFAULT NULL,
+    sync_interval INTEGER DEFAULT NULL,
+    stats_interval INTEGER,
+    rsyslog_enabled SMALLINT,
+    system_load_circuit_breaker_threshold REAL DEFAULT NULL,
+    system_load_circuit_breaker_recover REAL DEFAULT NULL,
+    system_load_circuit_breaker_metric CHAR(64) DEFAULT NULL,
+    max_tx_bandwidth BIGINT,
+    bandwidth_probe_interval INTEGER,
+    tap_interface_regex TEXT,
+    max_escape_seconds INTEGER,
+    mtu INTEGER,
+    output_vlan INTEGER DEFAULT NULL,
+    collector_socket_type CHAR(64),
+    compressor_socket_type CHAR(64),
+    npb_socket_type CHAR(64),
+    npb_vlan_mode INTEGER,
+    collector_enabled SMALLINT,
+    vtap_flow_1s_enabled SMALLINT,
+    l4_log_tap_types TEXT,
+    npb_dedup_enabled SMALLINT,
+    platform_enabled SMALLINT,
+    if_mac_source INTEGER,
+    vm_xml_path TEXT,
+    extra_netns_regex TEXT,
+    nat_ip_enabled SMALLINT,
+    capture_packet_size INTEGER,
+    inactive_server_port_enabled SMALLINT,
+    inactive_ip_enabled SMALLINT,
+    vtap_group_lcuuid CHAR(64) DEFAULT NULL,
+    log_threshold INTEGER,
+    log_level CHAR(64),
+    log_retention INTEGER,
+    http_log_proxy_client CHAR(64),
+    http_log_trace_id TEXT DEFAULT NULL,
+    l7_log_packet_size INTEGER,
+    l4_log_collect_nps_threshold INTEGER,
+    l7_log_collect_nps_threshold INTEGER,
+    l7_metrics_enabled SMALLINT,
+    l7_log_store_tap_types TEXT,
+    l4_log_ignore_tap_sides TEXT,
+    l7_log_ignore_tap_sides TEXT,
+    decap_type TEXT,
+    capture_socket_type INTEGER,
+    capture_bpf VARCHAR(512),
+    tap_mode INTEGER,
+    thread_threshold INTEGER,
+    process_threshold INTEGER,
+    ntp_enabled SMALLINT,
+    l4_performance_enabled SMALLINT,
+    pod_cluster_internal_ip SMALLINT,
+    domains TEXT,
+    http_log_span_id TEXT DEFAULT NULL,
+    http_log_x_request_id CHAR(64),
+    sys_free_memory_metric CHAR(64),
+    sys_free_memory_limit INTEGER DEFAULT NULL,
+    log_file_size INTEGER DEFAULT NULL,
+    external_agent_http_proxy_enabled SMALLINT,
+    external_agent_http_proxy_port INTEGER DEFAULT NULL,
+    proxy_controller_port INTEGER DEFAULT NULL,
+    analyzer_port INTEGER DEFAULT NULL,
+    proxy_controller_ip VARCHAR(128),
+    analyzer_ip VARCHAR(128),
+    wasm_plugins TEXT,
+    so_plugins TEXT,
+    yaml_config TEXT,
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS agent_group_configuration (
+    id SERIAL PRIMARY KEY,
+    lcuuid CHAR(64) NOT NULL,
+    agent_group_lcuuid CHAR(64) NOT NULL,
+    yaml TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS npb_tunnel (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER DEFAULT 1,
+    team_id INTEGER DEFAULT 1,
+    name CHAR(64) NOT NULL,
+    ip CHAR(64),
+    type INTEGER,
+    vni_input_type SMALLINT DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS tap_type (
+    id SERIAL PRIMARY KEY,
+    name CHAR(64) NOT NULL,
+    type INTEGER NOT NULL DEFAULT 1,
+    region CHAR(64),
+    value INTEGER NOT NULL,
+    vlan INTEGER,
+    src_ip CHAR(64),
+    interface_index BIGINT,
+    interface_name CHAR(64),
+    sampling_rate BIGINT,
+    description VARCHAR(256),
+    lcuuid CHAR(64)
+);
+
+INSERT INTO tap_type (name, value, vlan, description, lcuuid) VALUES ('云网络', 3, 768, '', (gen_random_uuid()::text));
+
+CREATE TABLE IF NOT EXISTS genesis_host (
+    lcuuid CHAR(64),
+    hostname VARCHAR(256),
+    ip CHAR(64),
+    vtap_id INTEGER,
+    node_ip CHAR(48),
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_vm (
+    lcuuid CHAR(64),
+    name VARCHAR(256),
+    label CHAR(64),
+    vpc_lcuuid CHAR(64),
+    launch_server CHAR(64),
+    node_ip CHAR(48),
+    state INTEGER,
+    vtap_id INTEGER,
+    created_at TIMESTAMP,
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_vip (
+    lcuuid CHAR(64),
+    ip CHAR(64),
+    vtap_id INTEGER,
+    node_ip CHAR(48),
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_vpc (
+    lcuuid CHAR(64),
+    node_ip CHAR(48),
+    vtap_id INTEGER,
+    name VARCHAR(256),
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_network (
+    name VARCHAR(256),
+    lcuuid CHAR(64),
+    segmentation_id INTEGER,
+    net_type INTEGER,
+    external SMALLINT,
+    vpc_lcuuid CHAR(64),
+    vtap_id INTEGER,
+    node_ip CHAR(48),
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_port (
+    lcuuid CHAR(64),
+    type INTEGER,
+    device_type INTEGER,
+    mac CHAR(32),
+    device_lcuuid CHAR(64),
+    network_lcuuid CHAR(64),
+    vpc_lcuuid CHAR(64),
+    vtap_id INTEGER,
+    node_ip CHAR(48),
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_ip (
+    lcuuid CHAR(64),
+    ip CHAR(64),
+    vinterface_lcuuid CHAR(64),
+    node_ip CHAR(48),
+    last_seen TIMESTAMP,
+    vtap_id INTEGER,
+    masklen INTEGER DEFAULT 0,
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_lldp (
+    lcuuid CHAR(64),
+    host_ip CHAR(48),
+    host_interface CHAR(64),
+    node_ip CHAR(48),
+    system_name VARCHAR(512),
+    management_address VARCHAR(512),
+    vinterface_lcuuid VARCHAR(512),
+    vinterface_description VARCHAR(512),
+    vtap_id INTEGER,
+    last_seen TIMESTAMP,
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_vinterface (
+    netns_id BIGINT DEFAULT 0,
+    lcuuid CHAR(64),
+    name CHAR(64),
+    mac CHAR(32),
+    ips TEXT,
+    tap_name CHAR(64),
+    tap_mac CHAR(32),
+    device_lcuuid CHAR(64),
+    device_name VARCHAR(512),
+    device_type CHAR(64),
+    if_type CHAR(64) DEFAULT '',
+    host_ip CHAR(48),
+    node_ip CHAR(48),
+    last_seen TIMESTAMP,
+    vtap_id INTEGER,
+    kubernetes_cluster_id CHAR(64),
+    team_id INTEGER DEFAULT 1,
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_process (
+    netns_id BIGINT DEFAULT 0,
+    vtap_id INTEGER NOT NULL DEFAULT 0,
+    pid INTEGER NOT NULL,
+    lcuuid CHAR(64) DEFAULT '',
+    name TEXT,
+    process_name TEXT,
+    cmd_line TEXT,
+    "user" VARCHAR(256) DEFAULT '',
+    container_id CHAR(64) DEFAULT '',
+    os_app_tags TEXT,
+    node_ip CHAR(48) DEFAULT '',
+    start_time TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (lcuuid, vtap_id, node_ip)
+);
+
+CREATE TABLE IF NOT EXISTS genesis_storage (
+    vtap_id INTEGER NOT NULL PRIMARY KEY,
+    node_ip CHAR(48)
+);
+
+CREATE TABLE IF NOT EXISTS controller (
+    id SERIAL PRIMARY KEY,
+    state INTEGER,
+    name CHAR(64),
+    description VARCHAR(256),
+    ip CHAR(64),
+    nat_ip CHAR(64),
+    cpu_num INTEGER DEFAULT 0,
+    memory_size BIGINT DEFAULT 0,
+    arch VARCHAR(256),
+    os VARCHAR(256),
+    kernel_version VARCHAR(256),
+    vtap_max INTEGER DEFAULT 2000,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    nat_ip_enabled SMALLINT DEFAULT 0,
+    node_type INTEGER DEFAULT 2,
+    region_domain_prefix VARCHAR(256) DEFAULT '',
+    node_name CHAR(64),
+    pod_ip CHAR(64),
+    pod_name CHAR(64),
+    ca_md5 CHAR(64),
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS analyzer (
+    id SERIAL PRIMARY KEY,
+    state INTEGER,
+    ha_state INTEGER DEFAULT 1,
+    name CHAR(64),
+    description VARCHAR(256),
+    ip CHAR(64),
+    nat_ip CHAR(64),
+    agg INTEGER DEFAULT 1,
+    cpu_num INTEGER DEFAULT 0,
+    memory_size BIGINT DEFAULT 0,
+    arch VARCHAR(256),
+    os VARCHAR(256),
+    kernel_version VARCHAR(256),
+    tsdb_shard_id INTEGER,
+    tsdb_replica_ip CHAR(64),
+    tsdb_data_mount_path VARCHAR(256),
+    pcap_data_mount_path VARCHAR(256),
+    vtap_max INTEGER DEFAULT 200,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    nat_ip_enabled SMALLINT DEFAULT 0,
+    pod_ip CHAR(64),
+    pod_name CHAR(64),
+    ca_md5 CHAR(64),
+    lcuuid CHAR(64)
+);
+
+CREATE TABLE IF NOT EXISTS ch_region (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    icon_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_az (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    icon_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_l3_epc (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    uid CHAR(64),
+    icon_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_subnet (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    icon_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_cluster (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    icon_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_node (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    pod_cluster_id INTEGER,
+    icon_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_ns (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    icon_id INTEGER,
+    pod_cluster_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_group (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    pod_group_type INTEGER DEFAULT NULL,
+    icon_id INTEGER,
+    pod_cluster_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    icon_id INTEGER,
+    pod_cluster_id INTEGER,
+    pod_ns_id INTEGER,
+    pod_node_id INTEGER,
+    pod_service_id INTEGER,
+    pod_group_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_device (
+    devicetype INTEGER NOT NULL,
+    deviceid INTEGER NOT NULL,
+    name TEXT,
+    uid CHAR(64),
+    icon_id INTEGER,
+    ip CHAR(64),
+    hostname VARCHAR(256),
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (devicetype, deviceid)
+);
+
+INSERT INTO ch_device (devicetype, deviceid, name, icon_id, team_id, domain_id, sub_domain_id) VALUES (63999, 63999, 'Internet', - 1, 0, 0, 0);
+
+INSERT INTO ch_device (devicetype, deviceid, icon_id, team_id, domain_id, sub_domain_id) VALUES (64000, 64000, - 10, 0, 0, 0);
+
+CREATE TABLE IF NOT EXISTS ch_vtap_port (
+    vtap_id INTEGER NOT NULL,
+    tap_port BIGINT NOT NULL,
+    name VARCHAR(256),
+    mac_type INTEGER DEFAULT 1,
+    host_id INTEGER,
+    host_name VARCHAR(256),
+    chost_id INTEGER,
+    chost_name VARCHAR(256),
+    pod_node_id INTEGER,
+    pod_node_name VARCHAR(256),
+    device_type INTEGER,
+    device_id INTEGER,
+    device_name VARCHAR(256),
+    icon_id INTEGER,
+    team_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (vtap_id, tap_port)
+);
+
+CREATE TABLE IF NOT EXISTS ch_tap_type (
+    value INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_vtap (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    type INTEGER,
+    team_id INTEGER,
+    host_id INTEGER,
+    host_name VARCHAR(256),
+    chost_id INTEGER,
+    chost_name VARCHAR(256),
+    pod_node_id INTEGER,
+    pod_node_name VARCHAR(256),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_k8s_label (
+    id INTEGER NOT NULL,
+    key VARCHAR(256) NOT NULL,
+    value VARCHAR(256),
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, key)
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_k8s_labels (
+    id INTEGER NOT NULL PRIMARY KEY,
+    labels TEXT,
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_ip_relation (
+    l3_epc_id INTEGER NOT NULL,
+    ip CHAR(64) NOT NULL,
+    natgw_id INTEGER,
+    natgw_name VARCHAR(256),
+    lb_id INTEGER,
+    lb_name VARCHAR(256),
+    lb_listener_id INTEGER,
+    lb_listener_name VARCHAR(256),
+    pod_ingress_id INTEGER,
+    pod_ingress_name VARCHAR(256),
+    pod_service_id INTEGER,
+    pod_service_name VARCHAR(256),
+    team_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (l3_epc_id, ip)
+);
+
+CREATE TABLE IF NOT EXISTS ch_ip_resource (
+    ip VARCHAR(64) NOT NULL,
+    subnet_id INTEGER NOT NULL,
+    subnet_name VARCHAR(256),
+    region_id INTEGER,
+    region_name VARCHAR(256),
+    az_id INTEGER,
+    az_name VARCHAR(256),
+    host_id INTEGER,
+    host_name VARCHAR(256),
+    chost_id INTEGER,
+    chost_name VARCHAR(256),
+    l3_epc_id INTEGER,
+    l3_epc_name VARCHAR(256),
+    router_id INTEGER,
+    router_name VARCHAR(256),
+    dhcpgw_id INTEGER,
+    dhcpgw_name VARCHAR(256),
+    lb_id INTEGER,
+    lb_name VARCHAR(256),
+    lb_listener_id INTEGER,
+    lb_listener_name VARCHAR(256),
+    natgw_id INTEGER,
+    natgw_name VARCHAR(256),
+    redis_id INTEGER,
+    redis_name VARCHAR(256),
+    rds_id INTEGER,
+    rds_name VARCHAR(256),
+    pod_cluster_id INTEGER,
+    pod_cluster_name VARCHAR(256),
+    pod_ns_id INTEGER,
+    pod_ns_name VARCHAR(256),
+    pod_node_id INTEGER,
+    pod_node_name VARCHAR(256),
+    pod_ingress_id INTEGER,
+    pod_ingress_name VARCHAR(256),
+    pod_service_id INTEGER,
+    pod_service_name VARCHAR(256),
+    pod_group_id INTEGER,
+    pod_group_name VARCHAR(256),
+    pod_id INTEGER,
+    pod_name VARCHAR(256),
+    uid CHAR(64),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (ip, subnet_id)
+);
+
+CREATE TABLE IF NOT EXISTS ch_lb_listener (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    team_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_ingress (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    pod_cluster_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_node_type (
+    resource_type INTEGER NOT NULL DEFAULT 0 PRIMARY KEY,
+    node_type VARCHAR(256),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO vl2 (state, name, net_type, isp, lcuuid, domain) VALUES (0, 'PublicNetwork', 3, 7, 'ffffffff-ffff-ffff-ffff-ffffffffffff', 'ffffffff-ffff-ffff-ffff-ffffffffffff');
+
+INSERT INTO vtap_group (lcuuid, id, name, short_uuid, team_id) VALUES ((gen_random_uuid()::text), 1, 'default', (('g-' || (substr(replace(gen_random_uuid()::text, '-', ''), 1, 10)))), 1);
+
+CREATE TABLE IF NOT EXISTS data_source (
+    id SERIAL PRIMARY KEY,
+    display_name CHAR(64),
+    data_table_collection CHAR(64),
+    state INTEGER DEFAULT 1,
+    base_data_source_id INTEGER,
+    interval INTEGER NOT NULL,
+    retention_time INTEGER NOT NULL,
+    summable_metrics_operator CHAR(64),
+    unsummable_metrics_operator CHAR(64),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    lcuuid CHAR(64)
+);
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (1, '网络-指标（秒级）', 'flow_metrics.network*', 1, 1 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, base_data_source_id, interval, retention_time, summable_metrics_operator, unsummable_metrics_operator, lcuuid) VALUES (3, '网络-指标（分钟级）', 'flow_metrics.network*', 1, 60, 7 * 24, 'Sum', 'Avg', (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (6, '网络-流日志', 'flow_log.l4_flow_log', 0, 3 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (7, '应用-指标（秒级）', 'flow_metrics.application*', 1, 1 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, base_data_source_id, interval, retention_time, summable_metrics_operator, unsummable_metrics_operator, lcuuid) VALUES (8, '应用-指标（分钟级）', 'flow_metrics.application*', 7, 60, 7 * 24, 'Sum', 'Avg', (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (9, '应用-调用日志', 'flow_log.l7_flow_log', 0, 3 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (10, '网络-TCP 时序数据', 'flow_log.l4_packet', 0, 3 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (11, '网络-PCAP 数据', 'flow_log.l7_packet', 0, 3 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (12, '租户侧监控数据', 'deepflow_tenant.*', 0, 7 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (13, '外部指标数据', 'ext_metrics.*', 0, 7 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (14, 'Prometheus 数据', 'prometheus.*', 0, 7 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (15, '事件-资源变更事件', 'event.event', 0, 30 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (16, '事件-IO 事件', 'event.perf_event', 0, 7 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (17, '事件-告警事件', 'event.alert_event', 0, 30 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (18, '应用-性能剖析', 'profile.in_process', 0, 3 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (19, '网络-网络策略', 'flow_metrics.traffic_policy', 60, 3 * 24, (gen_random_uuid()::text));
+
+INSERT INTO data_source (id, display_name, data_table_collection, interval, retention_time, lcuuid) VALUES (20, '日志-日志数据', 'application_log.log', 1, 30 * 24, (gen_random_uuid()::text));
+
+CREATE TABLE IF NOT EXISTS voucher (
+    id SERIAL PRIMARY KEY,
+    status INTEGER DEFAULT 0,
+    name VARCHAR(256) DEFAULT NULL,
+    value BYTEA,
+    lcuuid CHAR(64) DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS license_func_log (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER DEFAULT 1,
+    agent_id INTEGER NOT NULL,
+    agent_name VARCHAR(256) NOT NULL,
+    user_id INTEGER NOT NULL,
+    license_function INTEGER NOT NULL,
+    enabled INTEGER NOT NULL,
+    agent_group_name VARCHAR(64) DEFAULT NULL,
+    agent_group_operation SMALLINT DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS kubernetes_cluster (
+    id SERIAL PRIMARY KEY,
+    cluster_id VARCHAR(256) NOT NULL,
+    value VARCHAR(256) NOT NULL,
+    updated_time TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    synced_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (cluster_id)
+);
+
+CREATE TABLE IF NOT EXISTS mail_server (
+    id SERIAL PRIMARY KEY,
+    status INTEGER NOT NULL,
+    host TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    "user" TEXT NOT NULL,
+    password TEXT NOT NULL,
+    security TEXT NOT NULL,
+    ntlm_enabled INTEGER,
+    ntlm_name TEXT,
+    ntlm_password TEXT,
+    lcuuid CHAR(64) DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS slo (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    description TEXT,
+    sli_type VARCHAR(64) NOT NULL DEFAULT 'availability',
+    sli_source VARCHAR(64) NOT NULL DEFAULT 'deepflow',
+    db VARCHAR(64) DEFAULT '',
+    good_query TEXT NOT NULL,
+    total_query TEXT NOT NULL,
+    target DOUBLE PRECISION NOT NULL,
+    time_window INTEGER NOT NULL DEFAULT 2592000,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lcuuid CHAR(64) DEFAULT '',
+    UNIQUE (name),
+    UNIQUE (lcuuid)
+);
+
+CREATE TABLE IF NOT EXISTS ch_string_enum (
+    tag_name VARCHAR(256) NOT NULL,
+    value VARCHAR(256) NOT NULL,
+    name VARCHAR(256),
+    description VARCHAR(256),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tag_name, value)
+);
+
+CREATE TABLE IF NOT EXISTS ch_int_enum (
+    tag_name VARCHAR(256) NOT NULL,
+    value INTEGER DEFAULT 0,
+    name VARCHAR(256),
+    description VARCHAR(256),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tag_name, value)
+);
+
+CREATE TABLE IF NOT EXISTS dial_test_task (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    protocol INTEGER NOT NULL,
+    host VARCHAR(256) NOT NULL,
+    overtime_time INTEGER DEFAULT 2000,
+    payload INTEGER DEFAULT 64,
+    ttl SMALLINT DEFAULT 64,
+    dial_location VARCHAR(256) NOT NULL,
+    dial_frequency INTEGER DEFAULT 1000,
+    pcap BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_chost_cloud_tag (
+    id INTEGER NOT NULL,
+    key VARCHAR(256) NOT NULL,
+    value VARCHAR(256),
+    team_id INTEGER,
+    domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, key)
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_ns_cloud_tag (
+    id INTEGER NOT NULL,
+    key VARCHAR(256) NOT NULL,
+    value VARCHAR(256),
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, key)
+);
+
+CREATE TABLE IF NOT EXISTS ch_chost_cloud_tags (
+    id INTEGER NOT NULL PRIMARY KEY,
+    cloud_tags TEXT,
+    team_id INTEGER,
+    domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_ns_cloud_tags (
+    id INTEGER NOT NULL PRIMARY KEY,
+    cloud_tags TEXT,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_os_app_tag (
+    pid INTEGER NOT NULL,
+    key VARCHAR(256) NOT NULL,
+    value VARCHAR(256),
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (pid, key)
+);
+
+CREATE TABLE IF NOT EXISTS ch_os_app_tags (
+    pid INTEGER NOT NULL PRIMARY KEY,
+    os_app_tags TEXT,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_gprocess (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name TEXT,
+    icon_id INTEGER,
+    chost_id INTEGER,
+    l3_epc_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_service_k8s_label (
+    id INTEGER NOT NULL,
+    key VARCHAR(256) NOT NULL,
+    value VARCHAR(256),
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, key)
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_service_k8s_labels (
+    id INTEGER NOT NULL PRIMARY KEY,
+    labels TEXT,
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_k8s_annotation (
+    id INTEGER NOT NULL,
+    key VARCHAR(256) NOT NULL,
+    value VARCHAR(256),
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, key)
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_k8s_annotations (
+    id INTEGER NOT NULL PRIMARY KEY,
+    annotations TEXT,
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_service_k8s_annotation (
+    id INTEGER NOT NULL,
+    key VARCHAR(256) NOT NULL,
+    value VARCHAR(256),
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, key)
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_service_k8s_annotations (
+    id INTEGER NOT NULL PRIMARY KEY,
+    annotations TEXT,
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS prometheus_metric_name (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL UNIQUE,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS prometheus_label_name (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL UNIQUE,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS prometheus_label_value (
+    id SERIAL PRIMARY KEY,
+    value TEXT,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS prometheus_label (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    value TEXT,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS prometheus_metric_app_label_layout (
+    id SERIAL PRIMARY KEY,
+    metric_name VARCHAR(256) NOT NULL,
+    app_label_name VARCHAR(256) NOT NULL,
+    app_label_column_index SMALLINT NOT NULL,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (metric_name, app_label_name)
+);
+
+CREATE TABLE IF NOT EXISTS prometheus_metric_label_name (
+    id SERIAL PRIMARY KEY,
+    metric_name VARCHAR(256) NOT NULL,
+    label_name_id INTEGER NOT NULL,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (metric_name, label_name_id)
+);
+
+CREATE TABLE IF NOT EXISTS prometheus_metric_target (
+    id SERIAL PRIMARY KEY,
+    metric_name VARCHAR(256) NOT NULL,
+    target_id INTEGER NOT NULL,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (metric_name, target_id)
+);
+
+CREATE TABLE IF NOT EXISTS resource_version (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    version INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO resource_version (name, version) VALUES ('prometheus', (CAST(EXTRACT(EPOCH FROM NOW()) AS INTEGER)));
+
+CREATE TABLE IF NOT EXISTS ch_pod_k8s_env (
+    id INTEGER NOT NULL,
+    key VARCHAR(256) NOT NULL,
+    value VARCHAR(256),
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, key)
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_k8s_envs (
+    id INTEGER NOT NULL PRIMARY KEY,
+    envs TEXT,
+    l3_epc_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_app_label (
+    label_name_id INTEGER NOT NULL,
+    label_value_id INTEGER NOT NULL,
+    label_value TEXT,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (label_name_id, label_value_id)
+);
+
+CREATE TABLE IF NOT EXISTS ch_target_label (
+    metric_id INTEGER NOT NULL,
+    label_name_id INTEGER NOT NULL,
+    target_id INTEGER NOT NULL,
+    label_value VARCHAR(256) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (metric_id, label_name_id, target_id)
+);
+
+CREATE TABLE IF NOT EXISTS ch_prometheus_label_name (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_prometheus_metric_name (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_prometheus_metric_app_label_layout (
+    id INTEGER NOT NULL PRIMARY KEY,
+    metric_name VARCHAR(256) NOT NULL,
+    app_label_name VARCHAR(256) NOT NULL,
+    app_label_column_index SMALLINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_prometheus_target_label_layout (
+    target_id INTEGER NOT NULL PRIMARY KEY,
+    target_label_names TEXT,
+    target_label_values TEXT,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_pod_service (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    pod_cluster_id INTEGER,
+    pod_ns_id INTEGER,
+    team_id INTEGER,
+    domain_id INTEGER,
+    sub_domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_chost (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    host_id INTEGER,
+    l3_epc_id INTEGER,
+    ip CHAR(64),
+    hostname VARCHAR(256),
+    team_id INTEGER,
+    domain_id INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_policy (
+    tunnel_type INTEGER NOT NULL,
+    acl_gid INTEGER NOT NULL,
+    id INTEGER,
+    name VARCHAR(256),
+    team_id INTEGER DEFAULT 1,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tunnel_type, acl_gid)
+);
+
+CREATE TABLE IF NOT EXISTS ch_npb_tunnel (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    team_id INTEGER DEFAULT 1,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_alarm_policy (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    user_id INTEGER,
+    team_id INTEGER DEFAULT 1,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ch_user (
+    id INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(256),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+SELECT setval(pg_get_serial_sequence('az', 'id'), (SELECT MAX(id) FROM az));
+
+SELECT setval(pg_get_serial_sequence('data_source', 'id'), (SELECT MAX(id) FROM data_source));
+
+SELECT setval(pg_get_serial_sequence('region', 'id'), (SELECT MAX(id) FROM region));
+
+SELECT setval(pg_get_serial_sequence('sys_configuration', 'id'), (SELECT MAX(id) FROM sys_configuration));
+
+SELECT setval(pg_get_serial_sequence('vtap_group', 'id'), (SELECT MAX(id) FROM vtap_group));
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS slo (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(256) NOT NULL,
+    description TEXT,
+    sli_type VARCHAR(64) NOT NULL DEFAULT 'availability',
+    sli_source VARCHAR(64) NOT NULL DEFAULT 'deepflow',
+    db VARCHAR(64) DEFAULT '',
+    good_query TEXT NOT NULL,
+    total_query TEXT NOT NULL,
+    target DOUBLE NOT NULL,
+    time_window INTEGER NOT NULL DEFAULT 2592000,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lcuuid CHAR(64) DEFAULT '',
+    UNIQUE (name),
+    UNIQUE (lcuuid)
+);
+
+UPDATE db_version SET version='6.6.1.15';
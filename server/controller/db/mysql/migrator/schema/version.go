@@ -18,7 +18,7 @@ package schema
 
 const (
 	DB_VERSION_TABLE    = "db_version"
-	DB_VERSION_EXPECTED = "6.6.1.14"
+	DB_VERSION_EXPECTED = "6.6.1.15"
 )
 
 const (
@@ -27,4 +27,14 @@ const (
 		created_at          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at          DATETIME NOT NULL ON UPDATE CURRENT_TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	)ENGINE=innodb DEFAULT CHARSET=utf8;`
+	CREATE_TABLE_DB_VERSION_POSTGRESQL = `CREATE TABLE IF NOT EXISTS db_version (
+		version             CHAR(64) PRIMARY KEY,
+		created_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	CREATE_TABLE_DB_VERSION_SQLITE = `CREATE TABLE IF NOT EXISTS db_version (
+		version             CHAR(64) PRIMARY KEY,
+		created_at          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
 )
@@ -518,6 +518,26 @@ func (MailServer) TableName() string {
 	return "mail_server"
 }
 
+type SLO struct {
+	ID          int       `gorm:"primaryKey;column:id;type:int;not null" json:"ID"`
+	Name        string    `gorm:"column:name;type:varchar(256);not null" json:"NAME"`
+	Description string    `gorm:"column:description;type:text" json:"DESCRIPTION"`
+	SLIType     string    `gorm:"column:sli_type;type:varchar(64);not null;default:availability" json:"SLI_TYPE"` // availability, latency
+	SLISource   string    `gorm:"column:sli_source;type:varchar(64);not null;default:deepflow" json:"SLI_SOURCE"` // deepflow, promql
+	DB          string    `gorm:"column:db;type:varchar(64);default:''" json:"DB"`
+	GoodQuery   string    `gorm:"column:good_query;type:text;not null" json:"GOOD_QUERY"`
+	TotalQuery  string    `gorm:"column:total_query;type:text;not null" json:"TOTAL_QUERY"`
+	Target      float64   `gorm:"column:target;type:double;not null" json:"TARGET"`                        // unit: %
+	TimeWindow  int       `gorm:"column:time_window;type:int;not null;default:2592000" json:"TIME_WINDOW"` // unit: s
+	CreatedAt   time.Time `gorm:"column:created_at;type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"CREATED_AT"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"UPDATED_AT"`
+	Lcuuid      string    `gorm:"unique;column:lcuuid;type:char(64)" json:"LCUUID"`
+}
+
+func (SLO) TableName() string {
+	return "slo"
+}
+
 type AlarmPolicy struct {
 	ID     int    `gorm:"primaryKey;column:id;type:int;not null" json:"ID"`
 	Name   string `gorm:"column:name;type:char(128)" json:"NAME"`
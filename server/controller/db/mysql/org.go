@@ -77,17 +77,9 @@ func GetDeletedORGIDs() ([]int, error) {
 	return ids, nil
 }
 
+// CheckIfORGTableExists checks org table in the current database, gorm migrator works for all supported database types.
 func CheckIfORGTableExists() (bool, error) {
-	var orgTable string
-	err := DefaultDB.Raw(fmt.Sprintf("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA='%s' AND TABLE_NAME='%s'", GetConfig().Database, ORG_TABLE)).Scan(&orgTable).Error
-	if err != nil {
-		log.Errorf("failed to check org table: %v", err.Error())
-		return false, err
-	}
-	if orgTable == "" {
-		return false, nil
-	}
-	return true, nil
+	return DefaultDB.Migrator().HasTable(ORG_TABLE), nil
 }
 
 func CheckORGNumberAndLog() ([]int, error) {
@@ -41,6 +41,7 @@ func NewPlugin() *Plugin {
 func (p *Plugin) RegisterTo(e *gin.Engine) {
 	e.GET("/v1/plugin/", getPlugin)
 	e.POST("/v1/plugin/", createPlugin)
+	e.POST("/v1/plugin/test/", testPlugin)
 	e.DELETE("/v1/plugin/:name/", deletePlugin)
 }
 
@@ -99,6 +100,51 @@ func createPlugin(c *gin.Context) {
 	JsonResponse(c, data, err)
 }
 
+// testPlugin dry-runs an uploaded lua plugin without saving it
+func testPlugin(c *gin.Context) {
+	plugin := &mysqlmodel.Plugin{
+		Name: c.PostForm("NAME"),
+		Type: common.PLUGIN_TYPE_LUA,
+		User: common.PLUGIN_USER_SERVER,
+	}
+	image, err := readFormFile(c, "IMAGE")
+	if err != nil {
+		BadRequestResponse(c, httpcommon.INVALID_PARAMETERS, err.Error())
+		return
+	}
+	plugin.Image = image
+
+	// METADATA is optional, live metadata of DOMAIN is used if not specified
+	var metaData []byte
+	if _, _, err := c.Request.FormFile("METADATA"); err == nil {
+		if metaData, err = readFormFile(c, "METADATA"); err != nil {
+			BadRequestResponse(c, httpcommon.INVALID_PARAMETERS, err.Error())
+			return
+		}
+	}
+
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.TestPlugin(dbInfo, plugin, metaData, c.PostForm("DOMAIN"))
+	JsonResponse(c, data, err)
+}
+
+func readFormFile(c *gin.Context, key string) ([]byte, error) {
+	file, _, err := c.Request.FormFile(key)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	buf := bytes.NewBuffer(nil)
+	if _, err = io.Copy(buf, file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 func deletePlugin(c *gin.Context) {
 	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
 	if err != nil {
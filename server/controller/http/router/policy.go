@@ -0,0 +1,291 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/deepflowio/deepflow/server/controller/db/mysql"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	. "github.com/deepflowio/deepflow/server/controller/http/router/common"
+	"github.com/deepflowio/deepflow/server/controller/http/service"
+	"github.com/deepflowio/deepflow/server/controller/model"
+)
+
+// Policy manages pcap policies, npb policies and their acls and resource groups. Writes with ?dry_run=true
+// return the agents affected by the change instead of saving it.
+type Policy struct{}
+
+func NewPolicy() *Policy {
+	return new(Policy)
+}
+
+func (p *Policy) RegisterTo(e *gin.Engine) {
+	e.GET("/v1/pcap-policies/", getPcapPolicy)
+	e.POST("/v1/pcap-policies/", createPcapPolicy)
+	e.PATCH("/v1/pcap-policies/:lcuuid/", updatePcapPolicy)
+	e.DELETE("/v1/pcap-policies/:lcuuid/", deletePcapPolicy)
+
+	e.GET("/v1/npb-policies/", getNpbPolicy)
+	e.POST("/v1/npb-policies/", createNpbPolicy)
+	e.PATCH("/v1/npb-policies/:lcuuid/", updateNpbPolicy)
+	e.DELETE("/v1/npb-policies/:lcuuid/", deleteNpbPolicy)
+
+	e.GET("/v1/acls/", getACL)
+	e.POST("/v1/acls/", createACL)
+	e.PATCH("/v1/acls/:lcuuid/", updateACL)
+	e.DELETE("/v1/acls/:lcuuid/", deleteACL)
+
+	e.GET("/v1/resource-groups/", getResourceGroup)
+	e.POST("/v1/resource-groups/", createResourceGroup)
+	e.PATCH("/v1/resource-groups/:lcuuid/", updateResourceGroup)
+	e.DELETE("/v1/resource-groups/:lcuuid/", deleteResourceGroup)
+}
+
+func isDryRun(c *gin.Context) bool {
+	return c.Query("dry_run") == "true"
+}
+
+func getPolicyFilter(c *gin.Context, params ...string) map[string]interface{} {
+	args := make(map[string]interface{})
+	for _, param := range append([]string{"lcuuid", "name"}, params...) {
+		if value, ok := c.GetQuery(param); ok {
+			args[param] = value
+		}
+	}
+	return args
+}
+
+// getPolicyPatchMap 避免struct会有默认值，这里转为map作为函数入参
+func getPolicyPatchMap(c *gin.Context) (map[string]interface{}, bool) {
+	patchMap := map[string]interface{}{}
+	if err := c.ShouldBindBodyWith(&patchMap, binding.JSON); err != nil {
+		BadRequestResponse(c, httpcommon.INVALID_PARAMETERS, err.Error())
+		return nil, false
+	}
+	return patchMap, true
+}
+
+func getPcapPolicy(c *gin.Context) {
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.GetPcapPolicy(dbInfo, getPolicyFilter(c, "acl_id"))
+	JsonResponse(c, data, err)
+}
+
+func createPcapPolicy(c *gin.Context) {
+	var policyCreate model.PcapPolicyCreate
+
+	// 参数校验
+	if err := c.ShouldBindBodyWith(&policyCreate, binding.JSON); err != nil {
+		BadRequestResponse(c, httpcommon.INVALID_PARAMETERS, err.Error())
+		return
+	}
+
+	userInfo := httpcommon.GetUserInfo(c)
+	dbInfo, err := mysql.GetDB(userInfo.ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.CreatePcapPolicy(dbInfo, policyCreate, userInfo.ID, isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func updatePcapPolicy(c *gin.Context) {
+	patchMap, ok := getPolicyPatchMap(c)
+	if !ok {
+		return
+	}
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.UpdatePcapPolicy(dbInfo, c.Param("lcuuid"), patchMap, isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func deletePcapPolicy(c *gin.Context) {
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.DeletePcapPolicy(dbInfo, c.Param("lcuuid"), isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func getNpbPolicy(c *gin.Context) {
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.GetNpbPolicy(dbInfo, getPolicyFilter(c, "acl_id", "npb_tunnel_id"))
+	JsonResponse(c, data, err)
+}
+
+func createNpbPolicy(c *gin.Context) {
+	var policyCreate model.NpbPolicyCreate
+
+	// 参数校验
+	if err := c.ShouldBindBodyWith(&policyCreate, binding.JSON); err != nil {
+		BadRequestResponse(c, httpcommon.INVALID_PARAMETERS, err.Error())
+		return
+	}
+
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.CreateNpbPolicy(dbInfo, policyCreate, isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func updateNpbPolicy(c *gin.Context) {
+	patchMap, ok := getPolicyPatchMap(c)
+	if !ok {
+		return
+	}
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.UpdateNpbPolicy(dbInfo, c.Param("lcuuid"), patchMap, isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func deleteNpbPolicy(c *gin.Context) {
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.DeleteNpbPolicy(dbInfo, c.Param("lcuuid"), isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func getACL(c *gin.Context) {
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.GetACL(dbInfo, getPolicyFilter(c))
+	JsonResponse(c, data, err)
+}
+
+func createACL(c *gin.Context) {
+	var aclCreate model.ACLCreate
+
+	// 参数校验
+	if err := c.ShouldBindBodyWith(&aclCreate, binding.JSON); err != nil {
+		BadRequestResponse(c, httpcommon.INVALID_PARAMETERS, err.Error())
+		return
+	}
+
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.CreateACL(dbInfo, aclCreate, isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func updateACL(c *gin.Context) {
+	patchMap, ok := getPolicyPatchMap(c)
+	if !ok {
+		return
+	}
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.UpdateACL(dbInfo, c.Param("lcuuid"), patchMap, isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func deleteACL(c *gin.Context) {
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.DeleteACL(dbInfo, c.Param("lcuuid"), isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func getResourceGroup(c *gin.Context) {
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.GetResourceGroup(dbInfo, getPolicyFilter(c))
+	JsonResponse(c, data, err)
+}
+
+func createResourceGroup(c *gin.Context) {
+	var groupCreate model.ResourceGroupCreate
+
+	// 参数校验
+	if err := c.ShouldBindBodyWith(&groupCreate, binding.JSON); err != nil {
+		BadRequestResponse(c, httpcommon.INVALID_PARAMETERS, err.Error())
+		return
+	}
+
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.CreateResourceGroup(dbInfo, groupCreate, isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func updateResourceGroup(c *gin.Context) {
+	patchMap, ok := getPolicyPatchMap(c)
+	if !ok {
+		return
+	}
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.UpdateResourceGroup(dbInfo, c.Param("lcuuid"), patchMap, isDryRun(c))
+	JsonResponse(c, data, err)
+}
+
+func deleteResourceGroup(c *gin.Context) {
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.DeleteResourceGroup(dbInfo, c.Param("lcuuid"), isDryRun(c))
+	JsonResponse(c, data, err)
+}
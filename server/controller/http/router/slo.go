@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/deepflowio/deepflow/server/controller/db/mysql"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	. "github.com/deepflowio/deepflow/server/controller/http/router/common"
+	"github.com/deepflowio/deepflow/server/controller/http/service"
+	"github.com/deepflowio/deepflow/server/controller/model"
+)
+
+type SLO struct{}
+
+func NewSLO() *SLO {
+	return new(SLO)
+}
+
+func (s *SLO) RegisterTo(e *gin.Engine) {
+	e.GET("/v1/slos/", getSLO)
+	e.POST("/v1/slos/", createSLO)
+	e.PATCH("/v1/slos/:lcuuid/", updateSLO)
+	e.DELETE("/v1/slos/:lcuuid/", deleteSLO)
+}
+
+func getSLO(c *gin.Context) {
+	args := make(map[string]interface{})
+	if value, ok := c.GetQuery("lcuuid"); ok {
+		args["lcuuid"] = value
+	}
+	if value, ok := c.GetQuery("name"); ok {
+		args["name"] = value
+	}
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.GetSLO(dbInfo, args)
+	JsonResponse(c, data, err)
+}
+
+func createSLO(c *gin.Context) {
+	var err error
+	var sloCreate model.SLOCreate
+
+	// 参数校验
+	err = c.ShouldBindBodyWith(&sloCreate, binding.JSON)
+	if err != nil {
+		BadRequestResponse(c, httpcommon.INVALID_PARAMETERS, err.Error())
+		return
+	}
+
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.CreateSLO(dbInfo, sloCreate)
+	JsonResponse(c, data, err)
+}
+
+func updateSLO(c *gin.Context) {
+	var err error
+	var sloUpdate model.SLOUpdate
+
+	// 参数校验
+	err = c.ShouldBindBodyWith(&sloUpdate, binding.JSON)
+	if err != nil {
+		BadRequestResponse(c, httpcommon.INVALID_PARAMETERS, err.Error())
+		return
+	}
+
+	// 接收参数
+	// 避免struct会有默认值，这里转为map作为函数入参
+	patchMap := map[string]interface{}{}
+	c.ShouldBindBodyWith(&patchMap, binding.JSON)
+
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.UpdateSLO(dbInfo, c.Param("lcuuid"), patchMap)
+	JsonResponse(c, data, err)
+}
+
+func deleteSLO(c *gin.Context) {
+	dbInfo, err := mysql.GetDB(httpcommon.GetUserInfo(c).ORGID)
+	if err != nil {
+		JsonResponse(c, nil, err)
+		return
+	}
+	data, err := service.DeleteSLO(dbInfo, c.Param("lcuuid"))
+	JsonResponse(c, data, err)
+}
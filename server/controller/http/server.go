@@ -113,6 +113,8 @@ func (s *Server) appendRegistrant() []registrant.Registrant {
 		router.NewVtapRepo(),
 		router.NewPlugin(),
 		router.NewMail(),
+		router.NewSLO(),
+		router.NewPolicy(),
 		router.NewDatabase(s.controllerConfig),
 		router.NewAgentCMD(s.controllerConfig),
 		router.NewAgentGroupConfig(s.controllerConfig),
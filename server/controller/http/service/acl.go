@@ -0,0 +1,620 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql"
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	. "github.com/deepflowio/deepflow/server/controller/http/service/common"
+	"github.com/deepflowio/deepflow/server/controller/model"
+	trisolariscommon "github.com/deepflowio/deepflow/server/controller/trisolaris/common"
+)
+
+const (
+	ACL_TYPE_CUSTOM        = 2
+	ACL_TAP_TYPE_DEFAULT   = 3
+	ACL_PROTOCOL_MAX       = 255
+	ACL_PORT_MAX           = 65535
+	RESOURCE_GROUP_TYPE_IP = 2
+
+	RESOURCE_GROUP_IP_TYPE_SINGLE = 1
+	RESOURCE_GROUP_IP_TYPE_RANGE  = 2
+	RESOURCE_GROUP_IP_TYPE_CIDR   = 3
+	RESOURCE_GROUP_IP_TYPE_MIX    = 4
+)
+
+func aclApplicationName(applications string) string {
+	for name, app := range aclApplicationToInt {
+		if applications == strconv.Itoa(app) {
+			return name
+		}
+	}
+	return applications
+}
+
+// checkACLPorts checks ports separated by ",", each port is a number or a range like 8000-8080
+func checkACLPorts(ports string) error {
+	if ports == "" {
+		return nil
+	}
+	for _, port := range strings.Split(ports, ",") {
+		bounds := strings.Split(strings.TrimSpace(port), "-")
+		if len(bounds) > 2 {
+			return fmt.Errorf("port (%s) is invalid", port)
+		}
+		values := make([]int, 0, len(bounds))
+		for _, bound := range bounds {
+			value, err := strconv.Atoi(strings.TrimSpace(bound))
+			if err != nil || value < 0 || value > ACL_PORT_MAX {
+				return fmt.Errorf("port (%s) is invalid", port)
+			}
+			values = append(values, value)
+		}
+		if len(values) == 2 && values[0] > values[1] {
+			return fmt.Errorf("port range (%s) is invalid", port)
+		}
+	}
+	return nil
+}
+
+// resourceGroupIPType checks ips of the ip resource group and returns the ip type, the same ip family is required
+// by both ends of an ip range
+func resourceGroupIPType(ips []string) (int, error) {
+	if len(ips) == 0 {
+		return 0, errors.New("IPS must not be empty for ip resource group")
+	}
+	types := make(map[int]struct{})
+	for _, ip := range ips {
+		switch {
+		case strings.Contains(ip, "/"):
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return 0, fmt.Errorf("cidr (%s) is invalid", ip)
+			}
+			types[RESOURCE_GROUP_IP_TYPE_CIDR] = struct{}{}
+		case strings.Contains(ip, "-"):
+			bounds := strings.Split(ip, "-")
+			if len(bounds) != 2 {
+				return 0, fmt.Errorf("ip range (%s) is invalid", ip)
+			}
+			start, end := net.ParseIP(bounds[0]), net.ParseIP(bounds[1])
+			if start == nil || end == nil || (start.To4() == nil) != (end.To4() == nil) {
+				return 0, fmt.Errorf("ip range (%s) is invalid", ip)
+			}
+			if string(start.To16()) > string(end.To16()) {
+				return 0, fmt.Errorf("ip range (%s) is invalid", ip)
+			}
+			types[RESOURCE_GROUP_IP_TYPE_RANGE] = struct{}{}
+		default:
+			if net.ParseIP(ip) == nil {
+				return 0, fmt.Errorf("ip (%s) is invalid", ip)
+			}
+			types[RESOURCE_GROUP_IP_TYPE_SINGLE] = struct{}{}
+		}
+	}
+	if len(types) > 1 {
+		return RESOURCE_GROUP_IP_TYPE_MIX, nil
+	}
+	for ipType := range types {
+		return ipType, nil
+	}
+	return 0, nil
+}
+
+func checkVPC(db *mysql.DB, vpcID int) error {
+	var vpc mysqlmodel.VPC
+	if err := db.Where("id = ?", vpcID).First(&vpc).Error; err != nil {
+		return NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("vpc (id: %d) not found", vpcID))
+	}
+	return nil
+}
+
+func GetACL(db *mysql.DB, filter map[string]interface{}) ([]model.ACL, error) {
+	var acls []mysqlmodel.ACL
+	queryDB := db.Where("applications IN ?", []string{
+		strconv.Itoa(trisolariscommon.APPLICATION_PCAP), strconv.Itoa(trisolariscommon.APPLICATION_NPB),
+	})
+	for _, param := range []string{"lcuuid", "name"} {
+		if _, ok := filter[param]; ok {
+			queryDB = queryDB.Where(fmt.Sprintf("%s = ?", param), filter[param])
+		}
+	}
+	if err := queryDB.Order("id").Find(&acls).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query acl, error: %s", err))
+	}
+
+	resp := make([]model.ACL, 0, len(acls))
+	for _, acl := range acls {
+		resp = append(resp, model.ACL{
+			ID:          acl.ID,
+			Name:        acl.Name,
+			State:       acl.State,
+			Application: aclApplicationName(acl.Applications),
+			TapType:     acl.TapType,
+			VPCID:       acl.EpcID,
+			SrcGroupIDs: splitPolicyInts(acl.SrcGroupIDs),
+			DstGroupIDs: splitPolicyInts(acl.DstGroupIDs),
+			Protocol:    acl.Protocol,
+			SrcPorts:    acl.SrcPorts,
+			DstPorts:    acl.DstPorts,
+			Vlan:        acl.Vlan,
+			CreatedAt:   acl.CreatedAt.Format(common.GO_BIRTHDAY),
+			UpdatedAt:   acl.UpdatedAt.Format(common.GO_BIRTHDAY),
+			Lcuuid:      acl.Lcuuid,
+		})
+	}
+	return resp, nil
+}
+
+func checkACL(db *mysql.DB, aclCreate *model.ACLCreate, lcuuid string) (*mysqlmodel.ACL, error) {
+	if err := checkPolicyCommon(aclCreate.Name, aclCreate.State, nil); err != nil {
+		return nil, err
+	}
+	application, ok := aclApplicationToInt[aclCreate.Application]
+	if !ok {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("APPLICATION (%s) must be %s or %s", aclCreate.Application, ACL_APPLICATION_PCAP, ACL_APPLICATION_NPB))
+	}
+	if aclCreate.TapType == 0 {
+		aclCreate.TapType = ACL_TAP_TYPE_DEFAULT
+	}
+	if aclCreate.Protocol != nil && (*aclCreate.Protocol < 0 || *aclCreate.Protocol > ACL_PROTOCOL_MAX) {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("PROTOCOL (%d) must be in [0, %d]", *aclCreate.Protocol, ACL_PROTOCOL_MAX))
+	}
+	if err := checkACLPorts(aclCreate.SrcPorts); err != nil {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("SRC_PORTS: %s", err))
+	}
+	if err := checkACLPorts(aclCreate.DstPorts); err != nil {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("DST_PORTS: %s", err))
+	}
+	if aclCreate.VPCID != 0 {
+		if err := checkVPC(db, aclCreate.VPCID); err != nil {
+			return nil, err
+		}
+	}
+	var count int64
+	db.Model(&mysqlmodel.ACL{}).Where("name = ? AND lcuuid != ?", aclCreate.Name, lcuuid).Count(&count)
+	if count > 0 {
+		return nil, NewError(httpcommon.RESOURCE_ALREADY_EXIST, fmt.Sprintf("acl (name: %s) already exist", aclCreate.Name))
+	}
+
+	groupIDs := append(append([]int{}, aclCreate.SrcGroupIDs...), aclCreate.DstGroupIDs...)
+	if len(groupIDs) > 0 {
+		var groups []mysqlmodel.ResourceGroup
+		if err := db.Select("id").Where("id IN ?", groupIDs).Find(&groups).Error; err != nil {
+			return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query resource group, error: %s", err))
+		}
+		exists := make(map[int]struct{}, len(groups))
+		for _, group := range groups {
+			exists[group.ID] = struct{}{}
+		}
+		for _, id := range groupIDs {
+			if _, ok := exists[id]; !ok {
+				return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("resource group (id: %d) not found", id))
+			}
+		}
+	}
+
+	return &mysqlmodel.ACL{
+		BusinessID:   POLICY_DEFAULT_BUSINESS_ID,
+		Name:         aclCreate.Name,
+		Type:         ACL_TYPE_CUSTOM,
+		TapType:      aclCreate.TapType,
+		State:        policyState(aclCreate.State),
+		Applications: strconv.Itoa(application),
+		EpcID:        aclCreate.VPCID,
+		SrcGroupIDs:  joinPolicyInts(aclCreate.SrcGroupIDs),
+		DstGroupIDs:  joinPolicyInts(aclCreate.DstGroupIDs),
+		Protocol:     aclCreate.Protocol,
+		SrcPorts:     aclCreate.SrcPorts,
+		DstPorts:     aclCreate.DstPorts,
+		Vlan:         aclCreate.Vlan,
+		Lcuuid:       lcuuid,
+	}, nil
+}
+
+// saveGroupACLs rebuilds the group_acl relations of the acl
+func saveGroupACLs(tx *gorm.DB, acl *mysqlmodel.ACL) error {
+	if err := tx.Where("acl_id = ?", acl.ID).Delete(&mysqlmodel.GroupACL{}).Error; err != nil {
+		return err
+	}
+	groupIDs := make(map[int]struct{})
+	for _, id := range append(splitPolicyInts(acl.SrcGroupIDs), splitPolicyInts(acl.DstGroupIDs)...) {
+		groupIDs[id] = struct{}{}
+	}
+	groupACLs := make([]mysqlmodel.GroupACL, 0, len(groupIDs))
+	for id := range groupIDs {
+		groupACLs = append(groupACLs, mysqlmodel.GroupACL{GroupID: id, ACLID: acl.ID, Lcuuid: uuid.New().String()})
+	}
+	if len(groupACLs) == 0 {
+		return nil
+	}
+	return tx.Create(&groupACLs).Error
+}
+
+func CreateACL(db *mysql.DB, aclCreate model.ACLCreate, dryRun bool) (interface{}, error) {
+	acl, err := checkACL(db, &aclCreate, uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		// a new acl is not referenced by any policy
+		return previewPolicyChange(db, POLICY_OPERATION_CREATE, nil)
+	}
+
+	log.Infof("create acl (%s) config %+v", acl.Name, aclCreate, db.LogPrefixORGID)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(acl).Error; err != nil {
+			return err
+		}
+		return saveGroupACLs(tx, acl)
+	})
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("create acl (name: %s) failed, err: %v", acl.Name, err))
+	}
+	refreshFlowACL(db)
+
+	acls, err := GetACL(db, map[string]interface{}{"lcuuid": acl.Lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &acls[0], nil
+}
+
+func UpdateACL(db *mysql.DB, lcuuid string, patchMap map[string]interface{}, dryRun bool) (interface{}, error) {
+	var oldACL mysqlmodel.ACL
+	if err := db.Where("lcuuid = ?", lcuuid).First(&oldACL).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("acl (%s) not found", lcuuid))
+	}
+
+	aclCreate := model.ACLCreate{
+		Name:        oldACL.Name,
+		State:       copyIntPtr(&oldACL.State),
+		Application: aclApplicationName(oldACL.Applications),
+		TapType:     oldACL.TapType,
+		VPCID:       oldACL.EpcID,
+		SrcGroupIDs: splitPolicyInts(oldACL.SrcGroupIDs),
+		DstGroupIDs: splitPolicyInts(oldACL.DstGroupIDs),
+		Protocol:    copyIntPtr(oldACL.Protocol),
+		SrcPorts:    oldACL.SrcPorts,
+		DstPorts:    oldACL.DstPorts,
+		Vlan:        oldACL.Vlan,
+	}
+	// group ids in the patch replace the old ones
+	if _, ok := patchMap["SRC_GROUP_IDS"]; ok {
+		aclCreate.SrcGroupIDs = nil
+	}
+	if _, ok := patchMap["DST_GROUP_IDS"]; ok {
+		aclCreate.DstGroupIDs = nil
+	}
+	if err := mergePolicyPatch(&aclCreate, patchMap); err != nil {
+		return nil, err
+	}
+	acl, err := checkACL(db, &aclCreate, lcuuid)
+	if err != nil {
+		return nil, err
+	}
+	acl.ID = oldACL.ID
+	oldTargets, err := aclPolicyTargets(db, &oldACL)
+	if err != nil {
+		return nil, err
+	}
+	if acl.Applications != oldACL.Applications && len(oldTargets) > 0 {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("acl (%s) is used by policies, APPLICATION can not be changed", oldACL.Name))
+	}
+	if dryRun {
+		newTargets, err := aclPolicyTargets(db, acl)
+		if err != nil {
+			return nil, err
+		}
+		return previewPolicyChange(db, POLICY_OPERATION_UPDATE, append(oldTargets, newTargets...))
+	}
+
+	log.Infof("update acl (%s) config %v", oldACL.Name, patchMap, db.LogPrefixORGID)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&oldACL).Updates(map[string]interface{}{
+			"name":          acl.Name,
+			"state":         acl.State,
+			"applications":  acl.Applications,
+			"tap_type":      acl.TapType,
+			"epc_id":        acl.EpcID,
+			"src_group_ids": acl.SrcGroupIDs,
+			"dst_group_ids": acl.DstGroupIDs,
+			"protocol":      acl.Protocol,
+			"src_ports":     acl.SrcPorts,
+			"dst_ports":     acl.DstPorts,
+			"vlan":          acl.Vlan,
+		}).Error; err != nil {
+			return err
+		}
+		return saveGroupACLs(tx, acl)
+	})
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("update acl (%s) failed, err: %v", lcuuid, err))
+	}
+	refreshFlowACL(db)
+
+	acls, err := GetACL(db, map[string]interface{}{"lcuuid": lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &acls[0], nil
+}
+
+func DeleteACL(db *mysql.DB, lcuuid string, dryRun bool) (interface{}, error) {
+	var acl mysqlmodel.ACL
+	if err := db.Where("lcuuid = ?", lcuuid).First(&acl).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("acl (%s) not found", lcuuid))
+	}
+	var pcapCount, npbCount int64
+	db.Model(&mysqlmodel.PcapPolicy{}).Where("acl_id = ?", acl.ID).Count(&pcapCount)
+	db.Model(&mysqlmodel.NpbPolicy{}).Where("acl_id = ?", acl.ID).Count(&npbCount)
+	if pcapCount+npbCount > 0 {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("acl (%s) is used by %d policies", acl.Name, pcapCount+npbCount))
+	}
+	if dryRun {
+		return previewPolicyChange(db, POLICY_OPERATION_DELETE, nil)
+	}
+
+	log.Infof("delete acl (%s)", acl.Name, db.LogPrefixORGID)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("acl_id = ?", acl.ID).Delete(&mysqlmodel.GroupACL{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&acl).Error
+	})
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("delete acl (%s) failed, err: %v", lcuuid, err))
+	}
+	refreshFlowACL(db)
+	return map[string]string{"LCUUID": lcuuid}, nil
+}
+
+func GetResourceGroup(db *mysql.DB, filter map[string]interface{}) ([]model.ResourceGroup, error) {
+	var groups []mysqlmodel.ResourceGroup
+	queryDB := db.Where("type IN ?", []int{trisolariscommon.RESOURCE_GROUP_TYPE_VM, RESOURCE_GROUP_TYPE_IP})
+	for _, param := range []string{"lcuuid", "name"} {
+		if _, ok := filter[param]; ok {
+			queryDB = queryDB.Where(fmt.Sprintf("%s = ?", param), filter[param])
+		}
+	}
+	if err := queryDB.Order("id").Find(&groups).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query resource group, error: %s", err))
+	}
+
+	resp := make([]model.ResourceGroup, 0, len(groups))
+	for _, group := range groups {
+		resourceGroup := model.ResourceGroup{
+			ID:        group.ID,
+			Name:      group.Name,
+			Type:      group.Type,
+			IPType:    group.IPType,
+			IPs:       []string{},
+			VMIDs:     splitPolicyInts(group.VMIDs),
+			CreatedAt: group.CreatedAt.Format(common.GO_BIRTHDAY),
+			UpdatedAt: group.UpdatedAt.Format(common.GO_BIRTHDAY),
+			Lcuuid:    group.Lcuuid,
+		}
+		if group.VPCID != nil {
+			resourceGroup.VPCID = *group.VPCID
+		}
+		if group.IPs != "" {
+			resourceGroup.IPs = strings.Split(group.IPs, ",")
+		}
+		resp = append(resp, resourceGroup)
+	}
+	return resp, nil
+}
+
+func checkResourceGroup(db *mysql.DB, groupCreate *model.ResourceGroupCreate, lcuuid string) (*mysqlmodel.ResourceGroup, error) {
+	if groupCreate.Name == "" || len(groupCreate.Name) > POLICY_NAME_MAX_LENGTH {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("NAME must not be empty or longer than %d", POLICY_NAME_MAX_LENGTH))
+	}
+	var count int64
+	db.Model(&mysqlmodel.ResourceGroup{}).Where("name = ? AND lcuuid != ?", groupCreate.Name, lcuuid).Count(&count)
+	if count > 0 {
+		return nil, NewError(httpcommon.RESOURCE_ALREADY_EXIST, fmt.Sprintf("resource group (name: %s) already exist", groupCreate.Name))
+	}
+
+	group := &mysqlmodel.ResourceGroup{
+		BusinessID: POLICY_DEFAULT_BUSINESS_ID,
+		Name:       groupCreate.Name,
+		Type:       groupCreate.Type,
+		Lcuuid:     lcuuid,
+	}
+	if groupCreate.VPCID != 0 {
+		if err := checkVPC(db, groupCreate.VPCID); err != nil {
+			return nil, err
+		}
+		vpcID := groupCreate.VPCID
+		group.VPCID = &vpcID
+	}
+	switch groupCreate.Type {
+	case trisolariscommon.RESOURCE_GROUP_TYPE_VM:
+		if groupCreate.VPCID == 0 {
+			return nil, NewError(httpcommon.INVALID_PARAMETERS, "VPC_ID is required for vm resource group")
+		}
+		if len(groupCreate.VMIDs) > 0 {
+			var vms []mysqlmodel.VM
+			if err := db.Select("id").Where("id IN ? AND epc_id = ?", groupCreate.VMIDs, groupCreate.VPCID).Find(&vms).Error; err != nil {
+				return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query vm, error: %s", err))
+			}
+			exists := make(map[int]struct{}, len(vms))
+			for _, vm := range vms {
+				exists[vm.ID] = struct{}{}
+			}
+			for _, id := range groupCreate.VMIDs {
+				if _, ok := exists[id]; !ok {
+					return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("vm (id: %d) not found in vpc (id: %d)", id, groupCreate.VPCID))
+				}
+			}
+			vmIDs := append([]int{}, groupCreate.VMIDs...)
+			sort.Ints(vmIDs)
+			group.VMIDs = joinPolicyInts(vmIDs)
+		}
+	case RESOURCE_GROUP_TYPE_IP:
+		ipType, err := resourceGroupIPType(groupCreate.IPs)
+		if err != nil {
+			return nil, NewError(httpcommon.INVALID_PARAMETERS, err.Error())
+		}
+		group.IPType = ipType
+		group.IPs = strings.Join(groupCreate.IPs, ",")
+	default:
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("TYPE (%d) must be 1 (vm) or 2 (ip)", groupCreate.Type))
+	}
+	return group, nil
+}
+
+// resourceGroupPolicyTargets returns the targets of all policies of acls using the resource group
+func resourceGroupPolicyTargets(db *mysql.DB, groupID int) ([]policyTarget, error) {
+	var groupACLs []mysqlmodel.GroupACL
+	if err := db.Where("group_id = ?", groupID).Find(&groupACLs).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query group acl, error: %s", err))
+	}
+	var targets []policyTarget
+	for _, groupACL := range groupACLs {
+		var acl mysqlmodel.ACL
+		if err := db.Where("id = ?", groupACL.ACLID).First(&acl).Error; err != nil {
+			continue
+		}
+		aclTargets, err := aclPolicyTargets(db, &acl)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, aclTargets...)
+	}
+	return targets, nil
+}
+
+func CreateResourceGroup(db *mysql.DB, groupCreate model.ResourceGroupCreate, dryRun bool) (interface{}, error) {
+	group, err := checkResourceGroup(db, &groupCreate, uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return previewPolicyChange(db, POLICY_OPERATION_CREATE, nil)
+	}
+
+	log.Infof("create resource group (%s) config %+v", group.Name, groupCreate, db.LogPrefixORGID)
+	if err := db.Create(group).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("create resource group (name: %s) failed, err: %v", group.Name, err))
+	}
+	refreshFlowACL(db, common.DATA_CHANGED_GROUP)
+
+	groups, err := GetResourceGroup(db, map[string]interface{}{"lcuuid": group.Lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &groups[0], nil
+}
+
+func UpdateResourceGroup(db *mysql.DB, lcuuid string, patchMap map[string]interface{}, dryRun bool) (interface{}, error) {
+	var oldGroup mysqlmodel.ResourceGroup
+	if err := db.Where("lcuuid = ?", lcuuid).First(&oldGroup).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("resource group (%s) not found", lcuuid))
+	}
+	if oldGroup.Type != trisolariscommon.RESOURCE_GROUP_TYPE_VM && oldGroup.Type != RESOURCE_GROUP_TYPE_IP {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("resource group (%s) of type %d can not be updated", lcuuid, oldGroup.Type))
+	}
+
+	groupCreate := model.ResourceGroupCreate{
+		Name:  oldGroup.Name,
+		Type:  oldGroup.Type,
+		VMIDs: splitPolicyInts(oldGroup.VMIDs),
+	}
+	if oldGroup.VPCID != nil {
+		groupCreate.VPCID = *oldGroup.VPCID
+	}
+	if oldGroup.IPs != "" {
+		groupCreate.IPs = strings.Split(oldGroup.IPs, ",")
+	}
+	// lists in the patch replace the old ones
+	if _, ok := patchMap["IPS"]; ok {
+		groupCreate.IPs = nil
+	}
+	if _, ok := patchMap["VM_IDS"]; ok {
+		groupCreate.VMIDs = nil
+	}
+	if err := mergePolicyPatch(&groupCreate, patchMap); err != nil {
+		return nil, err
+	}
+	group, err := checkResourceGroup(db, &groupCreate, lcuuid)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		targets, err := resourceGroupPolicyTargets(db, oldGroup.ID)
+		if err != nil {
+			return nil, err
+		}
+		return previewPolicyChange(db, POLICY_OPERATION_UPDATE, targets)
+	}
+
+	log.Infof("update resource group (%s) config %v", oldGroup.Name, patchMap, db.LogPrefixORGID)
+	if err := db.Model(&oldGroup).Updates(map[string]interface{}{
+		"name":    group.Name,
+		"type":    group.Type,
+		"ip_type": group.IPType,
+		"ips":     group.IPs,
+		"vm_ids":  group.VMIDs,
+		"epc_id":  group.VPCID,
+	}).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("update resource group (%s) failed, err: %v", lcuuid, err))
+	}
+	refreshFlowACL(db, common.DATA_CHANGED_GROUP)
+
+	groups, err := GetResourceGroup(db, map[string]interface{}{"lcuuid": lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &groups[0], nil
+}
+
+func DeleteResourceGroup(db *mysql.DB, lcuuid string, dryRun bool) (interface{}, error) {
+	var group mysqlmodel.ResourceGroup
+	if err := db.Where("lcuuid = ?", lcuuid).First(&group).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("resource group (%s) not found", lcuuid))
+	}
+	if group.Type != trisolariscommon.RESOURCE_GROUP_TYPE_VM && group.Type != RESOURCE_GROUP_TYPE_IP {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("resource group (%s) of type %d can not be deleted", lcuuid, group.Type))
+	}
+	var count int64
+	db.Model(&mysqlmodel.GroupACL{}).Where("group_id = ?", group.ID).Count(&count)
+	if count > 0 {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("resource group (%s) is used by %d acls", group.Name, count))
+	}
+	if dryRun {
+		return previewPolicyChange(db, POLICY_OPERATION_DELETE, nil)
+	}
+
+	log.Infof("delete resource group (%s)", group.Name, db.LogPrefixORGID)
+	if err := db.Delete(&group).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("delete resource group (%s) failed, err: %v", lcuuid, err))
+	}
+	refreshFlowACL(db, common.DATA_CHANGED_GROUP)
+	return map[string]string{"LCUUID": lcuuid}, nil
+}
@@ -17,12 +17,17 @@
 package service
 
 import (
+	"crypto/md5"
 	"errors"
 	"fmt"
 
+	simplejson "github.com/bitly/go-simplejson"
+
+	"github.com/deepflowio/deepflow/server/controller/cloud/kubernetes_gather/plugin"
 	"github.com/deepflowio/deepflow/server/controller/common"
 	"github.com/deepflowio/deepflow/server/controller/db/mysql"
 	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+	"github.com/deepflowio/deepflow/server/controller/genesis"
 	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
 	. "github.com/deepflowio/deepflow/server/controller/http/service/common"
 	"github.com/deepflowio/deepflow/server/controller/model"
@@ -70,6 +75,7 @@ func GetPlugin(db *mysql.DB, filter map[string]interface{}) ([]model.Plugin, err
 		temp := model.Plugin{
 			Name:      plugin.Name,
 			Type:      plugin.Type,
+			ImageMD5:  fmt.Sprintf("%x", md5.Sum(plugin.Image)),
 			UpdatedAt: plugin.UpdatedAt.Format(common.GO_BIRTHDAY),
 			User:      plugin.User,
 		}
@@ -90,3 +96,54 @@ func DeletePlugin(db *mysql.DB, name string) error {
 	}
 	return nil
 }
+
+// TestPlugin dry-runs a lua plugin against sample metadata, or against the pods of a domain/sub_domain
+// synced by genesis when no metadata is provided.
+func TestPlugin(db *mysql.DB, candidate *mysqlmodel.Plugin, metaData []byte, domainLcuuid string) (*plugin.DryRunResult, error) {
+	if candidate.Type != common.PLUGIN_TYPE_LUA {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, "only lua plugin supports test")
+	}
+
+	var metaDatas []*simplejson.Json
+	var err error
+	if len(metaData) > 0 {
+		if metaDatas, err = plugin.ParseMetaDatas(metaData); err != nil {
+			return nil, NewError(httpcommon.INVALID_PARAMETERS, err.Error())
+		}
+	} else if domainLcuuid != "" {
+		clusterID, err := getClusterIDByLcuuid(db, domainLcuuid)
+		if err != nil {
+			return nil, err
+		}
+		k8sInfo, err := genesis.GenesisService.GetKubernetesResponse(db.ORGID, clusterID)
+		if err != nil {
+			return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("get kubernetes info (cluster_id: %s) failed, err: %s", clusterID, err))
+		}
+		if metaDatas, err = plugin.PodMetaDatas(k8sInfo); err != nil {
+			return nil, NewError(httpcommon.SERVER_ERROR, err.Error())
+		}
+	} else {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, "one of METADATA or DOMAIN must be specified")
+	}
+
+	plugins, err := plugin.LoadLuaPlugins(db.DB)
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query lua plugins, error: %s", err))
+	}
+	return plugin.DryRun(db.ORGID, plugins, *candidate, metaDatas), nil
+}
+
+func getClusterIDByLcuuid(db *mysql.DB, lcuuid string) (string, error) {
+	var domain mysqlmodel.Domain
+	if err := db.Where("lcuuid = ?", lcuuid).First(&domain).Error; err == nil {
+		if domain.Type != common.KUBERNETES {
+			return "", NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("domain (lcuuid: %s) is not kubernetes", lcuuid))
+		}
+		return domain.ClusterID, nil
+	}
+	var subDomain mysqlmodel.SubDomain
+	if err := db.Where("lcuuid = ?", lcuuid).First(&subDomain).Error; err != nil {
+		return "", NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("domain or sub_domain (lcuuid: %s) not found", lcuuid))
+	}
+	return subDomain.ClusterID, nil
+}
@@ -0,0 +1,689 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql"
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	. "github.com/deepflowio/deepflow/server/controller/http/service/common"
+	"github.com/deepflowio/deepflow/server/controller/model"
+	trisolariscommon "github.com/deepflowio/deepflow/server/controller/trisolaris/common"
+	"github.com/deepflowio/deepflow/server/controller/trisolaris/metadata"
+	"github.com/deepflowio/deepflow/server/controller/trisolaris/refresh"
+)
+
+const (
+	POLICY_OPERATION_CREATE = "create"
+	POLICY_OPERATION_UPDATE = "update"
+	POLICY_OPERATION_DELETE = "delete"
+
+	POLICY_DEFAULT_BUSINESS_ID = 1
+	POLICY_NAME_MAX_LENGTH     = 64
+
+	ACL_APPLICATION_PCAP = "pcap"
+	ACL_APPLICATION_NPB  = "npb"
+)
+
+var aclApplicationToInt = map[string]int{
+	ACL_APPLICATION_PCAP: trisolariscommon.APPLICATION_PCAP,
+	ACL_APPLICATION_NPB:  trisolariscommon.APPLICATION_NPB,
+}
+
+// policyTarget is an enabled pcap or npb policy of an enabled acl, which is compiled into flow acls of agents
+// by trisolaris
+type policyTarget struct {
+	application int
+	name        string
+	vtapIDs     string
+}
+
+func pcapPolicyTargets(acl *mysqlmodel.ACL, policies ...*mysqlmodel.PcapPolicy) []policyTarget {
+	var targets []policyTarget
+	if acl == nil || acl.State != common.ACL_STATE_ENABLE {
+		return targets
+	}
+	for _, policy := range policies {
+		if policy.State == common.ACL_STATE_ENABLE {
+			targets = append(targets, policyTarget{trisolariscommon.APPLICATION_PCAP, policy.Name, policy.VtapIDs})
+		}
+	}
+	return targets
+}
+
+func npbPolicyTargets(acl *mysqlmodel.ACL, policies ...*mysqlmodel.NpbPolicy) []policyTarget {
+	var targets []policyTarget
+	if acl == nil || acl.State != common.ACL_STATE_ENABLE {
+		return targets
+	}
+	for _, policy := range policies {
+		if policy.State == common.ACL_STATE_ENABLE {
+			targets = append(targets, policyTarget{trisolariscommon.APPLICATION_NPB, policy.Name, policy.VtapIDs})
+		}
+	}
+	return targets
+}
+
+// aclPolicyTargets returns the targets of all policies of the acl
+func aclPolicyTargets(db *mysql.DB, acl *mysqlmodel.ACL) ([]policyTarget, error) {
+	var pcapPolicies []*mysqlmodel.PcapPolicy
+	if err := db.Where("acl_id = ?", acl.ID).Find(&pcapPolicies).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query pcap policy, error: %s", err))
+	}
+	var npbPolicies []*mysqlmodel.NpbPolicy
+	if err := db.Where("acl_id = ?", acl.ID).Find(&npbPolicies).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query npb policy, error: %s", err))
+	}
+	return append(pcapPolicyTargets(acl, pcapPolicies...), npbPolicyTargets(acl, npbPolicies...)...), nil
+}
+
+// previewPolicyChange computes the agents whose flow acls are changed by the targets in the same way as
+// PolicyDataOP: a policy without vtap_ids is shared by all agents, and only agents with the license function of
+// the acl application receive it.
+func previewPolicyChange(db *mysql.DB, operation string, targets []policyTarget) (*model.PolicyChangePreview, error) {
+	preview := &model.PolicyChangePreview{
+		Operation:    operation,
+		VTaps:        []model.PolicyAffectedVTap{},
+		PcapPolicies: []string{},
+		NpbPolicies:  []string{},
+	}
+	if len(targets) == 0 {
+		return preview, nil
+	}
+
+	functionToAll := make(map[int]bool)
+	functionToVTapIDs := make(map[int]map[int]struct{})
+	pcapNames := make(map[string]struct{})
+	npbNames := make(map[string]struct{})
+	for _, target := range targets {
+		if target.application == trisolariscommon.APPLICATION_PCAP {
+			preview.Ingester = true
+			pcapNames[target.name] = struct{}{}
+		} else {
+			npbNames[target.name] = struct{}{}
+		}
+		function := metadata.ApplicationLicenseFunction[target.application]
+		vtapIDs, err := metadata.PolicyVTapIDs(target.vtapIDs)
+		if err != nil {
+			log.Warningf("policy (%s) vtap_ids (%s) invalid: %s", target.name, target.vtapIDs, err, db.LogPrefixORGID)
+		}
+		if vtapIDs == nil {
+			preview.AllVTaps = true
+			functionToAll[function] = true
+			continue
+		}
+		if _, ok := functionToVTapIDs[function]; !ok {
+			functionToVTapIDs[function] = make(map[int]struct{})
+		}
+		for _, vtapID := range vtapIDs {
+			functionToVTapIDs[function][vtapID] = struct{}{}
+		}
+	}
+	for name := range pcapNames {
+		preview.PcapPolicies = append(preview.PcapPolicies, name)
+	}
+	sort.Strings(preview.PcapPolicies)
+	for name := range npbNames {
+		preview.NpbPolicies = append(preview.NpbPolicies, name)
+	}
+	sort.Strings(preview.NpbPolicies)
+
+	var vtaps []mysqlmodel.VTap
+	if err := db.Select("id", "name", "vtap_group_lcuuid", "license_functions").Order("id").Find(&vtaps).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query vtap, error: %s", err))
+	}
+	for _, vtap := range vtaps {
+		for _, function := range splitPolicyInts(vtap.LicenseFunctions) {
+			_, ok := functionToVTapIDs[function][vtap.ID]
+			if ok || functionToAll[function] {
+				preview.VTaps = append(preview.VTaps, model.PolicyAffectedVTap{
+					ID:              vtap.ID,
+					Name:            vtap.Name,
+					VTapGroupLcuuid: vtap.VtapGroupLcuuid,
+				})
+				break
+			}
+		}
+	}
+	return preview, nil
+}
+
+// resolvePolicyVTapIDs merges agents and agents of agent groups into vtap_ids of policies
+func resolvePolicyVTapIDs(db *mysql.DB, vtapIDs []int, vtapGroupIDs []string) (string, error) {
+	idSet := make(map[int]struct{})
+	if len(vtapIDs) > 0 {
+		var vtaps []mysqlmodel.VTap
+		if err := db.Select("id").Where("id IN ?", vtapIDs).Find(&vtaps).Error; err != nil {
+			return "", NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query vtap, error: %s", err))
+		}
+		for _, vtap := range vtaps {
+			idSet[vtap.ID] = struct{}{}
+		}
+		for _, id := range vtapIDs {
+			if _, ok := idSet[id]; !ok {
+				return "", NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("vtap (id: %d) not found", id))
+			}
+		}
+	}
+	if len(vtapGroupIDs) > 0 {
+		var groups []mysqlmodel.VTapGroup
+		if err := db.Where("short_uuid IN ?", vtapGroupIDs).Find(&groups).Error; err != nil {
+			return "", NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query vtap group, error: %s", err))
+		}
+		groupLcuuids := make([]string, 0, len(groups))
+		shortUUIDs := make(map[string]struct{}, len(groups))
+		for _, group := range groups {
+			groupLcuuids = append(groupLcuuids, group.Lcuuid)
+			shortUUIDs[group.ShortUUID] = struct{}{}
+		}
+		for _, id := range vtapGroupIDs {
+			if _, ok := shortUUIDs[id]; !ok {
+				return "", NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("vtap group (id: %s) not found", id))
+			}
+		}
+		var vtaps []mysqlmodel.VTap
+		if err := db.Select("id").Where("vtap_group_lcuuid IN ?", groupLcuuids).Find(&vtaps).Error; err != nil {
+			return "", NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query vtap, error: %s", err))
+		}
+		// empty vtap_ids means all agents, which must not be the result of empty groups
+		if len(vtaps) == 0 && len(idSet) == 0 {
+			return "", NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("vtap groups (%s) have no vtap", strings.Join(vtapGroupIDs, ", ")))
+		}
+		for _, vtap := range vtaps {
+			idSet[vtap.ID] = struct{}{}
+		}
+	}
+	ids := make([]int, 0, len(idSet))
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return joinPolicyInts(ids), nil
+}
+
+func checkPolicyACL(db *mysql.DB, aclID int, application string) (*mysqlmodel.ACL, error) {
+	var acl mysqlmodel.ACL
+	if err := db.Where("id = ?", aclID).First(&acl).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("acl (id: %d) not found", aclID))
+		}
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query acl (id: %d), error: %s", aclID, err))
+	}
+	if acl.Applications != strconv.Itoa(aclApplicationToInt[application]) {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("acl (id: %d) is not a %s acl", aclID, application))
+	}
+	return &acl, nil
+}
+
+func checkPolicyCommon(name string, state *int, payloadSlice *int) error {
+	if name == "" || len(name) > POLICY_NAME_MAX_LENGTH {
+		return NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("NAME must not be empty or longer than %d", POLICY_NAME_MAX_LENGTH))
+	}
+	if state != nil && *state != 0 && *state != common.ACL_STATE_ENABLE {
+		return NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("STATE (%d) must be 0 or 1", *state))
+	}
+	if payloadSlice != nil && (*payloadSlice < 0 || *payloadSlice > trisolariscommon.MAX_PAYLOAD_SLICE) {
+		return NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("PAYLOAD_SLICE (%d) must be in [0, %d]", *payloadSlice, trisolariscommon.MAX_PAYLOAD_SLICE))
+	}
+	return nil
+}
+
+func policyState(state *int) int {
+	if state == nil {
+		return common.ACL_STATE_ENABLE
+	}
+	return *state
+}
+
+// mergePolicyPatch applies the patch map (keys are the json tags of the model) to v
+func mergePolicyPatch(v interface{}, patchMap map[string]interface{}) error {
+	data, err := json.Marshal(patchMap)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return NewError(httpcommon.INVALID_PARAMETERS, err.Error())
+	}
+	return nil
+}
+
+// policyVTapsPatched returns whether the patch changes the agents of the policy, in which case VTAP_IDS and
+// VTAP_GROUP_IDS replace the saved agents instead of being merged with them
+func policyVTapsPatched(patchMap map[string]interface{}) bool {
+	_, ok1 := patchMap["VTAP_IDS"]
+	_, ok2 := patchMap["VTAP_GROUP_IDS"]
+	return ok1 || ok2
+}
+
+func copyIntPtr(v *int) *int {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func joinPolicyInts(ids []int) string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, strconv.Itoa(id))
+	}
+	return strings.Join(strs, ",")
+}
+
+func splitPolicyInts(s string) []int {
+	ids := []int{}
+	for _, str := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(str)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func refreshFlowACL(db *mysql.DB, dataTypes ...common.DataChanged) {
+	refresh.RefreshCache(db.GetORGID(), append([]common.DataChanged{common.DATA_CHANGED_FLOW_ACL}, dataTypes...))
+}
+
+func GetPcapPolicy(db *mysql.DB, filter map[string]interface{}) ([]model.PcapPolicy, error) {
+	var policies []mysqlmodel.PcapPolicy
+	queryDB := db.DB
+	for _, param := range []string{"lcuuid", "name", "acl_id"} {
+		if _, ok := filter[param]; ok {
+			queryDB = queryDB.Where(fmt.Sprintf("%s = ?", param), filter[param])
+		}
+	}
+	if err := queryDB.Order("id").Find(&policies).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query pcap policy, error: %s", err))
+	}
+
+	resp := make([]model.PcapPolicy, 0, len(policies))
+	for _, policy := range policies {
+		resp = append(resp, model.PcapPolicy{
+			ID:           policy.ID,
+			Name:         policy.Name,
+			State:        policy.State,
+			ACLID:        policy.ACLID,
+			VTapIDs:      splitPolicyInts(policy.VtapIDs),
+			PayloadSlice: policy.PayloadSlice,
+			CreatedAt:    policy.CreatedAt.Format(common.GO_BIRTHDAY),
+			UpdatedAt:    policy.UpdatedAt.Format(common.GO_BIRTHDAY),
+			Lcuuid:       policy.Lcuuid,
+		})
+	}
+	return resp, nil
+}
+
+// checkPcapPolicy validates the policy and its references, and returns the policy to save
+func checkPcapPolicy(db *mysql.DB, policyCreate *model.PcapPolicyCreate, lcuuid string) (*mysqlmodel.PcapPolicy, *mysqlmodel.ACL, error) {
+	if err := checkPolicyCommon(policyCreate.Name, policyCreate.State, policyCreate.PayloadSlice); err != nil {
+		return nil, nil, err
+	}
+	var count int64
+	db.Model(&mysqlmodel.PcapPolicy{}).Where("name = ? AND lcuuid != ?", policyCreate.Name, lcuuid).Count(&count)
+	if count > 0 {
+		return nil, nil, NewError(httpcommon.RESOURCE_ALREADY_EXIST, fmt.Sprintf("pcap policy (name: %s) already exist", policyCreate.Name))
+	}
+	acl, err := checkPolicyACL(db, policyCreate.ACLID, ACL_APPLICATION_PCAP)
+	if err != nil {
+		return nil, nil, err
+	}
+	vtapIDs, err := resolvePolicyVTapIDs(db, policyCreate.VTapIDs, policyCreate.VTapGroupIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &mysqlmodel.PcapPolicy{
+		Name:         policyCreate.Name,
+		State:        policyState(policyCreate.State),
+		BusinessID:   POLICY_DEFAULT_BUSINESS_ID,
+		ACLID:        acl.ID,
+		VtapIDs:      vtapIDs,
+		PayloadSlice: policyCreate.PayloadSlice,
+		Lcuuid:       lcuuid,
+	}, acl, nil
+}
+
+func CreatePcapPolicy(db *mysql.DB, policyCreate model.PcapPolicyCreate, userID int, dryRun bool) (interface{}, error) {
+	policy, acl, err := checkPcapPolicy(db, &policyCreate, uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return previewPolicyChange(db, POLICY_OPERATION_CREATE, pcapPolicyTargets(acl, policy))
+	}
+
+	policy.UserID = userID
+	log.Infof("create pcap policy (%s) config %+v", policy.Name, policyCreate, db.LogPrefixORGID)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		aclGroup := mysqlmodel.PolicyACLGroup{ACLIDs: strconv.Itoa(acl.ID), COUNT: 1}
+		if err := tx.Create(&aclGroup).Error; err != nil {
+			return err
+		}
+		policy.PolicyACLGroupID = aclGroup.ID
+		return tx.Create(policy).Error
+	})
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("create pcap policy (name: %s) failed, err: %v", policy.Name, err))
+	}
+	refreshFlowACL(db)
+
+	policies, err := GetPcapPolicy(db, map[string]interface{}{"lcuuid": policy.Lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &policies[0], nil
+}
+
+func UpdatePcapPolicy(db *mysql.DB, lcuuid string, patchMap map[string]interface{}, dryRun bool) (interface{}, error) {
+	var oldPolicy mysqlmodel.PcapPolicy
+	if err := db.Where("lcuuid = ?", lcuuid).First(&oldPolicy).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("pcap policy (%s) not found", lcuuid))
+	}
+	var oldACL *mysqlmodel.ACL
+	db.Where("id = ?", oldPolicy.ACLID).Find(&oldACL)
+
+	// pointers are copied, otherwise the patch overwrites the old policy
+	policyCreate := model.PcapPolicyCreate{
+		Name:         oldPolicy.Name,
+		State:        copyIntPtr(&oldPolicy.State),
+		ACLID:        oldPolicy.ACLID,
+		PayloadSlice: copyIntPtr(oldPolicy.PayloadSlice),
+	}
+	if err := mergePolicyPatch(&policyCreate, patchMap); err != nil {
+		return nil, err
+	}
+	policy, acl, err := checkPcapPolicy(db, &policyCreate, lcuuid)
+	if err != nil {
+		return nil, err
+	}
+	if !policyVTapsPatched(patchMap) {
+		policy.VtapIDs = oldPolicy.VtapIDs
+	}
+	if dryRun {
+		return previewPolicyChange(db, POLICY_OPERATION_UPDATE,
+			append(pcapPolicyTargets(oldACL, &oldPolicy), pcapPolicyTargets(acl, policy)...))
+	}
+
+	log.Infof("update pcap policy (%s) config %v", oldPolicy.Name, patchMap, db.LogPrefixORGID)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if acl.ID != oldPolicy.ACLID {
+			if err := tx.Model(&mysqlmodel.PolicyACLGroup{}).Where("id = ?", oldPolicy.PolicyACLGroupID).
+				Update("acl_ids", strconv.Itoa(acl.ID)).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&oldPolicy).Updates(map[string]interface{}{
+			"name":          policy.Name,
+			"state":         policy.State,
+			"acl_id":        policy.ACLID,
+			"vtap_ids":      policy.VtapIDs,
+			"payload_slice": policy.PayloadSlice,
+		}).Error
+	})
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("update pcap policy (%s) failed, err: %v", lcuuid, err))
+	}
+	refreshFlowACL(db)
+
+	policies, err := GetPcapPolicy(db, map[string]interface{}{"lcuuid": lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &policies[0], nil
+}
+
+func DeletePcapPolicy(db *mysql.DB, lcuuid string, dryRun bool) (interface{}, error) {
+	var policy mysqlmodel.PcapPolicy
+	if err := db.Where("lcuuid = ?", lcuuid).First(&policy).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("pcap policy (%s) not found", lcuuid))
+	}
+	if dryRun {
+		var acl *mysqlmodel.ACL
+		db.Where("id = ?", policy.ACLID).Find(&acl)
+		return previewPolicyChange(db, POLICY_OPERATION_DELETE, pcapPolicyTargets(acl, &policy))
+	}
+
+	log.Infof("delete pcap policy (%s)", policy.Name, db.LogPrefixORGID)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", policy.PolicyACLGroupID).Delete(&mysqlmodel.PolicyACLGroup{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&policy).Error
+	})
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("delete pcap policy (%s) failed, err: %v", lcuuid, err))
+	}
+	refreshFlowACL(db)
+	return map[string]string{"LCUUID": lcuuid}, nil
+}
+
+func GetNpbPolicy(db *mysql.DB, filter map[string]interface{}) ([]model.NpbPolicy, error) {
+	var policies []mysqlmodel.NpbPolicy
+	queryDB := db.DB
+	for _, param := range []string{"lcuuid", "name", "acl_id", "npb_tunnel_id"} {
+		if _, ok := filter[param]; ok {
+			queryDB = queryDB.Where(fmt.Sprintf("%s = ?", param), filter[param])
+		}
+	}
+	if err := queryDB.Order("id").Find(&policies).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query npb policy, error: %s", err))
+	}
+
+	resp := make([]model.NpbPolicy, 0, len(policies))
+	for _, policy := range policies {
+		resp = append(resp, model.NpbPolicy{
+			ID:           policy.ID,
+			Name:         policy.Name,
+			State:        policy.State,
+			ACLID:        policy.ACLID,
+			NpbTunnelID:  policy.NpbTunnelID,
+			VTapIDs:      splitPolicyInts(policy.VtapIDs),
+			Direction:    policy.Direction,
+			Distribute:   policy.Distribute,
+			Vni:          policy.Vni,
+			PayloadSlice: policy.PayloadSlice,
+			CreatedAt:    policy.CreatedAt.Format(common.GO_BIRTHDAY),
+			UpdatedAt:    policy.UpdatedAt.Format(common.GO_BIRTHDAY),
+			Lcuuid:       policy.Lcuuid,
+		})
+	}
+	return resp, nil
+}
+
+func checkNpbPolicy(db *mysql.DB, policyCreate *model.NpbPolicyCreate, lcuuid string) (*mysqlmodel.NpbPolicy, *mysqlmodel.ACL, error) {
+	if err := checkPolicyCommon(policyCreate.Name, policyCreate.State, policyCreate.PayloadSlice); err != nil {
+		return nil, nil, err
+	}
+	if policyCreate.Direction == 0 {
+		policyCreate.Direction = 1
+	}
+	if policyCreate.Direction != 1 && policyCreate.Direction != 2 {
+		return nil, nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("DIRECTION (%d) must be 1 or 2", policyCreate.Direction))
+	}
+	distribute := common.NPB_POLICY_FLOW_DISTRIBUTE
+	if policyCreate.Distribute != nil {
+		distribute = *policyCreate.Distribute
+	}
+	if distribute != common.NPB_POLICY_FLOW_DROP && distribute != common.NPB_POLICY_FLOW_DISTRIBUTE {
+		return nil, nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("DISTRIBUTE (%d) must be 0 or 1", distribute))
+	}
+	if policyCreate.Vni != nil && (*policyCreate.Vni < 0 || *policyCreate.Vni > 0xFFFFFF) {
+		return nil, nil, NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("VNI (%d) must be in [0, %d]", *policyCreate.Vni, 0xFFFFFF))
+	}
+	var count int64
+	db.Model(&mysqlmodel.NpbPolicy{}).Where("name = ? AND lcuuid != ?", policyCreate.Name, lcuuid).Count(&count)
+	if count > 0 {
+		return nil, nil, NewError(httpcommon.RESOURCE_ALREADY_EXIST, fmt.Sprintf("npb policy (name: %s) already exist", policyCreate.Name))
+	}
+	var tunnel mysqlmodel.NpbTunnel
+	if err := db.Where("id = ?", policyCreate.NpbTunnelID).First(&tunnel).Error; err != nil {
+		return nil, nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("npb tunnel (id: %d) not found", policyCreate.NpbTunnelID))
+	}
+	acl, err := checkPolicyACL(db, policyCreate.ACLID, ACL_APPLICATION_NPB)
+	if err != nil {
+		return nil, nil, err
+	}
+	vtapIDs, err := resolvePolicyVTapIDs(db, policyCreate.VTapIDs, policyCreate.VTapGroupIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &mysqlmodel.NpbPolicy{
+		Name:         policyCreate.Name,
+		State:        policyState(policyCreate.State),
+		BusinessID:   POLICY_DEFAULT_BUSINESS_ID,
+		Direction:    policyCreate.Direction,
+		Vni:          policyCreate.Vni,
+		NpbTunnelID:  tunnel.ID,
+		Distribute:   distribute,
+		PayloadSlice: policyCreate.PayloadSlice,
+		ACLID:        acl.ID,
+		VtapIDs:      vtapIDs,
+		Lcuuid:       lcuuid,
+	}, acl, nil
+}
+
+func CreateNpbPolicy(db *mysql.DB, policyCreate model.NpbPolicyCreate, dryRun bool) (interface{}, error) {
+	policy, acl, err := checkNpbPolicy(db, &policyCreate, uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return previewPolicyChange(db, POLICY_OPERATION_CREATE, npbPolicyTargets(acl, policy))
+	}
+
+	log.Infof("create npb policy (%s) config %+v", policy.Name, policyCreate, db.LogPrefixORGID)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		aclGroup := mysqlmodel.PolicyACLGroup{ACLIDs: strconv.Itoa(acl.ID), COUNT: 1}
+		if err := tx.Create(&aclGroup).Error; err != nil {
+			return err
+		}
+		policy.PolicyACLGroupID = aclGroup.ID
+		return tx.Create(policy).Error
+	})
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("create npb policy (name: %s) failed, err: %v", policy.Name, err))
+	}
+	refreshFlowACL(db)
+
+	policies, err := GetNpbPolicy(db, map[string]interface{}{"lcuuid": policy.Lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &policies[0], nil
+}
+
+func UpdateNpbPolicy(db *mysql.DB, lcuuid string, patchMap map[string]interface{}, dryRun bool) (interface{}, error) {
+	var oldPolicy mysqlmodel.NpbPolicy
+	if err := db.Where("lcuuid = ?", lcuuid).First(&oldPolicy).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("npb policy (%s) not found", lcuuid))
+	}
+	var oldACL *mysqlmodel.ACL
+	db.Where("id = ?", oldPolicy.ACLID).Find(&oldACL)
+
+	// pointers are copied, otherwise the patch overwrites the old policy
+	policyCreate := model.NpbPolicyCreate{
+		Name:         oldPolicy.Name,
+		State:        copyIntPtr(&oldPolicy.State),
+		ACLID:        oldPolicy.ACLID,
+		NpbTunnelID:  oldPolicy.NpbTunnelID,
+		Direction:    oldPolicy.Direction,
+		Distribute:   copyIntPtr(&oldPolicy.Distribute),
+		Vni:          copyIntPtr(oldPolicy.Vni),
+		PayloadSlice: copyIntPtr(oldPolicy.PayloadSlice),
+	}
+	if err := mergePolicyPatch(&policyCreate, patchMap); err != nil {
+		return nil, err
+	}
+	policy, acl, err := checkNpbPolicy(db, &policyCreate, lcuuid)
+	if err != nil {
+		return nil, err
+	}
+	if !policyVTapsPatched(patchMap) {
+		policy.VtapIDs = oldPolicy.VtapIDs
+	}
+	if dryRun {
+		return previewPolicyChange(db, POLICY_OPERATION_UPDATE,
+			append(npbPolicyTargets(oldACL, &oldPolicy), npbPolicyTargets(acl, policy)...))
+	}
+
+	log.Infof("update npb policy (%s) config %v", oldPolicy.Name, patchMap, db.LogPrefixORGID)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if acl.ID != oldPolicy.ACLID {
+			if err := tx.Model(&mysqlmodel.PolicyACLGroup{}).Where("id = ?", oldPolicy.PolicyACLGroupID).
+				Update("acl_ids", strconv.Itoa(acl.ID)).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&oldPolicy).Updates(map[string]interface{}{
+			"name":          policy.Name,
+			"state":         policy.State,
+			"acl_id":        policy.ACLID,
+			"npb_tunnel_id": policy.NpbTunnelID,
+			"vtap_ids":      policy.VtapIDs,
+			"direction":     policy.Direction,
+			"distribute":    policy.Distribute,
+			"vni":           policy.Vni,
+			"payload_slice": policy.PayloadSlice,
+		}).Error
+	})
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("update npb policy (%s) failed, err: %v", lcuuid, err))
+	}
+	refreshFlowACL(db)
+
+	policies, err := GetNpbPolicy(db, map[string]interface{}{"lcuuid": lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &policies[0], nil
+}
+
+func DeleteNpbPolicy(db *mysql.DB, lcuuid string, dryRun bool) (interface{}, error) {
+	var policy mysqlmodel.NpbPolicy
+	if err := db.Where("lcuuid = ?", lcuuid).First(&policy).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("npb policy (%s) not found", lcuuid))
+	}
+	if dryRun {
+		var acl *mysqlmodel.ACL
+		db.Where("id = ?", policy.ACLID).Find(&acl)
+		return previewPolicyChange(db, POLICY_OPERATION_DELETE, npbPolicyTargets(acl, &policy))
+	}
+
+	log.Infof("delete npb policy (%s)", policy.Name, db.LogPrefixORGID)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", policy.PolicyACLGroupID).Delete(&mysqlmodel.PolicyACLGroup{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&policy).Error
+	})
+	if err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("delete npb policy (%s) failed, err: %v", lcuuid, err))
+	}
+	refreshFlowACL(db)
+	return map[string]string{"LCUUID": lcuuid}, nil
+}
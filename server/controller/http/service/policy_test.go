@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckACLPorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   string
+		wantErr bool
+	}{
+		{name: "empty", ports: "", wantErr: false},
+		{name: "single port", ports: "80", wantErr: false},
+		{name: "ports and ranges", ports: "80,443, 8000-8080", wantErr: false},
+		{name: "max port", ports: "65535", wantErr: false},
+		{name: "out of range", ports: "65536", wantErr: true},
+		{name: "negative", ports: "-1", wantErr: true},
+		{name: "reversed range", ports: "8080-8000", wantErr: true},
+		{name: "not a number", ports: "http", wantErr: true},
+		{name: "too many bounds", ports: "1-2-3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkACLPorts(tt.ports); (err != nil) != tt.wantErr {
+				t.Errorf("checkACLPorts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResourceGroupIPType(t *testing.T) {
+	tests := []struct {
+		name    string
+		ips     []string
+		want    int
+		wantErr bool
+	}{
+		{name: "single", ips: []string{"10.1.1.1", "fe80::1"}, want: RESOURCE_GROUP_IP_TYPE_SINGLE},
+		{name: "range", ips: []string{"10.1.1.1-10.1.1.9"}, want: RESOURCE_GROUP_IP_TYPE_RANGE},
+		{name: "cidr", ips: []string{"10.1.0.0/16", "fe80::/64"}, want: RESOURCE_GROUP_IP_TYPE_CIDR},
+		{name: "mix", ips: []string{"10.1.1.1", "10.2.0.0/16"}, want: RESOURCE_GROUP_IP_TYPE_MIX},
+		{name: "empty", ips: nil, wantErr: true},
+		{name: "invalid ip", ips: []string{"10.1.1.256"}, wantErr: true},
+		{name: "invalid cidr", ips: []string{"10.1.0.0/33"}, wantErr: true},
+		{name: "reversed range", ips: []string{"10.1.1.9-10.1.1.1"}, wantErr: true},
+		{name: "mixed family range", ips: []string{"10.1.1.1-fe80::1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resourceGroupIPType(tt.ips)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resourceGroupIPType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("resourceGroupIPType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyInts(t *testing.T) {
+	ids := []int{1, 3, 20}
+	if got := joinPolicyInts(ids); got != "1,3,20" {
+		t.Errorf("joinPolicyInts() = %v, want 1,3,20", got)
+	}
+	if got := splitPolicyInts("1, 3,x,20"); !reflect.DeepEqual(got, ids) {
+		t.Errorf("splitPolicyInts() = %v, want %v", got, ids)
+	}
+	if got := splitPolicyInts(""); len(got) != 0 {
+		t.Errorf("splitPolicyInts() = %v, want empty", got)
+	}
+}
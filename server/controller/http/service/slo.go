@@ -0,0 +1,222 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/deepflowio/deepflow/server/controller/common"
+	"github.com/deepflowio/deepflow/server/controller/db/mysql"
+	mysqlmodel "github.com/deepflowio/deepflow/server/controller/db/mysql/model"
+	httpcommon "github.com/deepflowio/deepflow/server/controller/http/common"
+	. "github.com/deepflowio/deepflow/server/controller/http/service/common"
+	"github.com/deepflowio/deepflow/server/controller/model"
+)
+
+const (
+	SLI_TYPE_AVAILABILITY = "availability"
+	SLI_TYPE_LATENCY      = "latency"
+
+	SLI_SOURCE_DEEPFLOW = "deepflow"
+	SLI_SOURCE_PROMQL   = "promql"
+
+	SLO_DEFAULT_DB          = "flow_metrics"
+	SLO_DEFAULT_TIME_WINDOW = 30 * 24 * 3600
+)
+
+func GetSLO(db *mysql.DB, filter map[string]interface{}) ([]model.SLO, error) {
+	var slos []mysqlmodel.SLO
+	queryDB := db.DB
+	for _, param := range []string{"lcuuid", "name"} {
+		if _, ok := filter[param]; ok {
+			queryDB = queryDB.Where(fmt.Sprintf("%s = ?", param), filter[param])
+		}
+	}
+	if err := queryDB.Order("id").Find(&slos).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query slo, error: %s", err))
+	}
+
+	resp := make([]model.SLO, 0, len(slos))
+	for _, slo := range slos {
+		resp = append(resp, model.SLO{
+			ID:          slo.ID,
+			Name:        slo.Name,
+			Description: slo.Description,
+			SLIType:     slo.SLIType,
+			SLISource:   slo.SLISource,
+			DB:          slo.DB,
+			GoodQuery:   slo.GoodQuery,
+			TotalQuery:  slo.TotalQuery,
+			Target:      slo.Target,
+			TimeWindow:  slo.TimeWindow,
+			CreatedAt:   slo.CreatedAt.Format(common.GO_BIRTHDAY),
+			UpdatedAt:   slo.UpdatedAt.Format(common.GO_BIRTHDAY),
+			Lcuuid:      slo.Lcuuid,
+		})
+	}
+	return resp, nil
+}
+
+func CreateSLO(db *mysql.DB, sloCreate model.SLOCreate) (*model.SLO, error) {
+	slo := mysqlmodel.SLO{
+		Name:        sloCreate.Name,
+		Description: sloCreate.Description,
+		SLIType:     sloCreate.SLIType,
+		SLISource:   sloCreate.SLISource,
+		DB:          sloCreate.DB,
+		GoodQuery:   sloCreate.GoodQuery,
+		TotalQuery:  sloCreate.TotalQuery,
+		Target:      sloCreate.Target,
+		TimeWindow:  sloCreate.TimeWindow,
+		Lcuuid:      uuid.New().String(),
+	}
+	if err := checkSLO(&slo); err != nil {
+		return nil, err
+	}
+
+	var sloFirst mysqlmodel.SLO
+	if err := db.Where("name = ?", slo.Name).First(&sloFirst).Error; err == nil {
+		return nil, NewError(httpcommon.RESOURCE_ALREADY_EXIST, fmt.Sprintf("slo (name: %s) already exist", slo.Name))
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("fail to query slo by name(%s), error: %s", slo.Name, err))
+	}
+
+	log.Infof("create slo (%s) config %+v", slo.Name, sloCreate, db.LogPrefixORGID)
+	if err := db.Create(&slo).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("create slo (name: %s) failed, err: %v", slo.Name, err))
+	}
+
+	slos, err := GetSLO(db, map[string]interface{}{"lcuuid": slo.Lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &slos[0], nil
+}
+
+func UpdateSLO(db *mysql.DB, lcuuid string, sloUpdate map[string]interface{}) (*model.SLO, error) {
+	var slo mysqlmodel.SLO
+	if err := db.Where("lcuuid = ?", lcuuid).First(&slo).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("slo (%s) not found", lcuuid))
+	}
+
+	dbUpdateMap := make(map[string]interface{})
+	for _, key := range []string{
+		"NAME", "DESCRIPTION", "SLI_TYPE", "SLI_SOURCE", "DB", "GOOD_QUERY", "TOTAL_QUERY", "TARGET", "TIME_WINDOW",
+	} {
+		if _, ok := sloUpdate[key]; ok {
+			dbUpdateMap[strings.ToLower(key)] = sloUpdate[key]
+		}
+	}
+
+	// 校验更新后的完整配置
+	updated := slo
+	if err := mergeSLOPatch(&updated, sloUpdate); err != nil {
+		return nil, NewError(httpcommon.INVALID_PARAMETERS, err.Error())
+	}
+	if err := checkSLO(&updated); err != nil {
+		return nil, err
+	}
+	if updated.Name != slo.Name {
+		var count int64
+		db.Model(&mysqlmodel.SLO{}).Where("name = ? AND lcuuid != ?", updated.Name, lcuuid).Count(&count)
+		if count > 0 {
+			return nil, NewError(httpcommon.RESOURCE_ALREADY_EXIST, fmt.Sprintf("slo (name: %s) already exist", updated.Name))
+		}
+	}
+
+	log.Infof("update slo (%s) config %v", slo.Name, sloUpdate, db.LogPrefixORGID)
+	if err := db.Model(&slo).Updates(dbUpdateMap).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("update slo (%s) failed, err: %v", lcuuid, err))
+	}
+
+	slos, err := GetSLO(db, map[string]interface{}{"lcuuid": lcuuid})
+	if err != nil {
+		return nil, err
+	}
+	return &slos[0], nil
+}
+
+func DeleteSLO(db *mysql.DB, lcuuid string) (map[string]string, error) {
+	var slo mysqlmodel.SLO
+	if err := db.Where("lcuuid = ?", lcuuid).First(&slo).Error; err != nil {
+		return nil, NewError(httpcommon.RESOURCE_NOT_FOUND, fmt.Sprintf("slo (%s) not found", lcuuid))
+	}
+
+	log.Infof("delete slo (%s)", slo.Name, db.LogPrefixORGID)
+	if err := db.Delete(&slo).Error; err != nil {
+		return nil, NewError(httpcommon.SERVER_ERROR, fmt.Sprintf("delete slo (%s) failed, err: %v", lcuuid, err))
+	}
+	return map[string]string{"LCUUID": lcuuid}, nil
+}
+
+// mergeSLOPatch applies the patch map (keys are the json tags of the model) to slo
+func mergeSLOPatch(slo *mysqlmodel.SLO, patchMap map[string]interface{}) error {
+	patch := make(map[string]interface{})
+	for key, value := range patchMap {
+		switch key {
+		case "NAME", "DESCRIPTION", "SLI_TYPE", "SLI_SOURCE", "DB", "GOOD_QUERY", "TOTAL_QUERY", "TARGET", "TIME_WINDOW":
+			patch[key] = value
+		}
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, slo)
+}
+
+// checkSLO fills default values and validates the slo config
+func checkSLO(slo *mysqlmodel.SLO) error {
+	if slo.SLIType == "" {
+		slo.SLIType = SLI_TYPE_AVAILABILITY
+	}
+	if slo.SLISource == "" {
+		slo.SLISource = SLI_SOURCE_DEEPFLOW
+	}
+	if slo.SLISource == SLI_SOURCE_DEEPFLOW && slo.DB == "" {
+		slo.DB = SLO_DEFAULT_DB
+	}
+	if slo.TimeWindow == 0 {
+		slo.TimeWindow = SLO_DEFAULT_TIME_WINDOW
+	}
+
+	if slo.Name == "" {
+		return NewError(httpcommon.INVALID_PARAMETERS, "NAME must not be empty")
+	}
+	if slo.SLIType != SLI_TYPE_AVAILABILITY && slo.SLIType != SLI_TYPE_LATENCY {
+		return NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("SLI_TYPE (%s) must be %s or %s", slo.SLIType, SLI_TYPE_AVAILABILITY, SLI_TYPE_LATENCY))
+	}
+	if slo.SLISource != SLI_SOURCE_DEEPFLOW && slo.SLISource != SLI_SOURCE_PROMQL {
+		return NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("SLI_SOURCE (%s) must be %s or %s", slo.SLISource, SLI_SOURCE_DEEPFLOW, SLI_SOURCE_PROMQL))
+	}
+	if strings.TrimSpace(slo.GoodQuery) == "" || strings.TrimSpace(slo.TotalQuery) == "" {
+		return NewError(httpcommon.INVALID_PARAMETERS, "GOOD_QUERY and TOTAL_QUERY must not be empty")
+	}
+	if slo.Target <= 0 || slo.Target >= 100 {
+		return NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("TARGET (%v) must be in (0, 100)", slo.Target))
+	}
+	if slo.TimeWindow <= 0 {
+		return NewError(httpcommon.INVALID_PARAMETERS, fmt.Sprintf("TIME_WINDOW (%d) must be greater than 0", slo.TimeWindow))
+	}
+	return nil
+}
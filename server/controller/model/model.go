@@ -722,6 +722,7 @@ type Plugin struct {
 	Type      int    `json:"TYPE" binding:"required"`
 	User      int    `json:"USER" binding:"required"`
 	Image     []byte `json:"IMAGE,omitempty" binding:"required"`
+	ImageMD5  string `json:"IMAGE_MD5,omitempty"` // used by deepflow-ctl diff to detect image changes
 	UpdatedAt string `json:"UPDATED_AT"`
 }
 
@@ -763,6 +764,172 @@ type MailServer struct {
 	Lcuuid       string `json:"LCUUID"`
 }
 
+// SLI of SLO is the ratio of good events to total events, queries are DeepFlow SQL without time range or PromQL
+// with $__range, e.g.:
+//
+//	SELECT Sum(response)-Sum(server_error) AS value FROM application WHERE pod_service='cart'
+//	sum(increase(http_requests_total{code!~"5.."}[$__range]))
+type SLOCreate struct {
+	Name        string  `json:"NAME" binding:"required"`
+	Description string  `json:"DESCRIPTION"`
+	SLIType     string  `json:"SLI_TYPE"`
+	SLISource   string  `json:"SLI_SOURCE"`
+	DB          string  `json:"DB"`
+	GoodQuery   string  `json:"GOOD_QUERY" binding:"required"`
+	TotalQuery  string  `json:"TOTAL_QUERY" binding:"required"`
+	Target      float64 `json:"TARGET" binding:"required"`
+	TimeWindow  int     `json:"TIME_WINDOW"`
+}
+
+type SLOUpdate struct {
+	Name        string  `json:"NAME"`
+	Description string  `json:"DESCRIPTION"`
+	SLIType     string  `json:"SLI_TYPE"`
+	SLISource   string  `json:"SLI_SOURCE"`
+	DB          string  `json:"DB"`
+	GoodQuery   string  `json:"GOOD_QUERY"`
+	TotalQuery  string  `json:"TOTAL_QUERY"`
+	Target      float64 `json:"TARGET"`
+	TimeWindow  int     `json:"TIME_WINDOW"`
+}
+
+type SLO struct {
+	ID          int     `json:"ID"`
+	Name        string  `json:"NAME"`
+	Description string  `json:"DESCRIPTION"`
+	SLIType     string  `json:"SLI_TYPE"`
+	SLISource   string  `json:"SLI_SOURCE"`
+	DB          string  `json:"DB"`
+	GoodQuery   string  `json:"GOOD_QUERY"`
+	TotalQuery  string  `json:"TOTAL_QUERY"`
+	Target      float64 `json:"TARGET"`
+	TimeWindow  int     `json:"TIME_WINDOW"`
+	CreatedAt   string  `json:"CREATED_AT"`
+	UpdatedAt   string  `json:"UPDATED_AT"`
+	Lcuuid      string  `json:"LCUUID"`
+}
+
+type ResourceGroupCreate struct {
+	Name  string   `json:"NAME" binding:"required"`
+	Type  int      `json:"TYPE" binding:"required"` // 1: vm, 2: ip
+	VPCID int      `json:"VPC_ID"`                  // required by vm type, optional for ip type
+	IPs   []string `json:"IPS"`                     // single ip, ip range (e.g. 10.1.1.1-10.1.1.9) or cidr, for ip type
+	VMIDs []int    `json:"VM_IDS"`                  // for vm type, empty means all vms in the vpc
+}
+
+type ResourceGroup struct {
+	ID        int      `json:"ID"`
+	Name      string   `json:"NAME"`
+	Type      int      `json:"TYPE"`
+	IPType    int      `json:"IP_TYPE"`
+	VPCID     int      `json:"VPC_ID"`
+	IPs       []string `json:"IPS"`
+	VMIDs     []int    `json:"VM_IDS"`
+	CreatedAt string   `json:"CREATED_AT"`
+	UpdatedAt string   `json:"UPDATED_AT"`
+	Lcuuid    string   `json:"LCUUID"`
+}
+
+type ACLCreate struct {
+	Name        string `json:"NAME" binding:"required"`
+	State       *int   `json:"STATE"`                          // 0: disable, 1: enable, default 1
+	Application string `json:"APPLICATION" binding:"required"` // pcap | npb
+	TapType     int    `json:"TAP_TYPE"`                       // default 3 (cloud network)
+	VPCID       int    `json:"VPC_ID"`
+	SrcGroupIDs []int  `json:"SRC_GROUP_IDS"`
+	DstGroupIDs []int  `json:"DST_GROUP_IDS"`
+	Protocol    *int   `json:"PROTOCOL"` // empty means any, 6: tcp, 17: udp, 1: icmp
+	SrcPorts    string `json:"SRC_PORTS"`
+	DstPorts    string `json:"DST_PORTS"`
+	Vlan        int    `json:"VLAN"`
+}
+
+type ACL struct {
+	ID          int    `json:"ID"`
+	Name        string `json:"NAME"`
+	State       int    `json:"STATE"`
+	Application string `json:"APPLICATION"`
+	TapType     int    `json:"TAP_TYPE"`
+	VPCID       int    `json:"VPC_ID"`
+	SrcGroupIDs []int  `json:"SRC_GROUP_IDS"`
+	DstGroupIDs []int  `json:"DST_GROUP_IDS"`
+	Protocol    *int   `json:"PROTOCOL"`
+	SrcPorts    string `json:"SRC_PORTS"`
+	DstPorts    string `json:"DST_PORTS"`
+	Vlan        int    `json:"VLAN"`
+	CreatedAt   string `json:"CREATED_AT"`
+	UpdatedAt   string `json:"UPDATED_AT"`
+	Lcuuid      string `json:"LCUUID"`
+}
+
+// VTapIDs and VTapGroupIDs of policies are merged and saved as agent ids, agents joining the groups later are
+// not included. Both empty means the policy is delivered to all agents.
+type PcapPolicyCreate struct {
+	Name         string   `json:"NAME" binding:"required"`
+	State        *int     `json:"STATE"` // 0: disable, 1: enable, default 1
+	ACLID        int      `json:"ACL_ID" binding:"required"`
+	VTapIDs      []int    `json:"VTAP_IDS"`
+	VTapGroupIDs []string `json:"VTAP_GROUP_IDS"` // short uuid of agent groups, e.g. g-1yhIguXABC
+	PayloadSlice *int     `json:"PAYLOAD_SLICE"`
+}
+
+type PcapPolicy struct {
+	ID           int    `json:"ID"`
+	Name         string `json:"NAME"`
+	State        int    `json:"STATE"`
+	ACLID        int    `json:"ACL_ID"`
+	VTapIDs      []int  `json:"VTAP_IDS"`
+	PayloadSlice *int   `json:"PAYLOAD_SLICE"`
+	CreatedAt    string `json:"CREATED_AT"`
+	UpdatedAt    string `json:"UPDATED_AT"`
+	Lcuuid       string `json:"LCUUID"`
+}
+
+type NpbPolicyCreate struct {
+	Name         string   `json:"NAME" binding:"required"`
+	State        *int     `json:"STATE"` // 0: disable, 1: enable, default 1
+	ACLID        int      `json:"ACL_ID" binding:"required"`
+	NpbTunnelID  int      `json:"NPB_TUNNEL_ID" binding:"required"`
+	VTapIDs      []int    `json:"VTAP_IDS"`
+	VTapGroupIDs []string `json:"VTAP_GROUP_IDS"` // short uuid of agent groups, e.g. g-1yhIguXABC
+	Direction    int      `json:"DIRECTION"`      // 1: two way, 2: server to client, default 1
+	Distribute   *int     `json:"DISTRIBUTE"`     // 0: drop, 1: distribute, default 1
+	Vni          *int     `json:"VNI"`
+	PayloadSlice *int     `json:"PAYLOAD_SLICE"`
+}
+
+type NpbPolicy struct {
+	ID           int    `json:"ID"`
+	Name         string `json:"NAME"`
+	State        int    `json:"STATE"`
+	ACLID        int    `json:"ACL_ID"`
+	NpbTunnelID  int    `json:"NPB_TUNNEL_ID"`
+	VTapIDs      []int  `json:"VTAP_IDS"`
+	Direction    int    `json:"DIRECTION"`
+	Distribute   int    `json:"DISTRIBUTE"`
+	Vni          *int   `json:"VNI"`
+	PayloadSlice *int   `json:"PAYLOAD_SLICE"`
+	CreatedAt    string `json:"CREATED_AT"`
+	UpdatedAt    string `json:"UPDATED_AT"`
+	Lcuuid       string `json:"LCUUID"`
+}
+
+type PolicyAffectedVTap struct {
+	ID              int    `json:"ID"`
+	Name            string `json:"NAME"`
+	VTapGroupLcuuid string `json:"VTAP_GROUP_LCUUID"`
+}
+
+// PolicyChangePreview is the result of a dry-run change of policies, acls or resource groups
+type PolicyChangePreview struct {
+	Operation    string               `json:"OPERATION"` // create | update | delete
+	AllVTaps     bool                 `json:"ALL_VTAPS"` // policies shared by all agents are affected
+	Ingester     bool                 `json:"INGESTER"`  // pcap policies are also delivered to ingesters
+	VTaps        []PolicyAffectedVTap `json:"VTAPS"`
+	PcapPolicies []string             `json:"PCAP_POLICIES"` // names of affected pcap policies
+	NpbPolicies  []string             `json:"NPB_POLICIES"`  // names of affected npb policies
+}
+
 type RemoteExecReq struct {
 	trident.RemoteExecRequest
 
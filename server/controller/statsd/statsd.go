@@ -65,13 +65,20 @@ func (s *StatsdMonitor) initStatsdClient() error {
 }
 
 func (s *StatsdMonitor) RegisterStatsdTable(stable Statsdtable) {
-	if !s.enable {
+	prometheusEnabled := stats.IsPrometheusEnabled()
+	if !s.enable && !prometheusEnabled {
 		return
 	}
 
-	if err := s.initStatsdClient(); err != nil {
-		log.Warning(err)
-		return
+	statsdEnabled := s.enable
+	if statsdEnabled {
+		if err := s.initStatsdClient(); err != nil {
+			log.Warning(err)
+			if !prometheusEnabled {
+				return
+			}
+			statsdEnabled = false
+		}
 	}
 
 	encoder := new(codec.SimpleEncoder)
@@ -122,6 +129,22 @@ func (s *StatsdMonitor) RegisterStatsdTable(stable Statsdtable) {
 				continue
 			}
 
+			if prometheusEnabled {
+				tags := make(map[string]string, len(tagNames)+1)
+				for i, k := range tagNames {
+					tags[k] = tagValues[i]
+				}
+				tags["org_id"] = strconv.Itoa(statter.OrgID)
+				fields := make(map[string]interface{}, len(metricsFloatNames))
+				for i, k := range metricsFloatNames {
+					fields[k] = metricsFloatValues[i]
+				}
+				stats.ObservePrometheus(name, tags, fields)
+			}
+			if !statsdEnabled {
+				continue
+			}
+
 			dfStats.OrgId = uint32(statter.OrgID)
 			dfStats.TeamId = uint32(statter.TeamID)
 			dfStats.Timestamp = uint64(timeStamp)
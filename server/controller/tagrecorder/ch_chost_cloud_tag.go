@@ -61,7 +61,7 @@ func (c *ChChostCloudTag) onResourceUpdated(sourceID int, fieldsUpdate *message.
 				if oldV != v {
 					key := c.newTargetKey(sourceID, k)
 					updateInfo["value"] = v
-					db.Where("id = ? and `key` = ?", sourceID, k).First(&chItem) // TODO common
+					db.Where(map[string]interface{}{"id": sourceID, "key": k}).First(&chItem) // TODO common
 					if chItem.ID == 0 {
 						keysToAdd = append(keysToAdd, key)
 						targetsToAdd = append(targetsToAdd, mysqlmodel.ChChostCloudTag{
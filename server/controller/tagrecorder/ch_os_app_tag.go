@@ -64,7 +64,7 @@ func (c *ChOSAppTag) onResourceUpdated(sourceID int, fieldsUpdate *message.Proce
 				if oldV != v {
 					updateKey = OSAPPTagKey{PID: sourceID, Key: k}
 					updateInfo["value"] = v
-					db.Where("pid = ? and `key` = ?", sourceID, k).First(&chItem) // TODO common
+					db.Where(map[string]interface{}{"pid": sourceID, "key": k}).First(&chItem) // TODO common
 					if chItem.PID == 0 {
 						keysToAdd = append(keysToAdd, OSAPPTagKey{PID: sourceID, Key: k})
 						targetsToAdd = append(targetsToAdd, mysqlmodel.ChOSAppTag{
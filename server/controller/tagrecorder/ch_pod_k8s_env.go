@@ -64,7 +64,7 @@ func (c *ChPodK8sEnv) onResourceUpdated(sourceID int, fieldsUpdate *message.PodF
 				if oldV != v {
 					updateKey = K8sEnvKey{ID: sourceID, Key: k}
 					updateInfo["value"] = v
-					db.Where("id = ? and `key` = ?", sourceID, k).First(&chItem)
+					db.Where(map[string]interface{}{"id": sourceID, "key": k}).First(&chItem)
 					if chItem.ID == 0 {
 						keysToAdd = append(keysToAdd, K8sEnvKey{ID: sourceID, Key: k})
 						targetsToAdd = append(targetsToAdd, mysqlmodel.ChPodK8sEnv{
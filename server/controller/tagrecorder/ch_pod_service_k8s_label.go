@@ -63,7 +63,7 @@ func (c *ChPodServiceK8sLabel) onResourceUpdated(sourceID int, fieldsUpdate *mes
 				if oldV != v {
 					key := K8sLabelKey{ID: sourceID, Key: k}
 					var chItem mysqlmodel.ChPodServiceK8sLabel
-					db.Where("id = ? and `key` = ?", sourceID, k).First(&chItem)
+					db.Where(map[string]interface{}{"id": sourceID, "key": k}).First(&chItem)
 					if chItem.ID == 0 {
 						keysToAdd = append(keysToAdd, key)
 						targetsToAdd = append(targetsToAdd, mysqlmodel.ChPodServiceK8sLabel{
@@ -214,11 +214,14 @@ var CH_IP_RESOURCE_TAGS = []string{
 const (
 	SQL_CREATE_DICT               = "CREATE DICTIONARY %s.%s\n"
 	SQL_REPLICA                   = "REPLICA (HOST '%s' PRIORITY 1)"
-	SQL_SOURCE_MYSQL              = "SOURCE(MYSQL(PORT %d USER '%s' PASSWORD '%s' %s DB %s TABLE %s INVALIDATE_QUERY 'select(select updated_at from %s order by updated_at desc limit 1) as updated_at'))\n"
+	SQL_SOURCE                    = "SOURCE(%s(PORT %d USER '%s' PASSWORD '%s' %s DB %s TABLE %s INVALIDATE_QUERY 'select(select updated_at from %s order by updated_at desc limit 1) as updated_at'))\n"
 	SQL_LIFETIME                  = "LIFETIME(MIN 30 MAX %d)\n"
 	SQL_LAYOUT_FLAT               = "LAYOUT(FLAT())"
 	SQL_LAYOUT_COMPLEX_KEY_HASHED = "LAYOUT(COMPLEX_KEY_HASHED())"
 
+	SQL_SOURCE_TYPE_MYSQL      = "MYSQL"
+	SQL_SOURCE_TYPE_POSTGRESQL = "POSTGRESQL"
+
 	CREATE_DICTIONARY_SQL = SQL_CREATE_DICT +
 		"(\n" +
 		"    `id` UInt64,\n" +
@@ -229,7 +232,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_AZ_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -241,7 +244,7 @@ const (
 		"    `domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_REGION_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -251,7 +254,7 @@ const (
 		"    `icon_id` Int64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_VPC_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -264,7 +267,7 @@ const (
 		"    `domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_TAP_TYPE_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -273,7 +276,7 @@ const (
 		"    `name` String\n" +
 		")\n" +
 		"PRIMARY KEY value\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_VTAP_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -290,7 +293,7 @@ const (
 		"    `pod_node_name` String\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_DEVICE_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -307,7 +310,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY devicetype, deviceid\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_VTAP_PORT_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -328,7 +331,7 @@ const (
 		"    `team_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY vtap_id, tap_port\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_PORT_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -344,7 +347,7 @@ const (
 		"    `port_pod_service_name` String\n" +
 		")\n" +
 		"PRIMARY KEY id, protocol, port\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_IP_PORT_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -361,7 +364,7 @@ const (
 		"    `port_pod_service_name` String\n" +
 		")\n" +
 		"PRIMARY KEY ip, subnet_id, protocol, port\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_DEVICE_PORT_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -378,7 +381,7 @@ const (
 		"    `port_pod_service_name` String\n" +
 		")\n" +
 		"PRIMARY KEY devicetype, deviceid, protocol, port\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_SERVER_PORT_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -387,7 +390,7 @@ const (
 		"    `server_port_name` String\n" +
 		")\n" +
 		"PRIMARY KEY server_port\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_IP_RELATION_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -407,7 +410,7 @@ const (
 		"    `team_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY l3_epc_id, ip\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_ID_NAME_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -416,7 +419,7 @@ const (
 		"    `name` String\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_NPB_TUNNEL_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -426,7 +429,7 @@ const (
 		"    `team_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_POD_NODE_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -440,7 +443,7 @@ const (
 		"    `pod_cluster_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_POD_INGRESS_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -454,7 +457,7 @@ const (
 		"    `pod_cluster_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_LB_LISTENER_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -464,7 +467,7 @@ const (
 		"    `team_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_K8S_LABEL_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -479,7 +482,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id, key\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_K8S_LABELS_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -493,7 +496,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_IP_RESOURCE_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -542,7 +545,7 @@ const (
 		"    `uid` String\n" +
 		")\n" +
 		"PRIMARY KEY ip, subnet_id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_STRING_ENUM_SQL = SQL_CREATE_DICT +
@@ -553,7 +556,7 @@ const (
 		"    `description` String\n" +
 		")\n" +
 		"PRIMARY KEY tag_name, value\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_INT_ENUM_SQL = SQL_CREATE_DICT +
@@ -564,7 +567,7 @@ const (
 		"    `description` String\n" +
 		")\n" +
 		"PRIMARY KEY tag_name, value\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_NODE_TYPE_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -573,7 +576,7 @@ const (
 		"    `node_type` String\n" +
 		")\n" +
 		"PRIMARY KEY resource_type\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_CLOUD_TAG_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -585,7 +588,7 @@ const (
 		"    `domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id, key\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_POD_NS_CLOUD_TAG_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -598,7 +601,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id, key\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_CLOUD_TAGS_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -609,7 +612,7 @@ const (
 		"    `domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_POD_NS_CLOUD_TAGS_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -621,7 +624,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_OS_APP_TAG_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -634,7 +637,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY pid, key\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_OS_APP_TAGS_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -646,7 +649,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY pid\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 
@@ -662,7 +665,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id, key\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_K8S_ANNOTATIONS_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -676,7 +679,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_K8S_ENV_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -691,7 +694,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id, key\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_K8S_ENVS_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -705,7 +708,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_PROMETHEUS_LABEL_NAME_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -714,7 +717,7 @@ const (
 		"    `name` String\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_PROMETHEUS_METRIC_APP_LABEL_LAYOUT_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -725,7 +728,7 @@ const (
 		"    `app_label_column_index` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_APP_LABEL_SQL = SQL_CREATE_DICT +
@@ -735,7 +738,7 @@ const (
 		"    `label_value_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY label_name_id, label_value_id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_TARGET_LABEL_SQL = SQL_CREATE_DICT +
@@ -746,7 +749,7 @@ const (
 		"    `target_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY metric_id, label_name_id, target_id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_PROMETHEUS_TARGET_LABEL_LAYOUT_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -756,7 +759,7 @@ const (
 		"    `target_label_values` String\n" +
 		")\n" +
 		"PRIMARY KEY target_id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_POD_NS_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -770,7 +773,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_POD_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -788,7 +791,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_POD_SERVICE_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -802,7 +805,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_CHOST_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -817,7 +820,7 @@ const (
 		"    `domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_POD_GROUP_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -833,7 +836,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_GPROCESS_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -848,7 +851,7 @@ const (
 		"    `sub_domain_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 	CREATE_POLICY_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -860,7 +863,7 @@ const (
 		"    `team_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY tunnel_type, acl_gid\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_COMPLEX_KEY_HASHED
 	CREATE_AlARM_POLICY_DICTIONARY_SQL = SQL_CREATE_DICT +
@@ -871,7 +874,7 @@ const (
 		"    `team_id` UInt64\n" +
 		")\n" +
 		"PRIMARY KEY id\n" +
-		SQL_SOURCE_MYSQL +
+		SQL_SOURCE +
 		SQL_LIFETIME +
 		SQL_LAYOUT_FLAT
 )
@@ -62,6 +62,10 @@ func (c *Dictionary) Init(cfg config.ControllerConfig) {
 }
 
 func (c *Dictionary) Start(sCtx context.Context) {
+	if _, err := c.sourceType(); err != nil {
+		log.Errorf("tagrecorder does not update ch dictionary: %s", err.Error())
+		return
+	}
 	go func() {
 		ticker := time.NewTicker(time.Duration(c.cfg.TagRecorderCfg.Interval) * time.Second)
 		defer ticker.Stop()
@@ -147,7 +151,25 @@ func (c *Dictionary) Update() {
 	return
 }
 
+// sourceType returns the type of the ClickHouse dictionary source which reads the ch tables of the controller database,
+// ClickHouse dictionaries can not read SQLite databases.
+func (c *Dictionary) sourceType() (string, error) {
+	switch dbType := mysqlCommon.GetDBType(c.cfg.MySqlCfg); dbType {
+	case mysqlCommon.DB_TYPE_MYSQL:
+		return SQL_SOURCE_TYPE_MYSQL, nil
+	case mysqlCommon.DB_TYPE_POSTGRESQL:
+		return SQL_SOURCE_TYPE_POSTGRESQL, nil
+	default:
+		return "", fmt.Errorf("ClickHouse dictionaries do not support database type %s", dbType)
+	}
+}
+
 func (c *Dictionary) update(clickHouseCfg *clickhouse.ClickHouseConfig) {
+	sourceType, err := c.sourceType()
+	if err != nil {
+		log.Error(err)
+		return
+	}
 	var mysqlDatabaseName string
 	var ckDatabaseName string
 	// 在本区域所有数据节点更新字典
@@ -313,7 +335,7 @@ func (c *Dictionary) update(clickHouseCfg *clickhouse.ClickHouseConfig) {
 			dictName := dict.(string)
 			chTable := "ch_" + strings.TrimSuffix(dictName, "_map")
 			createSQL := CREATE_SQL_MAP[dictName]
-			createSQL = fmt.Sprintf(createSQL, ckDatabaseName, dictName, mysqlPort, c.cfg.MySqlCfg.UserName, c.cfg.MySqlCfg.UserPassword, replicaSQL, mysqlDatabaseName, chTable, chTable, c.cfg.TagRecorderCfg.DictionaryRefreshInterval)
+			createSQL = fmt.Sprintf(createSQL, ckDatabaseName, dictName, sourceType, mysqlPort, c.cfg.MySqlCfg.UserName, c.cfg.MySqlCfg.UserPassword, replicaSQL, mysqlDatabaseName, chTable, chTable, c.cfg.TagRecorderCfg.DictionaryRefreshInterval)
 			log.Infof("create dictionary %s", dictName, logger.NewORGPrefix(orgID))
 			log.Info(createSQL, logger.NewORGPrefix(orgID))
 			_, err = ckDb.Exec(createSQL)
@@ -344,7 +366,7 @@ func (c *Dictionary) update(clickHouseCfg *clickhouse.ClickHouseConfig) {
 				break
 			}
 			createSQL := CREATE_SQL_MAP[dictName]
-			createSQL = fmt.Sprintf(createSQL, ckDatabaseName, dictName, mysqlPort, c.cfg.MySqlCfg.UserName, c.cfg.MySqlCfg.UserPassword, replicaSQL, mysqlDatabaseName, chTable, chTable, c.cfg.TagRecorderCfg.DictionaryRefreshInterval)
+			createSQL = fmt.Sprintf(createSQL, ckDatabaseName, dictName, sourceType, mysqlPort, c.cfg.MySqlCfg.UserName, c.cfg.MySqlCfg.UserPassword, replicaSQL, mysqlDatabaseName, chTable, chTable, c.cfg.TagRecorderCfg.DictionaryRefreshInterval)
 			// In the new version of CK (version after 23.8), when ‘SHOW CREATE DICTIONARY’ does not display plain text password information, the password is fixedly displayed as ‘[HIDDEN]’, and password comparison needs to be repair.
 			checkDictSQL := strings.Replace(dictSQL[0], "[HIDDEN]", c.cfg.MySqlCfg.UserPassword, 1)
 			if createSQL == checkDictSQL {
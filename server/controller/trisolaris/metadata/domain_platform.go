@@ -71,7 +71,7 @@ func (s DomainPlatformData) checkVersion(t DomainPlatformData, orgID int) bool {
 			newDomainData.setVersion(oldDomainData.GetVersion() + 1)
 			log.Infof("domain data changed, (%s) to (%s)", oldDomainData, newDomainData, logger.NewORGPrefix(orgID))
 		} else {
-			newDomainData.setVersion(oldDomainData.GetVersion())
+			newDomainData.inheritVersion(oldDomainData)
 		}
 	}
 	return flag
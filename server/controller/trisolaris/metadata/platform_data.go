@@ -39,6 +39,7 @@ type PlatformData struct {
 	cidrProtos         []*trident.Cidr
 	gprocessInfoProtos []*trident.GProcessInfo
 	version            uint64
+	versionTime        uint32 // unix time(s) when the version is generated
 	mergeDomains       []string
 	dataType           uint32
 }
@@ -80,18 +81,37 @@ func (f *PlatformData) GetPlatformDataVersion() uint64 {
 
 func (f *PlatformData) setVersion(version uint64) {
 	f.version = version
+	f.versionTime = uint32(time.Now().Unix())
 }
 
 func (f *PlatformData) GetVersion() uint64 {
 	return f.version
 }
 
+// inheritVersion keeps the version and the version time of the unchanged data
+func (f *PlatformData) inheritVersion(old *PlatformData) {
+	f.version = old.version
+	f.versionTime = old.versionTime
+}
+
+func (f *PlatformData) GetVersionTime() uint32 {
+	return f.versionTime
+}
+
 func (f *PlatformData) initVersion() {
 	rand.Seed(time.Now().Unix())
 	f.version = offsetVersion + uint64(time.Now().Unix()) + uint64(rand.Intn(10000))
+	f.versionTime = uint32(time.Now().Unix())
 	offsetVersion += offsetInterval
 }
 
+// mergeVersionTime keeps the latest version time of the merged data, which is when the merged version changes
+func (f *PlatformData) mergeVersionTime(other *PlatformData) {
+	if other.versionTime > f.versionTime {
+		f.versionTime = other.versionTime
+	}
+}
+
 func (f *PlatformData) initPlatformData(ifs []*trident.Interface, pcs []*trident.PeerConnection, cidrs []*trident.Cidr,
 	gpi []*trident.GProcessInfo) {
 	f.interfaceProtos = ifs
@@ -126,6 +146,7 @@ func (f *PlatformData) Merge(other *PlatformData) {
 	f.cidrProtos = append(f.cidrProtos, other.cidrProtos...)
 	f.gprocessInfoProtos = append(f.gprocessInfoProtos, other.gprocessInfoProtos...)
 	f.version += other.version
+	f.mergeVersionTime(other)
 	if len(other.domain) != 0 {
 		f.mergeDomains = append(f.mergeDomains, other.domain)
 	}
@@ -137,6 +158,7 @@ func (f *PlatformData) MergeInterfaces(other *PlatformData) {
 	}
 	f.interfaceProtos = append(f.interfaceProtos, other.interfaceProtos...)
 	f.version += other.version
+	f.mergeVersionTime(other)
 	if len(other.domain) != 0 {
 		f.mergeDomains = append(f.mergeDomains, other.domain)
 	}
@@ -148,6 +170,7 @@ func (f *PlatformData) MergePeerConnProtos(other *PlatformData) {
 	}
 	f.peerConnProtos = append(f.peerConnProtos, other.peerConnProtos...)
 	f.version += other.version
+	f.mergeVersionTime(other)
 	if len(other.domain) != 0 {
 		f.mergeDomains = append(f.mergeDomains, other.domain)
 	}
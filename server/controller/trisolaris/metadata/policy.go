@@ -623,16 +623,15 @@ func (op *PolicyDataOP) generateProtoActions(acl *models.ACL) (map[int][]*triden
 				NpbAclGroupId: proto.Uint32(uint32(npbPolicy.PolicyACLGroupID)),
 				Direction:     &direction,
 			}
-			if len(npbPolicy.VtapIDs) == 0 {
+			vtapIDs, err := PolicyVTapIDs(npbPolicy.VtapIDs)
+			if err != nil {
+				log.Errorf(op.Logf("err: %s, vtapIDs: %s", err, npbPolicy.VtapIDs))
+			}
+			if vtapIDs == nil {
 				allVTapNpbActions = append(allVTapNpbActions, npbAction)
 			} else {
-				for _, vtapIDStr := range strings.Split(npbPolicy.VtapIDs, ",") {
-					vtapIDInt, err := strconv.Atoi(vtapIDStr)
-					if err != nil {
-						log.Errorf(op.Logf("err: %s, vtapIDs: %s", err, npbPolicy.VtapIDs))
-						continue
-					}
-					vtapIDToNpbActions[vtapIDInt] = append(vtapIDToNpbActions[vtapIDInt], npbAction)
+				for _, vtapID := range vtapIDs {
+					vtapIDToNpbActions[vtapID] = append(vtapIDToNpbActions[vtapID], npbAction)
 				}
 			}
 		}
@@ -650,16 +649,15 @@ func (op *PolicyDataOP) generateProtoActions(acl *models.ACL) (map[int][]*triden
 				PayloadSlice:  proto.Uint32(uint32(payloadSlice)),
 				NpbAclGroupId: proto.Uint32(uint32(pcapPolicy.PolicyACLGroupID)),
 			}
-			if len(pcapPolicy.VtapIDs) == 0 {
+			vtapIDs, err := PolicyVTapIDs(pcapPolicy.VtapIDs)
+			if err != nil {
+				log.Errorf(op.Logf("err: %s, vtapIDs: %s", err, pcapPolicy.VtapIDs))
+			}
+			if vtapIDs == nil {
 				allVTapNpbActions = append(allVTapNpbActions, npbAction)
 			} else {
-				for _, vtapIDStr := range strings.Split(pcapPolicy.VtapIDs, ",") {
-					vtapIDInt, err := strconv.Atoi(vtapIDStr)
-					if err != nil {
-						log.Errorf(op.Logf("err: %s, vtapIDs: %s", err, pcapPolicy.VtapIDs))
-						continue
-					}
-					vtapIDToNpbActions[vtapIDInt] = append(vtapIDToNpbActions[vtapIDInt], npbAction)
+				for _, vtapID := range vtapIDs {
+					vtapIDToNpbActions[vtapID] = append(vtapIDToNpbActions[vtapID], npbAction)
 				}
 			}
 		}
@@ -668,6 +666,32 @@ func (op *PolicyDataOP) generateProtoActions(acl *models.ACL) (map[int][]*triden
 	return vtapIDToNpbActions, allVTapNpbActions
 }
 
+// ApplicationLicenseFunction is the license function which agents must have to receive the flow acls of the
+// application, see getPolicyString
+var ApplicationLicenseFunction = map[int]int{
+	APPLICATION_NPB:  VTAP_LICENSE_FUNCTION_TRAFFIC_DISTRIBUTION,
+	APPLICATION_PCAP: VTAP_LICENSE_FUNCTION_NETWORK_MONITORING,
+}
+
+// PolicyVTapIDs returns the agents which an npb or pcap policy is delivered to. nil means the policy is shared by
+// all agents, invalid ids are skipped and reported by err.
+func PolicyVTapIDs(vtapIDs string) ([]int, error) {
+	if len(vtapIDs) == 0 {
+		return nil, nil
+	}
+	var err error
+	ids := []int{}
+	for _, vtapIDStr := range strings.Split(vtapIDs, ",") {
+		vtapIDInt, e := strconv.Atoi(vtapIDStr)
+		if e != nil {
+			err = e
+			continue
+		}
+		ids = append(ids, vtapIDInt)
+	}
+	return ids, err
+}
+
 func (op *PolicyDataOP) generatePolicies() {
 	vtapIDToPolicy := make(map[int]*Policy)
 	allVTapSharePolicy := NewPolicy(0, op.billingMethod, op.metaData.ORGID)
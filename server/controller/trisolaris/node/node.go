@@ -788,6 +788,13 @@ func (n *NodeInfo) GetPlatformDataVersion() uint64 {
 	return n.getPlatformData().GetPlatformDataVersion()
 }
 
+func (n *NodeInfo) GetPlatformDataVersionTime() uint32 {
+	if n == nil {
+		return 0
+	}
+	return n.getPlatformData().GetVersionTime()
+}
+
 func (n *NodeInfo) getPlatformData() *metadata.PlatformData {
 	if n == nil {
 		return nil
@@ -145,6 +145,7 @@ func (e *TSDBEvent) AnalyzerSync(ctx context.Context, in *api.SyncRequest) (*api
 		PodIps:                  podIPs,
 		VtapIps:                 vTapIPs,
 		VersionPlatformData:     proto.Uint64(versionPlatformData),
+		VersionPlatformDataTime: proto.Uint32(nodeInfo.GetPlatformDataVersionTime()),
 		VersionGroups:           proto.Uint64(versionGroups),
 		VersionAcls:             proto.Uint64(versionPolicy),
 		DeepflowServerInstances: localServers,
@@ -216,6 +217,7 @@ func (e *TSDBEvent) pushResponse(in *api.SyncRequest) (*api.SyncResponse, error)
 		PodIps:                  podIPs,
 		VtapIps:                 vTapIPs,
 		VersionPlatformData:     proto.Uint64(versionPlatformData),
+		VersionPlatformDataTime: proto.Uint32(nodeInfo.GetPlatformDataVersionTime()),
 		VersionGroups:           proto.Uint64(versionGroups),
 		VersionAcls:             proto.Uint64(versionPolicy),
 		DeepflowServerInstances: localServers,
@@ -24,6 +24,7 @@ import (
 	"github.com/deepflowio/deepflow/server/ingester/app_log/dbwriter"
 	"github.com/deepflowio/deepflow/server/ingester/app_log/decoder"
 	dropletqueue "github.com/deepflowio/deepflow/server/ingester/droplet/queue"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/geo"
 	"github.com/deepflowio/deepflow/server/ingester/ingesterctl"
 	"github.com/deepflowio/deepflow/server/ingester/pkg/ckwriter"
 	"github.com/deepflowio/deepflow/server/libs/datatype"
@@ -53,6 +54,7 @@ func NewApplicationLogger(
 	platformDataManager *grpc.PlatformDataManager,
 ) (*ApplicationLogger, error) {
 	manager := dropletqueue.NewManager(ingesterctl.INGESTERCTL_APPLICATION_LOG_QUEUE)
+	geo.NewIPGeoTree(&config.Base.GeoIP)
 
 	ckwriter, err := dbwriter.NewAppLogCKWriter(config)
 	if err != nil {
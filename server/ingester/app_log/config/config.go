@@ -34,12 +34,16 @@ const (
 	DefaultTTL               = 720 // hour
 )
 
+// the first attribute which is an IP is used as the client IP to query the global geo info
+var DefaultClientIPAttributes = []string{"client.address", "client.ip", "client_ip", "remote_addr"}
+
 type Config struct {
-	Base              *config.Config
-	CKWriterConfig    config.CKWriterConfig `yaml:"application-log-ck-writer"`
-	DecoderQueueCount int                   `yaml:"application-log-decoder-queue-count"`
-	DecoderQueueSize  int                   `yaml:"application-log-decoder-queue-size"`
-	TTL               int                   `yaml:"application-log-ttl-hour"`
+	Base               *config.Config
+	CKWriterConfig     config.CKWriterConfig `yaml:"application-log-ck-writer"`
+	DecoderQueueCount  int                   `yaml:"application-log-decoder-queue-count"`
+	DecoderQueueSize   int                   `yaml:"application-log-decoder-queue-size"`
+	TTL                int                   `yaml:"application-log-ttl-hour"`
+	ClientIPAttributes []string              `yaml:"application-log-client-ip-attributes"`
 }
 
 type ApplicationLogConfig struct {
@@ -60,11 +64,12 @@ func (c *Config) Validate() error {
 func Load(base *config.Config, path string) *Config {
 	config := &ApplicationLogConfig{
 		ApplicationLog: Config{
-			Base:              base,
-			CKWriterConfig:    config.CKWriterConfig{QueueCount: 2, QueueSize: 25600, BatchSize: 12800, FlushTimeout: 5},
-			DecoderQueueCount: DefaultDecoderQueueCount,
-			DecoderQueueSize:  DefaultDecoderQueueSize,
-			TTL:               DefaultTTL,
+			Base:               base,
+			CKWriterConfig:     config.CKWriterConfig{QueueCount: 2, QueueSize: 25600, BatchSize: 12800, FlushTimeout: 5},
+			DecoderQueueCount:  DefaultDecoderQueueCount,
+			DecoderQueueSize:   DefaultDecoderQueueSize,
+			TTL:                DefaultTTL,
+			ClientIPAttributes: DefaultClientIPAttributes,
 		},
 	}
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -30,10 +30,12 @@ import (
 	"github.com/deepflowio/deepflow/server/ingester/app_log/config"
 	"github.com/deepflowio/deepflow/server/ingester/app_log/dbwriter"
 	ingestercommon "github.com/deepflowio/deepflow/server/ingester/common"
+	flowloggeo "github.com/deepflowio/deepflow/server/ingester/flow_log/geo"
 	"github.com/deepflowio/deepflow/server/libs/ckdb"
 	"github.com/deepflowio/deepflow/server/libs/codec"
 	"github.com/deepflowio/deepflow/server/libs/datatype"
 	flow_metrics "github.com/deepflowio/deepflow/server/libs/flow-metrics"
+	"github.com/deepflowio/deepflow/server/libs/geo"
 	"github.com/deepflowio/deepflow/server/libs/grpc"
 	"github.com/deepflowio/deepflow/server/libs/queue"
 	"github.com/deepflowio/deepflow/server/libs/receiver"
@@ -244,11 +246,11 @@ func (d *Decoder) WriteAgentLog(agentId uint16, bs []byte) error {
 				if ip4 := ip.To4(); ip4 != nil {
 					s.IsIPv4 = true
 					s.IP4 = utils.IpToUint32(ip4)
-					info = d.platformData.QueryIPV4Infos(s.OrgId, s.L3EpcID, s.IP4)
+					info = d.platformData.QueryIPV4Infos(s.OrgId, s.L3EpcID, s.IP4, s.Time)
 				} else {
 					s.IsIPv4 = false
 					s.IP6 = ip
-					info = d.platformData.QueryIPV6Infos(s.OrgId, s.L3EpcID, s.IP6)
+					info = d.platformData.QueryIPV6Infos(s.OrgId, s.L3EpcID, s.IP6, s.Time)
 				}
 			}
 		}
@@ -357,6 +359,8 @@ func (d *Decoder) WriteAppLog(agentId uint16, l *AppLogEntry) error {
 		}
 	}
 
+	d.fillClientGeo(s)
+
 	s.SeverityNumber = StringToSeverity(l.Level)
 	s.AppService = strings.Clone(l.AppService)
 
@@ -409,9 +413,9 @@ func (d *Decoder) WriteAppLog(agentId uint16, l *AppLogEntry) error {
 		info = d.platformData.QueryPodIdInfo(s.OrgId, s.PodID)
 	} else {
 		if s.IsIPv4 && ip != nil {
-			info = d.platformData.QueryIPV4Infos(s.OrgId, s.L3EpcID, s.IP4)
+			info = d.platformData.QueryIPV4Infos(s.OrgId, s.L3EpcID, s.IP4, s.Time)
 		} else {
-			info = d.platformData.QueryIPV6Infos(s.OrgId, s.L3EpcID, s.IP6)
+			info = d.platformData.QueryIPV6Infos(s.OrgId, s.L3EpcID, s.IP6, s.Time)
 		}
 	}
 
@@ -451,6 +455,40 @@ func (d *Decoder) WriteAppLog(agentId uint16, l *AppLogEntry) error {
 	return nil
 }
 
+// fillClientGeo appends the global geo and ASN info of the client IP in attributes as attributes
+func (d *Decoder) fillClientGeo(s *dbwriter.ApplicationLogStore) {
+	for _, name := range d.config.ClientIPAttributes {
+		for i, attributeName := range s.AttributeNames {
+			if attributeName != name {
+				continue
+			}
+			ip := net.ParseIP(strings.TrimSpace(s.AttributeValues[i]))
+			if ip == nil {
+				continue
+			}
+			var info geo.IPGeoInfo
+			if ip4 := ip.To4(); ip4 != nil {
+				info = flowloggeo.QueryIPGeo(false, utils.IpToUint32(ip4), nil)
+			} else {
+				info = flowloggeo.QueryIPGeo(true, 0, ip)
+			}
+			if info.Country != "" {
+				s.AttributeNames = append(s.AttributeNames, "client.geo.country_iso_code")
+				s.AttributeValues = append(s.AttributeValues, info.Country)
+			}
+			if info.City != "" {
+				s.AttributeNames = append(s.AttributeNames, "client.geo.city_name")
+				s.AttributeValues = append(s.AttributeValues, info.City)
+			}
+			if info.ASN != 0 {
+				s.AttributeNames = append(s.AttributeNames, "client.as.number", "client.as.organization.name")
+				s.AttributeValues = append(s.AttributeValues, strconv.FormatUint(uint64(info.ASN), 10), info.ASOrganization)
+			}
+			return
+		}
+	}
+}
+
 type AppLogEntry struct {
 	LogType    string `json:"_df_log_type"`
 	UserID     int    `json:"user_id"`
@@ -20,7 +20,7 @@ import (
 	"github.com/deepflowio/deepflow/server/libs/ckdb"
 )
 
-var AllColumnAdds = [][]*ColumnAdds{ColumnAdd64, ColumnAdd65, ColumnAdd66}
+var AllColumnAdds = [][]*ColumnAdds{ColumnAdd64, ColumnAdd65, ColumnAdd66, ColumnAdd67}
 var AllIndexAdds = [][]*IndexAdd{getIndexAdds(IndexAdd64), getIndexAdds(IndexAdd65)}
 var AllColumnMods = [][]*ColumnMod{}
 var AllColumnRenames = [][]*ColumnRename{getColumnRenames(ColumnRename65)}
@@ -376,3 +376,24 @@ var ColumnAdd66 = []*ColumnAdds{
 		ColumnType:  ckdb.UInt8,
 	},
 }
+
+var ColumnAdd67 = []*ColumnAdds{
+	{
+		Dbs:         []string{"flow_log"},
+		Tables:      []string{"l4_flow_log", "l4_flow_log_local", "l7_flow_log", "l7_flow_log_local"},
+		ColumnNames: []string{"country_0", "country_1", "city_0", "city_1", "as_org_0", "as_org_1"},
+		ColumnType:  ckdb.LowCardinalityString,
+	},
+	{
+		Dbs:         []string{"flow_log"},
+		Tables:      []string{"l4_flow_log", "l4_flow_log_local", "l7_flow_log", "l7_flow_log_local"},
+		ColumnNames: []string{"asn_0", "asn_1"},
+		ColumnType:  ckdb.UInt32,
+	},
+	{
+		Dbs:         []string{"prometheus"},
+		Tables:      []string{"samples", "samples_local"},
+		ColumnNames: []string{"millisecond"},
+		ColumnType:  ckdb.UInt16,
+	},
+}
@@ -58,6 +58,12 @@ const (
 	DefaultStatsInterval            = 10      // s
 	DefaultFlowTagCacheFlushTimeout = 1800    // s
 	DefaultFlowTagCacheMaxSize      = 1 << 18 // 256k
+	DefaultGeoIPReloadInterval      = 60      // s
+	DefaultPlatformDataMaxVersions  = 8
+	DefaultPlatformDataMaxAge       = 3600 // s
+	DefaultReceiverRecordDir        = "/var/log/deepflow/receiver-record"
+	DefaultReceiverRecordFileSize   = 256 // MB
+	DefaultReceiverRecordFileCount  = 8
 	IndexTypeHash                   = "hash"
 	IndexTypeIncremetalIdLocation   = "incremental-id"
 	FormatHex                       = "hex"
@@ -178,18 +184,42 @@ type Config struct {
 	CKDiskMonitor            CKDiskMonitor   `yaml:"ck-disk-monitor"`
 	ColdStorage              CKDBColdStorage `yaml:"ckdb-cold-storage"`
 	ckdbColdStorages         map[string]*ckdb.ColdStorage
-	NodeIP                   string `yaml:"node-ip"`
-	GrpcBufferSize           int    `yaml:"grpc-buffer-size"`
-	ServiceLabelerLruCap     int    `yaml:"service-labeler-lru-cap"`
-	StatsInterval            int    `yaml:"stats-interval"`
-	FlowTagCacheFlushTimeout uint32 `yaml:"flow-tag-cache-flush-timeout"`
-	FlowTagCacheMaxSize      uint32 `yaml:"flow-tag-cache-max-size"`
+	NodeIP                   string              `yaml:"node-ip"`
+	GrpcBufferSize           int                 `yaml:"grpc-buffer-size"`
+	ServiceLabelerLruCap     int                 `yaml:"service-labeler-lru-cap"`
+	StatsInterval            int                 `yaml:"stats-interval"`
+	FlowTagCacheFlushTimeout uint32              `yaml:"flow-tag-cache-flush-timeout"`
+	FlowTagCacheMaxSize      uint32              `yaml:"flow-tag-cache-max-size"`
+	GeoIP                    GeoIP               `yaml:"geoip"`
+	PlatformDataHistory      PlatformDataHistory `yaml:"platform-data-history"`
+	ReceiverRecord           ReceiverRecord      `yaml:"receiver-record"`
 	LogFile                  string
 	LogLevel                 string
 	MyNodeName               string
 	TraceIdWithIndex         TraceIdWithIndex
 }
 
+// GeoIP configures the global GeoIP and ASN databases used by flow logs and application logs
+type GeoIP struct {
+	Databases      []string `yaml:"databases"`       // MaxMind DB files, such as GeoLite2-City.mmdb and GeoLite2-ASN.mmdb
+	ReloadInterval int      `yaml:"reload-interval"` // s
+}
+
+// PlatformDataHistory retains the retired platform data versions, so that delayed or replayed data
+// is enriched by the pods, VMs and services valid at its timestamp
+type PlatformDataHistory struct {
+	MaxVersions int `yaml:"max-versions"` // 0 means disabled
+	MaxAge      int `yaml:"max-age"`      // s, the versions retired longer than it are dropped, 0 means no limit
+}
+
+// ReceiverRecord configures the files of recording the raw messages received from agents,
+// the recording is started and stopped by 'deepflow-ctl ingester record'
+type ReceiverRecord struct {
+	Dir       string `yaml:"dir"`
+	FileSize  int    `yaml:"file-size"` // MB
+	FileCount int    `yaml:"file-count"`
+}
+
 type Location struct {
 	Start  int    `yaml:"start"`
 	Length int    `yaml:"length"`
@@ -263,6 +293,18 @@ func (c *Config) Validate() error {
 	if c.FlowTagCacheFlushTimeout == 0 {
 		c.FlowTagCacheFlushTimeout = DefaultFlowTagCacheFlushTimeout
 	}
+	if c.GeoIP.ReloadInterval <= 0 {
+		c.GeoIP.ReloadInterval = DefaultGeoIPReloadInterval
+	}
+	if c.ReceiverRecord.Dir == "" {
+		c.ReceiverRecord.Dir = DefaultReceiverRecordDir
+	}
+	if c.ReceiverRecord.FileSize <= 0 {
+		c.ReceiverRecord.FileSize = DefaultReceiverRecordFileSize
+	}
+	if c.ReceiverRecord.FileCount <= 0 {
+		c.ReceiverRecord.FileCount = DefaultReceiverRecordFileCount
+	}
 
 	level := strings.ToLower(c.LogLevel)
 	c.LogLevel = "info"
@@ -284,6 +326,13 @@ func (c *Config) Validate() error {
 		c.StatsInterval = DefaultStatsInterval
 	}
 
+	if c.PlatformDataHistory.MaxVersions < 0 {
+		c.PlatformDataHistory.MaxVersions = 0
+	}
+	if c.PlatformDataHistory.MaxAge < 0 {
+		c.PlatformDataHistory.MaxAge = 0
+	}
+
 	var myNodeName, myPodName, myNamespace string
 	// in standalone mode, no 'EnvK8sNodeName', 'EnvK8sPodName', 'EnvK8sNamespace' environment variables
 	if c.IsRunningModeStandalone {
@@ -533,6 +582,9 @@ func Load(path string) *Config {
 			StatsInterval:            DefaultStatsInterval,
 			FlowTagCacheFlushTimeout: DefaultFlowTagCacheFlushTimeout,
 			FlowTagCacheMaxSize:      DefaultFlowTagCacheMaxSize,
+			GeoIP:                    GeoIP{ReloadInterval: DefaultGeoIPReloadInterval},
+			PlatformDataHistory:      PlatformDataHistory{MaxVersions: DefaultPlatformDataMaxVersions, MaxAge: DefaultPlatformDataMaxAge},
+			ReceiverRecord:           ReceiverRecord{Dir: DefaultReceiverRecordDir, FileSize: DefaultReceiverRecordFileSize, FileCount: DefaultReceiverRecordFileCount},
 		},
 	}
 	if err != nil {
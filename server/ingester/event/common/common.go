@@ -30,6 +30,7 @@ const (
 	PERF_EVENT
 	ALERT_EVENT
 	K8S_EVENT
+	SLOW_QUERY_EVENT
 )
 
 func (e EventType) String() string {
@@ -42,6 +43,8 @@ func (e EventType) String() string {
 		return "alert_event"
 	case K8S_EVENT:
 		return "k8s_event"
+	case SLOW_QUERY_EVENT:
+		return "slow_query_event"
 	default:
 		return "unknown_event"
 	}
@@ -56,6 +59,8 @@ func (e EventType) TableName() string {
 		return "perf_event"
 	case ALERT_EVENT:
 		return "alert_event"
+	case SLOW_QUERY_EVENT:
+		return "slow_query_event"
 	default:
 		return "unknown_event"
 	}
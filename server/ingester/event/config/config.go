@@ -36,6 +36,7 @@ const (
 	DefaultEventTTL              = 720 // hour
 	DefaultPerfEventTTL          = 168 // hour
 	DefaultAlertEventTTL         = 720 // hour
+	DefaultSlowQueryEventTTL     = 168 // hour
 )
 
 type Config struct {
@@ -49,6 +50,7 @@ type Config struct {
 	PerfDecoderQueueSize  int                   `yaml:"perf-event-decoder-queue-size"`
 	PerfEventTTL          int                   `yaml:"perf-event-ttl"`
 	AlertEventTTL         int                   `yaml:"alert-event-ttl"`
+	SlowQueryEventTTL     int                   `yaml:"slow-query-event-ttl"`
 	K8sCKWriterConfig     config.CKWriterConfig `yaml:"k8s-event-ck-writer"`
 	K8sDecoderQueueCount  int                   `yaml:"k8s-event-decoder-queue-count"`
 	K8sDecoderQueueSize   int                   `yaml:"k8s-event-decoder-queue-size"`
@@ -80,6 +82,9 @@ func (c *Config) Validate() error {
 	if c.AlertEventTTL <= 0 {
 		c.AlertEventTTL = DefaultAlertEventTTL
 	}
+	if c.SlowQueryEventTTL <= 0 {
+		c.SlowQueryEventTTL = DefaultSlowQueryEventTTL
+	}
 	if c.K8sDecoderQueueCount == 0 {
 		c.K8sDecoderQueueCount = DefaultDecoderQueueCount
 	}
@@ -104,6 +109,7 @@ func Load(base *config.Config, path string) *Config {
 			PerfDecoderQueueSize:  DefaultPerfDecoderQueueSize,
 			PerfEventTTL:          DefaultPerfEventTTL,
 			AlertEventTTL:         DefaultAlertEventTTL,
+			SlowQueryEventTTL:     DefaultSlowQueryEventTTL,
 			K8sCKWriterConfig:     config.CKWriterConfig{QueueCount: 1, QueueSize: 50000, BatchSize: 25600, FlushTimeout: 5},
 			K8sDecoderQueueCount:  DefaultDecoderQueueCount,
 			K8sDecoderQueueSize:   DefaultDecoderQueueSize,
@@ -73,6 +73,10 @@ func (w *EventWriter) WriteAlertEvent(e *AlertEventStore) {
 	w.ckWriter.Put(e)
 }
 
+func (w *EventWriter) WriteSlowQueryEvent(e *SlowQueryEventStore) {
+	w.ckWriter.Put(e)
+}
+
 func NewEventWriter(eventType common.EventType, decoderIndex int, config *config.Config) (*EventWriter, error) {
 	w := &EventWriter{
 		ckdbAddrs:         config.Base.CKDB.ActualAddrs,
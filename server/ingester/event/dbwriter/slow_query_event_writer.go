@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dbwriter
+
+import (
+	"sync/atomic"
+
+	basecommon "github.com/deepflowio/deepflow/server/ingester/common"
+	"github.com/deepflowio/deepflow/server/ingester/event/common"
+	"github.com/deepflowio/deepflow/server/ingester/event/config"
+	"github.com/deepflowio/deepflow/server/ingester/pkg/ckwriter"
+	"github.com/deepflowio/deepflow/server/libs/ckdb"
+	"github.com/deepflowio/deepflow/server/libs/pool"
+)
+
+var slowQueryEventPool = pool.NewLockFreePool(func() interface{} {
+	return &SlowQueryEventStore{}
+})
+
+func AcquireSlowQueryEventStore() *SlowQueryEventStore {
+	return slowQueryEventPool.Get().(*SlowQueryEventStore)
+}
+
+func ReleaseSlowQueryEventStore(e *SlowQueryEventStore) {
+	if e == nil {
+		return
+	}
+	*e = SlowQueryEventStore{}
+	slowQueryEventPool.Put(e)
+}
+
+type SlowQueryEventStore struct {
+	Time uint32
+	_id  uint64
+
+	StartTime     int64
+	EndTime       int64
+	Duration      uint64
+	QueryUUID     string
+	DB            string
+	Sql           string
+	TranslatedSql string
+	ReadRows      uint64
+	ReadBytes     uint64
+	ResultRows    uint64
+	Error         string
+
+	UserId uint32
+	OrgId  uint16
+}
+
+func (e *SlowQueryEventStore) SetId(time, analyzerID uint32) {
+	count := atomic.AddUint32(&EventCounter, 1)
+	// The high 32 bits of time, 23-32 bits represent analyzerId, the low 22 bits are counter
+	e._id = uint64(time)<<32 | uint64(analyzerID&0x3ff)<<22 | (uint64(count) & 0x3fffff)
+}
+
+func SlowQueryEventColumns() []*ckdb.Column {
+	return []*ckdb.Column{
+		ckdb.NewColumn("time", ckdb.DateTime),
+		ckdb.NewColumn("_id", ckdb.UInt64),
+		ckdb.NewColumn("start_time", ckdb.DateTime64us).SetComment("精度: 微秒"),
+		ckdb.NewColumn("end_time", ckdb.DateTime64us).SetComment("精度: 微秒"),
+		ckdb.NewColumn("duration", ckdb.UInt64).SetComment("查询耗时, 单位: 微秒"),
+
+		ckdb.NewColumn("query_uuid", ckdb.String),
+		ckdb.NewColumn("db", ckdb.LowCardinalityString),
+		ckdb.NewColumn("sql", ckdb.String).SetComment("DeepFlow SQL"),
+		ckdb.NewColumn("translated_sql", ckdb.String).SetComment("ClickHouse SQL"),
+		ckdb.NewColumn("read_rows", ckdb.UInt64),
+		ckdb.NewColumn("read_bytes", ckdb.UInt64),
+		ckdb.NewColumn("result_rows", ckdb.UInt64),
+		ckdb.NewColumn("error", ckdb.String),
+
+		ckdb.NewColumn("user_id", ckdb.UInt32),
+	}
+}
+
+func (e *SlowQueryEventStore) WriteBlock(block *ckdb.Block) {
+	block.WriteDateTime(e.Time)
+	block.Write(
+		e._id,
+		e.StartTime,
+		e.EndTime,
+		e.Duration,
+
+		e.QueryUUID,
+		e.DB,
+		e.Sql,
+		e.TranslatedSql,
+		e.ReadRows,
+		e.ReadBytes,
+		e.ResultRows,
+		e.Error,
+
+		e.UserId,
+	)
+}
+
+func (e *SlowQueryEventStore) Release() {
+	ReleaseSlowQueryEventStore(e)
+}
+
+func (e *SlowQueryEventStore) OrgID() uint16 {
+	return e.OrgId
+}
+
+func GenSlowQueryEventCKTable(cluster, storagePolicy, ckdbType string, ttl int, coldStorage *ckdb.ColdStorage) *ckdb.Table {
+	table := common.SLOW_QUERY_EVENT.TableName()
+	timeKey := "time"
+	engine := ckdb.MergeTree
+	orderKeys := []string{"time", "db"}
+
+	return &ckdb.Table{
+		Version:         basecommon.CK_VERSION,
+		Database:        EVENT_DB,
+		DBType:          ckdbType,
+		LocalName:       table + ckdb.LOCAL_SUBFFIX,
+		GlobalName:      table,
+		Columns:         SlowQueryEventColumns(),
+		TimeKey:         timeKey,
+		TTL:             ttl,
+		PartitionFunc:   DefaultPartition,
+		Engine:          engine,
+		Cluster:         cluster,
+		StoragePolicy:   storagePolicy,
+		ColdStorage:     *coldStorage,
+		OrderKeys:       orderKeys,
+		PrimaryKeyCount: len(orderKeys),
+	}
+}
+
+func NewSlowQueryEventWriter(config *config.Config) (*EventWriter, error) {
+	w := &EventWriter{
+		ckdbAddrs:         config.Base.CKDB.ActualAddrs,
+		ckdbUsername:      config.Base.CKDBAuth.Username,
+		ckdbPassword:      config.Base.CKDBAuth.Password,
+		ckdbCluster:       config.Base.CKDB.ClusterName,
+		ckdbStoragePolicy: config.Base.CKDB.StoragePolicy,
+		ckdbColdStorages:  config.Base.GetCKDBColdStorages(),
+		ttl:               config.SlowQueryEventTTL,
+		writerConfig:      config.CKWriterConfig,
+	}
+
+	ckTable := GenSlowQueryEventCKTable(w.ckdbCluster, w.ckdbStoragePolicy, config.Base.CKDB.Type, w.ttl, ckdb.GetColdStorage(w.ckdbColdStorages, EVENT_DB, common.SLOW_QUERY_EVENT.TableName()))
+
+	ckwriter, err := ckwriter.NewCKWriter(*w.ckdbAddrs, w.ckdbUsername, w.ckdbPassword,
+		common.SLOW_QUERY_EVENT.TableName(), config.Base.CKDB.TimeZone, ckTable, w.writerConfig.QueueCount, w.writerConfig.QueueSize, w.writerConfig.BatchSize, w.writerConfig.FlushTimeout, config.Base.CKDB.Watcher)
+	if err != nil {
+		return nil, err
+	}
+	w.ckWriter = ckwriter
+	w.ckWriter.Run()
+	return w, nil
+}
@@ -158,6 +158,14 @@ func (d *Decoder) Run() {
 				d.orgId, d.teamId = uint16(recvBytes.OrgID), uint16(recvBytes.TeamID)
 				d.handleK8sEvent(recvBytes.VtapID, decoder)
 				receiver.ReleaseRecvBuffer(recvBytes)
+			case common.SLOW_QUERY_EVENT:
+				event, ok := buffer[i].(*eventapi.SlowQueryEvent)
+				if !ok {
+					log.Warning("get slow query event decode queue data type wrong")
+					continue
+				}
+				d.writeSlowQueryEvent(event)
+				event.Release()
 			}
 		}
 	}
@@ -207,10 +215,10 @@ func (d *Decoder) WritePerfEvent(vtapId uint16, e *pb.ProcEvent) {
 				if ip4 := vtapIP.To4(); ip4 != nil {
 					s.IsIPv4 = true
 					s.IP4 = utils.IpToUint32(ip4)
-					info = d.platformData.QueryIPV4Infos(s.OrgId, vtapInfo.EpcId, s.IP4)
+					info = d.platformData.QueryIPV4Infos(s.OrgId, vtapInfo.EpcId, s.IP4, s.Time)
 				} else {
 					s.IP6 = vtapIP
-					info = d.platformData.QueryIPV6Infos(s.OrgId, vtapInfo.EpcId, s.IP6)
+					info = d.platformData.QueryIPV6Infos(s.OrgId, vtapInfo.EpcId, s.IP6, s.Time)
 				}
 			}
 		}
@@ -465,3 +473,27 @@ func (d *Decoder) writeAlertEvent(event *alert_event.AlertEvent) {
 
 	d.eventWriter.WriteAlertEvent(s)
 }
+
+func (d *Decoder) writeSlowQueryEvent(event *eventapi.SlowQueryEvent) {
+	s := dbwriter.AcquireSlowQueryEventStore()
+	s.Time = event.Time
+	s.SetId(s.Time, d.platformData.QueryAnalyzerID())
+
+	s.StartTime = event.StartTime
+	s.EndTime = event.StartTime + int64(event.Duration)
+	s.Duration = event.Duration
+	s.QueryUUID = event.QueryUUID
+	s.DB = event.DB
+	s.Sql = event.Sql
+	s.TranslatedSql = event.TranslatedSql
+	s.ReadRows = event.ReadRows
+	s.ReadBytes = event.ReadBytes
+	s.ResultRows = event.ResultRows
+	s.Error = event.Error
+
+	s.OrgId = event.ORGID
+	s.UserId = event.UserID
+
+	d.counter.OutCount++
+	d.eventWriter.WriteSlowQueryEvent(s)
+}
@@ -39,11 +39,12 @@ import (
 )
 
 type Event struct {
-	Config          *config.Config
-	ResourceEventor *Eventor
-	PerfEventor     *Eventor
-	AlertEventor    *Eventor
-	K8sEventor      *Eventor
+	Config           *config.Config
+	ResourceEventor  *Eventor
+	PerfEventor      *Eventor
+	AlertEventor     *Eventor
+	K8sEventor       *Eventor
+	SlowQueryEventor *Eventor
 }
 
 type Eventor struct {
@@ -52,7 +53,7 @@ type Eventor struct {
 	PlatformDatas []*grpc.PlatformInfoTable
 }
 
-func NewEvent(config *config.Config, resourceEventQueue *queue.OverwriteQueue, recv *receiver.Receiver, platformDataManager *grpc.PlatformDataManager, exporters *exporters.Exporters) (*Event, error) {
+func NewEvent(config *config.Config, resourceEventQueue, slowQueryEventQueue *queue.OverwriteQueue, recv *receiver.Receiver, platformDataManager *grpc.PlatformDataManager, exporters *exporters.Exporters) (*Event, error) {
 	manager := dropletqueue.NewManager(ingesterctl.INGESTERCTL_EVENT_QUEUE)
 	resourceEventor, err := NewResouceEventor(resourceEventQueue, config, platformDataManager.GetMasterPlatformInfoTable())
 	if err != nil {
@@ -74,12 +75,18 @@ func NewEvent(config *config.Config, resourceEventQueue *queue.OverwriteQueue, r
 		return nil, err
 	}
 
+	slowQueryEventor, err := NewSlowQueryEventor(slowQueryEventQueue, config, platformDataManager.GetMasterPlatformInfoTable())
+	if err != nil {
+		return nil, err
+	}
+
 	return &Event{
-		Config:          config,
-		ResourceEventor: resourceEventor,
-		PerfEventor:     perfEventor,
-		AlertEventor:    alertEventor,
-		K8sEventor:      k8sEventor,
+		Config:           config,
+		ResourceEventor:  resourceEventor,
+		PerfEventor:      perfEventor,
+		AlertEventor:     alertEventor,
+		K8sEventor:       k8sEventor,
+		SlowQueryEventor: slowQueryEventor,
 	}, nil
 }
 
@@ -103,6 +110,26 @@ func NewResouceEventor(eventQueue *queue.OverwriteQueue, config *config.Config,
 	}, nil
 }
 
+func NewSlowQueryEventor(eventQueue *queue.OverwriteQueue, config *config.Config, platformTable *grpc.PlatformInfoTable) (*Eventor, error) {
+	eventWriter, err := dbwriter.NewSlowQueryEventWriter(config)
+	if err != nil {
+		return nil, err
+	}
+	d := decoder.NewDecoder(
+		0,
+		common.SLOW_QUERY_EVENT,
+		queue.QueueReader(eventQueue),
+		eventWriter,
+		platformTable,
+		nil,
+		config,
+	)
+	return &Eventor{
+		Config:   config,
+		Decoders: []*decoder.Decoder{d},
+	}, nil
+}
+
 func NewAlertEventor(config *config.Config, recv *receiver.Receiver, manager *dropletqueue.Manager, platformTable *grpc.PlatformInfoTable) (*Eventor, error) {
 	eventMsg := datatype.MESSAGE_TYPE_ALERT_EVENT
 	decodeQueues := manager.NewQueues(
@@ -210,6 +237,7 @@ func (e *Event) Start() {
 	e.PerfEventor.Start()
 	e.AlertEventor.Start()
 	e.K8sEventor.Start()
+	e.SlowQueryEventor.Start()
 }
 
 func (e *Event) Close() error {
@@ -217,5 +245,6 @@ func (e *Event) Close() error {
 	e.PerfEventor.Close()
 	e.AlertEventor.Close()
 	e.K8sEventor.Close()
+	e.SlowQueryEventor.Close()
 	return nil
 }
@@ -36,6 +36,10 @@ const (
 	DefaultDecoderQueueSize  = 4096
 	DefaultBrokerQueueSize   = 1 << 14
 	DefaultFlowLogTTL        = 72 // hour
+	DefaultL7ProcessorReload = 60 // s
+
+	DefaultTraceAnalysisDelay       = 60 // s
+	DefaultTraceAnalysisMaxPendings = 100000
 )
 
 type FlowLogTTL struct {
@@ -44,6 +48,41 @@ type FlowLogTTL struct {
 	L4Packet  int `yaml:"l4-packet"`
 }
 
+// L7SamplingConfig replaces the reservoir sampling of l7_flow_log. Logs of the same trace are kept or dropped
+// together by the hash of trace_id, logs with error status or slow responses are always kept. Budgets are the
+// maximum number of logs per second.
+type L7SamplingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// budget of all logs sampled by the budgets below, 0 means using l7-throttle or throttle
+	GlobalBudget int `yaml:"global-budget"`
+	// budget of each app_service without service or protocol budget, 0 means using l7-throttle or throttle
+	DefaultBudget int `yaml:"default-budget"`
+	// key is app_service
+	ServiceBudgets map[string]int `yaml:"service-budgets"`
+	// key is l7_protocol_str, e.g.: HTTP, MySQL, gRPC
+	ProtocolBudgets map[string]int `yaml:"protocol-budgets"`
+	// unit: ms, 0 means disabled
+	SlowThreshold int `yaml:"slow-threshold"`
+	// key is app_service, unit: ms
+	ServiceSlowThresholds map[string]int `yaml:"service-slow-thresholds"`
+}
+
+// L7ProcessorConfig configures the redaction and attribute extraction rules of l7_flow_log
+type L7ProcessorConfig struct {
+	RulesFile      string `yaml:"rules-file"`      // yaml file of rules, empty means disabled
+	ReloadInterval int    `yaml:"reload-interval"` // s, the rules file is reloaded if it is modified
+}
+
+// TraceAnalysisConfig configures the analytics of completed traces, which is computed from the spans written to
+// span_with_trace_id, so it works only if flow-log-trace-tree-enabled is true.
+type TraceAnalysisConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// s, a trace is analyzed if no span of it is received for the delay, it should be greater than the flush-timeout of flowlog-ck-writer
+	Delay int `yaml:"delay"`
+	// traces waiting for the delay, spans of new traces are not analyzed if exceeded
+	MaxPendingTraces int `yaml:"max-pending-traces"`
+}
+
 type Config struct {
 	Base              *config.Config
 	CKWriterConfig    config.CKWriterConfig `yaml:"flowlog-ck-writer"`
@@ -51,10 +90,13 @@ type Config struct {
 	ThrottleBucket    int                   `yaml:"throttle-bucket"`
 	L4Throttle        int                   `yaml:"l4-throttle"`
 	L7Throttle        int                   `yaml:"l7-throttle"`
+	L7Sampling        L7SamplingConfig      `yaml:"l7-sampling"`
+	L7Processor       L7ProcessorConfig     `yaml:"l7-processor"`
 	FlowLogTTL        FlowLogTTL            `yaml:"flow-log-ttl-hour"`
 	DecoderQueueCount int                   `yaml:"flow-log-decoder-queue-count"`
 	DecoderQueueSize  int                   `yaml:"flow-log-decoder-queue-size"`
 	TraceTreeEnabled  *bool                 `yaml:"flow-log-trace-tree-enabled"`
+	TraceAnalysis     TraceAnalysisConfig   `yaml:"flow-log-trace-analysis"`
 }
 
 type FlowLogConfig struct {
@@ -79,6 +121,27 @@ func (c *Config) Validate() error {
 		c.FlowLogTTL.L4Packet = DefaultFlowLogTTL
 	}
 
+	if c.L7Sampling.GlobalBudget <= 0 {
+		c.L7Sampling.GlobalBudget = c.Throttle
+		if c.L7Throttle != 0 {
+			c.L7Sampling.GlobalBudget = c.L7Throttle
+		}
+	}
+	if c.L7Sampling.DefaultBudget <= 0 {
+		c.L7Sampling.DefaultBudget = c.L7Sampling.GlobalBudget
+	}
+
+	if c.L7Processor.ReloadInterval <= 0 {
+		c.L7Processor.ReloadInterval = DefaultL7ProcessorReload
+	}
+
+	if c.TraceAnalysis.Delay <= 0 {
+		c.TraceAnalysis.Delay = DefaultTraceAnalysisDelay
+	}
+	if c.TraceAnalysis.MaxPendingTraces <= 0 {
+		c.TraceAnalysis.MaxPendingTraces = DefaultTraceAnalysisMaxPendings
+	}
+
 	if c.TraceTreeEnabled == nil {
 		value := configdefaults.FLOG_LOG_TRACE_TREE_ENABLED_DEFAULT
 		c.TraceTreeEnabled = &value
@@ -97,6 +160,8 @@ func Load(base *config.Config, path string) *Config {
 			DecoderQueueSize:  DefaultDecoderQueueSize,
 			CKWriterConfig:    config.CKWriterConfig{QueueCount: 1, QueueSize: 256000, BatchSize: 128000, FlushTimeout: 10},
 			FlowLogTTL:        FlowLogTTL{DefaultFlowLogTTL, DefaultFlowLogTTL, DefaultFlowLogTTL},
+			L7Processor:       L7ProcessorConfig{ReloadInterval: DefaultL7ProcessorReload},
+			TraceAnalysis:     TraceAnalysisConfig{Delay: DefaultTraceAnalysisDelay, MaxPendingTraces: DefaultTraceAnalysisMaxPendings},
 		},
 	}
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -142,7 +142,8 @@ type SpanWriter struct {
 	ttl               int
 	writerConfig      baseconfig.CKWriterConfig
 
-	traceWriter *ckwriter.CKWriter
+	traceWriter itemWriter
+	analyzer    *TraceAnalyzer
 }
 
 func NewSpanWriter(config *config.Config) (*SpanWriter, error) {
@@ -169,18 +170,33 @@ func NewSpanWriter(config *config.Config) (*SpanWriter, error) {
 	}
 	w.traceWriter = ckwriter
 
+	w.analyzer, err = NewTraceAnalyzer(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return w, nil
 }
 
 func (s *SpanWriter) Put(items []interface{}) {
+	// the spans are released after written, the analyzer must be put first
+	if s.analyzer != nil {
+		s.analyzer.Put(items)
+	}
 	s.traceWriter.Put(items...)
 }
 
 func (s *SpanWriter) Start() {
 	log.Info("flow log span writer starting")
 	s.traceWriter.Run()
+	if s.analyzer != nil {
+		s.analyzer.Start()
+	}
 }
 
 func (s *SpanWriter) Close() {
 	s.traceWriter.Close()
+	if s.analyzer != nil {
+		s.analyzer.Close()
+	}
 }
@@ -0,0 +1,386 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dbwriter
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	basecommon "github.com/deepflowio/deepflow/server/ingester/common"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/common"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/config"
+	"github.com/deepflowio/deepflow/server/ingester/pkg/ckwriter"
+	"github.com/deepflowio/deepflow/server/libs/ckdb"
+	"github.com/deepflowio/deepflow/server/libs/codec"
+	"github.com/deepflowio/deepflow/server/libs/tracetree"
+	"github.com/deepflowio/deepflow/server/libs/utils"
+)
+
+const (
+	TRACE_ANALYSIS_TABLE = "trace_analysis"
+
+	TRACE_ANALYSIS_BATCH_SIZE = 256
+	// spans of a trace are searched within the range around the time of the spans received by this ingester
+	TRACE_ANALYSIS_SPAN_TIME_RANGE = 300 // s
+	TRACE_ANALYSIS_CHECK_INTERVAL  = time.Second
+)
+
+// GenTraceAnalysisCKTable uses ReplacingMergeTree ordered by trace_id, because a trace is analyzed by every ingester
+// receiving its spans, and is analyzed again if its spans arrive after it is analyzed. The row with the latest time,
+// which is the end time of the last span, is kept.
+func GenTraceAnalysisCKTable(cluster, storagePolicy, ckdbType string, ttl int, coldStorage *ckdb.ColdStorage) *ckdb.Table {
+	table := TRACE_ANALYSIS_TABLE
+	timeKey := "time"
+	engine := ckdb.ReplacingMergeTree
+	orderKeys := []string{"trace_id", "app_service", "auto_service_type", "auto_service_id"}
+
+	return &ckdb.Table{
+		Version:         basecommon.CK_VERSION,
+		Database:        common.FLOW_LOG_DB,
+		DBType:          ckdbType,
+		LocalName:       table + ckdb.LOCAL_SUBFFIX,
+		GlobalName:      table,
+		Columns:         tracetree.TraceAnalysisColumns(),
+		TimeKey:         timeKey,
+		TTL:             ttl,
+		PartitionFunc:   DefaultPartition,
+		Engine:          engine,
+		Cluster:         cluster,
+		StoragePolicy:   storagePolicy,
+		ColdStorage:     *coldStorage,
+		OrderKeys:       orderKeys,
+		PrimaryKeyCount: 1,
+	}
+}
+
+type TraceAnalyzerCounter struct {
+	InSpans         int64 `statsd:"in-spans"`
+	PendingTraces   int64 `statsd:"pending-traces"`
+	DroppedTraces   int64 `statsd:"dropped-traces"` // traces dropped when there are too many pending traces
+	QueryErrors     int64 `statsd:"query-errors"`
+	AnalyzedTraces  int64 `statsd:"analyzed-traces"`
+	WrittenServices int64 `statsd:"written-services"`
+}
+
+type traceKey struct {
+	orgId   uint16
+	traceId string
+}
+
+type pendingTrace struct {
+	minTime  uint32
+	maxTime  uint32
+	lastSeen int64 // s, the time when the last span is received
+}
+
+// spanReader reads the spans of traces, the returned spans should be released by the caller
+type spanReader interface {
+	querySpans(orgId uint16, traceIds []string, minTime, maxTime uint32) (map[string][]*tracetree.SpanTrace, error)
+	close()
+}
+
+// itemWriter is implemented by ckwriter.CKWriter
+type itemWriter interface {
+	Put(items ...interface{})
+	Run()
+	Close()
+}
+
+// TraceAnalyzer computes the analytics of the traces whose spans are written by this ingester. A trace is analyzed
+// when no span of it is received for a delay, its spans are read back from flow_log.span_with_trace_id so that the
+// spans received by other ingesters are included. The analytics are written to flow_log.trace_analysis
+type TraceAnalyzer struct {
+	delay     int64
+	maxTraces int
+
+	lock   sync.Mutex
+	traces map[traceKey]*pendingTrace
+
+	reader  spanReader
+	writer  itemWriter
+	counter *TraceAnalyzerCounter
+	closed  chan struct{}
+	utils.Closable
+}
+
+func newTraceAnalyzer(cfg *config.TraceAnalysisConfig, reader spanReader, writer itemWriter) *TraceAnalyzer {
+	return &TraceAnalyzer{
+		delay:     int64(cfg.Delay),
+		maxTraces: cfg.MaxPendingTraces,
+		traces:    make(map[traceKey]*pendingTrace),
+		reader:    reader,
+		writer:    writer,
+		counter:   &TraceAnalyzerCounter{},
+		closed:    make(chan struct{}),
+	}
+}
+
+func NewTraceAnalyzer(config *config.Config) (*TraceAnalyzer, error) {
+	if !config.TraceAnalysis.Enabled {
+		return nil, nil
+	}
+	writerConfig := config.CKWriterConfig
+	ckTable := GenTraceAnalysisCKTable(config.Base.CKDB.ClusterName, config.Base.CKDB.StoragePolicy, config.Base.CKDB.Type, config.FlowLogTTL.L7FlowLog,
+		ckdb.GetColdStorage(config.Base.GetCKDBColdStorages(), common.FLOW_LOG_DB, TRACE_ANALYSIS_TABLE))
+	ckwriter, err := ckwriter.NewCKWriter(*config.Base.CKDB.ActualAddrs, config.Base.CKDBAuth.Username, config.Base.CKDBAuth.Password,
+		TRACE_ANALYSIS_TABLE, config.Base.CKDB.TimeZone, ckTable, writerConfig.QueueCount, writerConfig.QueueSize, writerConfig.BatchSize, writerConfig.FlushTimeout, config.Base.CKDB.Watcher)
+	if err != nil {
+		return nil, err
+	}
+	reader := &ckSpanReader{
+		ckdbAddrs:    config.Base.CKDB.ActualAddrs,
+		ckdbUsername: config.Base.CKDBAuth.Username,
+		ckdbPassword: config.Base.CKDBAuth.Password,
+	}
+	a := newTraceAnalyzer(&config.TraceAnalysis, reader, ckwriter)
+	basecommon.RegisterCountableForIngester("trace_analyzer", a)
+	return a, nil
+}
+
+func (a *TraceAnalyzer) GetCounter() interface{} {
+	counter := &TraceAnalyzerCounter{}
+	counter.InSpans = atomic.SwapInt64(&a.counter.InSpans, 0)
+	a.lock.Lock()
+	counter.PendingTraces = int64(len(a.traces))
+	a.lock.Unlock()
+	counter.DroppedTraces = atomic.SwapInt64(&a.counter.DroppedTraces, 0)
+	counter.QueryErrors = atomic.SwapInt64(&a.counter.QueryErrors, 0)
+	counter.AnalyzedTraces = atomic.SwapInt64(&a.counter.AnalyzedTraces, 0)
+	counter.WrittenServices = atomic.SwapInt64(&a.counter.WrittenServices, 0)
+	return counter
+}
+
+// Put records the traces of the spans, the spans are not buffered
+func (a *TraceAnalyzer) Put(spans []interface{}) {
+	now := time.Now().Unix()
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, item := range spans {
+		span, ok := item.(*SpanWithTraceID)
+		if !ok || span.TraceId == "" {
+			continue
+		}
+		atomic.AddInt64(&a.counter.InSpans, 1)
+		key := traceKey{orgId: span.OrgId, traceId: span.TraceId}
+		trace, ok := a.traces[key]
+		if !ok {
+			if len(a.traces) >= a.maxTraces {
+				atomic.AddInt64(&a.counter.DroppedTraces, 1)
+				continue
+			}
+			trace = &pendingTrace{minTime: span.Time, maxTime: span.Time}
+			a.traces[key] = trace
+		}
+		if span.Time < trace.minTime {
+			trace.minTime = span.Time
+		}
+		if span.Time > trace.maxTime {
+			trace.maxTime = span.Time
+		}
+		trace.lastSeen = now
+	}
+}
+
+// flush analyzes the traces which have no span received since the delay before now
+func (a *TraceAnalyzer) flush(now int64) {
+	orgTraces := make(map[uint16]map[string]*pendingTrace)
+	a.lock.Lock()
+	for key, trace := range a.traces {
+		if trace.lastSeen+a.delay > now {
+			continue
+		}
+		delete(a.traces, key)
+		if orgTraces[key.orgId] == nil {
+			orgTraces[key.orgId] = make(map[string]*pendingTrace)
+		}
+		orgTraces[key.orgId][key.traceId] = trace
+	}
+	a.lock.Unlock()
+
+	for orgId, traces := range orgTraces {
+		traceIds := make([]string, 0, TRACE_ANALYSIS_BATCH_SIZE)
+		var minTime, maxTime uint32
+		for traceId, trace := range traces {
+			if len(traceIds) == 0 || trace.minTime < minTime {
+				minTime = trace.minTime
+			}
+			if trace.maxTime > maxTime {
+				maxTime = trace.maxTime
+			}
+			traceIds = append(traceIds, traceId)
+			if len(traceIds) == TRACE_ANALYSIS_BATCH_SIZE {
+				a.analyze(orgId, traceIds, minTime, maxTime)
+				traceIds = traceIds[:0]
+				maxTime = 0
+			}
+		}
+		if len(traceIds) > 0 {
+			a.analyze(orgId, traceIds, minTime, maxTime)
+		}
+	}
+}
+
+func (a *TraceAnalyzer) analyze(orgId uint16, traceIds []string, minTime, maxTime uint32) {
+	spans, err := a.reader.querySpans(orgId, traceIds, minTime, maxTime)
+	if err != nil {
+		atomic.AddInt64(&a.counter.QueryErrors, 1)
+		log.Warningf("query spans of traces failed: %s", err)
+	}
+	var items []interface{}
+	for traceId, traceSpans := range spans {
+		// the spans may be partially read if the query failed
+		if err == nil {
+			for _, row := range tracetree.AnalyzeTrace(orgId, traceId, traceSpans) {
+				items = append(items, row)
+			}
+			atomic.AddInt64(&a.counter.AnalyzedTraces, 1)
+		}
+		for _, span := range traceSpans {
+			tracetree.ReleaseSpanTrace(span)
+		}
+	}
+	atomic.AddInt64(&a.counter.WrittenServices, int64(len(items)))
+	if len(items) > 0 {
+		a.writer.Put(items...)
+	}
+}
+
+func (a *TraceAnalyzer) run() {
+	ticker := time.NewTicker(TRACE_ANALYSIS_CHECK_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.flush(time.Now().Unix())
+		case <-a.closed:
+			return
+		}
+	}
+}
+
+func (a *TraceAnalyzer) Start() {
+	log.Info("flow log trace analyzer starting")
+	a.writer.Run()
+	go a.run()
+}
+
+func (a *TraceAnalyzer) Close() {
+	close(a.closed)
+	a.Closable.Close()
+	a.writer.Close()
+	a.reader.close()
+}
+
+// ckSpanReader reads spans from the distributed table, so any clickhouse can be queried. If the clickhouse fails,
+// the next one is used.
+type ckSpanReader struct {
+	ckdbAddrs    *[]string
+	ckdbUsername string
+	ckdbPassword string
+
+	connAddr string
+	conn     *sql.DB
+	next     int
+}
+
+func (r *ckSpanReader) resetConn() {
+	if r.conn != nil {
+		r.conn.Close()
+		r.conn = nil
+	}
+}
+
+func (r *ckSpanReader) getConn() (*sql.DB, error) {
+	addrs := *r.ckdbAddrs
+	if r.conn != nil {
+		for _, addr := range addrs {
+			if addr == r.connAddr {
+				return r.conn, nil
+			}
+		}
+		// the clickhouse endpoints are changed
+		r.resetConn()
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no clickhouse address")
+	}
+	var err error
+	for i := 0; i < len(addrs); i++ {
+		addr := addrs[(r.next+i)%len(addrs)]
+		var conn *sql.DB
+		if conn, err = basecommon.NewCKConnection(addr, r.ckdbUsername, r.ckdbPassword); err == nil {
+			r.next = (r.next + i + 1) % len(addrs)
+			r.conn, r.connAddr = conn, addr
+			return conn, nil
+		}
+		log.Warning(err)
+	}
+	return nil, err
+}
+
+func (r *ckSpanReader) querySpans(orgId uint16, traceIds []string, minTime, maxTime uint32) (map[string][]*tracetree.SpanTrace, error) {
+	query := fmt.Sprintf("SELECT time, trace_id, encoded_span FROM %s.%s WHERE time >= %d AND time <= %d AND trace_id IN (?)",
+		ckdb.OrgDatabasePrefix(orgId)+common.FLOW_LOG_DB, SPAN_WITH_TRACE_ID_TABLE, int64(minTime)-TRACE_ANALYSIS_SPAN_TIME_RANGE, int64(maxTime)+TRACE_ANALYSIS_SPAN_TIME_RANGE)
+	var err error
+	for i := 0; i < len(*r.ckdbAddrs); i++ {
+		var conn *sql.DB
+		if conn, err = r.getConn(); err != nil {
+			return nil, err
+		}
+		var rows *sql.Rows
+		if rows, err = conn.Query(query, traceIds); err != nil {
+			err = fmt.Errorf("query SQL: %s from %s failed: %s", query, r.connAddr, err)
+			r.resetConn()
+			continue
+		}
+		defer rows.Close()
+		spans := make(map[string][]*tracetree.SpanTrace, len(traceIds))
+		decoder := &codec.SimpleDecoder{}
+		for rows.Next() {
+			var t time.Time
+			var traceId, encodedSpan string
+			if err := rows.Scan(&t, &traceId, &encodedSpan); err != nil {
+				return spans, err
+			}
+			if span := decodeSpan(decoder, uint32(t.Unix()), []byte(encodedSpan)); span != nil {
+				spans[traceId] = append(spans[traceId], span)
+			}
+		}
+		return spans, rows.Err()
+	}
+	return nil, err
+}
+
+func (r *ckSpanReader) close() {
+	r.resetConn()
+}
+
+// decodeSpan decodes the encoded_span of span_with_trace_id, nil is returned if it is invalid
+func decodeSpan(decoder *codec.SimpleDecoder, t uint32, encodedSpan []byte) *tracetree.SpanTrace {
+	span := tracetree.AcquireSpanTrace()
+	decoder.Init(encodedSpan)
+	if err := span.Decode(decoder); err != nil {
+		tracetree.ReleaseSpanTrace(span)
+		log.Debugf("decode span failed: %s", err)
+		return nil
+	}
+	span.Time = t
+	return span
+}
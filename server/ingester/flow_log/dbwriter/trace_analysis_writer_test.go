@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dbwriter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/config"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/log_data"
+	"github.com/deepflowio/deepflow/server/libs/ckdb"
+	"github.com/deepflowio/deepflow/server/libs/codec"
+	flow_metrics "github.com/deepflowio/deepflow/server/libs/flow-metrics"
+	"github.com/deepflowio/deepflow/server/libs/tracetree"
+)
+
+type storedSpan struct {
+	time        uint32
+	encodedSpan []byte
+}
+
+// testSpanStore stores the spans written to span_with_trace_id, and reads them back like clickhouse
+type testSpanStore struct {
+	spans map[uint16]map[string][]storedSpan
+}
+
+func (s *testSpanStore) Put(items ...interface{}) {
+	for _, item := range items {
+		span := item.(*SpanWithTraceID)
+		span.Encode()
+		if s.spans[span.OrgId] == nil {
+			s.spans[span.OrgId] = make(map[string][]storedSpan)
+		}
+		s.spans[span.OrgId][span.TraceId] = append(s.spans[span.OrgId][span.TraceId],
+			storedSpan{time: span.Time, encodedSpan: append([]byte(nil), span.EncodedSpan...)})
+	}
+}
+
+func (s *testSpanStore) Run()   {}
+func (s *testSpanStore) Close() {}
+
+func (s *testSpanStore) querySpans(orgId uint16, traceIds []string, minTime, maxTime uint32) (map[string][]*tracetree.SpanTrace, error) {
+	spans := make(map[string][]*tracetree.SpanTrace)
+	decoder := &codec.SimpleDecoder{}
+	for _, traceId := range traceIds {
+		for _, stored := range s.spans[orgId][traceId] {
+			if int64(stored.time) < int64(minTime)-TRACE_ANALYSIS_SPAN_TIME_RANGE || int64(stored.time) > int64(maxTime)+TRACE_ANALYSIS_SPAN_TIME_RANGE {
+				continue
+			}
+			if span := decodeSpan(decoder, stored.time, stored.encodedSpan); span != nil {
+				spans[traceId] = append(spans[traceId], span)
+			}
+		}
+	}
+	return spans, nil
+}
+
+func (s *testSpanStore) close() {}
+
+type testItemWriter struct {
+	items []interface{}
+}
+
+func (w *testItemWriter) Put(items ...interface{}) { w.items = append(w.items, items...) }
+func (w *testItemWriter) Run()                     {}
+func (w *testItemWriter) Close()                   {}
+
+func newTestSpan(traceId, spanId, parentSpanId, appService string, id0, id1 uint32, endUs int64, durationUs uint64) *SpanWithTraceID {
+	l := &log_data.L7FlowLog{}
+	l.OrgId = 1
+	l.Time = uint32(endUs / 1000000)
+	l.L7Base.EndTime = endUs
+	l.IsIPv4 = true
+	l.TapSideEnum = uint8(flow_metrics.ServerApp)
+	l.AutoServiceType0, l.AutoServiceID0 = 1, id0
+	l.AutoServiceType1, l.AutoServiceID1 = 1, id1
+	l.TraceId = traceId
+	l.SpanId = spanId
+	l.ParentSpanId = parentSpanId
+	l.AppService = appService
+	l.ResponseDuration = durationUs
+	return (*SpanWithTraceID)(l)
+}
+
+func TestTraceAnalyzer(t *testing.T) {
+	store := &testSpanStore{spans: make(map[uint16]map[string][]storedSpan)}
+	output := &testItemWriter{}
+	cfg := &config.TraceAnalysisConfig{Enabled: true, Delay: 60, MaxPendingTraces: 10}
+	w := &SpanWriter{traceWriter: store, analyzer: newTraceAnalyzer(cfg, store, output)}
+
+	base := time.Now().Unix() * 1000000
+	// gateway handles the request in 1000us and calls order, which handles it in 600us
+	w.Put([]interface{}{newTestSpan("trace-1", "a", "", "gateway", 0, 10, base+1000, 1000)})
+	w.Put([]interface{}{newTestSpan("trace-1", "b", "a", "order", 10, 20, base+800, 600)})
+
+	w.analyzer.flush(time.Now().Unix())
+	if len(output.items) != 0 {
+		t.Fatalf("trace is analyzed before the delay")
+	}
+	w.analyzer.flush(time.Now().Unix() + 60)
+	rows := map[string]*tracetree.TraceAnalysis{}
+	for _, item := range output.items {
+		row := item.(*tracetree.TraceAnalysis)
+		rows[row.AppService] = row
+	}
+	if len(rows) != 2 || rows["gateway"] == nil || rows["order"] == nil {
+		t.Fatalf("analysis rows: %+v, want gateway and order", rows)
+	}
+	for _, row := range rows {
+		if row.TraceId != "trace-1" || row.RootAppService != "gateway" || row.TraceSpanCount != 2 {
+			t.Errorf("analysis row: %+v", row)
+		}
+	}
+	if rows["gateway"].SelfTime != 400 || rows["order"].SelfTime != 600 {
+		t.Errorf("self time of gateway %d, order %d, want 400, 600", rows["gateway"].SelfTime, rows["order"].SelfTime)
+	}
+	if len(w.analyzer.traces) != 0 {
+		t.Errorf("analyzed trace is still pending")
+	}
+
+	// spans of new traces are not analyzed if there are too many pending traces
+	for i := 0; i < 20; i++ {
+		w.Put([]interface{}{newTestSpan(string(rune('a'+i)), "a", "", "gateway", 0, 10, base, 1000)})
+	}
+	if len(w.analyzer.traces) != cfg.MaxPendingTraces {
+		t.Errorf("pending traces %d, want %d", len(w.analyzer.traces), cfg.MaxPendingTraces)
+	}
+}
+
+func TestTraceAnalysisTable(t *testing.T) {
+	table := GenTraceAnalysisCKTable("", "", ckdb.CKDBTypeClickhouse, 72, &ckdb.ColdStorage{})
+	sql := table.MakeLocalTableCreateSQL()
+	if !strings.Contains(sql, "ReplacingMergeTree(time)") || !strings.Contains(sql, "ORDER BY (trace_id,") {
+		t.Errorf("analysis of a trace should be deduplicated by trace_id: %s", sql)
+	}
+}
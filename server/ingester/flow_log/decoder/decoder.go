@@ -36,6 +36,7 @@ import (
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/dbwriter"
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/log_data"
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/log_data/sw_import"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/processor"
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/throttler"
 	"github.com/deepflowio/deepflow/server/ingester/flow_tag"
 	"github.com/deepflowio/deepflow/server/libs/codec"
@@ -269,8 +270,9 @@ func (d *Decoder) sendOpenMetetry(tracesData *v1.TracesData) {
 	d.counter.Count++
 	ls := log_data.OTelTracesDataToL7FlowLogs(d.agentId, d.orgId, d.teamId, tracesData, d.platformData, d.cfg)
 	for _, l := range ls {
+		processor.Process(l)
 		l.AddReferenceCount()
-		if !d.throttler.SendWithThrottling(l) {
+		if !d.throttler.SendL7WithSampling(l) {
 			d.counter.DropCount++
 		} else {
 			d.fieldsBuf, d.fieldValuesBuf = d.fieldsBuf[:0], d.fieldValuesBuf[:0]
@@ -315,8 +317,9 @@ func (d *Decoder) sendSkyWalking(segmentData, peerIP []byte, uri string) {
 	d.counter.Count++
 	ls := sw_import.SkyWalkingDataToL7FlowLogs(d.agentId, d.orgId, d.teamId, segmentData, peerIP, uri, d.platformData, d.cfg)
 	for _, l := range ls {
+		processor.Process(l)
 		l.AddReferenceCount()
-		if !d.throttler.SendWithThrottling(l) {
+		if !d.throttler.SendL7WithSampling(l) {
 			d.counter.DropCount++
 		} else {
 			d.fieldsBuf, d.fieldValuesBuf = d.fieldsBuf[:0], d.fieldValuesBuf[:0]
@@ -417,8 +420,9 @@ func (d *Decoder) sendProto(proto *pb.AppProtoLogsData) {
 	}
 
 	l := log_data.ProtoLogToL7FlowLog(d.orgId, d.teamId, proto, d.platformData, d.cfg)
+	processor.Process(l)
 	l.AddReferenceCount()
-	sent := d.throttler.SendWithThrottling(l)
+	sent := d.throttler.SendL7WithSampling(l)
 	if sent {
 		if d.flowTagWriter != nil {
 			d.fieldsBuf, d.fieldValuesBuf = d.fieldsBuf[:0], d.fieldValuesBuf[:0]
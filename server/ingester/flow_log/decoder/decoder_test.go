@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/libs/codec"
+	"github.com/deepflowio/deepflow/server/libs/datatype"
+	"github.com/deepflowio/deepflow/server/libs/datatype/pb"
+	"github.com/deepflowio/deepflow/server/libs/receiver"
+)
+
+type expectedProtoLog struct {
+	proto    datatype.L7Protocol
+	reqType  string
+	domain   string
+	resource string
+	code     int32
+}
+
+// the fixtures are recorded by the receiver recorder (ingesterctl receiver-record), they guard the decoding of
+// the data sent by the agents against the changes of the message format
+var protoLogFixtures = map[string][]expectedProtoLog{
+	"l7_log_http_dns.rec": {
+		{datatype.L7_PROTOCOL_HTTP_1, "GET", "example.com", "/api/v1/users", 200},
+		{datatype.L7_PROTOCOL_DNS, "A", "", "example.com", 0},
+	},
+}
+
+func readFixture(t *testing.T, name string) []*receiver.Record {
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := receiver.NewRecordReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []*receiver.Record{}
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return records
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestDecodeProtoLogFixtures(t *testing.T) {
+	for name, expected := range protoLogFixtures {
+		decoder := &codec.SimpleDecoder{}
+		protoLogs := []*pb.AppProtoLogsData{}
+		for _, record := range readFixture(t, name) {
+			if record.MsgType != datatype.MESSAGE_TYPE_PROTOCOLLOG {
+				t.Fatalf("%s: unexpected message type %s", name, record.MsgType)
+			}
+			payload, err := record.Payload()
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			decoder.Init(payload)
+			for !decoder.IsEnd() {
+				protoLog := &pb.AppProtoLogsData{}
+				decoder.ReadPB(protoLog)
+				if decoder.Failed() || !protoLog.IsValid() {
+					t.Fatalf("%s: proto log decode failed, offset=%d len=%d", name, decoder.Offset(), len(decoder.Bytes()))
+				}
+				protoLogs = append(protoLogs, protoLog)
+			}
+		}
+		if len(protoLogs) != len(expected) {
+			t.Fatalf("%s: expect %d proto logs, got %d", name, len(expected), len(protoLogs))
+		}
+		for i, e := range expected {
+			l := protoLogs[i]
+			if datatype.L7Protocol(l.Base.Head.Proto) != e.proto || l.Req == nil || l.Resp == nil ||
+				l.Req.ReqType != e.reqType || l.Req.Domain != e.domain || l.Req.Resource != e.resource || l.Resp.Code != e.code {
+				t.Errorf("%s: proto log %d is %s, expect %+v", name, i, l, e)
+			}
+		}
+	}
+}
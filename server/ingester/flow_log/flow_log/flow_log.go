@@ -26,6 +26,7 @@ import (
 	_ "golang.org/x/net/context"
 	_ "google.golang.org/grpc"
 
+	ingestercommon "github.com/deepflowio/deepflow/server/ingester/common"
 	dropletqueue "github.com/deepflowio/deepflow/server/ingester/droplet/queue"
 	"github.com/deepflowio/deepflow/server/ingester/exporters"
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/common"
@@ -33,6 +34,7 @@ import (
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/dbwriter"
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/decoder"
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/geo"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/processor"
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/throttler"
 	"github.com/deepflowio/deepflow/server/ingester/flow_tag"
 	"github.com/deepflowio/deepflow/server/ingester/ingesterctl"
@@ -43,6 +45,7 @@ import (
 	"github.com/deepflowio/deepflow/server/libs/queue"
 	libqueue "github.com/deepflowio/deepflow/server/libs/queue"
 	"github.com/deepflowio/deepflow/server/libs/receiver"
+	"github.com/deepflowio/deepflow/server/libs/stats"
 )
 
 var log = logging.MustGetLogger("flow_log")
@@ -69,6 +72,9 @@ type Logger struct {
 
 func NewFlowLog(config *config.Config, traceTreeQueue *queue.OverwriteQueue, recv *receiver.Receiver, platformDataManager *grpc.PlatformDataManager, exporters *exporters.Exporters) (*FlowLog, error) {
 	manager := dropletqueue.NewManager(ingesterctl.INGESTERCTL_FLOW_LOG_QUEUE)
+	if err := processor.Init(&config.L7Processor); err != nil {
+		return nil, err
+	}
 
 	if config.Base.StorageDisabled {
 		l7FlowLogger, err := NewL7FlowLogger(config, platformDataManager, manager, recv, nil, exporters, nil)
@@ -82,6 +88,7 @@ func NewFlowLog(config *config.Config, traceTreeQueue *queue.OverwriteQueue, rec
 	}
 
 	geo.NewGeoTree()
+	geo.NewIPGeoTree(&config.Base.GeoIP)
 
 	flowLogWriter, err := dbwriter.NewFlowLogWriter(
 		*config.Base.CKDB.ActualAddrs, config.Base.CKDBAuth.Username, config.Base.CKDBAuth.Password,
@@ -167,6 +174,7 @@ func NewLogger(msgType datatype.MessageType, config *config.Config, platformData
 			flowLogWriter,
 			int(flowLogId),
 		)
+		setL7Sampler(config, throttlers[i], queueCount, i, msgType)
 		if platformDataManager != nil {
 			platformDatas[i], _ = platformDataManager.NewPlatformInfoTable("flow-log-" + datatype.MessageTypeString[msgType] + "-" + strconv.Itoa(i))
 			if i == 0 {
@@ -291,6 +299,7 @@ func NewL7FlowLogger(config *config.Config, platformDataManager *grpc.PlatformDa
 			flowLogWriter,
 			int(common.L7_FLOW_ID),
 		)
+		setL7Sampler(config, throttlers[i], queueCount, i, msgType)
 		platformDatas[i], _ = platformDataManager.NewPlatformInfoTable("l7-flow-log-" + strconv.Itoa(i))
 		if i == 0 {
 			debug.ServerRegisterSimple(ingesterctl.CMD_PLATFORMDATA_FLOW_LOG, platformDatas[i])
@@ -318,6 +327,18 @@ func NewL7FlowLogger(config *config.Config, platformDataManager *grpc.PlatformDa
 	return l, nil
 }
 
+// setL7Sampler replaces the reservoir sampling of l7_flow_log if l7-sampling is enabled
+func setL7Sampler(config *config.Config, thq *throttler.ThrottlingQueue, queueCount, index int, msgType datatype.MessageType) {
+	if !config.L7Sampling.Enabled {
+		return
+	}
+	sampler := throttler.NewL7Sampler(&config.L7Sampling, queueCount, config.ThrottleBucket)
+	ingestercommon.RegisterCountableForIngester("l7_sampler", sampler, stats.OptionStatTags{
+		"thread":   strconv.Itoa(index),
+		"msg_type": msgType.String()})
+	thq.SetL7Sampler(sampler)
+}
+
 func (l *Logger) HandleSimpleCommand(op uint16, arg string) string {
 	sb := &strings.Builder{}
 	sb.WriteString("last 10s counter:\n")
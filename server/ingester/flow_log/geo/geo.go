@@ -17,11 +17,25 @@
 package geo
 
 import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/ingester/config"
 	"github.com/deepflowio/deepflow/server/libs/geo"
+	"github.com/deepflowio/deepflow/server/libs/utils"
+	logging "github.com/op/go-logging"
 )
 
+var log = logging.MustGetLogger("flow_log.geo")
+
 var geoTree geo.GeoTree
 
+var (
+	ipGeoTree     geo.IPGeoTree
+	ipGeoTreeOnce sync.Once
+)
+
 func NewGeoTree() {
 	geoTree = geo.NewNetmaskGeoTree()
 }
@@ -30,3 +44,31 @@ func QueryProvince(ip uint32) string {
 	region, _ := geoTree.Query(ip)
 	return geo.DecodeRegion(region)
 }
+
+// NewIPGeoTree loads the global GeoIP and ASN databases, it is shared by flow logs and application logs,
+// so only the first call takes effect
+func NewIPGeoTree(cfg *config.GeoIP) {
+	ipGeoTreeOnce.Do(func() {
+		if len(cfg.Databases) == 0 {
+			return
+		}
+		tree, err := geo.NewMMDBGeoTree(cfg.Databases)
+		if err != nil {
+			log.Errorf("load geoip databases failed: %s", err)
+			return
+		}
+		tree.Start(time.Duration(cfg.ReloadInterval) * time.Second)
+		ipGeoTree = tree
+	})
+}
+
+// QueryIPGeo returns the global geo and ASN info of the IP, empty if no database is configured
+func QueryIPGeo(isIPv6 bool, ip4 uint32, ip6 net.IP) geo.IPGeoInfo {
+	if ipGeoTree == nil {
+		return geo.IPGeoInfo{}
+	}
+	if isIPv6 {
+		return ipGeoTree.QueryIP(ip6)
+	}
+	return ipGeoTree.QueryIP(utils.IpFromUint32(ip4))
+}
@@ -0,0 +1,678 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log_data
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+
+	flowlogCfg "github.com/deepflowio/deepflow/server/ingester/flow_log/config"
+	"github.com/deepflowio/deepflow/server/libs/grpc"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	v11 "go.opentelemetry.io/proto/otlp/common/v1"
+	v1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Jaeger Thrift 和 Protobuf 编码的首字节, 用于区分数据格式
+const (
+	JAEGER_THRIFT_BINARY_MESSAGE  = 0x80 // Agent.emitBatch, TBinaryProtocol
+	JAEGER_THRIFT_COMPACT_MESSAGE = 0x82 // Agent.emitBatch, TCompactProtocol
+	JAEGER_THRIFT_BINARY_BATCH    = 0x0c // Batch field 1 (Process struct), TBinaryProtocol
+	JAEGER_THRIFT_COMPACT_BATCH   = 0x1c // Batch field 1 (Process struct), TCompactProtocol
+)
+
+// Jaeger tag value types, thrift and protobuf use different enum values
+const (
+	jaegerThriftTagString = iota
+	jaegerThriftTagDouble
+	jaegerThriftTagBool
+	jaegerThriftTagLong
+	jaegerThriftTagBinary
+)
+
+const (
+	jaegerProtoTagString = iota
+	jaegerProtoTagBool
+	jaegerProtoTagInt64
+	jaegerProtoTagFloat64
+	jaegerProtoTagBinary
+)
+
+const jaegerRefChildOf = 0
+
+type jaegerProcess struct {
+	ServiceName string
+	Tags        []*v11.KeyValue
+}
+
+type jaegerSpanRef struct {
+	RefType     int32
+	TraceIDHigh uint64
+	TraceIDLow  uint64
+	SpanID      uint64
+}
+
+type jaegerSpan struct {
+	TraceIDHigh   uint64
+	TraceIDLow    uint64
+	SpanID        uint64
+	ParentSpanID  uint64
+	OperationName string
+	References    []jaegerSpanRef
+	StartTime     uint64 // ns
+	Duration      uint64 // ns
+	Tags          []*v11.KeyValue
+	Logs          []*v1.Span_Event
+	Process       *jaegerProcess
+}
+
+// JaegerBatchToL7FlowLogs converts a Jaeger batch (Thrift or Protobuf) into l7 flow logs.
+func JaegerBatchToL7FlowLogs(vtapID, orgId, teamId uint16, data []byte, platformData *grpc.PlatformInfoTable, cfg *flowlogCfg.Config) ([]*L7FlowLog, error) {
+	tracesData, err := JaegerToTracesData(data)
+	if err != nil {
+		return nil, err
+	}
+	return OTelTracesDataToL7FlowLogs(vtapID, orgId, teamId, tracesData, platformData, cfg), nil
+}
+
+// JaegerToTracesData converts a Jaeger batch to OTLP, so that they are stored the same way as OpenTelemetry spans.
+// Supported payloads:
+//   - Thrift Agent.emitBatch message, binary or compact protocol (jaeger-agent UDP)
+//   - Thrift Batch, binary or compact protocol (jaeger-collector /api/traces)
+//   - Protobuf model.Batch (jaeger-collector gRPC PostSpans)
+func JaegerToTracesData(data []byte) (*v1.TracesData, error) {
+	if len(data) == 0 {
+		return &v1.TracesData{}, nil
+	}
+	var process *jaegerProcess
+	var spans []*jaegerSpan
+	var err error
+	switch data[0] {
+	case JAEGER_THRIFT_BINARY_MESSAGE, JAEGER_THRIFT_BINARY_BATCH:
+		p := thrift.NewTBinaryProtocolConf(&thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(data)}, nil)
+		process, spans, err = readJaegerThrift(p, data[0] == JAEGER_THRIFT_BINARY_MESSAGE)
+	case JAEGER_THRIFT_COMPACT_MESSAGE, JAEGER_THRIFT_COMPACT_BATCH:
+		p := thrift.NewTCompactProtocolConf(&thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(data)}, nil)
+		process, spans, err = readJaegerThrift(p, data[0] == JAEGER_THRIFT_COMPACT_MESSAGE)
+	default:
+		process, spans, err = decodeJaegerProtoBatch(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jaeger batch decode failed: %s", err)
+	}
+
+	resourceSpans := newResourceSpansBuilder()
+	for _, s := range spans {
+		// protobuf span 可以携带自己的 process, 优先于 batch 的 process
+		p := s.Process
+		if p == nil {
+			p = process
+		}
+		resourceSpans.add(p.resourceAttributes(), s.toOTel())
+	}
+	return &v1.TracesData{ResourceSpans: resourceSpans.list}, nil
+}
+
+func (p *jaegerProcess) resourceAttributes() []*v11.KeyValue {
+	if p == nil {
+		return nil
+	}
+	attrs := make([]*v11.KeyValue, 0, len(p.Tags)+2)
+	if p.ServiceName != "" {
+		attrs = append(attrs, stringKeyValue("service.name", p.ServiceName))
+	}
+	for _, tag := range p.Tags {
+		// jaeger client 在 process tags 中上报本机 IP
+		if tag.Key == "ip" {
+			if ip := jaegerTagIP(tag.Value); ip != "" {
+				attrs = append(attrs, stringKeyValue("app.host.ip", ip))
+			}
+		}
+		attrs = append(attrs, tag)
+	}
+	return attrs
+}
+
+// the ip tag may be a string or an ipv4 address packed into an int
+func jaegerTagIP(value *v11.AnyValue) string {
+	if s, ok := value.GetValue().(*v11.AnyValue_StringValue); ok {
+		if net.ParseIP(s.StringValue) == nil {
+			return ""
+		}
+		return s.StringValue
+	}
+	if i, ok := value.GetValue().(*v11.AnyValue_IntValue); ok {
+		return IPIntToString(uint32(i.IntValue))
+	}
+	return ""
+}
+
+// jaeger 用 128 位 trace id 时 high 非 0, 否则只用低 64 位, 与 uber-trace-id header 中的长度保持一致
+func jaegerTraceID(high, low uint64) []byte {
+	if high == 0 {
+		return uint64ToID(low)
+	}
+	return append(uint64ToID(high), uint64ToID(low)...)
+}
+
+func jaegerSpanKind(kind string) v1.Span_SpanKind {
+	switch strings.ToLower(kind) {
+	case "client":
+		return v1.Span_SPAN_KIND_CLIENT
+	case "server":
+		return v1.Span_SPAN_KIND_SERVER
+	case "producer":
+		return v1.Span_SPAN_KIND_PRODUCER
+	case "consumer":
+		return v1.Span_SPAN_KIND_CONSUMER
+	case "internal":
+		return v1.Span_SPAN_KIND_INTERNAL
+	default:
+		return v1.Span_SPAN_KIND_UNSPECIFIED
+	}
+}
+
+func (s *jaegerSpan) toOTel() *v1.Span {
+	span := &v1.Span{
+		TraceId:           jaegerTraceID(s.TraceIDHigh, s.TraceIDLow),
+		SpanId:            uint64ToID(s.SpanID),
+		Name:              s.OperationName,
+		StartTimeUnixNano: s.StartTime,
+		EndTimeUnixNano:   s.StartTime + s.Duration,
+		Events:            s.Logs,
+	}
+
+	parentSpanID := s.ParentSpanID
+	if parentSpanID == 0 {
+		for _, ref := range s.References {
+			if ref.RefType == jaegerRefChildOf && ref.TraceIDHigh == s.TraceIDHigh && ref.TraceIDLow == s.TraceIDLow {
+				parentSpanID = ref.SpanID
+				break
+			}
+		}
+	}
+	if parentSpanID != 0 {
+		span.ParentSpanId = uint64ToID(parentSpanID)
+	}
+
+	hasPeerIP := false
+	var peerIP string
+	for _, tag := range s.Tags {
+		switch tag.Key {
+		case "span.kind":
+			span.Kind = jaegerSpanKind(getValueString(tag.Value))
+		case "error":
+			if getValueString(tag.Value) == "true" {
+				if span.Status == nil {
+					span.Status = &v1.Status{}
+				}
+				span.Status.Code = v1.Status_STATUS_CODE_ERROR
+			}
+		case "otel.status_code":
+			if span.Status == nil {
+				span.Status = &v1.Status{}
+			}
+			switch strings.ToUpper(getValueString(tag.Value)) {
+			case "ERROR":
+				span.Status.Code = v1.Status_STATUS_CODE_ERROR
+			case "OK":
+				span.Status.Code = v1.Status_STATUS_CODE_OK
+			}
+		case "otel.status_description":
+			if span.Status == nil {
+				span.Status = &v1.Status{}
+			}
+			span.Status.Message = getValueString(tag.Value)
+		case "net.peer.ip":
+			hasPeerIP = true
+		case "peer.ipv4", "peer.ipv6":
+			peerIP = jaegerTagIP(tag.Value)
+		}
+		span.Attributes = append(span.Attributes, tag)
+	}
+	if !hasPeerIP && peerIP != "" {
+		span.Attributes = append(span.Attributes, stringKeyValue("net.peer.ip", peerIP))
+	}
+	return span
+}
+
+func newJaegerEvent(timestamp uint64, fields []*v11.KeyValue) *v1.Span_Event {
+	event := &v1.Span_Event{TimeUnixNano: timestamp, Name: "log"}
+	for _, field := range fields {
+		if field.Key == "event" {
+			event.Name = getValueString(field.Value)
+			continue
+		}
+		event.Attributes = append(event.Attributes, field)
+	}
+	return event
+}
+
+// thrift, 参考: https://github.com/jaegertracing/jaeger-idl/blob/main/thrift/jaeger.thrift
+
+func readJaegerThrift(p thrift.TProtocol, isMessage bool) (process *jaegerProcess, spans []*jaegerSpan, err error) {
+	ctx := context.Background()
+	readBatch := func() error {
+		return readThriftStruct(ctx, p, func(id int16, typ thrift.TType) error {
+			switch {
+			case id == 1 && typ == thrift.STRUCT:
+				process, err = readJaegerThriftProcess(ctx, p)
+				return err
+			case id == 2 && typ == thrift.LIST:
+				return readThriftList(ctx, p, func() error {
+					span, err := readJaegerThriftSpan(ctx, p)
+					if err == nil {
+						spans = append(spans, span)
+					}
+					return err
+				})
+			}
+			return p.Skip(ctx, typ)
+		})
+	}
+	if !isMessage {
+		err = readBatch()
+		return
+	}
+
+	// emitBatch_args { 1: Batch batch }
+	if _, _, _, err = p.ReadMessageBegin(ctx); err != nil {
+		return
+	}
+	err = readThriftStruct(ctx, p, func(id int16, typ thrift.TType) error {
+		if id == 1 && typ == thrift.STRUCT {
+			return readBatch()
+		}
+		return p.Skip(ctx, typ)
+	})
+	if err == nil {
+		err = p.ReadMessageEnd(ctx)
+	}
+	return
+}
+
+func readJaegerThriftProcess(ctx context.Context, p thrift.TProtocol) (*jaegerProcess, error) {
+	process := &jaegerProcess{}
+	err := readThriftStruct(ctx, p, func(id int16, typ thrift.TType) (err error) {
+		switch {
+		case id == 1 && typ == thrift.STRING:
+			process.ServiceName, err = p.ReadString(ctx)
+			return
+		case id == 2 && typ == thrift.LIST:
+			process.Tags, err = readJaegerThriftTags(ctx, p)
+			return
+		}
+		return p.Skip(ctx, typ)
+	})
+	return process, err
+}
+
+func readJaegerThriftSpan(ctx context.Context, p thrift.TProtocol) (*jaegerSpan, error) {
+	s := &jaegerSpan{}
+	err := readThriftStruct(ctx, p, func(id int16, typ thrift.TType) (err error) {
+		var v int64
+		switch {
+		case id == 1 && typ == thrift.I64:
+			v, err = p.ReadI64(ctx)
+			s.TraceIDLow = uint64(v)
+		case id == 2 && typ == thrift.I64:
+			v, err = p.ReadI64(ctx)
+			s.TraceIDHigh = uint64(v)
+		case id == 3 && typ == thrift.I64:
+			v, err = p.ReadI64(ctx)
+			s.SpanID = uint64(v)
+		case id == 4 && typ == thrift.I64:
+			v, err = p.ReadI64(ctx)
+			s.ParentSpanID = uint64(v)
+		case id == 5 && typ == thrift.STRING:
+			s.OperationName, err = p.ReadString(ctx)
+		case id == 6 && typ == thrift.LIST:
+			err = readThriftList(ctx, p, func() error {
+				ref, err := readJaegerThriftSpanRef(ctx, p)
+				if err == nil {
+					s.References = append(s.References, ref)
+				}
+				return err
+			})
+		case id == 8 && typ == thrift.I64:
+			v, err = p.ReadI64(ctx)
+			s.StartTime = uint64(v) * 1000
+		case id == 9 && typ == thrift.I64:
+			v, err = p.ReadI64(ctx)
+			s.Duration = uint64(v) * 1000
+		case id == 10 && typ == thrift.LIST:
+			s.Tags, err = readJaegerThriftTags(ctx, p)
+		case id == 11 && typ == thrift.LIST:
+			err = readThriftList(ctx, p, func() error {
+				event, err := readJaegerThriftLog(ctx, p)
+				if err == nil {
+					s.Logs = append(s.Logs, event)
+				}
+				return err
+			})
+		default:
+			err = p.Skip(ctx, typ)
+		}
+		return
+	})
+	return s, err
+}
+
+func readJaegerThriftSpanRef(ctx context.Context, p thrift.TProtocol) (jaegerSpanRef, error) {
+	ref := jaegerSpanRef{}
+	err := readThriftStruct(ctx, p, func(id int16, typ thrift.TType) (err error) {
+		var v int64
+		switch {
+		case id == 1 && typ == thrift.I32:
+			ref.RefType, err = p.ReadI32(ctx)
+		case id == 2 && typ == thrift.I64:
+			v, err = p.ReadI64(ctx)
+			ref.TraceIDLow = uint64(v)
+		case id == 3 && typ == thrift.I64:
+			v, err = p.ReadI64(ctx)
+			ref.TraceIDHigh = uint64(v)
+		case id == 4 && typ == thrift.I64:
+			v, err = p.ReadI64(ctx)
+			ref.SpanID = uint64(v)
+		default:
+			err = p.Skip(ctx, typ)
+		}
+		return
+	})
+	return ref, err
+}
+
+func readJaegerThriftLog(ctx context.Context, p thrift.TProtocol) (*v1.Span_Event, error) {
+	var timestamp int64
+	var fields []*v11.KeyValue
+	err := readThriftStruct(ctx, p, func(id int16, typ thrift.TType) (err error) {
+		switch {
+		case id == 1 && typ == thrift.I64:
+			timestamp, err = p.ReadI64(ctx)
+		case id == 2 && typ == thrift.LIST:
+			fields, err = readJaegerThriftTags(ctx, p)
+		default:
+			err = p.Skip(ctx, typ)
+		}
+		return
+	})
+	return newJaegerEvent(uint64(timestamp)*1000, fields), err
+}
+
+func readJaegerThriftTags(ctx context.Context, p thrift.TProtocol) ([]*v11.KeyValue, error) {
+	var tags []*v11.KeyValue
+	err := readThriftList(ctx, p, func() error {
+		var key, vStr string
+		var vType int32
+		var vDouble float64
+		var vBool bool
+		var vLong int64
+		var vBinary []byte
+		err := readThriftStruct(ctx, p, func(id int16, typ thrift.TType) (err error) {
+			switch {
+			case id == 1 && typ == thrift.STRING:
+				key, err = p.ReadString(ctx)
+			case id == 2 && typ == thrift.I32:
+				vType, err = p.ReadI32(ctx)
+			case id == 3 && typ == thrift.STRING:
+				vStr, err = p.ReadString(ctx)
+			case id == 4 && typ == thrift.DOUBLE:
+				vDouble, err = p.ReadDouble(ctx)
+			case id == 5 && typ == thrift.BOOL:
+				vBool, err = p.ReadBool(ctx)
+			case id == 6 && typ == thrift.I64:
+				vLong, err = p.ReadI64(ctx)
+			case id == 7 && typ == thrift.STRING:
+				vBinary, err = p.ReadBinary(ctx)
+			default:
+				err = p.Skip(ctx, typ)
+			}
+			return
+		})
+		if err != nil {
+			return err
+		}
+		switch vType {
+		case jaegerThriftTagDouble:
+			tags = append(tags, doubleKeyValue(key, vDouble))
+		case jaegerThriftTagBool:
+			tags = append(tags, boolKeyValue(key, vBool))
+		case jaegerThriftTagLong:
+			tags = append(tags, intKeyValue(key, vLong))
+		case jaegerThriftTagBinary:
+			tags = append(tags, bytesKeyValue(key, vBinary))
+		default:
+			tags = append(tags, stringKeyValue(key, vStr))
+		}
+		return nil
+	})
+	return tags, err
+}
+
+func readThriftStruct(ctx context.Context, p thrift.TProtocol, fn func(id int16, typ thrift.TType) error) error {
+	if _, err := p.ReadStructBegin(ctx); err != nil {
+		return err
+	}
+	for {
+		_, typ, id, err := p.ReadFieldBegin(ctx)
+		if err != nil {
+			return err
+		}
+		if typ == thrift.STOP {
+			break
+		}
+		if err := fn(id, typ); err != nil {
+			return err
+		}
+		if err := p.ReadFieldEnd(ctx); err != nil {
+			return err
+		}
+	}
+	return p.ReadStructEnd(ctx)
+}
+
+func readThriftList(ctx context.Context, p thrift.TProtocol, fn func() error) error {
+	_, size, err := p.ReadListBegin(ctx)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < size; i++ {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return p.ReadListEnd(ctx)
+}
+
+// protobuf, 参考: https://github.com/jaegertracing/jaeger-idl/blob/main/proto/api_v2/model.proto
+
+func decodeJaegerProtoBatch(data []byte) (process *jaegerProcess, spans []*jaegerSpan, err error) {
+	err = walkProtoFields(data, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			span, err := decodeJaegerProtoSpan(v)
+			if err != nil {
+				return err
+			}
+			spans = append(spans, span)
+		case 2:
+			process, err = decodeJaegerProtoProcess(v)
+			return err
+		}
+		return nil
+	})
+	return
+}
+
+func decodeJaegerProtoSpan(data []byte) (*jaegerSpan, error) {
+	s := &jaegerSpan{}
+	err := walkProtoFields(data, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) (err error) {
+		switch num {
+		case 1:
+			s.TraceIDHigh, s.TraceIDLow = jaegerProtoTraceID(v)
+		case 2:
+			s.SpanID = jaegerProtoSpanID(v)
+		case 3:
+			s.OperationName = string(v)
+		case 4:
+			ref := jaegerSpanRef{}
+			err = walkProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+				switch num {
+				case 1:
+					ref.TraceIDHigh, ref.TraceIDLow = jaegerProtoTraceID(v)
+				case 2:
+					ref.SpanID = jaegerProtoSpanID(v)
+				case 3:
+					ref.RefType = int32(n)
+				}
+				return nil
+			})
+			s.References = append(s.References, ref)
+		case 6:
+			s.StartTime, err = decodeProtoTimestamp(v)
+		case 7:
+			s.Duration, err = decodeProtoTimestamp(v)
+		case 8:
+			var tag *v11.KeyValue
+			if tag, err = decodeJaegerProtoKeyValue(v); err == nil {
+				s.Tags = append(s.Tags, tag)
+			}
+		case 9:
+			var timestamp uint64
+			var fields []*v11.KeyValue
+			err = walkProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) (err error) {
+				switch num {
+				case 1:
+					timestamp, err = decodeProtoTimestamp(v)
+				case 2:
+					var field *v11.KeyValue
+					if field, err = decodeJaegerProtoKeyValue(v); err == nil {
+						fields = append(fields, field)
+					}
+				}
+				return
+			})
+			s.Logs = append(s.Logs, newJaegerEvent(timestamp, fields))
+		case 10:
+			s.Process, err = decodeJaegerProtoProcess(v)
+		}
+		return
+	})
+	return s, err
+}
+
+func decodeJaegerProtoProcess(data []byte) (*jaegerProcess, error) {
+	process := &jaegerProcess{}
+	err := walkProtoFields(data, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+		switch num {
+		case 1:
+			process.ServiceName = string(v)
+		case 2:
+			tag, err := decodeJaegerProtoKeyValue(v)
+			if err != nil {
+				return err
+			}
+			process.Tags = append(process.Tags, tag)
+		}
+		return nil
+	})
+	return process, err
+}
+
+func decodeJaegerProtoKeyValue(data []byte) (*v11.KeyValue, error) {
+	var key, vStr string
+	var vType, vInt64, vFloat64 uint64
+	var vBool bool
+	var vBinary []byte
+	err := walkProtoFields(data, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			key = string(v)
+		case 2:
+			vType = n
+		case 3:
+			vStr = string(v)
+		case 4:
+			vBool = n != 0
+		case 5:
+			vInt64 = n
+		case 6:
+			vFloat64 = n
+		case 7:
+			vBinary = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch vType {
+	case jaegerProtoTagBool:
+		return boolKeyValue(key, vBool), nil
+	case jaegerProtoTagInt64:
+		return intKeyValue(key, int64(vInt64)), nil
+	case jaegerProtoTagFloat64:
+		return doubleKeyValue(key, math.Float64frombits(vFloat64)), nil
+	case jaegerProtoTagBinary:
+		return bytesKeyValue(key, vBinary), nil
+	default:
+		return stringKeyValue(key, vStr), nil
+	}
+}
+
+// google.protobuf.Timestamp 和 google.protobuf.Duration 的编码相同, 返回纳秒
+func decodeProtoTimestamp(data []byte) (uint64, error) {
+	var seconds, nanos uint64
+	err := walkProtoFields(data, func(num protowire.Number, _ protowire.Type, _ []byte, n uint64) error {
+		switch num {
+		case 1:
+			seconds = n
+		case 2:
+			nanos = n
+		}
+		return nil
+	})
+	return seconds*1000000000 + nanos, err
+}
+
+// protobuf 中 trace id 固定为 16 字节(high, low), span id 为 8 字节, 均为网络字节序
+func jaegerProtoTraceID(v []byte) (high, low uint64) {
+	switch len(v) {
+	case 16:
+		return binary.BigEndian.Uint64(v[:8]), binary.BigEndian.Uint64(v[8:])
+	case 8:
+		return 0, binary.BigEndian.Uint64(v)
+	}
+	return 0, 0
+}
+
+func jaegerProtoSpanID(v []byte) uint64 {
+	if len(v) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(v)
+}
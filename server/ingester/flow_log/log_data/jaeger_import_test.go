@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log_data
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	v1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type thriftTag struct {
+	key   string
+	vType int32
+	vStr  string
+	vLong int64
+	vBool bool
+}
+
+func writeThriftTags(ctx context.Context, p thrift.TProtocol, id int16, tags []thriftTag) {
+	p.WriteFieldBegin(ctx, "", thrift.LIST, id)
+	p.WriteListBegin(ctx, thrift.STRUCT, len(tags))
+	for _, tag := range tags {
+		p.WriteStructBegin(ctx, "Tag")
+		p.WriteFieldBegin(ctx, "key", thrift.STRING, 1)
+		p.WriteString(ctx, tag.key)
+		p.WriteFieldEnd(ctx)
+		p.WriteFieldBegin(ctx, "vType", thrift.I32, 2)
+		p.WriteI32(ctx, tag.vType)
+		p.WriteFieldEnd(ctx)
+		switch tag.vType {
+		case jaegerThriftTagString:
+			p.WriteFieldBegin(ctx, "vStr", thrift.STRING, 3)
+			p.WriteString(ctx, tag.vStr)
+		case jaegerThriftTagBool:
+			p.WriteFieldBegin(ctx, "vBool", thrift.BOOL, 5)
+			p.WriteBool(ctx, tag.vBool)
+		case jaegerThriftTagLong:
+			p.WriteFieldBegin(ctx, "vLong", thrift.I64, 6)
+			p.WriteI64(ctx, tag.vLong)
+		}
+		p.WriteFieldEnd(ctx)
+		p.WriteFieldStop(ctx)
+		p.WriteStructEnd(ctx)
+	}
+	p.WriteListEnd(ctx)
+	p.WriteFieldEnd(ctx)
+}
+
+func writeThriftI64(ctx context.Context, p thrift.TProtocol, id int16, v int64) {
+	p.WriteFieldBegin(ctx, "", thrift.I64, id)
+	p.WriteI64(ctx, v)
+	p.WriteFieldEnd(ctx)
+}
+
+// writeJaegerThriftBatch writes a Batch with one client span and one server span which references it
+func writeJaegerThriftBatch(ctx context.Context, p thrift.TProtocol) {
+	p.WriteStructBegin(ctx, "Batch")
+	p.WriteFieldBegin(ctx, "process", thrift.STRUCT, 1)
+	p.WriteStructBegin(ctx, "Process")
+	p.WriteFieldBegin(ctx, "serviceName", thrift.STRING, 1)
+	p.WriteString(ctx, "frontend")
+	p.WriteFieldEnd(ctx)
+	writeThriftTags(ctx, p, 2, []thriftTag{{key: "ip", vType: jaegerThriftTagString, vStr: "10.1.2.3"}})
+	p.WriteFieldStop(ctx)
+	p.WriteStructEnd(ctx)
+	p.WriteFieldEnd(ctx)
+
+	p.WriteFieldBegin(ctx, "spans", thrift.LIST, 2)
+	p.WriteListBegin(ctx, thrift.STRUCT, 2)
+	// client span
+	p.WriteStructBegin(ctx, "Span")
+	writeThriftI64(ctx, p, 1, 0x1234)
+	writeThriftI64(ctx, p, 2, 0)
+	writeThriftI64(ctx, p, 3, 0x10)
+	writeThriftI64(ctx, p, 4, 0)
+	p.WriteFieldBegin(ctx, "operationName", thrift.STRING, 5)
+	p.WriteString(ctx, "HTTP GET")
+	p.WriteFieldEnd(ctx)
+	writeThriftI64(ctx, p, 8, 1000)
+	writeThriftI64(ctx, p, 9, 50)
+	writeThriftTags(ctx, p, 10, []thriftTag{
+		{key: "span.kind", vType: jaegerThriftTagString, vStr: "client"},
+		{key: "http.status_code", vType: jaegerThriftTagLong, vLong: 500},
+		{key: "error", vType: jaegerThriftTagBool, vBool: true},
+		{key: "peer.ipv4", vType: jaegerThriftTagLong, vLong: 0x0a000002},
+	})
+	p.WriteFieldStop(ctx)
+	p.WriteStructEnd(ctx)
+	// server span, parent comes from CHILD_OF reference
+	p.WriteStructBegin(ctx, "Span")
+	writeThriftI64(ctx, p, 1, 0x1234)
+	writeThriftI64(ctx, p, 2, 0)
+	writeThriftI64(ctx, p, 3, 0x20)
+	writeThriftI64(ctx, p, 4, 0)
+	p.WriteFieldBegin(ctx, "references", thrift.LIST, 6)
+	p.WriteListBegin(ctx, thrift.STRUCT, 1)
+	p.WriteStructBegin(ctx, "SpanRef")
+	p.WriteFieldBegin(ctx, "refType", thrift.I32, 1)
+	p.WriteI32(ctx, jaegerRefChildOf)
+	p.WriteFieldEnd(ctx)
+	writeThriftI64(ctx, p, 2, 0x1234)
+	writeThriftI64(ctx, p, 3, 0)
+	writeThriftI64(ctx, p, 4, 0x10)
+	p.WriteFieldStop(ctx)
+	p.WriteStructEnd(ctx)
+	p.WriteListEnd(ctx)
+	p.WriteFieldEnd(ctx)
+	writeThriftTags(ctx, p, 10, []thriftTag{{key: "span.kind", vType: jaegerThriftTagString, vStr: "server"}})
+	p.WriteFieldBegin(ctx, "logs", thrift.LIST, 11)
+	p.WriteListBegin(ctx, thrift.STRUCT, 1)
+	p.WriteStructBegin(ctx, "Log")
+	writeThriftI64(ctx, p, 1, 1010)
+	writeThriftTags(ctx, p, 2, []thriftTag{
+		{key: "event", vType: jaegerThriftTagString, vStr: "retry"},
+		{key: "attempt", vType: jaegerThriftTagLong, vLong: 2},
+	})
+	p.WriteFieldStop(ctx)
+	p.WriteStructEnd(ctx)
+	p.WriteListEnd(ctx)
+	p.WriteFieldEnd(ctx)
+	p.WriteFieldStop(ctx)
+	p.WriteStructEnd(ctx)
+	p.WriteListEnd(ctx)
+	p.WriteFieldEnd(ctx)
+
+	p.WriteFieldStop(ctx)
+	p.WriteStructEnd(ctx)
+}
+
+func checkJaegerThriftTracesData(t *testing.T, tracesData *v1.TracesData) {
+	if len(tracesData.ResourceSpans) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(tracesData.ResourceSpans))
+	}
+	resource := attributesToMap(tracesData.ResourceSpans[0].Resource.Attributes)
+	if resource["service.name"] != "frontend" || resource["app.host.ip"] != "10.1.2.3" {
+		t.Errorf("unexpected resource attributes %v", resource)
+	}
+	spans := tracesData.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	client, server := spans[0], spans[1]
+	if hex.EncodeToString(client.TraceId) != "0000000000001234" || hex.EncodeToString(client.SpanId) != "0000000000000010" || len(client.ParentSpanId) != 0 {
+		t.Errorf("unexpected client ids %x %x %x", client.TraceId, client.SpanId, client.ParentSpanId)
+	}
+	if client.Kind != v1.Span_SPAN_KIND_CLIENT || client.Status.GetCode() != v1.Status_STATUS_CODE_ERROR {
+		t.Errorf("unexpected client kind %s status %v", client.Kind, client.Status)
+	}
+	if client.StartTimeUnixNano != 1000000 || client.EndTimeUnixNano != 1050000 {
+		t.Errorf("unexpected client time %d-%d", client.StartTimeUnixNano, client.EndTimeUnixNano)
+	}
+	attrs := attributesToMap(client.Attributes)
+	if attrs["http.status_code"] != "500" || attrs["net.peer.ip"] != "10.0.0.2" {
+		t.Errorf("unexpected client attributes %v", attrs)
+	}
+
+	if hex.EncodeToString(server.ParentSpanId) != "0000000000000010" || server.Kind != v1.Span_SPAN_KIND_SERVER || server.Status != nil {
+		t.Errorf("unexpected server span %v", server)
+	}
+	if len(server.Events) != 1 || server.Events[0].Name != "retry" || server.Events[0].TimeUnixNano != 1010000 || len(server.Events[0].Attributes) != 1 {
+		t.Errorf("unexpected server events %v", server.Events)
+	}
+}
+
+func TestJaegerThriftToTracesData(t *testing.T) {
+	ctx := context.Background()
+	for name, newProtocol := range map[string]func(thrift.TTransport) thrift.TProtocol{
+		"binary":  func(t thrift.TTransport) thrift.TProtocol { return thrift.NewTBinaryProtocolConf(t, nil) },
+		"compact": func(t thrift.TTransport) thrift.TProtocol { return thrift.NewTCompactProtocolConf(t, nil) },
+	} {
+		buffer := thrift.NewTMemoryBuffer()
+		writeJaegerThriftBatch(ctx, newProtocol(buffer))
+		tracesData, err := JaegerToTracesData(buffer.Bytes())
+		if err != nil {
+			t.Fatalf("%s batch: %s", name, err)
+		}
+		checkJaegerThriftTracesData(t, tracesData)
+
+		// Agent.emitBatch message sent by jaeger client over UDP
+		buffer = thrift.NewTMemoryBuffer()
+		p := newProtocol(buffer)
+		p.WriteMessageBegin(ctx, "emitBatch", thrift.ONEWAY, 1)
+		p.WriteStructBegin(ctx, "emitBatch_args")
+		p.WriteFieldBegin(ctx, "batch", thrift.STRUCT, 1)
+		writeJaegerThriftBatch(ctx, p)
+		p.WriteFieldEnd(ctx)
+		p.WriteFieldStop(ctx)
+		p.WriteStructEnd(ctx)
+		p.WriteMessageEnd(ctx)
+		tracesData, err = JaegerToTracesData(buffer.Bytes())
+		if err != nil {
+			t.Fatalf("%s message: %s", name, err)
+		}
+		checkJaegerThriftTracesData(t, tracesData)
+	}
+}
+
+func TestJaegerProtoToTracesData(t *testing.T) {
+	keyValue := func(key, value string) []byte {
+		b := protowire.AppendTag(nil, 1, protowire.BytesType)
+		b = protowire.AppendString(b, key)
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		return protowire.AppendString(b, value)
+	}
+	timestamp := func(seconds, nanos uint64) []byte {
+		b := protowire.AppendTag(nil, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, seconds)
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		return protowire.AppendVarint(b, nanos)
+	}
+
+	process := protowire.AppendTag(nil, 1, protowire.BytesType)
+	process = protowire.AppendString(process, "backend")
+
+	span := protowire.AppendTag(nil, 1, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2})
+	span = protowire.AppendTag(span, 2, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 3})
+	span = protowire.AppendTag(span, 3, protowire.BytesType)
+	span = protowire.AppendString(span, "query")
+	span = protowire.AppendTag(span, 6, protowire.BytesType)
+	span = protowire.AppendBytes(span, timestamp(10, 500))
+	span = protowire.AppendTag(span, 7, protowire.BytesType)
+	span = protowire.AppendBytes(span, timestamp(0, 1000))
+	span = protowire.AppendTag(span, 8, protowire.BytesType)
+	span = protowire.AppendBytes(span, keyValue("span.kind", "server"))
+	span = protowire.AppendTag(span, 8, protowire.BytesType)
+	span = protowire.AppendBytes(span, keyValue("otel.status_code", "OK"))
+
+	batch := protowire.AppendTag(nil, 1, protowire.BytesType)
+	batch = protowire.AppendBytes(batch, span)
+	batch = protowire.AppendTag(batch, 2, protowire.BytesType)
+	batch = protowire.AppendBytes(batch, process)
+
+	tracesData, err := JaegerToTracesData(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource := attributesToMap(tracesData.ResourceSpans[0].Resource.Attributes); resource["service.name"] != "backend" {
+		t.Errorf("unexpected resource attributes %v", resource)
+	}
+	s := tracesData.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if hex.EncodeToString(s.TraceId) != "00000000000000010000000000000002" || hex.EncodeToString(s.SpanId) != "0000000000000003" {
+		t.Errorf("unexpected ids %x %x", s.TraceId, s.SpanId)
+	}
+	if s.Kind != v1.Span_SPAN_KIND_SERVER || s.Status.GetCode() != v1.Status_STATUS_CODE_OK {
+		t.Errorf("unexpected kind %s status %v", s.Kind, s.Status)
+	}
+	if s.StartTimeUnixNano != 10000000500 || s.EndTimeUnixNano != 10000001500 {
+		t.Errorf("unexpected time %d-%d", s.StartTimeUnixNano, s.EndTimeUnixNano)
+	}
+}
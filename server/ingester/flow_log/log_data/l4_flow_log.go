@@ -220,16 +220,60 @@ func (a *ApplicationLayer) WriteBlock(block *ckdb.Block) {
 type Internet struct {
 	Province0 string `json:"province_0" category:"$tag" sub:"network_layer"`
 	Province1 string `json:"province_1" category:"$tag" sub:"network_layer"`
+	GlobalGeo
 }
 
-var InternetColumns = []*ckdb.Column{
+var InternetColumns = append([]*ckdb.Column{
 	// 广域网
 	ckdb.NewColumn("province_0", ckdb.LowCardinalityString),
 	ckdb.NewColumn("province_1", ckdb.LowCardinalityString),
-}
+}, GlobalGeoColumns...)
 
 func (i *Internet) WriteBlock(block *ckdb.Block) {
 	block.Write(i.Province0, i.Province1)
+	i.GlobalGeo.WriteBlock(block)
+}
+
+// GlobalGeo is the info of IPs in the global GeoIP and ASN databases, it supports IPv6 and IPs outside China
+type GlobalGeo struct {
+	Country0 string `json:"country_0" category:"$tag" sub:"network_layer"`
+	Country1 string `json:"country_1" category:"$tag" sub:"network_layer"`
+	City0    string `json:"city_0" category:"$tag" sub:"network_layer"`
+	City1    string `json:"city_1" category:"$tag" sub:"network_layer"`
+	ASN0     uint32 `json:"asn_0" category:"$tag" sub:"network_layer"`
+	ASN1     uint32 `json:"asn_1" category:"$tag" sub:"network_layer"`
+	ASOrg0   string `json:"as_org_0" category:"$tag" sub:"network_layer"`
+	ASOrg1   string `json:"as_org_1" category:"$tag" sub:"network_layer"`
+}
+
+var GlobalGeoColumns = []*ckdb.Column{
+	ckdb.NewColumn("country_0", ckdb.LowCardinalityString).SetComment("ISO 3166-1 alpha-2 code"),
+	ckdb.NewColumn("country_1", ckdb.LowCardinalityString).SetComment("ISO 3166-1 alpha-2 code"),
+	ckdb.NewColumn("city_0", ckdb.LowCardinalityString),
+	ckdb.NewColumn("city_1", ckdb.LowCardinalityString),
+	ckdb.NewColumn("asn_0", ckdb.UInt32).SetComment("autonomous system number"),
+	ckdb.NewColumn("asn_1", ckdb.UInt32).SetComment("autonomous system number"),
+	ckdb.NewColumn("as_org_0", ckdb.LowCardinalityString).SetComment("autonomous system organization"),
+	ckdb.NewColumn("as_org_1", ckdb.LowCardinalityString).SetComment("autonomous system organization"),
+}
+
+func (g *GlobalGeo) WriteBlock(block *ckdb.Block) {
+	block.Write(
+		g.Country0,
+		g.Country1,
+		g.City0,
+		g.City1,
+		g.ASN0,
+		g.ASN1,
+		g.ASOrg0,
+		g.ASOrg1)
+}
+
+func (g *GlobalGeo) Fill(isIPv6 bool, ip40, ip41 uint32, ip60, ip61 net.IP) {
+	info0 := geo.QueryIPGeo(isIPv6, ip40, ip60)
+	info1 := geo.QueryIPGeo(isIPv6, ip41, ip61)
+	g.Country0, g.City0, g.ASN0, g.ASOrg0 = info0.Country, info0.City, info0.ASN, info0.ASOrganization
+	g.Country1, g.City1, g.ASN1, g.ASOrg1 = info1.Country, info1.City, info1.ASN, info1.ASOrganization
 }
 
 type KnowledgeGraph struct {
@@ -702,9 +746,10 @@ func (a *ApplicationLayer) Fill(f *pb.Flow) {
 	}
 }
 
-func (i *Internet) Fill(f *pb.Flow) {
+func (i *Internet) Fill(f *pb.Flow, isIPV6 bool) {
 	i.Province0 = geo.QueryProvince(f.FlowKey.IpSrc)
 	i.Province1 = geo.QueryProvince(f.FlowKey.IpDst)
+	i.GlobalGeo.Fill(isIPV6, f.FlowKey.IpSrc, f.FlowKey.IpDst, f.FlowKey.Ip6Src, f.FlowKey.Ip6Dst)
 }
 
 func isLocalIP(isIPv6 bool, ip4 uint32, ip6 net.IP) bool {
@@ -726,7 +771,8 @@ func (k *KnowledgeGraph) fill(
 	vtapId uint16, podId0, podId1 uint32,
 	port uint16,
 	tapSide uint32,
-	protocol layers.IPProtocol) {
+	protocol layers.IPProtocol,
+	timestamp uint32) {
 
 	var info0, info1, agentInfo *grpc.Info
 
@@ -793,47 +839,47 @@ func (k *KnowledgeGraph) fill(
 	if info0 == nil {
 		if lookupByMac0 {
 			k.TagSource0 |= uint8(flow_metrics.Mac)
-			info0 = platformData.QueryMacInfo(k.OrgId, l3EpcMac0)
+			info0 = platformData.QueryMacInfo(k.OrgId, l3EpcMac0, timestamp)
 		} else if lookupByAgent0 {
 			k.TagSource0 |= uint8(flow_metrics.Agent)
 			if info := platformData.QueryVtapInfo(k.OrgId, vtapId); info != nil {
-				agentInfo = common.RegetInfoFromIP(k.OrgId, !info.IsIPv4, info.IP6, info.IP4, info.EpcId, platformData)
+				agentInfo = common.RegetInfoFromIP(k.OrgId, !info.IsIPv4, info.IP6, info.IP4, info.EpcId, timestamp, platformData)
 				info0 = agentInfo
 			}
 		}
 		if info0 == nil {
 			k.TagSource0 |= uint8(flow_metrics.EpcIP)
-			info0 = common.RegetInfoFromIP(k.OrgId, isIPv6, ip60, ip40, l3EpcID0, platformData)
+			info0 = common.RegetInfoFromIP(k.OrgId, isIPv6, ip60, ip40, l3EpcID0, timestamp, platformData)
 		}
 	}
 
 	if info1 == nil {
 		if lookupByMac1 {
 			k.TagSource1 |= uint8(flow_metrics.Mac)
-			info1 = platformData.QueryMacInfo(k.OrgId, l3EpcMac1)
+			info1 = platformData.QueryMacInfo(k.OrgId, l3EpcMac1, timestamp)
 		} else if lookupByAgent1 {
 			k.TagSource1 |= uint8(flow_metrics.Agent)
 			if lookupByAgent0 && agentInfo != nil {
 				info1 = agentInfo
 			} else {
 				if info := platformData.QueryVtapInfo(k.OrgId, vtapId); info != nil {
-					info1 = common.RegetInfoFromIP(k.OrgId, !info.IsIPv4, info.IP6, info.IP4, info.EpcId, platformData)
+					info1 = common.RegetInfoFromIP(k.OrgId, !info.IsIPv4, info.IP6, info.IP4, info.EpcId, timestamp, platformData)
 				}
 			}
 		}
 		if info1 == nil {
 			k.TagSource1 |= uint8(flow_metrics.EpcIP)
-			info1 = common.RegetInfoFromIP(k.OrgId, isIPv6, ip61, ip41, l3EpcID1, platformData)
+			info1 = common.RegetInfoFromIP(k.OrgId, isIPv6, ip61, ip41, l3EpcID1, timestamp, platformData)
 		}
 	}
 
 	var l2Info0, l2Info1 *grpc.Info
 	if l3EpcID0 > 0 && l3EpcID1 > 0 {
-		l2Info0, l2Info1 = platformData.QueryMacInfosPair(k.OrgId, l3EpcMac0, l3EpcMac1)
+		l2Info0, l2Info1 = platformData.QueryMacInfosPair(k.OrgId, l3EpcMac0, l3EpcMac1, timestamp)
 	} else if l3EpcID0 > 0 {
-		l2Info0 = platformData.QueryMacInfo(k.OrgId, l3EpcMac0)
+		l2Info0 = platformData.QueryMacInfo(k.OrgId, l3EpcMac0, timestamp)
 	} else if l3EpcID1 > 0 {
-		l2Info1 = platformData.QueryMacInfo(k.OrgId, l3EpcMac1)
+		l2Info1 = platformData.QueryMacInfo(k.OrgId, l3EpcMac1, timestamp)
 	}
 
 	if info0 != nil {
@@ -899,7 +945,8 @@ func (k *KnowledgeGraph) FillL4(f *pb.Flow, isIPv6 bool, platformData *grpc.Plat
 		uint16(f.FlowKey.VtapId), 0, 0,
 		uint16(f.FlowKey.PortDst),
 		f.TapSide,
-		layers.IPProtocol(f.FlowKey.Proto))
+		layers.IPProtocol(f.FlowKey.Proto),
+		uint32(f.EndTime/uint64(time.Second)))
 }
 
 func getStatus(t datatype.CloseType, p layers.IPProtocol) datatype.LogMessageStatus {
@@ -1105,7 +1152,7 @@ func TaggedFlowToL4FlowLog(orgId, teamId uint16, f *pb.TaggedFlow, platformData
 	s.NetworkLayer.Fill(f.Flow, isIPV6)
 	s.TransportLayer.Fill(f.Flow)
 	s.ApplicationLayer.Fill(f.Flow)
-	s.Internet.Fill(f.Flow)
+	s.Internet.Fill(f.Flow, isIPV6)
 	s.KnowledgeGraph.FillL4(f.Flow, isIPV6, platformData)
 	s.FlowInfo.Fill(f.Flow)
 	s.Metrics.Fill(f.Flow)
@@ -44,6 +44,7 @@ var log = logging.MustGetLogger("flow_log.log_data")
 type L7Base struct {
 	// 知识图谱
 	KnowledgeGraph
+	GlobalGeo
 
 	Time uint32 `json:"time" category:"$tag" sub:"flow_info"` // s
 	// 网络层
@@ -142,6 +143,7 @@ func L7BaseColumns() []*ckdb.Column {
 		ckdb.NewColumn("syscall_cap_seq_0", ckdb.UInt32).SetComment("Syscall序列号-请求"),
 		ckdb.NewColumn("syscall_cap_seq_1", ckdb.UInt32).SetComment("Syscall序列号-响应"),
 	)
+	columns = append(columns, GlobalGeoColumns...)
 
 	return columns
 }
@@ -189,6 +191,7 @@ func (f *L7Base) WriteBlock(block *ckdb.Block) {
 		f.SyscallCoroutine1,
 		f.SyscallCapSeq0,
 		f.SyscallCapSeq1)
+	f.GlobalGeo.WriteBlock(block)
 }
 
 type L7FlowLog struct {
@@ -570,6 +573,7 @@ func (b *L7Base) Fill(log *pb.AppProtoLogsData, platformData *grpc.PlatformInfoT
 		b.IP40 = l.IpSrc
 		b.IP41 = l.IpDst
 	}
+	b.GlobalGeo.Fill(!b.IsIPv4, b.IP40, b.IP41, b.IP60, b.IP61)
 
 	// 传输层
 	b.ClientPort = uint16(l.PortSrc)
@@ -635,6 +639,7 @@ func (k *KnowledgeGraph) FillL7(l *pb.AppProtoLogsBaseInfo, platformData *grpc.P
 		uint16(l.PortDst),
 		l.TapSide,
 		protocol,
+		uint32(l.EndTime/uint64(time.Second)),
 	)
 }
 
@@ -0,0 +1,386 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log_data
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	flowlogCfg "github.com/deepflowio/deepflow/server/ingester/flow_log/config"
+	"github.com/deepflowio/deepflow/server/libs/grpc"
+
+	json "github.com/goccy/go-json"
+	v11 "go.opentelemetry.io/proto/otlp/common/v1"
+	v12 "go.opentelemetry.io/proto/otlp/resource/v1"
+	v1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Zipkin v2 span, 参考: https://github.com/openzipkin/zipkin-api/blob/master/zipkin2-api.yaml
+type zipkinSpan struct {
+	TraceID        string             `json:"traceId"`
+	ParentID       string             `json:"parentId"`
+	ID             string             `json:"id"`
+	Kind           string             `json:"kind"`
+	Name           string             `json:"name"`
+	Timestamp      uint64             `json:"timestamp"` // us
+	Duration       uint64             `json:"duration"`  // us
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnotation `json:"annotations"`
+	Tags           map[string]string  `json:"tags"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int32  `json:"port"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp uint64 `json:"timestamp"` // us
+	Value     string `json:"value"`
+}
+
+// ZipkinSpansToL7FlowLogs converts a Zipkin v2 span list (JSON or Proto3 ListOfSpans) into l7 flow logs.
+func ZipkinSpansToL7FlowLogs(vtapID, orgId, teamId uint16, data []byte, platformData *grpc.PlatformInfoTable, cfg *flowlogCfg.Config) ([]*L7FlowLog, error) {
+	tracesData, err := ZipkinToTracesData(data)
+	if err != nil {
+		return nil, err
+	}
+	return OTelTracesDataToL7FlowLogs(vtapID, orgId, teamId, tracesData, platformData, cfg), nil
+}
+
+// ZipkinToTracesData converts Zipkin v2 spans to OTLP, so that they are stored the same way as OpenTelemetry spans.
+// JSON payloads always start with '[', otherwise the payload is decoded as Proto3 ListOfSpans.
+func ZipkinToTracesData(data []byte) (*v1.TracesData, error) {
+	var spans []*zipkinSpan
+	var err error
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal([]byte(trimmed), &spans)
+	} else {
+		spans, err = decodeZipkinProto(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("zipkin spans decode failed: %s", err)
+	}
+
+	resourceSpans := newResourceSpansBuilder()
+	for _, s := range spans {
+		span, err := s.toOTel()
+		if err != nil {
+			return nil, err
+		}
+		resourceSpans.add(s.resourceAttributes(), span)
+	}
+	return &v1.TracesData{ResourceSpans: resourceSpans.list}, nil
+}
+
+func (s *zipkinSpan) resourceAttributes() []*v11.KeyValue {
+	if s.LocalEndpoint == nil {
+		return nil
+	}
+	var attrs []*v11.KeyValue
+	if s.LocalEndpoint.ServiceName != "" {
+		attrs = append(attrs, stringKeyValue("service.name", s.LocalEndpoint.ServiceName))
+	}
+	if ip := s.LocalEndpoint.ip(); ip != "" {
+		attrs = append(attrs, stringKeyValue("app.host.ip", ip))
+	}
+	return attrs
+}
+
+func (e *zipkinEndpoint) ip() string {
+	if e.IPv4 != "" {
+		return e.IPv4
+	}
+	return e.IPv6
+}
+
+func zipkinKindToSpanKind(kind string) v1.Span_SpanKind {
+	switch strings.ToUpper(kind) {
+	case "CLIENT":
+		return v1.Span_SPAN_KIND_CLIENT
+	case "SERVER":
+		return v1.Span_SPAN_KIND_SERVER
+	case "PRODUCER":
+		return v1.Span_SPAN_KIND_PRODUCER
+	case "CONSUMER":
+		return v1.Span_SPAN_KIND_CONSUMER
+	default:
+		// a span without kind is a local span in zipkin
+		return v1.Span_SPAN_KIND_INTERNAL
+	}
+}
+
+func (s *zipkinSpan) toOTel() (*v1.Span, error) {
+	// trace id 保持原始长度(16或32个hex字符), 与B3 header中传递的值一致, 才能和eBPF采集的调用关联
+	traceID, err := hex.DecodeString(strings.ToLower(s.TraceID))
+	if err != nil || len(traceID) == 0 {
+		return nil, fmt.Errorf("invalid zipkin trace id '%s'", s.TraceID)
+	}
+	spanID, err := hex.DecodeString(strings.ToLower(s.ID))
+	if err != nil || len(spanID) == 0 {
+		return nil, fmt.Errorf("invalid zipkin span id '%s'", s.ID)
+	}
+	parentID, err := hex.DecodeString(strings.ToLower(s.ParentID))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zipkin parent id '%s'", s.ParentID)
+	}
+
+	span := &v1.Span{
+		TraceId:           traceID,
+		SpanId:            spanID,
+		ParentSpanId:      parentID,
+		Name:              s.Name,
+		Kind:              zipkinKindToSpanKind(s.Kind),
+		StartTimeUnixNano: s.Timestamp * 1000,
+		EndTimeUnixNano:   (s.Timestamp + s.Duration) * 1000,
+		Status:            &v1.Status{},
+	}
+
+	for k, v := range s.Tags {
+		switch k {
+		case "error":
+			span.Status.Code = v1.Status_STATUS_CODE_ERROR
+			span.Status.Message = v
+		case "http.path":
+			// zipkin 使用 http.path 记录请求路径, 对应 OTel 的 http.target
+			span.Attributes = append(span.Attributes, stringKeyValue("http.target", v))
+		}
+		span.Attributes = append(span.Attributes, stringKeyValue(k, v))
+	}
+	if r := s.RemoteEndpoint; r != nil {
+		if ip := r.ip(); ip != "" {
+			span.Attributes = append(span.Attributes, stringKeyValue("net.peer.ip", ip))
+		}
+		if r.Port != 0 {
+			span.Attributes = append(span.Attributes, intKeyValue("net.peer.port", int64(r.Port)))
+		}
+		if r.ServiceName != "" {
+			span.Attributes = append(span.Attributes, stringKeyValue("peer.service", r.ServiceName))
+		}
+	}
+	for _, a := range s.Annotations {
+		span.Events = append(span.Events, &v1.Span_Event{TimeUnixNano: a.Timestamp * 1000, Name: a.Value})
+	}
+	return span, nil
+}
+
+func decodeZipkinProto(data []byte) ([]*zipkinSpan, error) {
+	var spans []*zipkinSpan
+	err := walkProtoFields(data, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		span, err := decodeZipkinProtoSpan(v)
+		if err != nil {
+			return err
+		}
+		spans = append(spans, span)
+		return nil
+	})
+	return spans, err
+}
+
+var zipkinProtoKinds = []string{"", "CLIENT", "SERVER", "PRODUCER", "CONSUMER"}
+
+func decodeZipkinProtoSpan(data []byte) (*zipkinSpan, error) {
+	s := &zipkinSpan{}
+	err := walkProtoFields(data, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			s.TraceID = hex.EncodeToString(v)
+		case 2:
+			s.ParentID = hex.EncodeToString(v)
+		case 3:
+			s.ID = hex.EncodeToString(v)
+		case 4:
+			if n < uint64(len(zipkinProtoKinds)) {
+				s.Kind = zipkinProtoKinds[n]
+			}
+		case 5:
+			s.Name = string(v)
+		case 6:
+			s.Timestamp = n
+		case 7:
+			s.Duration = n
+		case 8, 9:
+			e, err := decodeZipkinProtoEndpoint(v)
+			if err != nil {
+				return err
+			}
+			if num == 8 {
+				s.LocalEndpoint = e
+			} else {
+				s.RemoteEndpoint = e
+			}
+		case 10:
+			a := zipkinAnnotation{}
+			if err := walkProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+				switch num {
+				case 1:
+					a.Timestamp = n
+				case 2:
+					a.Value = string(v)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			s.Annotations = append(s.Annotations, a)
+		case 11:
+			var key, value string
+			if err := walkProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+				switch num {
+				case 1:
+					key = string(v)
+				case 2:
+					value = string(v)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			if s.Tags == nil {
+				s.Tags = make(map[string]string)
+			}
+			s.Tags[key] = value
+		}
+		return nil
+	})
+	return s, err
+}
+
+func decodeZipkinProtoEndpoint(data []byte) (*zipkinEndpoint, error) {
+	e := &zipkinEndpoint{}
+	err := walkProtoFields(data, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			e.ServiceName = string(v)
+		case 2:
+			if len(v) == net.IPv4len {
+				e.IPv4 = net.IP(v).String()
+			}
+		case 3:
+			if len(v) == net.IPv6len {
+				e.IPv6 = net.IP(v).String()
+			}
+		case 4:
+			e.Port = int32(n)
+		}
+		return nil
+	})
+	return e, err
+}
+
+// walkProtoFields iterates over the fields of a protobuf message without generated code,
+// length-delimited values are passed as v, varint/fixed values as n.
+func walkProtoFields(data []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error) error {
+	for len(data) > 0 {
+		num, typ, l := protowire.ConsumeTag(data)
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		data = data[l:]
+		var v []byte
+		var n uint64
+		switch typ {
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(data)
+		case protowire.Fixed32Type:
+			var n32 uint32
+			n32, l = protowire.ConsumeFixed32(data)
+			n = uint64(n32)
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(data)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		data = data[l:]
+		if err := fn(num, typ, v, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resourceSpansBuilder groups spans of the same resource into one ResourceSpans, keeping the input order
+type resourceSpansBuilder struct {
+	index map[string]*v1.ScopeSpans
+	list  []*v1.ResourceSpans
+}
+
+func newResourceSpansBuilder() *resourceSpansBuilder {
+	return &resourceSpansBuilder{index: make(map[string]*v1.ScopeSpans)}
+}
+
+func (b *resourceSpansBuilder) add(attrs []*v11.KeyValue, span *v1.Span) {
+	var key strings.Builder
+	for _, attr := range attrs {
+		key.WriteString(attr.Key)
+		key.WriteByte('=')
+		key.WriteString(getValueString(attr.Value))
+		key.WriteByte(',')
+	}
+	scopeSpans, ok := b.index[key.String()]
+	if !ok {
+		scopeSpans = &v1.ScopeSpans{}
+		b.index[key.String()] = scopeSpans
+		b.list = append(b.list, &v1.ResourceSpans{
+			Resource:   &v12.Resource{Attributes: attrs},
+			ScopeSpans: []*v1.ScopeSpans{scopeSpans},
+		})
+	}
+	scopeSpans.Spans = append(scopeSpans.Spans, span)
+}
+
+func stringKeyValue(key, value string) *v11.KeyValue {
+	return &v11.KeyValue{Key: key, Value: &v11.AnyValue{Value: &v11.AnyValue_StringValue{StringValue: value}}}
+}
+
+func intKeyValue(key string, value int64) *v11.KeyValue {
+	return &v11.KeyValue{Key: key, Value: &v11.AnyValue{Value: &v11.AnyValue_IntValue{IntValue: value}}}
+}
+
+func boolKeyValue(key string, value bool) *v11.KeyValue {
+	return &v11.KeyValue{Key: key, Value: &v11.AnyValue{Value: &v11.AnyValue_BoolValue{BoolValue: value}}}
+}
+
+func doubleKeyValue(key string, value float64) *v11.KeyValue {
+	return &v11.KeyValue{Key: key, Value: &v11.AnyValue{Value: &v11.AnyValue_DoubleValue{DoubleValue: value}}}
+}
+
+func bytesKeyValue(key string, value []byte) *v11.KeyValue {
+	return &v11.KeyValue{Key: key, Value: &v11.AnyValue{Value: &v11.AnyValue_BytesValue{BytesValue: value}}}
+}
+
+// uint64ToID encodes a 64-bit trace/span id in network byte order, as zipkin and jaeger do
+func uint64ToID(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log_data
+
+import (
+	"encoding/hex"
+	"testing"
+
+	v11 "go.opentelemetry.io/proto/otlp/common/v1"
+	v1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestZipkinJSONToTracesData(t *testing.T) {
+	data := []byte(`[{
+		"traceId": "5af7183fb1d4cf5f",
+		"parentId": "6b221d5bc9e6496c",
+		"id": "352bff9a74ca9ad2",
+		"kind": "SERVER",
+		"name": "get /api",
+		"timestamp": 1556604172355737,
+		"duration": 1431,
+		"localEndpoint": {"serviceName": "backend", "ipv4": "192.168.99.1", "port": 3306},
+		"remoteEndpoint": {"ipv4": "172.19.0.2", "port": 58648},
+		"annotations": [{"timestamp": 1556604172355800, "value": "ws"}],
+		"tags": {"http.method": "GET", "http.path": "/api", "error": "timeout"}
+	}]`)
+	tracesData, err := ZipkinToTracesData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracesData.ResourceSpans) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(tracesData.ResourceSpans))
+	}
+	resource := attributesToMap(tracesData.ResourceSpans[0].Resource.Attributes)
+	if resource["service.name"] != "backend" || resource["app.host.ip"] != "192.168.99.1" {
+		t.Errorf("unexpected resource attributes %v", resource)
+	}
+	span := tracesData.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if hex.EncodeToString(span.TraceId) != "5af7183fb1d4cf5f" || hex.EncodeToString(span.SpanId) != "352bff9a74ca9ad2" || hex.EncodeToString(span.ParentSpanId) != "6b221d5bc9e6496c" {
+		t.Errorf("unexpected ids %x %x %x", span.TraceId, span.SpanId, span.ParentSpanId)
+	}
+	if span.Kind != v1.Span_SPAN_KIND_SERVER {
+		t.Errorf("expected server span, got %s", span.Kind)
+	}
+	if span.StartTimeUnixNano != 1556604172355737000 || span.EndTimeUnixNano != 1556604172357168000 {
+		t.Errorf("unexpected time %d-%d", span.StartTimeUnixNano, span.EndTimeUnixNano)
+	}
+	if span.Status.Code != v1.Status_STATUS_CODE_ERROR || span.Status.Message != "timeout" {
+		t.Errorf("unexpected status %v", span.Status)
+	}
+	attrs := attributesToMap(span.Attributes)
+	if attrs["http.target"] != "/api" || attrs["net.peer.ip"] != "172.19.0.2" || attrs["net.peer.port"] != "58648" {
+		t.Errorf("unexpected attributes %v", attrs)
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != "ws" {
+		t.Errorf("unexpected events %v", span.Events)
+	}
+}
+
+func TestZipkinProtoToTracesData(t *testing.T) {
+	endpoint := protowire.AppendTag(nil, 1, protowire.BytesType)
+	endpoint = protowire.AppendString(endpoint, "frontend")
+	endpoint = protowire.AppendTag(endpoint, 2, protowire.BytesType)
+	endpoint = protowire.AppendBytes(endpoint, []byte{10, 0, 0, 1})
+
+	tag := protowire.AppendTag(nil, 1, protowire.BytesType)
+	tag = protowire.AppendString(tag, "http.status_code")
+	tag = protowire.AppendTag(tag, 2, protowire.BytesType)
+	tag = protowire.AppendString(tag, "503")
+
+	span := protowire.AppendTag(nil, 1, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2})
+	span = protowire.AppendTag(span, 3, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 3})
+	span = protowire.AppendTag(span, 4, protowire.VarintType)
+	span = protowire.AppendVarint(span, 1)
+	span = protowire.AppendTag(span, 5, protowire.BytesType)
+	span = protowire.AppendString(span, "get")
+	span = protowire.AppendTag(span, 6, protowire.Fixed64Type)
+	span = protowire.AppendFixed64(span, 1000)
+	span = protowire.AppendTag(span, 7, protowire.VarintType)
+	span = protowire.AppendVarint(span, 20)
+	span = protowire.AppendTag(span, 8, protowire.BytesType)
+	span = protowire.AppendBytes(span, endpoint)
+	span = protowire.AppendTag(span, 11, protowire.BytesType)
+	span = protowire.AppendBytes(span, tag)
+
+	list := protowire.AppendTag(nil, 1, protowire.BytesType)
+	list = protowire.AppendBytes(list, span)
+
+	tracesData, err := ZipkinToTracesData(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := attributesToMap(tracesData.ResourceSpans[0].Resource.Attributes)
+	if resource["service.name"] != "frontend" || resource["app.host.ip"] != "10.0.0.1" {
+		t.Errorf("unexpected resource attributes %v", resource)
+	}
+	s := tracesData.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if hex.EncodeToString(s.TraceId) != "00000000000000010000000000000002" || len(s.ParentSpanId) != 0 {
+		t.Errorf("unexpected ids %x %x", s.TraceId, s.ParentSpanId)
+	}
+	if s.Kind != v1.Span_SPAN_KIND_CLIENT || s.Name != "get" || s.EndTimeUnixNano != 1020000 {
+		t.Errorf("unexpected span %v", s)
+	}
+	if attributesToMap(s.Attributes)["http.status_code"] != "503" {
+		t.Errorf("unexpected attributes %v", s.Attributes)
+	}
+}
+
+func TestZipkinInvalidTraceID(t *testing.T) {
+	if _, err := ZipkinToTracesData([]byte(`[{"traceId": "xyz", "id": "01"}]`)); err == nil {
+		t.Error("expected error for invalid trace id")
+	}
+}
+
+func attributesToMap(attrs []*v11.KeyValue) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		m[attr.Key] = getValueString(attr.Value)
+	}
+	return m
+}
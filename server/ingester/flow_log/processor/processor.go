@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logging "github.com/op/go-logging"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/deepflowio/deepflow/server/ingester/common"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/config"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/log_data"
+	"github.com/deepflowio/deepflow/server/libs/stats"
+)
+
+var log = logging.MustGetLogger("flow_log.processor")
+
+var (
+	processor     *Processor
+	processorLock sync.Mutex
+)
+
+type RulesFile struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Processor applies the ordered rules to l7_flow_log before it is sampled, written and exported
+type Processor struct {
+	path    string
+	modTime time.Time
+	size    int64
+	rules   atomic.Value // []*compiledRule
+
+	exit      chan struct{}
+	closeOnce sync.Once
+}
+
+// Init loads the rules file, it is shared by all decoders, so only the first successful call takes effect.
+// It fails if the configured rules file can not be loaded, so that l7_flow_log is not stored without redaction.
+func Init(cfg *config.L7ProcessorConfig) error {
+	processorLock.Lock()
+	defer processorLock.Unlock()
+	if processor != nil || cfg.RulesFile == "" {
+		return nil
+	}
+	p, err := NewProcessor(cfg.RulesFile)
+	if err != nil {
+		return fmt.Errorf("load l7 processor rules failed: %s", err)
+	}
+	p.Start(time.Duration(cfg.ReloadInterval) * time.Second)
+	processor = p
+	return nil
+}
+
+// Process applies the rules of the global processor, it does nothing if no rules file is configured
+func Process(l *log_data.L7FlowLog) {
+	if processor != nil {
+		processor.Process(l)
+	}
+}
+
+func NewProcessor(path string) (*Processor, error) {
+	p := &Processor{path: path, exit: make(chan struct{})}
+	p.rules.Store([]*compiledRule{})
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Processor) Process(l *log_data.L7FlowLog) {
+	for _, rule := range p.rules.Load().([]*compiledRule) {
+		rule.apply(l)
+	}
+}
+
+func (p *Processor) load() error {
+	stat, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	rules, err := ParseRules(content)
+	if err != nil {
+		return fmt.Errorf("parse %s failed: %s", p.path, err)
+	}
+	for _, rule := range rules {
+		common.RegisterCountableForIngester("l7_processor_rule", rule, stats.OptionStatTags{
+			"rule":   rule.Name,
+			"action": rule.Action})
+	}
+	old := p.rules.Load().([]*compiledRule)
+	p.rules.Store(rules)
+	for _, rule := range old {
+		rule.Close()
+	}
+	p.modTime, p.size = stat.ModTime(), stat.Size()
+	log.Infof("load %d l7 processor rules from %s", len(rules), p.path)
+	return nil
+}
+
+// ParseRules compiles the rules in yaml, names of rules must be unique
+func ParseRules(content []byte) ([]*compiledRule, error) {
+	file := &RulesFile{}
+	if err := yaml.Unmarshal(content, file); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(file.Rules))
+	rules := make([]*compiledRule, 0, len(file.Rules))
+	for _, rule := range file.Rules {
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule %s", rule.Name)
+		}
+		names[rule.Name] = true
+		r, err := compileRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Reload loads the rules file if it is modified, the old rules are kept if it fails to load
+func (p *Processor) Reload() {
+	stat, err := os.Stat(p.path)
+	if err != nil {
+		log.Warningf("stat l7 processor rules %s failed: %s", p.path, err)
+		return
+	}
+	if stat.ModTime().Equal(p.modTime) && stat.Size() == p.size {
+		return
+	}
+	if err := p.load(); err != nil {
+		log.Warningf("reload l7 processor rules failed: %s", err)
+		// avoid reporting the same error every interval
+		p.modTime, p.size = stat.ModTime(), stat.Size()
+	}
+}
+
+// Start reloads the modified rules file every interval until Close
+func (p *Processor) Start(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.Reload()
+			case <-p.exit:
+				return
+			}
+		}
+	}()
+}
+
+func (p *Processor) Close() {
+	p.closeOnce.Do(func() { close(p.exit) })
+}
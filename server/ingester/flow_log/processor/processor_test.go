@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/config"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/log_data"
+)
+
+const testRules = `
+rules:
+- name: mask-email
+  action: mask
+  preset: email
+  fields: [request_resource, attribute.*]
+- name: hash-token
+  protocols: [http]
+  action: hash
+  pattern: 'token=[^&]+'
+  fields: [request_resource]
+- name: drop-cookie
+  action: drop
+  pattern: '(?i)^http\.request\.header\.cookie$'
+- name: extract-user
+  action: extract
+  pattern: 'user_id=(\d+)'
+  fields: [request_resource]
+  target: user_id
+- name: extract-order
+  services: [order]
+  action: extract
+  json-path: order.id
+  fields: [attribute.http.request.body]
+  target: order_id
+- name: template-user
+  action: rewrite
+  pattern: '^/user/\d+'
+  replacement: '/user/{id}'
+`
+
+func TestProcess(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Processor{}
+	p.rules.Store(rules)
+
+	l := &log_data.L7FlowLog{
+		L7ProtocolStr:   "HTTP",
+		AppService:      "order",
+		RequestResource: "/user/123?user_id=123&token=abc&mail=a.b@example.com",
+		Endpoint:        "/user/123",
+		AttributeNames:  []string{"http.request.header.cookie", "http.request.body", "contact"},
+		AttributeValues: []string{"sid=1", `{"order":{"id":42}}`, "c@example.org"},
+	}
+	p.Process(l)
+
+	if strings.Contains(l.RequestResource, "example.com") || !strings.Contains(l.RequestResource, "mail=***") {
+		t.Errorf("email is not masked: %s", l.RequestResource)
+	}
+	if strings.Contains(l.RequestResource, "token=abc") || !strings.Contains(l.RequestResource, HASH_PREFIX) {
+		t.Errorf("token is not hashed: %s", l.RequestResource)
+	}
+	if l.Endpoint != "/user/{id}" {
+		t.Errorf("endpoint: %s, want /user/{id}", l.Endpoint)
+	}
+	wantNames := []string{"http.request.body", "contact", "user_id", "order_id"}
+	wantValues := []string{`{"order":{"id":42}}`, "***", "123", "42"}
+	if !reflect.DeepEqual(l.AttributeNames, wantNames) || !reflect.DeepEqual(l.AttributeValues, wantValues) {
+		t.Errorf("attributes: %v %v, want %v %v", l.AttributeNames, l.AttributeValues, wantNames, wantValues)
+	}
+	for _, rule := range rules {
+		if hit := rule.GetCounter().(*RuleCounter).Hit; hit != 1 {
+			t.Errorf("rule %s hit %d, want 1", rule.Name, hit)
+		}
+	}
+
+	// rules of other protocols and services are not applied
+	l = &log_data.L7FlowLog{L7ProtocolStr: "gRPC", AppService: "cart", RequestResource: "token=abc"}
+	p.Process(l)
+	if l.RequestResource != "token=abc" {
+		t.Errorf("rule of http is applied to grpc: %s", l.RequestResource)
+	}
+}
+
+func TestParseRulesError(t *testing.T) {
+	for _, content := range []string{
+		"rules:\n- {name: a, action: mask, fields: [endpoint]}",
+		"rules:\n- {name: a, action: unknown, pattern: a}",
+		"rules:\n- {name: a, action: mask, pattern: '(', fields: [endpoint]}",
+		"rules:\n- {name: a, action: mask, pattern: a, fields: [unknown]}",
+		"rules:\n- {name: a, action: extract, pattern: a, fields: [endpoint]}",
+		"rules:\n- {name: a, action: drop, fields: [endpoint]}\n- {name: a, action: drop, fields: [endpoint]}",
+	} {
+		if _, err := ParseRules([]byte(content)); err == nil {
+			t.Errorf("%s should be invalid", content)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte("rules:\n- {name: a, action: drop, fields: [endpoint]}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProcessor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	old := p.rules.Load().([]*compiledRule)
+
+	// invalid rules are not loaded
+	os.WriteFile(path, []byte("rules:\n- {name: b, action: unknown}"), 0644)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	p.Reload()
+	if rules := p.rules.Load().([]*compiledRule); len(rules) != 1 || rules[0].Name != "a" {
+		t.Errorf("invalid rules should not be loaded")
+	}
+
+	os.WriteFile(path, []byte("rules:\n- {name: b, action: drop, fields: [events]}"), 0644)
+	os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second))
+	p.Reload()
+	if rules := p.rules.Load().([]*compiledRule); len(rules) != 1 || rules[0].Name != "b" {
+		t.Errorf("rules are not reloaded")
+	}
+	if !old[0].Closed() {
+		t.Errorf("counter of the removed rule is not closed")
+	}
+}
+
+func TestInitInvalidRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte("rules:\n- {name: a, action: unknown}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.L7ProcessorConfig{RulesFile: path, ReloadInterval: 60}
+	if err := Init(cfg); err == nil {
+		t.Fatal("invalid rules file should fail the startup")
+	}
+
+	// the startup succeeds after the rules file is fixed
+	if err := os.WriteFile(path, []byte(testRules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		processor.Close()
+		processor = nil
+	}()
+	l := &log_data.L7FlowLog{RequestResource: "mail=a.b@example.com"}
+	Process(l)
+	if l.RequestResource != "mail=***" {
+		t.Errorf("email is not masked: %s", l.RequestResource)
+	}
+}
@@ -0,0 +1,345 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/log_data"
+	"github.com/deepflowio/deepflow/server/libs/utils"
+)
+
+const (
+	ACTION_MASK    = "mask"    // replaces matches of pattern by replacement
+	ACTION_HASH    = "hash"    // replaces matches of pattern by their hashes, so that values can still be grouped
+	ACTION_DROP    = "drop"    // clears fields, or removes attributes whose names match pattern
+	ACTION_EXTRACT = "extract" // appends the first submatch of pattern or the value of json-path as attribute target
+	ACTION_REWRITE = "rewrite" // replaces matches of pattern by replacement with submatches, e.g. /user/123 to /user/{id}
+
+	DEFAULT_MASK_REPLACEMENT = "***"
+	HASH_PREFIX              = "sha256:"
+	HASH_LENGTH              = 16
+
+	FIELD_REQUEST_RESOURCE   = "request_resource"
+	FIELD_REQUEST_DOMAIN     = "request_domain"
+	FIELD_ENDPOINT           = "endpoint"
+	FIELD_RESPONSE_EXCEPTION = "response_exception"
+	FIELD_RESPONSE_RESULT    = "response_result"
+	FIELD_EVENTS             = "events"
+	FIELD_ATTRIBUTE_PREFIX   = "attribute."
+	FIELD_ALL_ATTRIBUTES     = "attribute.*"
+)
+
+// presets of frequently used patterns
+var PRESET_PATTERNS = map[string]string{
+	"email":        `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+	"card-number":  `\b(?:\d[ -]?){12,18}\d\b`,
+	"bearer-token": `(?i)bearer\s+[A-Za-z0-9._~+/-]+=*`,
+	"jwt":          `eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`,
+	"ipv4":         `\b(?:\d{1,3}\.){3}\d{1,3}\b`,
+}
+
+var stringFields = map[string]func(l *log_data.L7FlowLog) *string{
+	FIELD_REQUEST_RESOURCE:   func(l *log_data.L7FlowLog) *string { return &l.RequestResource },
+	FIELD_REQUEST_DOMAIN:     func(l *log_data.L7FlowLog) *string { return &l.RequestDomain },
+	FIELD_ENDPOINT:           func(l *log_data.L7FlowLog) *string { return &l.Endpoint },
+	FIELD_RESPONSE_EXCEPTION: func(l *log_data.L7FlowLog) *string { return &l.ResponseException },
+	FIELD_RESPONSE_RESULT:    func(l *log_data.L7FlowLog) *string { return &l.ResponseResult },
+	FIELD_EVENTS:             func(l *log_data.L7FlowLog) *string { return &l.Events },
+}
+
+// Rule is applied to logs of the protocols and services, empty means all
+type Rule struct {
+	Name      string   `yaml:"name"`
+	Protocols []string `yaml:"protocols"` // l7_protocol_str, case insensitive
+	Services  []string `yaml:"services"`  // app_service
+	Action    string   `yaml:"action"`
+	// request_resource, request_domain, endpoint, response_exception, response_result, events,
+	// attribute.<name> or attribute.*
+	Fields      []string `yaml:"fields"`
+	Pattern     string   `yaml:"pattern"`
+	Preset      string   `yaml:"preset"` // name of PRESET_PATTERNS, used if pattern is empty
+	Replacement string   `yaml:"replacement"`
+	JSONPath    string   `yaml:"json-path"` // e.g. user.id, used by extract
+	Target      string   `yaml:"target"`    // attribute name of the extracted value
+}
+
+type RuleCounter struct {
+	Hit int64 `statsd:"hit"`
+}
+
+type compiledRule struct {
+	*Rule
+	protocols map[string]bool
+	services  map[string]bool
+	pattern   *regexp.Regexp
+	jsonPath  []string
+
+	counter *RuleCounter
+	utils.Closable
+}
+
+func (r *compiledRule) GetCounter() interface{} {
+	return &RuleCounter{Hit: atomic.SwapInt64(&r.counter.Hit, 0)}
+}
+
+func compileRule(rule *Rule) (*compiledRule, error) {
+	if rule.Name == "" {
+		return nil, fmt.Errorf("name of rule is empty")
+	}
+	r := &compiledRule{Rule: rule, counter: &RuleCounter{}}
+	if len(rule.Protocols) > 0 {
+		r.protocols = make(map[string]bool, len(rule.Protocols))
+		for _, protocol := range rule.Protocols {
+			r.protocols[strings.ToLower(protocol)] = true
+		}
+	}
+	if len(rule.Services) > 0 {
+		r.services = make(map[string]bool, len(rule.Services))
+		for _, service := range rule.Services {
+			r.services[service] = true
+		}
+	}
+	pattern := rule.Pattern
+	if pattern == "" && rule.Preset != "" {
+		var ok bool
+		if pattern, ok = PRESET_PATTERNS[rule.Preset]; !ok {
+			return nil, fmt.Errorf("rule %s: unknown preset %s", rule.Name, rule.Preset)
+		}
+	}
+	if pattern != "" {
+		var err error
+		if r.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("rule %s: %s", rule.Name, err)
+		}
+	}
+	if rule.JSONPath != "" {
+		r.jsonPath = strings.Split(rule.JSONPath, ".")
+	}
+	for _, field := range rule.Fields {
+		if _, ok := stringFields[field]; !ok && !strings.HasPrefix(field, FIELD_ATTRIBUTE_PREFIX) {
+			return nil, fmt.Errorf("rule %s: unknown field %s", rule.Name, field)
+		}
+	}
+
+	switch rule.Action {
+	case ACTION_MASK:
+		if r.Replacement == "" {
+			r.Replacement = DEFAULT_MASK_REPLACEMENT
+		}
+		fallthrough
+	case ACTION_HASH:
+		if r.pattern == nil || len(rule.Fields) == 0 {
+			return nil, fmt.Errorf("rule %s: pattern and fields are required by %s", rule.Name, rule.Action)
+		}
+	case ACTION_REWRITE:
+		if len(rule.Fields) == 0 {
+			r.Fields = []string{FIELD_ENDPOINT}
+		}
+		if r.pattern == nil {
+			return nil, fmt.Errorf("rule %s: pattern is required by %s", rule.Name, rule.Action)
+		}
+	case ACTION_DROP:
+		if r.pattern == nil && len(rule.Fields) == 0 {
+			return nil, fmt.Errorf("rule %s: pattern or fields is required by %s", rule.Name, rule.Action)
+		}
+	case ACTION_EXTRACT:
+		if rule.Target == "" || len(rule.Fields) == 0 || (r.pattern == nil && r.jsonPath == nil) {
+			return nil, fmt.Errorf("rule %s: target, fields and pattern or json-path are required by %s", rule.Name, rule.Action)
+		}
+	default:
+		return nil, fmt.Errorf("rule %s: unknown action %s", rule.Name, rule.Action)
+	}
+	return r, nil
+}
+
+func (r *compiledRule) match(l *log_data.L7FlowLog) bool {
+	if r.protocols != nil && !r.protocols[strings.ToLower(l.L7ProtocolStr)] {
+		return false
+	}
+	if r.services != nil && !r.services[l.AppService] {
+		return false
+	}
+	return true
+}
+
+// apply returns true if the log is modified
+func (r *compiledRule) apply(l *log_data.L7FlowLog) bool {
+	if !r.match(l) {
+		return false
+	}
+	hit := false
+	switch r.Action {
+	case ACTION_MASK, ACTION_REWRITE:
+		hit = r.replaceFields(l, func(value string) string {
+			return r.pattern.ReplaceAllString(value, r.Replacement)
+		})
+	case ACTION_HASH:
+		hit = r.replaceFields(l, func(value string) string {
+			return r.pattern.ReplaceAllStringFunc(value, hashValue)
+		})
+	case ACTION_DROP:
+		hit = r.drop(l)
+	case ACTION_EXTRACT:
+		hit = r.extract(l)
+	}
+	if hit {
+		atomic.AddInt64(&r.counter.Hit, 1)
+	}
+	return hit
+}
+
+func (r *compiledRule) replaceFields(l *log_data.L7FlowLog, replace func(string) string) bool {
+	hit := false
+	for _, field := range r.Fields {
+		if getField, ok := stringFields[field]; ok {
+			value := getField(l)
+			if *value == "" || !r.pattern.MatchString(*value) {
+				continue
+			}
+			*value = replace(*value)
+			hit = true
+			continue
+		}
+		for i, name := range l.AttributeNames {
+			if i >= len(l.AttributeValues) || !matchAttribute(field, name) || !r.pattern.MatchString(l.AttributeValues[i]) {
+				continue
+			}
+			l.AttributeValues[i] = replace(l.AttributeValues[i])
+			hit = true
+		}
+	}
+	return hit
+}
+
+// drop clears the fields, attributes are removed if their names are in fields or match pattern
+func (r *compiledRule) drop(l *log_data.L7FlowLog) bool {
+	hit := false
+	for _, field := range r.Fields {
+		if getField, ok := stringFields[field]; ok {
+			if value := getField(l); *value != "" {
+				*value = ""
+				hit = true
+			}
+		}
+	}
+	names, values := l.AttributeNames[:0], l.AttributeValues[:0]
+	for i, name := range l.AttributeNames {
+		dropped := r.pattern != nil && r.pattern.MatchString(name)
+		for _, field := range r.Fields {
+			if dropped {
+				break
+			}
+			dropped = strings.HasPrefix(field, FIELD_ATTRIBUTE_PREFIX) && matchAttribute(field, name)
+		}
+		if dropped {
+			hit = true
+			continue
+		}
+		names = append(names, name)
+		if i < len(l.AttributeValues) {
+			values = append(values, l.AttributeValues[i])
+		}
+	}
+	l.AttributeNames, l.AttributeValues = names, values
+	return hit
+}
+
+// extract appends the first value found in fields as the target attribute, existing attribute is not overwritten
+func (r *compiledRule) extract(l *log_data.L7FlowLog) bool {
+	for _, name := range l.AttributeNames {
+		if name == r.Target {
+			return false
+		}
+	}
+	for _, field := range r.Fields {
+		values := []string{}
+		if getField, ok := stringFields[field]; ok {
+			values = append(values, *getField(l))
+		} else {
+			for i, name := range l.AttributeNames {
+				if i < len(l.AttributeValues) && matchAttribute(field, name) {
+					values = append(values, l.AttributeValues[i])
+				}
+			}
+		}
+		for _, value := range values {
+			if extracted, ok := r.extractValue(value); ok {
+				l.AttributeNames = append(l.AttributeNames, r.Target)
+				l.AttributeValues = append(l.AttributeValues, extracted)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *compiledRule) extractValue(value string) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+	if r.jsonPath != nil {
+		var data interface{}
+		if err := json.Unmarshal([]byte(value), &data); err != nil {
+			return "", false
+		}
+		for _, key := range r.jsonPath {
+			m, ok := data.(map[string]interface{})
+			if !ok {
+				return "", false
+			}
+			if data, ok = m[key]; !ok {
+				return "", false
+			}
+		}
+		switch v := data.(type) {
+		case nil:
+			return "", false
+		case string:
+			value = v
+		default:
+			b, _ := json.Marshal(v)
+			value = string(b)
+		}
+		if r.pattern == nil {
+			return value, true
+		}
+	}
+	match := r.pattern.FindStringSubmatch(value)
+	if match == nil {
+		return "", false
+	}
+	if len(match) > 1 {
+		return match[1], true
+	}
+	return match[0], true
+}
+
+func matchAttribute(field, name string) bool {
+	return field == FIELD_ALL_ATTRIBUTES || field[len(FIELD_ATTRIBUTE_PREFIX):] == name
+}
+
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return HASH_PREFIX + hex.EncodeToString(sum[:])[:HASH_LENGTH]
+}
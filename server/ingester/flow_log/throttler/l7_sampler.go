@@ -0,0 +1,260 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package throttler
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/config"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/log_data"
+	"github.com/deepflowio/deepflow/server/libs/datatype"
+	"github.com/deepflowio/deepflow/server/libs/utils"
+)
+
+const (
+	BUDGET_SERVICE  = "service:"
+	BUDGET_PROTOCOL = "protocol:"
+	BUDGET_DEFAULT  = "default:"
+)
+
+type L7SamplerCounter struct {
+	KeptError             int64 `statsd:"kept-error"`
+	KeptSlow              int64 `statsd:"kept-slow"`
+	ServiceBudgetKept     int64 `statsd:"service-budget-kept"`
+	ServiceBudgetDropped  int64 `statsd:"service-budget-dropped"`
+	ProtocolBudgetKept    int64 `statsd:"protocol-budget-kept"`
+	ProtocolBudgetDropped int64 `statsd:"protocol-budget-dropped"`
+	DefaultBudgetKept     int64 `statsd:"default-budget-kept"`
+	DefaultBudgetDropped  int64 `statsd:"default-budget-dropped"`
+	// logs dropped after the budget or the global budget of the period is used up, they are not consistent with the trace
+	OverBudgetDropped int64 `statsd:"over-budget-dropped"`
+	// logs without trace_id are sampled randomly
+	NoTraceCount int64 `statsd:"no-trace-count"`
+}
+
+type sampleBudget struct {
+	rule      string
+	budget    int // per period, <= 0 means unlimited
+	rate      float64
+	count     int // logs arrived in this period
+	keptCount int
+}
+
+// L7Sampler decides whether a l7_flow_log is kept. The decision of a log with trace_id is hash(trace_id) < rate of
+// its budget, the rate is the budget divided by the count of the last period. Since hashes are the same on all
+// ingesters, a trace kept with a lower rate is also kept with a higher rate, so spans of a trace are kept together.
+// The budgets are also limited by the global budget, rates of all budgets are lowered by the global rate if the logs
+// kept by them exceed the global budget in the last period.
+type L7Sampler struct {
+	periodSeconds         int64
+	lastPeriod            int64
+	globalBudget          int // per period, <= 0 means unlimited
+	globalRate            float64
+	globalCount           int // logs kept by the rates of their budgets in this period
+	globalKeptCount       int
+	defaultBudget         int
+	serviceBudgets        map[string]int
+	protocolBudgets       map[string]int
+	slowThreshold         uint64 // unit: us
+	serviceSlowThresholds map[string]uint64
+
+	budgets map[string]*sampleBudget
+	counter *L7SamplerCounter
+	utils.Closable
+}
+
+// budgets of cfg are shared by queueCount samplers
+func NewL7Sampler(cfg *config.L7SamplingConfig, queueCount, throttleBucket int) *L7Sampler {
+	if queueCount <= 0 {
+		queueCount = 1
+	}
+	if throttleBucket <= 0 {
+		throttleBucket = 1
+	}
+	s := &L7Sampler{
+		periodSeconds:         int64(throttleBucket),
+		globalBudget:          periodBudget(cfg.GlobalBudget, queueCount, throttleBucket),
+		globalRate:            1,
+		defaultBudget:         periodBudget(cfg.DefaultBudget, queueCount, throttleBucket),
+		serviceBudgets:        make(map[string]int, len(cfg.ServiceBudgets)),
+		protocolBudgets:       make(map[string]int, len(cfg.ProtocolBudgets)),
+		slowThreshold:         uint64(cfg.SlowThreshold) * uint64(time.Millisecond/time.Microsecond),
+		serviceSlowThresholds: make(map[string]uint64, len(cfg.ServiceSlowThresholds)),
+		budgets:               make(map[string]*sampleBudget),
+		counter:               &L7SamplerCounter{},
+	}
+	for service, budget := range cfg.ServiceBudgets {
+		s.serviceBudgets[service] = periodBudget(budget, queueCount, throttleBucket)
+	}
+	for protocol, budget := range cfg.ProtocolBudgets {
+		s.protocolBudgets[protocol] = periodBudget(budget, queueCount, throttleBucket)
+	}
+	for service, threshold := range cfg.ServiceSlowThresholds {
+		s.serviceSlowThresholds[service] = uint64(threshold) * uint64(time.Millisecond/time.Microsecond)
+	}
+	return s
+}
+
+// budget of the sampler in a period, at least 1 if budget is set
+func periodBudget(budget, queueCount, throttleBucket int) int {
+	if budget <= 0 {
+		return 0
+	}
+	b := budget * throttleBucket / queueCount
+	if b < 1 {
+		b = 1
+	}
+	return b
+}
+
+func (s *L7Sampler) GetCounter() interface{} {
+	var counter *L7SamplerCounter
+	counter, s.counter = s.counter, &L7SamplerCounter{}
+	return counter
+}
+
+func (s *L7Sampler) Sample(l *log_data.L7FlowLog) bool {
+	return s.sample(l, time.Now().Unix())
+}
+
+func (s *L7Sampler) sample(l *log_data.L7FlowLog, now int64) bool {
+	if period := now / s.periodSeconds; period != s.lastPeriod {
+		s.rollover()
+		s.lastPeriod = period
+	}
+
+	if l.ResponseStatus == uint8(datatype.STATUS_SERVER_ERROR) || l.ResponseStatus == uint8(datatype.STATUS_CLIENT_ERROR) {
+		s.counter.KeptError++
+		return true
+	}
+	slowThreshold, ok := s.serviceSlowThresholds[l.AppService]
+	if !ok {
+		slowThreshold = s.slowThreshold
+	}
+	if slowThreshold > 0 && l.ResponseDuration > slowThreshold {
+		s.counter.KeptSlow++
+		return true
+	}
+
+	b := s.getBudget(l)
+	b.count++
+	var below func(rate float64) bool
+	if l.TraceId != "" {
+		hash := float64(traceHash(l.TraceId))
+		below = func(rate float64) bool { return rate >= 1 || hash < rate*math.MaxUint64 }
+	} else {
+		s.counter.NoTraceCount++
+		random := rand.Float64()
+		below = func(rate float64) bool { return rate >= 1 || random < rate }
+	}
+	keep := below(b.rate)
+	if keep {
+		s.globalCount++
+		keep = below(b.rate * s.globalRate)
+	}
+	if keep && (b.budget > 0 && b.keptCount >= b.budget || s.globalBudget > 0 && s.globalKeptCount >= s.globalBudget) {
+		s.counter.OverBudgetDropped++
+		return false
+	}
+	if keep {
+		b.keptCount++
+		s.globalKeptCount++
+	}
+	s.count(b.rule, keep)
+	return keep
+}
+
+// getBudget returns the budget of app_service, l7_protocol_str or the default budget of app_service in order
+func (s *L7Sampler) getBudget(l *log_data.L7FlowLog) *sampleBudget {
+	var key, rule string
+	var budget int
+	if b, ok := s.serviceBudgets[l.AppService]; ok {
+		key, rule, budget = BUDGET_SERVICE+l.AppService, BUDGET_SERVICE, b
+	} else if b, ok := s.protocolBudgets[l.L7ProtocolStr]; ok {
+		key, rule, budget = BUDGET_PROTOCOL+l.L7ProtocolStr, BUDGET_PROTOCOL, b
+	} else {
+		key, rule, budget = BUDGET_DEFAULT+l.AppService, BUDGET_DEFAULT, s.defaultBudget
+	}
+	b, ok := s.budgets[key]
+	if !ok {
+		b = &sampleBudget{rule: rule, budget: budget, rate: 1}
+		s.budgets[key] = b
+	}
+	return b
+}
+
+// rollover updates rates by the counts of the last period, budgets without logs are removed
+func (s *L7Sampler) rollover() {
+	if s.globalBudget <= 0 || s.globalCount <= s.globalBudget {
+		s.globalRate = 1
+	} else {
+		s.globalRate = float64(s.globalBudget) / float64(s.globalCount)
+	}
+	s.globalCount, s.globalKeptCount = 0, 0
+	for key, b := range s.budgets {
+		if b.count == 0 {
+			delete(s.budgets, key)
+			continue
+		}
+		if b.budget <= 0 || b.count <= b.budget {
+			b.rate = 1
+		} else {
+			b.rate = float64(b.budget) / float64(b.count)
+		}
+		b.count, b.keptCount = 0, 0
+	}
+}
+
+func (s *L7Sampler) count(rule string, kept bool) {
+	switch rule {
+	case BUDGET_SERVICE:
+		if kept {
+			s.counter.ServiceBudgetKept++
+		} else {
+			s.counter.ServiceBudgetDropped++
+		}
+	case BUDGET_PROTOCOL:
+		if kept {
+			s.counter.ProtocolBudgetKept++
+		} else {
+			s.counter.ProtocolBudgetDropped++
+		}
+	default:
+		if kept {
+			s.counter.DefaultBudgetKept++
+		} else {
+			s.counter.DefaultBudgetDropped++
+		}
+	}
+}
+
+// traceHash must be the same on all ingesters, high bits of FNV-1a are mixed by the finalizer of MurmurHash3,
+// since trace_ids often differ only in the last bytes
+func traceHash(traceID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(traceID))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
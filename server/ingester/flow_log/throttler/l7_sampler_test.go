@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package throttler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/config"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/log_data"
+	"github.com/deepflowio/deepflow/server/libs/datatype"
+)
+
+func newTestL7FlowLog(service, protocol, traceID string, status datatype.LogMessageStatus, duration uint64) *log_data.L7FlowLog {
+	return &log_data.L7FlowLog{
+		AppService:       service,
+		L7ProtocolStr:    protocol,
+		TraceId:          traceID,
+		ResponseStatus:   uint8(status),
+		ResponseDuration: duration,
+	}
+}
+
+func TestL7SamplerTraceConsistent(t *testing.T) {
+	cfg := &config.L7SamplingConfig{DefaultBudget: 200}
+	// two ingesters with the same traffic, each trace has 2 spans
+	samplers := []*L7Sampler{NewL7Sampler(cfg, 1, 1), NewL7Sampler(cfg, 1, 1)}
+	// the first period learns the rate: 200 / 2000
+	for _, s := range samplers {
+		for i := 0; i < 2000; i++ {
+			s.sample(newTestL7FlowLog("cart", "HTTP", fmt.Sprintf("trace-%d", i/2), datatype.STATUS_OK, 0), 1)
+		}
+	}
+	kept := 0
+	for i := 0; i < 500; i++ {
+		traceID := fmt.Sprintf("trace-%d", i+1000)
+		decisions := []bool{}
+		for _, s := range samplers {
+			for span := 0; span < 2; span++ {
+				decisions = append(decisions, s.sample(newTestL7FlowLog("cart", "HTTP", traceID, datatype.STATUS_OK, 0), 2))
+			}
+		}
+		for _, decision := range decisions {
+			if decision != decisions[0] {
+				t.Fatalf("spans of trace %s have different decisions: %v", traceID, decisions)
+			}
+		}
+		if decisions[0] {
+			kept++
+		}
+	}
+	// about 10% of the traces are kept
+	if kept < 25 || kept > 100 {
+		t.Errorf("kept %d traces, want about 50", kept)
+	}
+}
+
+func TestL7SamplerRules(t *testing.T) {
+	cfg := &config.L7SamplingConfig{
+		DefaultBudget:         1,
+		ServiceBudgets:        map[string]int{"cart": 10},
+		ProtocolBudgets:       map[string]int{"MySQL": 5},
+		SlowThreshold:         100,
+		ServiceSlowThresholds: map[string]int{"cart": 10},
+	}
+	s := NewL7Sampler(cfg, 1, 1)
+	for i := 0; i < 20; i++ {
+		traceID := fmt.Sprintf("trace-%d", i)
+		if !s.sample(newTestL7FlowLog("order", "HTTP", traceID, datatype.STATUS_SERVER_ERROR, 0), 1) {
+			t.Errorf("error response should be kept")
+		}
+		// 20ms is slow for cart only
+		if !s.sample(newTestL7FlowLog("cart", "HTTP", traceID, datatype.STATUS_OK, 20000), 1) {
+			t.Errorf("slow response should be kept")
+		}
+		s.sample(newTestL7FlowLog("order", "HTTP", traceID, datatype.STATUS_OK, 20000), 1)
+		s.sample(newTestL7FlowLog("cart", "HTTP", traceID, datatype.STATUS_OK, 0), 1)
+		s.sample(newTestL7FlowLog("", "MySQL", traceID, datatype.STATUS_OK, 0), 1)
+	}
+	counter := s.GetCounter().(*L7SamplerCounter)
+	if counter.KeptError != 20 || counter.KeptSlow != 20 {
+		t.Errorf("kept error %d, slow %d, want 20, 20", counter.KeptError, counter.KeptSlow)
+	}
+	if counter.ServiceBudgetKept != 10 || counter.ProtocolBudgetKept != 5 || counter.DefaultBudgetKept != 1 {
+		t.Errorf("budget kept: service %d, protocol %d, default %d, want 10, 5, 1",
+			counter.ServiceBudgetKept, counter.ProtocolBudgetKept, counter.DefaultBudgetKept)
+	}
+	if counter.OverBudgetDropped != 10+15+19 {
+		t.Errorf("over budget dropped %d, want %d", counter.OverBudgetDropped, 10+15+19)
+	}
+}
+
+func TestL7SamplerGlobalBudget(t *testing.T) {
+	// the default budget of each service is not reached, but the sum of them exceeds the global budget
+	cfg := &config.L7SamplingConfig{GlobalBudget: 1000, DefaultBudget: 1000}
+	s := NewL7Sampler(cfg, 1, 1)
+	sampleServices := func(period int64) int {
+		kept := 0
+		for service := 0; service < 100; service++ {
+			for i := 0; i < 100; i++ {
+				traceID := fmt.Sprintf("trace-%d-%d-%d", period, service, i)
+				if s.sample(newTestL7FlowLog(fmt.Sprintf("service-%d", service), "HTTP", traceID, datatype.STATUS_OK, 0), period) {
+					kept++
+				}
+			}
+		}
+		return kept
+	}
+	if kept := sampleServices(1); kept != 1000 {
+		t.Errorf("first period kept %d, want the global budget 1000", kept)
+	}
+	// the global rate learnt from the first period is 1000 / 10000
+	kept := sampleServices(2)
+	if kept < 800 || kept > 1000 {
+		t.Errorf("kept %d, want about 1000", kept)
+	}
+	counter := s.GetCounter().(*L7SamplerCounter)
+	if counter.DefaultBudgetKept != int64(1000+kept) {
+		t.Errorf("default budget kept %d, want %d", counter.DefaultBudgetKept, 1000+kept)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/deepflowio/deepflow/server/ingester/flow_log/dbwriter"
+	"github.com/deepflowio/deepflow/server/ingester/flow_log/log_data"
 )
 
 const (
@@ -43,6 +44,8 @@ type ThrottlingQueue struct {
 
 	sampleItems    []interface{}
 	nonSampleItems []interface{}
+
+	l7Sampler *L7Sampler
 }
 
 func NewThrottlingQueue(throttle, throttleBucket int, flowLogWriter *dbwriter.FlowLogWriter, index int) *ThrottlingQueue {
@@ -117,6 +120,24 @@ func (thq *ThrottlingQueue) SendWithThrottling(flow interface{}) bool {
 	}
 }
 
+// SetL7Sampler replaces the reservoir sampling of l7_flow_log by the sampler
+func (thq *ThrottlingQueue) SetL7Sampler(sampler *L7Sampler) {
+	thq.l7Sampler = sampler
+}
+
+// SendL7WithSampling sends l7_flow_log by the sampler if it is set, the log is released if it is dropped
+func (thq *ThrottlingQueue) SendL7WithSampling(l *log_data.L7FlowLog) bool {
+	if thq.l7Sampler == nil {
+		return thq.SendWithThrottling(l)
+	}
+	if !thq.l7Sampler.Sample(l) {
+		l.Release()
+		return false
+	}
+	thq.SendWithoutThrottling(l)
+	return true
+}
+
 func (thq *ThrottlingQueue) SendWithoutThrottling(flow interface{}) {
 	if flow == nil || len(thq.nonSampleItems) >= QUEUE_BATCH {
 		if len(thq.nonSampleItems) > 0 {
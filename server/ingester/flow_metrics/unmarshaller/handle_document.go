@@ -38,7 +38,7 @@ const (
 	SIGNAL_SOURCE_OTEL = 4
 )
 
-func getPlatformInfos(t *flow_metrics.Tag, platformData *grpc.PlatformInfoTable) (*grpc.Info, *grpc.Info) {
+func getPlatformInfos(t *flow_metrics.Tag, timestamp uint32, platformData *grpc.PlatformInfoTable) (*grpc.Info, *grpc.Info) {
 	var info, info1 *grpc.Info
 	if t.L3EpcID != datatype.EPC_FROM_INTERNET {
 		// if the GpId exists but the podId does not exist, first obtain the podId through the GprocessId table delivered by the Controller
@@ -60,17 +60,17 @@ func getPlatformInfos(t *flow_metrics.Tag, platformData *grpc.PlatformInfoTable)
 		if info == nil {
 			if t.MAC != 0 {
 				t.TagSource |= uint8(flow_metrics.Mac)
-				info = platformData.QueryMacInfo(t.OrgId, t.MAC|uint64(t.L3EpcID)<<48)
+				info = platformData.QueryMacInfo(t.OrgId, t.MAC|uint64(t.L3EpcID)<<48, timestamp)
 				if info == nil {
 					t.TagSource |= uint8(flow_metrics.EpcIP)
-					info = common.RegetInfoFromIP(t.OrgId, t.IsIPv4 == 0, t.IP6, t.IP, t.L3EpcID, platformData)
+					info = common.RegetInfoFromIP(t.OrgId, t.IsIPv4 == 0, t.IP6, t.IP, t.L3EpcID, timestamp, platformData)
 				}
 			} else if t.IsIPv4 == 0 {
 				t.TagSource |= uint8(flow_metrics.EpcIP)
-				info = platformData.QueryIPV6Infos(t.OrgId, t.L3EpcID, t.IP6)
+				info = platformData.QueryIPV6Infos(t.OrgId, t.L3EpcID, t.IP6, timestamp)
 			} else {
 				t.TagSource |= uint8(flow_metrics.EpcIP)
-				info = platformData.QueryIPV4Infos(t.OrgId, t.L3EpcID, t.IP)
+				info = platformData.QueryIPV4Infos(t.OrgId, t.L3EpcID, t.IP, timestamp)
 			}
 		}
 	}
@@ -93,17 +93,17 @@ func getPlatformInfos(t *flow_metrics.Tag, platformData *grpc.PlatformInfoTable)
 		if info1 == nil {
 			if t.MAC1 != 0 {
 				t.TagSource1 |= uint8(flow_metrics.Mac)
-				info1 = platformData.QueryMacInfo(t.OrgId, t.MAC1|uint64(t.L3EpcID1)<<48)
+				info1 = platformData.QueryMacInfo(t.OrgId, t.MAC1|uint64(t.L3EpcID1)<<48, timestamp)
 				if info1 == nil {
 					t.TagSource1 |= uint8(flow_metrics.EpcIP)
-					info1 = common.RegetInfoFromIP(t.OrgId, t.IsIPv4 == 0, t.IP61, t.IP1, t.L3EpcID1, platformData)
+					info1 = common.RegetInfoFromIP(t.OrgId, t.IsIPv4 == 0, t.IP61, t.IP1, t.L3EpcID1, timestamp, platformData)
 				}
 			} else if t.IsIPv4 == 0 {
 				t.TagSource1 |= uint8(flow_metrics.EpcIP)
-				info1 = platformData.QueryIPV6Infos(t.OrgId, t.L3EpcID1, t.IP61)
+				info1 = platformData.QueryIPV6Infos(t.OrgId, t.L3EpcID1, t.IP61, timestamp)
 			} else {
 				t.TagSource1 |= uint8(flow_metrics.EpcIP)
-				info1 = platformData.QueryIPV4Infos(t.OrgId, t.L3EpcID1, t.IP1)
+				info1 = platformData.QueryIPV4Infos(t.OrgId, t.L3EpcID1, t.IP1, timestamp)
 			}
 		}
 	}
@@ -127,7 +127,7 @@ func DocumentExpand(doc app.Document, platformData *grpc.PlatformInfoTable) erro
 		t.Code |= PortAddCode
 	}
 
-	info, info1 := getPlatformInfos(t, platformData)
+	info, info1 := getPlatformInfos(t, doc.Time(), platformData)
 	if t.Code&EdgeCode == EdgeCode {
 		t.Code |= EdgeAddCode
 	} else {
@@ -93,7 +93,9 @@ func Start(configPath string, shared *servercommon.ControllerIngesterShared) []i
 	stats.SetRemoteType(stats.REMOTE_TYPE_DFSTATSD)
 	stats.SetDFRemote(net.JoinHostPort("127.0.0.1", strconv.Itoa(int(cfg.ListenPort))))
 
+	recorder := receiver.NewRecorder(cfg.ReceiverRecord.Dir, cfg.ReceiverRecord.FileSize, cfg.ReceiverRecord.FileCount)
 	receiver := receiver.NewReceiver(int(cfg.ListenPort), cfg.UDPReadBuffer, cfg.TCPReadBuffer, cfg.TCPReaderBuffer)
+	receiver.SetRecorder(recorder)
 
 	ingesterOrgHandler := NewOrgHandler(cfg)
 	closers := []io.Closer{}
@@ -165,7 +167,9 @@ func Start(configPath string, shared *servercommon.ControllerIngesterShared) []i
 			MAX_SLAVE_PLATFORMDATA_COUNT,
 			cfg.GrpcBufferSize,
 			cfg.NodeIP,
-			receiver)
+			receiver,
+			cfg.PlatformDataHistory.MaxVersions,
+			cfg.PlatformDataHistory.MaxAge)
 
 		exporters := exporters.NewExporters(exportersConfig)
 		if exporters != nil {
@@ -193,7 +197,7 @@ func Start(configPath string, shared *servercommon.ControllerIngesterShared) []i
 			closers = append(closers, flowMetrics)
 
 			// write event data
-			event, err := event.NewEvent(eventConfig, shared.ResourceEventQueue, receiver, platformDataManager, exporters)
+			event, err := event.NewEvent(eventConfig, shared.ResourceEventQueue, shared.SlowQueryEventQueue, receiver, platformDataManager, exporters)
 			checkError(err)
 			event.Start()
 			closers = append(closers, event)
@@ -104,6 +104,8 @@ func RegisterIngesterCommand(root *cobra.Command) {
 		nil,
 	))
 	ingesterCmd.AddCommand(RegisterDecodeTraceCommand(ip, uint16(orgId)))
+	ingesterCmd.AddCommand(receiver.RegisterRecordCommand())
+	ingesterCmd.AddCommand(receiver.RegisterReplayCommand(ip))
 
 	dropletCmd.AddCommand(queue.RegisterCommand(ingesterctl.INGESTERCTL_QUEUE, []string{
 		"1-receiver-to-statsd",
@@ -59,6 +59,7 @@ const (
 	CMD_CONTINUOUS_PROFILER
 	CMD_ORG_SWITCH
 	CMD_FREE_OS_MEMORY
+	RECEIVER_RECORD_CMD // 48
 )
 
 const (
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dbwriter
+
+import (
+	"github.com/deepflowio/deepflow/server/libs/ckdb"
+	"github.com/deepflowio/deepflow/server/libs/datatype/prompb"
+	flow_metrics "github.com/deepflowio/deepflow/server/libs/flow-metrics"
+	"github.com/deepflowio/deepflow/server/libs/pool"
+)
+
+// NativeHistogram is a Prometheus native (sparse) histogram, the buckets are kept
+// in the same layout as remote write: spans of consecutive bucket indexes and the
+// deltas (integer histogram) or absolute counts (float histogram) of the buckets.
+type NativeHistogram struct {
+	Count         float64
+	Sum           float64
+	Schema        int8
+	ZeroThreshold float64
+	ZeroCount     float64
+	ResetHint     uint8
+
+	NegativeSpanOffsets []int64
+	NegativeSpanLengths []uint32
+	NegativeDeltas      []int64
+	NegativeCounts      []float64
+	PositiveSpanOffsets []int64
+	PositiveSpanLengths []uint32
+	PositiveDeltas      []int64
+	PositiveCounts      []float64
+}
+
+func (h *NativeHistogram) Fill(p *prompb.Histogram) {
+	if _, ok := p.Count.(*prompb.Histogram_CountFloat); ok {
+		h.Count = p.GetCountFloat()
+	} else {
+		h.Count = float64(p.GetCountInt())
+	}
+	if _, ok := p.ZeroCount.(*prompb.Histogram_ZeroCountFloat); ok {
+		h.ZeroCount = p.GetZeroCountFloat()
+	} else {
+		h.ZeroCount = float64(p.GetZeroCountInt())
+	}
+	h.Sum = p.Sum
+	h.Schema = int8(p.Schema)
+	h.ZeroThreshold = p.ZeroThreshold
+	h.ResetHint = uint8(p.ResetHint)
+
+	for _, span := range p.NegativeSpans {
+		h.NegativeSpanOffsets = append(h.NegativeSpanOffsets, int64(span.Offset))
+		h.NegativeSpanLengths = append(h.NegativeSpanLengths, span.Length)
+	}
+	h.NegativeDeltas = append(h.NegativeDeltas, p.NegativeDeltas...)
+	h.NegativeCounts = append(h.NegativeCounts, p.NegativeCounts...)
+	for _, span := range p.PositiveSpans {
+		h.PositiveSpanOffsets = append(h.PositiveSpanOffsets, int64(span.Offset))
+		h.PositiveSpanLengths = append(h.PositiveSpanLengths, span.Length)
+	}
+	h.PositiveDeltas = append(h.PositiveDeltas, p.PositiveDeltas...)
+	h.PositiveCounts = append(h.PositiveCounts, p.PositiveCounts...)
+}
+
+func (h *NativeHistogram) Reset() {
+	*h = NativeHistogram{
+		NegativeSpanOffsets: h.NegativeSpanOffsets[:0],
+		NegativeSpanLengths: h.NegativeSpanLengths[:0],
+		NegativeDeltas:      h.NegativeDeltas[:0],
+		NegativeCounts:      h.NegativeCounts[:0],
+		PositiveSpanOffsets: h.PositiveSpanOffsets[:0],
+		PositiveSpanLengths: h.PositiveSpanLengths[:0],
+		PositiveDeltas:      h.PositiveDeltas[:0],
+		PositiveCounts:      h.PositiveCounts[:0],
+	}
+}
+
+// Note: The order of Write() must be consistent with the order of append() in NativeHistogramColumns.
+func (h *NativeHistogram) WriteBlock(block *ckdb.Block) {
+	block.Write(
+		h.Count,
+		h.Sum,
+		h.Schema,
+		h.ZeroThreshold,
+		h.ZeroCount,
+		h.ResetHint,
+		h.NegativeSpanOffsets,
+		h.NegativeSpanLengths,
+		h.NegativeDeltas,
+		h.NegativeCounts,
+		h.PositiveSpanOffsets,
+		h.PositiveSpanLengths,
+		h.PositiveDeltas,
+		h.PositiveCounts,
+	)
+}
+
+func NativeHistogramColumns() []*ckdb.Column {
+	return []*ckdb.Column{
+		ckdb.NewColumn("count", ckdb.Float64).SetComment("the count of observations"),
+		ckdb.NewColumn("sum", ckdb.Float64).SetComment("the sum of observations"),
+		ckdb.NewColumn("schema", ckdb.Int8).SetComment("the bucket schema, each power of two is divided into 2^schema buckets"),
+		ckdb.NewColumn("zero_threshold", ckdb.Float64).SetIndex(ckdb.IndexNone).SetComment("the width of the zero bucket"),
+		ckdb.NewColumn("zero_count", ckdb.Float64).SetIndex(ckdb.IndexNone).SetComment("the count of observations in the zero bucket"),
+		ckdb.NewColumn("reset_hint", ckdb.UInt8).SetIndex(ckdb.IndexNone).SetComment("0: unknown, 1: yes, 2: no, 3: gauge"),
+		ckdb.NewColumn("negative_span_offsets", ckdb.ArrayInt64).SetComment("the offsets of the negative bucket spans"),
+		ckdb.NewColumn("negative_span_lengths", ckdb.ArrayUInt32).SetComment("the lengths of the negative bucket spans"),
+		ckdb.NewColumn("negative_deltas", ckdb.ArrayInt64).SetComment("the count deltas of the negative buckets of integer histograms"),
+		ckdb.NewColumn("negative_counts", ckdb.ArrayFloat64).SetComment("the counts of the negative buckets of float histograms"),
+		ckdb.NewColumn("positive_span_offsets", ckdb.ArrayInt64).SetComment("the offsets of the positive bucket spans"),
+		ckdb.NewColumn("positive_span_lengths", ckdb.ArrayUInt32).SetComment("the lengths of the positive bucket spans"),
+		ckdb.NewColumn("positive_deltas", ckdb.ArrayInt64).SetComment("the count deltas of the positive buckets of integer histograms"),
+		ckdb.NewColumn("positive_counts", ckdb.ArrayFloat64).SetComment("the counts of the positive buckets of float histograms"),
+	}
+}
+
+// PrometheusHistogramSample is stored in the 'histogram_samples' table, which has the same label columns as the 'samples' table
+type PrometheusHistogramSample struct {
+	PrometheusSample
+	Histogram NativeHistogram
+}
+
+func (m *PrometheusHistogramSample) TableName() string {
+	return PROMETHEUS_HISTOGRAM_TABLE
+}
+
+// Note: The order of Write() must be consistent with the order of append() in Columns.
+func (m *PrometheusHistogramSample) WriteBlock(block *ckdb.Block) {
+	m.writeBlockLabels(block)
+	m.Histogram.WriteBlock(block)
+	m.UniversalTag.WriteBlock(block)
+}
+
+// Note: The order of append() must be consistent with the order of Write() in WriteBlock.
+func (m *PrometheusHistogramSample) Columns(appLabelColumnCount int) []*ckdb.Column {
+	columns := labelColumns(appLabelColumnCount)
+	columns = append(columns, NativeHistogramColumns()...)
+	columns = flow_metrics.GenUniversalTagColumns(columns)
+	return columns
+}
+
+func (m *PrometheusHistogramSample) GenCKTable(cluster, storagePolicy, ckdbType string, ttl int, coldStorage *ckdb.ColdStorage, appLabelColumnCount int) *ckdb.Table {
+	table := m.PrometheusSampleMini.GenCKTable(cluster, storagePolicy, ckdbType, ttl, coldStorage, appLabelColumnCount)
+	table.LocalName = m.TableName() + ckdb.LOCAL_SUBFFIX
+	table.GlobalName = m.TableName()
+	table.Columns = m.Columns(appLabelColumnCount)
+	return table
+}
+
+func (m *PrometheusHistogramSample) Release() {
+	ReleasePrometheusHistogramSample(m)
+}
+
+var prometheusHistogramSamplePool = pool.NewLockFreePool(func() interface{} {
+	return &PrometheusHistogramSample{}
+})
+
+func AcquirePrometheusHistogramSample() *PrometheusHistogramSample {
+	return prometheusHistogramSamplePool.Get().(*PrometheusHistogramSample)
+}
+
+func ReleasePrometheusHistogramSample(p *PrometheusHistogramSample) {
+	p.UniversalTag = emptyUniversalTag
+	p.AppLabelValueIDs = p.AppLabelValueIDs[:0]
+	p.Histogram.Reset()
+	prometheusHistogramSamplePool.Put(p)
+}
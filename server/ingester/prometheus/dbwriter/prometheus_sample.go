@@ -54,10 +54,11 @@ type PrometheusSample struct {
 }
 
 type PrometheusSampleMini struct {
-	Timestamp uint32 // s
-	VtapId    uint16
-	MetricID  uint32
-	TargetID  uint32
+	Timestamp   uint32 // s
+	Millisecond uint16 // the millisecond part of the sample time
+	VtapId      uint16
+	MetricID    uint32
+	TargetID    uint32
 
 	// Not stored, only determines which database to store in.
 	// When Orgid is 0 or 1, it is stored in database 'prometheus', otherwise stored in '<OrgId>_prometheus'.
@@ -90,8 +91,15 @@ func (m *PrometheusSampleMini) PodNsId() uint16 {
 
 // Note: The order of Write() must be consistent with the order of append() in Columns.
 func (m *PrometheusSampleMini) WriteBlock(block *ckdb.Block) {
+	m.writeBlockLabels(block)
+	block.Write(m.Value)
+}
+
+// writes the columns shared by samples and histogram_samples
+func (m *PrometheusSampleMini) writeBlockLabels(block *ckdb.Block) {
 	block.WriteDateTime(m.Timestamp)
 	block.Write(
+		m.Millisecond,
 		m.MetricID,
 		m.TargetID,
 		m.TeamID,
@@ -99,7 +107,11 @@ func (m *PrometheusSampleMini) WriteBlock(block *ckdb.Block) {
 	for _, v := range m.AppLabelValueIDs[1:] {
 		block.Write(v)
 	}
-	block.Write(m.Value)
+}
+
+func (m *PrometheusSampleMini) SetTimestamp(timestampMs int64) {
+	m.Timestamp = uint32(timestampMs / 1000)
+	m.Millisecond = uint16(timestampMs % 1000)
 }
 
 func (m *PrometheusSampleMini) OrgID() uint16 {
@@ -108,10 +120,19 @@ func (m *PrometheusSampleMini) OrgID() uint16 {
 
 // Note: The order of append() must be consistent with the order of Write() in WriteBlock.
 func (m *PrometheusSampleMini) Columns(appLabelColumnCount int) []*ckdb.Column {
+	columns := labelColumns(appLabelColumnCount)
+	columns = append(columns, ckdb.NewColumn("value", ckdb.Float64))
+
+	return columns
+}
+
+// the columns shared by samples and histogram_samples
+func labelColumns(appLabelColumnCount int) []*ckdb.Column {
 	columns := []*ckdb.Column{}
 
 	columns = append(columns, ckdb.NewColumnWithGroupBy("time", ckdb.DateTime))
 	columns = append(columns,
+		ckdb.NewColumn("millisecond", ckdb.UInt16).SetIndex(ckdb.IndexNone).SetComment("the millisecond part of the sample time"),
 		ckdb.NewColumn("metric_id", ckdb.UInt32).SetComment("encoded ID of the metric name"),
 		ckdb.NewColumn("target_id", ckdb.UInt32).SetComment("the encoded ID of the target"),
 		ckdb.NewColumn("team_id", ckdb.UInt16).SetComment("the team ID"),
@@ -119,8 +140,6 @@ func (m *PrometheusSampleMini) Columns(appLabelColumnCount int) []*ckdb.Column {
 	for i := 1; i <= appLabelColumnCount; i++ {
 		columns = append(columns, ckdb.NewColumn(fmt.Sprintf("app_label_value_id_%d", i), ckdb.UInt32))
 	}
-	columns = append(columns, ckdb.NewColumn("value", ckdb.Float64))
-
 	return columns
 }
 
@@ -41,9 +41,10 @@ import (
 var log = logging.MustGetLogger("prometheus.dbwriter")
 
 const (
-	QUEUE_BATCH_SIZE = 1024
-	PROMETHEUS_DB    = "prometheus"
-	PROMETHEUS_TABLE = "samples"
+	QUEUE_BATCH_SIZE           = 1024
+	PROMETHEUS_DB              = "prometheus"
+	PROMETHEUS_TABLE           = "samples"
+	PROMETHEUS_HISTOGRAM_TABLE = "histogram_samples"
 )
 
 type ClusterNode struct {
@@ -63,24 +64,29 @@ type PrometheusCKWriter struct {
 
 // all 'PrometheusWriters' share 'prometheusCKWriters' to write to ClickHouse, preventing each PrometheusWriter from creating CKWriter and causing excessive resource consumption
 type PrometheusCKWriters struct {
-	writers [MAX_APP_LABEL_COLUMN_INDEX + 1]PrometheusCKWriter
+	writers          [MAX_APP_LABEL_COLUMN_INDEX + 1]PrometheusCKWriter
+	histogramWriters [MAX_APP_LABEL_COLUMN_INDEX + 1]PrometheusCKWriter
 	sync.Mutex
 }
 
 var prometheusCKWriters PrometheusCKWriters
 
-func getPrometheusCKWriter(columnCount int) *PrometheusCKWriter {
+func getPrometheusCKWriter(table string, columnCount int) *PrometheusCKWriter {
+	if table == PROMETHEUS_HISTOGRAM_TABLE {
+		return &prometheusCKWriters.histogramWriters[columnCount]
+	}
 	return &prometheusCKWriters.writers[columnCount]
 }
 
-func setPrometheusCKWriter(columnCount int, w *ckwriter.CKWriter) {
-	prometheusCKWriters.writers[columnCount] = PrometheusCKWriter{ckwriter: w}
+func setPrometheusCKWriter(table string, columnCount int, w *ckwriter.CKWriter) {
+	*getPrometheusCKWriter(table, columnCount) = PrometheusCKWriter{ckwriter: w}
 }
 
 func (p PrometheusCKWriters) EndpointsChange(addrs []string) {
 	log.Infof("prometheus clickhouse endpoints changes to %+v", addrs)
 	for i := range prometheusCKWriters.writers {
 		prometheusCKWriters.writers[i].updateAppLabelColumn = true
+		prometheusCKWriters.histogramWriters[i].updateAppLabelColumn = true
 	}
 }
 
@@ -133,18 +139,18 @@ func (w *PrometheusWriter) InitTable(appLabelCount int) error {
 	return err
 }
 
-func (w *PrometheusWriter) updateAppLabelValueIdColumns(orgID uint16, appLabelCount int) error {
-	log.Infof("organization %d needs to update the number of app_label_value_id column in the prometheus table to %d", orgID, appLabelCount)
+func (w *PrometheusWriter) updateAppLabelValueIdColumns(orgID uint16, table string, appLabelCount int) error {
+	log.Infof("organization %d needs to update the number of app_label_value_id column in the prometheus.%s table to %d", orgID, table, appLabelCount)
 	orgDatabase := ckdb.OrgDatabasePrefix(orgID) + PROMETHEUS_DB
-	currentCount, err := w.getCurrentAppLabelColumnCount(orgDatabase)
+	currentCount, err := w.getCurrentAppLabelColumnCount(orgDatabase, table)
 	if err != nil {
 		return err
 	}
-	// the prometheus.samples table has not been created yet, so there is no need to update the columns.
+	// the table has not been created yet, so there is no need to update the columns.
 	if currentCount == 0 {
 		return nil
 	}
-	maxLabelColumnIndex, err := w.getMaxAppLabelColumnIndex(orgDatabase)
+	maxLabelColumnIndex, err := w.getMaxAppLabelColumnIndex(orgDatabase, table)
 	if err != nil {
 		log.Warning(err)
 	}
@@ -156,11 +162,11 @@ func (w *PrometheusWriter) updateAppLabelValueIdColumns(orgID uint16, appLabelCo
 
 	if currentCount < appLabelCount {
 		startIndex, endIndex := currentCount+1, appLabelCount
-		if err := w.addAppLabelColumns(w.ckdbConn, startIndex, endIndex, orgDatabase); err != nil {
+		if err := w.addAppLabelColumns(w.ckdbConn, startIndex, endIndex, orgDatabase, table); err != nil {
 			return err
 		}
 		// 需要在cluseter其他节点也增加列
-		if err := w.addAppLabelColumnsOnCluster(startIndex, endIndex, orgDatabase); err != nil {
+		if err := w.addAppLabelColumnsOnCluster(startIndex, endIndex, orgDatabase, table); err != nil {
 			log.Warningf("other node failed when add app_value_id columns which index from %d to %d: %s", startIndex, endIndex, err)
 		}
 	}
@@ -176,14 +182,14 @@ func (w *PrometheusWriter) getOrCreateCkwriter(s PrometheusSampleInterface) (*ck
 	if appLabelCount > MAX_APP_LABEL_COLUMN_INDEX {
 		return nil, fmt.Errorf("the length of AppLabelValueIDs(%d) is > MAX_APP_LABEL_COLUMN_INDEX(%d)", s.AppLabelLen(), MAX_APP_LABEL_COLUMN_INDEX)
 	}
-	writer := getPrometheusCKWriter(appLabelCount)
+	writer := getPrometheusCKWriter(s.TableName(), appLabelCount)
 	if writer.ckwriter != nil && !writer.updateAppLabelColumn {
 		return writer.ckwriter, nil
 	}
 	lockPrometheusCKWriters()
 	defer unlockPrometheusCKWriters()
 	// check again
-	writer = getPrometheusCKWriter(appLabelCount)
+	writer = getPrometheusCKWriter(s.TableName(), appLabelCount)
 	if writer.ckwriter != nil && !writer.updateAppLabelColumn {
 		return writer.ckwriter, nil
 	}
@@ -201,7 +207,7 @@ func (w *PrometheusWriter) getOrCreateCkwriter(s PrometheusSampleInterface) (*ck
 		w.ckdbConn = conn
 	}
 
-	if err := w.updateAppLabelValueIdColumns(s.OrgID(), appLabelCount); err != nil {
+	if err := w.updateAppLabelValueIdColumns(s.OrgID(), s.TableName(), appLabelCount); err != nil {
 		return nil, err
 	}
 	writer.updateAppLabelColumn = false
@@ -211,7 +217,7 @@ func (w *PrometheusWriter) getOrCreateCkwriter(s PrometheusSampleInterface) (*ck
 	}
 
 	startTime := time.Now()
-	log.Infof("start create new ckwriter for prometheus, app label count: %d", appLabelCount)
+	log.Infof("start create new ckwriter for prometheus.%s, app label count: %d", s.TableName(), appLabelCount)
 	// 将要创建的表信息
 	table := s.GenCKTable(w.ckdbCluster, w.ckdbStoragePolicy, w.ckdbType, w.ttl, ckdb.GetColdStorage(w.ckdbColdStorages, s.DatabaseName(), s.TableName()), appLabelCount)
 
@@ -224,13 +230,13 @@ func (w *PrometheusWriter) getOrCreateCkwriter(s PrometheusSampleInterface) (*ck
 	}
 
 	ckwriter.Run()
-	setPrometheusCKWriter(appLabelCount, ckwriter)
-	log.Infof("finish create new ckwriter for prometheus, app label count: %d, cost time: %s", appLabelCount, time.Since(startTime))
+	setPrometheusCKWriter(s.TableName(), appLabelCount, ckwriter)
+	log.Infof("finish create new ckwriter for prometheus.%s, app label count: %d, cost time: %s", s.TableName(), appLabelCount, time.Since(startTime))
 
 	return ckwriter, nil
 }
 
-func (w *PrometheusWriter) addAppLabelColumnsOnCluster(startIndex, endIndex int, orgDatabase string) error {
+func (w *PrometheusWriter) addAppLabelColumnsOnCluster(startIndex, endIndex int, orgDatabase, table string) error {
 	// in standalone mode, ckdbWatcher will be nil
 	if w.ckdbWatcher == nil {
 		return nil
@@ -250,13 +256,13 @@ func (w *PrometheusWriter) addAppLabelColumnsOnCluster(startIndex, endIndex int,
 		return err
 	}
 	defer conn.Close()
-	return w.addAppLabelColumns(conn, startIndex, endIndex, orgDatabase)
+	return w.addAppLabelColumns(conn, startIndex, endIndex, orgDatabase, table)
 }
 
-func (w *PrometheusWriter) addAppLabelColumns(conn common.DBs, startIndex, endIndex int, orgDatabase string) error {
-	prometheusTables := []string{PROMETHEUS_TABLE, PROMETHEUS_TABLE + ckdb.LOCAL_SUBFFIX}
+func (w *PrometheusWriter) addAppLabelColumns(conn common.DBs, startIndex, endIndex int, orgDatabase, tableName string) error {
+	prometheusTables := []string{tableName, tableName + ckdb.LOCAL_SUBFFIX}
 	if w.ckdbType == ckdb.CKDBTypeByconity {
-		prometheusTables = []string{PROMETHEUS_TABLE}
+		prometheusTables = []string{tableName}
 	}
 	for i := startIndex; i <= endIndex; i++ {
 		for _, table := range prometheusTables {
@@ -274,8 +280,8 @@ func (w *PrometheusWriter) addAppLabelColumns(conn common.DBs, startIndex, endIn
 	return nil
 }
 
-func (w *PrometheusWriter) getCurrentAppLabelColumnCount(orgDatabase string) (int, error) {
-	sql := fmt.Sprintf("SELECT count(0) FROM system.%s where database='%s' and table='%s' and name like '%%app_label_value%%'", w.systemColumnsTableName, orgDatabase, PROMETHEUS_TABLE)
+func (w *PrometheusWriter) getCurrentAppLabelColumnCount(orgDatabase, table string) (int, error) {
+	sql := fmt.Sprintf("SELECT count(0) FROM system.%s where database='%s' and table='%s' and name like '%%app_label_value%%'", w.systemColumnsTableName, orgDatabase, table)
 	log.Info(sql)
 	rows, err := w.ckdbConn.Query(sql)
 	if err != nil {
@@ -297,9 +303,9 @@ func (w *PrometheusWriter) getCurrentAppLabelColumnCount(orgDatabase string) (in
 	return minCount, nil
 }
 
-func (w *PrometheusWriter) getMaxAppLabelColumnIndex(orgDatabase string) (int, error) {
+func (w *PrometheusWriter) getMaxAppLabelColumnIndex(orgDatabase, table string) (int, error) {
 	var name, maxName string
-	sql := fmt.Sprintf("WITH (SELECT max(length(name)) FROM system.%s where database='%s' and  table='%s' and name like '%%app_label_value%%') as maxNameLength SELECT max(name) from system.%s where database='%s' and  table='%s' and name like '%%app_label_value%%' and length(name)=maxNameLength", w.systemColumnsTableName, orgDatabase, PROMETHEUS_TABLE, w.systemColumnsTableName, orgDatabase, PROMETHEUS_TABLE)
+	sql := fmt.Sprintf("WITH (SELECT max(length(name)) FROM system.%s where database='%s' and  table='%s' and name like '%%app_label_value%%') as maxNameLength SELECT max(name) from system.%s where database='%s' and  table='%s' and name like '%%app_label_value%%' and length(name)=maxNameLength", w.systemColumnsTableName, orgDatabase, table, w.systemColumnsTableName, orgDatabase, table)
 	log.Info(sql)
 	rows, err := w.ckdbConn.Query(sql)
 	if err != nil {
@@ -74,6 +74,7 @@ type BuilderCounter struct {
 	TargetMiss        int64 `statsd:"target-miss"`
 	MetricTargetMiss  int64 `statsd:"metric-target-miss"`
 	Sample            int64 `statsd:"sample-out"`
+	Histogram         int64 `statsd:"histogram-out"`
 }
 
 type PrometheusSamplesBuilder struct {
@@ -87,6 +88,7 @@ type PrometheusSamplesBuilder struct {
 	// temporary buffers
 	metricName              string
 	samplesBuffer           []interface{} // store all Samples in a TimeSeries.
+	histogramsBuffer        []interface{} // store all native Histograms in a TimeSeries.
 	timeSeriesBuffer        *prompb.TimeSeries
 	tsLabelNameIDsBuffer    []uint32 // store timeSeries labelNameIDs without metricName
 	tsLabelValueIDsBuffer   []uint32 // store timeSeries labelValueIDs without metricID
@@ -301,7 +303,8 @@ func (d *Decoder) sendPrometheus(vtapID uint16, ts *prompb.TimeSeries, extraLabe
 		return
 	}
 	d.prometheusWriter.WriteBatch(builder.samplesBuffer, builder.metricName, builder.timeSeriesBuffer, extraLabels, builder.tsLabelNameIDsBuffer, builder.tsLabelValueIDsBuffer)
-	d.counter.OutCount += int64(len(builder.samplesBuffer))
+	d.prometheusWriter.WriteBatch(builder.histogramsBuffer, builder.metricName, builder.timeSeriesBuffer, extraLabels, builder.tsLabelNameIDsBuffer, builder.tsLabelValueIDsBuffer)
+	d.counter.OutCount += int64(len(builder.samplesBuffer) + len(builder.histogramsBuffer))
 	d.counter.TimeSeriesOut++
 }
 
@@ -321,13 +324,14 @@ func (b *PrometheusSamplesBuilder) GetEpcPodClusterId(orgId, vtapID uint16) (uin
 // if failed, return false,err
 // if isSlow, return true,slowReason
 func (b *PrometheusSamplesBuilder) TimeSeriesToStore(vtapID, epcId, podClusterId, orgId, teamID uint16, ts *prompb.TimeSeries, extraLabels []prompb.Label) (bool, error) {
-	if len(ts.Samples) == 0 {
+	if len(ts.Samples) == 0 && len(ts.Histograms) == 0 {
 		b.counter.TimeSeriesInvaild++
 		return false, fmt.Errorf("prometheum samples of time serries(%s) is empty.", ts)
 	}
 	b.counter.TimeSeriesIn++
 
 	b.samplesBuffer = b.samplesBuffer[:0]
+	b.histogramsBuffer = b.histogramsBuffer[:0]
 	b.timeSeriesBuffer = ts
 	b.tsLabelNameIDsBuffer = b.tsLabelNameIDsBuffer[:0]
 	b.tsLabelValueIDsBuffer = b.tsLabelValueIDsBuffer[:0]
@@ -431,7 +435,7 @@ func (b *PrometheusSamplesBuilder) TimeSeriesToStore(vtapID, epcId, podClusterId
 
 		if b.ignoreUniversalTag {
 			m := dbwriter.AcquirePrometheusSampleMini()
-			m.Timestamp = uint32(model.Time(s.Timestamp).Unix())
+			m.SetTimestamp(s.Timestamp)
 			m.MetricID = metricID
 			m.AppLabelValueIDs = append(m.AppLabelValueIDs, b.appLabelValueIDsBuffer...)
 			m.Value = v
@@ -440,7 +444,7 @@ func (b *PrometheusSamplesBuilder) TimeSeriesToStore(vtapID, epcId, podClusterId
 			m.OrgId, m.TeamID = orgId, teamID
 		} else {
 			m := dbwriter.AcquirePrometheusSample()
-			m.Timestamp = uint32(model.Time(s.Timestamp).Unix())
+			m.SetTimestamp(s.Timestamp)
 			m.MetricID = metricID
 			m.AppLabelValueIDs = append(m.AppLabelValueIDs, b.appLabelValueIDsBuffer...)
 			m.Value = v
@@ -463,6 +467,29 @@ func (b *PrometheusSamplesBuilder) TimeSeriesToStore(vtapID, epcId, podClusterId
 
 		b.counter.Sample++
 	}
+
+	for i := range ts.Histograms {
+		h := &ts.Histograms[i]
+		m := dbwriter.AcquirePrometheusHistogramSample()
+		m.SetTimestamp(h.Timestamp)
+		m.MetricID = metricID
+		m.AppLabelValueIDs = append(m.AppLabelValueIDs, b.appLabelValueIDsBuffer...)
+		m.VtapId = vtapID
+		m.OrgId, m.TeamID = orgId, teamID
+		m.Histogram.Fill(h)
+
+		if !b.ignoreUniversalTag {
+			if universalTag == nil {
+				b.fillUniversalTag(&m.PrometheusSample, vtapID, podName, instance, podNameID, instanceID, false)
+				universalTag = &m.UniversalTag
+			} else {
+				m.UniversalTag = *universalTag
+			}
+		}
+		b.histogramsBuffer = append(b.histogramsBuffer, m)
+
+		b.counter.Histogram++
+	}
 	return false, nil
 }
 
@@ -561,9 +588,9 @@ func (b *PrometheusSamplesBuilder) fillUniversalTagSlow(m *dbwriter.PrometheusSa
 
 	var info *grpc.Info
 	if t.IsIPv6 == 1 {
-		info = b.platformData.QueryIPV6Infos(m.OrgId, t.L3EpcID, t.IP6)
+		info = b.platformData.QueryIPV6Infos(m.OrgId, t.L3EpcID, t.IP6, m.Timestamp)
 	} else {
-		info = b.platformData.QueryIPV4Infos(m.OrgId, t.L3EpcID, t.IP)
+		info = b.platformData.QueryIPV4Infos(m.OrgId, t.L3EpcID, t.IP, m.Timestamp)
 	}
 	podGroupType := uint8(0)
 	if info != nil {
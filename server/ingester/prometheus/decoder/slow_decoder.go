@@ -77,9 +77,24 @@ func AcquireSlowItem(vtapId, epcId, podClusterId, orgId, teamId uint16, ts *prom
 	}
 
 	s.ts.Samples = append(s.ts.Samples, ts.Samples...)
+	for i := range ts.Histograms {
+		s.ts.Histograms = append(s.ts.Histograms, cloneHistogram(&ts.Histograms[i]))
+	}
 	return s
 }
 
+// the buckets of ts.Histograms are from temporary memory, so they need to be cloned
+func cloneHistogram(h *prompb.Histogram) prompb.Histogram {
+	c := *h
+	c.NegativeSpans = append([]prompb.BucketSpan(nil), h.NegativeSpans...)
+	c.NegativeDeltas = append([]int64(nil), h.NegativeDeltas...)
+	c.NegativeCounts = append([]float64(nil), h.NegativeCounts...)
+	c.PositiveSpans = append([]prompb.BucketSpan(nil), h.PositiveSpans...)
+	c.PositiveDeltas = append([]int64(nil), h.PositiveDeltas...)
+	c.PositiveCounts = append([]float64(nil), h.PositiveCounts...)
+	return c
+}
+
 func ReleaseSlowItem(s *SlowItem) {
 	if s.ts.Labels != nil {
 		s.ts.Labels = s.ts.Labels[:0]
@@ -87,6 +102,9 @@ func ReleaseSlowItem(s *SlowItem) {
 	if s.ts.Samples != nil {
 		s.ts.Samples = s.ts.Samples[:0]
 	}
+	if s.ts.Histograms != nil {
+		s.ts.Histograms = s.ts.Histograms[:0]
+	}
 	slowItemPool.Put(s)
 }
 
@@ -293,6 +311,11 @@ func (d *SlowDecoder) sendPrometheusSamples(vtapID, epcId, podClusterId, orgId,
 		d.samplesBuilder.timeSeriesBuffer,
 		nil,
 		d.samplesBuilder.tsLabelNameIDsBuffer, d.samplesBuilder.tsLabelValueIDsBuffer)
-	d.counter.SampleOut += int64(len(d.samplesBuilder.samplesBuffer))
+	d.prometheusWriter.WriteBatch(d.samplesBuilder.histogramsBuffer,
+		d.samplesBuilder.metricName,
+		d.samplesBuilder.timeSeriesBuffer,
+		nil,
+		d.samplesBuilder.tsLabelNameIDsBuffer, d.samplesBuilder.tsLabelValueIDsBuffer)
+	d.counter.SampleOut += int64(len(d.samplesBuilder.samplesBuffer) + len(d.samplesBuilder.histogramsBuffer))
 	d.counter.TimeSeriesOut++
 }
@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eventapi
+
+import "github.com/deepflowio/deepflow/server/libs/pool"
+
+// SlowQueryEvent is a query executed by querier which costs more than the slow query threshold
+type SlowQueryEvent struct {
+	Time          uint32 // end time of the query, unit: s
+	StartTime     int64  // unit: us
+	Duration      uint64 // unit: us
+	QueryUUID     string
+	DB            string
+	Sql           string // DeepFlow SQL
+	TranslatedSql string // ClickHouse SQL, multiple sqls are separated by ';'
+	ReadRows      uint64
+	ReadBytes     uint64
+	ResultRows    uint64
+	Error         string
+
+	UserID uint32
+	ORGID  uint16
+}
+
+func (e *SlowQueryEvent) Release() {
+	ReleaseSlowQueryEvent(e)
+}
+
+var poolSlowQueryEvent = pool.NewLockFreePool(func() interface{} {
+	return new(SlowQueryEvent)
+})
+
+func AcquireSlowQueryEvent() *SlowQueryEvent {
+	return poolSlowQueryEvent.Get().(*SlowQueryEvent)
+}
+
+func ReleaseSlowQueryEvent(event *SlowQueryEvent) {
+	if event == nil {
+		return
+	}
+	*event = SlowQueryEvent{}
+	poolSlowQueryEvent.Put(event)
+}
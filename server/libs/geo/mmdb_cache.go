@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package geo
+
+import (
+	"sync"
+)
+
+const (
+	MMDB_CACHE_SIZE   = 1 << 16
+	mmdbCacheShardNum = 64
+)
+
+type mmdbCacheShard struct {
+	sync.RWMutex
+	infos map[uintptr]IPGeoInfo
+}
+
+// mmdbCache caches the decoded records by their offsets in the data section. It is split into shards to reduce
+// the lock contention of concurrent lookups, and a shard is cleared when it is full.
+type mmdbCache struct {
+	shards [mmdbCacheShardNum]mmdbCacheShard
+}
+
+func newMMDBCache() *mmdbCache {
+	c := &mmdbCache{}
+	for i := range c.shards {
+		c.shards[i].infos = make(map[uintptr]IPGeoInfo)
+	}
+	return c
+}
+
+func (c *mmdbCache) shard(offset uintptr) *mmdbCacheShard {
+	// offsets of records are not aligned, the low bits are distributed evenly
+	return &c.shards[offset%mmdbCacheShardNum]
+}
+
+func (c *mmdbCache) get(offset uintptr) (IPGeoInfo, bool) {
+	shard := c.shard(offset)
+	shard.RLock()
+	info, ok := shard.infos[offset]
+	shard.RUnlock()
+	return info, ok
+}
+
+func (c *mmdbCache) set(offset uintptr, info IPGeoInfo) {
+	shard := c.shard(offset)
+	shard.Lock()
+	if len(shard.infos) >= MMDB_CACHE_SIZE/mmdbCacheShardNum {
+		shard.infos = make(map[uintptr]IPGeoInfo)
+	}
+	shard.infos[offset] = info
+	shard.Unlock()
+}
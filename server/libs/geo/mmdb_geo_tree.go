@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package geo
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// IPGeoInfo is the global geo and ASN info of an IPv4 or IPv6 address
+type IPGeoInfo struct {
+	Country        string // ISO 3166-1 alpha-2 code
+	City           string
+	ASN            uint32
+	ASOrganization string
+}
+
+// IPGeoTree queries the global geo and ASN info, unlike GeoTree, it supports IPv6 and IPs outside China
+type IPGeoTree interface {
+	QueryIP(ip net.IP) IPGeoInfo
+}
+
+type mmdbDatabase struct {
+	path    string
+	modTime time.Time
+	size    int64
+	reader  *maxminddb.Reader
+
+	// IPs of the same network share the same record, decoded records are cached by offset
+	cache *mmdbCache
+}
+
+func loadMMDBDatabase(path string) (*mmdbDatabase, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// the file is read into memory instead of mmap, so that the old reader is still valid after reloading
+	reader, err := maxminddb.FromBytes(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("load %s failed: %s", path, err)
+	}
+	return &mmdbDatabase{
+		path:    path,
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+		reader:  reader,
+		cache:   newMMDBCache(),
+	}, nil
+}
+
+func (d *mmdbDatabase) query(ip net.IP) IPGeoInfo {
+	offset, err := d.reader.LookupOffset(ip)
+	if err != nil || offset == maxminddb.NotFound {
+		return IPGeoInfo{}
+	}
+	if info, ok := d.cache.get(offset); ok {
+		return info
+	}
+	// records of different database types are decoded generally as maps
+	var record interface{}
+	if err := d.reader.Decode(offset, &record); err != nil {
+		log.Warningf("decode record of %s in %s failed: %s", ip, d.path, err)
+	}
+	info := recordToIPGeoInfo(record)
+	d.cache.set(offset, info)
+	return info
+}
+
+func getPath(record interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		m, ok := record.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		record = m[key]
+	}
+	return record
+}
+
+// recordToIPGeoInfo supports records of the MaxMind GeoIP2/GeoLite2 and DB-IP City, Country and ASN databases,
+// and the IPinfo databases
+func recordToIPGeoInfo(record interface{}) IPGeoInfo {
+	info := IPGeoInfo{}
+	if country, ok := getPath(record, "country", "iso_code").(string); ok {
+		info.Country = country
+	} else if country, ok := getPath(record, "registered_country", "iso_code").(string); ok {
+		info.Country = country
+	} else if country, ok := getPath(record, "country_code").(string); ok {
+		info.Country = country
+	}
+	if city, ok := getPath(record, "city", "names", "en").(string); ok {
+		info.City = city
+	} else if city, ok := getPath(record, "city").(string); ok {
+		info.City = city
+	}
+	if asn, ok := getPath(record, "autonomous_system_number").(uint64); ok {
+		info.ASN = uint32(asn)
+	} else if asn, ok := getPath(record, "asn").(string); ok {
+		number, _ := strconv.ParseUint(strings.TrimPrefix(asn, "AS"), 10, 32)
+		info.ASN = uint32(number)
+	}
+	if organization, ok := getPath(record, "autonomous_system_organization").(string); ok {
+		info.ASOrganization = organization
+	} else if organization, ok := getPath(record, "as_name").(string); ok {
+		info.ASOrganization = organization
+	}
+	return info
+}
+
+// MMDBGeoTree queries IPGeoInfo from MaxMind DB files, such as GeoLite2-City and GeoLite2-ASN.
+// Fields of the info are taken from the first database which has them, and files are reloaded when they change.
+type MMDBGeoTree struct {
+	paths     []string
+	databases atomic.Value // []*mmdbDatabase
+	exit      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewMMDBGeoTree(paths []string) (*MMDBGeoTree, error) {
+	databases := make([]*mmdbDatabase, 0, len(paths))
+	for _, path := range paths {
+		database, err := loadMMDBDatabase(path)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("load geo database %s, type: %s, build epoch: %d", path, database.reader.Metadata.DatabaseType, database.reader.Metadata.BuildEpoch)
+		databases = append(databases, database)
+	}
+	t := &MMDBGeoTree{paths: paths, exit: make(chan struct{})}
+	t.databases.Store(databases)
+	return t, nil
+}
+
+func (t *MMDBGeoTree) QueryIP(ip net.IP) IPGeoInfo {
+	info := IPGeoInfo{}
+	if ip == nil || !ip.IsGlobalUnicast() {
+		return info
+	}
+	for _, database := range t.databases.Load().([]*mmdbDatabase) {
+		dbInfo := database.query(ip)
+		if info.Country == "" {
+			info.Country = dbInfo.Country
+		}
+		if info.City == "" {
+			info.City = dbInfo.City
+		}
+		if info.ASN == 0 {
+			info.ASN = dbInfo.ASN
+		}
+		if info.ASOrganization == "" {
+			info.ASOrganization = dbInfo.ASOrganization
+		}
+	}
+	return info
+}
+
+// Reload loads the files which are modified, the old database is kept if a file fails to load
+func (t *MMDBGeoTree) Reload() {
+	databases := t.databases.Load().([]*mmdbDatabase)
+	var reloaded []*mmdbDatabase
+	for i, database := range databases {
+		stat, err := os.Stat(database.path)
+		if err != nil {
+			log.Warningf("stat geo database %s failed: %s", database.path, err)
+			continue
+		}
+		if stat.ModTime().Equal(database.modTime) && stat.Size() == database.size {
+			continue
+		}
+		newDatabase, err := loadMMDBDatabase(database.path)
+		if err != nil {
+			log.Warningf("reload geo database failed: %s", err)
+			continue
+		}
+		if reloaded == nil {
+			reloaded = append([]*mmdbDatabase{}, databases...)
+		}
+		reloaded[i] = newDatabase
+		log.Infof("reload geo database %s, build epoch: %d", database.path, newDatabase.reader.Metadata.BuildEpoch)
+	}
+	if reloaded != nil {
+		t.databases.Store(reloaded)
+	}
+}
+
+// Start reloads the modified files every interval until Close
+func (t *MMDBGeoTree) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.Reload()
+			case <-t.exit:
+				return
+			}
+		}
+	}()
+}
+
+func (t *MMDBGeoTree) Close() {
+	t.closeOnce.Do(func() { close(t.exit) })
+}
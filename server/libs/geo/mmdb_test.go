@@ -0,0 +1,302 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package geo
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// The MaxMind DB file format
+// Ref: https://maxmind.github.io/MaxMind-DB/
+const (
+	mmdbDataSectionSeparatorSize = 16
+
+	mmdbTypePointer = 1
+	mmdbTypeString  = 2
+	mmdbTypeUint16  = 5
+	mmdbTypeUint32  = 6
+	mmdbTypeMap     = 7
+	mmdbTypeUint64  = 9
+)
+
+var mmdbMetadataStartMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// mmdbTestWriter writes MaxMind DB files with only the features needed by tests
+type mmdbTestWriter struct {
+	recordSize int
+	// children of nodes, -1 means empty, data records are stored as -2-index
+	nodes [][2]int
+	data  []byte
+}
+
+func newMMDBTestWriter(recordSize int) *mmdbTestWriter {
+	return &mmdbTestWriter{recordSize: recordSize, nodes: [][2]int{{-1, -1}}}
+}
+
+func encodeMMDBCtrl(dataType int, size int) []byte {
+	var b []byte
+	if dataType > 7 {
+		b = []byte{0, byte(dataType - 7)}
+	} else {
+		b = []byte{byte(dataType << 5)}
+	}
+	if size < 29 {
+		b[0] |= byte(size)
+	} else {
+		b[0] |= 29
+		b = append(b, byte(size-29))
+	}
+	return b
+}
+
+func encodeMMDBValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		return append(encodeMMDBCtrl(mmdbTypeString, len(v)), v...)
+	case uint32:
+		b := []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+		for len(b) > 0 && b[0] == 0 {
+			b = b[1:]
+		}
+		return append(encodeMMDBCtrl(mmdbTypeUint32, len(b)), b...)
+	case uint16:
+		return append(encodeMMDBCtrl(mmdbTypeUint16, 2), byte(v>>8), byte(v))
+	case uint64:
+		return append(encodeMMDBCtrl(mmdbTypeUint64, 8), byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	case mmdbTestPointer:
+		return []byte{byte(mmdbTypePointer<<5) | byte(v>>8&0x7), byte(v)}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b := encodeMMDBCtrl(mmdbTypeMap, len(v))
+		for _, key := range keys {
+			b = append(b, encodeMMDBValue(key)...)
+			b = append(b, encodeMMDBValue(v[key])...)
+		}
+		return b
+	}
+	panic("unsupported type")
+}
+
+type mmdbTestPointer uint16
+
+// addData appends a value to the data section and returns its offset
+func (w *mmdbTestWriter) addData(value interface{}) int {
+	offset := len(w.data)
+	w.data = append(w.data, encodeMMDBValue(value)...)
+	return offset
+}
+
+func (w *mmdbTestWriter) insert(cidr string, dataOffset int) {
+	_, network, _ := net.ParseCIDR(cidr)
+	ones, bits := network.Mask.Size()
+	ip := network.IP.To16()
+	// IPv4 networks are in the ::/96 subtree
+	if bits == 32 {
+		ip = append(make(net.IP, 12), network.IP.To4()...)
+		ones += 96
+	}
+	node := 0
+	for i := 0; i < ones; i++ {
+		bit := int(ip[i>>3]>>(7-uint(i&7))) & 1
+		if i == ones-1 {
+			w.nodes[node][bit] = -2 - dataOffset
+			return
+		}
+		if w.nodes[node][bit] < 0 {
+			w.nodes = append(w.nodes, [2]int{-1, -1})
+			w.nodes[node][bit] = len(w.nodes) - 1
+		}
+		node = w.nodes[node][bit]
+	}
+}
+
+func (w *mmdbTestWriter) bytes() []byte {
+	nodeCount := len(w.nodes)
+	record := func(v int) uint32 {
+		if v == -1 {
+			return uint32(nodeCount)
+		} else if v < -1 {
+			return uint32(nodeCount + mmdbDataSectionSeparatorSize - 2 - v)
+		}
+		return uint32(v)
+	}
+	var buffer []byte
+	for _, node := range w.nodes {
+		left, right := record(node[0]), record(node[1])
+		switch w.recordSize {
+		case 24:
+			buffer = append(buffer, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+		case 28:
+			buffer = append(buffer, byte(left>>16), byte(left>>8), byte(left), byte(left>>24&0xF)<<4|byte(right>>24&0xF), byte(right>>16), byte(right>>8), byte(right))
+		default:
+			buffer = append(buffer, byte(left>>24), byte(left>>16), byte(left>>8), byte(left), byte(right>>24), byte(right>>16), byte(right>>8), byte(right))
+		}
+	}
+	buffer = append(buffer, make([]byte, mmdbDataSectionSeparatorSize)...)
+	buffer = append(buffer, w.data...)
+	buffer = append(buffer, mmdbMetadataStartMarker...)
+	buffer = append(buffer, encodeMMDBValue(map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(w.recordSize),
+		"ip_version":                  uint16(6),
+		"database_type":               "Test",
+		"build_epoch":                 uint64(1700000000),
+		"binary_format_major_version": uint16(2),
+	})...)
+	return buffer
+}
+
+func newTestCityDatabase(recordSize int, city string) []byte {
+	w := newMMDBTestWriter(recordSize)
+	// the country is shared by records through a pointer
+	countryOffset := w.addData(map[string]interface{}{"iso_code": "US"})
+	w.insert("8.8.8.0/24", w.addData(map[string]interface{}{
+		"country": mmdbTestPointer(countryOffset),
+		"city":    map[string]interface{}{"names": map[string]interface{}{"en": city}},
+	}))
+	w.insert("2001:4860::/32", w.addData(map[string]interface{}{
+		"country": mmdbTestPointer(countryOffset),
+	}))
+	w.insert("1.0.0.0/8", w.addData(map[string]interface{}{
+		"registered_country": map[string]interface{}{"iso_code": "AU"},
+	}))
+	return w.bytes()
+}
+
+func newTestASNDatabase() []byte {
+	w := newMMDBTestWriter(24)
+	record := w.addData(map[string]interface{}{
+		"autonomous_system_number":       uint32(15169),
+		"autonomous_system_organization": "GOOGLE",
+	})
+	w.insert("8.8.0.0/16", record)
+	w.insert("2001:4860::/32", record)
+	return w.bytes()
+}
+
+func TestRecordToIPGeoInfo(t *testing.T) {
+	for _, recordSize := range []int{24, 28, 32} {
+		reader, err := maxminddb.FromBytes(newTestCityDatabase(recordSize, "Mountain View"))
+		if err != nil {
+			t.Fatalf("record size %d: %s", recordSize, err)
+		}
+		cases := []struct {
+			ip   string
+			info IPGeoInfo
+		}{
+			{"8.8.8.8", IPGeoInfo{Country: "US", City: "Mountain View"}},
+			{"8.8.4.4", IPGeoInfo{}},
+			{"1.1.1.1", IPGeoInfo{Country: "AU"}},
+			{"2001:4860:4860::8888", IPGeoInfo{Country: "US"}},
+			{"2400:cb00::1", IPGeoInfo{}},
+		}
+		for _, c := range cases {
+			var record interface{}
+			if err := reader.Lookup(net.ParseIP(c.ip), &record); err != nil {
+				t.Fatalf("record size %d: lookup %s failed: %s", recordSize, c.ip, err)
+			}
+			if info := recordToIPGeoInfo(record); info != c.info {
+				t.Errorf("record size %d: lookup %s get %+v, expected %+v", recordSize, c.ip, info, c.info)
+			}
+		}
+	}
+
+	// IPinfo databases
+	record := map[string]interface{}{"country_code": "DE", "city": "Berlin", "asn": "AS3320", "as_name": "Deutsche Telekom AG"}
+	expected := IPGeoInfo{Country: "DE", City: "Berlin", ASN: 3320, ASOrganization: "Deutsche Telekom AG"}
+	if info := recordToIPGeoInfo(record); info != expected {
+		t.Errorf("get %+v, expected %+v", info, expected)
+	}
+}
+
+func TestMMDBCache(t *testing.T) {
+	cache := newMMDBCache()
+	cache.set(1, IPGeoInfo{Country: "US"})
+	if info, ok := cache.get(1); !ok || info.Country != "US" {
+		t.Errorf("get %+v, %v", info, ok)
+	}
+	if _, ok := cache.get(1 + mmdbCacheShardNum); ok {
+		t.Error("offset not set should not be found")
+	}
+	// a full shard is cleared
+	for i := 0; i <= MMDB_CACHE_SIZE/mmdbCacheShardNum; i++ {
+		cache.set(uintptr(i*mmdbCacheShardNum+2), IPGeoInfo{})
+	}
+	if len(cache.shard(2).infos) != 1 {
+		t.Errorf("shard should be cleared when full, get %d", len(cache.shard(2).infos))
+	}
+	if _, ok := cache.get(1); !ok {
+		t.Error("other shards should not be cleared")
+	}
+}
+
+func TestMMDBGeoTree(t *testing.T) {
+	dir := t.TempDir()
+	cityPath, asnPath := filepath.Join(dir, "city.mmdb"), filepath.Join(dir, "asn.mmdb")
+	if err := os.WriteFile(cityPath, newTestCityDatabase(24, "Mountain View"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(asnPath, newTestASNDatabase(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := NewMMDBGeoTree([]string{cityPath, asnPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	expected := IPGeoInfo{Country: "US", City: "Mountain View", ASN: 15169, ASOrganization: "GOOGLE"}
+	if info := tree.QueryIP(net.ParseIP("8.8.8.8")); info != expected {
+		t.Errorf("get %+v, expected %+v", info, expected)
+	}
+	expected = IPGeoInfo{Country: "US", ASN: 15169, ASOrganization: "GOOGLE"}
+	if info := tree.QueryIP(net.ParseIP("2001:4860::1")); info != expected {
+		t.Errorf("get %+v, expected %+v", info, expected)
+	}
+	if info := tree.QueryIP(net.ParseIP("10.1.1.1")); info != (IPGeoInfo{}) {
+		t.Errorf("private ip get %+v", info)
+	}
+
+	// broken files are not loaded
+	if err := os.WriteFile(cityPath, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tree.Reload()
+	if info := tree.QueryIP(net.ParseIP("8.8.8.8")); info.City != "Mountain View" {
+		t.Errorf("old database should be kept, get %+v", info)
+	}
+	if err := os.WriteFile(cityPath, newTestCityDatabase(28, "Palo Alto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(cityPath, future, future)
+	tree.Reload()
+	if info := tree.QueryIP(net.ParseIP("8.8.8.8")); info.City != "Palo Alto" || info.ASN != 15169 {
+		t.Errorf("database should be reloaded, get %+v", info)
+	}
+}
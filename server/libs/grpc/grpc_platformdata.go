@@ -141,6 +141,8 @@ type Counter struct {
 	ContainerTotalCount int64 `statsd:"container-total-count"`
 	ContainerHitCount   int64 `statsd:"container-hit-count"`
 	ContainerMissCount  int64 `statsd:"container-miss-count"`
+	HistoryQueryCount   int64 `statsd:"history-query-count"`   // queries resolved against retired platform data versions
+	HistoryExpiredCount int64 `statsd:"history-expired-count"` // queries older than all the retained versions
 }
 
 type PlatformInfoTable struct {
@@ -178,6 +180,10 @@ type PlatformInfoTable struct {
 	versionPlatformData [MAX_ORG_COUNT]uint64
 	ctlIP               string
 
+	// the time when the current platform data version became valid, and the retired versions in ascending order
+	versionStartTime    [MAX_ORG_COUNT]uint32
+	platformDataHistory [MAX_ORG_COUNT][]*platformDataVersion
+
 	hostname   string
 	runtimeEnv utils.RuntimeEnv
 
@@ -280,24 +286,38 @@ func (t *PlatformInfoTable) QueryEpcIDBaseInfosPair(orgId uint16, epcID0, epcID1
 	return t.queryEpcIDBaseInfosPair(orgId, epcID0, epcID1)
 }
 
-func (t *PlatformInfoTable) QueryMacInfo(orgId uint16, mac uint64) *Info {
+// timestamp(s) is the time of the data, the platform data version valid at that time is used, 0 means the current version
+func (t *PlatformInfoTable) QueryMacInfo(orgId uint16, mac uint64, timestamp uint32) *Info {
+	if v := t.historyVersion(orgId, timestamp); v != nil {
+		return t.queryHistoryMacInfo(orgId, v, mac)
+	}
 	return t.queryMacInfo(orgId, mac)
 }
 
-func (t *PlatformInfoTable) QueryMacInfosPair(orgId uint16, mac0, mac1 uint64) (*Info, *Info) {
+func (t *PlatformInfoTable) QueryMacInfosPair(orgId uint16, mac0, mac1 uint64, timestamp uint32) (*Info, *Info) {
+	if t.isHistorical(orgId, timestamp) {
+		return t.QueryMacInfo(orgId, mac0, timestamp), t.QueryMacInfo(orgId, mac1, timestamp)
+	}
 	return t.queryMacInfosPair(orgId, mac0, mac1)
 }
 
-func (t *PlatformInfoTable) QueryIPV4Infos(orgId uint16, epcID int32, ipv4 uint32) *Info {
+func (t *PlatformInfoTable) QueryIPV4Infos(orgId uint16, epcID int32, ipv4 uint32, timestamp uint32) *Info {
 	if epcID == datatype.EPC_FROM_INTERNET {
 		return nil
 	}
-	info := t.queryIPV4Infos(orgId, epcID, ipv4)
-	if info != nil {
-		return info
+	var baseInfo *BaseInfo
+	if v := t.historyVersion(orgId, timestamp); v != nil {
+		if info := t.queryHistoryIPV4Infos(v, epcID, ipv4); info != nil {
+			return info
+		}
+		baseInfo = t.queryHistoryEpcIDBaseInfo(orgId, v, epcID)
+	} else {
+		if info := t.queryIPV4Infos(orgId, epcID, ipv4); info != nil {
+			return info
+		}
+		baseInfo = t.queryEpcIDBaseInfo(orgId, int32(epcID))
 	}
 
-	baseInfo := t.queryEpcIDBaseInfo(orgId, int32(epcID))
 	if baseInfo == nil {
 		return nil
 	}
@@ -306,16 +326,23 @@ func (t *PlatformInfoTable) QueryIPV4Infos(orgId uint16, epcID int32, ipv4 uint3
 	}
 }
 
-func (t *PlatformInfoTable) QueryIPV6Infos(orgId uint16, epcID int32, ipv6 net.IP) *Info {
+func (t *PlatformInfoTable) QueryIPV6Infos(orgId uint16, epcID int32, ipv6 net.IP, timestamp uint32) *Info {
 	if epcID == datatype.EPC_FROM_INTERNET {
 		return nil
 	}
-	info := t.queryIPV6Infos(orgId, epcID, ipv6)
-	if info != nil {
-		return info
+	var baseInfo *BaseInfo
+	if v := t.historyVersion(orgId, timestamp); v != nil {
+		if info := t.queryHistoryIPV6Infos(v, epcID, ipv6); info != nil {
+			return info
+		}
+		baseInfo = t.queryHistoryEpcIDBaseInfo(orgId, v, epcID)
+	} else {
+		if info := t.queryIPV6Infos(orgId, epcID, ipv6); info != nil {
+			return info
+		}
+		baseInfo = t.queryEpcIDBaseInfo(orgId, int32(epcID))
 	}
 
-	baseInfo := t.queryEpcIDBaseInfo(orgId, int32(epcID))
 	if baseInfo == nil {
 		return nil
 	}
@@ -324,11 +351,13 @@ func (t *PlatformInfoTable) QueryIPV6Infos(orgId uint16, epcID int32, ipv6 net.I
 	}
 }
 
-func (t *PlatformInfoTable) QueryIPV4InfosPair(orgId uint16, epcID0 int32, ipv40 uint32, epcID1 int32, ipv41 uint32) (info0 *Info, info1 *Info) {
+func (t *PlatformInfoTable) QueryIPV4InfosPair(orgId uint16, epcID0 int32, ipv40 uint32, epcID1 int32, ipv41 uint32, timestamp uint32) (info0 *Info, info1 *Info) {
 	if epcID0 == datatype.EPC_FROM_INTERNET {
-		return nil, t.QueryIPV4Infos(orgId, epcID1, ipv41)
+		return nil, t.QueryIPV4Infos(orgId, epcID1, ipv41, timestamp)
 	} else if epcID1 == datatype.EPC_FROM_INTERNET {
-		return t.QueryIPV4Infos(orgId, epcID0, ipv40), nil
+		return t.QueryIPV4Infos(orgId, epcID0, ipv40, timestamp), nil
+	} else if t.isHistorical(orgId, timestamp) {
+		return t.QueryIPV4Infos(orgId, epcID0, ipv40, timestamp), t.QueryIPV4Infos(orgId, epcID1, ipv41, timestamp)
 	}
 	info0, info1 = t.queryIPV4InfosPair(orgId, epcID0, ipv40, epcID1, ipv41)
 	if info0 == nil {
@@ -348,11 +377,13 @@ func (t *PlatformInfoTable) QueryIPV4InfosPair(orgId uint16, epcID0 int32, ipv40
 	return
 }
 
-func (t *PlatformInfoTable) QueryIPV6InfosPair(orgId uint16, epcID0 int32, ipv60 net.IP, epcID1 int32, ipv61 net.IP) (info0 *Info, info1 *Info) {
+func (t *PlatformInfoTable) QueryIPV6InfosPair(orgId uint16, epcID0 int32, ipv60 net.IP, epcID1 int32, ipv61 net.IP, timestamp uint32) (info0 *Info, info1 *Info) {
 	if epcID0 == datatype.EPC_FROM_INTERNET {
-		return nil, t.QueryIPV6Infos(orgId, epcID1, ipv61)
+		return nil, t.QueryIPV6Infos(orgId, epcID1, ipv61, timestamp)
 	} else if epcID1 == datatype.EPC_FROM_INTERNET {
-		return t.QueryIPV6Infos(orgId, epcID0, ipv60), nil
+		return t.QueryIPV6Infos(orgId, epcID0, ipv60, timestamp), nil
+	} else if t.isHistorical(orgId, timestamp) {
+		return t.QueryIPV6Infos(orgId, epcID0, ipv60, timestamp), t.QueryIPV6Infos(orgId, epcID1, ipv61, timestamp)
 	}
 	info0, info1 = t.queryIPV6InfosPair(orgId, epcID0, ipv60, epcID1, ipv61)
 	if info0 == nil {
@@ -411,11 +442,15 @@ type PlatformDataManager struct {
 	rpcMaxMsgSize int
 	nodeIP        string
 	receiver      *receiver.Receiver
+
+	// retired platform data versions retained for the data delayed or replayed, 0 means disabled
+	historyMaxVersions int
+	historyMaxAge      int // s, 0 means no limit
 }
 
 var platformDataManager *PlatformDataManager
 
-func NewPlatformDataManager(ips []net.IP, port, maxSlaveTableSize, rpcMaxMsgSize int, nodeIP string, receiver *receiver.Receiver) *PlatformDataManager {
+func NewPlatformDataManager(ips []net.IP, port, maxSlaveTableSize, rpcMaxMsgSize int, nodeIP string, receiver *receiver.Receiver, historyMaxVersions, historyMaxAge int) *PlatformDataManager {
 	if platformDataManager != nil {
 		return platformDataManager
 	}
@@ -427,6 +462,9 @@ func NewPlatformDataManager(ips []net.IP, port, maxSlaveTableSize, rpcMaxMsgSize
 		rpcMaxMsgSize:     rpcMaxMsgSize,
 		nodeIP:            nodeIP,
 		receiver:          receiver,
+
+		historyMaxVersions: historyMaxVersions,
+		historyMaxAge:      historyMaxAge,
 	}
 	return platformDataManager
 }
@@ -800,8 +838,12 @@ func (t *PlatformInfoTable) queryIPV6InfosPair(orgId uint16, epcID0 int32, ipv60
 
 // 查询Cidr之前，需要先查询过epcip表, 否则会覆盖epcip表的内容
 func (t *PlatformInfoTable) queryIPV4Cidr(orgId uint16, epcID int32, ipv4 uint32) *Info {
+	return queryIPV4CidrInfos(t.epcIDIPV4CidrInfos[orgId], epcID, ipv4)
+}
+
+func queryIPV4CidrInfos(epcIDIPV4CidrInfos map[int32][]*CidrInfo, epcID int32, ipv4 uint32) *Info {
 	var info *Info
-	if cidrInfos, exist := t.epcIDIPV4CidrInfos[orgId][int32(epcID)]; exist {
+	if cidrInfos, exist := epcIDIPV4CidrInfos[int32(epcID)]; exist {
 		ip := utils.IpFromUint32(ipv4)
 		for _, cidrInfo := range cidrInfos {
 			if cidrInfo.Cidr.Contains(ip) {
@@ -820,8 +862,12 @@ func (t *PlatformInfoTable) queryIPV4Cidr(orgId uint16, epcID int32, ipv4 uint32
 
 // 查询Cidr之前，需要先查询过epcip表, 否则会覆盖epcip表的内容
 func (t *PlatformInfoTable) queryIPV6Cidr(orgId uint16, epcID int32, ipv6 net.IP) *Info {
+	return queryIPV6CidrInfos(t.epcIDIPV6CidrInfos[orgId], epcID, ipv6)
+}
+
+func queryIPV6CidrInfos(epcIDIPV6CidrInfos map[int32][]*CidrInfo, epcID int32, ipv6 net.IP) *Info {
 	var info *Info
-	if cidrInfos, exist := t.epcIDIPV6CidrInfos[orgId][epcID]; exist {
+	if cidrInfos, exist := epcIDIPV6CidrInfos[epcID]; exist {
 		for _, cidrInfo := range cidrInfos {
 			if cidrInfo.Cidr.Contains(ipv6) {
 				info = &Info{
@@ -1026,6 +1072,8 @@ func (t *PlatformInfoTable) HandleSimpleCommand(op uint16, arg string) string {
 		return t.gprocessInfosString(orgId)
 	case "container":
 		return t.containersString(orgId)
+	case "history":
+		return t.historyString(orgId)
 	}
 
 	filter := arg
@@ -1066,7 +1114,9 @@ func (t *PlatformInfoTable) updateServices(orgId uint16, groupsData *trident.Gro
 	t.counter.UpdateServicesCount += int64(len(groupsData.GetSvcs()))
 }
 
-func (t *PlatformInfoTable) updatePlatformData(orgId uint16, platformData *trident.PlatformData) {
+// versionTime(s) is when the version is generated by the controller, the local time is used if it is not sent by
+// the controllers of old versions
+func (t *PlatformInfoTable) updatePlatformData(orgId uint16, platformData *trident.PlatformData, versionTime uint32) {
 	newEpcIDIPV4Infos := make(map[uint64]*Info)
 	newEpcIDIPV6Infos := make(map[[EpcIDIPV6_LEN]byte]*Info)
 	newMacInfos := make(map[uint64]*Info)
@@ -1092,6 +1142,10 @@ func (t *PlatformInfoTable) updatePlatformData(orgId uint16, platformData *tride
 	}
 	t.updatePeerConnections(orgId, platformData.GetPeerConnections())
 	t.updateGprocessInfos(orgId, platformData.GetGprocessInfos())
+	if versionTime == 0 {
+		versionTime = uint32(time.Now().Unix())
+	}
+	t.archivePlatformData(orgId, versionTime)
 
 	t.epcIDIPV4Infos[orgId] = newEpcIDIPV4Infos
 	t.epcIDIPV4CidrInfos[orgId] = newEpcIDIPV4CidrInfos
@@ -1183,6 +1237,9 @@ func (t *PlatformInfoTable) ReloadSlave(orgId uint16) error {
 		t.epcIDBaseInfos[orgId] = masterTable.epcIDBaseInfos[orgId]
 		t.epcIDBaseMissCount[orgId] = make(map[int32]*uint64)
 
+		t.versionStartTime[orgId] = masterTable.versionStartTime[orgId]
+		t.platformDataHistory[orgId] = masterTable.platformDataHistory[orgId]
+
 		t.containerHitCount[orgId] = make(map[string]*uint64)
 		t.containerMissCount[orgId] = make(map[string]*uint64)
 
@@ -1289,7 +1346,7 @@ func (t *PlatformInfoTable) ReloadMaster(orgId uint16) error {
 
 		if isUnmarshalSuccess {
 			log.Infof("update rpc platformdata version %d -> %d  regionID=%d", t.versionPlatformData[orgId], newVersion, t.regionID[orgId], logger.NewORGPrefix(int(orgId)))
-			t.updatePlatformData(orgId, &platformData)
+			t.updatePlatformData(orgId, &platformData, response.GetVersionPlatformDataTime())
 			t.otherRegionCount[orgId] = 0
 		}
 	}
@@ -1891,9 +1948,9 @@ func (t *PlatformInfoTable) updatePodIps(orgId uint16, podIps []*trident.PodIp)
 				isIPv4, ip4, ip6 := parseIP(ip)
 				var infoPtr *Info
 				if isIPv4 {
-					infoPtr = t.QueryIPV4Infos(orgId, epcId, ip4)
+					infoPtr = t.QueryIPV4Infos(orgId, epcId, ip4, 0)
 				} else {
-					infoPtr = t.QueryIPV6Infos(orgId, epcId, ip6)
+					infoPtr = t.QueryIPV6Infos(orgId, epcId, ip6, 0)
 				}
 				if infoPtr != nil {
 					info = *infoPtr
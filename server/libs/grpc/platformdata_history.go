@@ -0,0 +1,198 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/libs/datatype"
+)
+
+// platformDataVersion is a retired version of the platform data, which was valid in [startTime, endTime).
+// delayed or replayed data is enriched by the version valid at its timestamp, so that short-lived
+// pods reusing IPs are not tagged with the workload currently holding the IP.
+type platformDataVersion struct {
+	version   uint64
+	startTime uint32
+	endTime   uint32
+
+	epcIDIPV4Infos     map[uint64]*Info
+	epcIDIPV6Infos     map[[EpcIDIPV6_LEN]byte]*Info
+	epcIDIPV4CidrInfos map[int32][]*CidrInfo
+	epcIDIPV6CidrInfos map[int32][]*CidrInfo
+	macInfos           map[uint64]*Info
+	epcIDBaseInfos     map[int32]*BaseInfo
+}
+
+func (v *platformDataVersion) queryIPV4Infos(epcID int32, ipv4 uint32) *Info {
+	if info, ok := v.epcIDIPV4Infos[uint64(epcID)<<32|uint64(ipv4)]; ok {
+		return info
+	}
+	return queryIPV4CidrInfos(v.epcIDIPV4CidrInfos, epcID, ipv4)
+}
+
+func (v *platformDataVersion) queryIPV6Infos(epcID int32, ipv6 net.IP) *Info {
+	var key [EpcIDIPV6_LEN]byte
+	binary.LittleEndian.PutUint32(key[:], uint32(epcID))
+	copy(key[4:], ipv6)
+	if info, ok := v.epcIDIPV6Infos[key]; ok {
+		return info
+	}
+	return queryIPV6CidrInfos(v.epcIDIPV6CidrInfos, epcID, ipv6)
+}
+
+// archivePlatformData retires the current platform data of the org into the history before it is replaced,
+// the history is bounded by the max number of versions and the max age
+func (t *PlatformInfoTable) archivePlatformData(orgId uint16, now uint32) {
+	maxVersions, maxAge := 0, 0
+	if t.manager != nil {
+		maxVersions, maxAge = t.manager.historyMaxVersions, t.manager.historyMaxAge
+	}
+	if maxVersions <= 0 || t.versionPlatformData[orgId] == 0 {
+		t.versionStartTime[orgId] = now
+		return
+	}
+
+	history := t.platformDataHistory[orgId]
+	newHistory := make([]*platformDataVersion, 0, len(history)+1)
+	for _, v := range history {
+		if maxAge > 0 && v.endTime+uint32(maxAge) < now {
+			continue
+		}
+		newHistory = append(newHistory, v)
+	}
+	newHistory = append(newHistory, &platformDataVersion{
+		version:            t.versionPlatformData[orgId],
+		startTime:          t.versionStartTime[orgId],
+		endTime:            now,
+		epcIDIPV4Infos:     t.epcIDIPV4Infos[orgId],
+		epcIDIPV6Infos:     t.epcIDIPV6Infos[orgId],
+		epcIDIPV4CidrInfos: t.epcIDIPV4CidrInfos[orgId],
+		epcIDIPV6CidrInfos: t.epcIDIPV6CidrInfos[orgId],
+		macInfos:           t.macInfos[orgId],
+		epcIDBaseInfos:     t.epcIDBaseInfos[orgId],
+	})
+	if len(newHistory) > maxVersions {
+		newHistory = newHistory[len(newHistory)-maxVersions:]
+	}
+	// replace instead of modifying in place, the slave tables share the history of the master table
+	t.platformDataHistory[orgId] = newHistory
+	t.versionStartTime[orgId] = now
+}
+
+// isHistorical returns whether the data of the timestamp should be enriched by a retired version,
+// timestamp 0 means using the current version
+func (t *PlatformInfoTable) isHistorical(orgId uint16, timestamp uint32) bool {
+	return timestamp != 0 && timestamp < t.versionStartTime[orgId] && len(t.platformDataHistory[orgId]) > 0
+}
+
+// historyVersion returns the retired version valid at the timestamp, nil if the current version should be used
+func (t *PlatformInfoTable) historyVersion(orgId uint16, timestamp uint32) *platformDataVersion {
+	if !t.isHistorical(orgId, timestamp) {
+		return nil
+	}
+	history := t.platformDataHistory[orgId]
+	t.counter.HistoryQueryCount++
+	for i := len(history) - 1; i >= 0; i-- {
+		if timestamp >= history[i].startTime {
+			return history[i]
+		}
+	}
+	// older than all the retained versions, the oldest one is the closest
+	t.counter.HistoryExpiredCount++
+	return history[0]
+}
+
+// the history queries are counted in the same counters as the queries of the current version
+
+func (t *PlatformInfoTable) queryHistoryMacInfo(orgId uint16, v *platformDataVersion, mac uint64) *Info {
+	info, ok := v.macInfos[mac]
+	if !ok {
+		t.InfoMissStat(orgId, mac)
+	} else {
+		atomic.AddUint64(info.HitCount, 1)
+	}
+	return info
+}
+
+func (t *PlatformInfoTable) queryHistoryIPV4Infos(v *platformDataVersion, epcID int32, ipv4 uint32) *Info {
+	t.counter.IP4TotalCount++
+	info := v.queryIPV4Infos(epcID, ipv4)
+	if info == nil {
+		t.counter.IP4MissCount++
+	} else {
+		t.counter.IP4HitCount++
+		atomic.AddUint64(info.HitCount, 1)
+	}
+	return info
+}
+
+func (t *PlatformInfoTable) queryHistoryIPV6Infos(v *platformDataVersion, epcID int32, ipv6 net.IP) *Info {
+	t.counter.IP6TotalCount++
+	info := v.queryIPV6Infos(epcID, ipv6)
+	if info == nil {
+		t.counter.IP6MissCount++
+	} else {
+		t.counter.IP6HitCount++
+		atomic.AddUint64(info.HitCount, 1)
+	}
+	return info
+}
+
+func (t *PlatformInfoTable) queryHistoryEpcIDBaseInfo(orgId uint16, v *platformDataVersion, epcID int32) *BaseInfo {
+	if epcID == datatype.EPC_UNKNOWN {
+		return &BaseInfo{
+			RegionID: t.QueryRegionID(orgId),
+		}
+	}
+	baseInfo, ok := v.epcIDBaseInfos[epcID]
+	if !ok {
+		t.baseInfoMissStat(orgId, epcID)
+	} else {
+		atomic.AddUint64(&baseInfo.HitCount, 1)
+	}
+	return baseInfo
+}
+
+func (t *PlatformInfoTable) historyString(orgId uint16) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "current version: %d start time: %s\n", t.versionPlatformData[orgId], formatHistoryTime(t.versionStartTime[orgId]))
+	history := t.platformDataHistory[orgId]
+	if len(history) == 0 {
+		return sb.String()
+	}
+	sb.WriteString("version               startTime            endTime              ipv4Count  ipv6Count  macCount\n")
+	sb.WriteString("-----------------------------------------------------------------------------------------------\n")
+	for i := len(history) - 1; i >= 0; i-- {
+		v := history[i]
+		fmt.Fprintf(sb, "%-20d  %-19s  %-19s  %-9d  %-9d  %d\n", v.version, formatHistoryTime(v.startTime), formatHistoryTime(v.endTime),
+			len(v.epcIDIPV4Infos), len(v.epcIDIPV6Infos), len(v.macInfos))
+	}
+	return sb.String()
+}
+
+func formatHistoryTime(t uint32) string {
+	if t == 0 {
+		return "-"
+	}
+	return time.Unix(int64(t), 0).Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"testing"
+)
+
+const testHistoryOrgId = 1
+
+func newTestHistoryTable(maxVersions, maxAge int) *PlatformInfoTable {
+	t := &PlatformInfoTable{
+		manager: &PlatformDataManager{historyMaxVersions: maxVersions, historyMaxAge: maxAge},
+		counter: &Counter{},
+	}
+	t.macMissCount[testHistoryOrgId] = make(map[uint64]*uint64)
+	t.epcIDBaseMissCount[testHistoryOrgId] = make(map[int32]*uint64)
+	return t
+}
+
+// updateTestVersion replaces the platform data the same way as updatePlatformData, the mac 1 and the ip 1 of epc 1
+// belong to the pod of podID in the new version
+func updateTestVersion(t *PlatformInfoTable, version uint64, versionTime uint32, podID uint32) {
+	t.archivePlatformData(testHistoryOrgId, versionTime)
+	info := &Info{PodID: podID, HitCount: new(uint64)}
+	t.macInfos[testHistoryOrgId] = map[uint64]*Info{1: info}
+	t.epcIDIPV4Infos[testHistoryOrgId] = map[uint64]*Info{1<<32 | 1: info}
+	t.epcIDBaseInfos[testHistoryOrgId] = map[int32]*BaseInfo{1: {RegionID: 1}}
+	t.versionPlatformData[testHistoryOrgId] = version
+}
+
+func TestArchivePlatformData(t *testing.T) {
+	table := newTestHistoryTable(2, 0)
+	updateTestVersion(table, 1, 100, 1)
+	if len(table.platformDataHistory[testHistoryOrgId]) != 0 || table.versionStartTime[testHistoryOrgId] != 100 {
+		t.Fatalf("the first version should not be archived")
+	}
+	updateTestVersion(table, 2, 200, 2)
+	updateTestVersion(table, 3, 300, 3)
+	updateTestVersion(table, 4, 400, 4)
+	history := table.platformDataHistory[testHistoryOrgId]
+	if len(history) != 2 {
+		t.Fatalf("history should be bounded by max versions, get %d versions", len(history))
+	}
+	if history[0].version != 2 || history[0].startTime != 200 || history[0].endTime != 300 ||
+		history[1].version != 3 || history[1].startTime != 300 || history[1].endTime != 400 {
+		t.Errorf("get history %+v %+v", history[0], history[1])
+	}
+	if table.versionStartTime[testHistoryOrgId] != 400 {
+		t.Errorf("current version should start at 400, get %d", table.versionStartTime[testHistoryOrgId])
+	}
+
+	table = newTestHistoryTable(8, 150)
+	updateTestVersion(table, 1, 100, 1)
+	updateTestVersion(table, 2, 200, 2)
+	updateTestVersion(table, 3, 300, 3)
+	updateTestVersion(table, 4, 400, 4)
+	history = table.platformDataHistory[testHistoryOrgId]
+	if len(history) != 2 || history[0].version != 2 {
+		t.Errorf("versions retired earlier than max age should be dropped, get %d versions", len(history))
+	}
+
+	table = newTestHistoryTable(0, 0)
+	updateTestVersion(table, 1, 100, 1)
+	updateTestVersion(table, 2, 200, 2)
+	if len(table.platformDataHistory[testHistoryOrgId]) != 0 {
+		t.Errorf("history should be disabled")
+	}
+}
+
+func TestHistoryVersion(t *testing.T) {
+	table := newTestHistoryTable(8, 0)
+	updateTestVersion(table, 1, 100, 1)
+	updateTestVersion(table, 2, 200, 2)
+	updateTestVersion(table, 3, 300, 3)
+
+	cases := []struct {
+		timestamp uint32
+		version   uint64 // 0 means the current version
+	}{
+		{0, 0},
+		{300, 0},
+		{350, 0},
+		{299, 2},
+		{200, 2},
+		{150, 1},
+		{50, 1},
+	}
+	for _, c := range cases {
+		v := table.historyVersion(testHistoryOrgId, c.timestamp)
+		if c.version == 0 && v != nil || c.version != 0 && (v == nil || v.version != c.version) {
+			t.Errorf("timestamp %d should use version %d, get %+v", c.timestamp, c.version, v)
+		}
+	}
+	if table.counter.HistoryQueryCount != 4 || table.counter.HistoryExpiredCount != 1 {
+		t.Errorf("get history counter %+v", table.counter)
+	}
+}
+
+func TestQueryHistoryInfos(t *testing.T) {
+	table := newTestHistoryTable(8, 0)
+	updateTestVersion(table, 1, 100, 1)
+	updateTestVersion(table, 2, 200, 2)
+
+	if info := table.QueryMacInfo(testHistoryOrgId, 1, 150); info == nil || info.PodID != 1 {
+		t.Errorf("mac should be of the pod in version 1, get %+v", info)
+	}
+	if info := table.QueryIPV4Infos(testHistoryOrgId, 1, 1, 150); info == nil || info.PodID != 1 || *info.HitCount != 2 {
+		t.Errorf("ip should be of the pod in version 1 and hit twice, get %+v", info)
+	}
+	if info := table.QueryIPV4Infos(testHistoryOrgId, 1, 2, 150); info == nil || info.RegionID != 1 {
+		t.Errorf("unknown ip should get the region of the epc, get %+v", info)
+	}
+	if info := table.QueryMacInfo(testHistoryOrgId, 2, 150); info != nil {
+		t.Errorf("unknown mac should not be found, get %+v", info)
+	}
+	counter := table.counter
+	if counter.IP4TotalCount != 2 || counter.IP4HitCount != 1 || counter.IP4MissCount != 1 || counter.MacMissCount != 1 {
+		t.Errorf("history queries should be counted, get %+v", counter)
+	}
+	if info := table.QueryMacInfo(testHistoryOrgId, 1, 250); info == nil || info.PodID != 2 {
+		t.Errorf("mac should be of the pod in the current version, get %+v", info)
+	}
+}
@@ -137,6 +137,20 @@ func (c *Cache[Key, Value]) Remove(key Key) {
 	}
 }
 
+// RemoveOldest removes the oldest entry and returns it
+func (c *Cache[Key, Value]) RemoveOldest() (key Key, value Value, ok bool) {
+	if c.cache == nil {
+		return
+	}
+	ele := c.lruList.Back()
+	if ele == nil {
+		return
+	}
+	kv := ele.Value.(*entry[Key, Value])
+	c.removeElement(ele)
+	return kv.key, kv.value, true
+}
+
 func (c *Cache[Key, Value]) removeOldest() {
 	if c.cache == nil {
 		return
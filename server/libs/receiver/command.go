@@ -18,6 +18,9 @@ package receiver
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -27,6 +30,9 @@ import (
 
 const (
 	TRIDENT_ADAPTER_STATUS_CMD = 40
+	RECEIVER_RECORD_CMD        = 48
+
+	DEFAULT_REPLAY_PORT = 20033
 )
 
 // 客户端注册命令
@@ -46,3 +52,51 @@ func RegisterTridentStatusCommand() *cobra.Command {
 		operates,
 	)
 }
+
+func RegisterRecordCommand() *cobra.Command {
+	return debug.ClientRegisterSimple(RECEIVER_RECORD_CMD,
+		debug.CmdHelper{
+			Cmd:    "record",
+			Helper: "record the raw messages received from agents for replaying",
+		},
+		[]debug.CmdHelper{
+			{Cmd: "start", Helper: "start recording"},
+			{Cmd: "stop", Helper: "stop recording"},
+			{Cmd: "status", Helper: "show recording status and files"},
+			{Cmd: "set-agent-ids [id][,id...]", Helper: "only record the messages of the agents, empty means all agents"},
+			{Cmd: "set-msg-types [type][,type...]", Helper: "only record the messages of the types, such as 'l4_log,l7_log', empty means all types"},
+		},
+	)
+}
+
+func RegisterReplayCommand(ip string) *cobra.Command {
+	var port int
+	var speed float64
+	var agentIDs, msgTypes string
+	cmd := &cobra.Command{
+		Use:   "replay <file|dir>...",
+		Short: "replay the recorded messages to an ingester",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				fmt.Println("please specify the record files or directories")
+				return
+			}
+			replayer, err := NewReplayer(net.JoinHostPort(ip, strconv.Itoa(port)), speed, agentIDs, msgTypes)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			defer replayer.Close()
+			start := time.Now()
+			if err := replayer.Replay(args); err != nil {
+				fmt.Println(err)
+			}
+			fmt.Printf("replay finished in %s, %s\n", time.Since(start), &replayer.Counter)
+		},
+	}
+	cmd.Flags().IntVarP(&port, "port", "p", DEFAULT_REPLAY_PORT, "the listen port of the ingester")
+	cmd.Flags().Float64VarP(&speed, "speed", "s", 1, "replay speed, 1 is the original speed, 0 is as fast as possible")
+	cmd.Flags().StringVar(&agentIDs, "agent-ids", "", "only replay the messages of the agents, such as '1,2'")
+	cmd.Flags().StringVar(&msgTypes, "msg-types", "", "only replay the messages of the types, such as 'l4_log,l7_log'")
+	return cmd
+}
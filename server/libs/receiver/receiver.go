@@ -399,6 +399,8 @@ type Receiver struct {
 	counter *ReceiverCounter
 
 	status *AdapterStatus
+
+	recorder *Recorder
 }
 
 type ReceiverCounter struct {
@@ -474,6 +476,11 @@ func (r *Receiver) SetServerType(serverType ServerType) {
 	r.serverType = serverType
 }
 
+// SetRecorder sets the recorder of the received messages, it should be called before Start
+func (r *Receiver) SetRecorder(recorder *Recorder) {
+	r.recorder = recorder
+}
+
 func (r *Receiver) GetCounter() interface{} {
 	counter := &ReceiverCounter{MaxDelay: -ONE_HOUR, MinDelay: ONE_HOUR}
 	counter, r.counter = r.counter, counter
@@ -505,6 +512,9 @@ func (r *Receiver) timeNowAndFlushTicker() {
 		}
 		r.timeNow = time.Now().Unix()
 		r.flushPutTCPQueues()
+		if r.recorder != nil {
+			r.recorder.Flush()
+		}
 	}
 }
 
@@ -748,6 +758,9 @@ func (r *Receiver) ProcessUDPServer() {
 			}
 		}
 		r.status.Update(uint32(r.timeNow), baseHeader.Type, vtapID, uint16(orgID), remoteAddr.IP, 0, metricsTimestamp, UDP)
+		if r.recorder != nil && r.recorder.Enabled() {
+			r.recorder.Record(UDP, baseHeader.Type, vtapID, orgID, remoteAddr.IP, recvBuffer.Buffer[:size])
+		}
 
 		// Unregistered messages are discarded directly after receiving them, but the connection is not disconnected to prevent the Agent from printing exception logs
 		if r.handlers[baseHeader.Type] == nil {
@@ -916,6 +929,13 @@ func (r *Receiver) handleTCPConnection(conn net.Conn) {
 			r.updateCounter(metricsTimestamp)
 		}
 		r.status.Update(uint32(r.timeNow), baseHeader.Type, vtapID, uint16(orgID), ip, 0, metricsTimestamp, TCP)
+		if r.recorder != nil && r.recorder.Enabled() {
+			if headerLen > datatype.MESSAGE_HEADER_LEN {
+				r.recorder.Record(TCP, baseHeader.Type, vtapID, orgID, ip, baseHeaderBuffer, flowHeaderBuffer, recvBuffer.Buffer[:dataLen])
+			} else {
+				r.recorder.Record(TCP, baseHeader.Type, vtapID, orgID, ip, baseHeaderBuffer, recvBuffer.Buffer[:dataLen])
+			}
+		}
 		atomic.AddUint64(&r.counter.RxPackets, 1)
 
 		// Unregistered messages are discarded directly after receiving them, but the connection is not disconnected to prevent the Agent from printing exception logs
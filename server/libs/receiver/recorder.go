@@ -0,0 +1,515 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package receiver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/libs/datatype"
+	"github.com/deepflowio/deepflow/server/libs/debug"
+)
+
+const (
+	RECORD_FILE_MAGIC   = "DFRECORD"
+	RECORD_FILE_VERSION = 1
+	RECORD_FILE_SUFFIX  = ".rec"
+	RECORD_FILE_PREFIX  = "receiver_"
+
+	RECORD_FILE_HEADER_LEN = 8 + 4
+	// | Timestamp(8B) | ServerType(1B) | MessageType(1B) | AgentID(2B) | OrgID(2B) | IP(16B) | FrameLen(4B) | Frame(...) |
+	RECORD_HEADER_LEN = 8 + 1 + 1 + 2 + 2 + net.IPv6len + 4
+
+	DEFAULT_RECORD_DIR        = "/var/log/deepflow/receiver-record"
+	DEFAULT_RECORD_FILE_SIZE  = 256 // MB
+	DEFAULT_RECORD_FILE_COUNT = 8
+
+	RECORD_WRITER_BUFFER_SIZE = 1 << 16
+)
+
+// Record is a message received from the agent, Frame is the original frame including the BaseHeader and the FlowHeader,
+// it can be sent to the ingester again as it is
+type Record struct {
+	Timestamp  int64 // ns
+	ServerType ServerType
+	MsgType    datatype.MessageType
+	AgentID    uint16
+	OrgID      uint16
+	IP         net.IP
+	Frame      []byte
+}
+
+func (r *Record) String() string {
+	return fmt.Sprintf("%s %s %s agent: %d org: %d ip: %s len: %d",
+		time.Unix(0, r.Timestamp).Format(time.RFC3339Nano), r.ServerType, r.MsgType, r.AgentID, r.OrgID, r.IP, len(r.Frame))
+}
+
+type RecorderCounter struct {
+	Records     uint64
+	Bytes       uint64
+	WriteErrors uint64
+}
+
+// Recorder records the raw messages received by the Receiver into rotating files,
+// the files can be replayed by the Replayer for debugging decoders or load testing
+type Recorder struct {
+	sync.Mutex
+
+	dir       string
+	fileSize  int64
+	fileCount int
+
+	enabled  int32
+	agentIDs map[uint16]bool
+	msgTypes map[datatype.MessageType]bool
+
+	file        *os.File
+	writer      *bufio.Writer
+	writtenSize int64
+	files       []string
+	fileSeq     int
+	header      [RECORD_HEADER_LEN]byte
+
+	counter RecorderCounter
+}
+
+// fileSize is in MB
+func NewRecorder(dir string, fileSize, fileCount int) *Recorder {
+	if dir == "" {
+		dir = DEFAULT_RECORD_DIR
+	}
+	if fileSize <= 0 {
+		fileSize = DEFAULT_RECORD_FILE_SIZE
+	}
+	if fileCount <= 0 {
+		fileCount = DEFAULT_RECORD_FILE_COUNT
+	}
+	r := &Recorder{
+		dir:       dir,
+		fileSize:  int64(fileSize) << 20,
+		fileCount: fileCount,
+		agentIDs:  make(map[uint16]bool),
+		msgTypes:  make(map[datatype.MessageType]bool),
+	}
+	debug.ServerRegisterSimple(RECEIVER_RECORD_CMD, r)
+	return r
+}
+
+func (r *Recorder) Enabled() bool {
+	return atomic.LoadInt32(&r.enabled) == 1
+}
+
+func (r *Recorder) match(msgType datatype.MessageType, agentID uint16) bool {
+	if len(r.msgTypes) > 0 && !r.msgTypes[msgType] {
+		return false
+	}
+	if len(r.agentIDs) > 0 && !r.agentIDs[agentID] {
+		return false
+	}
+	return true
+}
+
+// Record writes a frame, the frame is split into parts to avoid copying the headers and the data received separately
+func (r *Recorder) Record(serverType ServerType, msgType datatype.MessageType, agentID, orgID uint16, ip net.IP, frame ...[]byte) {
+	if !r.Enabled() {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.writer == nil || !r.match(msgType, agentID) {
+		return
+	}
+
+	frameLen := 0
+	for _, f := range frame {
+		frameLen += len(f)
+	}
+	if r.writtenSize > 0 && r.writtenSize+int64(RECORD_HEADER_LEN+frameLen) > r.fileSize {
+		if err := r.rotate(); err != nil {
+			r.counter.WriteErrors++
+			log.Warningf("receiver record rotate failed: %s, stop recording", err)
+			r.stop()
+			return
+		}
+	}
+
+	encodeRecordHeader(r.header[:], time.Now().UnixNano(), serverType, msgType, agentID, orgID, ip, frameLen)
+	if _, err := r.writer.Write(r.header[:]); err != nil {
+		r.writeFailed(err)
+		return
+	}
+	for _, f := range frame {
+		if _, err := r.writer.Write(f); err != nil {
+			r.writeFailed(err)
+			return
+		}
+	}
+	r.writtenSize += int64(RECORD_HEADER_LEN + frameLen)
+	r.counter.Records++
+	r.counter.Bytes += uint64(RECORD_HEADER_LEN + frameLen)
+}
+
+func (r *Recorder) writeFailed(err error) {
+	if r.counter.WriteErrors == 0 {
+		log.Warningf("receiver record write file %s failed: %s", r.file.Name(), err)
+	}
+	r.counter.WriteErrors++
+}
+
+// Flush is called periodically, so that the records can be replayed without stopping the recording
+func (r *Recorder) Flush() {
+	if !r.Enabled() {
+		return
+	}
+	r.Lock()
+	if r.writer != nil {
+		r.writer.Flush()
+	}
+	r.Unlock()
+}
+
+func (r *Recorder) Start() error {
+	r.Lock()
+	defer r.Unlock()
+	if r.writer != nil {
+		return errors.New("receiver record is already running")
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	if err := r.loadFiles(); err != nil {
+		return err
+	}
+	if err := r.rotate(); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&r.enabled, 1)
+	log.Infof("receiver record started, dir: %s, filter: %s", r.dir, r.filterString())
+	return nil
+}
+
+func (r *Recorder) Stop() {
+	r.Lock()
+	defer r.Unlock()
+	r.stop()
+}
+
+func (r *Recorder) stop() {
+	atomic.StoreInt32(&r.enabled, 0)
+	r.closeFile()
+	log.Infof("receiver record stopped, records: %d, bytes: %d", r.counter.Records, r.counter.Bytes)
+}
+
+func (r *Recorder) closeFile() {
+	if r.writer != nil {
+		r.writer.Flush()
+		r.writer = nil
+	}
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+// loadFiles takes the record files left by the previous runs into account, so that they are removed by rotate
+// and the files in the dir never exceed fileCount
+func (r *Recorder) loadFiles() error {
+	files, err := RecordFiles([]string{r.dir})
+	if err != nil {
+		return err
+	}
+	r.files = files
+	return nil
+}
+
+func (r *Recorder) createFile() (string, *os.File, error) {
+	now := time.Now().Format("20060102150405")
+	for {
+		r.fileSeq++
+		name := filepath.Join(r.dir, fmt.Sprintf("%s%s_%06d%s", RECORD_FILE_PREFIX, now, r.fileSeq, RECORD_FILE_SUFFIX))
+		file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		return name, file, err
+	}
+}
+
+func (r *Recorder) rotate() error {
+	r.closeFile()
+
+	name, file, err := r.createFile()
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriterSize(file, RECORD_WRITER_BUFFER_SIZE)
+	var header [RECORD_FILE_HEADER_LEN]byte
+	copy(header[:], RECORD_FILE_MAGIC)
+	binary.LittleEndian.PutUint32(header[len(RECORD_FILE_MAGIC):], RECORD_FILE_VERSION)
+	if _, err := writer.Write(header[:]); err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.writer, r.writtenSize = file, writer, RECORD_FILE_HEADER_LEN
+
+	r.files = append(r.files, name)
+	for len(r.files) > r.fileCount {
+		if err := os.Remove(r.files[0]); err != nil && !os.IsNotExist(err) {
+			log.Warningf("receiver record remove file %s failed: %s", r.files[0], err)
+		}
+		r.files = r.files[1:]
+	}
+	return nil
+}
+
+func (r *Recorder) SetAgentIDs(arg string) error {
+	agentIDs, err := parseAgentIDs(arg)
+	if err != nil {
+		return err
+	}
+	r.Lock()
+	r.agentIDs = agentIDs
+	r.Unlock()
+	return nil
+}
+
+func (r *Recorder) SetMsgTypes(arg string) error {
+	msgTypes, err := parseMsgTypes(arg)
+	if err != nil {
+		return err
+	}
+	r.Lock()
+	r.msgTypes = msgTypes
+	r.Unlock()
+	return nil
+}
+
+func (r *Recorder) filterString() string {
+	agentIDs := make([]int, 0, len(r.agentIDs))
+	for id := range r.agentIDs {
+		agentIDs = append(agentIDs, int(id))
+	}
+	sort.Ints(agentIDs)
+	msgTypes := make([]string, 0, len(r.msgTypes))
+	for t := range r.msgTypes {
+		msgTypes = append(msgTypes, t.String())
+	}
+	sort.Strings(msgTypes)
+	return fmt.Sprintf("agent-ids: %v msg-types: %v", agentIDs, msgTypes)
+}
+
+func (r *Recorder) Status() string {
+	r.Lock()
+	defer r.Unlock()
+	sb := &strings.Builder{}
+	if r.Enabled() {
+		fmt.Fprintf(sb, "running, current file: %s\n", r.file.Name())
+	} else {
+		sb.WriteString("stopped\n")
+	}
+	fmt.Fprintf(sb, "dir: %s file-size: %dMB file-count: %d\n", r.dir, r.fileSize>>20, r.fileCount)
+	fmt.Fprintf(sb, "filter: %s\n", r.filterString())
+	fmt.Fprintf(sb, "records: %d bytes: %d write-errors: %d\n", r.counter.Records, r.counter.Bytes, r.counter.WriteErrors)
+	if len(r.files) > 0 {
+		fmt.Fprintf(sb, "files:\n  %s", strings.Join(r.files, "\n  "))
+	}
+	return sb.String()
+}
+
+const (
+	CMD_RECORD_START uint16 = iota
+	CMD_RECORD_STOP
+	CMD_RECORD_STATUS
+	CMD_RECORD_SET_AGENT_IDS
+	CMD_RECORD_SET_MSG_TYPES
+)
+
+func (r *Recorder) HandleSimpleCommand(op uint16, arg string) string {
+	switch op {
+	case CMD_RECORD_START:
+		if err := r.Start(); err != nil {
+			return fmt.Sprintf("start receiver record failed: %s", err)
+		}
+		return "receiver record started\n" + r.Status()
+	case CMD_RECORD_STOP:
+		r.Stop()
+		return "receiver record stopped\n" + r.Status()
+	case CMD_RECORD_STATUS:
+		return r.Status()
+	case CMD_RECORD_SET_AGENT_IDS:
+		if err := r.SetAgentIDs(arg); err != nil {
+			return err.Error()
+		}
+		return r.Status()
+	case CMD_RECORD_SET_MSG_TYPES:
+		if err := r.SetMsgTypes(arg); err != nil {
+			return err.Error()
+		}
+		return r.Status()
+	}
+	return "invalid op"
+}
+
+// ParseMessageType parses the message type from its name, such as 'l7_log', or its value
+func ParseMessageType(s string) (datatype.MessageType, error) {
+	for i := datatype.MessageType(0); i < datatype.MESSAGE_TYPE_MAX; i++ {
+		if s == i.String() {
+			return i, nil
+		}
+	}
+	if v, err := strconv.Atoi(s); err == nil && v >= 0 && v < int(datatype.MESSAGE_TYPE_MAX) {
+		return datatype.MessageType(v), nil
+	}
+	return 0, fmt.Errorf("invalid message type %s", s)
+}
+
+// parseAgentIDs parses a comma separated list of agent ids, empty means no filter
+func parseAgentIDs(arg string) (map[uint16]bool, error) {
+	agentIDs := make(map[uint16]bool)
+	for _, s := range splitList(arg) {
+		id, err := strconv.ParseUint(s, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid agent id %s", s)
+		}
+		agentIDs[uint16(id)] = true
+	}
+	return agentIDs, nil
+}
+
+// parseMsgTypes parses a comma separated list of message types, empty means no filter
+func parseMsgTypes(arg string) (map[datatype.MessageType]bool, error) {
+	msgTypes := make(map[datatype.MessageType]bool)
+	for _, s := range splitList(arg) {
+		msgType, err := ParseMessageType(s)
+		if err != nil {
+			return nil, err
+		}
+		msgTypes[msgType] = true
+	}
+	return msgTypes, nil
+}
+
+func splitList(arg string) []string {
+	items := []string{}
+	for _, s := range strings.Split(arg, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			items = append(items, s)
+		}
+	}
+	return items
+}
+
+func encodeRecordHeader(buf []byte, timestamp int64, serverType ServerType, msgType datatype.MessageType, agentID, orgID uint16, ip net.IP, frameLen int) {
+	binary.LittleEndian.PutUint64(buf, uint64(timestamp))
+	buf[8] = byte(serverType)
+	buf[9] = byte(msgType)
+	binary.LittleEndian.PutUint16(buf[10:], agentID)
+	binary.LittleEndian.PutUint16(buf[12:], orgID)
+	ip16 := ip.To16()
+	if ip16 == nil {
+		ip16 = net.IPv6zero
+	}
+	copy(buf[14:14+net.IPv6len], ip16)
+	binary.LittleEndian.PutUint32(buf[14+net.IPv6len:], uint32(frameLen))
+}
+
+// Payload returns the data after the BaseHeader and the FlowHeader, which is the data passed to the decoders
+func (r *Record) Payload() ([]byte, error) {
+	var baseHeader datatype.BaseHeader
+	if len(r.Frame) < datatype.MESSAGE_HEADER_LEN {
+		return nil, fmt.Errorf("record frame length %d is smaller than header length %d", len(r.Frame), datatype.MESSAGE_HEADER_LEN)
+	}
+	if err := baseHeader.Decode(r.Frame); err != nil {
+		return nil, err
+	}
+	headerLen := datatype.MESSAGE_HEADER_LEN
+	if baseHeader.Type.HeaderType() == datatype.HEADER_TYPE_LT_VTAP {
+		headerLen += datatype.FLOW_HEADER_LEN
+	}
+	if len(r.Frame) < headerLen {
+		return nil, fmt.Errorf("record frame length %d is smaller than header length %d", len(r.Frame), headerLen)
+	}
+	end := len(r.Frame)
+	if baseHeader.Type == datatype.MESSAGE_TYPE_COMPRESS && int(baseHeader.FrameSize) < end {
+		end = int(baseHeader.FrameSize)
+	}
+	return r.Frame[headerLen:end], nil
+}
+
+// RecordReader reads the records from a file written by the Recorder
+type RecordReader struct {
+	reader *bufio.Reader
+	header [RECORD_HEADER_LEN]byte
+}
+
+func NewRecordReader(reader io.Reader) (*RecordReader, error) {
+	r := &RecordReader{reader: bufio.NewReaderSize(reader, RECORD_WRITER_BUFFER_SIZE)}
+	var header [RECORD_FILE_HEADER_LEN]byte
+	if _, err := io.ReadFull(r.reader, header[:]); err != nil {
+		return nil, fmt.Errorf("read record file header failed: %s", err)
+	}
+	if string(header[:len(RECORD_FILE_MAGIC)]) != RECORD_FILE_MAGIC {
+		return nil, errors.New("not a receiver record file")
+	}
+	if version := binary.LittleEndian.Uint32(header[len(RECORD_FILE_MAGIC):]); version != RECORD_FILE_VERSION {
+		return nil, fmt.Errorf("unsupported receiver record file version %d", version)
+	}
+	return r, nil
+}
+
+// Next returns io.EOF at the end of the file, a record truncated by a crash is treated as the end too
+func (r *RecordReader) Next() (*Record, error) {
+	if _, err := io.ReadFull(r.reader, r.header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	buf := r.header[:]
+	record := &Record{
+		Timestamp:  int64(binary.LittleEndian.Uint64(buf)),
+		ServerType: ServerType(buf[8]),
+		MsgType:    datatype.MessageType(buf[9]),
+		AgentID:    binary.LittleEndian.Uint16(buf[10:]),
+		OrgID:      binary.LittleEndian.Uint16(buf[12:]),
+		IP:         net.IP(append([]byte(nil), buf[14:14+net.IPv6len]...)),
+	}
+	frameLen := binary.LittleEndian.Uint32(buf[14+net.IPv6len:])
+	if frameLen > RECV_BUFSIZE_MAX+datatype.MESSAGE_HEADER_LEN+datatype.FLOW_HEADER_LEN {
+		return nil, fmt.Errorf("invalid record frame length %d", frameLen)
+	}
+	record.Frame = make([]byte, frameLen)
+	if _, err := io.ReadFull(r.reader, record.Frame); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	return record, nil
+}
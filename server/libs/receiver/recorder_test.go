@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package receiver
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/libs/datatype"
+)
+
+func testFrame(msgType datatype.MessageType, agentID uint16, payload []byte) []byte {
+	headerLen := datatype.MESSAGE_HEADER_LEN
+	if msgType.HeaderType() == datatype.HEADER_TYPE_LT_VTAP {
+		headerLen += datatype.FLOW_HEADER_LEN
+	}
+	frame := make([]byte, headerLen+len(payload))
+	baseHeader := datatype.BaseHeader{FrameSize: uint32(len(frame)), Type: msgType}
+	baseHeader.Encode(frame)
+	if headerLen > datatype.MESSAGE_HEADER_LEN {
+		flowHeader := datatype.FlowHeader{Version: datatype.LATEST_VERSION, OrgID: 1, AgentID: agentID}
+		flowHeader.Encode(frame[datatype.MESSAGE_HEADER_LEN:])
+	}
+	copy(frame[headerLen:], payload)
+	return frame
+}
+
+func TestRecordFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	var fileHeader [RECORD_FILE_HEADER_LEN]byte
+	copy(fileHeader[:], RECORD_FILE_MAGIC)
+	fileHeader[len(RECORD_FILE_MAGIC)] = RECORD_FILE_VERSION
+	buf.Write(fileHeader[:])
+
+	frame := testFrame(datatype.MESSAGE_TYPE_PROTOCOLLOG, 3, []byte("payload"))
+	var header [RECORD_HEADER_LEN]byte
+	encodeRecordHeader(header[:], 1234, TCP, datatype.MESSAGE_TYPE_PROTOCOLLOG, 3, 1, net.ParseIP("10.1.2.3"), len(frame))
+	buf.Write(header[:])
+	buf.Write(frame)
+	// a record truncated by a crash
+	buf.Write(header[:10])
+
+	reader, err := NewRecordReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Timestamp != 1234 || record.ServerType != TCP || record.MsgType != datatype.MESSAGE_TYPE_PROTOCOLLOG ||
+		record.AgentID != 3 || record.OrgID != 1 || !record.IP.Equal(net.ParseIP("10.1.2.3")) || !bytes.Equal(record.Frame, frame) {
+		t.Errorf("unexpected record %s", record)
+	}
+	payload, err := record.Payload()
+	if err != nil || string(payload) != "payload" {
+		t.Errorf("unexpected payload %q, err %v", payload, err)
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expect io.EOF for the truncated record, got %v", err)
+	}
+
+	if _, err := NewRecordReader(bytes.NewReader([]byte("NOTARECORDFILE"))); err == nil {
+		t.Error("expect error for invalid magic")
+	}
+	fileHeader[len(RECORD_FILE_MAGIC)] = RECORD_FILE_VERSION + 1
+	if _, err := NewRecordReader(bytes.NewReader(fileHeader[:])); err == nil {
+		t.Error("expect error for unsupported version")
+	}
+}
+
+func readRecords(t *testing.T, files []string) []*Record {
+	records := []*Record{}
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader, err := NewRecordReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			record, err := reader.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, record)
+		}
+		file.Close()
+	}
+	return records
+}
+
+func TestRecorderRotate(t *testing.T) {
+	dir := t.TempDir()
+	// files left by the previous run
+	for _, name := range []string{"receiver_20240101000000_000001.rec", "receiver_20240101000000_000002.rec"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(RECORD_FILE_MAGIC), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewRecorder(dir, 1, 3)
+	r.fileSize = 1024
+	if err := r.SetMsgTypes("l7_log"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+	frame := testFrame(datatype.MESSAGE_TYPE_PROTOCOLLOG, 1, make([]byte, 200))
+	for i := 0; i < 20; i++ {
+		r.Record(TCP, datatype.MESSAGE_TYPE_PROTOCOLLOG, 1, 1, net.ParseIP("10.1.2.3"), frame[:datatype.MESSAGE_HEADER_LEN], frame[datatype.MESSAGE_HEADER_LEN:])
+		r.Record(UDP, datatype.MESSAGE_TYPE_METRICS, 1, 1, net.ParseIP("10.1.2.3"), frame)
+	}
+	r.Stop()
+
+	files, err := RecordFiles([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expect 3 files, got %v", files)
+	}
+	for _, name := range files {
+		if filepath.Base(name) < "receiver_2025" {
+			t.Errorf("file %s of the previous run is not removed", name)
+		}
+	}
+	if r.counter.Records != 20 {
+		t.Errorf("expect 20 records, got %d", r.counter.Records)
+	}
+	// 4 records per file, the oldest 2 files are removed
+	records := readRecords(t, files)
+	if len(records) != 12 {
+		t.Errorf("expect 12 records, got %d", len(records))
+	}
+	for _, record := range records {
+		if record.MsgType != datatype.MESSAGE_TYPE_PROTOCOLLOG || !bytes.Equal(record.Frame, frame) {
+			t.Errorf("unexpected record %s", record)
+		}
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpListener.Close()
+	target := tcpListener.Addr().String()
+	udpConn, err := net.ListenPacket("udp", target)
+	if err != nil {
+		t.Skipf("listen udp %s failed: %s", target, err)
+	}
+	defer udpConn.Close()
+
+	dir := t.TempDir()
+	r := NewRecorder(dir, 1, 2)
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+	tcpFrame := testFrame(datatype.MESSAGE_TYPE_PROTOCOLLOG, 1, []byte("l7 log"))
+	udpFrame := testFrame(datatype.MESSAGE_TYPE_METRICS, 1, []byte("metrics"))
+	skippedFrame := testFrame(datatype.MESSAGE_TYPE_PROTOCOLLOG, 2, []byte("skipped"))
+	r.Record(TCP, datatype.MESSAGE_TYPE_PROTOCOLLOG, 1, 1, nil, tcpFrame)
+	r.Record(TCP, datatype.MESSAGE_TYPE_PROTOCOLLOG, 2, 1, nil, skippedFrame)
+	r.Record(UDP, datatype.MESSAGE_TYPE_METRICS, 1, 1, nil, udpFrame)
+	r.Record(TCP, datatype.MESSAGE_TYPE_PROTOCOLLOG, 1, 1, nil, tcpFrame)
+	r.Stop()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := tcpListener.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 2*len(tcpFrame))
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			received <- nil
+			return
+		}
+		received <- buf
+	}()
+
+	replayer, err := NewReplayer(target, 0, "1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replayer.Close()
+	if err := replayer.Replay([]string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	if replayer.Counter.Records != 3 || replayer.Counter.Skipped != 1 {
+		t.Errorf("unexpected counter %s", &replayer.Counter)
+	}
+
+	if buf := <-received; !bytes.Equal(buf, append(append([]byte{}, tcpFrame...), tcpFrame...)) {
+		t.Errorf("unexpected tcp data %v", buf)
+	}
+	udpConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := udpConn.ReadFrom(buf)
+	if err != nil || !bytes.Equal(buf[:n], udpFrame) {
+		t.Errorf("unexpected udp data %v, err %v", buf[:n], err)
+	}
+}
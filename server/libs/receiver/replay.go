@@ -0,0 +1,202 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package receiver
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/libs/datatype"
+)
+
+const (
+	REPLAY_DIAL_TIMEOUT  = 10 * time.Second
+	REPLAY_WRITE_TIMEOUT = 30 * time.Second
+)
+
+type ReplayCounter struct {
+	Records uint64
+	Bytes   uint64
+	Skipped uint64
+}
+
+func (c *ReplayCounter) String() string {
+	return fmt.Sprintf("records: %d bytes: %d skipped: %d", c.Records, c.Bytes, c.Skipped)
+}
+
+// Replayer sends the records written by the Recorder to an ingester, the frames received by TCP are sent by TCP
+// and the frames received by UDP are sent by UDP, keeping the original intervals divided by the speed
+type Replayer struct {
+	target   string
+	speed    float64 // 0 means as fast as possible
+	agentIDs map[uint16]bool
+	msgTypes map[datatype.MessageType]bool
+
+	tcpConn net.Conn
+	udpConn net.Conn
+
+	firstRecordTime int64
+	firstReplayTime time.Time
+
+	Counter ReplayCounter
+}
+
+// agentIDs and msgTypes are comma separated lists, empty means no filter
+func NewReplayer(target string, speed float64, agentIDs, msgTypes string) (*Replayer, error) {
+	if speed < 0 {
+		return nil, fmt.Errorf("invalid speed %f", speed)
+	}
+	r := &Replayer{
+		target: target,
+		speed:  speed,
+	}
+	var err error
+	if r.agentIDs, err = parseAgentIDs(agentIDs); err != nil {
+		return nil, err
+	}
+	if r.msgTypes, err = parseMsgTypes(msgTypes); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Replayer) match(record *Record) bool {
+	if len(r.msgTypes) > 0 && !r.msgTypes[record.MsgType] {
+		return false
+	}
+	if len(r.agentIDs) > 0 && !r.agentIDs[record.AgentID] {
+		return false
+	}
+	return true
+}
+
+// RecordFiles expands the directories in paths to the record files in them, sorted by name which is the creation order
+func RecordFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, RECORD_FILE_PREFIX+"*"+RECORD_FILE_SUFFIX))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func (r *Replayer) Replay(paths []string) error {
+	files, err := RecordFiles(paths)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no record files found in %s", strings.Join(paths, ","))
+	}
+	for _, file := range files {
+		if err := r.replayFile(file); err != nil {
+			return fmt.Errorf("replay file %s failed: %s", file, err)
+		}
+	}
+	return nil
+}
+
+func (r *Replayer) replayFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := NewRecordReader(file)
+	if err != nil {
+		return err
+	}
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !r.match(record) {
+			r.Counter.Skipped++
+			continue
+		}
+		r.wait(record.Timestamp)
+		if err := r.send(record); err != nil {
+			return err
+		}
+		r.Counter.Records++
+		r.Counter.Bytes += uint64(len(record.Frame))
+	}
+}
+
+func (r *Replayer) wait(timestamp int64) {
+	if r.firstReplayTime.IsZero() {
+		r.firstRecordTime, r.firstReplayTime = timestamp, time.Now()
+		return
+	}
+	if r.speed == 0 {
+		return
+	}
+	elapsed := time.Duration(float64(timestamp-r.firstRecordTime) / r.speed)
+	if d := time.Until(r.firstReplayTime.Add(elapsed)); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (r *Replayer) send(record *Record) error {
+	var err error
+	conn := &r.tcpConn
+	network := "tcp"
+	if record.ServerType == UDP {
+		conn, network = &r.udpConn, "udp"
+	}
+	if *conn == nil {
+		if *conn, err = net.DialTimeout(network, r.target, REPLAY_DIAL_TIMEOUT); err != nil {
+			return err
+		}
+	}
+	(*conn).SetWriteDeadline(time.Now().Add(REPLAY_WRITE_TIMEOUT))
+	if _, err = (*conn).Write(record.Frame); err != nil {
+		(*conn).Close()
+		*conn = nil
+	}
+	return err
+}
+
+func (r *Replayer) Close() {
+	for _, conn := range []net.Conn{r.tcpConn, r.udpConn} {
+		if conn != nil {
+			conn.Close()
+		}
+	}
+	r.tcpConn, r.udpConn = nil, nil
+}
@@ -0,0 +1,230 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	PROMETHEUS_METRICS_PATH  = "/metrics"
+	PROMETHEUS_LABEL_MODULE  = "module"
+	PROMETHEUS_SAMPLE_TTL    = 5 * time.Minute
+	PROMETHEUS_TTL_INTERVALS = 3
+)
+
+type prometheusSample struct {
+	name     string
+	labels   map[string]string
+	value    float64
+	expireAt time.Time
+}
+
+// PrometheusCollector keeps the latest value of each stats field and exposes them as gauges.
+// Countables are cleared after read, so values are recorded when stats are collected instead of reading countables on scrape.
+type PrometheusCollector struct {
+	sync.Mutex
+	enabled bool
+	samples map[string]*prometheusSample
+}
+
+var prometheusCollector = &PrometheusCollector{samples: make(map[string]*prometheusSample)}
+
+// EnablePrometheus starts recording stats for Prometheus, call it before registering PrometheusHandler.
+func EnablePrometheus() {
+	prometheusCollector.Lock()
+	prometheusCollector.enabled = true
+	prometheusCollector.Unlock()
+}
+
+func IsPrometheusEnabled() bool {
+	prometheusCollector.Lock()
+	defer prometheusCollector.Unlock()
+	return prometheusCollector.enabled
+}
+
+// PrometheusHandler returns the http handler of all stats, go runtime and process metrics
+func PrometheusHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheusCollector,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorLog: &promhttpLogger{}})
+}
+
+// StartPrometheusServer enables Prometheus stats and serves them at PROMETHEUS_METRICS_PATH of port
+func StartPrometheusServer(port int) {
+	EnablePrometheus()
+	mux := http.NewServeMux()
+	mux.Handle(PROMETHEUS_METRICS_PATH, PrometheusHandler())
+	go func() {
+		log.Infof("prometheus metrics server listening on :%d%s", port, PROMETHEUS_METRICS_PATH)
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux); err != nil {
+			log.Errorf("prometheus metrics server failed: %s", err)
+		}
+	}()
+}
+
+// ObservePrometheus records stats that are not reported through Countable, such as controller statsd
+func ObservePrometheus(module string, tags map[string]string, fields map[string]interface{}) {
+	prometheusCollector.update(module, module, tags, fields, PROMETHEUS_SAMPLE_TTL)
+}
+
+type promhttpLogger struct{}
+
+func (l *promhttpLogger) Println(v ...interface{}) {
+	log.Warning(v...)
+}
+
+func (c *PrometheusCollector) update(name, module string, tags map[string]string, fields map[string]interface{}, ttl time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	if !c.enabled {
+		return
+	}
+	now := time.Now()
+	labels := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		labels[sanitizePrometheusName(k)] = v
+	}
+	if _, ok := labels[PROMETHEUS_LABEL_MODULE]; !ok {
+		labels[PROMETHEUS_LABEL_MODULE] = module
+	}
+	labelKey := prometheusLabelsKey(labels)
+	for field, v := range fields {
+		value, ok := toFloat64(v)
+		if !ok {
+			continue
+		}
+		metricName := sanitizePrometheusName(name + "_" + field)
+		key := metricName + labelKey
+		if s, ok := c.samples[key]; ok {
+			s.value = value
+			s.expireAt = now.Add(ttl)
+			continue
+		}
+		c.samples[key] = &prometheusSample{name: metricName, labels: labels, value: value, expireAt: now.Add(ttl)}
+	}
+}
+
+func (c *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+	// unchecked collector, metrics are only known after stats are collected
+}
+
+func (c *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	c.Lock()
+	now := time.Now()
+	families := make(map[string][]*prometheusSample)
+	for key, s := range c.samples {
+		if now.After(s.expireAt) {
+			delete(c.samples, key)
+			continue
+		}
+		families[s.name] = append(families[s.name], s)
+	}
+	c.Unlock()
+
+	for name, samples := range families {
+		// samples of one metric must have the same label names, fill the missing labels with empty value
+		labelNameSet := make(map[string]bool)
+		for _, s := range samples {
+			for k := range s.labels {
+				labelNameSet[k] = true
+			}
+		}
+		labelNames := make([]string, 0, len(labelNameSet))
+		for k := range labelNameSet {
+			labelNames = append(labelNames, k)
+		}
+		sort.Strings(labelNames)
+		desc := prometheus.NewDesc(name, "deepflow-server stats of the latest stats interval", labelNames, nil)
+		for _, s := range samples {
+			labelValues := make([]string, len(labelNames))
+			for i, k := range labelNames {
+				labelValues[i] = s.labels[k]
+			}
+			metric, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, s.value, labelValues...)
+			if err != nil {
+				log.Warningf("invalid prometheus metric %s: %s", name, err)
+				continue
+			}
+			ch <- metric
+		}
+	}
+}
+
+func prometheusLabelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteByte(0)
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(labels[k])
+	}
+	return sb.String()
+}
+
+// sanitizePrometheusName replaces characters not allowed in Prometheus metric and label names with '_'
+func sanitizePrometheusName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= '0' && c <= '9' && i > 0) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case bool:
+		if value {
+			return 1, true
+		}
+		return 0, true
+	case float32:
+		return float64(value), true
+	case float64:
+		return value, !math.IsNaN(value)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCounter struct {
+	Rx      uint64 `statsd:"rx"`
+	Dropped int64  `statsd:"dropped-count"`
+	Ignored string
+}
+
+func TestPrometheusCollector(t *testing.T) {
+	EnablePrometheus()
+	fields := counterToFields(&testCounter{Rx: 10, Dropped: 2})
+	prometheusCollector.update("deepflow_server_receiver", "receiver", map[string]string{"host": "node-1", "kafka.topic": "t"}, fields, time.Minute)
+	ObservePrometheus("controller_cloud", map[string]string{"domain": "d1"}, map[string]interface{}{"count": 3.5, "name": "x"})
+
+	w := httptest.NewRecorder()
+	PrometheusHandler().ServeHTTP(w, httptest.NewRequest("GET", PROMETHEUS_METRICS_PATH, nil))
+	body, _ := io.ReadAll(w.Result().Body)
+	for _, expected := range []string{
+		`deepflow_server_receiver_rx{host="node-1",kafka_topic="t",module="receiver"} 10`,
+		`deepflow_server_receiver_dropped_count{host="node-1",kafka_topic="t",module="receiver"} 2`,
+		`controller_cloud_count{domain="d1",module="controller_cloud"} 3.5`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("expected %s in:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(string(body), "controller_cloud_name") {
+		t.Errorf("non-numeric field should not be exposed")
+	}
+}
+
+func TestPrometheusCollectorLabelUnion(t *testing.T) {
+	EnablePrometheus()
+	prometheusCollector.update("m", "m", map[string]string{"a": "1"}, map[string]interface{}{"v": 1}, time.Minute)
+	prometheusCollector.update("m", "m", map[string]string{"b": "2"}, map[string]interface{}{"v": 2}, time.Minute)
+	prometheusCollector.update("m", "m", map[string]string{"c": "3"}, map[string]interface{}{"v": 3}, -time.Minute)
+
+	w := httptest.NewRecorder()
+	PrometheusHandler().ServeHTTP(w, httptest.NewRequest("GET", PROMETHEUS_METRICS_PATH, nil))
+	body, _ := io.ReadAll(w.Result().Body)
+	for _, expected := range []string{`m_v{a="1",b="",module="m"} 1`, `m_v{a="",b="2",module="m"} 2`} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("expected %s in:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(string(body), `c="3"`) {
+		t.Errorf("expired sample should not be exposed:\n%s", body)
+	}
+}
+
+func TestSanitizePrometheusName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"deepflow_server.rx-bytes": "deepflow_server_rx_bytes",
+		"1m":                       "_m",
+		"ok_1":                     "ok_1",
+	} {
+		if got := sanitizePrometheusName(name); got != expected {
+			t.Errorf("sanitizePrometheusName(%s) = %s, expected %s", name, got, expected)
+		}
+	}
+}
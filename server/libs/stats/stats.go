@@ -173,7 +173,10 @@ func collectBatchPoints(timestamp time.Time) client.BatchPoints {
 		statSource.skip = int(max(statSource.interval, MinInterval) / TICK_CYCLE)
 
 		fields := counterToFields(statSource.countable.GetCounter())
-		point, _ := client.NewPoint(processName+processNameJoiner+statSource.modulePrefix+statSource.module, statSource.tags, fields, timestamp)
+		name := processName + processNameJoiner + statSource.modulePrefix + statSource.module
+		prometheusCollector.update(name, statSource.modulePrefix+statSource.module, statSource.tags, fields,
+			max(PROMETHEUS_SAMPLE_TTL, PROMETHEUS_TTL_INTERVALS*max(statSource.interval, MinInterval)))
+		point, _ := client.NewPoint(name, statSource.tags, fields, timestamp)
 		bp.AddPoint(point)
 	}
 	lock.Unlock()
@@ -0,0 +1,527 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracetree
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deepflowio/deepflow/server/libs/ckdb"
+	"github.com/deepflowio/deepflow/server/libs/pool"
+)
+
+const (
+	// 关键路径最多记录的服务段数
+	CRITICAL_PATH_MAX_SEGMENTS = 64
+	CRITICAL_PATH_SEPARATOR    = " > "
+
+	responseStatusServerError = 3 // datatype.STATUS_SERVER_ERROR
+)
+
+// TraceAnalysis is the analytics of one service in a completed trace. A trace is written as one row per service,
+// so that questions like "which service contributes most latency to checkout traces" can be answered by grouping
+// on app_service/auto_service and filtering on root_app_service.
+type TraceAnalysis struct {
+	Time  uint32
+	OrgId uint16
+
+	TraceId                 string
+	RootAppService          string
+	RootAutoServiceType     uint8
+	RootAutoServiceID       uint32
+	TraceDuration           uint64 // us, duration of the root span
+	TraceSpanCount          uint32
+	CriticalPath            string // services on the critical path in time order, separated by CRITICAL_PATH_SEPARATOR
+	ErrorOriginAppService   string
+	ErrorOriginAutoServType uint8
+	ErrorOriginAutoServID   uint32
+
+	AppService      string
+	AutoServiceType uint8
+	AutoServiceID   uint32
+
+	SpanCount        uint32
+	ErrorCount       uint32
+	IsErrorOrigin    uint8
+	SelfTime         uint64 // us
+	CriticalPathTime uint64 // us, self time on the critical path
+	NetworkTime      uint64 // us, gaps between the client spans of the service and the server spans they call
+}
+
+func (t *TraceAnalysis) Release() {
+	ReleaseTraceAnalysis(t)
+}
+
+func (t *TraceAnalysis) OrgID() uint16 {
+	return t.OrgId
+}
+
+func (t *TraceAnalysis) WriteBlock(block *ckdb.Block) {
+	block.WriteDateTime(t.Time)
+	block.Write(
+		t.TraceId,
+		t.RootAppService,
+		t.RootAutoServiceType,
+		t.RootAutoServiceID,
+		t.TraceDuration,
+		t.TraceSpanCount,
+		t.CriticalPath,
+		t.ErrorOriginAppService,
+		t.ErrorOriginAutoServType,
+		t.ErrorOriginAutoServID,
+		t.AppService,
+		t.AutoServiceType,
+		t.AutoServiceID,
+		t.SpanCount,
+		t.ErrorCount,
+		t.IsErrorOrigin,
+		t.SelfTime,
+		t.CriticalPathTime,
+		t.NetworkTime,
+	)
+}
+
+func TraceAnalysisColumns() []*ckdb.Column {
+	return []*ckdb.Column{
+		ckdb.NewColumn("time", ckdb.DateTime),
+		ckdb.NewColumn("trace_id", ckdb.String),
+		ckdb.NewColumn("root_app_service", ckdb.LowCardinalityString),
+		ckdb.NewColumn("root_auto_service_type", ckdb.UInt8),
+		ckdb.NewColumn("root_auto_service_id", ckdb.UInt32),
+		ckdb.NewColumn("trace_duration", ckdb.UInt64),
+		ckdb.NewColumn("trace_span_count", ckdb.UInt32),
+		ckdb.NewColumn("critical_path", ckdb.String),
+		ckdb.NewColumn("error_origin_app_service", ckdb.LowCardinalityString),
+		ckdb.NewColumn("error_origin_auto_service_type", ckdb.UInt8),
+		ckdb.NewColumn("error_origin_auto_service_id", ckdb.UInt32),
+		ckdb.NewColumn("app_service", ckdb.LowCardinalityString),
+		ckdb.NewColumn("auto_service_type", ckdb.UInt8),
+		ckdb.NewColumn("auto_service_id", ckdb.UInt32),
+		ckdb.NewColumn("span_count", ckdb.UInt32),
+		ckdb.NewColumn("error_count", ckdb.UInt32),
+		ckdb.NewColumn("is_error_origin", ckdb.UInt8),
+		ckdb.NewColumn("self_time", ckdb.UInt64),
+		ckdb.NewColumn("critical_path_time", ckdb.UInt64),
+		ckdb.NewColumn("network_time", ckdb.UInt64),
+	}
+}
+
+var poolTraceAnalysis = pool.NewLockFreePool(func() interface{} {
+	return new(TraceAnalysis)
+})
+
+func AcquireTraceAnalysis() *TraceAnalysis {
+	return poolTraceAnalysis.Get().(*TraceAnalysis)
+}
+
+func ReleaseTraceAnalysis(t *TraceAnalysis) {
+	if t == nil {
+		return
+	}
+	*t = TraceAnalysis{}
+	poolTraceAnalysis.Put(t)
+}
+
+// serviceKey identifies a service in a trace. Spans of the same workload captured by eBPF and by OTel SDK are
+// merged by auto_service, app_service is used only if auto_service is unknown.
+type serviceKey struct {
+	autoServiceType uint8
+	autoServiceID   uint32
+	appService      string
+}
+
+type analysisSpan struct {
+	span       *SpanTrace
+	start, end int64 // us
+	parent     int
+	children   []int
+
+	service  serviceKey
+	callee   serviceKey // valid for client side spans
+	isClient bool
+	isServer bool
+	isNet    bool // captured by network tap, not by process or app
+
+	selfTime     int64
+	criticalTime int64
+	hasError     bool // the span or its descendants are server errors
+}
+
+type serviceStats struct {
+	appService       string
+	spanCount        uint32
+	errorCount       uint32
+	selfTime         uint64
+	criticalPathTime uint64
+	networkTime      uint64
+}
+
+func isProcessOrApp(observationPoint string) bool {
+	switch observationPoint {
+	case "c-p", "s-p", "c-app", "s-app", "app":
+		return true
+	}
+	return false
+}
+
+func newAnalysisSpan(s *SpanTrace) *analysisSpan {
+	end := int64(s.Time)*1000000 + int64(s.EndTimeUsPart)
+	a := &analysisSpan{
+		span:     s,
+		start:    end - int64(s.ResponseDuration),
+		end:      end,
+		parent:   -1,
+		isClient: strings.HasPrefix(s.ObservationPoint, "c"),
+		isServer: strings.HasPrefix(s.ObservationPoint, "s"),
+		isNet:    !isProcessOrApp(s.ObservationPoint),
+	}
+	client := serviceKey{autoServiceType: s.AutoServiceType0, autoServiceID: s.AutoServiceID0}
+	server := serviceKey{autoServiceType: s.AutoServiceType1, autoServiceID: s.AutoServiceID1}
+	if a.isClient || (!a.isServer && server.autoServiceID == 0) {
+		a.service = client
+	} else {
+		a.service = server
+	}
+	if a.service.autoServiceID == 0 {
+		a.service = serviceKey{appService: s.AppService}
+	}
+	if a.isClient {
+		a.callee = server
+	}
+	return a
+}
+
+// spans of the same hop (one call observed at different observation points) share the same span_id,
+// the outer one (longer, client side first) is the parent of the inner one
+func hopLess(a, b *analysisSpan) bool {
+	if a.end-a.start != b.end-b.start {
+		return a.end-a.start > b.end-b.start
+	}
+	if a.isClient != b.isClient {
+		return a.isClient
+	}
+	return a.span.ObservationPoint < b.span.ObservationPoint
+}
+
+// AnalyzeTrace computes the critical path, self time and network time of each service, and the error origin of a
+// completed trace. Spans are linked by span_id/parent_span_id, spans without span_id can not be placed in the
+// tree and are only counted. The returned rows should be released by the caller.
+func AnalyzeTrace(orgId uint16, traceId string, spans []*SpanTrace) []*TraceAnalysis {
+	if len(spans) == 0 {
+		return nil
+	}
+	nodes := make([]*analysisSpan, len(spans))
+	hops := make(map[string][]int)
+	var hopIds []string
+	for i, s := range spans {
+		nodes[i] = newAnalysisSpan(s)
+		if s.SpanId == "" {
+			continue
+		}
+		if _, ok := hops[s.SpanId]; !ok {
+			hopIds = append(hopIds, s.SpanId)
+		}
+		hops[s.SpanId] = append(hops[s.SpanId], i)
+	}
+
+	// link spans
+	for _, id := range hopIds {
+		hop := hops[id]
+		sort.SliceStable(hop, func(i, j int) bool { return hopLess(nodes[hop[i]], nodes[hop[j]]) })
+	}
+	for _, id := range hopIds {
+		hop := hops[id]
+		for i := 1; i < len(hop); i++ {
+			nodes[hop[i]].parent = hop[i-1]
+		}
+		first := nodes[hop[0]]
+		if parentSpanId := first.span.ParentSpanId; parentSpanId != "" && parentSpanId != id {
+			if parentHop, ok := hops[parentSpanId]; ok {
+				first.parent = parentHop[len(parentHop)-1]
+			}
+		}
+	}
+	// break cycles, a span which can not reach a root is detached
+	for i, n := range nodes {
+		steps := 0
+		for p := n.parent; p >= 0 && steps <= len(nodes); p = nodes[p].parent {
+			steps++
+		}
+		if steps > len(nodes) {
+			nodes[i].parent = -1
+		}
+	}
+	root := -1
+	for i, n := range nodes {
+		if n.span.SpanId == "" {
+			continue
+		}
+		if n.parent >= 0 {
+			nodes[n.parent].children = append(nodes[n.parent].children, i)
+		} else if root < 0 || n.end-n.start > nodes[root].end-nodes[root].start {
+			root = i
+		}
+	}
+
+	stats := make(map[serviceKey]*serviceStats)
+	var keys []serviceKey
+	getStats := func(k serviceKey, appService string) *serviceStats {
+		s, ok := stats[k]
+		if !ok {
+			s = &serviceStats{}
+			stats[k] = s
+			keys = append(keys, k)
+		}
+		if s.appService == "" {
+			s.appService = appService
+		}
+		return s
+	}
+
+	for _, n := range nodes {
+		s := getStats(n.service, n.span.AppService)
+		s.spanCount++
+		if n.span.ResponseStatus == responseStatusServerError {
+			s.errorCount++
+		}
+		if n.span.SpanId == "" {
+			continue
+		}
+		n.selfTime = selfTime(n, nodes)
+	}
+	var segments []criticalSegment
+	if root >= 0 {
+		segments = criticalPath(root, nodes[root].end, nodes, nil)
+	}
+
+	// attribute self time, on the critical path or not
+	for _, n := range nodes {
+		if n.span.SpanId == "" {
+			continue
+		}
+		network, owner := attribution(n, nodes)
+		s := getStats(owner, "")
+		if network {
+			s.networkTime += uint64(n.selfTime)
+		} else {
+			s.selfTime += uint64(n.selfTime)
+		}
+		s.criticalPathTime += uint64(n.criticalTime)
+	}
+
+	// error origin: the earliest error span without error in its descendants
+	errorOrigin := -1
+	if root >= 0 {
+		markErrors(root, nodes)
+	}
+	for i, n := range nodes {
+		if n.span.ResponseStatus != responseStatusServerError || n.span.SpanId == "" {
+			continue
+		}
+		childError := false
+		for _, c := range n.children {
+			if nodes[c].hasError {
+				childError = true
+				break
+			}
+		}
+		if !childError && (errorOrigin < 0 || n.start < nodes[errorOrigin].start) {
+			errorOrigin = i
+		}
+	}
+
+	trace := TraceAnalysis{
+		OrgId:          orgId,
+		TraceId:        traceId,
+		TraceSpanCount: uint32(len(spans)),
+	}
+	for _, n := range nodes {
+		if t := uint32(n.end / 1000000); t > trace.Time {
+			trace.Time = t
+		}
+	}
+	if root >= 0 {
+		r := nodes[root]
+		trace.RootAppService = stats[r.service].appService
+		trace.RootAutoServiceType, trace.RootAutoServiceID = r.service.autoServiceType, r.service.autoServiceID
+		trace.TraceDuration = uint64(r.end - r.start)
+		trace.CriticalPath = criticalPathString(segments, nodes, stats)
+	}
+	var originKey serviceKey
+	if errorOrigin >= 0 {
+		originKey = nodes[errorOrigin].service
+		trace.ErrorOriginAppService = stats[originKey].appService
+		trace.ErrorOriginAutoServType, trace.ErrorOriginAutoServID = originKey.autoServiceType, originKey.autoServiceID
+	}
+
+	rows := make([]*TraceAnalysis, 0, len(keys))
+	for _, k := range keys {
+		s := stats[k]
+		row := AcquireTraceAnalysis()
+		*row = trace
+		row.AppService = s.appService
+		row.AutoServiceType, row.AutoServiceID = k.autoServiceType, k.autoServiceID
+		row.SpanCount = s.spanCount
+		row.ErrorCount = s.errorCount
+		if errorOrigin >= 0 && k == originKey {
+			row.IsErrorOrigin = 1
+		}
+		row.SelfTime = s.selfTime
+		row.CriticalPathTime = s.criticalPathTime
+		row.NetworkTime = s.networkTime
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// selfTime is the duration of the span not covered by its children
+func selfTime(n *analysisSpan, nodes []*analysisSpan) int64 {
+	type interval struct{ start, end int64 }
+	intervals := make([]interval, 0, len(n.children))
+	for _, c := range n.children {
+		start, end := nodes[c].start, nodes[c].end
+		if start < n.start {
+			start = n.start
+		}
+		if end > n.end {
+			end = n.end
+		}
+		if end > start {
+			intervals = append(intervals, interval{start, end})
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	covered, cursor := int64(0), n.start
+	for _, i := range intervals {
+		if i.start > cursor {
+			cursor = i.start
+		}
+		if i.end > cursor {
+			covered += i.end - cursor
+			cursor = i.end
+		}
+	}
+	return n.end - n.start - covered
+}
+
+type criticalSegment struct {
+	node       int
+	start, end int64
+}
+
+// criticalPath walks back from the end of the span: the last finished child is on the critical path, the time
+// between its end and the cursor is spent by the span itself, then the cursor moves to the start of the child.
+// Children overlapping with a child already on the path run in parallel and are skipped.
+func criticalPath(i int, end int64, nodes []*analysisSpan, segments []criticalSegment) []criticalSegment {
+	n := nodes[i]
+	children := append([]int(nil), n.children...)
+	sort.Slice(children, func(a, b int) bool { return nodes[children[a]].end > nodes[children[b]].end })
+	cursor := end
+	for _, c := range children {
+		child := nodes[c]
+		if child.start >= cursor || child.end <= n.start {
+			continue
+		}
+		childEnd := child.end
+		if childEnd > cursor {
+			childEnd = cursor
+		}
+		if cursor > childEnd {
+			n.criticalTime += cursor - childEnd
+			segments = append(segments, criticalSegment{i, childEnd, cursor})
+		}
+		segments = criticalPath(c, childEnd, nodes, segments)
+		cursor = child.start
+		if cursor < n.start {
+			cursor = n.start
+		}
+	}
+	if cursor > n.start {
+		n.criticalTime += cursor - n.start
+		segments = append(segments, criticalSegment{i, n.start, cursor})
+	}
+	return segments
+}
+
+// attribution returns whether the self time of the span is network time, and the service it belongs to
+func attribution(n *analysisSpan, nodes []*analysisSpan) (bool, serviceKey) {
+	if n.isNet {
+		// time between packets observed on the network belongs to the caller
+		return true, n.service
+	}
+	if !n.isClient {
+		return false, n.service
+	}
+	if len(n.children) == 0 {
+		// the callee is not traced, the time is spent by the callee
+		if n.callee.autoServiceID != 0 {
+			return false, n.callee
+		}
+		return false, n.service
+	}
+	for _, c := range n.children {
+		if !nodes[c].isServer && !nodes[c].isNet {
+			return false, n.service
+		}
+	}
+	// gap between a client span and the server/network spans of the same call
+	return true, n.service
+}
+
+func markErrors(i int, nodes []*analysisSpan) bool {
+	n := nodes[i]
+	n.hasError = n.span.ResponseStatus == responseStatusServerError
+	for _, c := range n.children {
+		if markErrors(c, nodes) {
+			n.hasError = true
+		}
+	}
+	return n.hasError
+}
+
+func criticalPathString(segments []criticalSegment, nodes []*analysisSpan, stats map[serviceKey]*serviceStats) string {
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].start < segments[j].start })
+	names := make([]string, 0, len(segments))
+	var last serviceKey
+	for i, s := range segments {
+		_, key := attribution(nodes[s.node], nodes)
+		if i > 0 && key == last {
+			continue
+		}
+		last = key
+		if len(names) >= CRITICAL_PATH_MAX_SEGMENTS {
+			names = append(names, "...")
+			break
+		}
+		names = append(names, serviceName(key, stats))
+	}
+	return strings.Join(names, CRITICAL_PATH_SEPARATOR)
+}
+
+func serviceName(k serviceKey, stats map[serviceKey]*serviceStats) string {
+	if s, ok := stats[k]; ok && s.appService != "" {
+		return s.appService
+	}
+	if k.appService != "" {
+		return k.appService
+	}
+	if k.autoServiceID == 0 {
+		return "unknown"
+	}
+	return "auto_service:" + strconv.Itoa(int(k.autoServiceType)) + "-" + strconv.FormatUint(uint64(k.autoServiceID), 10)
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracetree
+
+import (
+	"testing"
+)
+
+func testSpan(op, spanId, parentSpanId, appService string, id0, id1 uint32, startUs, durationUs int64, status uint8) *SpanTrace {
+	end := startUs + durationUs
+	return &SpanTrace{
+		Time:             uint32(end / 1000000),
+		EndTimeUsPart:    uint32(end % 1000000),
+		AutoServiceType0: 1,
+		AutoServiceID0:   id0,
+		AutoServiceType1: 1,
+		AutoServiceID1:   id1,
+		ObservationPoint: op,
+		SpanId:           spanId,
+		ParentSpanId:     parentSpanId,
+		AppService:       appService,
+		ResponseDuration: uint64(durationUs),
+		ResponseStatus:   status,
+	}
+}
+
+func rowsByService(rows []*TraceAnalysis) map[string]*TraceAnalysis {
+	m := make(map[string]*TraceAnalysis)
+	for _, r := range rows {
+		m[r.AppService] = r
+	}
+	return m
+}
+
+func TestAnalyzeTrace(t *testing.T) {
+	base := int64(1700000000) * 1000000
+	spans := []*SpanTrace{
+		// gateway handles the request: 0 ~ 1000us
+		testSpan("s-app", "a", "", "gateway", 0, 10, base, 1000, 0),
+		// gateway calls order: 100 ~ 900us, the server side of the call is 150 ~ 850us
+		testSpan("c-p", "b", "a", "gateway", 10, 20, base+100, 800, 0),
+		testSpan("s-app", "b", "a", "order", 10, 20, base+150, 700, 0),
+		// order calls an untraced database: 200 ~ 700us
+		testSpan("c-p", "c", "b", "order", 20, 30, base+200, 500, 0),
+	}
+	spans[3].AutoServiceType1 = 2
+	rows := AnalyzeTrace(1, "trace-1", spans)
+	defer func() {
+		for _, r := range rows {
+			r.Release()
+		}
+	}()
+
+	m := rowsByService(rows)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 services, got %d", len(rows))
+	}
+	gateway, order := m["gateway"], m["order"]
+	if gateway == nil || order == nil {
+		t.Fatalf("missing service rows: %v", m)
+	}
+	if gateway.TraceDuration != 1000 || gateway.TraceSpanCount != 4 || gateway.RootAppService != "gateway" {
+		t.Errorf("unexpected trace info: %+v", gateway)
+	}
+	if gateway.SelfTime != 200 || gateway.NetworkTime != 100 {
+		t.Errorf("gateway self time %d network time %d, expected 200 and 100", gateway.SelfTime, gateway.NetworkTime)
+	}
+	if order.SelfTime != 200 || order.SpanCount != 2 {
+		t.Errorf("order self time %d span count %d, expected 200 and 2", order.SelfTime, order.SpanCount)
+	}
+	var db *TraceAnalysis
+	for _, r := range rows {
+		if r.AutoServiceType == 2 && r.AutoServiceID == 30 {
+			db = r
+		}
+	}
+	if db == nil || db.SelfTime != 500 || db.SpanCount != 0 {
+		t.Errorf("untraced callee should get the leaf client time: %+v", db)
+	}
+	expectedPath := "gateway > order > auto_service:2-30 > order > gateway"
+	if gateway.CriticalPath != expectedPath {
+		t.Errorf("critical path %q, expected %q", gateway.CriticalPath, expectedPath)
+	}
+	var total uint64
+	for _, r := range rows {
+		total += r.CriticalPathTime
+	}
+	if total != 1000 {
+		t.Errorf("critical path time %d, expected 1000", total)
+	}
+}
+
+func TestAnalyzeTraceErrorOrigin(t *testing.T) {
+	base := int64(1700000000) * 1000000
+	spans := []*SpanTrace{
+		testSpan("s-app", "a", "", "gateway", 0, 10, base, 1000, 3),
+		testSpan("c-app", "b", "a", "gateway", 10, 20, base+100, 300, 0),
+		testSpan("s-app", "b", "a", "user", 10, 20, base+100, 300, 0),
+		testSpan("c-app", "c", "a", "gateway", 10, 30, base+500, 400, 3),
+		testSpan("s-app", "c", "a", "payment", 10, 30, base+500, 400, 3),
+	}
+	rows := AnalyzeTrace(1, "trace-2", spans)
+	m := rowsByService(rows)
+	for name, r := range m {
+		if r.ErrorOriginAppService != "payment" {
+			t.Errorf("%s: error origin %q, expected payment", name, r.ErrorOriginAppService)
+		}
+		if (r.IsErrorOrigin == 1) != (name == "payment") {
+			t.Errorf("%s: unexpected is_error_origin %d", name, r.IsErrorOrigin)
+		}
+	}
+	if m["gateway"].ErrorCount != 2 {
+		t.Errorf("gateway error count %d, expected 2", m["gateway"].ErrorCount)
+	}
+	for _, r := range rows {
+		r.Release()
+	}
+}
+
+func TestAnalyzeTraceCycle(t *testing.T) {
+	base := int64(1700000000) * 1000000
+	spans := []*SpanTrace{
+		testSpan("s-app", "a", "b", "x", 0, 10, base, 100, 0),
+		testSpan("s-app", "b", "a", "y", 0, 20, base, 50, 0),
+		testSpan("s-app", "", "", "z", 0, 30, base, 10, 0),
+	}
+	rows := AnalyzeTrace(1, "trace-3", spans)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 services, got %d", len(rows))
+	}
+	for _, r := range rows {
+		r.Release()
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+const (
+	SUCCESS            = "SUCCESS"
+	FAIL               = "FAIL"
+	INVALID_PARAMETERS = "INVALID_PARAMETERS"
+	INVALID_POST_DATA  = "INVALID_POST_DATA"
+	SERVER_ERROR       = "SERVER_ERROR"
+)
+
+const (
+	METHOD_ZSCORE   = "zscore"   // z-score against the previous window points
+	METHOD_MAD      = "mad"      // median absolute deviation of the previous window points
+	METHOD_SEASONAL = "seasonal" // z-score against the same time of the previous days
+)
+
+const (
+	DEFAULT_WINDOW    = 30
+	DEFAULT_THRESHOLD = 3.0
+	DEFAULT_SEASONS   = 7
+	MAX_SEASONS       = 30
+	SEASON_PERIOD     = 86400
+	// scales MAD to the standard deviation of normal distribution
+	MAD_SCALE = 1.4826
+	// score of a point different from a constant history
+	MAX_SCORE = 1000.0
+	// a point needs at least 2 history points to get a score
+	MIN_HISTORY_POINTS = 2
+)
+
+const (
+	COLUMN_TIME    = "time"
+	COLUMN_VALUE   = "value"
+	COLUMN_SCORE   = "score"
+	COLUMN_LOWER   = "lower"
+	COLUMN_UPPER   = "upper"
+	COLUMN_ANOMALY = "is_anomaly"
+)
+
+// alert events share the event levels of event.alert_event
+const (
+	DEFAULT_ALERT_POLICY = "anomaly_detection"
+	EVENT_LEVEL_ERROR    = 2
+	EVENT_LEVEL_WARN     = 3
+	TAG_ANOMALY_METHOD   = "anomaly_method"
+)
+
+const (
+	HEADER_KEY_X_ORG_ID  = "X-Org-Id"
+	HEADER_KEY_X_USER_ID = "X-User-Id"
+)
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "context"
+
+type Anomaly struct {
+	// DeepFlow SQL grouped by time, or PromQL
+	DB         string `json:"db"`
+	DataSource string `json:"data_precision"`
+	Sql        string `json:"sql"`
+	Promql     string `json:"promql"`
+	// time range and step of PromQL, unit: s
+	TimeStart int64  `json:"time_start"`
+	TimeEnd   int64  `json:"time_end"`
+	Step      string `json:"step"`
+	// value column of sql, default is the last metric
+	Metric      string  `json:"metric"`
+	Method      string  `json:"method" binding:"required"`
+	Window      int     `json:"window"`
+	Seasons     int     `json:"seasons"`
+	Threshold   float64 `json:"threshold"`
+	AlertPolicy string  `json:"alert_policy"`
+	Debug       bool    `json:"debug"`
+	Context     context.Context
+	OrgID       string
+	UserID      string
+}
+
+type Series struct {
+	TagValues []string
+	Times     []int64
+	Values    []float64
+}
+
+// Band is the expected range of a point, points without enough history are not valid
+type Band struct {
+	Valid   bool
+	Score   float64
+	Lower   float64
+	Upper   float64
+	Anomaly bool
+}
+
+// AlertEvent has the same fields as event.alert_event
+type AlertEvent struct {
+	Time         uint32   `json:"time"`
+	AlertPolicy  string   `json:"alert_policy"`
+	MetricValue  float64  `json:"metric_value"`
+	EventLevel   uint32   `json:"event_level"`
+	TargetTags   string   `json:"target_tags"`
+	TagStrKeys   []string `json:"tag_str_keys"`
+	TagStrValues []string `json:"tag_str_values"`
+}
+
+type AnomalyResult struct {
+	Columns     []interface{} `json:"columns"`
+	Values      []interface{} `json:"values"`
+	AlertEvents []AlertEvent  `json:"alert_events"`
+}
+
+func (s *Series) Len() int {
+	return len(s.Times)
+}
+
+func (s *Series) Less(i, j int) bool {
+	return s.Times[i] < s.Times[j]
+}
+
+func (s *Series) Swap(i, j int) {
+	s.Times[i], s.Times[j] = s.Times[j], s.Times[i]
+	s.Values[i], s.Values[j] = s.Values[j], s.Values[i]
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/deepflowio/deepflow/server/querier/app/anomaly/common"
+	"github.com/deepflowio/deepflow/server/querier/app/anomaly/model"
+	"github.com/deepflowio/deepflow/server/querier/app/anomaly/service"
+	prometheus_service "github.com/deepflowio/deepflow/server/querier/app/prometheus/service"
+	querier_common "github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/router"
+)
+
+func AnomalyRouter(e *gin.Engine, promService *prometheus_service.PrometheusService) {
+	e.POST("/v1/anomaly/detect", detect(promService))
+}
+
+func detect(promService *prometheus_service.PrometheusService) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		var args model.Anomaly
+
+		// 参数校验
+		err := c.ShouldBindBodyWith(&args, binding.JSON)
+		if err != nil {
+			router.BadRequestResponse(c, common.INVALID_POST_DATA, err.Error())
+			return
+		}
+		args.Context = c.Request.Context()
+		args.OrgID = c.Request.Header.Get(common.HEADER_KEY_X_ORG_ID)
+		if args.OrgID == "" {
+			args.OrgID = querier_common.DEFAULT_ORG_ID
+		}
+		args.UserID = c.Request.Header.Get(common.HEADER_KEY_X_USER_ID)
+		result, debug, err := service.Detect(&args, promService)
+		if err == nil && !args.Debug {
+			debug = nil
+		}
+		router.JsonResponse(c, result, debug, err)
+	})
+}
@@ -0,0 +1,388 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	logging "github.com/op/go-logging"
+	"github.com/prometheus/prometheus/promql"
+
+	"github.com/deepflowio/deepflow/server/querier/app/anomaly/common"
+	"github.com/deepflowio/deepflow/server/querier/app/anomaly/model"
+	prometheus_model "github.com/deepflowio/deepflow/server/querier/app/prometheus/model"
+	prometheus_service "github.com/deepflowio/deepflow/server/querier/app/prometheus/service"
+	querier_common "github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse"
+	"github.com/deepflowio/deepflow/server/querier/service"
+)
+
+var log = logging.MustGetLogger("anomaly")
+
+// time(time, interval) AS alias
+var sqlTimeAliasRegexp = regexp.MustCompile("(?i)\\btime\\s*\\(\\s*`?time`?\\s*,[^)]*\\)\\s+AS\\s+`?(\\w+)`?")
+
+// seriesQuerier returns the series of the time range shifted back by offset seconds, times of the points are
+// not shifted
+type seriesQuerier interface {
+	query(offset int) (tagNames []string, series []*model.Series, err error)
+	debug() interface{}
+}
+
+func Detect(args *model.Anomaly, promService *prometheus_service.PrometheusService) (*model.AnomalyResult, interface{}, error) {
+	if err := checkArgs(args); err != nil {
+		return nil, nil, err
+	}
+	var querier seriesQuerier
+	if args.Sql != "" {
+		querier = &sqlQuerier{args: args}
+	} else {
+		querier = &promQuerier{args: args, promService: promService}
+	}
+	tagNames, series, err := querier.query(0)
+	if err != nil {
+		return nil, querier.debug(), err
+	}
+
+	bands := make([][]model.Band, len(series))
+	switch args.Method {
+	case common.METHOD_ZSCORE:
+		for i, s := range series {
+			bands[i] = ZScore(s.Values, args.Window, args.Threshold)
+		}
+	case common.METHOD_MAD:
+		for i, s := range series {
+			bands[i] = MAD(s.Values, args.Window, args.Threshold)
+		}
+	case common.METHOD_SEASONAL:
+		baselines := make([][][]float64, len(series))
+		for season := 1; season <= args.Seasons; season++ {
+			offset := season * common.SEASON_PERIOD
+			_, seasonSeries, err := querier.query(offset)
+			if err != nil {
+				return nil, querier.debug(), err
+			}
+			seasonValues := make(map[string]map[int64]float64, len(seasonSeries))
+			for _, s := range seasonSeries {
+				values := make(map[int64]float64, len(s.Times))
+				for i, t := range s.Times {
+					values[t+int64(offset)] = s.Values[i]
+				}
+				seasonValues[seriesKey(s.TagValues)] = values
+			}
+			for i, s := range series {
+				baseline := make([]float64, len(s.Times))
+				values := seasonValues[seriesKey(s.TagValues)]
+				for j, t := range s.Times {
+					if v, ok := values[t]; ok {
+						baseline[j] = v
+					} else {
+						baseline[j] = math.NaN()
+					}
+				}
+				baselines[i] = append(baselines[i], baseline)
+			}
+		}
+		for i, s := range series {
+			bands[i] = Seasonal(s.Values, baselines[i], args.Threshold)
+		}
+	}
+	return newAnomalyResult(args, tagNames, series, bands), querier.debug(), nil
+}
+
+func checkArgs(args *model.Anomaly) error {
+	if (args.Sql == "") == (args.Promql == "") {
+		return querier_common.NewError(common.INVALID_PARAMETERS, "one of sql and promql is required")
+	}
+	if args.Promql != "" && (args.TimeStart <= 0 || args.TimeEnd < args.TimeStart || args.Step == "") {
+		return querier_common.NewError(common.INVALID_PARAMETERS, "time_start, time_end and step are required by promql")
+	}
+	switch args.Method {
+	case common.METHOD_ZSCORE, common.METHOD_MAD, common.METHOD_SEASONAL:
+	default:
+		return querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("method [%s] is not supported", args.Method))
+	}
+	if args.Window <= 0 {
+		args.Window = common.DEFAULT_WINDOW
+	}
+	if args.Threshold <= 0 {
+		args.Threshold = common.DEFAULT_THRESHOLD
+	}
+	if args.Seasons <= 0 {
+		args.Seasons = common.DEFAULT_SEASONS
+	} else if args.Seasons > common.MAX_SEASONS {
+		return querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("seasons should not be greater than %d", common.MAX_SEASONS))
+	}
+	if args.AlertPolicy == "" {
+		args.AlertPolicy = common.DEFAULT_ALERT_POLICY
+	}
+	return nil
+}
+
+// newAnomalyResult returns rows of time, tags, value, score, lower, upper and is_anomaly, which can be shown by
+// grafana as the result of DeepFlow SQL, anomaly points are also returned as alert events
+func newAnomalyResult(args *model.Anomaly, tagNames []string, series []*model.Series, bands [][]model.Band) *model.AnomalyResult {
+	result := &model.AnomalyResult{Columns: []interface{}{common.COLUMN_TIME}, Values: []interface{}{}, AlertEvents: []model.AlertEvent{}}
+	for _, tagName := range tagNames {
+		result.Columns = append(result.Columns, tagName)
+	}
+	result.Columns = append(result.Columns, common.COLUMN_VALUE, common.COLUMN_SCORE, common.COLUMN_LOWER, common.COLUMN_UPPER, common.COLUMN_ANOMALY)
+	for i, s := range series {
+		targetTags := make([]string, len(tagNames))
+		for j, tagName := range tagNames {
+			targetTags[j] = tagName + "=" + s.TagValues[j]
+		}
+		for j, t := range s.Times {
+			row := []interface{}{uint32(t)}
+			for _, tagValue := range s.TagValues {
+				row = append(row, tagValue)
+			}
+			row = append(row, nullableFloat(s.Values[j]))
+			band := bands[i][j]
+			if !band.Valid {
+				row = append(row, nil, nil, nil, 0)
+				result.Values = append(result.Values, row)
+				continue
+			}
+			anomaly := 0
+			if band.Anomaly {
+				anomaly = 1
+			}
+			row = append(row, band.Score, band.Lower, band.Upper, anomaly)
+			result.Values = append(result.Values, row)
+			if !band.Anomaly {
+				continue
+			}
+			eventLevel := uint32(common.EVENT_LEVEL_WARN)
+			if math.Abs(band.Score) >= 2*args.Threshold {
+				eventLevel = common.EVENT_LEVEL_ERROR
+			}
+			result.AlertEvents = append(result.AlertEvents, model.AlertEvent{
+				Time:         uint32(t),
+				AlertPolicy:  args.AlertPolicy,
+				MetricValue:  s.Values[j],
+				EventLevel:   eventLevel,
+				TargetTags:   strings.Join(targetTags, ","),
+				TagStrKeys:   append(append([]string{}, tagNames...), common.TAG_ANOMALY_METHOD),
+				TagStrValues: append(append([]string{}, s.TagValues...), args.Method),
+			})
+		}
+	}
+	return result
+}
+
+type sqlQuerier struct {
+	args   *model.Anomaly
+	debugs []interface{}
+}
+
+func (q *sqlQuerier) query(offset int) ([]string, []*model.Series, error) {
+	sql := q.args.Sql
+	if offset > 0 {
+		var err error
+		sql, err = clickhouse.ShiftTimeFilter(sql, offset)
+		if err != nil {
+			return nil, nil, querier_common.NewError(common.INVALID_PARAMETERS, err.Error())
+		}
+	}
+	querierArgs := &querier_common.QuerierParams{
+		DB:         q.args.DB,
+		Sql:        sql,
+		DataSource: q.args.DataSource,
+		Debug:      strconv.FormatBool(q.args.Debug),
+		QueryUUID:  uuid.New().String(),
+		Context:    q.args.Context,
+		ORGID:      q.args.OrgID,
+		UserID:     q.args.UserID,
+	}
+	result, debug, err := service.StreamExecute(querierArgs)
+	if debug != nil {
+		q.debugs = append(q.debugs, debug)
+	}
+	if err != nil {
+		log.Errorf("query_uuid: %s | anomaly detection query failed: %s", querierArgs.QueryUUID, err)
+		return nil, nil, err
+	}
+	timeColumn := common.COLUMN_TIME
+	if match := sqlTimeAliasRegexp.FindStringSubmatch(sql); match != nil {
+		timeColumn = match[1]
+	}
+	return seriesFromResult(result, timeColumn, q.args.Metric)
+}
+
+func (q *sqlQuerier) debug() interface{} {
+	return q.debugs
+}
+
+// seriesFromResult splits rows into series by the tag columns
+func seriesFromResult(result *querier_common.Result, timeColumn, metric string) ([]string, []*model.Series, error) {
+	timeIndex, valueIndex := -1, -1
+	for i, column := range result.Columns {
+		name, _ := column.(string)
+		if name == timeColumn {
+			timeIndex = i
+		} else if metric != "" && name == metric {
+			valueIndex = i
+		} else if metric == "" && i < len(result.Schemas) && result.Schemas[i].Type == querier_common.COLUMN_SCHEMA_TYPE_METRICS {
+			valueIndex = i
+		}
+	}
+	if timeIndex < 0 {
+		return nil, nil, querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("time column [%s] not found", timeColumn))
+	}
+	if valueIndex < 0 {
+		return nil, nil, querier_common.NewError(common.INVALID_PARAMETERS, "metric column not found")
+	}
+	tagIndexes := []int{}
+	tagNames := []string{}
+	for i, column := range result.Columns {
+		if i == timeIndex || i == valueIndex || (i < len(result.Schemas) && result.Schemas[i].Type == querier_common.COLUMN_SCHEMA_TYPE_METRICS) {
+			continue
+		}
+		tagIndexes = append(tagIndexes, i)
+		tagNames = append(tagNames, fmt.Sprint(column))
+	}
+
+	seriesIndex := map[string]int{}
+	series := []*model.Series{}
+	for _, value := range result.Values {
+		row, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		t, ok := querier_common.ValueToFloat64(row[timeIndex])
+		if !ok {
+			return nil, nil, querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("time column [%s] is not a timestamp", timeColumn))
+		}
+		tagValues := make([]string, len(tagIndexes))
+		for i, tagIndex := range tagIndexes {
+			tagValues[i] = querier_common.ValueToString(row[tagIndex])
+		}
+		key := seriesKey(tagValues)
+		index, ok := seriesIndex[key]
+		if !ok {
+			index = len(series)
+			seriesIndex[key] = index
+			series = append(series, &model.Series{TagValues: tagValues})
+		}
+		v, ok := querier_common.ValueToFloat64(row[valueIndex])
+		if !ok {
+			v = math.NaN()
+		}
+		series[index].Times = append(series[index].Times, int64(t))
+		series[index].Values = append(series[index].Values, v)
+	}
+	for _, s := range series {
+		sort.Sort(s)
+	}
+	return tagNames, series, nil
+}
+
+type promQuerier struct {
+	args        *model.Anomaly
+	promService *prometheus_service.PrometheusService
+	debugs      []interface{}
+}
+
+func (q *promQuerier) query(offset int) ([]string, []*model.Series, error) {
+	if q.promService == nil {
+		return nil, nil, errors.New("promql is not supported")
+	}
+	ctx := q.args.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	promArgs := &prometheus_model.PromQueryParams{
+		Debug:     q.args.Debug,
+		Promql:    q.args.Promql,
+		StartTime: strconv.FormatInt(q.args.TimeStart-int64(offset), 10),
+		EndTime:   strconv.FormatInt(q.args.TimeEnd-int64(offset), 10),
+		Step:      q.args.Step,
+		OrgID:     q.args.OrgID,
+		Context:   ctx,
+	}
+	result, err := q.promService.PromRangeQueryService(promArgs, ctx)
+	if result != nil && q.args.Debug {
+		q.debugs = append(q.debugs, result.Stats)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	data, ok := result.Data.(*prometheus_model.PromQueryData)
+	if !ok {
+		return nil, nil, nil
+	}
+	matrix, ok := data.Result.(promql.Matrix)
+	if !ok {
+		return nil, nil, querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("promql result type [%s] is not matrix", data.ResultType))
+	}
+	return seriesFromMatrix(matrix)
+}
+
+func (q *promQuerier) debug() interface{} {
+	return q.debugs
+}
+
+// seriesFromMatrix uses all label names of the matrix as tags, the label is empty if a series does not have it
+func seriesFromMatrix(matrix promql.Matrix) ([]string, []*model.Series, error) {
+	tagNameSet := map[string]bool{}
+	for _, s := range matrix {
+		for _, label := range s.Metric {
+			tagNameSet[label.Name] = true
+		}
+	}
+	tagNames := make([]string, 0, len(tagNameSet))
+	for tagName := range tagNameSet {
+		tagNames = append(tagNames, tagName)
+	}
+	sort.Strings(tagNames)
+
+	series := make([]*model.Series, 0, len(matrix))
+	for _, s := range matrix {
+		tagValues := make([]string, len(tagNames))
+		for i, tagName := range tagNames {
+			tagValues[i] = s.Metric.Get(tagName)
+		}
+		item := &model.Series{TagValues: tagValues}
+		for _, point := range s.Points {
+			item.Times = append(item.Times, point.T/1000)
+			item.Values = append(item.Values, point.V)
+		}
+		series = append(series, item)
+	}
+	return tagNames, series, nil
+}
+
+func seriesKey(tagValues []string) string {
+	return strings.Join(tagValues, "\x00")
+}
+
+// values are returned as null if they are missing, since json does not support NaN
+func nullableFloat(value float64) interface{} {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return value
+}
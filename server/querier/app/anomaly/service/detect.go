@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"math"
+	"sort"
+
+	"github.com/deepflowio/deepflow/server/querier/app/anomaly/common"
+	"github.com/deepflowio/deepflow/server/querier/app/anomaly/model"
+)
+
+// ZScore scores each point against the mean and the standard deviation of the previous window points
+func ZScore(values []float64, window int, threshold float64) []model.Band {
+	bands := make([]model.Band, len(values))
+	for i := range values {
+		history := previousValues(values, i, window)
+		if len(history) < common.MIN_HISTORY_POINTS {
+			continue
+		}
+		mean, std := meanStd(history)
+		bands[i] = newBand(values[i], mean, std, threshold)
+	}
+	return bands
+}
+
+// MAD scores each point against the median and the median absolute deviation of the previous window points,
+// which is not affected by the outliers in the window
+func MAD(values []float64, window int, threshold float64) []model.Band {
+	bands := make([]model.Band, len(values))
+	for i := range values {
+		history := previousValues(values, i, window)
+		if len(history) < common.MIN_HISTORY_POINTS {
+			continue
+		}
+		center := median(history)
+		deviations := make([]float64, len(history))
+		for j, v := range history {
+			deviations[j] = math.Abs(v - center)
+		}
+		bands[i] = newBand(values[i], center, median(deviations)*common.MAD_SCALE, threshold)
+	}
+	return bands
+}
+
+// Seasonal scores each point against the points at the same time of the previous seasons, baselines[i] are the
+// values of the i-th previous season aligned with values, missing values are NaN
+func Seasonal(values []float64, baselines [][]float64, threshold float64) []model.Band {
+	bands := make([]model.Band, len(values))
+	history := make([]float64, 0, len(baselines))
+	for i := range values {
+		history = history[:0]
+		for _, baseline := range baselines {
+			if i < len(baseline) && !math.IsNaN(baseline[i]) {
+				history = append(history, baseline[i])
+			}
+		}
+		if len(history) < common.MIN_HISTORY_POINTS {
+			continue
+		}
+		mean, std := meanStd(history)
+		bands[i] = newBand(values[i], mean, std, threshold)
+	}
+	return bands
+}
+
+func newBand(value, center, sigma, threshold float64) model.Band {
+	if math.IsNaN(value) {
+		return model.Band{}
+	}
+	band := model.Band{
+		Valid: true,
+		Lower: center - threshold*sigma,
+		Upper: center + threshold*sigma,
+	}
+	if sigma > 0 {
+		band.Score = (value - center) / sigma
+	} else if value != center {
+		band.Score = math.Copysign(common.MAX_SCORE, value-center)
+	}
+	band.Anomaly = math.Abs(band.Score) >= threshold
+	return band
+}
+
+// previousValues returns the valid values of the window points before i
+func previousValues(values []float64, i, window int) []float64 {
+	start := i - window
+	if start < 0 {
+		start = 0
+	}
+	history := make([]float64, 0, i-start)
+	for _, v := range values[start:i] {
+		if !math.IsNaN(v) {
+			history = append(history, v)
+		}
+	}
+	return history
+}
+
+func meanStd(values []float64) (float64, float64) {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+func TestZScore(t *testing.T) {
+	values := []float64{10, 12, 10, 12, 10, 12, 30, math.NaN(), 11}
+	bands := ZScore(values, 6, 3)
+	if bands[0].Valid || bands[1].Valid {
+		t.Errorf("points without enough history should not be valid: %v", bands[:2])
+	}
+	if !bands[6].Valid || !bands[6].Anomaly || bands[6].Score != 19 {
+		t.Errorf("point 6 should be anomaly with score 19: %+v", bands[6])
+	}
+	if bands[7].Valid {
+		t.Errorf("missing point should not be valid: %+v", bands[7])
+	}
+	if bands[5].Anomaly || bands[5].Lower >= 12 || bands[5].Upper <= 12 {
+		t.Errorf("point 5 should be within the band: %+v", bands[5])
+	}
+}
+
+func TestMAD(t *testing.T) {
+	// the outlier in the window does not widen the band
+	values := []float64{10, 12, 10, 1000, 10, 12, 30}
+	bands := MAD(values, 6, 3)
+	if !bands[6].Anomaly {
+		t.Errorf("point 6 should be anomaly: %+v", bands[6])
+	}
+	if zscore := ZScore(values, 6, 3); zscore[6].Anomaly {
+		t.Errorf("point 6 should not be anomaly by zscore: %+v", zscore[6])
+	}
+
+	constant := MAD([]float64{5, 5, 5, 6}, 3, 3)
+	if constant[2].Score != 0 || constant[3].Score != 1000 || !constant[3].Anomaly {
+		t.Errorf("unexpected bands of constant history: %+v", constant)
+	}
+}
+
+func TestSeasonal(t *testing.T) {
+	values := []float64{100, 500}
+	baselines := [][]float64{{90, 100}, {110, 100}, {math.NaN(), 120}}
+	bands := Seasonal(values, baselines, 3)
+	if !bands[0].Valid || bands[0].Anomaly || bands[0].Score != 0 {
+		t.Errorf("point 0 should be normal: %+v", bands[0])
+	}
+	if !bands[1].Anomaly {
+		t.Errorf("point 1 should be anomaly: %+v", bands[1])
+	}
+	if bands := Seasonal(values, baselines[:1], 3); bands[0].Valid {
+		t.Errorf("points with one season should not be valid: %+v", bands[0])
+	}
+}
+
+func TestSeriesFromResult(t *testing.T) {
+	rrt := 1.5
+	result := &common.Result{
+		Columns: []interface{}{"time_60", "pod", "rrt", "count"},
+		Schemas: common.ColumnSchemas{
+			&common.ColumnSchema{Type: common.COLUMN_SCHEMA_TYPE_TAG},
+			&common.ColumnSchema{Type: common.COLUMN_SCHEMA_TYPE_TAG},
+			&common.ColumnSchema{Type: common.COLUMN_SCHEMA_TYPE_METRICS},
+			&common.ColumnSchema{Type: common.COLUMN_SCHEMA_TYPE_METRICS},
+		},
+		Values: []interface{}{
+			[]interface{}{uint32(120), "a", &rrt, uint64(1)},
+			[]interface{}{uint32(60), "a", (*float64)(nil), uint64(2)},
+			[]interface{}{uint32(60), "b", 2.5, uint64(3)},
+		},
+	}
+	tagNames, series, err := seriesFromResult(result, "time_60", "rrt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tagNames, []string{"pod"}) || len(series) != 2 {
+		t.Fatalf("unexpected tags %v or series count %d", tagNames, len(series))
+	}
+	if !reflect.DeepEqual(series[0].Times, []int64{60, 120}) || !math.IsNaN(series[0].Values[0]) || series[0].Values[1] != 1.5 {
+		t.Errorf("unexpected series a: %+v", series[0])
+	}
+
+	// the last metric is used by default
+	_, series, _ = seriesFromResult(result, "time_60", "")
+	if series[1].Values[0] != 3 {
+		t.Errorf("unexpected default metric: %+v", series[1])
+	}
+	if _, _, err := seriesFromResult(result, "time", ""); err == nil {
+		t.Errorf("time column should not be found")
+	}
+}
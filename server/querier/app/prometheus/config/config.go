@@ -27,6 +27,7 @@ type Prometheus struct {
 	ExternalTagLoadInterval int             `default:"300" yaml:"external-tag-load-interval"`
 	ThanosReplicaLabels     []string        `yaml:"thanos-replica-labels"`
 	OperatorOffloading      bool            `default:"false" yaml:"operator-offloading"`
+	NativeHistogramMetrics  []string        `yaml:"native-histogram-metrics"`
 	Cache                   PrometheusCache `yaml:"cache"`
 }
 
@@ -24,7 +24,8 @@ import (
 	"github.com/deepflowio/deepflow/server/querier/config"
 )
 
-func PrometheusRouter(e *gin.Engine) {
+// PrometheusRouter returns the prometheus service, so that other apps can execute PromQL with it
+func PrometheusRouter(e *gin.Engine) *service.PrometheusService {
 	// only one instance during server lifetime
 	prometheusService := service.NewPrometheusService()
 	// Both SetRate and Acquire are expanded by 1000 times, making it suitable for small QPS scenarios.
@@ -56,4 +57,5 @@ func PrometheusRouter(e *gin.Engine) {
 	e.GET("/prom/api/v1/analysis", promQLAnalysis(prometheusService))
 	e.GET("/prom/api/v1/parse", promQLParse(prometheusService))
 	e.GET("/prom/api/v1/addfilter", promQLAddFilters(prometheusService))
+	return prometheusService
 }
@@ -47,6 +47,7 @@ const (
 	PROMETHEUS_NATIVE_TAG_NAME = "tag"
 	PROMETHEUS_TIME_COLUMNS    = "timestamp"
 	PROMETHEUS_METRIC_VALUE    = "value"
+	PROMETHEUS_MILLISECOND     = "millisecond"
 	ENUM_TAG_SUFFIX            = "_enum"
 
 	FUNCTION_TOPK    = "topk"
@@ -77,6 +78,7 @@ const (
 	WINDOW_FIRST_TIME_INDEX
 	WINDOW_FIRST_VALUE_INDEX
 	WINDOW_LAST_TIME_INDEX
+	MILLISECOND_INDEX
 )
 
 const (
@@ -141,6 +143,11 @@ func (p *prometheusReader) promReaderTransToSQL(ctx context.Context, req *prompb
 	if err != nil {
 		return ctx, "", "", "", "", err
 	}
+	// native histograms only exist in prometheus
+	histogramField := ""
+	if db == "" || db == chCommon.DB_NAME_PROMETHEUS {
+		histogramField = getNativeHistogramField(q.Matchers)
+	}
 
 	metricsArray := []string{fmt.Sprintf("toUnixTimestamp(time) AS %s", PROMETHEUS_TIME_COLUMNS)}
 	orderBy := []string{fmt.Sprintf("%s desc", PROMETHEUS_TIME_COLUMNS)}
@@ -261,10 +268,23 @@ func (p *prometheusReader) promReaderTransToSQL(ctx context.Context, req *prompb
 
 	// append query field: 2. append metric name
 	if db == "" || db == chCommon.DB_NAME_PROMETHEUS {
-		// append metricName `value`
-		metricsArray = append(metricsArray, metricAlias)
+		switch histogramField {
+		case "":
+			// append metricName `value`
+			metricsArray = append(metricsArray, metricAlias)
+		case NATIVE_HISTOGRAM_BUCKET:
+			// buckets will be expanded as `value` by expandNativeHistograms
+			for _, c := range nativeHistogramBucketColumns {
+				metricsArray = append(metricsArray, fmt.Sprintf("`%s`", c))
+			}
+		default:
+			metricsArray = append(metricsArray, fmt.Sprintf("`%s` as %s", histogramField, PROMETHEUS_METRIC_VALUE))
+		}
+		metricsArray = append(metricsArray, PROMETHEUS_MILLISECOND)
+		orderBy = append(orderBy, fmt.Sprintf("%s desc", PROMETHEUS_MILLISECOND))
 		// append `tag` only for prometheus & ext_metrics & deepflow_admin / deepflow_tenant
-		if !common.IsValueInSliceString(q.Hints.Func, model.RelabelFunctions) {
+		// native histograms need all `tag`, `le` of buckets is appended into it
+		if histogramField != "" || !common.IsValueInSliceString(q.Hints.Func, model.RelabelFunctions) {
 			// `tag` should be append into `Select` with:
 			// 1. not any aggregations, try get all `tag`
 			// 2. topk(n)/bottomk(n) get all `tag`
@@ -301,6 +321,9 @@ func (p *prometheusReader) promReaderTransToSQL(ctx context.Context, req *prompb
 	filters := make([]string, 0, len(q.Matchers)+1)
 	filters = append(filters, fmt.Sprintf("(time >= %d AND time <= %d)", startTime, endTime))
 	for _, matcher := range q.Matchers {
+		if matcher.Name == NATIVE_HISTOGRAM_LABEL {
+			continue
+		}
 		tagName, tagAlias, isDeepFlowTag, newFilter := p.parseMatchers(matcher, prefixType, db)
 		if newFilter == "" {
 			continue
@@ -354,6 +377,10 @@ func (p *prometheusReader) promReaderTransToSQL(ctx context.Context, req *prompb
 		filters = append(filters, fmt.Sprintf("team_id not in (%s)", strings.Join(p.blockTeamID, ",")))
 	}
 
+	if histogramField != "" {
+		dataPrecision = chCommon.DATASOURCE_NATIVE_HISTOGRAM
+	}
+
 	sql := parseToQuerierSQL(ctx, db, table, metricsArray, filters, groupBy, orderBy)
 	return ctx, sql, db, dataPrecision, queryMetric, err
 }
@@ -638,7 +665,7 @@ func (p *prometheusReader) respTransToProm(ctx context.Context, metricsName stri
 	}
 	cacheEnabled := config.Cfg.Prometheus.Cache.RemoteReadCache && !strings.Contains(metricsName, "__")
 	log.Debugf("resTransToProm: result length: %d", len(result.Values))
-	columnIndexes := []int{-1, -1, -1, -1, -1, -1, -1}
+	columnIndexes := []int{-1, -1, -1, -1, -1, -1, -1, -1}
 	otherTagCount := 0
 	tagsFieldIndex := make(map[int]bool, len(result.Columns))
 	prefix, _ := ctx.Value(ctxKeyPrefixType{}).(prefix) // ignore if key not exist
@@ -659,6 +686,8 @@ func (p *prometheusReader) respTransToProm(ctx context.Context, metricsName stri
 			columnIndexes[WINDOW_FIRST_TIME_INDEX] = i
 		} else if tag == PROMETHEUS_WINDOW_LAST_TIME {
 			columnIndexes[WINDOW_LAST_TIME_INDEX] = i
+		} else if tag == PROMETHEUS_MILLISECOND {
+			columnIndexes[MILLISECOND_INDEX] = i
 		} else {
 			otherTagCount++
 		}
@@ -929,6 +958,10 @@ func (p *prometheusReader) respTransToProm(ctx context.Context, metricsName stri
 		}
 
 		currentTimestampMs := currentTimestamp * 1000
+		if columnIndexes[MILLISECOND_INDEX] > -1 {
+			millisecond, _ := toFloat64(values[columnIndexes[MILLISECOND_INDEX]])
+			currentTimestampMs += int64(millisecond)
+		}
 
 		// only rate/increase offloading have last timestamp, use last timestamp as current
 		if lastTimestamp != currentTimestampMs && lastTimestamp > 0 {
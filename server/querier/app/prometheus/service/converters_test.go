@@ -312,7 +312,7 @@ func TestPromReaderTransToSQL(t *testing.T) {
 		{
 			hints:    promqlHints{stepMs: 0, aggOp: "", matcher: "demo_cpu_usage_seconds_total"},
 			input:    "demo_cpu_usage_seconds_total",
-			output:   fmt.Sprintf("SELECT toUnixTimestamp(time) AS timestamp,value,`tag` FROM `demo_cpu_usage_seconds_total` WHERE (time >= %d AND time <= %d)  ORDER BY timestamp desc LIMIT %s", startS, endS, limit),
+			output:   fmt.Sprintf("SELECT toUnixTimestamp(time) AS timestamp,value,millisecond,`tag` FROM `demo_cpu_usage_seconds_total` WHERE (time >= %d AND time <= %d)  ORDER BY timestamp desc,millisecond desc LIMIT %s", startS, endS, limit),
 			ds:       "",
 			db:       "",
 			hasError: false,
@@ -328,11 +328,27 @@ func TestPromReaderTransToSQL(t *testing.T) {
 		{
 			hints:    promqlHints{stepMs: 0, aggOp: "", matcher: "prometheus__samples__demo_cpu_usage_seconds_total"},
 			input:    "prometheus__samples__demo_cpu_usage_seconds_total",
-			output:   fmt.Sprintf("SELECT toUnixTimestamp(time) AS timestamp,value,`tag` FROM `demo_cpu_usage_seconds_total` WHERE (time >= %d AND time <= %d)  ORDER BY timestamp desc LIMIT %s", startS, endS, limit),
+			output:   fmt.Sprintf("SELECT toUnixTimestamp(time) AS timestamp,value,millisecond,`tag` FROM `demo_cpu_usage_seconds_total` WHERE (time >= %d AND time <= %d)  ORDER BY timestamp desc,millisecond desc LIMIT %s", startS, endS, limit),
 			ds:       "",
 			db:       "prometheus",
 			hasError: false,
 		},
+		{
+			hints:    promqlHints{stepMs: 0, aggOp: "", matcher: `demo_latency_seconds{__native_histogram__="count"}`},
+			input:    `demo_latency_seconds{__native_histogram__="count"}`,
+			output:   fmt.Sprintf("SELECT toUnixTimestamp(time) AS timestamp,`count` as value,millisecond,`tag` FROM `demo_latency_seconds` WHERE (time >= %d AND time <= %d)  ORDER BY timestamp desc,millisecond desc LIMIT %s", startS, endS, limit),
+			ds:       "native_histogram",
+			db:       "",
+			hasError: false,
+		},
+		{
+			hints:    promqlHints{stepMs: 0, aggOp: "rate", matcher: `demo_latency_seconds{__native_histogram__="bucket"}`},
+			input:    `demo_latency_seconds{__native_histogram__="bucket"}`,
+			output:   fmt.Sprintf("SELECT toUnixTimestamp(time) AS timestamp,`count`,`schema`,`zero_threshold`,`zero_count`,`negative_span_offsets`,`negative_span_lengths`,`negative_deltas`,`negative_counts`,`positive_span_offsets`,`positive_span_lengths`,`positive_deltas`,`positive_counts`,millisecond,`tag` FROM `demo_latency_seconds` WHERE (time >= %d AND time <= %d)  ORDER BY timestamp desc,millisecond desc LIMIT %s", startS, endS, limit),
+			ds:       "native_histogram",
+			db:       "",
+			hasError: false,
+		},
 
 		// range query
 		{
@@ -357,7 +373,7 @@ func TestPromReaderTransToSQL(t *testing.T) {
 
 			hints:    promqlHints{matcher: "node_cpu_seconds_total{instance=\"'demo\"}"},
 			input:    "node_cpu_seconds_total{instance=\"'demo\"}",
-			output:   fmt.Sprintf("SELECT toUnixTimestamp(time) AS timestamp,value,`tag` FROM `node_cpu_seconds_total` WHERE (time >= %d AND time <= %d) AND `tag.instance` = '''demo'  ORDER BY timestamp desc LIMIT %s", startS, endS, limit),
+			output:   fmt.Sprintf("SELECT toUnixTimestamp(time) AS timestamp,value,millisecond,`tag` FROM `node_cpu_seconds_total` WHERE (time >= %d AND time <= %d) AND `tag.instance` = '''demo'  ORDER BY timestamp desc,millisecond desc LIMIT %s", startS, endS, limit),
 			hasError: false,
 		},
 	}
@@ -0,0 +1,388 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+// native histograms are stored in prometheus.histogram_samples, they are queried as float series:
+// histogram_count(x) and histogram_sum(x) query the count and sum of x,
+// histogram_quantile(φ, x) queries x as cumulative buckets with `le` label like classic histograms if x is configured
+// in prometheus.native-histogram-metrics
+const (
+	NATIVE_HISTOGRAM_LABEL = "__native_histogram__" // internal matcher to mark the field of native histograms to query
+
+	NATIVE_HISTOGRAM_BUCKET = "bucket"
+	NATIVE_HISTOGRAM_COUNT  = "count"
+	NATIVE_HISTOGRAM_SUM    = "sum"
+
+	NATIVE_HISTOGRAM_LE = "le"
+)
+
+const (
+	FUNCTION_HISTOGRAM_COUNT    = "histogram_count"
+	FUNCTION_HISTOGRAM_SUM      = "histogram_sum"
+	FUNCTION_HISTOGRAM_QUANTILE = "histogram_quantile"
+)
+
+// columns of prometheus.histogram_samples used to build buckets
+var nativeHistogramBucketColumns = []string{
+	"count", "schema", "zero_threshold", "zero_count",
+	"negative_span_offsets", "negative_span_lengths", "negative_deltas", "negative_counts",
+	"positive_span_offsets", "positive_span_lengths", "positive_deltas", "positive_counts",
+}
+
+// rewriteNativeHistogramQuery marks the selectors of native histogram functions by NATIVE_HISTOGRAM_LABEL,
+// and appends `le` into the aggregations of histogram_quantile, returns false if nothing rewritten.
+// histogram_quantile is rewritten only if the metric is one of nativeMetrics, classic histograms are queried as is.
+func rewriteNativeHistogramQuery(query string, nativeMetrics map[string]bool) (string, bool) {
+	if !strings.Contains(query, "histogram_") {
+		return query, false
+	}
+	expr, err := parser.ParseExpr(replaceNativeHistogramFunctions(query))
+	if err != nil {
+		// let the promql engine report the error
+		return query, false
+	}
+	rewritten := false
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.AggregateExpr:
+			if field := nativeHistogramFunctionField(n); field != "" {
+				n.Grouping = nil
+				rewritten = markNativeHistogram(n.Expr, field, nil) || rewritten
+			}
+		case *parser.Call:
+			if n.Func.Name == FUNCTION_HISTOGRAM_QUANTILE && len(n.Args) > 1 && markNativeHistogram(n.Args[1], NATIVE_HISTOGRAM_BUCKET, nativeMetrics) {
+				groupByLe(n.Args[1])
+				rewritten = true
+			}
+		}
+		return nil
+	})
+	if !rewritten {
+		return query, false
+	}
+	return expr.String(), true
+}
+
+// histogram_count and histogram_sum are not supported by the promql engine yet, the count/sum are queried
+// from clickhouse directly, so the functions are replaced by `sum without(<marker>)` before parsing,
+// which only drops the metric name like the functions do, and the marker is removed after the selectors are marked
+var nativeHistogramFunctionMarkers = map[string]string{
+	FUNCTION_HISTOGRAM_COUNT: NATIVE_HISTOGRAM_LABEL + NATIVE_HISTOGRAM_COUNT,
+	FUNCTION_HISTOGRAM_SUM:   NATIVE_HISTOGRAM_LABEL + NATIVE_HISTOGRAM_SUM,
+}
+
+func replaceNativeHistogramFunctions(query string) string {
+	var buf strings.Builder
+	lexer := parser.Lex(query)
+	var item, next parser.Item
+	last := 0
+	for lexer.NextItem(&item); item.Typ != parser.EOF && item.Typ != parser.ERROR; item = next {
+		lexer.NextItem(&next)
+		marker, ok := nativeHistogramFunctionMarkers[item.Val]
+		if !ok || item.Typ != parser.IDENTIFIER || next.Typ != parser.LEFT_PAREN {
+			continue
+		}
+		buf.WriteString(query[last:item.Pos])
+		buf.WriteString("sum without(" + marker + ") ")
+		last = int(item.Pos) + len(item.Val)
+	}
+	if last == 0 {
+		return query
+	}
+	buf.WriteString(query[last:])
+	return buf.String()
+}
+
+func nativeHistogramFunctionField(agg *parser.AggregateExpr) string {
+	if agg.Op != parser.SUM || !agg.Without || len(agg.Grouping) != 1 {
+		return ""
+	}
+	for _, marker := range nativeHistogramFunctionMarkers {
+		if agg.Grouping[0] == marker {
+			return strings.TrimPrefix(marker, NATIVE_HISTOGRAM_LABEL)
+		}
+	}
+	return ""
+}
+
+// markNativeHistogram marks the selectors in expr, only the metrics in nativeMetrics are marked if it is not nil
+func markNativeHistogram(expr parser.Expr, field string, nativeMetrics map[string]bool) bool {
+	marked := false
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+		metricName := vs.Name
+		for _, m := range vs.LabelMatchers {
+			if m.Name == NATIVE_HISTOGRAM_LABEL {
+				return nil
+			}
+			if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+				metricName = m.Value
+			}
+		}
+		if nativeMetrics != nil && !nativeMetrics[metricName] {
+			return nil
+		}
+		vs.LabelMatchers = append(vs.LabelMatchers, labels.MustNewMatcher(labels.MatchEqual, NATIVE_HISTOGRAM_LABEL, field))
+		marked = true
+		return nil
+	})
+	return marked
+}
+
+// buckets of different `le` should not be aggregated together for histogram_quantile
+func groupByLe(expr parser.Expr) {
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		agg, ok := node.(*parser.AggregateExpr)
+		if !ok || agg.Op.IsAggregatorWithParam() {
+			return nil
+		}
+		if agg.Without {
+			grouping := agg.Grouping[:0]
+			for _, g := range agg.Grouping {
+				if g != NATIVE_HISTOGRAM_LE {
+					grouping = append(grouping, g)
+				}
+			}
+			agg.Grouping = grouping
+		} else if !common.IsValueInSliceString(NATIVE_HISTOGRAM_LE, agg.Grouping) {
+			agg.Grouping = append(agg.Grouping, NATIVE_HISTOGRAM_LE)
+		}
+		return nil
+	})
+}
+
+// getNativeHistogramField returns the field marked by rewriteNativeHistogramQuery, empty if not a native histogram query
+func getNativeHistogramField(matchers []*prompb.LabelMatcher) string {
+	for _, m := range matchers {
+		if m.Name == NATIVE_HISTOGRAM_LABEL {
+			return m.Value
+		}
+	}
+	return ""
+}
+
+// expandNativeHistograms converts each row of histogram_samples into cumulative bucket rows,
+// the histogram columns are replaced by `value`, and `le` is appended into `tag`
+func expandNativeHistograms(result *common.Result) {
+	if result == nil || len(result.Values) == 0 {
+		return
+	}
+	histogramIndexes := make(map[string]int, len(nativeHistogramBucketColumns))
+	tagIndex := -1
+	columns := make([]interface{}, 0, len(result.Columns))
+	schemas := make(common.ColumnSchemas, 0, len(result.Columns))
+	keptIndexes := make([]int, 0, len(result.Columns))
+	for i, c := range result.Columns {
+		name, _ := c.(string)
+		if common.IsValueInSliceString(name, nativeHistogramBucketColumns) {
+			histogramIndexes[name] = i
+			continue
+		}
+		if name == PROMETHEUS_NATIVE_TAG_NAME {
+			tagIndex = len(columns)
+		}
+		keptIndexes = append(keptIndexes, i)
+		columns = append(columns, c)
+		if i < len(result.Schemas) {
+			schemas = append(schemas, result.Schemas[i])
+		}
+	}
+	if len(histogramIndexes) != len(nativeHistogramBucketColumns) || tagIndex < 0 {
+		log.Warningf("native histogram columns or tag not found in %v", result.Columns)
+		return
+	}
+	columns = append(columns, PROMETHEUS_METRIC_VALUE)
+	schemas = append(schemas, &common.ColumnSchema{Name: PROMETHEUS_METRIC_VALUE, ValueType: "Float64"})
+
+	values := make([]interface{}, 0, len(result.Values))
+	for _, v := range result.Values {
+		row := v.([]interface{})
+		h := nativeHistogramFromRow(row, histogramIndexes)
+		var tags map[string]string
+		tagJson, _ := row[keptIndexes[tagIndex]].(string)
+		if err := json.Unmarshal([]byte(tagJson), &tags); err != nil || tags == nil {
+			tags = make(map[string]string, 1)
+		}
+		for _, b := range h.cumulativeBuckets() {
+			tags[NATIVE_HISTOGRAM_LE] = formatLe(b.upperBound)
+			tagBytes, _ := json.Marshal(tags)
+			newRow := make([]interface{}, 0, len(columns))
+			for _, i := range keptIndexes {
+				newRow = append(newRow, row[i])
+			}
+			newRow[tagIndex] = string(tagBytes)
+			newRow = append(newRow, b.count)
+			values = append(values, newRow)
+		}
+	}
+	result.Columns = columns
+	result.Schemas = schemas
+	result.Values = values
+}
+
+type nativeHistogram struct {
+	count         float64
+	schema        int
+	zeroThreshold float64
+	zeroCount     float64
+	negative      nativeHistogramBuckets
+	positive      nativeHistogramBuckets
+}
+
+type nativeHistogramBuckets struct {
+	spanOffsets []int64
+	spanLengths []uint32
+	deltas      []int64   // for integer histograms
+	counts      []float64 // for float histograms
+}
+
+type histogramBucket struct {
+	upperBound float64
+	count      float64
+}
+
+func nativeHistogramFromRow(row []interface{}, indexes map[string]int) *nativeHistogram {
+	h := &nativeHistogram{}
+	h.count, _ = toFloat64(row[indexes["count"]])
+	schema, _ := toFloat64(row[indexes["schema"]])
+	h.schema = int(schema)
+	h.zeroThreshold, _ = toFloat64(row[indexes["zero_threshold"]])
+	h.zeroCount, _ = toFloat64(row[indexes["zero_count"]])
+	h.negative.spanOffsets, _ = row[indexes["negative_span_offsets"]].([]int64)
+	h.negative.spanLengths, _ = row[indexes["negative_span_lengths"]].([]uint32)
+	h.negative.deltas, _ = row[indexes["negative_deltas"]].([]int64)
+	h.negative.counts, _ = row[indexes["negative_counts"]].([]float64)
+	h.positive.spanOffsets, _ = row[indexes["positive_span_offsets"]].([]int64)
+	h.positive.spanLengths, _ = row[indexes["positive_span_lengths"]].([]uint32)
+	h.positive.deltas, _ = row[indexes["positive_deltas"]].([]int64)
+	h.positive.counts, _ = row[indexes["positive_counts"]].([]float64)
+	return h
+}
+
+// bucketIndexes returns the absolute index and the absolute count of each bucket
+func (b *nativeHistogramBuckets) bucketIndexes() ([]int, []float64) {
+	indexes := make([]int, 0, len(b.deltas)+len(b.counts))
+	counts := make([]float64, 0, len(b.deltas)+len(b.counts))
+	index := 0
+	for i, offset := range b.spanOffsets {
+		// the offset of the first span is the start index, others are the gap to the previous span
+		index += int(offset)
+		if i >= len(b.spanLengths) {
+			break
+		}
+		for j := uint32(0); j < b.spanLengths[i]; j++ {
+			indexes = append(indexes, index)
+			index++
+		}
+	}
+	var count int64
+	for i := range indexes {
+		if len(b.counts) > 0 {
+			if i >= len(b.counts) {
+				return indexes[:i], counts
+			}
+			counts = append(counts, b.counts[i])
+			continue
+		}
+		if i >= len(b.deltas) {
+			return indexes[:i], counts
+		}
+		count += b.deltas[i]
+		counts = append(counts, float64(count))
+	}
+	return indexes, counts
+}
+
+// cumulativeBuckets converts the buckets into classic histogram buckets in ascending order of upper bound,
+// with the +Inf bucket at last
+func (h *nativeHistogram) cumulativeBuckets() []histogramBucket {
+	negativeIndexes, negativeCounts := h.negative.bucketIndexes()
+	positiveIndexes, positiveCounts := h.positive.bucketIndexes()
+	buckets := make([]histogramBucket, 0, len(negativeIndexes)+len(positiveIndexes)+2)
+	var cumulative float64
+	// negative bucket i is [-base^i, -base^(i-1)), iterate from the most negative one
+	for i := len(negativeIndexes) - 1; i >= 0; i-- {
+		cumulative += negativeCounts[i]
+		buckets = append(buckets, histogramBucket{upperBound: -bucketBound(negativeIndexes[i]-1, h.schema), count: cumulative})
+	}
+	cumulative += h.zeroCount
+	buckets = append(buckets, histogramBucket{upperBound: h.zeroThreshold, count: cumulative})
+	// positive bucket i is (base^(i-1), base^i]
+	for i := range positiveIndexes {
+		cumulative += positiveCounts[i]
+		buckets = append(buckets, histogramBucket{upperBound: bucketBound(positiveIndexes[i], h.schema), count: cumulative})
+	}
+	buckets = append(buckets, histogramBucket{upperBound: math.Inf(1), count: h.count})
+	return buckets
+}
+
+// bucketBound returns base^index, where base = 2^(2^-schema)
+func bucketBound(index, schema int) float64 {
+	return math.Exp2(math.Ldexp(float64(index), -schema))
+}
+
+func formatLe(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func toFloat64(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
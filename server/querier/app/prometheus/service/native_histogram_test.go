@@ -0,0 +1,171 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+func TestRewriteNativeHistogramQuery(t *testing.T) {
+	testcases := []struct {
+		input     string
+		output    string
+		rewritten bool
+	}{
+		{
+			input:     `rate(http_request_duration_seconds_count[5m])`,
+			output:    `rate(http_request_duration_seconds_count[5m])`,
+			rewritten: false,
+		},
+		{
+			input:     `histogram_quantile(0.9, rate(http_request_duration_seconds_bucket[5m]))`,
+			output:    `histogram_quantile(0.9, rate(http_request_duration_seconds_bucket[5m]))`,
+			rewritten: false,
+		},
+		{
+			input:     `histogram_count(http_request_duration_seconds{job="api"})`,
+			output:    `sum without() (http_request_duration_seconds{__native_histogram__="count",job="api"})`,
+			rewritten: true,
+		},
+		{
+			input:     `histogram_sum (rate(http_request_duration_seconds[5m])) / histogram_count(rate(http_request_duration_seconds[5m]))`,
+			output:    `sum without() (rate(http_request_duration_seconds{__native_histogram__="sum"}[5m])) / sum without() (rate(http_request_duration_seconds{__native_histogram__="count"}[5m]))`,
+			rewritten: true,
+		},
+		{
+			input:     `histogram_quantile(0.9, sum by (job) (rate(http_request_duration_seconds[5m])))`,
+			output:    `histogram_quantile(0.9, sum by(job, le) (rate(http_request_duration_seconds{__native_histogram__="bucket"}[5m])))`,
+			rewritten: true,
+		},
+		{
+			input:     `histogram_quantile(0.9, sum without (le, instance) (rate(http_request_duration_seconds[5m])))`,
+			output:    `histogram_quantile(0.9, sum without(instance) (rate(http_request_duration_seconds{__native_histogram__="bucket"}[5m])))`,
+			rewritten: true,
+		},
+		{
+			// classic histograms aggregated by recording rules
+			input:     `histogram_quantile(0.9, job:http_request_duration_seconds_bucket:rate5m)`,
+			output:    `histogram_quantile(0.9, job:http_request_duration_seconds_bucket:rate5m)`,
+			rewritten: false,
+		},
+		{
+			input:     `histogram_quantile(0.9, rate({__name__=~"http_request_duration_seconds.*"}[5m]))`,
+			output:    `histogram_quantile(0.9, rate({__name__=~"http_request_duration_seconds.*"}[5m]))`,
+			rewritten: false,
+		},
+		{
+			input:     `sum(rate(histogram_count_total{job="histogram_count("}[5m]))`,
+			output:    `sum(rate(histogram_count_total{job="histogram_count("}[5m]))`,
+			rewritten: false,
+		},
+	}
+	nativeMetrics := map[string]bool{"http_request_duration_seconds": true}
+	for _, tc := range testcases {
+		output, rewritten := rewriteNativeHistogramQuery(tc.input, nativeMetrics)
+		if rewritten != tc.rewritten {
+			t.Errorf("rewrite %s: expected rewritten %v, got %v", tc.input, tc.rewritten, rewritten)
+		}
+		if rewritten && output != tc.output {
+			t.Errorf("rewrite %s: expected %s, got %s", tc.input, tc.output, output)
+		}
+	}
+}
+
+func TestNativeHistogramCumulativeBuckets(t *testing.T) {
+	h := &nativeHistogram{
+		count:         9,
+		schema:        0,
+		zeroThreshold: 0.001,
+		zeroCount:     2,
+		negative: nativeHistogramBuckets{
+			spanOffsets: []int64{1},
+			spanLengths: []uint32{1},
+			deltas:      []int64{3},
+		},
+		positive: nativeHistogramBuckets{
+			spanOffsets: []int64{0, 1},
+			spanLengths: []uint32{2, 1},
+			deltas:      []int64{1, 1, -1},
+		},
+	}
+	expected := []struct {
+		le    string
+		count float64
+	}{
+		{"-1", 3}, {"0.001", 5}, {"1", 6}, {"2", 8}, {"8", 9}, {"+Inf", 9},
+	}
+	buckets := h.cumulativeBuckets()
+	if len(buckets) != len(expected) {
+		t.Fatalf("expected %d buckets, got %v", len(expected), buckets)
+	}
+	for i, b := range buckets {
+		if formatLe(b.upperBound) != expected[i].le || b.count != expected[i].count {
+			t.Errorf("bucket %d: expected le=%s count=%v, got le=%s count=%v", i, expected[i].le, expected[i].count, formatLe(b.upperBound), b.count)
+		}
+	}
+
+	// float histograms use absolute counts, schema 1 splits each power of two into 2 buckets
+	h = &nativeHistogram{
+		count:  3,
+		schema: 1,
+		positive: nativeHistogramBuckets{
+			spanOffsets: []int64{2},
+			spanLengths: []uint32{2},
+			counts:      []float64{1, 2},
+		},
+	}
+	buckets = h.cumulativeBuckets()
+	if len(buckets) != 4 || buckets[1].upperBound != 2 || buckets[2].count != 3 || formatLe(buckets[3].upperBound) != "+Inf" {
+		t.Errorf("unexpected float histogram buckets %v", buckets)
+	}
+}
+
+func TestExpandNativeHistograms(t *testing.T) {
+	result := &common.Result{
+		Columns: []interface{}{PROMETHEUS_TIME_COLUMNS, "count", "schema", "zero_threshold", "zero_count",
+			"negative_span_offsets", "negative_span_lengths", "negative_deltas", "negative_counts",
+			"positive_span_offsets", "positive_span_lengths", "positive_deltas", "positive_counts",
+			PROMETHEUS_MILLISECOND, PROMETHEUS_NATIVE_TAG_NAME},
+		Values: []interface{}{
+			[]interface{}{uint32(1700000000), float64(3), int8(0), float64(0), float64(0),
+				[]int64{}, []uint32{}, []int64{}, []float64{},
+				[]int64{0}, []uint32{2}, []int64{1, 1}, []float64{},
+				uint16(500), `{"job":"api"}`},
+		},
+	}
+	for _, c := range result.Columns {
+		result.Schemas = append(result.Schemas, &common.ColumnSchema{Name: c.(string)})
+	}
+	expandNativeHistograms(result)
+
+	expectedColumns := []interface{}{PROMETHEUS_TIME_COLUMNS, PROMETHEUS_MILLISECOND, PROMETHEUS_NATIVE_TAG_NAME, PROMETHEUS_METRIC_VALUE}
+	if !reflect.DeepEqual(result.Columns, expectedColumns) || len(result.Schemas) != len(expectedColumns) {
+		t.Fatalf("expected columns %v, got %v", expectedColumns, result.Columns)
+	}
+	expectedValues := []interface{}{
+		[]interface{}{uint32(1700000000), uint16(500), `{"job":"api","le":"0"}`, float64(0)},
+		[]interface{}{uint32(1700000000), uint16(500), `{"job":"api","le":"1"}`, float64(1)},
+		[]interface{}{uint32(1700000000), uint16(500), `{"job":"api","le":"2"}`, float64(3)},
+		[]interface{}{uint32(1700000000), uint16(500), `{"job":"api","le":"+Inf"}`, float64(3)},
+	}
+	if !reflect.DeepEqual(result.Values, expectedValues) {
+		t.Errorf("expected values %v, got %v", expectedValues, result.Values)
+	}
+}
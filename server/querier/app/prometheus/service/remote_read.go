@@ -34,7 +34,8 @@ import (
 	"github.com/deepflowio/deepflow/server/querier/app/prometheus/cache"
 	"github.com/deepflowio/deepflow/server/querier/common"
 	"github.com/deepflowio/deepflow/server/querier/config"
-	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse"
+	chCommon "github.com/deepflowio/deepflow/server/querier/engine/clickhouse/common"
+	"github.com/deepflowio/deepflow/server/querier/service"
 )
 
 // prometheusReader's lifecycle is belong to each query through api
@@ -69,7 +70,9 @@ func (p *prometheusReader) promReaderExecute(ctx context.Context, req *prompb.Re
 
 	// should get cache result immediately
 	// for DeepFlow Native metrics, don't use cache
-	cacheAvailable := config.Cfg.Prometheus.Cache.RemoteReadCache && !strings.Contains(metricName, "__")
+	// for native histograms, the cache stores expanded buckets and don't use cache either
+	histogramField := getNativeHistogramField(req.Queries[0].Matchers)
+	cacheAvailable := config.Cfg.Prometheus.Cache.RemoteReadCache && !strings.Contains(metricName, "__") && histogramField == ""
 	if cacheAvailable {
 		var hit cache.CacheHit
 		var cacheItem *cache.CacheItem
@@ -143,13 +146,14 @@ func (p *prometheusReader) promReaderExecute(ctx context.Context, req *prompb.Re
 		defer span.End()
 	}
 
-	ckEngine := &clickhouse.CHEngine{DB: args.DB, DataSource: args.DataSource}
-	ckEngine.Init()
-	result, debugInfo, err = ckEngine.ExecuteQuery(&args)
+	result, debugInfo, err = service.StreamExecute(&args)
 	if err != nil {
 		log.Errorf("ExecuteQuery failed, debug info = %v, err info = %v", debugInfo, err)
 		return nil, "", "", 0, err
 	}
+	if histogramField == NATIVE_HISTOGRAM_BUCKET && datasource == chCommon.DATASOURCE_NATIVE_HISTOGRAM {
+		expandNativeHistograms(result)
+	}
 
 	if debug {
 		duration = extractQueryTimeFromQueryResponse(debugInfo)
@@ -238,9 +242,7 @@ func queryDataExecute(ctx context.Context, querierSql string, db string, ds stri
 
 		defer span.End()
 	}
-	ckEngine := &clickhouse.CHEngine{DB: args.DB, DataSource: args.DataSource}
-	ckEngine.Init()
-	result, debugInfo, err := ckEngine.ExecuteQuery(&args)
+	result, debugInfo, err := service.StreamExecute(&args)
 	if debug && debugInfo != nil {
 		duration = extractQueryTimeFromQueryResponse(debugInfo)
 		sql = extractQuerySQLFromQueryResponse(debugInfo)
@@ -46,6 +46,8 @@ type PrometheusService struct {
 	executor *prometheusExecutor
 	// prometheus query rate limit
 	QPSLeakyBucket *datastructure.LeakyBucket
+	// metrics queried as native histograms by histogram_quantile
+	nativeHistogramMetrics map[string]bool
 }
 
 func NewPrometheusService() *PrometheusService {
@@ -61,10 +63,15 @@ func NewPrometheusService() *PrometheusService {
 		EnableNegativeOffset:     true,
 		EnablePerStepStats:       true,
 	}
+	nativeHistogramMetrics := make(map[string]bool, len(config.Cfg.Prometheus.NativeHistogramMetrics))
+	for _, metric := range config.Cfg.Prometheus.NativeHistogramMetrics {
+		nativeHistogramMetrics[metric] = true
+	}
 	return &PrometheusService{
-		engine:         promql.NewEngine(opts),
-		executor:       NewPrometheusExecutor(opts.LookbackDelta),
-		QPSLeakyBucket: &datastructure.LeakyBucket{},
+		engine:                 promql.NewEngine(opts),
+		executor:               NewPrometheusExecutor(opts.LookbackDelta),
+		QPSLeakyBucket:         &datastructure.LeakyBucket{},
+		nativeHistogramMetrics: nativeHistogramMetrics,
 	}
 }
 
@@ -77,6 +84,11 @@ func (s *PrometheusService) PromRemoteReadService(req *prompb.ReadRequest, ctx c
 }
 
 func (s *PrometheusService) PromInstantQueryService(args *model.PromQueryParams, ctx context.Context) (*model.PromQueryResponse, error) {
+	if query, ok := rewriteNativeHistogramQuery(args.Promql, s.nativeHistogramMetrics); ok {
+		// native histograms are expanded after query, can't be offloaded
+		args.Promql = query
+		args.Offloading = false
+	}
 	if args.Offloading {
 		return s.executor.offloadInstantQueryExecute(ctx, args, s.engine)
 	} else {
@@ -85,6 +97,11 @@ func (s *PrometheusService) PromInstantQueryService(args *model.PromQueryParams,
 }
 
 func (s *PrometheusService) PromRangeQueryService(args *model.PromQueryParams, ctx context.Context) (*model.PromQueryResponse, error) {
+	if query, ok := rewriteNativeHistogramQuery(args.Promql, s.nativeHistogramMetrics); ok {
+		// native histograms are expanded after query, can't be offloaded
+		args.Promql = query
+		args.Offloading = false
+	}
 	if args.Offloading {
 		return s.executor.offloadRangeQueryExecute(ctx, args, s.engine)
 	} else {
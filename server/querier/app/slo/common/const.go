@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+const (
+	SUCCESS            = "SUCCESS"
+	FAIL               = "FAIL"
+	INVALID_PARAMETERS = "INVALID_PARAMETERS"
+	INVALID_POST_DATA  = "INVALID_POST_DATA"
+	SERVER_ERROR       = "SERVER_ERROR"
+)
+
+// same as SLI_SOURCE of controller
+const (
+	SLI_SOURCE_DEEPFLOW = "deepflow"
+	SLI_SOURCE_PROMQL   = "promql"
+)
+
+// PromQL SLIs use $__range as the range of range vectors, e.g.: sum(increase(http_requests_total[$__range]))
+const PROMQL_RANGE_PLACEHOLDER = "$__range"
+
+type BurnRateWindow struct {
+	Name    string
+	Seconds int64
+}
+
+// windows of burn rates, see Google SRE Workbook, Alerting on SLOs
+var BURN_RATE_WINDOWS = []BurnRateWindow{
+	{"5m", 300},
+	{"30m", 1800},
+	{"1h", 3600},
+	{"2h", 7200},
+	{"6h", 21600},
+	{"1d", 86400},
+	{"3d", 259200},
+}
+
+// SLIs of the windows ending now are cached between evaluations for window/SLI_CACHE_TTL_RATIO seconds,
+// limited by SLI_CACHE_MIN_TTL and SLI_CACHE_MAX_TTL, so that long windows are not queried on every scrape
+const (
+	SLI_CACHE_TTL_RATIO = 720
+	SLI_CACHE_MIN_TTL   = 60
+	SLI_CACHE_MAX_TTL   = 3600
+)
+
+type BurnRateAlertRule struct {
+	LongWindow  string
+	ShortWindow string
+	Threshold   float64
+	Severity    string
+}
+
+// multi-window, multi-burn-rate alerts, an alert fires when both windows burn faster than the threshold
+var BURN_RATE_ALERT_RULES = []BurnRateAlertRule{
+	{"1h", "5m", 14.4, SEVERITY_PAGE},
+	{"6h", "30m", 6, SEVERITY_PAGE},
+	{"1d", "2h", 3, SEVERITY_TICKET},
+	{"3d", "6h", 1, SEVERITY_TICKET},
+}
+
+const (
+	SEVERITY_PAGE   = "page"
+	SEVERITY_TICKET = "ticket"
+)
+
+const (
+	METRIC_SLI                    = "deepflow_slo_sli"
+	METRIC_TARGET                 = "deepflow_slo_target"
+	METRIC_ERROR_BUDGET_REMAINING = "deepflow_slo_error_budget_remaining"
+	METRIC_BURN_RATE              = "deepflow_slo_burn_rate"
+	METRIC_BURN_RATE_ALERT        = "deepflow_slo_burn_rate_alert"
+	CONTENT_TYPE_PROMETHEUS_TEXT  = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+const (
+	HEADER_KEY_X_ORG_ID  = "X-Org-Id"
+	HEADER_KEY_X_USER_ID = "X-User-Id"
+)
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "context"
+
+// SLO is defined in controller
+type SLO struct {
+	Name        string  `json:"NAME"`
+	Description string  `json:"DESCRIPTION"`
+	SLIType     string  `json:"SLI_TYPE"`
+	SLISource   string  `json:"SLI_SOURCE"`
+	DB          string  `json:"DB"`
+	GoodQuery   string  `json:"GOOD_QUERY"`
+	TotalQuery  string  `json:"TOTAL_QUERY"`
+	Target      float64 `json:"TARGET"`
+	TimeWindow  int64   `json:"TIME_WINDOW"`
+	Lcuuid      string  `json:"LCUUID"`
+}
+
+type StatusArgs struct {
+	Lcuuid string
+	// end of the windows, unit: s, default is now
+	Time    int64
+	Debug   bool
+	Context context.Context
+	OrgID   string
+	UserID  string
+}
+
+// SLI and burn rates are null if there is no event in the window
+type SLOStatus struct {
+	Name                 string          `json:"name"`
+	Lcuuid               string          `json:"lcuuid"`
+	SLIType              string          `json:"sli_type"`
+	Target               float64         `json:"target"`
+	TimeWindow           int64           `json:"time_window"`
+	Time                 int64           `json:"time"`
+	SLI                  *float64        `json:"sli"`
+	ErrorBudget          float64         `json:"error_budget"`
+	ErrorBudgetRemaining *float64        `json:"error_budget_remaining"`
+	BurnRates            []BurnRate      `json:"burn_rates"`
+	Alerts               []BurnRateAlert `json:"alerts"`
+}
+
+type BurnRate struct {
+	Window   string   `json:"window"`
+	SLI      *float64 `json:"sli"`
+	BurnRate *float64 `json:"burn_rate"`
+}
+
+type BurnRateAlert struct {
+	LongWindow  string  `json:"long_window"`
+	ShortWindow string  `json:"short_window"`
+	Threshold   float64 `json:"threshold"`
+	Severity    string  `json:"severity"`
+	Firing      bool    `json:"firing"`
+}
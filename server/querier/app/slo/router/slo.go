@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	prometheus_service "github.com/deepflowio/deepflow/server/querier/app/prometheus/service"
+	"github.com/deepflowio/deepflow/server/querier/app/slo/common"
+	"github.com/deepflowio/deepflow/server/querier/app/slo/model"
+	"github.com/deepflowio/deepflow/server/querier/app/slo/service"
+	querier_common "github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/router"
+)
+
+func SLORouter(e *gin.Engine, promService *prometheus_service.PrometheusService) {
+	e.GET("/v1/slo/:lcuuid/status", status(promService))
+	e.GET("/v1/slo/metrics", metrics(promService))
+}
+
+func status(promService *prometheus_service.PrometheusService) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		args, err := statusArgs(c)
+		if err != nil {
+			router.BadRequestResponse(c, common.INVALID_PARAMETERS, err.Error())
+			return
+		}
+		args.Lcuuid = c.Param("lcuuid")
+		result, debug, err := service.Status(args, promService)
+		if err == nil && !args.Debug {
+			debug = nil
+		}
+		router.JsonResponse(c, result, debug, err)
+	})
+}
+
+func metrics(promService *prometheus_service.PrometheusService) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		args, err := statusArgs(c)
+		if err != nil {
+			router.BadRequestResponse(c, common.INVALID_PARAMETERS, err.Error())
+			return
+		}
+		statuses, err := service.Statuses(args, promService)
+		if err != nil {
+			router.JsonResponse(c, nil, nil, err)
+			return
+		}
+		c.Data(http.StatusOK, common.CONTENT_TYPE_PROMETHEUS_TEXT, []byte(service.ToPrometheusText(statuses)))
+	})
+}
+
+func statusArgs(c *gin.Context) (*model.StatusArgs, error) {
+	// 参数校验
+	args := &model.StatusArgs{
+		Context: c.Request.Context(),
+		OrgID:   c.Request.Header.Get(common.HEADER_KEY_X_ORG_ID),
+		UserID:  c.Request.Header.Get(common.HEADER_KEY_X_USER_ID),
+	}
+	if args.OrgID == "" {
+		args.OrgID = querier_common.DEFAULT_ORG_ID
+	}
+	var err error
+	if value := c.Query("time"); value != "" {
+		if args.Time, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	if value := c.Query("debug"); value != "" {
+		if args.Debug, err = strconv.ParseBool(value); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
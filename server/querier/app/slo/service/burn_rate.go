@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"math"
+
+	"github.com/deepflowio/deepflow/server/querier/app/slo/common"
+	"github.com/deepflowio/deepflow/server/querier/app/slo/model"
+)
+
+// SLI returns the ratio of good events to total events, NaN if there is no event
+func SLI(good, total float64) float64 {
+	if total <= 0 || math.IsNaN(good) || math.IsNaN(total) {
+		return math.NaN()
+	}
+	return math.Min(math.Max(good/total, 0), 1)
+}
+
+// ErrorBudget returns the ratio of bad events allowed by target, target unit: %
+func ErrorBudget(target float64) float64 {
+	return 1 - target/100
+}
+
+// BurnRate returns how fast the error budget is consumed, 1 means the budget will be exhausted at the end of
+// the SLO window
+func BurnRate(sli, target float64) float64 {
+	budget := ErrorBudget(target)
+	if math.IsNaN(sli) || budget <= 0 {
+		return math.NaN()
+	}
+	return (1 - sli) / budget
+}
+
+// ErrorBudgetRemaining returns the ratio of error budget left in the SLO window, it is negative if the budget
+// is exhausted
+func ErrorBudgetRemaining(sli, target float64) float64 {
+	return 1 - BurnRate(sli, target)
+}
+
+// BurnRateAlerts evaluates the multi-window alert rules, windows without events do not fire
+func BurnRateAlerts(burnRates map[string]float64) []model.BurnRateAlert {
+	alerts := make([]model.BurnRateAlert, 0, len(common.BURN_RATE_ALERT_RULES))
+	for _, rule := range common.BURN_RATE_ALERT_RULES {
+		long, short := burnRates[rule.LongWindow], burnRates[rule.ShortWindow]
+		alerts = append(alerts, model.BurnRateAlert{
+			LongWindow:  rule.LongWindow,
+			ShortWindow: rule.ShortWindow,
+			Threshold:   rule.Threshold,
+			Severity:    rule.Severity,
+			// comparisons with NaN are false
+			Firing: long > rule.Threshold && short > rule.Threshold,
+		})
+	}
+	return alerts
+}
+
+// newSLOStatus builds the status from SLIs of the SLO window and the burn rate windows
+func newSLOStatus(slo *model.SLO, end int64, sli float64, windowSLIs map[string]float64) *model.SLOStatus {
+	status := &model.SLOStatus{
+		Name:                 slo.Name,
+		Lcuuid:               slo.Lcuuid,
+		SLIType:              slo.SLIType,
+		Target:               slo.Target,
+		TimeWindow:           slo.TimeWindow,
+		Time:                 end,
+		SLI:                  optionalFloat(sli),
+		ErrorBudget:          ErrorBudget(slo.Target),
+		ErrorBudgetRemaining: optionalFloat(ErrorBudgetRemaining(sli, slo.Target)),
+		BurnRates:            make([]model.BurnRate, 0, len(common.BURN_RATE_WINDOWS)),
+	}
+	burnRates := make(map[string]float64, len(common.BURN_RATE_WINDOWS))
+	for _, window := range common.BURN_RATE_WINDOWS {
+		windowSLI, ok := windowSLIs[window.Name]
+		if !ok {
+			windowSLI = math.NaN()
+		}
+		burnRates[window.Name] = BurnRate(windowSLI, slo.Target)
+		status.BurnRates = append(status.BurnRates, model.BurnRate{
+			Window:   window.Name,
+			SLI:      optionalFloat(windowSLI),
+			BurnRate: optionalFloat(burnRates[window.Name]),
+		})
+	}
+	status.Alerts = BurnRateAlerts(burnRates)
+	return status
+}
+
+// values are returned as null if they are missing, since json does not support NaN
+func optionalFloat(value float64) *float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/querier/app/slo/model"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestBurnRate(t *testing.T) {
+	// 99.9% target allows 0.1% bad events
+	if v := ErrorBudget(99.9); !almostEqual(v, 0.001) {
+		t.Errorf("ErrorBudget: %v, want 0.001", v)
+	}
+	sli := SLI(9986, 10000)
+	if !almostEqual(sli, 0.9986) {
+		t.Errorf("SLI: %v, want 0.9986", sli)
+	}
+	if v := BurnRate(sli, 99.9); !almostEqual(v, 1.4) {
+		t.Errorf("BurnRate: %v, want 1.4", v)
+	}
+	if v := ErrorBudgetRemaining(0.9995, 99.9); !almostEqual(v, 0.5) {
+		t.Errorf("ErrorBudgetRemaining: %v, want 0.5", v)
+	}
+	if v := SLI(0, 0); !math.IsNaN(v) {
+		t.Errorf("SLI without events: %v, want NaN", v)
+	}
+	if v := BurnRate(math.NaN(), 99.9); !math.IsNaN(v) {
+		t.Errorf("BurnRate without events: %v, want NaN", v)
+	}
+}
+
+func TestBurnRateAlerts(t *testing.T) {
+	burnRates := map[string]float64{
+		"5m": 20, "30m": 7, "1h": 15, "2h": 2, "6h": 5, "1d": 4, "3d": math.NaN(),
+	}
+	want := map[string]bool{"1h": true, "6h": false, "1d": false, "3d": false}
+	for _, alert := range BurnRateAlerts(burnRates) {
+		if alert.Firing != want[alert.LongWindow] {
+			t.Errorf("alert %s/%s firing: %v, want %v", alert.LongWindow, alert.ShortWindow, alert.Firing, want[alert.LongWindow])
+		}
+	}
+}
+
+func TestNewSLOStatus(t *testing.T) {
+	// budget of 98.4375% is 1/64, so that burn rates are exact
+	slo := &model.SLO{Name: "cart", Lcuuid: "l", Target: 98.4375, TimeWindow: 86400}
+	status := newSLOStatus(slo, 1700000000, 0.9921875, map[string]float64{"5m": 0.75, "1h": 0.75})
+	if status.ErrorBudgetRemaining == nil || !almostEqual(*status.ErrorBudgetRemaining, 0.5) {
+		t.Errorf("error budget remaining: %v, want 0.5", status.ErrorBudgetRemaining)
+	}
+	for _, burnRate := range status.BurnRates {
+		switch burnRate.Window {
+		case "5m", "1h":
+			if burnRate.BurnRate == nil || !almostEqual(*burnRate.BurnRate, 16) {
+				t.Errorf("burn rate of %s: %v, want 16", burnRate.Window, burnRate.BurnRate)
+			}
+		default:
+			if burnRate.BurnRate != nil {
+				t.Errorf("burn rate of %s: %v, want null", burnRate.Window, *burnRate.BurnRate)
+			}
+		}
+	}
+	if !status.Alerts[0].Firing {
+		t.Errorf("1h/5m alert should fire")
+	}
+
+	text := ToPrometheusText([]*model.SLOStatus{status})
+	for _, line := range []string{
+		"# TYPE deepflow_slo_sli gauge",
+		`deepflow_slo_sli{slo="cart",lcuuid="l"} 0.9921875`,
+		`deepflow_slo_target{slo="cart",lcuuid="l"} 0.984375`,
+		`deepflow_slo_error_budget_remaining{slo="cart",lcuuid="l"} 0.5`,
+		`deepflow_slo_burn_rate{slo="cart",lcuuid="l",window="5m"} 16`,
+		`deepflow_slo_burn_rate{slo="cart",lcuuid="l",window="3d"} NaN`,
+		`deepflow_slo_burn_rate_alert{slo="cart",lcuuid="l",long_window="1h",short_window="5m",severity="page"} 1`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("%s not found in:\n%s", line, text)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/deepflowio/deepflow/server/querier/app/slo/common"
+	"github.com/deepflowio/deepflow/server/querier/app/slo/model"
+)
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// ToPrometheusText exports statuses in Prometheus text format, so that SLOs can be scraped and alerted by
+// Prometheus compatible systems
+func ToPrometheusText(statuses []*model.SLOStatus) string {
+	var b strings.Builder
+	writeMetric := func(name, help string, f func(status *model.SLOStatus)) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, status := range statuses {
+			f(status)
+		}
+	}
+	writeSample := func(name string, labels []string, value *float64) {
+		v := math.NaN()
+		if value != nil {
+			v = *value
+		}
+		pairs := make([]string, 0, len(labels)/2)
+		for i := 0; i+1 < len(labels); i += 2 {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], labelValueReplacer.Replace(labels[i+1])))
+		}
+		fmt.Fprintf(&b, "%s{%s} %s\n", name, strings.Join(pairs, ","), strconv.FormatFloat(v, 'g', -1, 64))
+	}
+
+	writeMetric(common.METRIC_SLI, "Ratio of good events to total events in the SLO window.", func(s *model.SLOStatus) {
+		writeSample(common.METRIC_SLI, []string{"slo", s.Name, "lcuuid", s.Lcuuid}, s.SLI)
+	})
+	writeMetric(common.METRIC_TARGET, "Target of the SLO, ratio of good events.", func(s *model.SLOStatus) {
+		target := s.Target / 100
+		writeSample(common.METRIC_TARGET, []string{"slo", s.Name, "lcuuid", s.Lcuuid}, &target)
+	})
+	writeMetric(common.METRIC_ERROR_BUDGET_REMAINING, "Ratio of error budget left in the SLO window.", func(s *model.SLOStatus) {
+		writeSample(common.METRIC_ERROR_BUDGET_REMAINING, []string{"slo", s.Name, "lcuuid", s.Lcuuid}, s.ErrorBudgetRemaining)
+	})
+	writeMetric(common.METRIC_BURN_RATE, "Error budget burn rate of the window.", func(s *model.SLOStatus) {
+		for _, burnRate := range s.BurnRates {
+			writeSample(common.METRIC_BURN_RATE, []string{"slo", s.Name, "lcuuid", s.Lcuuid, "window", burnRate.Window}, burnRate.BurnRate)
+		}
+	})
+	writeMetric(common.METRIC_BURN_RATE_ALERT, "1 if both windows burn faster than the threshold.", func(s *model.SLOStatus) {
+		for _, alert := range s.Alerts {
+			firing := 0.0
+			if alert.Firing {
+				firing = 1
+			}
+			writeSample(common.METRIC_BURN_RATE_ALERT, []string{
+				"slo", s.Name, "lcuuid", s.Lcuuid, "long_window", alert.LongWindow, "short_window", alert.ShortWindow, "severity", alert.Severity,
+			}, &firing)
+		}
+	})
+	return b.String()
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"sync"
+
+	"github.com/deepflowio/deepflow/server/querier/app/slo/common"
+	"github.com/deepflowio/deepflow/server/querier/app/slo/model"
+)
+
+// SLIs are cached by the definition of the SLO, so that modified SLOs are queried again
+type sliCacheKey struct {
+	orgID      string
+	source     string
+	db         string
+	goodQuery  string
+	totalQuery string
+	window     int64
+}
+
+func newSLICacheKey(orgID string, slo *model.SLO, window int64) sliCacheKey {
+	return sliCacheKey{
+		orgID:      orgID,
+		source:     slo.SLISource,
+		db:         slo.DB,
+		goodQuery:  slo.GoodQuery,
+		totalQuery: slo.TotalQuery,
+		window:     window,
+	}
+}
+
+type sliCacheItem struct {
+	sli    float64
+	expire int64
+}
+
+type sliCache struct {
+	sync.Mutex
+	items map[sliCacheKey]sliCacheItem
+}
+
+var slis = &sliCache{items: make(map[sliCacheKey]sliCacheItem)}
+
+func (c *sliCache) get(key sliCacheKey, now int64) (float64, bool) {
+	c.Lock()
+	defer c.Unlock()
+	item, ok := c.items[key]
+	if !ok || item.expire <= now {
+		return 0, false
+	}
+	return item.sli, true
+}
+
+// put also removes the expired items, such as SLIs of deleted SLOs
+func (c *sliCache) put(key sliCacheKey, sli float64, now int64) {
+	c.Lock()
+	defer c.Unlock()
+	for k, item := range c.items {
+		if item.expire <= now {
+			delete(c.items, k)
+		}
+	}
+	c.items[key] = sliCacheItem{sli: sli, expire: now + sliCacheTTL(key.window)}
+}
+
+func sliCacheTTL(window int64) int64 {
+	ttl := window / common.SLI_CACHE_TTL_RATIO
+	if ttl < common.SLI_CACHE_MIN_TTL {
+		return common.SLI_CACHE_MIN_TTL
+	}
+	if ttl > common.SLI_CACHE_MAX_TTL {
+		return common.SLI_CACHE_MAX_TTL
+	}
+	return ttl
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/querier/app/slo/model"
+)
+
+func TestSLICache(t *testing.T) {
+	cache := &sliCache{items: make(map[sliCacheKey]sliCacheItem)}
+	slo := &model.SLO{SLISource: "deepflow", DB: "flow_metrics", GoodQuery: "good", TotalQuery: "total"}
+	short := newSLICacheKey("1", slo, 300)
+	long := newSLICacheKey("1", slo, 2592000)
+	cache.put(short, 0.99, 1000)
+	cache.put(long, 0.999, 1000)
+	if sli, ok := cache.get(short, 1059); !ok || sli != 0.99 {
+		t.Errorf("short window should be cached for 60s, get %v %v", sli, ok)
+	}
+	if _, ok := cache.get(short, 1060); ok {
+		t.Errorf("short window should expire after 60s")
+	}
+	// the 30d window is queried once an hour
+	if sli, ok := cache.get(long, 4599); !ok || sli != 0.999 {
+		t.Errorf("long window should be cached for 1h, get %v %v", sli, ok)
+	}
+	modified := *slo
+	modified.GoodQuery = "modified"
+	if _, ok := cache.get(newSLICacheKey("1", &modified, 2592000), 1001); ok {
+		t.Errorf("modified slo should not be cached")
+	}
+	if _, ok := cache.get(newSLICacheKey("2", slo, 2592000), 1001); ok {
+		t.Errorf("slo of other org should not be cached")
+	}
+	cache.put(short, 0.98, 1060)
+	if len(cache.items) != 2 {
+		t.Errorf("expired items should be removed: %d", len(cache.items))
+	}
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	logging "github.com/op/go-logging"
+	"github.com/prometheus/prometheus/promql"
+
+	controller_common "github.com/deepflowio/deepflow/server/controller/common"
+	prometheus_model "github.com/deepflowio/deepflow/server/querier/app/prometheus/model"
+	prometheus_service "github.com/deepflowio/deepflow/server/querier/app/prometheus/service"
+	"github.com/deepflowio/deepflow/server/querier/app/slo/common"
+	"github.com/deepflowio/deepflow/server/querier/app/slo/model"
+	querier_common "github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse"
+	"github.com/deepflowio/deepflow/server/querier/service"
+)
+
+var log = logging.MustGetLogger("slo")
+
+// GetSLOs returns SLOs of the organization defined in controller, all SLOs are returned if lcuuid is empty
+func GetSLOs(orgID, lcuuid string) ([]*model.SLO, error) {
+	url := fmt.Sprintf("http://localhost:%d/v1/slos/", config.ControllerCfg.ListenPort)
+	if lcuuid != "" {
+		url += "?lcuuid=" + lcuuid
+	}
+	resp, err := controller_common.CURLPerform("GET", url, nil, controller_common.WithORGHeader(orgID))
+	if err != nil {
+		log.Errorf("request controller failed: %s, URL: %s", err, url)
+		return nil, querier_common.NewError(common.SERVER_ERROR, fmt.Sprintf("get slo failed: %s", err))
+	}
+	data, err := resp.Get("DATA").MarshalJSON()
+	if err != nil {
+		return nil, querier_common.NewError(common.SERVER_ERROR, err.Error())
+	}
+	slos := []*model.SLO{}
+	if err := json.Unmarshal(data, &slos); err != nil {
+		return nil, querier_common.NewError(common.SERVER_ERROR, fmt.Sprintf("parse slo failed: %s", err))
+	}
+	return slos, nil
+}
+
+func Status(args *model.StatusArgs, promService *prometheus_service.PrometheusService) (*model.SLOStatus, interface{}, error) {
+	slos, err := GetSLOs(args.OrgID, args.Lcuuid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(slos) == 0 {
+		return nil, nil, querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("slo (%s) not found", args.Lcuuid))
+	}
+	q := &sliQuerier{args: args, promService: promService}
+	status, err := q.status(slos[0])
+	return status, q.debugs, err
+}
+
+// Statuses returns statuses of all SLOs of the organization, SLOs failed to query are skipped
+func Statuses(args *model.StatusArgs, promService *prometheus_service.PrometheusService) ([]*model.SLOStatus, error) {
+	slos, err := GetSLOs(args.OrgID, "")
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]*model.SLOStatus, 0, len(slos))
+	for _, slo := range slos {
+		q := &sliQuerier{args: args, promService: promService}
+		status, err := q.status(slo)
+		if err != nil {
+			log.Warningf("query slo (%s) failed: %s", slo.Name, err)
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+type sliQuerier struct {
+	args        *model.StatusArgs
+	promService *prometheus_service.PrometheusService
+	debugs      []interface{}
+}
+
+// status queries SLI of the SLO window and each burn rate window, all windows end at the same time
+func (q *sliQuerier) status(slo *model.SLO) (*model.SLOStatus, error) {
+	end := q.args.Time
+	if end <= 0 {
+		end = time.Now().Unix()
+	}
+	sli, err := q.windowSLI(slo, slo.TimeWindow, end)
+	if err != nil {
+		return nil, err
+	}
+	windowSLIs := make(map[string]float64, len(common.BURN_RATE_WINDOWS))
+	for _, window := range common.BURN_RATE_WINDOWS {
+		if window.Seconds == slo.TimeWindow {
+			windowSLIs[window.Name] = sli
+			continue
+		}
+		windowSLIs[window.Name], err = q.windowSLI(slo, window.Seconds, end)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newSLOStatus(slo, end, sli, windowSLIs), nil
+}
+
+// windowSLI queries SLI of the window ending at end, SLIs of windows ending now are cached if not debugging
+func (q *sliQuerier) windowSLI(slo *model.SLO, window, end int64) (float64, error) {
+	cacheable := q.args.Time <= 0 && !q.args.Debug
+	key := newSLICacheKey(q.args.OrgID, slo, window)
+	if cacheable {
+		if sli, ok := slis.get(key, end); ok {
+			return sli, nil
+		}
+	}
+	sli, err := q.sli(slo, end-window, end)
+	if err == nil && cacheable {
+		slis.put(key, sli, end)
+	}
+	return sli, err
+}
+
+func (q *sliQuerier) sli(slo *model.SLO, start, end int64) (float64, error) {
+	query := q.querySQL
+	if slo.SLISource == common.SLI_SOURCE_PROMQL {
+		query = q.queryPromQL
+	}
+	good, err := query(slo, slo.GoodQuery, start, end)
+	if err != nil {
+		return 0, err
+	}
+	total, err := query(slo, slo.TotalQuery, start, end)
+	if err != nil {
+		return 0, err
+	}
+	return SLI(good, total), nil
+}
+
+// querySQL returns the first metric of the first row, sql is restricted to the time range
+func (q *sliQuerier) querySQL(slo *model.SLO, sql string, start, end int64) (float64, error) {
+	sql, err := clickhouse.AddTimeFilter(sql, start, end)
+	if err != nil {
+		return 0, querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("slo (%s): %s", slo.Name, err))
+	}
+	querierArgs := &querier_common.QuerierParams{
+		DB:        slo.DB,
+		Sql:       sql,
+		Debug:     strconv.FormatBool(q.args.Debug),
+		QueryUUID: uuid.New().String(),
+		Context:   q.args.Context,
+		ORGID:     q.args.OrgID,
+		UserID:    q.args.UserID,
+	}
+	result, debug, err := service.StreamExecute(querierArgs)
+	if debug != nil && q.args.Debug {
+		q.debugs = append(q.debugs, debug)
+	}
+	if err != nil {
+		log.Errorf("query_uuid: %s | slo (%s) query failed: %s", querierArgs.QueryUUID, slo.Name, err)
+		return 0, err
+	}
+	return scalarFromResult(result), nil
+}
+
+// queryPromQL executes an instant query at the end of the time range, $__range is replaced by the length of it
+func (q *sliQuerier) queryPromQL(slo *model.SLO, query string, start, end int64) (float64, error) {
+	if q.promService == nil {
+		return 0, errors.New("promql is not supported")
+	}
+	ctx := q.args.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	promArgs := &prometheus_model.PromQueryParams{
+		Debug:     q.args.Debug,
+		Promql:    strings.ReplaceAll(query, common.PROMQL_RANGE_PLACEHOLDER, fmt.Sprintf("%ds", end-start)),
+		StartTime: strconv.FormatInt(end, 10),
+		OrgID:     q.args.OrgID,
+		Context:   ctx,
+	}
+	result, err := q.promService.PromInstantQueryService(promArgs, ctx)
+	if result != nil && q.args.Debug {
+		q.debugs = append(q.debugs, result.Stats)
+	}
+	if err != nil {
+		return 0, err
+	}
+	data, ok := result.Data.(*prometheus_model.PromQueryData)
+	if !ok {
+		return 0, nil
+	}
+	return scalarFromPromValue(data.Result), nil
+}
+
+// scalarFromResult returns the first metric column of the first row, 0 if there is no row
+func scalarFromResult(result *querier_common.Result) float64 {
+	if result == nil || len(result.Values) == 0 {
+		return 0
+	}
+	row, ok := result.Values[0].([]interface{})
+	if !ok {
+		return 0
+	}
+	for i := range row {
+		if i < len(result.Schemas) && result.Schemas[i].Type == querier_common.COLUMN_SCHEMA_TYPE_METRICS {
+			if v, ok := querier_common.ValueToFloat64(row[i]); ok {
+				return v
+			}
+		}
+	}
+	// schemas of some results are not set, use the first number
+	for _, value := range row {
+		if v, ok := querier_common.ValueToFloat64(value); ok {
+			return v
+		}
+	}
+	return 0
+}
+
+// scalarFromPromValue returns the sum of a vector or the value of a scalar
+func scalarFromPromValue(value interface{}) float64 {
+	switch v := value.(type) {
+	case promql.Scalar:
+		return v.V
+	case promql.Vector:
+		sum := 0.0
+		for _, sample := range v {
+			if !math.IsNaN(sample.V) {
+				sum += sample.V
+			}
+		}
+		return sum
+	}
+	return 0
+}
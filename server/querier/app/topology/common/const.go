@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+const (
+	SUCCESS            = "SUCCESS"
+	FAIL               = "FAIL"
+	INVALID_PARAMETERS = "INVALID_PARAMETERS"
+	INVALID_POST_DATA  = "INVALID_POST_DATA"
+	SERVER_ERROR       = "SERVER_ERROR"
+)
+
+const (
+	DATABASE_FLOW_METRICS = "flow_metrics"
+	TABLE_APPLICATION_MAP = "application_map"
+	TABLE_NETWORK_MAP     = "network_map"
+	DEFAULT_DATA_SOURCE   = "1m"
+	DEFAULT_GROUP_BY      = "pod_service"
+	DEFAULT_DEPTH         = 1
+	DEFAULT_EDGE_LIMIT    = 10000
+)
+
+const (
+	FORMAT_JSON       = "json"
+	FORMAT_JSON_GRAPH = "json_graph" // JSON Graph Format, https://jsongraphformat.info
+	FORMAT_DOT        = "dot"        // Graphviz DOT
+)
+
+const (
+	CONTENT_TYPE_DOT = "text/vnd.graphviz; charset=utf-8"
+)
+
+// result columns of the topology sql
+const (
+	COLUMN_CLIENT      = "client"
+	COLUMN_SERVER      = "server"
+	COLUMN_REQUEST     = "request"
+	COLUMN_ERROR_RATIO = "error_ratio"
+	COLUMN_RRT         = "rrt"
+	COLUMN_RRT_P50     = "rrt_p50"
+	COLUMN_RRT_P95     = "rrt_p95"
+	COLUMN_RRT_P99     = "rrt_p99"
+)
+
+const (
+	HEADER_KEY_X_ORG_ID  = "X-Org-Id"
+	HEADER_KEY_X_USER_ID = "X-User-Id"
+)
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "context"
+
+type Topology struct {
+	// application_map or network_map
+	Table      string `json:"table"`
+	DataSource string `json:"data_precision"`
+	// tag of nodes, such as pod_service, auto_service or k8s.label.app
+	GroupBy   string `json:"group_by"`
+	TimeStart int64  `json:"time_start" binding:"required"`
+	TimeEnd   int64  `json:"time_end" binding:"required"`
+	// condition of DeepFlow SQL, such as pod_ns_0='default'
+	Filter string `json:"filter"`
+	// only nodes within depth hops of the focus node are returned
+	Focus   string `json:"focus"`
+	Depth   int    `json:"depth"`
+	Limit   int    `json:"limit"`
+	Format  string `json:"format"`
+	Debug   bool   `json:"debug"`
+	Context context.Context
+	OrgID   string
+	UserID  string
+}
+
+// Metrics are RED metrics, rrt is in us, error ratio is in %, rrt and error ratio are nil if there is no response
+type Metrics struct {
+	Request     float64  `json:"request"`
+	RequestRate float64  `json:"request_rate"`
+	ErrorRatio  *float64 `json:"error_ratio"`
+	RRT         *float64 `json:"rrt"`
+}
+
+type Edge struct {
+	Client   string `json:"client"`
+	Server   string `json:"server"`
+	Protocol string `json:"protocol"`
+	Metrics
+	RRTP50 *float64 `json:"rrt_p50"`
+	RRTP95 *float64 `json:"rrt_p95"`
+	RRTP99 *float64 `json:"rrt_p99"`
+}
+
+// Node metrics are aggregated from the edges to the node, and client metrics from the edges from the node
+type Node struct {
+	ID            string  `json:"id"`
+	Metrics               // as server
+	ClientMetrics Metrics `json:"client_metrics"`
+	// hops from the focus node, 0 if there is no focus node
+	Depth int `json:"depth"`
+}
+
+type Graph struct {
+	GroupBy string  `json:"group_by"`
+	Nodes   []*Node `json:"nodes"`
+	Edges   []*Edge `json:"edges"`
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deepflowio/deepflow/server/querier/app/topology/model"
+)
+
+// ToDOT exports the graph in Graphviz DOT, e.g.:
+//
+//	digraph "pod_service" {
+//	  "a" [label="a\n1.00 req/s, 0.00% err, rrt 100.00us"];
+//	  "a" -> "b" [label="HTTP\n1.00 req/s, 0.00% err, p95 100.00us"];
+//	}
+func ToDOT(g *model.Graph) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", quoteDOT(g.GroupBy))
+	b.WriteString("  rankdir=LR;\n  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		label := fmt.Sprintf("%s\n%s req/s, %s err, rrt %s", node.ID, formatFloat(&node.RequestRate), formatPercentage(node.ErrorRatio), formatDelay(node.RRT))
+		fmt.Fprintf(&b, "  %s [label=%s];\n", quoteDOT(node.ID), quoteDOT(label))
+	}
+	for _, edge := range g.Edges {
+		label := fmt.Sprintf("%s\n%s req/s, %s err, p95 %s", edge.Protocol, formatFloat(&edge.RequestRate), formatPercentage(edge.ErrorRatio), formatDelay(edge.RRTP95))
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", quoteDOT(edge.Client), quoteDOT(edge.Server), quoteDOT(label))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ToJSONGraph exports the graph in JSON Graph Format v2, metrics are in metadata
+func ToJSONGraph(g *model.Graph) map[string]interface{} {
+	nodes := make(map[string]interface{}, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes[node.ID] = map[string]interface{}{
+			"label": node.ID,
+			"metadata": map[string]interface{}{
+				"request":        node.Request,
+				"request_rate":   node.RequestRate,
+				"error_ratio":    node.ErrorRatio,
+				"rrt":            node.RRT,
+				"client_metrics": node.ClientMetrics,
+				"depth":          node.Depth,
+			},
+		}
+	}
+	edges := make([]interface{}, 0, len(g.Edges))
+	for _, edge := range g.Edges {
+		edges = append(edges, map[string]interface{}{
+			"source":   edge.Client,
+			"target":   edge.Server,
+			"relation": edge.Protocol,
+			"directed": true,
+			"metadata": map[string]interface{}{
+				"request":      edge.Request,
+				"request_rate": edge.RequestRate,
+				"error_ratio":  edge.ErrorRatio,
+				"rrt":          edge.RRT,
+				"rrt_p50":      edge.RRTP50,
+				"rrt_p95":      edge.RRTP95,
+				"rrt_p99":      edge.RRTP99,
+			},
+		})
+	}
+	return map[string]interface{}{
+		"graph": map[string]interface{}{
+			"type":     "topology",
+			"label":    g.GroupBy,
+			"directed": true,
+			"nodes":    nodes,
+			"edges":    edges,
+		},
+	}
+}
+
+func quoteDOT(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return "\"" + s + "\""
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*v, 'f', 2, 64)
+}
+
+func formatPercentage(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return formatFloat(v) + "%"
+}
+
+func formatDelay(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return formatFloat(v) + "us"
+}
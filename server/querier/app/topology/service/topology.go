@@ -0,0 +1,358 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	logging "github.com/op/go-logging"
+
+	"github.com/deepflowio/deepflow/server/querier/app/topology/common"
+	"github.com/deepflowio/deepflow/server/querier/app/topology/model"
+	querier_common "github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/service"
+)
+
+var log = logging.MustGetLogger("topology")
+
+// metrics and protocol of the map tables
+type tableMetrics struct {
+	request    string
+	errorRatio string
+	protocol   string
+}
+
+var TABLE_METRICS = map[string]tableMetrics{
+	common.TABLE_APPLICATION_MAP: {request: "request", errorRatio: "error_ratio", protocol: "l7_protocol"},
+	common.TABLE_NETWORK_MAP:     {request: "l7_request", errorRatio: "l7_error_ratio", protocol: "protocol"},
+}
+
+func Topology(args *model.Topology) (*model.Graph, interface{}, error) {
+	if err := checkArgs(args); err != nil {
+		return nil, nil, err
+	}
+	return topology(args, queryEdges)
+}
+
+// edgesQuerier queries the top edges of args.Limit, only edges from or to the nodes are queried if nodes is not empty
+type edgesQuerier func(args *model.Topology, nodes []string) ([]*model.Edge, interface{}, error)
+
+func topology(args *model.Topology, query edgesQuerier) (*model.Graph, interface{}, error) {
+	if args.Focus == "" {
+		edges, debug, err := query(args, nil)
+		if err != nil {
+			return nil, debug, err
+		}
+		return NewGraph(args.GroupBy, edges), debug, nil
+	}
+	edges, debug, err := focusEdges(args, query)
+	if err != nil {
+		return nil, debug, err
+	}
+	return ExpandGraph(NewGraph(args.GroupBy, edges), args.Focus, args.Depth), debug, nil
+}
+
+// focusEdges queries the edges hop by hop from the focus node, so that nodes of low traffic near the focus node
+// are not dropped by the limit of edges
+func focusEdges(args *model.Topology, query edgesQuerier) ([]*model.Edge, interface{}, error) {
+	var edges []*model.Edge
+	var debugs []interface{}
+	queried := map[string]bool{}
+	added := map[model.Edge]bool{}
+	frontier := []string{args.Focus}
+	for d := 0; d < args.Depth && len(frontier) > 0; d++ {
+		hopEdges, debug, err := query(args, frontier)
+		if debug != nil {
+			debugs = append(debugs, debug)
+		}
+		if err != nil {
+			return nil, debugs, err
+		}
+		for _, id := range frontier {
+			queried[id] = true
+		}
+		frontier = nil
+		for _, edge := range hopEdges {
+			key := model.Edge{Client: edge.Client, Server: edge.Server, Protocol: edge.Protocol}
+			if added[key] {
+				continue
+			}
+			added[key] = true
+			edges = append(edges, edge)
+			for _, id := range []string{edge.Client, edge.Server} {
+				if !queried[id] {
+					queried[id] = true
+					frontier = append(frontier, id)
+				}
+			}
+		}
+	}
+	if len(debugs) == 0 {
+		return edges, nil, nil
+	}
+	return edges, debugs, nil
+}
+
+func queryEdges(args *model.Topology, nodes []string) ([]*model.Edge, interface{}, error) {
+	querierArgs := &querier_common.QuerierParams{
+		DB:         common.DATABASE_FLOW_METRICS,
+		Sql:        topologySql(args, nodes),
+		DataSource: args.DataSource,
+		Debug:      strconv.FormatBool(args.Debug),
+		QueryUUID:  uuid.New().String(),
+		Context:    args.Context,
+		ORGID:      args.OrgID,
+		UserID:     args.UserID,
+	}
+	result, debug, err := service.StreamExecute(querierArgs)
+	if err != nil {
+		log.Errorf("query_uuid: %s | topology query failed: %s", querierArgs.QueryUUID, err)
+		return nil, debug, err
+	}
+	return edgesFromResult(result, TABLE_METRICS[args.Table].protocol, float64(args.TimeEnd-args.TimeStart+1)), debug, nil
+}
+
+func checkArgs(args *model.Topology) error {
+	if args.Table == "" {
+		args.Table = common.TABLE_APPLICATION_MAP
+	} else if _, ok := TABLE_METRICS[args.Table]; !ok {
+		return querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("table [%s] is not supported", args.Table))
+	}
+	if args.DataSource == "" {
+		args.DataSource = common.DEFAULT_DATA_SOURCE
+	}
+	if args.GroupBy == "" {
+		args.GroupBy = common.DEFAULT_GROUP_BY
+	}
+	if strings.ContainsAny(args.GroupBy, "`'\"(), ") {
+		return querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("group_by [%s] is not a tag", args.GroupBy))
+	}
+	if args.TimeEnd < args.TimeStart {
+		return querier_common.NewError(common.INVALID_PARAMETERS, "time_end should not be less than time_start")
+	}
+	if args.Depth <= 0 {
+		args.Depth = common.DEFAULT_DEPTH
+	}
+	if args.Limit <= 0 {
+		args.Limit = common.DEFAULT_EDGE_LIMIT
+	}
+	switch args.Format {
+	case "":
+		args.Format = common.FORMAT_JSON
+	case common.FORMAT_JSON, common.FORMAT_JSON_GRAPH, common.FORMAT_DOT:
+	default:
+		return querier_common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("format [%s] is not supported", args.Format))
+	}
+	return nil
+}
+
+// topologySql groups the map table by the client and the server side of the tag, e.g.:
+// SELECT `pod_service_0` AS `client`, `pod_service_1` AS `server`, Enum(l7_protocol), Sum(request) AS `request`, ...
+// FROM `application_map` WHERE time>=1 AND time<=60 GROUP BY `client`, `server`, l7_protocol,
+// only edges from or to the nodes are grouped if nodes is not empty
+func topologySql(args *model.Topology, nodes []string) string {
+	metrics := TABLE_METRICS[args.Table]
+	where := fmt.Sprintf("time>=%d AND time<=%d", args.TimeStart, args.TimeEnd)
+	if args.Filter != "" {
+		where += fmt.Sprintf(" AND (%s)", args.Filter)
+	}
+	if len(nodes) > 0 {
+		values := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			values = append(values, "'"+strings.ReplaceAll(node, "'", "''")+"'")
+		}
+		in := strings.Join(values, ", ")
+		where += fmt.Sprintf(" AND (`%s_0` IN (%s) OR `%s_1` IN (%s))", args.GroupBy, in, args.GroupBy, in)
+	}
+	return fmt.Sprintf(
+		"SELECT `%s_0` AS `%s`, `%s_1` AS `%s`, Enum(%s), Sum(%s) AS `%s`, Avg(%s) AS `%s`, Avg(rrt) AS `%s`, "+
+			"Percentile(rrt, 50) AS `%s`, Percentile(rrt, 95) AS `%s`, Percentile(rrt, 99) AS `%s` "+
+			"FROM `%s` WHERE %s GROUP BY `%s`, `%s`, %s ORDER BY `%s` DESC LIMIT %d",
+		args.GroupBy, common.COLUMN_CLIENT, args.GroupBy, common.COLUMN_SERVER, metrics.protocol,
+		metrics.request, common.COLUMN_REQUEST, metrics.errorRatio, common.COLUMN_ERROR_RATIO, common.COLUMN_RRT,
+		common.COLUMN_RRT_P50, common.COLUMN_RRT_P95, common.COLUMN_RRT_P99,
+		args.Table, where, common.COLUMN_CLIENT, common.COLUMN_SERVER, metrics.protocol, common.COLUMN_REQUEST, args.Limit,
+	)
+}
+
+func edgesFromResult(result *querier_common.Result, protocol string, seconds float64) []*model.Edge {
+	indexes := map[string]int{}
+	for i, column := range result.Columns {
+		name, _ := column.(string)
+		indexes[name] = i
+	}
+	protocolColumn := fmt.Sprintf("Enum(%s)", protocol)
+	edges := make([]*model.Edge, 0, len(result.Values))
+	for _, value := range result.Values {
+		row, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		get := func(column string) interface{} {
+			if i, ok := indexes[column]; ok && i < len(row) {
+				return row[i]
+			}
+			return nil
+		}
+		edge := &model.Edge{
+			Client:   querier_common.ValueToString(get(common.COLUMN_CLIENT)),
+			Server:   querier_common.ValueToString(get(common.COLUMN_SERVER)),
+			Protocol: querier_common.ValueToString(get(protocolColumn)),
+			RRTP50:   nullableFloat(get(common.COLUMN_RRT_P50)),
+			RRTP95:   nullableFloat(get(common.COLUMN_RRT_P95)),
+			RRTP99:   nullableFloat(get(common.COLUMN_RRT_P99)),
+		}
+		edge.Request, _ = querier_common.ValueToFloat64(get(common.COLUMN_REQUEST))
+		if seconds > 0 {
+			edge.RequestRate = edge.Request / seconds
+		}
+		edge.ErrorRatio = nullableFloat(get(common.COLUMN_ERROR_RATIO))
+		edge.RRT = nullableFloat(get(common.COLUMN_RRT))
+		edges = append(edges, edge)
+	}
+	return edges
+}
+
+func nullableFloat(value interface{}) *float64 {
+	if v, ok := querier_common.ValueToFloat64(value); ok {
+		return &v
+	}
+	return nil
+}
+
+// metricsAggregator averages error ratio and rrt weighted by requests
+type metricsAggregator struct {
+	request, requestRate       float64
+	errorRatioSum, errorWeight float64
+	rrtSum, rrtWeight          float64
+}
+
+func (a *metricsAggregator) add(m *model.Metrics) {
+	a.request += m.Request
+	a.requestRate += m.RequestRate
+	if m.ErrorRatio != nil {
+		a.errorRatioSum += *m.ErrorRatio * m.Request
+		a.errorWeight += m.Request
+	}
+	if m.RRT != nil {
+		a.rrtSum += *m.RRT * m.Request
+		a.rrtWeight += m.Request
+	}
+}
+
+func (a *metricsAggregator) metrics() model.Metrics {
+	m := model.Metrics{Request: a.request, RequestRate: a.requestRate}
+	if a.errorWeight > 0 {
+		errorRatio := a.errorRatioSum / a.errorWeight
+		m.ErrorRatio = &errorRatio
+	}
+	if a.rrtWeight > 0 {
+		rrt := a.rrtSum / a.rrtWeight
+		m.RRT = &rrt
+	}
+	return m
+}
+
+// NewGraph aggregates the metrics of nodes from edges, nodes are sorted by id and edges by client, server and
+// protocol, so that exports are stable
+func NewGraph(groupBy string, edges []*model.Edge) *model.Graph {
+	serverMetrics := map[string]*metricsAggregator{}
+	clientMetrics := map[string]*metricsAggregator{}
+	for _, edge := range edges {
+		for _, id := range []string{edge.Client, edge.Server} {
+			if _, ok := serverMetrics[id]; !ok {
+				serverMetrics[id] = &metricsAggregator{}
+				clientMetrics[id] = &metricsAggregator{}
+			}
+		}
+		serverMetrics[edge.Server].add(&edge.Metrics)
+		clientMetrics[edge.Client].add(&edge.Metrics)
+	}
+	graph := &model.Graph{GroupBy: groupBy, Nodes: make([]*model.Node, 0, len(serverMetrics)), Edges: edges}
+	for id, aggregator := range serverMetrics {
+		graph.Nodes = append(graph.Nodes, &model.Node{ID: id, Metrics: aggregator.metrics(), ClientMetrics: clientMetrics[id].metrics()})
+	}
+	sortGraph(graph)
+	return graph
+}
+
+// ExpandGraph returns the subgraph of nodes within depth hops of the focus node in both directions, and the
+// edges between them which are within depth hops
+func ExpandGraph(g *model.Graph, focus string, depth int) *model.Graph {
+	adjacency := map[string][]string{}
+	for _, edge := range g.Edges {
+		adjacency[edge.Client] = append(adjacency[edge.Client], edge.Server)
+		adjacency[edge.Server] = append(adjacency[edge.Server], edge.Client)
+	}
+	depths := map[string]int{}
+	if _, ok := adjacency[focus]; ok {
+		depths[focus] = 0
+	}
+	queue := []string{focus}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		d, ok := depths[id]
+		if !ok || d >= depth {
+			continue
+		}
+		for _, peer := range adjacency[id] {
+			if _, ok := depths[peer]; !ok {
+				depths[peer] = d + 1
+				queue = append(queue, peer)
+			}
+		}
+	}
+
+	expanded := &model.Graph{GroupBy: g.GroupBy, Nodes: []*model.Node{}, Edges: []*model.Edge{}}
+	for _, node := range g.Nodes {
+		if d, ok := depths[node.ID]; ok {
+			n := *node
+			n.Depth = d
+			expanded.Nodes = append(expanded.Nodes, &n)
+		}
+	}
+	for _, edge := range g.Edges {
+		clientDepth, clientOK := depths[edge.Client]
+		serverDepth, serverOK := depths[edge.Server]
+		// edges between two nodes at the boundary are not traversed
+		if clientOK && serverOK && (clientDepth < depth || serverDepth < depth) {
+			expanded.Edges = append(expanded.Edges, edge)
+		}
+	}
+	return expanded
+}
+
+func sortGraph(g *model.Graph) {
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.Client != b.Client {
+			return a.Client < b.Client
+		}
+		if a.Server != b.Server {
+			return a.Server < b.Server
+		}
+		return a.Protocol < b.Protocol
+	})
+}
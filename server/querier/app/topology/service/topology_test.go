@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/querier/app/topology/model"
+	querier_common "github.com/deepflowio/deepflow/server/querier/common"
+)
+
+func testGraph() *model.Graph {
+	ratio := 10.0
+	rrt := 100.0
+	result := &querier_common.Result{
+		Columns: []interface{}{"client", "server", "Enum(l7_protocol)", "request", "error_ratio", "rrt", "rrt_p50", "rrt_p95", "rrt_p99"},
+		Values: []interface{}{
+			[]interface{}{"frontend", "cart", "HTTP", uint64(60), &ratio, &rrt, 1.0, 2.0, 3.0},
+			[]interface{}{"cart", "redis", "Redis", uint64(120), (*float64)(nil), 50.0, 1.0, 2.0, 3.0},
+			[]interface{}{"checkout", "cart", "gRPC", uint64(60), 0.0, 200.0, 1.0, 2.0, 3.0},
+			[]interface{}{"redis", "backup", "Redis", uint64(6), 0.0, 1.0, 1.0, 2.0, 3.0},
+		},
+	}
+	return NewGraph("pod_service", edgesFromResult(result, "l7_protocol", 60))
+}
+
+func TestTopologySql(t *testing.T) {
+	args := &model.Topology{TimeStart: 60, TimeEnd: 119, Filter: "pod_ns_0='default'", GroupBy: "k8s.label.app"}
+	if err := checkArgs(args); err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT `k8s.label.app_0` AS `client`, `k8s.label.app_1` AS `server`, Enum(l7_protocol), Sum(request) AS `request`, Avg(error_ratio) AS `error_ratio`, Avg(rrt) AS `rrt`, Percentile(rrt, 50) AS `rrt_p50`, Percentile(rrt, 95) AS `rrt_p95`, Percentile(rrt, 99) AS `rrt_p99` FROM `application_map` WHERE time>=60 AND time<=119 AND (pod_ns_0='default') GROUP BY `client`, `server`, l7_protocol ORDER BY `request` DESC LIMIT 10000"
+	if got := topologySql(args, nil); got != want {
+		t.Errorf("topologySql: %s, want %s", got, want)
+	}
+	want = "time>=60 AND time<=119 AND (pod_ns_0='default') AND (`k8s.label.app_0` IN ('cart', 'o''brien') OR `k8s.label.app_1` IN ('cart', 'o''brien')) GROUP BY"
+	if got := topologySql(args, []string{"cart", "o'brien"}); !strings.Contains(got, want) {
+		t.Errorf("topologySql with nodes: %s, want %s", got, want)
+	}
+	if err := checkArgs(&model.Topology{GroupBy: "pod) AS x", TimeEnd: 1}); err == nil {
+		t.Errorf("group_by should be checked")
+	}
+}
+
+func TestNewGraph(t *testing.T) {
+	g := testGraph()
+	ids := []string{}
+	for _, node := range g.Nodes {
+		ids = append(ids, node.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"backup", "cart", "checkout", "frontend", "redis"}) {
+		t.Fatalf("unexpected nodes: %v", ids)
+	}
+	cart := g.Nodes[1]
+	if cart.Request != 120 || cart.RequestRate != 2 || *cart.ErrorRatio != 5 || *cart.RRT != 150 {
+		t.Errorf("unexpected server metrics of cart: %+v", cart.Metrics)
+	}
+	if cart.ClientMetrics.Request != 120 || cart.ClientMetrics.ErrorRatio != nil || *cart.ClientMetrics.RRT != 50 {
+		t.Errorf("unexpected client metrics of cart: %+v", cart.ClientMetrics)
+	}
+	if g.Edges[0].Client != "cart" || g.Edges[0].Protocol != "Redis" || *g.Edges[0].RRTP95 != 2 {
+		t.Errorf("unexpected first edge: %+v", g.Edges[0])
+	}
+}
+
+func TestExpandGraph(t *testing.T) {
+	g := ExpandGraph(testGraph(), "cart", 1)
+	depths := map[string]int{}
+	for _, node := range g.Nodes {
+		depths[node.ID] = node.Depth
+	}
+	if !reflect.DeepEqual(depths, map[string]int{"cart": 0, "checkout": 1, "frontend": 1, "redis": 1}) {
+		t.Errorf("unexpected nodes: %v", depths)
+	}
+	if len(g.Edges) != 3 {
+		t.Errorf("unexpected edges: %d", len(g.Edges))
+	}
+	if g := ExpandGraph(testGraph(), "cart", 2); len(g.Nodes) != 5 || len(g.Edges) != 4 {
+		t.Errorf("unexpected graph of depth 2: %d nodes, %d edges", len(g.Nodes), len(g.Edges))
+	}
+	if g := ExpandGraph(testGraph(), "unknown", 2); len(g.Nodes) != 0 || len(g.Edges) != 0 {
+		t.Errorf("graph of unknown focus should be empty")
+	}
+}
+
+// testEdgesQuerier returns the top edges from or to the nodes like the topology sql
+func testEdgesQuerier(edges []*model.Edge) edgesQuerier {
+	return func(args *model.Topology, nodes []string) ([]*model.Edge, interface{}, error) {
+		matched := []*model.Edge{}
+		for _, edge := range edges {
+			if len(nodes) == 0 || querier_common.IsValueInSliceString(edge.Client, nodes) || querier_common.IsValueInSliceString(edge.Server, nodes) {
+				matched = append(matched, edge)
+			}
+		}
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].Request > matched[j].Request })
+		if len(matched) > args.Limit {
+			matched = matched[:args.Limit]
+		}
+		return matched, nil, nil
+	}
+}
+
+func TestTopologyFocus(t *testing.T) {
+	edges := testGraph().Edges
+	// edges of busy services are more than the limit
+	for _, client := range []string{"a", "b", "c", "d"} {
+		edges = append(edges, &model.Edge{Client: client, Server: "gateway", Protocol: "HTTP", Metrics: model.Metrics{Request: 1000}})
+	}
+	args := &model.Topology{TimeStart: 60, TimeEnd: 119, Limit: 4, Focus: "redis", Depth: 2}
+	if err := checkArgs(args); err != nil {
+		t.Fatal(err)
+	}
+	g, _, err := topology(args, testEdgesQuerier(edges))
+	if err != nil {
+		t.Fatal(err)
+	}
+	depths := map[string]int{}
+	for _, node := range g.Nodes {
+		depths[node.ID] = node.Depth
+	}
+	want := map[string]int{"redis": 0, "cart": 1, "backup": 1, "frontend": 2, "checkout": 2}
+	if !reflect.DeepEqual(depths, want) {
+		t.Errorf("unexpected nodes: %v, want %v", depths, want)
+	}
+	if len(g.Edges) != 4 {
+		t.Errorf("unexpected edges: %d", len(g.Edges))
+	}
+
+	args.Focus = ""
+	g, _, err = topology(args, testEdgesQuerier(edges))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Edges) != 4 || g.Edges[0].Server != "gateway" {
+		t.Errorf("unexpected top edges: %d, first %+v", len(g.Edges), g.Edges[0])
+	}
+}
+
+func TestExport(t *testing.T) {
+	g := ExpandGraph(testGraph(), "frontend", 1)
+	dot := ToDOT(g)
+	for _, want := range []string{
+		"digraph \"pod_service\" {\n",
+		"  \"cart\" [label=\"cart\\n2.00 req/s, 5.00% err, rrt 150.00us\"];\n",
+		"  \"frontend\" -> \"cart\" [label=\"HTTP\\n1.00 req/s, 10.00% err, p95 2.00us\"];\n",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("dot %s should contain %s", dot, want)
+		}
+	}
+	graph := ToJSONGraph(g)["graph"].(map[string]interface{})
+	if len(graph["nodes"].(map[string]interface{})) != 2 || len(graph["edges"].([]interface{})) != 1 {
+		t.Errorf("unexpected json graph: %v", graph)
+	}
+}
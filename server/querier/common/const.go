@@ -27,6 +27,9 @@ const (
 	SERVER_ERROR                    = "SERVER_ERROR"
 	RESOURCE_NUM_EXCEEDED           = "RESOURCE_NUM_EXCEEDED"
 	SELECTED_RESOURCES_NUM_EXCEEDED = "SELECTED_RESOURCES_NUM_EXCEEDED"
+	QUERY_LIMIT_EXCEEDED            = "QUERY_LIMIT_EXCEEDED"
+	TOO_MANY_QUERIES                = "TOO_MANY_QUERIES"
+	PERMISSION_DENIED               = "PERMISSION_DENIED"
 )
 
 const (
@@ -46,8 +49,16 @@ const (
 )
 
 const (
-	HEADER_KEY_X_ORG_ID = "X-Org-Id"
-	DEFAULT_ORG_ID      = "1"
+	HEADER_KEY_X_ORG_ID      = "X-Org-Id"
+	HEADER_KEY_X_USER_ID     = "X-User-Id"
+	HEADER_KEY_X_USER_TYPE   = "X-User-Type"
+	HEADER_KEY_X_NEXT_CURSOR = "X-Next-Cursor"
+	DEFAULT_ORG_ID           = "1"
+)
+
+const (
+	USER_TYPE_SUPER_ADMIN = "1"
+	USER_TYPE_ADMIN       = "2"
 )
 
 const NO_LIMIT = "-1"
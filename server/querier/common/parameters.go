@@ -33,7 +33,14 @@ type QuerierParams struct {
 	Context       context.Context
 	NoPreWhere    bool
 	ORGID         string
+	UserID        string
 	SimpleSql     bool
+	OutputFormat  string
+	ResultWriter  ResultWriter
+	PageSize      int
+	Cursor        string
+	Clusters      []string
+	MergeClusters bool
 }
 
 type TempoParams struct {
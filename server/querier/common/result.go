@@ -26,17 +26,31 @@ const (
 )
 
 type Result struct {
-	Columns []interface{}
-	Values  []interface{}
-	Schemas ColumnSchemas
+	Columns    []interface{}
+	Values     []interface{}
+	Schemas    ColumnSchemas
+	NextCursor string
 }
 
 func (r *Result) ToJson() map[string]interface{} {
-	return map[string]interface{}{
+	jsonData := map[string]interface{}{
 		"columns": r.Columns,
 		"values":  r.Values,
 		"schemas": r.Schemas.ToArray(),
 	}
+	if r.NextCursor != "" {
+		jsonData["next_cursor"] = r.NextCursor
+	}
+	return jsonData
+}
+
+// ResultWriter writes the result of a query batch by batch instead of returning it as a whole
+type ResultWriter interface {
+	// WriteHeader is called once before the first batch of rows
+	WriteHeader(columns []interface{}, schemas ColumnSchemas) error
+	WriteRows(values []interface{}) error
+	HeaderWritten() bool
+	Close() error
 }
 
 type ColumnSchema struct {
@@ -18,8 +18,10 @@ package common
 
 import (
 	"bufio"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"strings"
 
 	"golang.org/x/exp/slices"
@@ -111,3 +113,38 @@ func TransMapItem(name, table string) (nameNoPreffix string, nameNoSuffix string
 	}
 	return
 }
+
+// ValueToFloat64 converts a number or a pointer to number in the result rows to float64, nil is not converted
+func ValueToFloat64(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// ValueToString converts a value or a pointer to value in the result rows to string, nil is converted to ""
+func ValueToString(value interface{}) string {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		return fmt.Sprint(v.Elem().Interface())
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -62,6 +62,8 @@ type QuerierConfig struct {
 	MaxPrometheusIdSubqueryLruEntry int                           `default:"8000" yaml:"max-prometheus-id-subquery-lru-entry"`
 	PrometheusIdSubqueryLruTimeout  int                           `default:"60" yaml:"prometheus-id-subquery-lru-timeout"`
 	AutoCustomTags                  []AutoCustomTags              `yaml:"auto-custom-tags" binding:"omitempty,dive"`
+	QueryGovernance                 QueryGovernance               `yaml:"query-governance"`
+	SplitCache                      SplitCache                    `yaml:"split-cache"`
 }
 
 type DeepflowApp struct {
@@ -91,6 +93,63 @@ type Clickhouse struct {
 	MaxConnection  int    `default:"20" yaml:"max-connection"`
 	UseQueryCache  bool   `default:"true" yaml:"use-query-cache"`
 	QueryCacheTTL  string `default:"600" yaml:"query-cache-ttl"`
+	// replicas of the default cluster as host:port, Host and Port are used if empty
+	Endpoints           []string            `yaml:"endpoints"`
+	LoadBalance         string              `default:"round-robin" yaml:"load-balance"` // round-robin, least-inflight or in-order
+	HealthCheckInterval int                 `default:"10" yaml:"health-check-interval"` // unit: s, 0 means disabled
+	Clusters            []ClickhouseCluster `yaml:"clusters"`
+}
+
+// ClickhouseCluster is a named group of replicas, such as the ClickHouse of another region,
+// queries can be federated across clusters by the clusters parameter
+type ClickhouseCluster struct {
+	Name        string   `yaml:"name"`
+	Endpoints   []string `yaml:"endpoints"`
+	LoadBalance string   `yaml:"load-balance"` // load-balance of the default cluster is used if empty
+}
+
+type QueryGovernance struct {
+	Enabled bool             `default:"false" yaml:"enabled"`
+	Default QueryLimit       `yaml:"default"`
+	Rules   []QueryLimitRule `yaml:"rules"`
+	// queries cost more than the threshold are written to event.slow_query_event, unit: ms, 0 means disabled
+	SlowQueryThreshold int `default:"10000" yaml:"slow-query-threshold"`
+}
+
+// QueryLimit limits the queries of an org or a user, 0 means no limit
+type QueryLimit struct {
+	MaxConcurrentQueries int    `yaml:"max-concurrent-queries"`
+	MaxTimeRange         int    `yaml:"max-time-range"` // unit: s
+	MaxRowsToRead        uint64 `yaml:"max-rows-to-read"`
+	MaxExecutionTime     int    `yaml:"max-execution-time"` // unit: s
+}
+
+// QueryLimitRule overrides the default limit for an org, or a user of an org if UserID is set
+type QueryLimitRule struct {
+	ORGID      string `yaml:"org-id"`
+	UserID     string `yaml:"user-id"`
+	QueryLimit `yaml:",inline"`
+}
+
+// SplitCache caches the results of SQL grouped by time(time, interval) in aligned segments, only the segments
+// not cached and the fresh tail are queried
+type SplitCache struct {
+	Enabled        bool            `default:"false" yaml:"enabled"`
+	SegmentSize    int             `default:"3600" yaml:"segment-size"`   // unit: s, rounded up to a multiple of the interval
+	ImmutableDelay int             `default:"300" yaml:"immutable-delay"` // unit: s, segments end before now-delay are cached
+	MaxMemory      int             `default:"256" yaml:"max-memory"`      // unit: MB
+	MaxEntries     int             `default:"100000" yaml:"max-entries"`  // max count of segments in memory
+	TTL            int             `default:"86400" yaml:"ttl"`           // unit: s
+	Redis          SplitCacheRedis `yaml:"redis"`
+}
+
+// SplitCacheRedis is the optional shared backend of the split cache, so that the replicas of querier share segments
+type SplitCacheRedis struct {
+	Enabled  bool   `default:"false" yaml:"enabled"`
+	Addr     string `default:"redis:6379" yaml:"addr"`
+	Password string `default:"" yaml:"password"`
+	DB       int    `default:"0" yaml:"db"`
+	Timeout  int    `default:"1" yaml:"timeout"` // unit: s
 }
 
 type AutoCustomTags struct {
@@ -30,6 +30,7 @@ import (
 	//"github.com/k0kubun/pp"
 	logging "github.com/op/go-logging"
 	"github.com/xwb1989/sqlparser"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/exp/slices"
 
 	"github.com/deepflowio/deepflow/server/querier/common"
@@ -101,8 +102,20 @@ type CHEngine struct {
 	TargetLabelFilters []TargetLabelFilter
 	NoPreWhere         bool
 	IsDerivative       bool
+	HasWindowFunction  bool
 	DerivativeGroupBy  []string
 	ORGID              string
+	subTimeRanges      []client.TimeRange // time ranges of the sub queries translated by sub engines
+	mergeFuncs         map[string]string  // metric column -> client.MERGE_*, if it can be re-aggregated across clusters
+	stream             bool               // results are streamed to args.ResultWriter, the default limit is not applied
+}
+
+// aggregate functions whose results of clusters can be re-aggregated by federated queries with merge_clusters
+var federationMergeFuncs = map[string]string{
+	view.FUNCTION_SUM:   client.MERGE_SUM,
+	view.FUNCTION_COUNT: client.MERGE_SUM,
+	view.FUNCTION_MAX:   client.MERGE_MAX,
+	view.FUNCTION_MIN:   client.MERGE_MIN,
 }
 
 func init() {
@@ -113,12 +126,26 @@ func init() {
 }
 
 func (e *CHEngine) ExecuteQuery(args *common.QuerierParams) (*common.Result, map[string]interface{}, error) {
+	ctx, span := startSpan(args.Context, "ExecuteQuery",
+		attribute.String("query_uuid", args.QueryUUID),
+		attribute.String("db", args.DB),
+		attribute.String("org_id", args.ORGID),
+		attribute.String("sql", args.Sql),
+	)
+	args.Context = ctx
+	result, debug, err := e.executeQuery(args)
+	endSpan(span, err)
+	return result, debug, err
+}
+
+func (e *CHEngine) executeQuery(args *common.QuerierParams) (*common.Result, map[string]interface{}, error) {
 	// 解析show开头的sql
 	// show metrics/tags from <table_name> 例：show metrics/tags from l4_flow_log
 	var err error
 	sql := args.Sql
 	e.Context = args.Context
 	e.NoPreWhere = args.NoPreWhere
+	e.stream = args.ResultWriter != nil
 	e.ORGID = common.DEFAULT_ORG_ID
 	if args.ORGID != "" {
 		e.ORGID = args.ORGID
@@ -126,6 +153,11 @@ func (e *CHEngine) ExecuteQuery(args *common.QuerierParams) (*common.Result, map
 	query_uuid := args.QueryUUID // FIXME: should be queryUUID
 	log.Debugf("query_uuid: %s | raw sql: %s", query_uuid, sql)
 	debug_info := &client.DebugInfo{}
+	if args.PageSize > 0 {
+		if err := CheckPaginationSql(sql); err != nil {
+			return nil, nil, err
+		}
+	}
 	// Parse withSql
 	withResult, withDebug, err := e.QueryWithSql(sql, args)
 	if err != nil {
@@ -150,6 +182,18 @@ func (e *CHEngine) ExecuteQuery(args *common.QuerierParams) (*common.Result, map
 		debug_info.Debug = append(debug_info.Debug, *slimitDebug)
 		return slimitResult, debug_info.Get(), err
 	}
+	// Parse offsetSql
+	offsetResult, offsetDebug, err := e.QueryOffsetSql(sql, args)
+	if err != nil {
+		if offsetDebug != nil {
+			debug_info.Debug = append(debug_info.Debug, *offsetDebug)
+		}
+		return nil, debug_info.Get(), err
+	}
+	if offsetResult != nil {
+		debug_info.Debug = append(debug_info.Debug, *offsetDebug)
+		return offsetResult, debug_info.Get(), err
+	}
 	// Parse showSql
 	debug := &client.Debug{
 		IP:        config.Cfg.Clickhouse.Host,
@@ -196,23 +240,38 @@ func (e *CHEngine) ExecuteQuery(args *common.QuerierParams) (*common.Result, map
 			parser.Engine = e
 			usedEngine = e
 		}
+		_, translateSpan := startSpan(e.Context, "TranslateSQL", attribute.String("sql", sql1))
 		err = parser.ParseSQL(sql1)
 		if err != nil {
 			errorMessage := fmt.Sprintf("sql: %s; parse error: %s", sql1, err.Error())
 			log.Error(errorMessage)
+			endSpan(translateSpan, err)
 			return nil, nil, err
 		}
 		// To do
 		for _, stmt := range usedEngine.Statements {
 			stmt.Format(usedEngine.Model)
 		}
-		FormatModel(usedEngine.Model)
+		if isShow {
+			FormatModel(usedEngine.Model)
+		} else {
+			e.formatModel(usedEngine.Model)
+		}
+		if !isShow && args.PageSize > 0 {
+			err = usedEngine.TransPagination(args.PageSize, args.Cursor)
+			if err != nil {
+				endSpan(translateSpan, err)
+				return nil, nil, err
+			}
+		}
 		// 使用Model生成View
 		usedEngine.View = view.NewView(usedEngine.Model)
 		if !isShow {
 			usedEngine.View.NoPreWhere = usedEngine.NoPreWhere
 		}
 		chSql := usedEngine.ToSQLString()
+		translateSpan.SetAttributes(attribute.String("clickhouse.sql", chSql))
+		endSpan(translateSpan, nil)
 		log.Debug(chSql)
 		callbacks := usedEngine.View.GetCallbacks()
 		debug.Sql = chSql
@@ -229,11 +288,27 @@ func (e *CHEngine) ExecuteQuery(args *common.QuerierParams) (*common.Result, map
 			QueryUUID:       query_uuid,
 			ColumnSchemaMap: ColumnSchemaMap,
 			ORGID:           args.ORGID,
+			UserID:          args.UserID,
 		}
 		if !isShow {
 			params.Callbacks = callbacks
+			params.ResultWriter = args.ResultWriter
+			params.Clusters = args.Clusters
+			params.TimeRanges = []client.TimeRange{usedEngine.timeRange()}
+			if len(args.Clusters) > 0 {
+				params.Federation, err = usedEngine.federation(args.MergeClusters)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		var result *common.Result
+		if !isShow {
+			result, err = e.QuerySplitCache(sql1, args, params, &chClient)
+		}
+		if result == nil && err == nil {
+			result, err = chClient.DoQuery(params)
 		}
-		result, err := chClient.DoQuery(params)
 		if err != nil {
 			log.Error(err)
 			debug_info.Debug = append(debug_info.Debug, *debug)
@@ -244,6 +319,7 @@ func (e *CHEngine) ExecuteQuery(args *common.QuerierParams) (*common.Result, map
 			results.Columns = result.Columns
 			if !isShow {
 				results.Schemas = result.Schemas
+				results.NextCursor = result.NextCursor
 			}
 			debug_info.Debug = append(debug_info.Debug, *debug)
 		}
@@ -471,6 +547,10 @@ func (e *CHEngine) ParseShowSql(sql string, args *common.QuerierParams, DebugInf
 }
 
 func (e *CHEngine) QuerySlimitSql(sql string, args *common.QuerierParams) (*common.Result, *client.Debug, error) {
+	e.subTimeRanges = nil
+	if len(args.Clusters) > 0 && (strings.Contains(sql, "SLIMIT") || strings.Contains(sql, "slimit")) {
+		return nil, nil, common.NewError(common.INVALID_PARAMETERS, "SLIMIT is not supported by federated queries")
+	}
 	sql, callbacks, columnSchemaMap, err := e.ParseSlimitSql(sql, args)
 	if err != nil {
 		log.Error(err)
@@ -502,6 +582,9 @@ func (e *CHEngine) QuerySlimitSql(sql string, args *common.QuerierParams) (*comm
 		QueryUUID:       query_uuid,
 		ColumnSchemaMap: columnSchemaMap,
 		ORGID:           args.ORGID,
+		UserID:          args.UserID,
+		ResultWriter:    args.ResultWriter,
+		TimeRanges:      e.subTimeRanges,
 	}
 	rst, err := chClient.DoQuery(params)
 	if err != nil {
@@ -770,6 +853,7 @@ func (e *CHEngine) ParseSlimitSql(sql string, args *common.QuerierParams) (strin
 		// 使用Model生成View
 		innerEngine.View = view.NewView(innerEngine.Model)
 		innerTransSql = innerEngine.ToSQLString()
+		e.subTimeRanges = append(e.subTimeRanges, innerEngine.timeRange())
 	}
 	outerEngine := &CHEngine{DB: e.DB, DataSource: e.DataSource, Context: e.Context, ORGID: e.ORGID}
 	outerEngine.Init()
@@ -786,10 +870,11 @@ func (e *CHEngine) ParseSlimitSql(sql string, args *common.QuerierParams) (strin
 	for _, stmt := range outerEngine.Statements {
 		stmt.Format(outerEngine.Model)
 	}
-	FormatModel(outerEngine.Model)
+	e.formatModel(outerEngine.Model)
 	// 使用Model生成View
 	outerEngine.View = view.NewView(outerEngine.Model)
 	outerTransSql := outerEngine.ToSQLString()
+	e.subTimeRanges = append(e.subTimeRanges, outerEngine.timeRange())
 	outerSlice := []string{}
 	outerWhereLeftSql := strings.Join(outerWhereLeftSlice, ",")
 	outerSql := ""
@@ -831,6 +916,10 @@ func (e *CHEngine) ParseSlimitSql(sql string, args *common.QuerierParams) (strin
 }
 
 func (e *CHEngine) QueryWithSql(sql string, args *common.QuerierParams) (*common.Result, *client.Debug, error) {
+	e.subTimeRanges = nil
+	if len(args.Clusters) > 0 && checkWithSqlRegexp.MatchString(sql) {
+		return nil, nil, common.NewError(common.INVALID_PARAMETERS, "WITH is not supported by federated queries")
+	}
 	sql, callbacks, columnSchemaMap, err := e.ParseWithSql(sql)
 	if err != nil {
 		log.Error(err)
@@ -863,6 +952,9 @@ func (e *CHEngine) QueryWithSql(sql string, args *common.QuerierParams) (*common
 		QueryUUID:       query_uuid,
 		ColumnSchemaMap: columnSchemaMap,
 		ORGID:           args.ORGID,
+		UserID:          args.UserID,
+		ResultWriter:    args.ResultWriter,
+		TimeRanges:      e.subTimeRanges,
 	}
 	rst, err := chClient.DoQuery(params)
 	if err != nil {
@@ -894,13 +986,14 @@ func (e *CHEngine) ParseWithSql(sql string) (string, map[string]func(*common.Res
 		for _, stmt := range matchEngine.Statements {
 			stmt.Format(matchEngine.Model)
 		}
-		FormatModel(matchEngine.Model)
+		e.formatModel(matchEngine.Model)
 		// 使用Model生成View
 		matchEngine.View = view.NewView(matchEngine.Model)
 		if callbacks == nil {
 			callbacks = matchEngine.View.GetCallbacks()
 		}
 		parsedSql := matchEngine.ToSQLString()
+		e.subTimeRanges = append(e.subTimeRanges, matchEngine.timeRange())
 		for _, columnSchema := range matchEngine.ColumnSchemas {
 			columnSchemaMap[columnSchema.Name] = columnSchema
 		}
@@ -912,6 +1005,64 @@ func (e *CHEngine) ParseWithSql(sql string) (string, map[string]func(*common.Res
 	return sql, callbacks, columnSchemaMap, nil
 }
 
+// federation returns how rows of the clusters are merged. By default rows are tagged by their cluster, which is an
+// extra group key of aggregated queries, and they are sorted and limited again if the order by columns are selected,
+// nil means rows are only concatenated.
+// If merge is set, rows of aggregated queries with the same group keys are re-aggregated across the clusters, in
+// which case aggregate functions other than Sum, Count, Max and Min can not be merged and are rejected.
+func (e *CHEngine) federation(merge bool) (*client.Federation, error) {
+	aggregated := !e.Model.Groups.IsNull()
+	columns := make(map[string]bool, len(e.ColumnSchemas))
+	merges := make(map[string]string)
+	for _, schema := range e.ColumnSchemas {
+		columns[schema.Name] = true
+		if schema.Type != common.COLUMN_SCHEMA_TYPE_METRICS {
+			continue
+		}
+		aggregated = true
+		if !merge {
+			continue
+		}
+		mergeFunc, ok := e.mergeFuncs[schema.Name]
+		if !ok {
+			return nil, common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("%s can not be merged across clusters, only Sum, Count, Max and Min are supported by merge_clusters", schema.Name))
+		}
+		merges[schema.Name] = mergeFunc
+	}
+	federation := &client.Federation{}
+	if merge && aggregated {
+		federation.Merges = merges
+	}
+	for _, node := range e.Model.Orders.Orders {
+		order := node.(*view.Order)
+		column := strings.Trim(order.SortBy, "`")
+		if !columns[column] {
+			if federation.Merges != nil {
+				return nil, common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("order by %s must be selected by merge_clusters", column))
+			}
+			return nil, nil
+		}
+		federation.Orders = append(federation.Orders, client.FederationOrder{Column: column, Desc: strings.EqualFold(order.OrderBy, "desc")})
+	}
+	if federation.Merges == nil && len(federation.Orders) == 0 {
+		// rows tagged by their cluster are not sorted, all rows of each cluster are kept
+		return nil, nil
+	}
+	if e.Model.Limit.Offset != "" {
+		if federation.Merges != nil {
+			return nil, common.NewError(common.INVALID_PARAMETERS, "limit with offset is not supported by merge_clusters")
+		}
+		return nil, nil
+	}
+	federation.Limit, _ = strconv.Atoi(e.Model.Limit.Limit)
+	return federation, nil
+}
+
+// timeRange returns the time range of the translated sql, which is limited by query governance
+func (e *CHEngine) timeRange() client.TimeRange {
+	return client.TimeRange{Start: e.Model.Time.TimeStart, End: e.Model.Time.TimeEnd}
+}
+
 func (e *CHEngine) Init() {
 	e.Model = view.NewModel()
 	e.Model.DB = e.DB
@@ -943,6 +1094,13 @@ func (e *CHEngine) TransSelect(tags sqlparser.SelectExprs) error {
 			}
 		}
 	}
+	// window functions are calculated over time buckets
+	if windowFunction, hasTime := e.getSelectWindowFunction(tags); windowFunction != "" {
+		if !hasTime {
+			return fmt.Errorf("function [%s] requires time(time, interval) in select and group by", windowFunction)
+		}
+		e.HasWindowFunction = true
+	}
 	// tap_port and tap_port_type must exist together in select
 	if (common.IsValueInSliceString("tap_port", tagSlice) || common.IsValueInSliceString("capture_nic", tagSlice)) && !common.IsValueInSliceString("tap_port_type", tagSlice) && !common.IsValueInSliceString("capture_nic_type", tagSlice) && !common.IsValueInSliceString("enum(tap_port_type)", tagSlice) && !common.IsValueInSliceString("enum(capture_nic_type)", tagSlice) {
 		return errors.New("tap_port(capture_nic) and tap_port_type(capture_nic_type) must exist together in select")
@@ -1184,7 +1342,12 @@ func (e *CHEngine) TransFrom(froms sqlparser.TableExprs) error {
 				e.Statements = append(e.Statements, &whereStmt)
 				table = "samples"
 			}
-			interval, err := chCommon.GetDatasourceInterval(e.DB, e.Table, e.DataSource, e.ORGID)
+			dataSource := e.DataSource
+			if e.DB == chCommon.DB_NAME_PROMETHEUS && dataSource == chCommon.DATASOURCE_NATIVE_HISTOGRAM {
+				table = chCommon.TABLE_NAME_HISTOGRAM_SAMPLES
+				dataSource = ""
+			}
+			interval, err := chCommon.GetDatasourceInterval(e.DB, e.Table, dataSource, e.ORGID)
 			if err != nil {
 				log.Error(err)
 				return err
@@ -1201,8 +1364,8 @@ func (e *CHEngine) TransFrom(froms sqlparser.TableExprs) error {
 					newDB = fmt.Sprintf("%04d_%s", orgIDInt, e.DB)
 				}
 			}
-			if e.DataSource != "" {
-				e.AddTable(fmt.Sprintf("%s.`%s.%s`", newDB, table, e.DataSource))
+			if dataSource != "" {
+				e.AddTable(fmt.Sprintf("%s.`%s.%s`", newDB, table, dataSource))
 			} else {
 				e.AddTable(fmt.Sprintf("%s.`%s`", newDB, table))
 			}
@@ -1399,6 +1562,30 @@ func (e *CHEngine) parseGroupBy(group sqlparser.Expr) error {
 }
 
 // 解析Select
+// getSelectWindowFunction returns the first window function in select and whether time(time, interval) is selected
+func (e *CHEngine) getSelectWindowFunction(tags sqlparser.SelectExprs) (windowFunction string, hasTime bool) {
+	for _, tag := range tags {
+		item, ok := tag.(*sqlparser.AliasedExpr)
+		if !ok {
+			continue
+		}
+		sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			funcExpr, ok := node.(*sqlparser.FuncExpr)
+			if !ok {
+				return true, nil
+			}
+			name := strings.Trim(sqlparser.String(funcExpr.Name), "`")
+			if name == TAG_FUNCTION_TIME {
+				hasTime = true
+			} else if windowFunction == "" && slices.Contains(view.WINDOW_FUNCTIONS, name) {
+				windowFunction = name
+			}
+			return true, nil
+		}, item.Expr)
+	}
+	return
+}
+
 func (e *CHEngine) parseSelect(tag sqlparser.SelectExpr) error {
 	// 解析select内容
 	switch tag := tag.(type) {
@@ -1483,6 +1670,12 @@ func (e *CHEngine) parseSelectAlias(item *sqlparser.AliasedExpr) error {
 			e.SetLevelFlag(levelFlag)
 			e.Statements = append(e.Statements, function)
 			e.ColumnSchemas[len(e.ColumnSchemas)-1].Type = common.COLUMN_SCHEMA_TYPE_METRICS
+			if merge, ok := federationMergeFuncs[name]; ok {
+				if e.mergeFuncs == nil {
+					e.mergeFuncs = make(map[string]string)
+				}
+				e.mergeFuncs[e.ColumnSchemas[len(e.ColumnSchemas)-1].Name] = merge
+			}
 			if unit != "" {
 				e.ColumnSchemas[len(e.ColumnSchemas)-1].Unit = unit
 			}
@@ -1893,6 +2086,14 @@ func FormatModel(m *view.Model) {
 	FormatLimit(m)
 }
 
+// formatModel formats the model of a query translated by e, stream queries only keep an explicit LIMIT
+func (e *CHEngine) formatModel(m *view.Model) {
+	if e.stream && m.Limit.Limit == "" {
+		m.Limit.Limit = common.NO_LIMIT
+	}
+	FormatModel(m)
+}
+
 func FormatLimit(m *view.Model) {
 	if m.Limit.Limit == "" {
 		defaultLimit := DEFAULT_LIMIT
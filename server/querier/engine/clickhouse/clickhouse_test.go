@@ -239,6 +239,28 @@ var (
 		input:  "select Sum(log_count) from event",
 		output: []string{"SELECT SUM(1) AS `Sum(log_count)` FROM event.`event` LIMIT 10000"},
 		db:     "event",
+	}, {
+		input:  "select query_uuid, sql, duration, read_rows from slow_query_event where time>=60 and time<=180 and user_id=3 order by duration desc limit 10",
+		output: []string{"SELECT query_uuid, sql, duration, read_rows FROM event.`slow_query_event` PREWHERE `time` >= 60 AND `time` <= 180 AND user_id = 3 ORDER BY `duration` desc LIMIT 10"},
+		db:     "event",
+	}, {
+		input:  "select Max(duration) as max_duration, db from slow_query_event group by db",
+		output: []string{"SELECT db, MAXIf(duration, duration > 0) AS `max_duration` FROM event.`slow_query_event` GROUP BY `db` LIMIT 10000"},
+		db:     "event",
+	}, {
+		input:  "select country_1, as_org_1, Count(row) as c from l4_flow_log where country_0='US' and asn_1=15169 group by country_1, as_org_1",
+		output: []string{"SELECT country_1, as_org_1, COUNT(1) AS `c` FROM flow_log.`l4_flow_log` PREWHERE country_0 = 'US' AND asn_1 = 15169 GROUP BY `country_1`, `as_org_1` LIMIT 10000"},
+	}, {
+		input:  "select city_0, Count(row) as c from l7_flow_log where city_0 != '' group by city_0",
+		output: []string{"SELECT city_0, COUNT(1) AS `c` FROM flow_log.`l7_flow_log` PREWHERE city_0 != '' GROUP BY `city_0` LIMIT 10000"},
+	}, {
+		input:  "select app_service, Sum(self_time) as sum_self_time from trace_analysis where root_app_service='checkout' group by app_service order by sum_self_time desc limit 10",
+		output: []string{"SELECT app_service, SUMIf(self_time, self_time > 0) AS `sum_self_time` FROM flow_log.`trace_analysis` PREWHERE root_app_service = 'checkout' GROUP BY `app_service` ORDER BY `sum_self_time` desc LIMIT 10"},
+		db:     "flow_log",
+	}, {
+		input:  "select trace_id, critical_path, trace_duration from trace_analysis where is_error_origin=1 and app_service='payment' limit 10",
+		output: []string{"SELECT trace_id, critical_path, trace_duration FROM flow_log.`trace_analysis` PREWHERE is_error_origin = 1 AND app_service = 'payment' LIMIT 10"},
+		db:     "flow_log",
 	}, {
 		input:  "select Sum(session_length) from l7_flow_log",
 		output: []string{"SELECT SUM(if(request_length>0,request_length,0)+if(response_length>0,response_length,0)) AS `Sum(session_length)` FROM flow_log.`l7_flow_log` LIMIT 10000"},
@@ -588,6 +610,50 @@ var (
 		db:     "_prometheus",
 		input:  "SHOW tag-values",
 		output: []string{"SELECT field_name AS `label_name`, field_value AS `label_value` FROM flow_tag.`prometheus_custom_field_value` GROUP BY `label_name`, `label_value` ORDER BY `label_name` asc LIMIT 10000"},
+	}, {
+		name:   "test_window_moving_avg",
+		db:     "flow_metrics",
+		input:  "select pod, MovingAvg(Avg(rrt), 5) as rrt_ma, time(time, 60) as toi from application where time>=1700000000 and time<=1700003600 group by pod, toi limit 10",
+		output: []string{"WITH toStartOfInterval(time, toIntervalSecond(60)) + toIntervalSecond(arrayJoin([0]) * 60) AS `_toi`, if(SUMIf(rrt_count, rrt_count>0)>0, divide(SUM(rrt_sum), SUMIf(rrt_count, rrt_count>0)), null) AS `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` SELECT dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod`, toUnixTimestamp(`_toi`) AS `toi`, avg(`divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0`) OVER (PARTITION BY `pod` ORDER BY `toi` RANGE BETWEEN 240 PRECEDING AND CURRENT ROW) AS `rrt_ma` FROM flow_metrics.`application` WHERE `time` >= 1700000000 AND `time` <= 1700003600 AND (pod_id!=0) GROUP BY `toi`, dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod` LIMIT 10"},
+	}, {
+		name:   "test_window_cumulative_sum",
+		db:     "flow_metrics",
+		input:  "select CumulativeSum(Sum(byte)) as byte_total, Difference(Sum(byte)) as byte_diff, time(time, 60) as toi from network where time>=1700000000 and time<=1700003600 group by toi limit 10",
+		output: []string{"WITH toStartOfInterval(time, toIntervalSecond(60)) + toIntervalSecond(arrayJoin([0]) * 60) AS `_toi` SELECT toUnixTimestamp(`_toi`) AS `toi`, sum(SUM(byte)) OVER (ORDER BY `toi` ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS `byte_total`, minus(SUM(byte), lagInFrame(toNullable(SUM(byte))) OVER (ORDER BY `toi` ROWS BETWEEN 1 PRECEDING AND CURRENT ROW)) AS `byte_diff` FROM flow_metrics.`network` WHERE `time` >= 1700000000 AND `time` <= 1700003600 GROUP BY `toi` LIMIT 10"},
+	}, {
+		name:   "test_window_ewma_rate",
+		input:  "select EWMA(Sum(byte), 0.5) as byte_ewma, Rate(Max(byte)) as byte_rate, region_0, time(time, 120) as time_120 from l4_flow_log where time>=1700000000 and time<=1700003600 group by region_0, time_120 limit 10",
+		output: []string{"WITH toStartOfInterval(time, toIntervalSecond(120)) + toIntervalSecond(arrayJoin([0]) * 120) AS `_time_120` SELECT dictGet('flow_tag.region_map', 'name', (toUInt64(region_id_0))) AS `region_0`, toUnixTimestamp(`_time_120`) AS `time_120`, exponentialTimeDecayedAvg(1.4426950408889634)(SUM(byte_tx+byte_rx), `time_120`/120) OVER (PARTITION BY `region_0` ORDER BY `time_120` ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS `byte_ewma`, divide(minus(MAX(byte_tx+byte_rx), lagInFrame(toNullable(MAX(byte_tx+byte_rx))) OVER (PARTITION BY `region_0` ORDER BY `time_120` ROWS BETWEEN 1 PRECEDING AND CURRENT ROW)), minus(`time_120`, lagInFrame(toNullable(`time_120`)) OVER (PARTITION BY `region_0` ORDER BY `time_120` ROWS BETWEEN 1 PRECEDING AND CURRENT ROW))) AS `byte_rate` FROM flow_log.`l4_flow_log` PREWHERE `time` >= 1700000000 AND `time` <= 1700003600 GROUP BY `time_120`, dictGet('flow_tag.region_map', 'name', (toUInt64(region_id_0))) AS `region_0` LIMIT 10"},
+	}, {
+		name:    "test_window_without_time",
+		db:      "flow_metrics",
+		input:   "select pod, CumulativeSum(Sum(byte)) as byte_total from network group by pod",
+		wantErr: "function [CumulativeSum] requires time(time, interval) in select and group by",
+	}, {
+		name:    "test_window_invalid_alpha",
+		db:      "flow_metrics",
+		input:   "select EWMA(Sum(byte), 1.5) as byte_ewma, time(time, 60) as toi from network group by toi",
+		wantErr: "function [EWMA] argument [1.5] should be within (0, 1)",
+	}, {
+		name:   "test_offset",
+		db:     "flow_metrics",
+		input:  "select pod, Avg(rrt) as rrt, Avg(rrt) OFFSET 1d as rrt_yesterday, Avg(rrt) / (Avg(rrt) OFFSET 1d) as rrt_ratio, time(time, 60) as toi from application where time>=1700000000 and time<=1700003600 group by pod, toi order by toi limit 10",
+		output: []string{"SELECT b.`pod` AS `pod`, b.`rrt` AS `rrt`, o0.`__offset_0` AS `rrt_yesterday`, b.`__base_2` / (o0.`__offset_1`) AS `rrt_ratio`, b.`toi` AS `toi` FROM (WITH toStartOfInterval(time, toIntervalSecond(60)) + toIntervalSecond(arrayJoin([0]) * 60) AS `_toi`, if(SUMIf(rrt_count, rrt_count>0)>0, divide(SUM(rrt_sum), SUMIf(rrt_count, rrt_count>0)), null) AS `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` SELECT dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod`, toUnixTimestamp(`_toi`) AS `toi`, `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` AS `rrt`, `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` AS `__base_2` FROM flow_metrics.`application` WHERE `time` >= 1700000000 AND `time` <= 1700003600 AND (pod_id!=0) GROUP BY `toi`, dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod` ORDER BY `toi` asc LIMIT 10) AS b LEFT JOIN (SELECT *, `toi` + 86400 AS `__offset_time` FROM (WITH toStartOfInterval(time, toIntervalSecond(60)) + toIntervalSecond(arrayJoin([0]) * 60) AS `_toi`, if(SUMIf(rrt_count, rrt_count>0)>0, divide(SUM(rrt_sum), SUMIf(rrt_count, rrt_count>0)), null) AS `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` SELECT dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod`, toUnixTimestamp(`_toi`) AS `toi`, `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` AS `__offset_0`, `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` AS `__offset_1` FROM flow_metrics.`application` WHERE `time` >= 1699913600 AND `time` <= 1699917200 AND (pod_id!=0) GROUP BY `toi`, dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod` HAVING (`pod`, `toi` + 86400) IN (SELECT `pod`, `toi` FROM (WITH toStartOfInterval(time, toIntervalSecond(60)) + toIntervalSecond(arrayJoin([0]) * 60) AS `_toi`, if(SUMIf(rrt_count, rrt_count>0)>0, divide(SUM(rrt_sum), SUMIf(rrt_count, rrt_count>0)), null) AS `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` SELECT dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod`, toUnixTimestamp(`_toi`) AS `toi`, `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` AS `rrt`, `divide_0diveider_as_null_sum_rrt_sum_sum_rrt_count_rrt_count>0` AS `__base_2` FROM flow_metrics.`application` WHERE `time` >= 1700000000 AND `time` <= 1700003600 AND (pod_id!=0) GROUP BY `toi`, dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod` ORDER BY `toi` asc LIMIT 10)) LIMIT 10)) AS o0 ON b.`pod` = o0.`pod` AND b.`toi` = o0.`__offset_time` ORDER BY `toi` SETTINGS join_use_nulls = 1"},
+	}, {
+		name:   "test_offset_over_default_limit",
+		db:     "flow_metrics",
+		input:  "select pod, Sum(byte) as byte, Sum(byte) OFFSET 1d as byte_yesterday, time(time, 60) as toi from network where time>=1700000000 and time<=1700003600 group by pod, toi limit 20000",
+		output: []string{"SELECT b.`pod` AS `pod`, b.`byte` AS `byte`, o0.`__offset_0` AS `byte_yesterday`, b.`toi` AS `toi` FROM (WITH toStartOfInterval(time, toIntervalSecond(60)) + toIntervalSecond(arrayJoin([0]) * 60) AS `_toi` SELECT dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod`, toUnixTimestamp(`_toi`) AS `toi`, SUM(byte) AS `byte` FROM flow_metrics.`network` WHERE `time` >= 1700000000 AND `time` <= 1700003600 AND (pod_id!=0) GROUP BY `toi`, dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod` LIMIT 20000) AS b LEFT JOIN (SELECT *, `toi` + 86400 AS `__offset_time` FROM (WITH toStartOfInterval(time, toIntervalSecond(60)) + toIntervalSecond(arrayJoin([0]) * 60) AS `_toi` SELECT dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod`, toUnixTimestamp(`_toi`) AS `toi`, SUM(byte) AS `__offset_0` FROM flow_metrics.`network` WHERE `time` >= 1699913600 AND `time` <= 1699917200 AND (pod_id!=0) GROUP BY `toi`, dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod` HAVING (`pod`, `toi` + 86400) IN (SELECT `pod`, `toi` FROM (WITH toStartOfInterval(time, toIntervalSecond(60)) + toIntervalSecond(arrayJoin([0]) * 60) AS `_toi` SELECT dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod`, toUnixTimestamp(`_toi`) AS `toi`, SUM(byte) AS `byte` FROM flow_metrics.`network` WHERE `time` >= 1700000000 AND `time` <= 1700003600 AND (pod_id!=0) GROUP BY `toi`, dictGet('flow_tag.pod_map', 'name', (toUInt64(pod_id))) AS `pod` LIMIT 20000)) LIMIT 20000)) AS o0 ON b.`pod` = o0.`pod` AND b.`toi` = o0.`__offset_time` SETTINGS join_use_nulls = 1"},
+	}, {
+		name:   "test_offset_without_group",
+		db:     "flow_metrics",
+		input:  "select Sum(byte) - Sum(byte) OFFSET 1w as byte_diff from network where time>=1700000000 and time<=1700003600",
+		output: []string{"SELECT b.`__base_1` - o0.`__offset_0` AS `byte_diff` FROM (SELECT SUM(byte) AS `__base_1` FROM flow_metrics.`network` WHERE `time` >= 1700000000 AND `time` <= 1700003600 LIMIT 10000) AS b CROSS JOIN (SELECT SUM(byte) AS `__offset_0` FROM flow_metrics.`network` WHERE `time` >= 1699395200 AND `time` <= 1699398800 LIMIT 10000) AS o0 SETTINGS join_use_nulls = 1"},
+	}, {
+		name:    "test_offset_without_alias",
+		db:      "flow_metrics",
+		input:   "select Avg(rrt) OFFSET 1d from application where time>=1700000000 and time<=1700003600",
+		wantErr: "metric with OFFSET requires an alias: Avg(rrt) OFFSET 1d",
 	}}
 )
 
@@ -629,6 +695,9 @@ func TestGetSql(t *testing.T) {
 		} else if strings.Contains(pcase.input, "SLIMIT") || strings.Contains(pcase.input, "slimit") {
 			outSql, _, _, err = e.ParseSlimitSql(pcase.input, args)
 			out = append(out, outSql)
+		} else if checkOffsetSqlRegexp.MatchString(pcase.input) {
+			outSql, _, _, err = e.ParseOffsetSql(pcase.input, args)
+			out = append(out, outSql)
 		} else {
 			DebugInfo := &client.DebugInfo{}
 			if strings.HasPrefix(pcase.input, "SHOW") {
@@ -728,3 +797,139 @@ func convertNameToInterval(name string) (interval int) {
 		return 0
 	}
 }
+
+func TestFederation(t *testing.T) {
+	Load()
+	cases := []struct {
+		input      string
+		merge      bool
+		federation *client.Federation
+		wantErr    bool
+	}{{
+		input: "select byte from l4_flow_log limit 10",
+	}, {
+		input: "select byte from l4_flow_log order by byte desc limit 10",
+		federation: &client.Federation{
+			Orders: []client.FederationOrder{{Column: "byte", Desc: true}},
+			Limit:  10,
+		},
+	}, {
+		input: "select Avg(rtt) as avg_rtt, pod from l4_flow_log group by pod order by avg_rtt desc limit 10",
+		federation: &client.Federation{
+			Orders: []client.FederationOrder{{Column: "avg_rtt", Desc: true}},
+			Limit:  10,
+		},
+	}, {
+		input: "select Avg(rtt) as avg_rtt, pod from l4_flow_log group by pod order by avg_rtt desc limit 10, 10",
+	}, {
+		input: "select byte from l4_flow_log limit 10",
+		merge: true,
+	}, {
+		input: "select Sum(byte) as sum_byte, Max(rtt) as max_rtt, Count(row) as c, pod from l4_flow_log group by pod order by sum_byte desc limit 10",
+		merge: true,
+		federation: &client.Federation{
+			Merges: map[string]string{"sum_byte": client.MERGE_SUM, "max_rtt": client.MERGE_MAX, "c": client.MERGE_SUM},
+			Orders: []client.FederationOrder{{Column: "sum_byte", Desc: true}},
+			Limit:  10,
+		},
+	}, {
+		input:   "select Avg(rtt) as avg_rtt, pod from l4_flow_log group by pod",
+		merge:   true,
+		wantErr: true,
+	}, {
+		input:   "select Sum(byte) as sum_byte, pod from l4_flow_log group by pod limit 10, 10",
+		merge:   true,
+		wantErr: true,
+	}}
+	for _, c := range cases {
+		e := CHEngine{DB: "flow_log", Context: context.Background()}
+		e.Init()
+		parser := parse.Parser{Engine: &e}
+		if err := parser.ParseSQL(c.input); err != nil {
+			t.Errorf("ParseSQL(%s) failed: %s", c.input, err)
+			continue
+		}
+		for _, stmt := range e.Statements {
+			stmt.Format(e.Model)
+		}
+		FormatModel(e.Model)
+		federation, err := e.federation(c.merge)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("federation(%s, %v) should fail", c.input, c.merge)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(federation, c.federation) {
+			t.Errorf("federation(%s, %v) = %+v, %v, want %+v", c.input, c.merge, federation, err, c.federation)
+		}
+	}
+}
+
+func TestStreamLimit(t *testing.T) {
+	cases := []struct {
+		input  string
+		stream bool
+		limit  string
+	}{{
+		input: "select byte from l4_flow_log",
+		limit: " LIMIT 10000",
+	}, {
+		input:  "select byte from l4_flow_log",
+		stream: true,
+	}, {
+		input:  "select byte from l4_flow_log limit 100",
+		stream: true,
+		limit:  " LIMIT 100",
+	}}
+	for _, c := range cases {
+		e := CHEngine{DB: "flow_log", Context: context.Background(), stream: c.stream}
+		e.Init()
+		_, sql, err := e.transSubSql(c.input, nil)
+		if err != nil {
+			t.Errorf("transSubSql(%s) failed: %s", c.input, err)
+			continue
+		}
+		if c.limit == "" && strings.Contains(sql, " LIMIT ") || c.limit != "" && !strings.HasSuffix(sql, c.limit) {
+			t.Errorf("transSubSql(%s) with stream %v = %s, want limit %q", c.input, c.stream, sql, c.limit)
+		}
+	}
+}
+
+func TestFederationUnsupportedSql(t *testing.T) {
+	args := &common.QuerierParams{DB: "flow_metrics", Clusters: []string{"a", "b"}, Context: context.Background()}
+	cases := []struct {
+		name  string
+		sql   string
+		query func(e *CHEngine, sql string) error
+	}{{
+		name: "WITH",
+		sql:  "WITH query1 AS (SELECT pod FROM `vtap_flow_port` WHERE time>=60 AND time<=180 GROUP BY pod LIMIT 10) SELECT Sum(byte) AS sum_byte, pod FROM `vtap_flow_port` WHERE pod IN query1 GROUP BY pod",
+		query: func(e *CHEngine, sql string) error {
+			_, _, err := e.QueryWithSql(sql, args)
+			return err
+		},
+	}, {
+		name: "SLIMIT",
+		sql:  "SELECT time(time,1,1,0) as toi, Sum(byte) AS sum_byte, pod FROM `vtap_flow_port` WHERE time>=60 AND time<=180 GROUP BY toi, pod ORDER BY toi desc SLIMIT 5",
+		query: func(e *CHEngine, sql string) error {
+			_, _, err := e.QuerySlimitSql(sql, args)
+			return err
+		},
+	}, {
+		name: "OFFSET",
+		sql:  "SELECT Sum(byte) AS sum_byte, Sum(byte) OFFSET 1d AS sum_byte_1d, pod FROM `vtap_flow_port` WHERE time>=86460 AND time<=86580 GROUP BY pod",
+		query: func(e *CHEngine, sql string) error {
+			_, _, err := e.QueryOffsetSql(sql, args)
+			return err
+		},
+	}}
+	for _, c := range cases {
+		e := &CHEngine{DB: args.DB, Context: args.Context}
+		e.Init()
+		err := c.query(e, c.sql)
+		if serviceErr, ok := err.(*common.ServiceError); !ok || serviceErr.Status != common.INVALID_PARAMETERS {
+			t.Errorf("%s should be rejected by federated queries, get %v", c.name, err)
+		}
+	}
+}
@@ -26,40 +26,68 @@ import (
 	"unsafe"
 
 	clickhouse "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	//"github.com/k0kubun/pp"
 
 	"github.com/deepflowio/deepflow/server/querier/common"
-	"github.com/deepflowio/deepflow/server/querier/config"
+	"github.com/deepflowio/deepflow/server/querier/governance"
 	"github.com/deepflowio/deepflow/server/querier/statsd"
 	"github.com/google/uuid"
 	logging "github.com/op/go-logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var log = logging.MustGetLogger("clickhouse.client")
 
 type QueryParams struct {
 	Sql             string
+	Clusters        []string    // if set, the query is federated across the clusters
+	Federation      *Federation // how rows of the clusters are re-aggregated, nil if rows are tagged by their cluster
 	UseQueryCache   bool
 	QueryCacheTTL   string
 	Callbacks       map[string]func(result *common.Result) error
 	QueryUUID       string
 	ColumnSchemaMap map[string]*common.ColumnSchema
 	ORGID           string
+	UserID          string
 	SimpleSql       bool
+	ResultWriter    common.ResultWriter
+	TimeRanges      []TimeRange // time ranges of the DeepFlow SQLs translated into Sql, nil if Sql is not translated
 }
 
-// All ClickHouse Client share one connection
-var connection clickhouse.Conn
+// TimeRange is the time range of a translated DeepFlow SQL, 0 means not limited
+type TimeRange struct {
+	Start int64
+	End   int64
+}
+
+// CheckTimeRanges checks the time ranges against the query governance of the request in ctx
+func CheckTimeRanges(ctx context.Context, timeRanges []TimeRange) error {
+	tracker := governance.FromContext(ctx)
+	if tracker == nil {
+		return nil
+	}
+	for _, timeRange := range timeRanges {
+		if err := tracker.CheckTimeRange(timeRange.Start, timeRange.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
 type Client struct {
-	Host       string
-	Port       int
-	UserName   string
-	Password   string
-	connection clickhouse.Conn
-	DB         string
-	Context    context.Context
-	Debug      *Debug
+	Host     string
+	Port     int
+	UserName string
+	Password string
+	Cluster  string // the default cluster is used if empty
+	pool     *Pool
+	DB       string
+	Context  context.Context
+	Debug    *Debug
 }
 
 func (c *Client) init(query_uuid string) error {
@@ -72,33 +100,11 @@ func (c *Client) init(query_uuid string) error {
 			IP:        c.Host,
 		}
 	}
-	if connection == nil { // FIXME: add a RWLock
-		conn, err := clickhouse.Open(&clickhouse.Options{
-			Addr: []string{fmt.Sprintf("%s:%d", c.Host, c.Port)},
-			Auth: clickhouse.Auth{
-				Database: "default",
-				Username: c.UserName,
-				Password: c.Password,
-			},
-			// Default MaxOpenConns = MaxIdleConns + 5
-			//     Ref: https://clickhouse.com/docs/en/integrations/go/clickhouse-go/clickhouse-api#connection-settings
-			// In ClickHouse SDK, when returning a connection, if the current number of idle connections is equal to
-			// `MaxIdleConns`, the connection to be returned will be closed directly. Therefore, when `MaxOpenConns`
-			// is greater than `MaxIdleConns`, it is very easy for the connection to be actively closed, and it is
-			// easy to cause a lot of short connections during high-concurrency queries, so set the two to the same
-			// value here.
-			//     Ref: https://github.com/ClickHouse/clickhouse-go/blob/main/clickhouse.go#L296
-			MaxOpenConns: config.Cfg.Clickhouse.MaxConnection,
-			MaxIdleConns: config.Cfg.Clickhouse.MaxConnection,
-			DialTimeout:  time.Duration(config.Cfg.Clickhouse.Timeout) * time.Second,
-		})
-		if err != nil {
-			log.Errorf("connect clickhouse failed: %s, url: %s:%s@%s:%d", err, c.UserName, c.Password, c.Host, c.Port)
-			return err
-		}
-		connection = conn
+	pool, err := GetPool(c.Cluster, c.UserName, c.Password)
+	if err != nil {
+		return err
 	}
-	c.connection = connection
+	c.pool = pool
 	return nil
 }
 
@@ -107,6 +113,13 @@ func (c *Client) Close() error {
 }
 
 func (c *Client) DoQuery(params *QueryParams) (result *common.Result, err error) {
+	// every translated sql is checked here, including the sub queries of WITH, SLIMIT, OFFSET and split cache
+	if err = CheckTimeRanges(c.Context, params.TimeRanges); err != nil {
+		return nil, err
+	}
+	if len(params.Clusters) > 0 {
+		return c.doFederatedQuery(params)
+	}
 	sqlstr, callbacks, query_uuid, columnSchemaMap, simpleSql := params.Sql, params.Callbacks, params.QueryUUID, params.ColumnSchemaMap, params.SimpleSql
 	queryCacheStr := ""
 	if params.UseQueryCache {
@@ -130,18 +143,69 @@ func (c *Client) DoQuery(params *QueryParams) (result *common.Result, err error)
 	}
 	defer c.Close()
 
+	orgID := params.ORGID
+	if orgID == "" {
+		orgID = common.DEFAULT_ORG_ID
+	}
+	activeQuery := ActiveQueries.Register(c.Debug.QueryUUID, sqlstr, params.UserID, orgID)
+	defer ActiveQueries.Unregister(activeQuery)
+
 	start := time.Now()
 	ctx := c.Context
 	if c.Context == nil {
 		ctx = context.Background()
 	}
-	rows, err := c.connection.Query(ctx, sqlstr)
+	ctx, span := otel.GetTracerProvider().Tracer("querier/engine/clickhouse/client").Start(ctx, "ClickHouseQuery",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "clickhouse"),
+			attribute.String("db.name", c.DB),
+			attribute.String("db.statement", sqlstr),
+			attribute.String("query_uuid", query_uuid),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	settings := clickhouse.Settings{"log_comment": fmt.Sprintf("org_id=%s", orgID)}
+	tracker := governance.FromContext(ctx)
+	if tracker != nil {
+		for name, value := range tracker.Settings() {
+			settings[name] = value
+		}
+	}
+	// tag the query with query_uuid and org, so that it can be found in system.processes/system.query_log and killed
+	ctx = clickhouse.Context(ctx,
+		clickhouse.WithQueryID(activeQuery.QueryID),
+		clickhouse.WithSettings(settings),
+		clickhouse.WithProgress(func(p *clickhouse.Progress) {
+			activeQuery.addProgress(p.Rows, p.Bytes)
+			if tracker != nil {
+				tracker.AddProgress(p.Rows, p.Bytes)
+			}
+		}),
+	)
+	var rows driver.Rows
+	addr, done, err := c.pool.Do(func(addr string, conn clickhouse.Conn) error {
+		var err error
+		rows, err = conn.Query(ctx, sqlstr)
+		return err
+	})
 	c.Debug.Sql = sqlstr
+	if addr != "" {
+		c.Debug.IP = addr
+		span.SetAttributes(attribute.String("net.peer.name", addr))
+	}
 	if err != nil {
 		log.Errorf("query clickhouse Error: %s, sql: %s, query_uuid: %s", err, sqlstr, c.Debug.QueryUUID)
 		c.Debug.Error = fmt.Sprintf("%s", err)
 		return nil, err
 	}
+	defer done()
 	defer rows.Close()
 	columns := rows.ColumnTypes()
 	resColumns := len(columns)
@@ -162,9 +226,14 @@ func (c *Client) DoQuery(params *QueryParams) (result *common.Result, err error)
 		columnValues[i] = reflect.New(columns[i].ScanType()).Interface()
 		columnSchemas[i].ValueType = columns[i].DatabaseTypeName()
 	}
+	var streamer *resultStreamer
+	if params.ResultWriter != nil {
+		streamer = newResultStreamer(params.ResultWriter, callbacks, columnNames, columnSchemas)
+	}
 	resSize := 0
+	resRows := 0
 	for rows.Next() {
-		if err := rows.Scan(columnValues...); err != nil {
+		if err = rows.Scan(columnValues...); err != nil {
 			c.Debug.Error = fmt.Sprintf("%s", err)
 			return nil, err
 		}
@@ -174,17 +243,36 @@ func (c *Client) DoQuery(params *QueryParams) (result *common.Result, err error)
 			resSize += int(unsafe.Sizeof(value))
 			record = append(record, value)
 		}
+		resRows++
+		if streamer != nil {
+			if err = streamer.append(record); err != nil {
+				log.Errorf("write result Error: %s, query_uuid: %s", err, c.Debug.QueryUUID)
+				c.Debug.Error = fmt.Sprintf("%s", err)
+				return nil, err
+			}
+			continue
+		}
 		values = append(values, record)
 	}
 	// Even if the query operation produces an error, it does not necessarily return an error in the'err 'parameter,
 	// so the return value of the'rows. Err () ' method must be checked to ensure that the query operation is successful
-	if err := rows.Err(); err != nil {
+	if err = rows.Err(); err != nil {
 		log.Errorf("query clickhouse Error: %s, sql: %s, query_uuid: %s", err, sqlstr, c.Debug.QueryUUID)
 		c.Debug.Error = fmt.Sprintf("%s", err)
 		return nil, err
 	}
+	if streamer != nil {
+		result, err = streamer.close()
+		if err != nil {
+			log.Errorf("write result Error: %s, query_uuid: %s", err, c.Debug.QueryUUID)
+			c.Debug.Error = fmt.Sprintf("%s", err)
+			return nil, err
+		}
+	}
 	queryTime := time.Since(start)
-	resRows := len(values)
+	if tracker != nil {
+		tracker.AddResult(sqlstr, resRows)
+	}
 	statsd.QuerierCounter.WriteCk(
 		&statsd.ClickhouseCounter{
 			ResponseSize: uint64(resSize),
@@ -194,18 +282,86 @@ func (c *Client) DoQuery(params *QueryParams) (result *common.Result, err error)
 		},
 	)
 	c.Debug.QueryTime = fmt.Sprintf("%.9fs", float64(queryTime)/1e9)
-	result = &common.Result{
-		Columns: columnNames,
-		Values:  values,
-		Schemas: columnSchemas,
+	span.SetAttributes(attribute.Int("db.rows", resRows), attribute.Int("db.columns", resColumns))
+	if streamer == nil {
+		result = &common.Result{
+			Columns: columnNames,
+			Values:  values,
+			Schemas: columnSchemas,
+		}
+		ApplyCallbacks(result, callbacks)
 	}
+	log.Debugf("sql: %s, query_uuid: %s", sqlstr, c.Debug.QueryUUID)
+	log.Infof("query_uuid: %s. query api statistics: %d rows, %d columns, %d bytes, cost %f ms", c.Debug.QueryUUID, resRows, resColumns, resSize, float64(queryTime.Milliseconds()))
+	return result, nil
+}
+
+// ApplyCallbacks runs the callbacks such as time fill on the raw result
+func ApplyCallbacks(result *common.Result, callbacks map[string]func(result *common.Result) error) {
 	for _, callback := range callbacks {
 		err := callback(result)
 		if err != nil {
 			log.Error("Execute Callback %v Error: %v", callback, err)
 		}
 	}
-	log.Debugf("sql: %s, query_uuid: %s", sqlstr, c.Debug.QueryUUID)
-	log.Infof("query_uuid: %s. query api statistics: %d rows, %d columns, %d bytes, cost %f ms", c.Debug.QueryUUID, resRows, resColumns, resSize, float64(queryTime.Milliseconds()))
-	return result, nil
+}
+
+// KillQuery kills the running queries with queryUUID in orgID and returns the killed ClickHouse query_ids
+func (c *Client) KillQuery(queryUUID, orgID, userID string, isAdmin bool) ([]string, error) {
+	queryIDs := ActiveQueries.Get(queryUUID, orgID, "")
+	if len(queryIDs) == 0 {
+		return nil, common.NewError(common.RESOURCE_NOT_FOUND, fmt.Sprintf("query (%s) not found", queryUUID))
+	}
+	if !isAdmin {
+		queryIDs = nil
+		if userID != "" {
+			queryIDs = ActiveQueries.Get(queryUUID, orgID, userID)
+		}
+		if len(queryIDs) == 0 {
+			return nil, common.NewError(common.PERMISSION_DENIED, fmt.Sprintf("query (%s) is not owned by user (%s)", queryUUID, userID))
+		}
+	}
+	err := c.init(queryUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	ctx := c.Context
+	if c.Context == nil {
+		ctx = context.Background()
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	quotedIDs := make([]string, 0, len(queryIDs))
+	for _, queryID := range queryIDs {
+		quotedIDs = append(quotedIDs, "'"+escaper.Replace(queryID)+"'")
+	}
+	sqlstr := fmt.Sprintf("KILL QUERY WHERE query_id IN (%s) ASYNC", strings.Join(quotedIDs, ","))
+	c.Debug.Sql = sqlstr
+	// the replica executing the query is unknown, kill it on all replicas of all clusters
+	var lastErr error
+	killed := false
+	for _, cluster := range append([]string{DEFAULT_CLUSTER}, ClusterNames()...) {
+		pool, err := GetPool(cluster, c.UserName, c.Password)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		endpoints := pool.Endpoints()
+		for i, conn := range pool.Conns() {
+			if err := conn.Exec(ctx, sqlstr); err != nil {
+				log.Warningf("kill query Error: %s, clickhouse: %s, sql: %s, query_uuid: %s", err, endpoints[i], sqlstr, queryUUID)
+				lastErr = err
+				continue
+			}
+			killed = true
+		}
+	}
+	if !killed {
+		log.Errorf("kill query Error: %s, sql: %s, query_uuid: %s", lastErr, sqlstr, queryUUID)
+		c.Debug.Error = fmt.Sprintf("%s", lastErr)
+		return nil, lastErr
+	}
+	log.Infof("query_uuid: %s. killed query_ids: %v", queryUUID, queryIDs)
+	return queryIDs, nil
 }
@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/querier/config"
+	"github.com/deepflowio/deepflow/server/querier/governance"
+)
+
+func TestCheckTimeRanges(t *testing.T) {
+	config.Cfg = &config.QuerierConfig{
+		QueryGovernance: config.QueryGovernance{
+			Enabled: true,
+			Default: config.QueryLimit{MaxTimeRange: 3600},
+		},
+	}
+	defer func() { config.Cfg = nil }()
+
+	timeRanges := []TimeRange{{Start: 1700000000, End: 1700003600}, {Start: 1699996400, End: 1700003600}}
+	if err := CheckTimeRanges(context.Background(), timeRanges); err != nil {
+		t.Errorf("query without tracker should not be checked, get %v", err)
+	}
+	ctx := governance.WithTracker(context.Background(), governance.NewTracker("1", "1"))
+	if err := CheckTimeRanges(ctx, nil); err != nil {
+		t.Errorf("untranslated query should not be checked, get %v", err)
+	}
+	if err := CheckTimeRanges(ctx, timeRanges[:1]); err != nil {
+		t.Errorf("time range within limit, get %v", err)
+	}
+	if err := CheckTimeRanges(ctx, timeRanges); err == nil {
+		t.Errorf("time range of the second sql exceeds limit, should be rejected")
+	}
+}
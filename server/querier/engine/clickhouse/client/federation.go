@@ -0,0 +1,283 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+// FEDERATION_COLUMN is appended to the results of federated queries, its value is the cluster of the row
+const FEDERATION_COLUMN = "_cluster"
+
+const (
+	MERGE_SUM = "sum"
+	MERGE_MAX = "max"
+	MERGE_MIN = "min"
+)
+
+// Federation merges the rows returned by each cluster
+type Federation struct {
+	Merges map[string]string // metric column -> MERGE_*, the other columns are group keys, nil means rows are tagged by their cluster
+	Orders []FederationOrder
+	Limit  int // 0 means no limit
+}
+
+type FederationOrder struct {
+	Column string
+	Desc   bool
+}
+
+// doFederatedQuery executes the query on every cluster of params.Clusters concurrently and merges the results.
+// Rows are tagged by their cluster, or re-aggregated with the same group keys if params.Federation.Merges is set,
+// then they are sorted and limited again by params.Federation. When rows are re-aggregated, as LIMIT also takes
+// effect in each cluster first, the top N rows of the merged result are approximate if any cluster returns LIMIT rows.
+func (c *Client) doFederatedQuery(params *QueryParams) (*common.Result, error) {
+	clusters := params.Clusters
+	if c.Debug == nil {
+		c.Debug = &Debug{QueryUUID: params.QueryUUID, IP: c.Host}
+	}
+	clients := make([]*Client, len(clusters))
+	results := make([]*common.Result, len(clusters))
+	errs := make([]error, len(clusters))
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		clusterParams := *params
+		clusterParams.Clusters = nil
+		// callbacks are not designed to be called concurrently, they are applied after all clusters respond
+		clusterParams.Callbacks = nil
+		clusterParams.ResultWriter = nil
+		clients[i] = &Client{
+			Host:     c.Host,
+			Port:     c.Port,
+			UserName: c.UserName,
+			Password: c.Password,
+			Cluster:  cluster,
+			DB:       c.DB,
+			Context:  c.Context,
+			Debug:    &Debug{QueryUUID: c.Debug.QueryUUID},
+		}
+		wg.Add(1)
+		go func(i int, params *QueryParams) {
+			defer wg.Done()
+			results[i], errs[i] = clients[i].DoQuery(params)
+		}(i, &clusterParams)
+	}
+	wg.Wait()
+
+	ips := make([]string, 0, len(clusters))
+	var queryTimes, errMsgs []string
+	for i, cluster := range clusters {
+		ips = append(ips, fmt.Sprintf("%s/%s", cluster, clients[i].Debug.IP))
+		if clients[i].Debug.QueryTime != "" {
+			queryTimes = append(queryTimes, fmt.Sprintf("%s/%s", cluster, clients[i].Debug.QueryTime))
+		}
+		if errs[i] != nil {
+			errMsgs = append(errMsgs, fmt.Sprintf("%s/%s", cluster, errs[i]))
+		}
+	}
+	c.Debug.Sql = params.Sql
+	c.Debug.IP = strings.Join(ips, ",")
+	c.Debug.QueryTime = strings.Join(queryTimes, ",")
+	for i, err := range errs {
+		if err != nil {
+			log.Errorf("federated query Error: %s, cluster: %s, query_uuid: %s", err, clusters[i], c.Debug.QueryUUID)
+			c.Debug.Error = strings.Join(errMsgs, ",")
+			return nil, err
+		}
+	}
+
+	var result *common.Result
+	if params.Federation != nil && params.Federation.Merges != nil {
+		result = mergeAggregatedResults(results, params.Federation)
+		ApplyCallbacks(result, params.Callbacks)
+	} else {
+		for _, result := range results {
+			ApplyCallbacks(result, params.Callbacks)
+		}
+		result = mergeClusterResults(clusters, results)
+		if params.Federation != nil {
+			sortRows(result, params.Federation.Orders)
+			limitRows(result, params.Federation.Limit)
+		}
+	}
+	if params.ResultWriter != nil {
+		if err := params.ResultWriter.WriteHeader(result.Columns, result.Schemas); err != nil {
+			return nil, err
+		}
+		if len(result.Values) > 0 {
+			if err := params.ResultWriter.WriteRows(result.Values); err != nil {
+				return nil, err
+			}
+		}
+		result.Values = nil
+	}
+	return result, nil
+}
+
+// mergeClusterResults concatenates rows of all clusters and tags each row with its cluster,
+// columns of the first result are used as all clusters execute the same sql
+func mergeClusterResults(clusters []string, results []*common.Result) *common.Result {
+	schema := common.NewColumnSchema(FEDERATION_COLUMN, "", "")
+	schema.ValueType = "String"
+	merged := &common.Result{
+		Columns: append(append([]interface{}{}, results[0].Columns...), FEDERATION_COLUMN),
+		Schemas: append(append(common.ColumnSchemas{}, results[0].Schemas...), schema),
+	}
+	for i, result := range results {
+		for _, value := range result.Values {
+			record, ok := value.([]interface{})
+			if !ok {
+				continue
+			}
+			merged.Values = append(merged.Values, append(record, clusters[i]))
+		}
+	}
+	return merged
+}
+
+// mergeAggregatedResults merges rows with the same group keys by the merge function of each metric column
+func mergeAggregatedResults(results []*common.Result, federation *Federation) *common.Result {
+	merged := &common.Result{Columns: results[0].Columns, Schemas: results[0].Schemas}
+	merges := make([]string, len(merged.Columns))
+	for i, column := range merged.Columns {
+		merges[i] = federation.Merges[common.ValueToString(column)]
+	}
+	rowIndexes := make(map[string]int)
+	var key strings.Builder
+	for _, result := range results {
+		for _, value := range result.Values {
+			record, ok := value.([]interface{})
+			if !ok || len(record) != len(merges) {
+				continue
+			}
+			key.Reset()
+			for i, v := range record {
+				if merges[i] == "" {
+					key.WriteString(common.ValueToString(v))
+					key.WriteByte(0)
+				}
+			}
+			index, ok := rowIndexes[key.String()]
+			if !ok {
+				rowIndexes[key.String()] = len(merged.Values)
+				merged.Values = append(merged.Values, append([]interface{}{}, record...))
+				continue
+			}
+			row := merged.Values[index].([]interface{})
+			for i, merge := range merges {
+				if merge != "" {
+					row[i] = mergeValue(merge, row[i], record[i])
+				}
+			}
+		}
+	}
+	sortRows(merged, federation.Orders)
+	limitRows(merged, federation.Limit)
+	return merged
+}
+
+func limitRows(result *common.Result, limit int) {
+	if limit > 0 && len(result.Values) > limit {
+		result.Values = result.Values[:limit]
+	}
+}
+
+func mergeValue(merge string, a, b interface{}) interface{} {
+	x, okA := common.ValueToFloat64(a)
+	y, okB := common.ValueToFloat64(b)
+	if !okB {
+		return a
+	}
+	if !okA {
+		return b
+	}
+	switch merge {
+	case MERGE_SUM:
+		// keep the integer type of counters
+		switch a := a.(type) {
+		case uint64:
+			if b, ok := b.(uint64); ok {
+				return a + b
+			}
+		case int64:
+			if b, ok := b.(int64); ok {
+				return a + b
+			}
+		}
+		return x + y
+	case MERGE_MAX:
+		if y > x {
+			return b
+		}
+	case MERGE_MIN:
+		if y < x {
+			return b
+		}
+	}
+	return a
+}
+
+// sortRows sorts rows by the order columns, numbers are compared by value and the others by string
+func sortRows(result *common.Result, orders []FederationOrder) {
+	type sortKey struct {
+		index int
+		desc  bool
+	}
+	var keys []sortKey
+	for _, order := range orders {
+		for i, column := range result.Columns {
+			if common.ValueToString(column) == order.Column {
+				keys = append(keys, sortKey{i, order.Desc})
+				break
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.SliceStable(result.Values, func(i, j int) bool {
+		rowI, rowJ := result.Values[i].([]interface{}), result.Values[j].([]interface{})
+		for _, key := range keys {
+			compared := compareValue(rowI[key.index], rowJ[key.index])
+			if compared == 0 {
+				continue
+			}
+			return (compared < 0) != key.desc
+		}
+		return false
+	})
+}
+
+func compareValue(a, b interface{}) int {
+	x, okA := common.ValueToFloat64(a)
+	y, okB := common.ValueToFloat64(b)
+	if okA && okB {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(common.ValueToString(a), common.ValueToString(b))
+}
@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	clickhouse "github.com/ClickHouse/clickhouse-go/v2"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+)
+
+const (
+	LOAD_BALANCE_ROUND_ROBIN    = "round-robin"
+	LOAD_BALANCE_LEAST_INFLIGHT = "least-inflight"
+	LOAD_BALANCE_IN_ORDER       = "in-order"
+)
+
+const DEFAULT_CLUSTER = ""
+
+type endpoint struct {
+	addr     string
+	conn     clickhouse.Conn
+	healthy  int32
+	inflight int64
+}
+
+func (e *endpoint) isHealthy() bool {
+	return atomic.LoadInt32(&e.healthy) == 1
+}
+
+func (e *endpoint) setHealthy(healthy bool) {
+	if healthy {
+		atomic.StoreInt32(&e.healthy, 1)
+	} else {
+		atomic.StoreInt32(&e.healthy, 0)
+	}
+}
+
+// Pool is the replicas of a ClickHouse cluster, queries are sent to a healthy replica chosen by
+// the load balance strategy, and failover to the others if the replica can not be connected.
+type Pool struct {
+	name        string
+	loadBalance string
+	endpoints   []*endpoint
+	next        uint32
+}
+
+func newPool(name, loadBalance string, endpoints []*endpoint) (*Pool, error) {
+	switch loadBalance {
+	case "":
+		loadBalance = LOAD_BALANCE_ROUND_ROBIN
+	case LOAD_BALANCE_ROUND_ROBIN, LOAD_BALANCE_LEAST_INFLIGHT, LOAD_BALANCE_IN_ORDER:
+	default:
+		return nil, fmt.Errorf("clickhouse cluster (%s) load-balance (%s) not support", name, loadBalance)
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("clickhouse cluster (%s) has no endpoint", name)
+	}
+	for _, e := range endpoints {
+		e.setHealthy(true)
+	}
+	return &Pool{name: name, loadBalance: loadBalance, endpoints: endpoints}, nil
+}
+
+// candidates returns the endpoints in the order they should be tried,
+// unhealthy endpoints are put at the end as the last resort
+func (p *Pool) candidates() []*endpoint {
+	ordered := make([]*endpoint, 0, len(p.endpoints))
+	switch p.loadBalance {
+	case LOAD_BALANCE_ROUND_ROBIN:
+		start := int(atomic.AddUint32(&p.next, 1)-1) % len(p.endpoints)
+		for i := range p.endpoints {
+			ordered = append(ordered, p.endpoints[(start+i)%len(p.endpoints)])
+		}
+	case LOAD_BALANCE_LEAST_INFLIGHT:
+		ordered = append(ordered, p.endpoints...)
+		// insertion sort is stable, endpoints with the same inflight keep the configured order
+		inflights := make([]int64, len(ordered))
+		for i, e := range ordered {
+			inflights[i] = atomic.LoadInt64(&e.inflight)
+		}
+		for i := 1; i < len(ordered); i++ {
+			for j := i; j > 0 && inflights[j] < inflights[j-1]; j-- {
+				ordered[j], ordered[j-1] = ordered[j-1], ordered[j]
+				inflights[j], inflights[j-1] = inflights[j-1], inflights[j]
+			}
+		}
+	default:
+		ordered = append(ordered, p.endpoints...)
+	}
+	healthy := ordered[:0:0]
+	var unhealthy []*endpoint
+	for _, e := range ordered {
+		if e.isHealthy() {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// Do calls f with the connection of the candidates one by one until it succeeds or fails with a non-failover error,
+// the endpoint which f succeeds with is returned, and f should release what it holds on the connection by the returned done
+func (p *Pool) Do(f func(addr string, conn clickhouse.Conn) error) (addr string, done func(), err error) {
+	for _, e := range p.candidates() {
+		atomic.AddInt64(&e.inflight, 1)
+		err = f(e.addr, e.conn)
+		if err == nil {
+			e.setHealthy(true)
+			e := e
+			return e.addr, func() { atomic.AddInt64(&e.inflight, -1) }, nil
+		}
+		atomic.AddInt64(&e.inflight, -1)
+		if errors.Is(err, clickhouse.ErrAcquireConnTimeout) {
+			// all connections of the replica are in use, it is busy rather than unavailable
+			log.Warningf("clickhouse (%s) of cluster (%s) is busy: %s, try next", e.addr, p.name, err)
+			continue
+		}
+		if !isFailoverError(err) {
+			return e.addr, nil, err
+		}
+		e.setHealthy(false)
+		log.Warningf("clickhouse (%s) of cluster (%s) is unavailable: %s, try next", e.addr, p.name, err)
+	}
+	return addr, nil, err
+}
+
+// Endpoints returns addresses of all replicas
+func (p *Pool) Endpoints() []string {
+	addrs := make([]string, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		addrs = append(addrs, e.addr)
+	}
+	return addrs
+}
+
+// Conns returns connections of all replicas
+func (p *Pool) Conns() []clickhouse.Conn {
+	conns := make([]clickhouse.Conn, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		conns = append(conns, e.conn)
+	}
+	return conns
+}
+
+func (p *Pool) healthCheck(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for _, e := range p.endpoints {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := e.conn.Ping(ctx)
+			cancel()
+			if err != nil && e.isHealthy() {
+				log.Warningf("clickhouse (%s) of cluster (%s) health check failed: %s", e.addr, p.name, err)
+			} else if err == nil && !e.isHealthy() {
+				log.Infof("clickhouse (%s) of cluster (%s) recovered", e.addr, p.name)
+			}
+			e.setHealthy(err == nil)
+		}
+	}
+}
+
+// isFailoverError returns whether the query could succeed on another replica,
+// errors returned by ClickHouse itself, such as a syntax error, would be returned by every replica
+func isFailoverError(err error) bool {
+	var exception *clickhouse.Exception
+	if errors.As(err, &exception) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// back-pressure of the connection pool, the replica is still healthy
+	if errors.Is(err, clickhouse.ErrAcquireConnTimeout) {
+		return false
+	}
+	// bad arguments of the query
+	var opError *clickhouse.OpError
+	if errors.As(err, &opError) {
+		return false
+	}
+	return true
+}
+
+var (
+	poolsLock sync.Mutex
+	pools     = map[poolKey]*Pool{}
+)
+
+// connections are authenticated by the user, pools of different users are not shared
+type poolKey struct {
+	cluster  string
+	userName string
+	password string
+}
+
+// GetPool returns the pool of the cluster for the user, the default cluster is DEFAULT_CLUSTER
+func GetPool(cluster, userName, password string) (*Pool, error) {
+	poolsLock.Lock()
+	defer poolsLock.Unlock()
+	key := poolKey{cluster: cluster, userName: userName, password: password}
+	if pool, ok := pools[key]; ok {
+		return pool, nil
+	}
+	cfg := config.Cfg.Clickhouse
+	addrs, loadBalance := cfg.Endpoints, cfg.LoadBalance
+	if cluster == DEFAULT_CLUSTER {
+		if len(addrs) == 0 {
+			addrs = []string{fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)}
+		}
+	} else {
+		found := false
+		for _, c := range cfg.Clusters {
+			if c.Name == cluster {
+				found = true
+				addrs = c.Endpoints
+				if c.LoadBalance != "" {
+					loadBalance = c.LoadBalance
+				}
+				break
+			}
+		}
+		if !found {
+			return nil, common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("clickhouse cluster (%s) not found", cluster))
+		}
+	}
+	endpoints := make([]*endpoint, 0, len(addrs))
+	for _, addr := range addrs {
+		conn, err := clickhouse.Open(&clickhouse.Options{
+			Addr: []string{addr},
+			Auth: clickhouse.Auth{
+				Database: "default",
+				Username: userName,
+				Password: password,
+			},
+			// Default MaxOpenConns = MaxIdleConns + 5
+			//     Ref: https://clickhouse.com/docs/en/integrations/go/clickhouse-go/clickhouse-api#connection-settings
+			// In ClickHouse SDK, when returning a connection, if the current number of idle connections is equal to
+			// `MaxIdleConns`, the connection to be returned will be closed directly. Therefore, when `MaxOpenConns`
+			// is greater than `MaxIdleConns`, it is very easy for the connection to be actively closed, and it is
+			// easy to cause a lot of short connections during high-concurrency queries, so set the two to the same
+			// value here.
+			//     Ref: https://github.com/ClickHouse/clickhouse-go/blob/main/clickhouse.go#L296
+			MaxOpenConns: cfg.MaxConnection,
+			MaxIdleConns: cfg.MaxConnection,
+			DialTimeout:  time.Duration(cfg.Timeout) * time.Second,
+		})
+		if err != nil {
+			log.Errorf("connect clickhouse failed: %s, url: %s:%s@%s", err, userName, password, addr)
+			return nil, err
+		}
+		endpoints = append(endpoints, &endpoint{addr: addr, conn: conn})
+	}
+	pool, err := newPool(cluster, loadBalance, endpoints)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.HealthCheckInterval > 0 && len(endpoints) > 1 {
+		go pool.healthCheck(time.Duration(cfg.HealthCheckInterval) * time.Second)
+	}
+	pools[key] = pool
+	return pool, nil
+}
+
+// ClusterNames returns names of all configured clusters
+func ClusterNames() []string {
+	names := make([]string, 0, len(config.Cfg.Clickhouse.Clusters))
+	for _, c := range config.Cfg.Clickhouse.Clusters {
+		names = append(names, c.Name)
+	}
+	return names
+}
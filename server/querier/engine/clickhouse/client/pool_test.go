@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	clickhouse "github.com/ClickHouse/clickhouse-go/v2"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+func newTestPool(t *testing.T, loadBalance string, addrs ...string) *Pool {
+	endpoints := make([]*endpoint, 0, len(addrs))
+	for _, addr := range addrs {
+		endpoints = append(endpoints, &endpoint{addr: addr})
+	}
+	pool, err := newPool("test", loadBalance, endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+func candidateAddrs(pool *Pool) []string {
+	var addrs []string
+	for _, e := range pool.candidates() {
+		addrs = append(addrs, e.addr)
+	}
+	return addrs
+}
+
+func TestPoolCandidates(t *testing.T) {
+	pool := newTestPool(t, LOAD_BALANCE_ROUND_ROBIN, "a", "b", "c")
+	for _, expected := range [][]string{{"a", "b", "c"}, {"b", "c", "a"}, {"c", "a", "b"}, {"a", "b", "c"}} {
+		if addrs := candidateAddrs(pool); !reflect.DeepEqual(addrs, expected) {
+			t.Errorf("round-robin candidates %v, expected %v", addrs, expected)
+		}
+	}
+
+	pool = newTestPool(t, LOAD_BALANCE_LEAST_INFLIGHT, "a", "b", "c")
+	pool.endpoints[0].inflight = 2
+	pool.endpoints[1].inflight = 1
+	if addrs := candidateAddrs(pool); !reflect.DeepEqual(addrs, []string{"c", "b", "a"}) {
+		t.Errorf("least-inflight candidates %v", addrs)
+	}
+
+	pool = newTestPool(t, LOAD_BALANCE_IN_ORDER, "a", "b", "c")
+	pool.endpoints[0].setHealthy(false)
+	if addrs := candidateAddrs(pool); !reflect.DeepEqual(addrs, []string{"b", "c", "a"}) {
+		t.Errorf("in-order candidates %v", addrs)
+	}
+
+	if _, err := newPool("test", "random", []*endpoint{{addr: "a"}}); err == nil {
+		t.Error("unknown load-balance should fail")
+	}
+}
+
+func TestPoolDo(t *testing.T) {
+	pool := newTestPool(t, LOAD_BALANCE_IN_ORDER, "a", "b", "c")
+	var tried []string
+	addr, done, err := pool.Do(func(addr string, conn clickhouse.Conn) error {
+		tried = append(tried, addr)
+		if addr == "a" {
+			return errors.New("dial tcp: connection refused")
+		}
+		return nil
+	})
+	if err != nil || addr != "b" || !reflect.DeepEqual(tried, []string{"a", "b"}) {
+		t.Errorf("failover to %s with %v, tried %v", addr, err, tried)
+	}
+	if pool.endpoints[0].isHealthy() || pool.endpoints[1].inflight != 1 {
+		t.Errorf("a should be unhealthy and b should be inflight")
+	}
+	done()
+	if pool.endpoints[1].inflight != 0 {
+		t.Errorf("b should not be inflight after done")
+	}
+
+	// b is tried first as a is unhealthy, and exceptions of ClickHouse are not failed over
+	tried = nil
+	_, _, err = pool.Do(func(addr string, conn clickhouse.Conn) error {
+		tried = append(tried, addr)
+		return fmt.Errorf("query failed: %w", &clickhouse.Exception{Code: 62, Message: "Syntax error"})
+	})
+	if err == nil || !reflect.DeepEqual(tried, []string{"b"}) || !pool.endpoints[1].isHealthy() {
+		t.Errorf("exception should not be failed over, tried %v", tried)
+	}
+
+	// busy replicas are tried one by one but stay healthy
+	tried = nil
+	_, _, err = pool.Do(func(addr string, conn clickhouse.Conn) error {
+		tried = append(tried, addr)
+		return clickhouse.ErrAcquireConnTimeout
+	})
+	if !errors.Is(err, clickhouse.ErrAcquireConnTimeout) || len(tried) != 3 || !pool.endpoints[1].isHealthy() || !pool.endpoints[2].isHealthy() {
+		t.Errorf("acquire conn timeout should not mark replicas unhealthy, tried %v", tried)
+	}
+}
+
+func TestIsFailoverError(t *testing.T) {
+	cases := []struct {
+		err      error
+		failover bool
+	}{
+		{errors.New("read: connection reset by peer"), true},
+		{clickhouse.ErrAcquireConnTimeout, false},
+		{&clickhouse.Exception{Code: 241, Message: "Memory limit exceeded"}, false},
+		{context.Canceled, false},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), false},
+	}
+	for _, c := range cases {
+		if isFailoverError(c.err) != c.failover {
+			t.Errorf("isFailoverError(%v) should be %v", c.err, c.failover)
+		}
+	}
+}
+
+func TestMergeClusterResults(t *testing.T) {
+	results := []*common.Result{
+		{
+			Columns: []interface{}{"pod", "byte"},
+			Schemas: common.ColumnSchemas{common.NewColumnSchema("pod", "", ""), common.NewColumnSchema("byte", "", "")},
+			Values:  []interface{}{[]interface{}{"a", 1}, []interface{}{"b", 2}},
+		},
+		{
+			Columns: []interface{}{"pod", "byte"},
+			Schemas: common.ColumnSchemas{common.NewColumnSchema("pod", "", ""), common.NewColumnSchema("byte", "", "")},
+			Values:  []interface{}{[]interface{}{"a", 3}},
+		},
+	}
+	merged := mergeClusterResults([]string{"beijing", "shanghai"}, results)
+	if !reflect.DeepEqual(merged.Columns, []interface{}{"pod", "byte", FEDERATION_COLUMN}) || len(merged.Schemas) != 3 {
+		t.Errorf("merged columns %v", merged.Columns)
+	}
+	expected := []interface{}{
+		[]interface{}{"a", 1, "beijing"},
+		[]interface{}{"b", 2, "beijing"},
+		[]interface{}{"a", 3, "shanghai"},
+	}
+	if !reflect.DeepEqual(merged.Values, expected) {
+		t.Errorf("merged values %v, expected %v", merged.Values, expected)
+	}
+}
+
+func TestMergeAggregatedResults(t *testing.T) {
+	columns := []interface{}{"pod", "sum_byte", "max_rtt"}
+	results := []*common.Result{
+		{
+			Columns: columns,
+			Values:  []interface{}{[]interface{}{"a", uint64(1), 5.0}, []interface{}{"b", uint64(2), 3.0}},
+		},
+		{
+			Columns: columns,
+			Values:  []interface{}{[]interface{}{"b", uint64(3), 4.0}, []interface{}{"c", uint64(1), 1.0}},
+		},
+	}
+	federation := &Federation{
+		Merges: map[string]string{"sum_byte": MERGE_SUM, "max_rtt": MERGE_MAX},
+		Orders: []FederationOrder{{Column: "sum_byte", Desc: true}},
+		Limit:  2,
+	}
+	merged := mergeAggregatedResults(results, federation)
+	expected := []interface{}{
+		[]interface{}{"b", uint64(5), 4.0},
+		[]interface{}{"a", uint64(1), 5.0},
+	}
+	if !reflect.DeepEqual(merged.Columns, columns) || !reflect.DeepEqual(merged.Values, expected) {
+		t.Errorf("merged values %v, expected %v", merged.Values, expected)
+	}
+	if !reflect.DeepEqual(results[0].Values[1], []interface{}{"b", uint64(2), 3.0}) {
+		t.Errorf("rows of clusters should not be modified")
+	}
+}
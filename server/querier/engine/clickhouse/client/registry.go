@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ActiveQuery is a ClickHouse query currently executed by this querier
+type ActiveQuery struct {
+	QueryUUID string
+	QueryID   string // query_id sent to ClickHouse, equal to QueryUUID unless it is already running
+	Sql       string
+	UserID    string
+	ORGID     string
+	StartTime time.Time
+	readRows  uint64
+	readBytes uint64
+}
+
+type ActiveQueryInfo struct {
+	QueryUUID string  `json:"query_uuid"`
+	QueryID   string  `json:"query_id"`
+	Sql       string  `json:"sql"`
+	UserID    string  `json:"user_id"`
+	ORGID     string  `json:"org_id"`
+	StartTime string  `json:"start_time"`
+	Elapsed   float64 `json:"elapsed"`
+	ReadRows  uint64  `json:"read_rows"`
+	ReadBytes uint64  `json:"read_bytes"`
+}
+
+func (q *ActiveQuery) addProgress(rows, bytes uint64) {
+	atomic.AddUint64(&q.readRows, rows)
+	atomic.AddUint64(&q.readBytes, bytes)
+}
+
+func (q *ActiveQuery) Info() ActiveQueryInfo {
+	return ActiveQueryInfo{
+		QueryUUID: q.QueryUUID,
+		QueryID:   q.QueryID,
+		Sql:       q.Sql,
+		UserID:    q.UserID,
+		ORGID:     q.ORGID,
+		StartTime: q.StartTime.Format(time.RFC3339),
+		Elapsed:   time.Since(q.StartTime).Seconds(),
+		ReadRows:  atomic.LoadUint64(&q.readRows),
+		ReadBytes: atomic.LoadUint64(&q.readBytes),
+	}
+}
+
+type QueryRegistry struct {
+	sync.RWMutex
+	queries map[string]*ActiveQuery // key: QueryID
+}
+
+// All ClickHouse Client share one registry
+var ActiveQueries = NewQueryRegistry()
+
+func NewQueryRegistry() *QueryRegistry {
+	return &QueryRegistry{queries: make(map[string]*ActiveQuery)}
+}
+
+// Register records a query and returns it with a ClickHouse query_id which is unique among running queries.
+// The same query_uuid may be used by several requests at the same time, so a suffix is appended when needed.
+func (r *QueryRegistry) Register(queryUUID, sql, userID, orgID string) *ActiveQuery {
+	r.Lock()
+	defer r.Unlock()
+	queryID := queryUUID
+	for i := 1; ; i++ {
+		if _, ok := r.queries[queryID]; !ok {
+			break
+		}
+		queryID = fmt.Sprintf("%s-%d", queryUUID, i)
+	}
+	query := &ActiveQuery{
+		QueryUUID: queryUUID,
+		QueryID:   queryID,
+		Sql:       sql,
+		UserID:    userID,
+		ORGID:     orgID,
+		StartTime: time.Now(),
+	}
+	r.queries[queryID] = query
+	return query
+}
+
+func (r *QueryRegistry) Unregister(query *ActiveQuery) {
+	r.Lock()
+	delete(r.queries, query.QueryID)
+	r.Unlock()
+}
+
+// List returns running queries of orgID ordered by start time
+func (r *QueryRegistry) List(orgID string) []ActiveQueryInfo {
+	r.RLock()
+	infos := make([]ActiveQueryInfo, 0, len(r.queries))
+	for _, query := range r.queries {
+		if query.ORGID != orgID {
+			continue
+		}
+		infos = append(infos, query.Info())
+	}
+	r.RUnlock()
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartTime < infos[j].StartTime || (infos[i].StartTime == infos[j].StartTime && infos[i].QueryID < infos[j].QueryID)
+	})
+	return infos
+}
+
+// Get returns the ClickHouse query_ids of all running queries with queryUUID in orgID,
+// only queries executed by userID are returned unless userID is empty
+func (r *QueryRegistry) Get(queryUUID, orgID, userID string) []string {
+	r.RLock()
+	defer r.RUnlock()
+	var queryIDs []string
+	for _, query := range r.queries {
+		if query.QueryUUID != queryUUID || query.ORGID != orgID || (userID != "" && query.UserID != userID) {
+			continue
+		}
+		queryIDs = append(queryIDs, query.QueryID)
+	}
+	sort.Strings(queryIDs)
+	return queryIDs
+}
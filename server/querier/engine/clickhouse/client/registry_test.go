@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+func TestQueryRegistry(t *testing.T) {
+	r := NewQueryRegistry()
+	q1 := r.Register("uuid-a", "SELECT 1", "1", "1")
+	q2 := r.Register("uuid-a", "SELECT 2", "1", "1")
+	q3 := r.Register("uuid-b", "SELECT 3", "2", "2")
+	if q1.QueryID != "uuid-a" || q2.QueryID != "uuid-a-1" || q3.QueryID != "uuid-b" {
+		t.Errorf("unexpected query ids: %s, %s, %s", q1.QueryID, q2.QueryID, q3.QueryID)
+	}
+	q2.addProgress(10, 100)
+	q2.addProgress(5, 50)
+	if info := q2.Info(); info.ReadRows != 15 || info.ReadBytes != 150 {
+		t.Errorf("unexpected progress: %d rows, %d bytes", info.ReadRows, info.ReadBytes)
+	}
+
+	if got := len(r.List("1")); got != 2 {
+		t.Errorf("List org 1: got %d queries, want 2", got)
+	}
+	if got := len(r.List("2")); got != 1 {
+		t.Errorf("List org 2: got %d queries, want 1", got)
+	}
+	if got := r.Get("uuid-a", "1", ""); !reflect.DeepEqual(got, []string{"uuid-a", "uuid-a-1"}) {
+		t.Errorf("Get uuid-a: got %v", got)
+	}
+	if got := r.Get("uuid-a", "1", "1"); !reflect.DeepEqual(got, []string{"uuid-a", "uuid-a-1"}) {
+		t.Errorf("Get uuid-a of user 1: got %v", got)
+	}
+	if got := r.Get("uuid-a", "1", "2"); len(got) != 0 {
+		t.Errorf("Get uuid-a of user 2: got %v, want none", got)
+	}
+	if got := r.Get("uuid-a", "2", ""); len(got) != 0 {
+		t.Errorf("Get uuid-a of org 2: got %v, want none", got)
+	}
+
+	r.Unregister(q1)
+	r.Unregister(q2)
+	if got := r.Get("uuid-a", "1", ""); len(got) != 0 {
+		t.Errorf("Get uuid-a after unregister: got %v, want none", got)
+	}
+	if q := r.Register("uuid-a", "SELECT 4", "1", "1"); q.QueryID != "uuid-a" {
+		t.Errorf("query id should be reused after unregister, got %s", q.QueryID)
+	}
+}
+
+func TestKillQueryPermission(t *testing.T) {
+	q := ActiveQueries.Register("uuid-kill", "SELECT 1", "1", "2")
+	defer ActiveQueries.Unregister(q)
+
+	c := &Client{}
+	cases := []struct {
+		name    string
+		orgID   string
+		userID  string
+		isAdmin bool
+		status  string
+	}{
+		{"other org", "1", "1", true, common.RESOURCE_NOT_FOUND},
+		{"other user", "2", "3", false, common.PERMISSION_DENIED},
+		{"no user", "2", "", false, common.PERMISSION_DENIED},
+	}
+	for _, tc := range cases {
+		_, err := c.KillQuery("uuid-kill", tc.orgID, tc.userID, tc.isAdmin)
+		serviceErr, ok := err.(*common.ServiceError)
+		if !ok || serviceErr.Status != tc.status {
+			t.Errorf("%s: got error %v, want %s", tc.name, err, tc.status)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+const STREAM_BATCH_SIZE = 1000
+
+// resultStreamer applies callbacks to rows batch by batch and hands them to a ResultWriter,
+// so that the whole result never needs to be kept in memory
+type resultStreamer struct {
+	writer    common.ResultWriter
+	callbacks map[string]func(result *common.Result) error
+	columns   []interface{}
+	schemas   common.ColumnSchemas
+	batchSize int
+	batch     []interface{}
+	result    *common.Result
+}
+
+func newResultStreamer(writer common.ResultWriter, callbacks map[string]func(result *common.Result) error, columns []interface{}, schemas common.ColumnSchemas) *resultStreamer {
+	batchSize := STREAM_BATCH_SIZE
+	// time fill needs all rows to fill the missing points, so rows are written in one batch
+	if _, ok := callbacks["time"]; ok {
+		batchSize = 0
+	}
+	return &resultStreamer{
+		writer:    writer,
+		callbacks: callbacks,
+		columns:   columns,
+		schemas:   schemas,
+		batchSize: batchSize,
+		batch:     make([]interface{}, 0, batchSize),
+		result:    &common.Result{Columns: columns, Schemas: schemas},
+	}
+}
+
+func (s *resultStreamer) append(record []interface{}) error {
+	s.batch = append(s.batch, record)
+	if s.batchSize > 0 && len(s.batch) >= s.batchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *resultStreamer) flush() error {
+	// callbacks may modify columns and schemas, every batch starts from the original ones
+	batch := &common.Result{
+		Columns: append([]interface{}{}, s.columns...),
+		Values:  s.batch,
+		Schemas: append(common.ColumnSchemas{}, s.schemas...),
+	}
+	ApplyCallbacks(batch, s.callbacks)
+	if !s.writer.HeaderWritten() {
+		if err := s.writer.WriteHeader(batch.Columns, batch.Schemas); err != nil {
+			return err
+		}
+	}
+	if len(batch.Values) > 0 {
+		if err := s.writer.WriteRows(batch.Values); err != nil {
+			return err
+		}
+	}
+	s.result.Columns, s.result.Schemas = batch.Columns, batch.Schemas
+	if batch.NextCursor != "" {
+		s.result.NextCursor = batch.NextCursor
+	}
+	s.batch = make([]interface{}, 0, s.batchSize)
+	return nil
+}
+
+// close writes the remaining rows and returns a result without values
+func (s *resultStreamer) close() (*common.Result, error) {
+	if len(s.batch) > 0 || !s.writer.HeaderWritten() {
+		if err := s.flush(); err != nil {
+			return nil, err
+		}
+	}
+	return s.result, nil
+}
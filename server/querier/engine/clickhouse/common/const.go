@@ -31,6 +31,8 @@ const DB_NAME_APPLICATION_LOG = "application_log"
 const TABLE_NAME_VTAP_ACL = "traffic_policy"
 const TABLE_NAME_TRACE_TREE = "trace_tree"
 const TABLE_NAME_SPAN_WITH_TRACE_ID = "span_with_trace_id"
+const TABLE_NAME_HISTOGRAM_SAMPLES = "histogram_samples"
+const DATASOURCE_NATIVE_HISTOGRAM = "native_histogram" // prometheus metrics with this datasource are queried from histogram_samples
 const IndexTypeIncremetalId = "incremental-id"
 const FormatHex = "hex"
 const TagServerChPrefix = "服务端"
@@ -39,12 +41,12 @@ const TagServerEnPrefix = "Server"
 const TagClientEnPrefix = "Client"
 
 var DB_TABLE_MAP = map[string][]string{
-	DB_NAME_FLOW_LOG:        []string{"l4_flow_log", "l7_flow_log", "l4_packet", "l7_packet"},
+	DB_NAME_FLOW_LOG:        []string{"l4_flow_log", "l7_flow_log", "l4_packet", "l7_packet", "trace_analysis"},
 	DB_NAME_FLOW_METRICS:    []string{"network", "network_map", "application", "application_map", "traffic_policy"},
 	DB_NAME_EXT_METRICS:     []string{"ext_common"},
 	DB_NAME_DEEPFLOW_ADMIN:  []string{"deepflow_server"},
 	DB_NAME_DEEPFLOW_TENANT: []string{"deepflow_collector"},
-	DB_NAME_EVENT:           []string{"event", "perf_event", "alert_event"},
+	DB_NAME_EVENT:           []string{"event", "perf_event", "alert_event", "slow_query_event"},
 	DB_NAME_PROFILE:         []string{"in_process"},
 	DB_NAME_PROMETHEUS:      []string{"samples"},
 	DB_NAME_APPLICATION_LOG: []string{"log"},
@@ -292,12 +292,47 @@ func GetUniqTrans(name string, args []string, alias string, e *CHEngine) (Statem
 }
 
 func GetBinaryFunc(name string, args []Function) (*BinaryFunction, error) {
+	if slices.Contains(view.WINDOW_FUNCTIONS, name) {
+		if err := checkWindowFunctionArgs(name, args); err != nil {
+			return nil, err
+		}
+	}
 	return &BinaryFunction{
 		Name:      name,
 		Functions: args,
 	}, nil
 }
 
+func checkWindowFunctionArgs(name string, args []Function) error {
+	argCount := 1
+	if name == view.FUNCTION_MOVING_AVG || name == view.FUNCTION_EWMA {
+		argCount = 2
+	}
+	if len(args) != argCount {
+		return fmt.Errorf("function [%s] requires %d arguments", name, argCount)
+	}
+	if argCount == 1 {
+		return nil
+	}
+	field, ok := args[1].(*Field)
+	if !ok {
+		return fmt.Errorf("function [%s] argument is not a number", name)
+	}
+	switch name {
+	case view.FUNCTION_MOVING_AVG:
+		windowSize, err := strconv.Atoi(field.Value)
+		if err != nil || windowSize < 1 {
+			return fmt.Errorf("function [%s] argument [%s] should be an int greater than 0", name, field.Value)
+		}
+	case view.FUNCTION_EWMA:
+		alpha, err := strconv.ParseFloat(field.Value, 64)
+		if err != nil || alpha <= 0 || alpha >= 1 {
+			return fmt.Errorf("function [%s] argument [%s] should be within (0, 1)", name, field.Value)
+		}
+	}
+	return nil
+}
+
 func GetFieldFunc(name string) (FieldFunction, error) {
 	switch strings.ToLower(name) {
 	case "time_interval":
@@ -337,6 +372,14 @@ func (f *BinaryFunction) Trans(m *view.Model) view.Node {
 		histogram.SetFlag(view.METRICS_FLAG_TOP)
 		histogram.Init()
 		return histogram
+	} else if slices.Contains(view.WINDOW_FUNCTIONS, f.Name) {
+		function := view.GetFunc(f.Name)
+		function.SetFields(fields)
+		function.(*view.WindowFunction).SetGroups(m.Groups)
+		function.SetFlag(view.METRICS_FLAG_OUTER)
+		function.SetTime(m.Time)
+		function.Init()
+		return function
 	} else if f.Name == view.FUNCTION_PCTL || f.Name == view.FUNCTION_PCTL_EXACT {
 		function := view.GetFunc(f.Name)
 		function.SetFields(fields[:1])                   // metrics
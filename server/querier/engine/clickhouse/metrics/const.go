@@ -61,6 +61,7 @@ const (
 	FUNCTION_TYPE_AGG                // 聚合类算子 例：sum、max、min
 	FUNCTION_TYPE_RATE               // 速率类算子 例：rate
 	FUNCTION_TYPE_MATH               // 算术类算子 例：+ - * /
+	FUNCTION_TYPE_WINDOW             // 窗口类算子 例：MovingAvg、CumulativeSum
 )
 
 // 指标量类型支持不用拆层的算子的集合
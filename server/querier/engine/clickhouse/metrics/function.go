@@ -49,7 +49,8 @@ var METRICS_FUNCTIONS = []string{
 	view.FUNCTION_RSPREAD, view.FUNCTION_STDDEV, view.FUNCTION_APDEX,
 	view.FUNCTION_UNIQ, view.FUNCTION_UNIQ_EXACT, view.FUNCTION_PERCENTAG,
 	view.FUNCTION_PERSECOND, view.FUNCTION_HISTOGRAM, view.FUNCTION_LAST, view.FUNCTION_COUNT,
-	view.FUNCTION_TOPK, view.FUNCTION_ANY,
+	view.FUNCTION_TOPK, view.FUNCTION_ANY, view.FUNCTION_MOVING_AVG, view.FUNCTION_CUMULATIVE,
+	view.FUNCTION_EWMA, view.FUNCTION_DIFFERENCE, view.FUNCTION_RATE,
 }
 
 var METRICS_FUNCTIONS_MAP = map[string]*Function{
@@ -75,6 +76,11 @@ var METRICS_FUNCTIONS_MAP = map[string]*Function{
 	view.FUNCTION_ANY:           NewFunction(view.FUNCTION_ANY, FUNCTION_TYPE_AGG, []int{METRICS_TYPE_TAG}, "$unit", 0, false, "String"),
 	view.FUNCTION_DERIVATIVE:    NewFunction(view.FUNCTION_DERIVATIVE, FUNCTION_TYPE_AGG, []int{METRICS_TYPE_COUNTER}, "$unit", 0, true, "Number"),
 	view.FUNCTION_COUNTDISTINCT: NewFunction(view.FUNCTION_COUNTDISTINCT, FUNCTION_TYPE_AGG, []int{METRICS_TYPE_TAG}, "$unit", 0, false, "Number"),
+	view.FUNCTION_MOVING_AVG:    NewFunction(view.FUNCTION_MOVING_AVG, FUNCTION_TYPE_WINDOW, nil, "$unit", 1, true, "Number"),
+	view.FUNCTION_CUMULATIVE:    NewFunction(view.FUNCTION_CUMULATIVE, FUNCTION_TYPE_WINDOW, nil, "$unit", 0, true, "Number"),
+	view.FUNCTION_EWMA:          NewFunction(view.FUNCTION_EWMA, FUNCTION_TYPE_WINDOW, nil, "$unit", 1, true, "Number"),
+	view.FUNCTION_DIFFERENCE:    NewFunction(view.FUNCTION_DIFFERENCE, FUNCTION_TYPE_WINDOW, nil, "$unit", 0, true, "Number"),
+	view.FUNCTION_RATE:          NewFunction(view.FUNCTION_RATE, FUNCTION_TYPE_WINDOW, nil, "$unit/s", 0, true, "Number"),
 }
 
 func GetFunctionDescriptions() (*common.Result, error) {
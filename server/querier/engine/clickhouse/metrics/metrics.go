@@ -293,6 +293,8 @@ func GetMetricsByDBTableStatic(db string, table string) map[string]*Metrics {
 			return GetL7FlowLogMetrics()
 		case "l7_packet":
 			return GetL7PacketMetrics()
+		case "trace_analysis":
+			return GetTraceAnalysisMetrics()
 		}
 	case "flow_metrics":
 		switch table {
@@ -315,6 +317,8 @@ func GetMetricsByDBTableStatic(db string, table string) map[string]*Metrics {
 			return GetResourcePerfEventMetrics()
 		case "alert_event":
 			return GetAlarmEventMetrics()
+		case "slow_query_event":
+			return GetSlowQueryEventMetrics()
 		}
 	case ckcommon.DB_NAME_PROFILE:
 		switch table {
@@ -628,6 +632,9 @@ func MergeMetrics(db string, table string, loadMetrics map[string]*Metrics) erro
 		case "l7_packet":
 			metrics = L7_PACKET_METRICS
 			replaceMetrics = L7_PACKET_METRICS_REPLACE
+		case "trace_analysis":
+			metrics = TRACE_ANALYSIS_METRICS
+			replaceMetrics = TRACE_ANALYSIS_METRICS_REPLACE
 		case "l7_flow_log":
 			metrics = L7_FLOW_LOG_METRICS
 			replaceMetrics = L7_FLOW_LOG_METRICS_REPLACE
@@ -661,6 +668,9 @@ func MergeMetrics(db string, table string, loadMetrics map[string]*Metrics) erro
 		case "alert_event":
 			metrics = ALARM_EVENT_METRICS
 			replaceMetrics = ALARM_EVENT_METRICS_REPLACE
+		case "slow_query_event":
+			metrics = SLOW_QUERY_EVENT_METRICS
+			replaceMetrics = SLOW_QUERY_EVENT_METRICS_REPLACE
 		}
 	case ckcommon.DB_NAME_PROFILE:
 		switch table {
@@ -0,0 +1,641 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clickhouse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/client"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/view"
+	"github.com/deepflowio/deepflow/server/querier/parse"
+)
+
+// Time-shift comparison, e.g.:
+//
+//	SELECT pod, Avg(rrt) AS rrt, Avg(rrt) OFFSET 1d AS rrt_yesterday, Avg(rrt) / (Avg(rrt) OFFSET 1d) AS rrt_ratio,
+//	       time(time, 60) AS toi FROM vtap_app_port WHERE time>=1700000000 AND time<=1700003600 GROUP BY pod, toi
+//
+// Metrics without OFFSET are calculated by the base query, metrics of each distinct OFFSET are calculated by a
+// query whose time range is shifted back by the offset and whose time bucket is shifted forward by the offset,
+// all the queries are joined on the group by tags and the time bucket.
+const (
+	OFFSET_BASE_TABLE        = "b"
+	OFFSET_TABLE_PREFIX      = "o"
+	OFFSET_BASE_PLACEHOLDER  = "__base_"
+	OFFSET_PLACEHOLDER       = "__offset_"
+	OFFSET_TIME_PLACEHOLDER  = "__offset_time"
+	OFFSET_JOIN_SETTINGS     = " SETTINGS join_use_nulls = 1"
+	OFFSET_WHERE_KEYWORD     = "where"
+	OFFSET_GROUP_BY_KEYWORD  = "group by"
+	OFFSET_HAVING_KEYWORD    = "having"
+	OFFSET_ORDER_BY_KEYWORD  = "order by"
+	OFFSET_LIMIT_KEYWORD     = "limit"
+	OFFSET_FROM_KEYWORD      = "from"
+	OFFSET_SELECT_KEYWORD    = "select"
+	OFFSET_TIME_FUNCTION_PRE = "time("
+)
+
+var checkOffsetSqlRegexp = regexp.MustCompile(`(?i)\)\s+OFFSET\s+\d+[smhdw]\b`)
+var offsetRegexp = regexp.MustCompile(`(?i)\)\s+OFFSET\s+(\d+)([smhdw])\b`)
+var offsetAliasRegexp = regexp.MustCompile("(?i)\\s+AS\\s+(`[^`]+`|[\\w.]+)\\s*$")
+var offsetFunctionRegexp = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*\s*\(`)
+var offsetTimeWhereRegexp = regexp.MustCompile("(?i)(^|[^\\w`])(`?time`?\\s*(?:>=|<=|>|<|=)\\s*)(\\d+)")
+
+var offsetUnitSeconds = map[string]int{
+	"s": 1,
+	"m": 60,
+	"h": 3600,
+	"d": 86400,
+	"w": 604800,
+}
+
+// offset clauses in the order they appear in sql
+var offsetClauseKeywords = []string{
+	OFFSET_WHERE_KEYWORD, OFFSET_GROUP_BY_KEYWORD, OFFSET_HAVING_KEYWORD, OFFSET_ORDER_BY_KEYWORD, OFFSET_LIMIT_KEYWORD,
+}
+
+type offsetTerm struct {
+	Expr        string // metric function without OFFSET modifier
+	Placeholder string // column alias of the metric in base query or offset query
+	Offset      int    // seconds, 0 means the metric is calculated by base query
+}
+
+type offsetItem struct {
+	Raw   string // original select item, used as is when it has no OFFSET
+	Name  string // output column name
+	Expr  string // outer expression with placeholders, empty when it has no OFFSET
+	Terms []*offsetTerm
+}
+
+func (e *CHEngine) QueryOffsetSql(sql string, args *common.QuerierParams) (*common.Result, *client.Debug, error) {
+	e.subTimeRanges = nil
+	if len(args.Clusters) > 0 && checkOffsetSqlRegexp.MatchString(sql) {
+		return nil, nil, common.NewError(common.INVALID_PARAMETERS, "OFFSET is not supported by federated queries")
+	}
+	sql, callbacks, columnSchemaMap, err := e.ParseOffsetSql(sql, args)
+	if err != nil {
+		log.Error(err)
+		return nil, nil, err
+	}
+	if sql == "" {
+		return nil, nil, nil
+	}
+
+	query_uuid := args.QueryUUID
+	debug := &client.Debug{
+		IP:        config.Cfg.Clickhouse.Host,
+		QueryUUID: query_uuid,
+	}
+	debug.Sql = sql
+	chClient := client.Client{
+		Host:     config.Cfg.Clickhouse.Host,
+		Port:     config.Cfg.Clickhouse.Port,
+		UserName: config.Cfg.Clickhouse.User,
+		Password: config.Cfg.Clickhouse.Password,
+		DB:       e.DB,
+		Debug:    debug,
+		Context:  e.Context,
+	}
+	params := &client.QueryParams{
+		Sql:             sql,
+		Callbacks:       callbacks,
+		QueryUUID:       query_uuid,
+		ColumnSchemaMap: columnSchemaMap,
+		ORGID:           args.ORGID,
+		UserID:          args.UserID,
+		ResultWriter:    args.ResultWriter,
+		TimeRanges:      e.subTimeRanges,
+	}
+	rst, err := chClient.DoQuery(params)
+	if err != nil {
+		log.Error(err)
+		return nil, debug, err
+	}
+	return rst, debug, err
+}
+
+// ParseOffsetSql translates sql with OFFSET modifiers into a join of the base query and the time-shifted queries,
+// it returns an empty sql if there is no OFFSET modifier.
+func (e *CHEngine) ParseOffsetSql(sql string, args *common.QuerierParams) (string, map[string]func(*common.Result) error, map[string]*common.ColumnSchema, error) {
+	if !checkOffsetSqlRegexp.MatchString(sql) {
+		return "", nil, nil, nil
+	}
+	sql = strings.TrimSpace(sql)
+	if !strings.HasPrefix(strings.ToLower(sql), OFFSET_SELECT_KEYWORD) {
+		return "", nil, nil, fmt.Errorf("OFFSET is only supported in SELECT: %s", sql)
+	}
+	fromIndex := indexTopLevelKeyword(sql, OFFSET_FROM_KEYWORD, 0)
+	if fromIndex < 0 {
+		return "", nil, nil, fmt.Errorf("OFFSET requires FROM: %s", sql)
+	}
+	selectSql := sql[len(OFFSET_SELECT_KEYWORD):fromIndex]
+	clauses := splitOffsetClauses(sql[fromIndex:])
+
+	items := []*offsetItem{}
+	offsets := []int{}
+	offsetTerms := map[int][]*offsetTerm{}
+	baseTerms := []*offsetTerm{}
+	derivedNames := map[string]bool{}
+	placeholderIndex := 0
+	for _, raw := range splitTopLevel(selectSql, ',') {
+		item, err := parseOffsetItem(strings.TrimSpace(raw), &placeholderIndex)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		items = append(items, item)
+		if item.Expr == "" {
+			continue
+		}
+		derivedNames[item.Name] = true
+		for _, term := range item.Terms {
+			if term.Offset == 0 {
+				baseTerms = append(baseTerms, term)
+				continue
+			}
+			if _, ok := offsetTerms[term.Offset]; !ok {
+				offsets = append(offsets, term.Offset)
+			}
+			offsetTerms[term.Offset] = append(offsetTerms[term.Offset], term)
+		}
+	}
+	sort.Ints(offsets)
+
+	whereSql := clauses[OFFSET_WHERE_KEYWORD]
+	if !offsetTimeWhereRegexp.MatchString(whereSql) {
+		return "", nil, nil, fmt.Errorf("OFFSET requires a time range in WHERE: %s", sql)
+	}
+
+	// group by tags are the join keys, the time bucket is shifted before joining
+	groupKeys := []string{}
+	timeKey := ""
+	groupSql := clauses[OFFSET_GROUP_BY_KEYWORD]
+	if groupSql != "" {
+		for _, key := range splitTopLevel(groupSql[len(OFFSET_GROUP_BY_KEYWORD):], ',') {
+			groupKeys = append(groupKeys, strings.Trim(strings.TrimSpace(key), "`"))
+		}
+	}
+	tagItems := []string{}
+	for _, item := range items {
+		if item.Expr != "" || !slices.Contains(groupKeys, item.Name) {
+			continue
+		}
+		tagItems = append(tagItems, item.Raw)
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(item.Raw)), OFFSET_TIME_FUNCTION_PRE) {
+			timeKey = item.Name
+		}
+	}
+
+	outputNames := map[string]bool{}
+	for _, item := range items {
+		outputNames[item.Name] = true
+	}
+	baseOrders, outerOrders := splitOffsetOrders(clauses[OFFSET_ORDER_BY_KEYWORD], outputNames, derivedNames)
+
+	// base query
+	baseSelects := []string{}
+	for _, item := range items {
+		if item.Expr == "" {
+			baseSelects = append(baseSelects, item.Raw)
+		}
+	}
+	for _, term := range baseTerms {
+		baseSelects = append(baseSelects, fmt.Sprintf("%s AS `%s`", term.Expr, term.Placeholder))
+	}
+	baseSql := OFFSET_SELECT_KEYWORD + " " + strings.Join(baseSelects, ", ") + " " + clauses[OFFSET_FROM_KEYWORD] + whereSql + groupSql + clauses[OFFSET_HAVING_KEYWORD]
+	if len(baseOrders) > 0 {
+		baseSql += OFFSET_ORDER_BY_KEYWORD + " " + strings.Join(baseOrders, ", ") + " "
+	}
+	baseSql += clauses[OFFSET_LIMIT_KEYWORD]
+	baseEngine, baseTransSql, err := e.transSubSql(baseSql, nil)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	callbacks := baseEngine.View.GetCallbacks()
+	columnSchemaMap := make(map[string]*common.ColumnSchema)
+	for _, columnSchema := range baseEngine.ColumnSchemas {
+		columnSchemaMap[columnSchema.Name] = columnSchema
+	}
+
+	// time-shifted queries only keep the rows whose keys are in the result of base query, so every base row can find
+	// its peer within the limit of base query
+	joinSqls := []string{}
+	offsetTables := map[int]string{}
+	for i, offset := range offsets {
+		offsetSelects := append([]string{}, tagItems...)
+		for _, term := range offsetTerms[offset] {
+			offsetSelects = append(offsetSelects, fmt.Sprintf("%s AS `%s`", term.Expr, term.Placeholder))
+		}
+		offsetWhereSql := shiftTimeFilter(whereSql, offset)
+		offsetSql := OFFSET_SELECT_KEYWORD + " " + strings.Join(offsetSelects, ", ") + " " + clauses[OFFSET_FROM_KEYWORD] + offsetWhereSql + groupSql
+		offsetEngine, offsetTransSql, err := e.transSubSql(strings.TrimSpace(offsetSql), func(m *view.Model) {
+			if having := offsetKeysHaving(groupKeys, timeKey, offset, baseTransSql); having != "" {
+				m.AddHaving(&view.Filters{Expr: &view.Expr{Value: having}})
+			}
+			m.Limit.Limit = baseEngine.Model.Limit.Limit
+		})
+		if err != nil {
+			return "", nil, nil, err
+		}
+		for _, columnSchema := range offsetEngine.ColumnSchemas {
+			if _, ok := columnSchemaMap[columnSchema.Name]; !ok {
+				columnSchemaMap[columnSchema.Name] = columnSchema
+			}
+		}
+
+		table := OFFSET_TABLE_PREFIX + strconv.Itoa(i)
+		offsetTables[offset] = table
+		conditions := []string{}
+		for _, key := range groupKeys {
+			if key == timeKey {
+				conditions = append(conditions, fmt.Sprintf("%s.`%s` = %s.`%s`", OFFSET_BASE_TABLE, key, table, OFFSET_TIME_PLACEHOLDER))
+			} else {
+				conditions = append(conditions, fmt.Sprintf("%s.`%s` = %s.`%s`", OFFSET_BASE_TABLE, key, table, key))
+			}
+		}
+		if timeKey != "" {
+			offsetTransSql = fmt.Sprintf("SELECT *, `%s` + %d AS `%s` FROM (%s)", timeKey, offset, OFFSET_TIME_PLACEHOLDER, offsetTransSql)
+		}
+		if len(conditions) == 0 {
+			joinSqls = append(joinSqls, fmt.Sprintf("CROSS JOIN (%s) AS %s", offsetTransSql, table))
+		} else {
+			joinSqls = append(joinSqls, fmt.Sprintf("LEFT JOIN (%s) AS %s ON %s", offsetTransSql, table, strings.Join(conditions, " AND ")))
+		}
+	}
+
+	// outer query keeps the order of select items
+	outerSelects := []string{}
+	for _, item := range items {
+		if item.Expr == "" {
+			outerSelects = append(outerSelects, fmt.Sprintf("%s.`%s` AS `%s`", OFFSET_BASE_TABLE, item.Name, item.Name))
+			continue
+		}
+		expr := item.Expr
+		for _, term := range item.Terms {
+			table := OFFSET_BASE_TABLE
+			if term.Offset != 0 {
+				table = offsetTables[term.Offset]
+			}
+			expr = strings.Replace(expr, term.Placeholder, fmt.Sprintf("%s.`%s`", table, term.Placeholder), 1)
+			delete(columnSchemaMap, term.Placeholder)
+		}
+		outerSelects = append(outerSelects, fmt.Sprintf("%s AS `%s`", expr, item.Name))
+		if _, ok := columnSchemaMap[item.Name]; !ok {
+			columnSchemaMap[item.Name] = common.NewColumnSchema(item.Name, "", "")
+		}
+	}
+	outerSql := fmt.Sprintf("SELECT %s FROM (%s) AS %s %s", strings.Join(outerSelects, ", "), baseTransSql, OFFSET_BASE_TABLE, strings.Join(joinSqls, " "))
+	if len(outerOrders) > 0 {
+		outerSql += " ORDER BY " + strings.Join(outerOrders, ", ")
+	}
+	outerSql += OFFSET_JOIN_SETTINGS
+	return outerSql, callbacks, columnSchemaMap, nil
+}
+
+// offsetKeysHaving returns the condition which restricts the time-shifted query to the group by keys of base query,
+// the time bucket of time-shifted query is shifted forward by offset before matching
+func offsetKeysHaving(groupKeys []string, timeKey string, offset int, baseSql string) string {
+	if len(groupKeys) == 0 {
+		return ""
+	}
+	keys, baseKeys := []string{}, []string{}
+	for _, key := range groupKeys {
+		if key == timeKey {
+			keys = append(keys, fmt.Sprintf("`%s` + %d", key, offset))
+		} else {
+			keys = append(keys, fmt.Sprintf("`%s`", key))
+		}
+		baseKeys = append(baseKeys, fmt.Sprintf("`%s`", key))
+	}
+	return fmt.Sprintf("(%s) IN (SELECT %s FROM (%s))", strings.Join(keys, ", "), strings.Join(baseKeys, ", "), baseSql)
+}
+
+// ShiftTimeFilter shifts the time filters in the where clause of sql back by offset seconds, so that the same
+// query can be executed over a previous period
+func ShiftTimeFilter(sql string, offset int) (string, error) {
+	sql = strings.TrimSpace(sql)
+	fromIndex := indexTopLevelKeyword(sql, OFFSET_FROM_KEYWORD, 0)
+	if fromIndex < 0 {
+		return "", errors.New("FROM not found in sql")
+	}
+	whereIndex := indexTopLevelKeyword(sql, OFFSET_WHERE_KEYWORD, fromIndex)
+	whereSql := splitOffsetClauses(sql[fromIndex:])[OFFSET_WHERE_KEYWORD]
+	if whereIndex < 0 || !offsetTimeWhereRegexp.MatchString(whereSql) {
+		return "", errors.New("time range not found in WHERE")
+	}
+	// clauses start with the keyword in lowercase, only the condition is replaced
+	condition := strings.TrimSpace(whereSql[len(OFFSET_WHERE_KEYWORD):])
+	return sql[:whereIndex] + strings.Replace(sql[whereIndex:], condition, shiftTimeFilter(condition, offset), 1), nil
+}
+
+func shiftTimeFilter(whereSql string, offset int) string {
+	return offsetTimeWhereRegexp.ReplaceAllStringFunc(whereSql, func(s string) string {
+		match := offsetTimeWhereRegexp.FindStringSubmatch(s)
+		value, _ := strconv.Atoi(match[3])
+		return match[1] + match[2] + strconv.Itoa(value-offset)
+	})
+}
+
+// AddTimeFilter restricts sql to the time range [start, end], the original where condition is kept in parentheses
+func AddTimeFilter(sql string, start, end int64) (string, error) {
+	sql = strings.TrimSpace(sql)
+	fromIndex := indexTopLevelKeyword(sql, OFFSET_FROM_KEYWORD, 0)
+	if fromIndex < 0 {
+		return "", errors.New("FROM not found in sql")
+	}
+	timeFilter := fmt.Sprintf("time>=%d AND time<=%d", start, end)
+	// the clause after where, or the end of sql
+	clauseIndex := len(sql)
+	for _, keyword := range offsetClauseKeywords[1:] {
+		if index := indexTopLevelKeyword(sql, keyword, fromIndex); index >= 0 && index < clauseIndex {
+			clauseIndex = index
+		}
+	}
+	whereIndex := indexTopLevelKeyword(sql, OFFSET_WHERE_KEYWORD, fromIndex)
+	if whereIndex < 0 || whereIndex > clauseIndex {
+		return strings.TrimSpace(strings.TrimSpace(sql[:clauseIndex]) + " WHERE " + timeFilter + " " + sql[clauseIndex:]), nil
+	}
+	condition := strings.TrimSpace(sql[whereIndex+len(OFFSET_WHERE_KEYWORD) : clauseIndex])
+	return strings.TrimSpace(sql[:whereIndex] + "WHERE " + timeFilter + " AND (" + condition + ") " + sql[clauseIndex:]), nil
+}
+
+// transSubSql translates sql generated from the original one with a new engine, format modifies the model before
+// the default limit is applied if it is not nil
+func (e *CHEngine) transSubSql(sql string, format func(m *view.Model)) (*CHEngine, string, error) {
+	subEngine := &CHEngine{DB: e.DB, DataSource: e.DataSource, Context: e.Context, ORGID: e.ORGID}
+	subEngine.Init()
+	subParser := parse.Parser{Engine: subEngine}
+	err := subParser.ParseSQL(sql)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, stmt := range subEngine.Statements {
+		stmt.Format(subEngine.Model)
+	}
+	if format != nil {
+		format(subEngine.Model)
+	}
+	e.formatModel(subEngine.Model)
+	subEngine.View = view.NewView(subEngine.Model)
+	e.subTimeRanges = append(e.subTimeRanges, subEngine.timeRange())
+	return subEngine, subEngine.ToSQLString(), nil
+}
+
+// parseOffsetItem replaces metrics with OFFSET in a select item by placeholders, the other metrics in the same item
+// are replaced by placeholders calculated by base query
+func parseOffsetItem(raw string, placeholderIndex *int) (*offsetItem, error) {
+	item := &offsetItem{Raw: raw}
+	expr := raw
+	if alias := offsetAliasRegexp.FindStringSubmatch(raw); alias != nil {
+		item.Name = strings.Trim(alias[1], "`")
+		expr = raw[:len(raw)-len(alias[0])]
+	} else {
+		item.Name = strings.Trim(raw, "`")
+	}
+	matches := offsetRegexp.FindAllStringSubmatchIndex(expr, -1)
+	if len(matches) == 0 {
+		return item, nil
+	}
+	if item.Name == raw {
+		return nil, fmt.Errorf("metric with OFFSET requires an alias: %s", raw)
+	}
+	for i := len(matches) - 1; i >= 0; i-- {
+		match := matches[i]
+		start := indexOpenParenthesis(expr, match[0])
+		if start < 0 {
+			return nil, fmt.Errorf("unmatched parenthesis: %s", raw)
+		}
+		for start > 0 && isIdentifierByte(expr[start-1]) {
+			start--
+		}
+		if !isIdentifierByte(expr[start]) {
+			return nil, fmt.Errorf("OFFSET must follow a metric function: %s", raw)
+		}
+		value, _ := strconv.Atoi(expr[match[2]:match[3]])
+		offset := value * offsetUnitSeconds[strings.ToLower(expr[match[4]:match[5]])]
+		if offset <= 0 {
+			return nil, fmt.Errorf("OFFSET must be positive: %s", raw)
+		}
+		term := &offsetTerm{Expr: expr[start : match[0]+1], Placeholder: OFFSET_PLACEHOLDER + strconv.Itoa(*placeholderIndex), Offset: offset}
+		*placeholderIndex++
+		item.Terms = append(item.Terms, term)
+		expr = expr[:start] + term.Placeholder + expr[match[1]:]
+	}
+	// the other metrics are calculated by base query
+	for pos := 0; pos < len(expr); {
+		loc := offsetFunctionRegexp.FindStringIndex(expr[pos:])
+		if loc == nil {
+			break
+		}
+		start, open := pos+loc[0], pos+loc[1]-1
+		end := indexCloseParenthesis(expr, open)
+		if end < 0 {
+			return nil, fmt.Errorf("unmatched parenthesis: %s", raw)
+		}
+		term := &offsetTerm{Expr: expr[start : end+1], Placeholder: OFFSET_BASE_PLACEHOLDER + strconv.Itoa(*placeholderIndex)}
+		*placeholderIndex++
+		item.Terms = append(item.Terms, term)
+		expr = expr[:start] + term.Placeholder + expr[end+1:]
+		pos = start + len(term.Placeholder)
+	}
+	item.Expr = strings.TrimSpace(expr)
+	return item, nil
+}
+
+// splitOffsetOrders returns order by items of base query and outer query, base query can not order by the items
+// calculated with OFFSET, outer query can only order by output columns
+func splitOffsetOrders(orderSql string, outputNames, derivedNames map[string]bool) ([]string, []string) {
+	baseOrders, outerOrders := []string{}, []string{}
+	if orderSql == "" {
+		return baseOrders, outerOrders
+	}
+	for _, order := range splitTopLevel(orderSql[len(OFFSET_ORDER_BY_KEYWORD):], ',') {
+		order = strings.TrimSpace(order)
+		fields := strings.Fields(order)
+		name := strings.Trim(fields[0], "`")
+		direction := ""
+		if len(fields) > 1 {
+			direction = " " + fields[len(fields)-1]
+		}
+		if !derivedNames[name] {
+			baseOrders = append(baseOrders, order)
+		}
+		if outputNames[name] {
+			outerOrders = append(outerOrders, fmt.Sprintf("`%s`%s", name, direction))
+		}
+	}
+	return baseOrders, outerOrders
+}
+
+// splitOffsetClauses splits sql from FROM into clauses, each clause starts with its keyword in lowercase and ends
+// with a space
+func splitOffsetClauses(sql string) map[string]string {
+	keywords := append([]string{OFFSET_FROM_KEYWORD}, offsetClauseKeywords...)
+	indexes := make([]int, len(keywords))
+	for i, keyword := range keywords {
+		indexes[i] = indexTopLevelKeyword(sql, keyword, 0)
+	}
+	clauses := map[string]string{}
+	for i, keyword := range keywords {
+		if indexes[i] < 0 {
+			continue
+		}
+		end := len(sql)
+		for j := i + 1; j < len(keywords); j++ {
+			if indexes[j] > indexes[i] {
+				end = indexes[j]
+				break
+			}
+		}
+		clauses[keyword] = keyword + " " + strings.TrimSpace(sql[indexes[i]+len(keyword):end]) + " "
+	}
+	return clauses
+}
+
+// indexTopLevelKeyword returns the index of keyword outside of parentheses and quotes, case insensitive
+func indexTopLevelKeyword(sql, keyword string, from int) int {
+	lowerSql := strings.ToLower(sql)
+	keywordFields := strings.Fields(keyword)
+	depth := 0
+	var quote byte
+	for i := from; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+			continue
+		case '(':
+			depth++
+			continue
+		case ')':
+			depth--
+			continue
+		}
+		if depth != 0 || (i > 0 && !isSpaceByte(sql[i-1])) {
+			continue
+		}
+		end := i
+		matched := true
+		for k, field := range keywordFields {
+			if k > 0 {
+				if end >= len(sql) || !isSpaceByte(sql[end]) {
+					matched = false
+					break
+				}
+				for end < len(sql) && isSpaceByte(sql[end]) {
+					end++
+				}
+			}
+			if !strings.HasPrefix(lowerSql[end:], field) {
+				matched = false
+				break
+			}
+			end += len(field)
+		}
+		if matched && (end == len(sql) || isSpaceByte(sql[end])) {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits sql by sep outside of parentheses and quotes
+func splitTopLevel(sql string, sep byte) []string {
+	result := []string{}
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				result = append(result, sql[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(result, sql[start:])
+}
+
+// indexOpenParenthesis returns the index of '(' matching the ')' at index close
+func indexOpenParenthesis(sql string, close int) int {
+	depth := 0
+	for i := close; i >= 0; i-- {
+		switch sql[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// indexCloseParenthesis returns the index of ')' matching the '(' at index open
+func indexCloseParenthesis(sql string, open int) int {
+	depth := 0
+	for i := open; i < len(sql); i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clickhouse
+
+import (
+	"testing"
+)
+
+func TestShiftTimeFilter(t *testing.T) {
+	sql := "SELECT time(time, 60) AS time_60, Avg(rrt) AS rrt FROM application.1m WHERE time>=86400 AND time<=90000 AND pod_0 IN (SELECT pod_0 FROM application.1m WHERE time>=86400) GROUP BY time_60"
+	want := "SELECT time(time, 60) AS time_60, Avg(rrt) AS rrt FROM application.1m WHERE time>=0 AND time<=3600 AND pod_0 IN (SELECT pod_0 FROM application.1m WHERE time>=0) GROUP BY time_60"
+	got, err := ShiftTimeFilter(sql, 86400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("ShiftTimeFilter: %s, want %s", got, want)
+	}
+	if _, err := ShiftTimeFilter("SELECT Avg(rrt) AS rrt FROM application.1m WHERE pod_0='a'", 86400); err == nil {
+		t.Errorf("sql without time range should not be shifted")
+	}
+}
+
+func TestAddTimeFilter(t *testing.T) {
+	cases := []struct {
+		sql  string
+		want string
+	}{
+		{
+			"SELECT Sum(request) AS value FROM application.1m",
+			"SELECT Sum(request) AS value FROM application.1m WHERE time>=0 AND time<=3600",
+		},
+		{
+			"SELECT Sum(request) AS value FROM application.1m WHERE pod_service_0='a' OR pod_service_0='b' LIMIT 1",
+			"SELECT Sum(request) AS value FROM application.1m WHERE time>=0 AND time<=3600 AND (pod_service_0='a' OR pod_service_0='b') LIMIT 1",
+		},
+		{
+			"SELECT Sum(request) AS value, pod_0 FROM application.1m WHERE pod_0 IN (SELECT pod_0 FROM application.1m LIMIT 1) GROUP BY pod_0",
+			"SELECT Sum(request) AS value, pod_0 FROM application.1m WHERE time>=0 AND time<=3600 AND (pod_0 IN (SELECT pod_0 FROM application.1m LIMIT 1)) GROUP BY pod_0",
+		},
+		{
+			"SELECT Sum(request) AS value, pod_0 FROM application.1m GROUP BY pod_0",
+			"SELECT Sum(request) AS value, pod_0 FROM application.1m WHERE time>=0 AND time<=3600 GROUP BY pod_0",
+		},
+	}
+	for _, c := range cases {
+		got, err := AddTimeFilter(c.sql, 0, 3600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("AddTimeFilter: %s, want %s", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clickhouse
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+	chCommon "github.com/deepflowio/deepflow/server/querier/engine/clickhouse/common"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/view"
+)
+
+const (
+	PAGINATION_CURSOR_TIME = "__cursor_time"
+	PAGINATION_CURSOR_ID   = "__cursor_id"
+)
+
+// tables with the `time` and `_id` columns, the high 32 bits of `_id` is the time
+var PAGINATION_TABLES = map[string][]string{
+	chCommon.DB_NAME_FLOW_LOG:        []string{"l4_flow_log", "l7_flow_log"},
+	chCommon.DB_NAME_APPLICATION_LOG: []string{"log"},
+}
+
+// Cursor is the position of the last row of a page, the next page starts after it
+type Cursor struct {
+	Time uint32
+	ID   uint64
+}
+
+func ParseCursor(cursor string) (*Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %s: %s", cursor, err)
+	}
+	parts := strings.Split(string(decoded), ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor %s", cursor)
+	}
+	t, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %s: %s", cursor, err)
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %s: %s", cursor, err)
+	}
+	return &Cursor{Time: uint32(t), ID: id}, nil
+}
+
+func (c *Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d,%d", c.Time, c.ID)))
+}
+
+func CheckPaginationSql(sql string) error {
+	upperSql := strings.ToUpper(strings.TrimSpace(sql))
+	if strings.HasPrefix(upperSql, "WITH") || strings.HasPrefix(upperSql, "SHOW") || strings.Contains(upperSql, "SLIMIT") {
+		return common.NewError(common.INVALID_PARAMETERS, "pagination is not supported for WITH, SHOW and SLIMIT sql")
+	}
+	return nil
+}
+
+// TransPagination turns a formatted model into a page of at most pageSize rows ordered by (time, _id) desc,
+// starting after cursor. The cursor of the next page is returned by the callback in Result.NextCursor.
+func (e *CHEngine) TransPagination(pageSize int, cursor string) error {
+	if !slices.Contains(PAGINATION_TABLES[e.DB], e.Table) {
+		return common.NewError(common.INVALID_PARAMETERS, fmt.Sprintf("pagination is not supported for table %s.%s", e.DB, e.Table))
+	}
+	if e.Model.HasAggFunc || !e.Model.Groups.IsNull() {
+		return common.NewError(common.INVALID_PARAMETERS, "pagination is not supported for aggregation sql")
+	}
+	if cursor != "" {
+		c, err := ParseCursor(cursor)
+		if err != nil {
+			return common.NewError(common.INVALID_PARAMETERS, err.Error())
+		}
+		filter := fmt.Sprintf("`time` <= %d AND (`time` < %d OR (`time` = %d AND _id < %d))", c.Time, c.Time, c.Time, c.ID)
+		var expr view.Node = &view.Expr{Value: filter}
+		if e.Model.Filters.Expr != nil {
+			expr = &view.BinaryExpr{
+				Left:  &view.Nested{Expr: e.Model.Filters.Expr},
+				Right: expr,
+				Op:    &view.Operator{Type: view.AND},
+			}
+		}
+		e.Model.Filters.Expr = expr
+	}
+	e.Model.AddTag(&view.Tag{Value: "toUnixTimestamp(`time`)", Alias: PAGINATION_CURSOR_TIME})
+	e.Model.AddTag(&view.Tag{Value: "_id", Alias: PAGINATION_CURSOR_ID})
+	e.Model.Orders = &view.Orders{}
+	e.Model.Orders.Append(&view.Order{SortBy: "time", OrderBy: "desc", IsField: true})
+	e.Model.Orders.Append(&view.Order{SortBy: "_id", OrderBy: "desc", IsField: true})
+	e.Model.Limit.Limit = strconv.Itoa(pageSize)
+	e.Model.Limit.Offset = ""
+	e.Model.AddCallback(PAGINATION_CURSOR_ID, PaginationCursor([]interface{}{pageSize}))
+	return nil
+}
+
+// PaginationCursor removes the cursor columns from result and sets Result.NextCursor when a full page is returned.
+// It is stateful because a streamed result is handled batch by batch.
+func PaginationCursor(args []interface{}) func(result *common.Result) error {
+	pageSize := args[0].(int)
+	rowCount := 0
+	return func(result *common.Result) error {
+		timeIndex, idIndex := -1, -1
+		for i, column := range result.Columns {
+			switch column.(string) {
+			case PAGINATION_CURSOR_TIME:
+				timeIndex = i
+			case PAGINATION_CURSOR_ID:
+				idIndex = i
+			}
+		}
+		if timeIndex < 0 || idIndex < 0 {
+			return fmt.Errorf("pagination cursor columns not found in %v", result.Columns)
+		}
+		rowCount += len(result.Values)
+		if rowCount >= pageSize && len(result.Values) > 0 {
+			lastRow := result.Values[len(result.Values)-1].([]interface{})
+			t, _ := lastRow[timeIndex].(uint32)
+			id, _ := lastRow[idIndex].(uint64)
+			result.NextCursor = (&Cursor{Time: t, ID: id}).String()
+		}
+
+		keep := func(i int) bool { return i != timeIndex && i != idIndex }
+		newColumns := make([]interface{}, 0, len(result.Columns)-2)
+		for i, column := range result.Columns {
+			if keep(i) {
+				newColumns = append(newColumns, column)
+			}
+		}
+		result.Columns = newColumns
+		if len(result.Schemas) == len(result.Columns)+2 {
+			newSchemas := make(common.ColumnSchemas, 0, len(result.Columns))
+			for i, schema := range result.Schemas {
+				if keep(i) {
+					newSchemas = append(newSchemas, schema)
+				}
+			}
+			result.Schemas = newSchemas
+		}
+		for i, value := range result.Values {
+			row := value.([]interface{})
+			newRow := make([]interface{}, 0, len(row)-2)
+			for j, v := range row {
+				if keep(j) {
+					newRow = append(newRow, v)
+				}
+			}
+			result.Values[i] = newRow
+		}
+		return nil
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clickhouse
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/view"
+	"github.com/deepflowio/deepflow/server/querier/parse"
+)
+
+func TestCursor(t *testing.T) {
+	c := &Cursor{Time: 1700000000, ID: 7301444457474574337}
+	got, err := ParseCursor(c.String())
+	if err != nil || *got != *c {
+		t.Errorf("ParseCursor(%s) = %v, %v, want %v", c.String(), got, err, c)
+	}
+	for _, invalid := range []string{"!", "MTcwMDAwMDAwMA", "YSxi"} {
+		if _, err := ParseCursor(invalid); err == nil {
+			t.Errorf("ParseCursor(%s) should fail", invalid)
+		}
+	}
+}
+
+func TestTransPagination(t *testing.T) {
+	Load()
+	cursor := (&Cursor{Time: 1700000000, ID: 100}).String()
+	cases := []struct {
+		input   string
+		cursor  string
+		output  string
+		wantErr bool
+	}{{
+		input:  "select byte from l4_flow_log where protocol=6 or protocol=17 order by byte limit 10",
+		output: "SELECT byte_tx+byte_rx AS `byte`, toUnixTimestamp(`time`) AS `__cursor_time`, _id AS `__cursor_id` FROM flow_log.`l4_flow_log` PREWHERE protocol = 6 OR protocol = 17 ORDER BY `time` desc,`_id` desc LIMIT 5",
+	}, {
+		input:  "select byte from l4_flow_log where protocol=6 or protocol=17",
+		cursor: cursor,
+		output: "SELECT byte_tx+byte_rx AS `byte`, toUnixTimestamp(`time`) AS `__cursor_time`, _id AS `__cursor_id` FROM flow_log.`l4_flow_log` PREWHERE (protocol = 6 OR protocol = 17) AND `time` <= 1700000000 AND (`time` < 1700000000 OR (`time` = 1700000000 AND _id < 100)) ORDER BY `time` desc,`_id` desc LIMIT 5",
+	}, {
+		input:   "select Sum(byte) as sum_byte from l4_flow_log",
+		wantErr: true,
+	}, {
+		input:   "select Sum(byte) as sum_byte from network.1m",
+		wantErr: true,
+	}}
+	for _, c := range cases {
+		e := CHEngine{DB: "flow_log", Context: context.Background()}
+		e.Init()
+		parser := parse.Parser{Engine: &e}
+		if err := parser.ParseSQL(c.input); err != nil {
+			if !c.wantErr {
+				t.Errorf("ParseSQL(%s) failed: %s", c.input, err)
+			}
+			continue
+		}
+		for _, stmt := range e.Statements {
+			stmt.Format(e.Model)
+		}
+		FormatModel(e.Model)
+		err := e.TransPagination(5, c.cursor)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("TransPagination(%s) should fail", c.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("TransPagination(%s) failed: %s", c.input, err)
+			continue
+		}
+		e.View = view.NewView(e.Model)
+		if out := e.ToSQLString(); out != c.output {
+			t.Errorf("TransPagination(%s)\n get: %s\n want: %s", c.input, out, c.output)
+		}
+	}
+}
+
+func TestPaginationCursor(t *testing.T) {
+	callback := PaginationCursor([]interface{}{3})
+	newResult := func(values ...[]interface{}) *common.Result {
+		result := &common.Result{
+			Columns: []interface{}{"byte", PAGINATION_CURSOR_TIME, PAGINATION_CURSOR_ID},
+			Schemas: common.ColumnSchemas{&common.ColumnSchema{Name: "byte"}, &common.ColumnSchema{Name: PAGINATION_CURSOR_TIME}, &common.ColumnSchema{Name: PAGINATION_CURSOR_ID}},
+		}
+		for _, v := range values {
+			result.Values = append(result.Values, v)
+		}
+		return result
+	}
+
+	// the first batch does not fill the page
+	result := newResult([]interface{}{1, uint32(30), uint64(3)}, []interface{}{2, uint32(20), uint64(2)})
+	if err := callback(result); err != nil {
+		t.Fatal(err)
+	}
+	if result.NextCursor != "" {
+		t.Errorf("NextCursor = %s, want empty", result.NextCursor)
+	}
+	wantColumns := []interface{}{"byte"}
+	wantValues := []interface{}{[]interface{}{1}, []interface{}{2}}
+	if !reflect.DeepEqual(result.Columns, wantColumns) || !reflect.DeepEqual(result.Values, wantValues) || len(result.Schemas) != 1 {
+		t.Errorf("get columns %v values %v, want %v %v", result.Columns, result.Values, wantColumns, wantValues)
+	}
+
+	result = newResult([]interface{}{3, uint32(10), uint64(1)})
+	if err := callback(result); err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Cursor{Time: 10, ID: 1}).String(); result.NextCursor != want {
+		t.Errorf("NextCursor = %s, want %s", result.NextCursor, want)
+	}
+}
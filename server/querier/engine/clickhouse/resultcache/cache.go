@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resultcache
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+
+	logging "github.com/op/go-logging"
+
+	"github.com/deepflowio/deepflow/server/libs/lru"
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+	"github.com/deepflowio/deepflow/server/querier/statsd"
+)
+
+var log = logging.MustGetLogger("clickhouse.resultcache")
+
+// Segment is the raw result of a query in an aligned time range, callbacks are not applied to it
+type Segment struct {
+	Columns  []interface{}
+	Schemas  common.ColumnSchemas
+	Values   []interface{}
+	ExpireAt int64 // unix timestamp
+
+	size uint64
+}
+
+// Copy returns a copy of the segment, callbacks modify rows and schemas of the result in place
+func (s *Segment) Copy() *Segment {
+	segment := &Segment{Columns: s.Columns, ExpireAt: s.ExpireAt, size: s.size}
+	segment.Schemas = make(common.ColumnSchemas, 0, len(s.Schemas))
+	for _, schema := range s.Schemas {
+		schemaCopy := *schema
+		segment.Schemas = append(segment.Schemas, &schemaCopy)
+	}
+	segment.Values = make([]interface{}, 0, len(s.Values))
+	for _, value := range s.Values {
+		if record, ok := value.([]interface{}); ok {
+			value = append([]interface{}{}, record...)
+		}
+		segment.Values = append(segment.Values, value)
+	}
+	return segment
+}
+
+func (s *Segment) Size() uint64 {
+	if s.size > 0 {
+		return s.size
+	}
+	size := uint64(unsafe.Sizeof(*s))
+	for _, column := range s.Columns {
+		size += sizeOfValue(column)
+	}
+	size += uint64(len(s.Schemas)) * uint64(unsafe.Sizeof(common.ColumnSchema{}))
+	for _, value := range s.Values {
+		record, ok := value.([]interface{})
+		if !ok {
+			size += sizeOfValue(value)
+			continue
+		}
+		size += uint64(unsafe.Sizeof(record))
+		for _, v := range record {
+			size += sizeOfValue(v)
+		}
+	}
+	s.size = size
+	return size
+}
+
+func sizeOfValue(value interface{}) uint64 {
+	size := uint64(unsafe.Sizeof(value))
+	switch v := value.(type) {
+	case string:
+		size += uint64(len(v))
+	case []string:
+		for _, s := range v {
+			size += uint64(unsafe.Sizeof(s)) + uint64(len(s))
+		}
+	case []interface{}:
+		for _, e := range v {
+			size += sizeOfValue(e)
+		}
+	}
+	return size
+}
+
+// Cache is a LRU cache of segments limited by count and memory, segments are also written to the shared backend
+// if it is enabled, so that other queriers can read them
+type Cache struct {
+	entries    *lru.Cache[string, *Segment]
+	lock       sync.Mutex
+	size       uint64
+	maxSize    uint64
+	maxEntries int
+	ttl        time.Duration
+	shared     Backend
+	counter    *Counter
+	timeNowFn  func() time.Time
+}
+
+func NewCache(cfg *config.SplitCache) *Cache {
+	c := &Cache{
+		// the count limit is checked by Cache, so that the size of evicted segments is known
+		entries:    lru.NewCache[string, *Segment](cfg.MaxEntries + 1),
+		maxSize:    uint64(cfg.MaxMemory) << 20,
+		maxEntries: cfg.MaxEntries,
+		ttl:        time.Duration(cfg.TTL) * time.Second,
+		counter:    &Counter{Stats: &CacheStats{}},
+		timeNowFn:  time.Now,
+	}
+	if cfg.Redis.Enabled {
+		c.shared = NewRedisBackend(&cfg.Redis)
+	}
+	statsd.RegisterCountableForIngester("split_cache_counter", c.counter)
+	return c
+}
+
+// Get returns a copy of the segment in memory or in the shared backend
+func (c *Cache) Get(key string) (*Segment, bool) {
+	now := c.timeNowFn().Unix()
+	c.lock.Lock()
+	segment, ok := c.entries.Get(key)
+	if ok && segment.ExpireAt < now {
+		c.remove(key, segment)
+		ok = false
+	}
+	c.lock.Unlock()
+	if ok {
+		c.counter.hit()
+		return segment.Copy(), true
+	}
+	if c.shared != nil {
+		segment, err := c.shared.Get(key)
+		if err != nil {
+			log.Warningf("get segment %s from shared backend failed: %s", key, err)
+			c.counter.sharedError()
+		} else if segment != nil && segment.ExpireAt >= now {
+			c.counter.sharedHit()
+			c.add(key, segment)
+			return segment.Copy(), true
+		}
+	}
+	c.counter.miss()
+	return nil, false
+}
+
+// Set stores a copy of the segment, the segment is dropped if it is larger than the memory limit
+func (c *Cache) Set(key string, segment *Segment) {
+	segment = segment.Copy()
+	segment.ExpireAt = c.timeNowFn().Add(c.ttl).Unix()
+	if segment.Size() > c.maxSize {
+		c.counter.overflow()
+		return
+	}
+	c.add(key, segment)
+	c.counter.store()
+	if c.shared != nil {
+		if err := c.shared.Set(key, segment, c.ttl); err != nil {
+			log.Warningf("set segment %s to shared backend failed: %s", key, err)
+			c.counter.sharedError()
+		}
+	}
+}
+
+func (c *Cache) add(key string, segment *Segment) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if old, ok := c.entries.Peek(key); ok {
+		c.remove(key, old)
+	}
+	c.entries.Add(key, segment)
+	c.size += segment.Size()
+	for c.entries.Len() > c.maxEntries || c.size > c.maxSize {
+		_, oldest, ok := c.entries.RemoveOldest()
+		if !ok {
+			c.size = 0
+			break
+		}
+		c.size -= oldest.Size()
+		c.counter.evict()
+	}
+	c.counter.setMemory(c.size)
+}
+
+func (c *Cache) remove(key string, segment *Segment) {
+	c.entries.Remove(key)
+	if segment != nil && c.size >= segment.Size() {
+		c.size -= segment.Size()
+	} else {
+		c.size = 0
+	}
+}
+
+func (c *Cache) Counter() *Counter {
+	return c.counter
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resultcache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+)
+
+func newTestSegment(timestamp uint32, value *float64) *Segment {
+	return &Segment{
+		Columns: []interface{}{"time_60", "byte"},
+		Schemas: common.ColumnSchemas{&common.ColumnSchema{Name: "time_60"}, &common.ColumnSchema{Name: "byte", Unit: "字节"}},
+		Values:  []interface{}{[]interface{}{timestamp, value}},
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := NewCache(&config.SplitCache{MaxEntries: 2, MaxMemory: 1, TTL: 60})
+	c.Set("a", newTestSegment(0, nil))
+	c.Set("b", newTestSegment(60, nil))
+	c.Set("c", newTestSegment(120, nil))
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("segment a should be evicted by count")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Errorf("segment c should be cached")
+	}
+
+	c.maxSize = newTestSegment(0, nil).Size() * 3 / 2
+	c.Set("d", newTestSegment(180, nil))
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("segment b should be evicted by memory")
+	}
+	if c.size > c.maxSize {
+		t.Errorf("memory size %d exceeds limit %d", c.size, c.maxSize)
+	}
+
+	c.maxSize = 1
+	c.Set("e", newTestSegment(240, nil))
+	if _, ok := c.Get("e"); ok || c.counter.Stats.CacheSizeOverFlow != 1 {
+		t.Errorf("segment e should be dropped for size overflow")
+	}
+}
+
+func TestCacheExpire(t *testing.T) {
+	c := NewCache(&config.SplitCache{MaxEntries: 10, MaxMemory: 1, TTL: 60})
+	now := time.Now()
+	c.timeNowFn = func() time.Time { return now }
+	c.Set("a", newTestSegment(0, nil))
+	c.timeNowFn = func() time.Time { return now.Add(61 * time.Second) }
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("segment a should be expired")
+	}
+}
+
+func TestCacheCopy(t *testing.T) {
+	c := NewCache(&config.SplitCache{MaxEntries: 10, MaxMemory: 1, TTL: 60})
+	value := 1.5
+	segment := newTestSegment(0, &value)
+	c.Set("a", segment)
+	segment.Values[0].([]interface{})[0] = uint32(60)
+	got, _ := c.Get("a")
+	got.Values = append(got.Values, []interface{}{uint32(120), nil})
+	got, _ = c.Get("a")
+	if len(got.Values) != 1 || got.Values[0].([]interface{})[0] != uint32(0) {
+		t.Errorf("cached segment is modified: %v", got.Values)
+	}
+}
+
+func TestEncodeSegment(t *testing.T) {
+	value := 1.5
+	segment := newTestSegment(60, &value)
+	segment.Values = append(segment.Values, []interface{}{uint32(120), (*float64)(nil)})
+	segment.ExpireAt = 100
+	data, err := EncodeSegment(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeSegment(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Columns, segment.Columns) || !reflect.DeepEqual(got.Schemas, segment.Schemas) || got.ExpireAt != segment.ExpireAt {
+		t.Errorf("decoded segment: %v, want %v", got, segment)
+	}
+	if !reflect.DeepEqual(got.Values, segment.Values) {
+		t.Errorf("decoded values: %#v, want %#v", got.Values, segment.Values)
+	}
+	if nilValue, ok := got.Values[1].([]interface{})[1].(*float64); !ok || nilValue != nil {
+		t.Errorf("typed nil pointer is not kept: %#v", got.Values[1])
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resultcache
+
+import "sync/atomic"
+
+type Counter struct {
+	Stats *CacheStats
+
+	exited bool
+}
+
+type CacheStats struct {
+	CacheHit          uint64 `statsd:"cache_hit"`
+	CacheMiss         uint64 `statsd:"cache_miss"`
+	CacheStore        uint64 `statsd:"cache_store"`
+	CacheEvict        uint64 `statsd:"cache_evict"`
+	CacheSizeOverFlow uint64 `statsd:"cache_size_overflow"`
+	CacheBypass       uint64 `statsd:"cache_bypass"`
+	SharedHit         uint64 `statsd:"shared_hit"`
+	SharedError       uint64 `statsd:"shared_error"`
+	MemorySize        uint64 `statsd:"memory_size"`
+}
+
+func (c *Counter) GetCounter() interface{} {
+	stats := &CacheStats{}
+	stats, c.Stats = c.Stats, stats
+	// memory size is a gauge
+	atomic.StoreUint64(&c.Stats.MemorySize, atomic.LoadUint64(&stats.MemorySize))
+	return stats
+}
+
+func (c *Counter) Close() {
+	c.exited = true
+}
+
+func (c *Counter) Closed() bool {
+	return c.exited
+}
+
+func (c *Counter) hit() {
+	atomic.AddUint64(&c.Stats.CacheHit, 1)
+}
+
+func (c *Counter) miss() {
+	atomic.AddUint64(&c.Stats.CacheMiss, 1)
+}
+
+func (c *Counter) store() {
+	atomic.AddUint64(&c.Stats.CacheStore, 1)
+}
+
+func (c *Counter) evict() {
+	atomic.AddUint64(&c.Stats.CacheEvict, 1)
+}
+
+func (c *Counter) overflow() {
+	atomic.AddUint64(&c.Stats.CacheSizeOverFlow, 1)
+}
+
+// Bypass counts the queries which can not use the cache
+func (c *Counter) Bypass() {
+	atomic.AddUint64(&c.Stats.CacheBypass, 1)
+}
+
+func (c *Counter) sharedHit() {
+	atomic.AddUint64(&c.Stats.SharedHit, 1)
+}
+
+func (c *Counter) sharedError() {
+	atomic.AddUint64(&c.Stats.SharedError, 1)
+}
+
+func (c *Counter) setMemory(size uint64) {
+	atomic.StoreUint64(&c.Stats.MemorySize, size)
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resultcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+)
+
+const REDIS_KEY_PREFIX = "deepflow_querier_split_cache:"
+
+// Backend is the shared storage of segments, Get returns nil if the key does not exist
+type Backend interface {
+	Get(key string) (*Segment, error)
+	Set(key string, segment *Segment, ttl time.Duration) error
+}
+
+type RedisBackend struct {
+	client  redis.UniversalClient
+	timeout time.Duration
+}
+
+func NewRedisBackend(cfg *config.SplitCacheRedis) *RedisBackend {
+	return &RedisBackend{
+		client: redis.NewClient(&redis.Options{
+			Addr:     cfg.Addr,
+			Password: cfg.Password,
+			DB:       cfg.DB,
+		}),
+		timeout: time.Duration(cfg.Timeout) * time.Second,
+	}
+}
+
+func (b *RedisBackend) Get(key string) (*Segment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	data, err := b.client.Get(ctx, REDIS_KEY_PREFIX+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return DecodeSegment(data)
+}
+
+func (b *RedisBackend) Set(key string, segment *Segment, ttl time.Duration) error {
+	data, err := EncodeSegment(segment)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	return b.client.Set(ctx, REDIS_KEY_PREFIX+key, data, ttl).Err()
+}
+
+// encodedSegment is the gob format of Segment, values of nullable columns are pointers which can not be encoded in
+// interfaces by gob, so every value is wrapped by encodedValue
+type encodedSegment struct {
+	Columns  []interface{}
+	Schemas  []encodedSchema
+	Values   [][]encodedValue
+	ExpireAt int64
+}
+
+type encodedSchema struct {
+	Name      string
+	Unit      string
+	Type      int
+	ValueType string
+	PreAS     string
+	LabelType string
+}
+
+type encodedValue struct {
+	Value   interface{}
+	Pointer bool   // Value is the element of a pointer
+	NilType string // type of a nil pointer
+}
+
+var nilPointerTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []interface{}{
+		int8(0), int16(0), int32(0), int64(0), uint8(0), uint16(0), uint32(0), uint64(0), float32(0), float64(0),
+		int(0), uint(0), "", false, time.Time{}, net.IP{},
+		[]int8{}, []int16{}, []int32{}, []int64{}, []uint8{}, []uint16{}, []uint32{}, []uint64{},
+		[]float32{}, []float64{}, []string{}, []interface{}{}, map[string]string{},
+	} {
+		gob.Register(v)
+		pointerType := reflect.PointerTo(reflect.TypeOf(v))
+		nilPointerTypes[pointerType.String()] = pointerType
+	}
+}
+
+func encodeValue(value interface{}) (encodedValue, error) {
+	if value == nil {
+		return encodedValue{}, nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer {
+		return encodedValue{Value: value}, nil
+	}
+	if _, ok := nilPointerTypes[v.Type().String()]; !ok {
+		return encodedValue{}, fmt.Errorf("unsupported type %s", v.Type())
+	}
+	if v.IsNil() {
+		return encodedValue{NilType: v.Type().String()}, nil
+	}
+	return encodedValue{Value: v.Elem().Interface(), Pointer: true}, nil
+}
+
+func decodeValue(value encodedValue) interface{} {
+	if value.NilType != "" {
+		return reflect.Zero(nilPointerTypes[value.NilType]).Interface()
+	}
+	if value.Pointer && value.Value != nil {
+		pointer := reflect.New(reflect.TypeOf(value.Value))
+		pointer.Elem().Set(reflect.ValueOf(value.Value))
+		return pointer.Interface()
+	}
+	return value.Value
+}
+
+func EncodeSegment(segment *Segment) ([]byte, error) {
+	encoded := encodedSegment{Columns: segment.Columns, ExpireAt: segment.ExpireAt}
+	for _, schema := range segment.Schemas {
+		encoded.Schemas = append(encoded.Schemas, encodedSchema(*schema))
+	}
+	for _, value := range segment.Values {
+		record, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unsupported record type %T", value)
+		}
+		encodedRecord := make([]encodedValue, 0, len(record))
+		for _, v := range record {
+			ev, err := encodeValue(v)
+			if err != nil {
+				return nil, err
+			}
+			encodedRecord = append(encodedRecord, ev)
+		}
+		encoded.Values = append(encoded.Values, encodedRecord)
+	}
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(&encoded); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func DecodeSegment(data []byte) (*Segment, error) {
+	encoded := encodedSegment{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&encoded); err != nil {
+		return nil, err
+	}
+	segment := &Segment{Columns: encoded.Columns, ExpireAt: encoded.ExpireAt}
+	for _, schema := range encoded.Schemas {
+		columnSchema := common.ColumnSchema(schema)
+		segment.Schemas = append(segment.Schemas, &columnSchema)
+	}
+	segment.Values = make([]interface{}, 0, len(encoded.Values))
+	for _, encodedRecord := range encoded.Values {
+		record := make([]interface{}, 0, len(encodedRecord))
+		for _, v := range encodedRecord {
+			record = append(record, decodeValue(v))
+		}
+		segment.Values = append(segment.Values, record)
+	}
+	return segment, nil
+}
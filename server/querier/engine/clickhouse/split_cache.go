@@ -0,0 +1,320 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clickhouse
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/client"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/resultcache"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/view"
+)
+
+// Split cache: a query grouped by time(time, interval) is split into segments aligned to a multiple of the
+// interval. Segments in the immutable past are read from the cache, the others and the fresh tail are queried
+// in as few ranges as possible. Raw rows are merged before callbacks (such as time fill) are applied.
+const SPLIT_CACHE_MAX_INTERVAL = 86400 // day intervals are aligned to the timezone of clickhouse
+
+var (
+	splitCache     *resultcache.Cache
+	splitCacheOnce sync.Once
+)
+
+func getSplitCache() *resultcache.Cache {
+	if config.Cfg == nil || !config.Cfg.SplitCache.Enabled {
+		return nil
+	}
+	splitCacheOnce.Do(func() {
+		splitCache = resultcache.NewCache(&config.Cfg.SplitCache)
+	})
+	return splitCache
+}
+
+type splitRange struct {
+	start     int // inclusive
+	end       int // inclusive
+	key       string
+	cacheable bool
+	segment   *resultcache.Segment
+}
+
+type splitTimeBounds struct {
+	start int // inclusive
+	end   int // inclusive
+	// replaces the time filters in where by the given range
+	replace func(start, end int) string
+}
+
+// checkSplitCache returns the segment size if the translated query can be split by time
+func (e *CHEngine) checkSplitCache(args *common.QuerierParams) int {
+	m := e.Model
+	if args.ResultWriter != nil || args.PageSize > 0 || len(args.Clusters) > 0 {
+		return 0
+	}
+	if m.Time.Interval <= 0 || m.Time.Interval >= SPLIT_CACHE_MAX_INTERVAL || m.Time.Alias == "" {
+		return 0
+	}
+	// windows and derivatives span adjacent segments
+	if m.Time.WindowSize > 1 || m.Time.Offset != 0 || e.IsDerivative || e.HasWindowFunction {
+		return 0
+	}
+	if m.Limit.Offset != "" {
+		return 0
+	}
+	for _, node := range m.Orders.Orders {
+		if strings.Trim(node.(*view.Order).SortBy, "`") != strings.Trim(m.Time.Alias, "`") {
+			return 0
+		}
+	}
+	segmentSize := config.Cfg.SplitCache.SegmentSize
+	if segmentSize < m.Time.Interval {
+		segmentSize = m.Time.Interval
+	}
+	return (segmentSize + m.Time.Interval - 1) / m.Time.Interval * m.Time.Interval
+}
+
+// parseSplitTimeBounds finds the time range in the where clause of sql, there must be one lower bound and one
+// upper bound of time
+func parseSplitTimeBounds(sql string) *splitTimeBounds {
+	sql = strings.TrimSpace(sql)
+	fromIndex := indexTopLevelKeyword(sql, OFFSET_FROM_KEYWORD, 0)
+	if fromIndex < 0 {
+		return nil
+	}
+	clauses := splitOffsetClauses(sql[fromIndex:])
+	whereSql := clauses[OFFSET_WHERE_KEYWORD]
+	whereIndex := indexTopLevelKeyword(sql, OFFSET_WHERE_KEYWORD, fromIndex)
+	if whereSql == "" || whereIndex < 0 {
+		return nil
+	}
+	matches := offsetTimeWhereRegexp.FindAllStringSubmatchIndex(whereSql, -1)
+	if len(matches) != 2 {
+		return nil
+	}
+	bounds := &splitTimeBounds{start: -1, end: -1}
+	for _, match := range matches {
+		operator := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(whereSql[match[4]:match[5]]), "`time"))
+		value, err := strconv.Atoi(whereSql[match[6]:match[7]])
+		if err != nil {
+			return nil
+		}
+		switch operator {
+		case ">=":
+			bounds.start = value
+		case ">":
+			bounds.start = value + 1
+		case "<=":
+			bounds.end = value
+		case "<":
+			bounds.end = value - 1
+		default:
+			return nil
+		}
+	}
+	if bounds.start < 0 || bounds.end < bounds.start {
+		return nil
+	}
+	// the rest of sql is kept as it is, so that the split queries are translated the same way
+	prefix := sql[:fromIndex]
+	suffix := sql[fromIndex:]
+	bounds.replace = func(start, end int) string {
+		replaced := []string{fmt.Sprintf("`time` >= %d", start), fmt.Sprintf("`time` <= %d", end)}
+		i := 0
+		newWhereSql := offsetTimeWhereRegexp.ReplaceAllStringFunc(whereSql, func(s string) string {
+			match := offsetTimeWhereRegexp.FindStringSubmatch(s)
+			s = match[1] + replaced[i]
+			i++
+			return s
+		})
+		return prefix + strings.Replace(suffix, strings.TrimSpace(whereSql[len(OFFSET_WHERE_KEYWORD):]), strings.TrimSpace(newWhereSql[len(OFFSET_WHERE_KEYWORD):]), 1)
+	}
+	return bounds
+}
+
+// splitCacheKey is unique for the org, the database and sql without time range
+func (e *CHEngine) splitCacheKey(sql string, segmentSize int) string {
+	sqlWithoutTime := offsetTimeWhereRegexp.ReplaceAllString(sql, "$1$2?")
+	orgID := e.ORGID
+	if orgID == "" {
+		orgID = common.DEFAULT_ORG_ID
+	}
+	return fmt.Sprintf("%s:%x:%d", orgID, md5.Sum([]byte(e.DB+"|"+e.DataSource+"|"+sqlWithoutTime)), segmentSize)
+}
+
+// splitRanges splits [start, end] at multiples of segmentSize, segments end before immutableEnd are cacheable
+func splitRanges(start, end, segmentSize, immutableEnd int, keyPrefix string) []*splitRange {
+	ranges := []*splitRange{}
+	for rangeStart := start; rangeStart <= end; {
+		segmentStart := rangeStart / segmentSize * segmentSize
+		segmentEnd := segmentStart + segmentSize - 1
+		rangeEnd := segmentEnd
+		if rangeEnd > end {
+			rangeEnd = end
+		}
+		r := &splitRange{start: rangeStart, end: rangeEnd}
+		if rangeStart == segmentStart && rangeEnd == segmentEnd && segmentEnd < immutableEnd {
+			r.cacheable = true
+			r.key = fmt.Sprintf("%s:%d", keyPrefix, segmentStart)
+		}
+		ranges = append(ranges, r)
+		rangeStart = rangeEnd + 1
+	}
+	return ranges
+}
+
+// QuerySplitCache returns nil if the query can not use the split cache or the result is truncated by limit, in
+// which case the query should be executed as usual
+func (e *CHEngine) QuerySplitCache(sql string, args *common.QuerierParams, params *client.QueryParams, chClient *client.Client) (*common.Result, error) {
+	cache := getSplitCache()
+	if cache == nil {
+		return nil, nil
+	}
+	// segments may be returned from cache without querying, the whole time range is checked first
+	if err := client.CheckTimeRanges(e.Context, params.TimeRanges); err != nil {
+		return nil, err
+	}
+	segmentSize := e.checkSplitCache(args)
+	bounds := parseSplitTimeBounds(sql)
+	if segmentSize == 0 || bounds == nil {
+		cache.Counter().Bypass()
+		return nil, nil
+	}
+	immutableEnd := int(time.Now().Unix()) - config.Cfg.SplitCache.ImmutableDelay
+	ranges := splitRanges(bounds.start, bounds.end, segmentSize, immutableEnd, e.splitCacheKey(sql, segmentSize))
+	hasCacheable := false
+	for _, r := range ranges {
+		if r.cacheable {
+			hasCacheable = true
+			if segment, ok := cache.Get(r.key); ok {
+				r.segment = segment
+			}
+		}
+	}
+	if !hasCacheable {
+		cache.Counter().Bypass()
+		return nil, nil
+	}
+	limit, _ := strconv.Atoi(e.Model.Limit.Limit)
+	timeAlias := strings.Trim(e.Model.Time.Alias, "`")
+
+	var columns []interface{}
+	var schemas common.ColumnSchemas
+	for _, r := range ranges {
+		if r.segment != nil {
+			columns, schemas = r.segment.Columns, r.segment.Schemas
+			break
+		}
+	}
+	// query the contiguous ranges not cached
+	for i := 0; i < len(ranges); {
+		if ranges[i].segment != nil {
+			i++
+			continue
+		}
+		j := i
+		for j+1 < len(ranges) && ranges[j+1].segment == nil {
+			j++
+		}
+		subEngine, subSql, err := e.transSubSql(bounds.replace(ranges[i].start, ranges[j].end), nil)
+		if err != nil {
+			return nil, err
+		}
+		subParams := *params
+		subParams.Sql = subSql
+		subParams.Callbacks = nil
+		result, err := chClient.DoQuery(&subParams)
+		if err != nil {
+			return nil, err
+		}
+		if limit > 0 && len(result.Values) >= limit {
+			return nil, nil
+		}
+		if columns == nil {
+			columns, schemas = result.Columns, result.Schemas
+		} else if len(columns) != len(result.Columns) {
+			return nil, nil
+		}
+		timeIndex := -1
+		for k, column := range result.Columns {
+			if column.(string) == timeAlias {
+				timeIndex = k
+				break
+			}
+		}
+		if timeIndex < 0 || subEngine.Model.Time.Interval != e.Model.Time.Interval {
+			return nil, nil
+		}
+		for k := i; k <= j; k++ {
+			ranges[k].segment = &resultcache.Segment{Columns: result.Columns, Schemas: result.Schemas}
+		}
+		for _, value := range result.Values {
+			timestamp, ok := value.([]interface{})[timeIndex].(uint32)
+			if !ok {
+				return nil, nil
+			}
+			for k := i; k <= j; k++ {
+				if int(timestamp) >= ranges[k].start/segmentSize*segmentSize && (int(timestamp) <= ranges[k].end || k == j) {
+					ranges[k].segment.Values = append(ranges[k].segment.Values, value)
+					break
+				}
+			}
+		}
+		for k := i; k <= j; k++ {
+			if ranges[k].cacheable {
+				cache.Set(ranges[k].key, ranges[k].segment)
+			}
+		}
+		i = j + 1
+	}
+
+	result := &common.Result{Columns: columns, Schemas: schemas}
+	for _, r := range ranges {
+		result.Values = append(result.Values, r.segment.Values...)
+	}
+	if limit > 0 && len(result.Values) >= limit {
+		return nil, nil
+	}
+	if len(e.Model.Orders.Orders) > 0 {
+		timeIndex := -1
+		for k, column := range result.Columns {
+			if column.(string) == timeAlias {
+				timeIndex = k
+			}
+		}
+		if timeIndex >= 0 {
+			reverse := e.Model.Orders.Orders[0].(*view.Order).OrderBy == "desc"
+			sort.SliceStable(result.Values, func(a, b int) bool {
+				timeA, _ := result.Values[a].([]interface{})[timeIndex].(uint32)
+				timeB, _ := result.Values[b].([]interface{})[timeIndex].(uint32)
+				if reverse {
+					return timeA > timeB
+				}
+				return timeA < timeB
+			})
+		}
+	}
+	client.ApplyCallbacks(result, params.Callbacks)
+	return result, nil
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clickhouse
+
+import (
+	"testing"
+)
+
+func TestParseSplitTimeBounds(t *testing.T) {
+	sql := "SELECT time(time, 60) AS time_60, Sum(byte) AS sum_byte FROM network.1m WHERE time>=3600 AND time<7200 AND ip='1.1.1.1' GROUP BY time_60 ORDER BY time_60 LIMIT 100"
+	bounds := parseSplitTimeBounds(sql)
+	if bounds == nil {
+		t.Fatal("bounds of time not found")
+	}
+	if bounds.start != 3600 || bounds.end != 7199 {
+		t.Errorf("bounds: [%d, %d], want [3600, 7199]", bounds.start, bounds.end)
+	}
+	want := "SELECT time(time, 60) AS time_60, Sum(byte) AS sum_byte FROM network.1m WHERE `time` >= 3600 AND `time` <= 3659 AND ip='1.1.1.1' GROUP BY time_60 ORDER BY time_60 LIMIT 100"
+	if got := bounds.replace(3600, 3659); got != want {
+		t.Errorf("replace: %s, want %s", got, want)
+	}
+
+	for _, sql := range []string{
+		"SELECT Sum(byte) AS sum_byte FROM network.1m WHERE time>=3600",
+		"SELECT Sum(byte) AS sum_byte FROM network.1m WHERE time>=3600 AND time<=7200 OR time>=9000",
+		"SELECT Sum(byte) AS sum_byte FROM network.1m WHERE time=3600 AND time<=7200",
+	} {
+		if bounds := parseSplitTimeBounds(sql); bounds != nil {
+			t.Errorf("bounds of %s should not be found", sql)
+		}
+	}
+}
+
+func TestSplitRanges(t *testing.T) {
+	ranges := splitRanges(3000, 11000, 3600, 10799, "key")
+	type r struct {
+		start, end int
+		key        string
+	}
+	want := []r{{3000, 3599, ""}, {3600, 7199, "key:3600"}, {7200, 10799, ""}, {10800, 11000, ""}}
+	if len(ranges) != len(want) {
+		t.Fatalf("got %d ranges, want %d", len(ranges), len(want))
+	}
+	for i, got := range ranges {
+		if got.start != want[i].start || got.end != want[i].end || got.key != want[i].key || got.cacheable != (want[i].key != "") {
+			t.Errorf("range %d: %+v, want %+v", i, *got, want[i])
+		}
+	}
+}
@@ -56,7 +56,7 @@ var AUTO_CUSTOM_TAG_MAP = map[string][]string{}
 var AUTO_CUSTOM_TAG_CHECK_MAP = map[string][]string{}
 
 var tagNativeTagDB = []string{ckcommon.DB_NAME_EXT_METRICS, ckcommon.DB_NAME_DEEPFLOW_ADMIN, ckcommon.DB_NAME_DEEPFLOW_TENANT, ckcommon.DB_NAME_PROFILE, ckcommon.DB_NAME_PROMETHEUS}
-var noCustomTagTable = []string{"traffic_policy", "l4_packet", "l7_packet", "alert_event"}
+var noCustomTagTable = []string{"traffic_policy", "l4_packet", "l7_packet", "alert_event", "slow_query_event", "trace_analysis"}
 var noCustomTagDB = []string{ckcommon.DB_NAME_DEEPFLOW_ADMIN, ckcommon.DB_NAME_DEEPFLOW_TENANT}
 
 var tagTypeToOperators = map[string][]string{
@@ -19,6 +19,7 @@ package view
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
@@ -55,6 +56,11 @@ const (
 	FUNCTION_ANY           = "Any"
 	FUNCTION_DERIVATIVE    = "nonNegativeDerivative"
 	FUNCTION_COUNTDISTINCT = "countDistinct"
+	FUNCTION_MOVING_AVG    = "MovingAvg"
+	FUNCTION_CUMULATIVE    = "CumulativeSum"
+	FUNCTION_EWMA          = "EWMA"
+	FUNCTION_DIFFERENCE    = "Difference"
+	FUNCTION_RATE          = "Rate"
 )
 
 // 对外提供的算子与数据库实际算子转换
@@ -83,6 +89,12 @@ var FUNC_NAME_MAP map[string]string = map[string]string{
 var MATH_FUNCTIONS = []string{
 	FUNCTION_DIV, FUNCTION_PLUS, FUNCTION_MINUS, FUNCTION_MULTIPLY,
 	FUNCTION_PERCENTAG, FUNCTION_PERSECOND, FUNCTION_HISTOGRAM,
+	FUNCTION_MOVING_AVG, FUNCTION_CUMULATIVE, FUNCTION_EWMA, FUNCTION_DIFFERENCE, FUNCTION_RATE,
+}
+
+// 窗口类算子，作用于相邻的时间桶，按time(time, interval)排序，按其他group by标签分区
+var WINDOW_FUNCTIONS = []string{
+	FUNCTION_MOVING_AVG, FUNCTION_CUMULATIVE, FUNCTION_EWMA, FUNCTION_DIFFERENCE, FUNCTION_RATE,
 }
 
 func GetFunc(name string) Function {
@@ -109,6 +121,8 @@ func GetFunc(name string) Function {
 		return &DelayAvgFunction{DefaultFunction: DefaultFunction{Name: FUNC_NAME_MAP[FUNCTION_AAVG]}}
 	case FUNCTION_DERIVATIVE:
 		return &NonNegativeDerivativeFunction{DefaultFunction: DefaultFunction{Name: name}}
+	case FUNCTION_MOVING_AVG, FUNCTION_CUMULATIVE, FUNCTION_EWMA, FUNCTION_DIFFERENCE, FUNCTION_RATE:
+		return &WindowFunction{DefaultFunction: DefaultFunction{Name: name}}
 	default:
 		return &DefaultFunction{Name: name}
 	}
@@ -980,3 +994,92 @@ func (f *NonNegativeDerivativeFunction) WriteTo(buf *bytes.Buffer) {
 		buf.WriteString("`")
 	}
 }
+
+// WindowFunction 窗口类算子
+// Fields[0]为聚合算子，Fields[1:]为算子参数，例：MovingAvg(Avg(rrt), 5)、EWMA(Sum(byte), 0.3)
+type WindowFunction struct {
+	DefaultFunction
+	Groups *Groups // 用于生成PARTITION BY，WriteTo时group by已全部解析
+}
+
+func (f *WindowFunction) SetGroups(groups *Groups) {
+	f.Groups = groups
+}
+
+func (f *WindowFunction) interval() int {
+	if f.Time.Interval > 0 {
+		return f.Time.Interval
+	}
+	return f.Time.DatasourceInterval
+}
+
+func (f *WindowFunction) over(frame string) string {
+	timeAlias := strings.Trim(f.Time.Alias, "`")
+	partitions := []string{}
+	if f.Groups != nil {
+		for _, node := range f.Groups.groups {
+			group := node.(*Group)
+			if group.Flag == GROUP_FLAG_METRICS_INNTER {
+				continue
+			}
+			name := strings.Trim(group.Alias, "`")
+			if name == "" {
+				name = strings.Trim(group.Value, "`")
+			}
+			if name == timeAlias {
+				continue
+			}
+			if group.Alias == "" && strings.Contains(name, ",") {
+				partitions = append(partitions, name)
+			} else {
+				partitions = append(partitions, "`"+name+"`")
+			}
+		}
+	}
+	buf := bytes.Buffer{}
+	buf.WriteString("OVER (")
+	if len(partitions) > 0 {
+		buf.WriteString("PARTITION BY ")
+		buf.WriteString(strings.Join(partitions, ", "))
+		buf.WriteString(" ")
+	}
+	buf.WriteString(fmt.Sprintf("ORDER BY `%s` %s)", timeAlias, frame))
+	return buf.String()
+}
+
+func (f *WindowFunction) WriteTo(buf *bytes.Buffer) {
+	// ToString会调用内嵌DefaultFunction的WriteTo，需直接调用聚合算子的WriteTo，以与不加窗口时的算子结果一致
+	fieldBuf := bytes.Buffer{}
+	f.Fields[0].WriteTo(&fieldBuf)
+	field := fieldBuf.String()
+	timeField := fmt.Sprintf("`%s`", strings.Trim(f.Time.Alias, "`"))
+	previousFrame := "ROWS BETWEEN 1 PRECEDING AND CURRENT ROW"
+	cumulativeFrame := "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW"
+	switch f.Name {
+	case FUNCTION_MOVING_AVG:
+		// RANGE窗口以时间计算，补点前缺失的时间桶不会被计入窗口
+		windowSize, _ := strconv.Atoi(f.Fields[1].ToString())
+		buf.WriteString(fmt.Sprintf("avg(%s) %s", field, f.over(fmt.Sprintf("RANGE BETWEEN %d PRECEDING AND CURRENT ROW", (windowSize-1)*f.interval()))))
+	case FUNCTION_CUMULATIVE:
+		buf.WriteString(fmt.Sprintf("sum(%s) %s", field, f.over(cumulativeFrame)))
+	case FUNCTION_EWMA:
+		// alpha为每个时间桶的平滑系数，换算为以时间桶为单位的衰减系数
+		alpha, _ := strconv.ParseFloat(f.Fields[1].ToString(), 64)
+		decay := -1 / math.Log(1-alpha)
+		buf.WriteString(fmt.Sprintf("exponentialTimeDecayedAvg(%s)(%s, %s/%d) %s", strconv.FormatFloat(decay, 'f', -1, 64), field, timeField, f.interval(), f.over(cumulativeFrame)))
+	case FUNCTION_DIFFERENCE:
+		// 第一个时间桶没有前值，结果为null
+		buf.WriteString(fmt.Sprintf("minus(%s, lagInFrame(toNullable(%s)) %s)", field, field, f.over(previousFrame)))
+	case FUNCTION_RATE:
+		buf.WriteString(fmt.Sprintf(
+			"divide(minus(%s, lagInFrame(toNullable(%s)) %s), minus(%s, lagInFrame(toNullable(%s)) %s))",
+			field, field, f.over(previousFrame), timeField, timeField, f.over(previousFrame),
+		))
+	}
+	if f.Alias != "" {
+		buf.WriteString(" AS ")
+		buf.WriteString("`")
+		buf.WriteString(strings.Trim(f.Alias, "`"))
+		buf.WriteString("`")
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package governance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logging "github.com/op/go-logging"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+)
+
+var log = logging.MustGetLogger("governance")
+
+// Tracker limits the ClickHouse queries of one querier request and collects their statistics
+type Tracker struct {
+	ORGID  string
+	UserID string
+	Limit  config.QueryLimit
+
+	key      string // concurrency is counted by key
+	acquired bool
+
+	readRows   uint64
+	readBytes  uint64
+	resultRows uint64
+
+	sqlLock sync.Mutex
+	sqls    []string
+}
+
+type concurrency struct {
+	sync.Mutex
+	running map[string]int
+}
+
+var runningQueries = &concurrency{running: make(map[string]int)}
+
+func getGovernance() *config.QueryGovernance {
+	if config.Cfg == nil || !config.Cfg.QueryGovernance.Enabled {
+		return nil
+	}
+	return &config.Cfg.QueryGovernance
+}
+
+// GetLimit returns the limit of a user and the key to count its concurrent queries.
+// A rule of the user is preferred to a rule of the org, and the default limit is used if no rule matches.
+// A field not set in the matched rule falls back to the default limit.
+func GetLimit(orgID, userID string) (config.QueryLimit, string) {
+	g := getGovernance()
+	if g == nil {
+		return config.QueryLimit{}, orgID
+	}
+	var orgRule, userRule *config.QueryLimitRule
+	for i := range g.Rules {
+		rule := &g.Rules[i]
+		if rule.ORGID != orgID {
+			continue
+		}
+		if rule.UserID == "" {
+			orgRule = rule
+		} else if rule.UserID == userID {
+			userRule = rule
+		}
+	}
+	limit, key := g.Default, orgID
+	rule := orgRule
+	if userRule != nil {
+		rule, key = userRule, orgID+"/"+userID
+	}
+	if rule != nil {
+		if rule.MaxConcurrentQueries > 0 {
+			limit.MaxConcurrentQueries = rule.MaxConcurrentQueries
+		}
+		if rule.MaxTimeRange > 0 {
+			limit.MaxTimeRange = rule.MaxTimeRange
+		}
+		if rule.MaxRowsToRead > 0 {
+			limit.MaxRowsToRead = rule.MaxRowsToRead
+		}
+		if rule.MaxExecutionTime > 0 {
+			limit.MaxExecutionTime = rule.MaxExecutionTime
+		}
+	}
+	return limit, key
+}
+
+func NewTracker(orgID, userID string) *Tracker {
+	if orgID == "" {
+		orgID = common.DEFAULT_ORG_ID
+	}
+	limit, key := GetLimit(orgID, userID)
+	return &Tracker{
+		ORGID:  orgID,
+		UserID: userID,
+		Limit:  limit,
+		key:    key,
+	}
+}
+
+// Acquire fails if the concurrent queries of the org or user reach the limit, Release must be called if it succeeds
+func (t *Tracker) Acquire() error {
+	if t.Limit.MaxConcurrentQueries <= 0 {
+		return nil
+	}
+	runningQueries.Lock()
+	defer runningQueries.Unlock()
+	if runningQueries.running[t.key] >= t.Limit.MaxConcurrentQueries {
+		return common.NewError(common.TOO_MANY_QUERIES, fmt.Sprintf("too many concurrent queries of %s, limit: %d", t.key, t.Limit.MaxConcurrentQueries))
+	}
+	runningQueries.running[t.key]++
+	t.acquired = true
+	return nil
+}
+
+func (t *Tracker) Release() {
+	if !t.acquired {
+		return
+	}
+	runningQueries.Lock()
+	defer runningQueries.Unlock()
+	runningQueries.running[t.key]--
+	if runningQueries.running[t.key] <= 0 {
+		delete(runningQueries.running, t.key)
+	}
+	t.acquired = false
+}
+
+// CheckTimeRange checks the time range of the query, timeStart and timeEnd are 0 if not limited by sql
+func (t *Tracker) CheckTimeRange(timeStart, timeEnd int64) error {
+	if t.Limit.MaxTimeRange <= 0 {
+		return nil
+	}
+	if timeEnd == 0 {
+		timeEnd = time.Now().Unix()
+	}
+	if timeStart == 0 || timeEnd-timeStart > int64(t.Limit.MaxTimeRange) {
+		return common.NewError(common.QUERY_LIMIT_EXCEEDED, fmt.Sprintf("time range of query exceeds the limit %ds, please add or narrow the time filter", t.Limit.MaxTimeRange))
+	}
+	return nil
+}
+
+// Settings returns the ClickHouse settings of the limit
+func (t *Tracker) Settings() map[string]interface{} {
+	settings := make(map[string]interface{})
+	if t.Limit.MaxRowsToRead > 0 {
+		settings["max_rows_to_read"] = t.Limit.MaxRowsToRead
+	}
+	if t.Limit.MaxExecutionTime > 0 {
+		settings["max_execution_time"] = t.Limit.MaxExecutionTime
+	}
+	return settings
+}
+
+func (t *Tracker) AddProgress(rows, bytes uint64) {
+	atomic.AddUint64(&t.readRows, rows)
+	atomic.AddUint64(&t.readBytes, bytes)
+}
+
+func (t *Tracker) AddResult(sql string, rows int) {
+	atomic.AddUint64(&t.resultRows, uint64(rows))
+	t.sqlLock.Lock()
+	t.sqls = append(t.sqls, sql)
+	t.sqlLock.Unlock()
+}
+
+// Stats returns rows and bytes read by ClickHouse and rows of the results
+func (t *Tracker) Stats() (readRows, readBytes, resultRows uint64) {
+	return atomic.LoadUint64(&t.readRows), atomic.LoadUint64(&t.readBytes), atomic.LoadUint64(&t.resultRows)
+}
+
+type trackerKey struct{}
+
+func WithTracker(ctx context.Context, t *Tracker) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, trackerKey{}, t)
+}
+
+func FromContext(ctx context.Context) *Tracker {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(trackerKey{}).(*Tracker)
+	return t
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package governance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/libs/eventapi"
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+)
+
+type testQueue struct {
+	items []interface{}
+}
+
+func (q *testQueue) Put(items ...interface{}) error {
+	q.items = append(q.items, items...)
+	return nil
+}
+
+func (q *testQueue) Len() int     { return len(q.items) }
+func (q *testQueue) Close() error { return nil }
+
+func setConfig() {
+	config.Cfg = &config.QuerierConfig{
+		QueryGovernance: config.QueryGovernance{
+			Enabled: true,
+			Default: config.QueryLimit{MaxConcurrentQueries: 2, MaxTimeRange: 3600, MaxExecutionTime: 60},
+			Rules: []config.QueryLimitRule{
+				{ORGID: "2", QueryLimit: config.QueryLimit{MaxConcurrentQueries: 1}},
+				{ORGID: "2", UserID: "10", QueryLimit: config.QueryLimit{MaxRowsToRead: 1000}},
+			},
+			SlowQueryThreshold: 1,
+		},
+	}
+}
+
+func TestGetLimit(t *testing.T) {
+	setConfig()
+	defer func() { config.Cfg = nil }()
+	cases := []struct {
+		orgID, userID string
+		limit         config.QueryLimit
+		key           string
+	}{
+		{"1", "10", config.QueryLimit{MaxConcurrentQueries: 2, MaxTimeRange: 3600, MaxExecutionTime: 60}, "1"},
+		{"2", "11", config.QueryLimit{MaxConcurrentQueries: 1, MaxTimeRange: 3600, MaxExecutionTime: 60}, "2"},
+		{"2", "10", config.QueryLimit{MaxConcurrentQueries: 2, MaxTimeRange: 3600, MaxRowsToRead: 1000, MaxExecutionTime: 60}, "2/10"},
+	}
+	for _, c := range cases {
+		limit, key := GetLimit(c.orgID, c.userID)
+		if limit != c.limit || key != c.key {
+			t.Errorf("GetLimit(%s, %s) = %+v %s, want %+v %s", c.orgID, c.userID, limit, key, c.limit, c.key)
+		}
+	}
+
+	config.Cfg.QueryGovernance.Enabled = false
+	if limit, _ := GetLimit("1", ""); limit != (config.QueryLimit{}) {
+		t.Errorf("GetLimit should not limit if governance is disabled, get %+v", limit)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	setConfig()
+	defer func() { config.Cfg = nil }()
+	t1, t2 := NewTracker("2", "11"), NewTracker("2", "12")
+	if err := t1.Acquire(); err != nil {
+		t.Fatal(err)
+	}
+	err := t2.Acquire()
+	if serviceErr, ok := err.(*common.ServiceError); !ok || serviceErr.Status != common.TOO_MANY_QUERIES {
+		t.Errorf("Acquire should fail with %s, get %v", common.TOO_MANY_QUERIES, err)
+	}
+	// user 10 has its own concurrency
+	t3 := NewTracker("2", "10")
+	if err := t3.Acquire(); err != nil {
+		t.Error(err)
+	}
+	t3.Release()
+	t1.Release()
+	if err := t2.Acquire(); err != nil {
+		t.Error(err)
+	}
+	t2.Release()
+	t2.Release()
+	if len(runningQueries.running) != 0 {
+		t.Errorf("running queries should be empty, get %v", runningQueries.running)
+	}
+}
+
+func TestCheckTimeRange(t *testing.T) {
+	setConfig()
+	defer func() { config.Cfg = nil }()
+	tracker := NewTracker("1", "")
+	now := time.Now().Unix()
+	if err := tracker.CheckTimeRange(now-3600, now); err != nil {
+		t.Error(err)
+	}
+	if err := tracker.CheckTimeRange(now-60, 0); err != nil {
+		t.Error(err)
+	}
+	for _, timeRange := range [][2]int64{{now - 3601, now}, {0, now}, {now - 7200, 0}} {
+		if err := tracker.CheckTimeRange(timeRange[0], timeRange[1]); err == nil {
+			t.Errorf("CheckTimeRange(%d, %d) should fail", timeRange[0], timeRange[1])
+		}
+	}
+	settings := NewTracker("2", "10").Settings()
+	if settings["max_rows_to_read"] != uint64(1000) || settings["max_execution_time"] != 60 {
+		t.Errorf("get settings %v", settings)
+	}
+}
+
+func TestRecordSlowQuery(t *testing.T) {
+	setConfig()
+	queue := &testQueue{}
+	SetSlowQueryQueue(queue)
+	defer func() {
+		config.Cfg = nil
+		SetSlowQueryQueue(nil)
+	}()
+
+	tracker := NewTracker("2", "10")
+	ctx := WithTracker(context.Background(), tracker)
+	FromContext(ctx).AddProgress(100, 2000)
+	FromContext(ctx).AddResult("SELECT 1", 1)
+	FromContext(ctx).AddResult("SELECT 2", 2)
+
+	tracker.RecordSlowQuery("uuid", "flow_log", "select 1", time.Now(), nil)
+	if len(queue.items) != 0 {
+		t.Errorf("fast query should not be recorded")
+	}
+	tracker.RecordSlowQuery("uuid", "flow_log", "select 1", time.Now().Add(-time.Second), errors.New("timeout"))
+	if len(queue.items) != 1 {
+		t.Fatalf("slow query should be recorded")
+	}
+	event := queue.items[0].(*eventapi.SlowQueryEvent)
+	if event.TranslatedSql != "SELECT 1; SELECT 2" || event.ReadRows != 100 || event.ReadBytes != 2000 || event.ResultRows != 3 ||
+		event.ORGID != 2 || event.UserID != 10 || event.Error != "timeout" || event.Duration < uint64(time.Second/time.Microsecond) {
+		t.Errorf("get slow query event %+v", event)
+	}
+}
+
+func TestRecordSlowQueryDisabled(t *testing.T) {
+	setConfig()
+	config.Cfg.QueryGovernance.Enabled = false
+	queue := &testQueue{}
+	SetSlowQueryQueue(queue)
+	defer func() {
+		config.Cfg = nil
+		SetSlowQueryQueue(nil)
+	}()
+
+	NewTracker("2", "10").RecordSlowQuery("uuid", "flow_log", "select 1", time.Now().Add(-time.Second), nil)
+	if len(queue.items) != 0 {
+		t.Errorf("slow query should not be recorded when query governance is disabled")
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package governance
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/libs/eventapi"
+	"github.com/deepflowio/deepflow/server/libs/queue"
+)
+
+var slowQueryQueue queue.QueueWriter
+
+// SetSlowQueryQueue sets the queue to ingester, which writes slow queries to event.slow_query_event
+func SetSlowQueryQueue(q queue.QueueWriter) {
+	slowQueryQueue = q
+}
+
+// RecordSlowQuery sends the query to ingester if query governance is enabled and it costs more than the slow query threshold
+func (t *Tracker) RecordSlowQuery(queryUUID, db, sql string, startTime time.Time, err error) {
+	g := getGovernance()
+	if slowQueryQueue == nil || g == nil || g.SlowQueryThreshold <= 0 {
+		return
+	}
+	duration := time.Since(startTime)
+	if duration < time.Duration(g.SlowQueryThreshold)*time.Millisecond {
+		return
+	}
+	event := eventapi.AcquireSlowQueryEvent()
+	event.Time = uint32(startTime.Add(duration).Unix())
+	event.StartTime = startTime.UnixMicro()
+	event.Duration = uint64(duration.Microseconds())
+	event.QueryUUID = queryUUID
+	event.DB = db
+	event.Sql = sql
+	t.sqlLock.Lock()
+	event.TranslatedSql = strings.Join(t.sqls, "; ")
+	t.sqlLock.Unlock()
+	event.ReadRows, event.ReadBytes, event.ResultRows = t.Stats()
+	if err != nil {
+		event.Error = err.Error()
+	}
+	orgID, _ := strconv.ParseUint(t.ORGID, 10, 16)
+	userID, _ := strconv.ParseUint(t.UserID, 10, 32)
+	event.ORGID, event.UserID = uint16(orgID), uint32(userID)
+	if err := slowQueryQueue.Put(event); err != nil {
+		log.Warningf("put slow query event failed: %s", err)
+		event.Release()
+	}
+}
@@ -32,10 +32,10 @@ import (
 	"github.com/deepflowio/deepflow/server/libs/utils"
 	querier_common "github.com/deepflowio/deepflow/server/querier/common"
 	"github.com/deepflowio/deepflow/server/querier/config"
-	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse"
 	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/client"
 	"github.com/deepflowio/deepflow/server/querier/profile/common"
 	"github.com/deepflowio/deepflow/server/querier/profile/model"
+	"github.com/deepflowio/deepflow/server/querier/service"
 )
 
 var log = logging.MustGetLogger("profile")
@@ -74,8 +74,6 @@ func GenerateProfile(args model.Profile, cfg *config.QuerierConfig, where string
 			common.PROFILE_LOCATION_STR, common.PROFILE_VALUE, common.PROFILE_VALUE, common.TABLE_PROFILE, where, common.PROFILE_LOCATION_STR, common.TAG_AGENT_ID, common.TAG_PROCESS_ID, limitSql,
 		)
 	}
-	ckEngine := &clickhouse.CHEngine{DB: common.DATABASE_PROFILE}
-	ckEngine.Init()
 	querierArgs := querier_common.QuerierParams{
 		DB:      common.DATABASE_PROFILE,
 		Sql:     sql,
@@ -84,7 +82,7 @@ func GenerateProfile(args model.Profile, cfg *config.QuerierConfig, where string
 		ORGID:   args.OrgID,
 	}
 	// XXX: change to streaming read, reduce memory
-	querierResult, querierDebug, err := ckEngine.ExecuteQuery(&querierArgs)
+	querierResult, querierDebug, err := service.StreamExecute(&querierArgs)
 	profileDebug := NewProfileDebug(sql, querierDebug)
 	debugs.QuerierDebug = append(debugs.QuerierDebug, profileDebug)
 	if err != nil {
@@ -30,13 +30,17 @@ import (
 	servercommon "github.com/deepflowio/deepflow/server/common"
 	"github.com/deepflowio/deepflow/server/libs/logger"
 	"github.com/deepflowio/deepflow/server/libs/stats"
+	anomaly_router "github.com/deepflowio/deepflow/server/querier/app/anomaly/router"
 	distributed_tracing "github.com/deepflowio/deepflow/server/querier/app/distributed_tracing/router"
 	"github.com/deepflowio/deepflow/server/querier/app/distributed_tracing/service/tracemap"
 	prometheus_router "github.com/deepflowio/deepflow/server/querier/app/prometheus/router"
+	slo_router "github.com/deepflowio/deepflow/server/querier/app/slo/router"
+	topology_router "github.com/deepflowio/deepflow/server/querier/app/topology/router"
 	tracing_adapter "github.com/deepflowio/deepflow/server/querier/app/tracing-adapter/router"
 	"github.com/deepflowio/deepflow/server/querier/common"
 	"github.com/deepflowio/deepflow/server/querier/config"
 	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/trans_prometheus"
+	"github.com/deepflowio/deepflow/server/querier/governance"
 	profile_router "github.com/deepflowio/deepflow/server/querier/profile/router"
 	"github.com/deepflowio/deepflow/server/querier/router"
 	"github.com/deepflowio/deepflow/server/querier/statsd"
@@ -80,6 +84,7 @@ func Start(configPath, serverLogFile string, shared *servercommon.ControllerInge
 	gin.DefaultWriter = io.MultiWriter(ginLogFile, os.Stdout)
 	tracemap_generator := tracemap.NewTraceMapGenerator(shared.TraceTreeQueue, &cfg)
 	tracemap_generator.Start()
+	governance.SetSlowQueryQueue(shared.SlowQueryEventQueue)
 
 	// 注册router
 	r := gin.New()
@@ -90,9 +95,12 @@ func Start(configPath, serverLogFile string, shared *servercommon.ControllerInge
 	r.Use(ErrHandle())
 	router.QueryRouter(r)
 	profile_router.ProfileRouter(r, &cfg)
-	prometheus_router.PrometheusRouter(r)
+	prometheusService := prometheus_router.PrometheusRouter(r)
+	anomaly_router.AnomalyRouter(r, prometheusService)
+	slo_router.SLORouter(r, prometheusService)
 	tracing_adapter.TracingAdapterRouter(r)
 	distributed_tracing.TraceMapRouter(r, &cfg, tracemap_generator)
+	topology_router.TopologyRouter(r)
 	registerRouterCounter(r.Routes())
 	// TODO: 增加router
 	if err := r.Run(fmt.Sprintf(":%d", cfg.ListenPort)); err != nil {
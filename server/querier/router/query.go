@@ -18,16 +18,23 @@ package router
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	logging "github.com/op/go-logging"
 
 	"github.com/deepflowio/deepflow/server/querier/common"
 	"github.com/deepflowio/deepflow/server/querier/service"
+	"github.com/deepflowio/deepflow/server/querier/stream"
 )
 
+var log = logging.MustGetLogger("router")
+
 func QueryRouter(e *gin.Engine) {
 	e.POST("/v1/query/", executeQuery())
+	e.GET("/v1/queries", listQueries())
+	e.DELETE("/v1/queries/:uuid", killQuery())
 
 	// api router for tempo
 	e.GET("/api/traces/:traceId", tempoTraceReader())
@@ -47,11 +54,19 @@ func executeQuery() gin.HandlerFunc {
 		args.QueryCacheTTL = c.Query("query_cache_ttl")
 		args.QueryUUID = c.Query("query_uuid")
 		args.NoPreWhere, _ = strconv.ParseBool(c.DefaultQuery("no_prewhere", "false"))
+		args.OutputFormat = c.DefaultQuery("output_format", stream.FORMAT_JSON)
+		args.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "0"))
+		args.Cursor = c.Query("cursor")
+		if clusters := c.Query("clusters"); clusters != "" {
+			args.Clusters = strings.Split(clusters, ",")
+		}
+		args.MergeClusters, _ = strconv.ParseBool(c.DefaultQuery("merge_clusters", "false"))
 		args.ORGID = c.Request.Header.Get(common.HEADER_KEY_X_ORG_ID)
 		// if no org_id in header, set default org id
 		if args.ORGID == "" {
 			args.ORGID = common.DEFAULT_ORG_ID
 		}
+		args.UserID = c.Request.Header.Get(common.HEADER_KEY_X_USER_ID)
 		if args.QueryUUID == "" {
 			query_uuid := uuid.New()
 			args.QueryUUID = query_uuid.String()
@@ -66,16 +81,100 @@ func executeQuery() gin.HandlerFunc {
 			args.Sql, _ = json["sql"].(string)
 		}
 
+		if args.PageSize < 0 {
+			JsonResponse(c, nil, nil, common.NewError(common.INVALID_PARAMETERS, "page_size must not be negative"))
+			return
+		}
+		if args.PageSize > 0 && len(args.Clusters) > 0 {
+			JsonResponse(c, nil, nil, common.NewError(common.INVALID_PARAMETERS, "page_size is not supported by federated queries"))
+			return
+		}
+		if args.OutputFormat != stream.FORMAT_JSON {
+			streamQuery(c, &args)
+			return
+		}
+
 		result := map[string]interface{}{}
 		debug := map[string]interface{}{}
 		var err error
-		// simple sql
-		if args.SimpleSql {
-			result, debug, err = service.SimpleExecute(&args)
+		result, debug, err = service.Execute(&args)
+		if err == nil && args.Debug != "true" {
+			debug = nil
+		}
+		JsonResponse(c, result, debug, err)
+	})
+}
+
+// streamQuery writes rows in args.OutputFormat while they are read from ClickHouse.
+// Errors are responded as json until the first row is written, after that they can only be logged.
+func streamQuery(c *gin.Context, args *common.QuerierParams) {
+	writer, err := stream.NewResultWriter(args.OutputFormat, c.Writer)
+	if err != nil {
+		JsonResponse(c, nil, nil, common.NewError(common.INVALID_PARAMETERS, err.Error()))
+		return
+	}
+	args.ResultWriter = writer
+	if args.PageSize > 0 {
+		c.Header("Trailer", common.HEADER_KEY_X_NEXT_CURSOR)
+	}
+	result, debug, err := service.StreamExecute(args)
+	if err != nil {
+		if !writer.HeaderWritten() {
+			if args.Debug != "true" {
+				debug = nil
+			}
+			JsonResponse(c, nil, debug, err)
 		} else {
-			result, debug, err = service.Execute(&args)
+			log.Errorf("query_uuid: %s | stream result failed: %s", args.QueryUUID, err)
 		}
-		if err == nil && args.Debug != "true" {
+		return
+	}
+	// results not read by the client streamer, such as show sql, are written as a whole
+	if !writer.HeaderWritten() {
+		err = writer.WriteHeader(result.Columns, result.Schemas)
+		if err == nil && len(result.Values) > 0 {
+			err = writer.WriteRows(result.Values)
+		}
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		log.Errorf("query_uuid: %s | stream result failed: %s", args.QueryUUID, err)
+		return
+	}
+	if result.NextCursor != "" {
+		c.Writer.Header().Set(common.HEADER_KEY_X_NEXT_CURSOR, result.NextCursor)
+	}
+}
+
+// only queries of the caller's org are visible, the default org is used if no org_id in header
+func getOrgID(c *gin.Context) string {
+	orgID := c.Request.Header.Get(common.HEADER_KEY_X_ORG_ID)
+	if orgID == "" {
+		orgID = common.DEFAULT_ORG_ID
+	}
+	return orgID
+}
+
+func isAdmin(c *gin.Context) bool {
+	userType := c.Request.Header.Get(common.HEADER_KEY_X_USER_TYPE)
+	return userType == common.USER_TYPE_SUPER_ADMIN || userType == common.USER_TYPE_ADMIN
+}
+
+func listQueries() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		result := service.ListQueries(getOrgID(c))
+		JsonResponse(c, result, nil, nil)
+	})
+}
+
+func killQuery() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		// non-admin users can only kill their own queries
+		userID := c.Request.Header.Get(common.HEADER_KEY_X_USER_ID)
+		result, debug, err := service.KillQuery(c.Request.Context(), c.Param("uuid"), getOrgID(c), userID, isAdmin(c))
+		if err == nil && c.Query("debug") != "true" {
 			debug = nil
 		}
 		JsonResponse(c, result, debug, err)
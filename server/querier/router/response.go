@@ -55,6 +55,20 @@ func BadRequestResponse(c *gin.Context, optStatus string, description string) {
 	})
 }
 
+func TooManyRequestsResponse(c *gin.Context, optStatus string, description string) {
+	c.JSON(http.StatusTooManyRequests, Response{
+		OptStatus:   optStatus,
+		Description: description,
+	})
+}
+
+func ForbiddenResponse(c *gin.Context, optStatus string, description string) {
+	c.JSON(http.StatusForbidden, Response{
+		OptStatus:   optStatus,
+		Description: description,
+	})
+}
+
 func InternalErrorResponse(c *gin.Context, data interface{}, debug interface{}, optStatus string, description string) {
 	c.JSON(http.StatusInternalServerError, Response{
 		OptStatus:   optStatus,
@@ -70,8 +84,12 @@ func JsonResponse(c *gin.Context, data interface{}, debug interface{}, err error
 		case *common.ServiceError:
 			switch t.Status {
 			case common.RESOURCE_NOT_FOUND, common.INVALID_POST_DATA, common.RESOURCE_NUM_EXCEEDED,
-				common.SELECTED_RESOURCES_NUM_EXCEEDED:
+				common.SELECTED_RESOURCES_NUM_EXCEEDED, common.INVALID_PARAMETERS, common.QUERY_LIMIT_EXCEEDED:
 				BadRequestResponse(c, t.Status, t.Message)
+			case common.TOO_MANY_QUERIES:
+				TooManyRequestsResponse(c, t.Status, t.Message)
+			case common.PERMISSION_DENIED:
+				ForbiddenResponse(c, t.Status, t.Message)
 			case common.SERVER_ERROR:
 				InternalErrorResponse(c, data, debug, t.Status, t.Message)
 			}
@@ -17,12 +17,52 @@
 package service
 
 import (
+	"context"
+	"time"
+
 	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
 	"github.com/deepflowio/deepflow/server/querier/engine"
 	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse"
+	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse/client"
+	"github.com/deepflowio/deepflow/server/querier/governance"
 )
 
 func Execute(args *common.QuerierParams) (jsonData map[string]interface{}, debug map[string]interface{}, err error) {
+	result, debug, err := StreamExecute(args)
+	if result != nil {
+		jsonData = result.ToJson()
+	}
+	return jsonData, debug, err
+}
+
+// CLUSTERS_ALL federates the query across all configured clusters
+const CLUSTERS_ALL = "all"
+
+// StreamExecute returns the result without converting it to json, if args.ResultWriter is set,
+// rows are written by it and the returned result only has columns, schemas and next cursor.
+// Queries are limited by the query governance of the org and user, and recorded if they are slow.
+// If args.Clusters is set, the query is executed on each of the clusters and rows are tagged with their cluster,
+// rows of aggregated queries are re-aggregated across the clusters instead if args.MergeClusters is set.
+func StreamExecute(args *common.QuerierParams) (result *common.Result, debug map[string]interface{}, err error) {
+	tracker := governance.NewTracker(args.ORGID, args.UserID)
+	if err := tracker.Acquire(); err != nil {
+		return nil, nil, err
+	}
+	defer tracker.Release()
+	args.Context = governance.WithTracker(args.Context, tracker)
+	startTime := time.Now()
+	defer func() {
+		tracker.RecordSlowQuery(args.QueryUUID, args.DB, args.Sql, startTime, err)
+	}()
+
+	if len(args.Clusters) == 1 && args.Clusters[0] == CLUSTERS_ALL {
+		args.Clusters = client.ClusterNames()
+	}
+
+	if args.SimpleSql {
+		return clickhouse.SimpleExecute(args)
+	}
 	db := getDbBy()
 	var engine engine.Engine
 	switch db {
@@ -30,21 +70,35 @@ func Execute(args *common.QuerierParams) (jsonData map[string]interface{}, debug
 		engine = &clickhouse.CHEngine{DB: args.DB, DataSource: args.DataSource, Context: args.Context}
 		engine.Init()
 	}
-	result, debug, err := engine.ExecuteQuery(args)
-	if result != nil {
-		jsonData = result.ToJson()
-	}
-	return jsonData, debug, err
+	return engine.ExecuteQuery(args)
 }
 
 func getDbBy() string {
 	return "clickhouse"
 }
 
-func SimpleExecute(args *common.QuerierParams) (jsonData map[string]interface{}, debug map[string]interface{}, err error) {
-	result, debug, err := clickhouse.SimpleExecute(args)
-	if result != nil {
-		jsonData = result.ToJson()
+// ListQueries returns the ClickHouse queries currently executed by this querier
+func ListQueries(orgID string) []client.ActiveQueryInfo {
+	return client.ActiveQueries.List(orgID)
+}
+
+// KillQuery kills the ClickHouse queries of query_uuid which are executed by this querier
+func KillQuery(ctx context.Context, queryUUID, orgID, userID string, isAdmin bool) (jsonData map[string]interface{}, debug map[string]interface{}, err error) {
+	chClient := client.Client{
+		Host:     config.Cfg.Clickhouse.Host,
+		Port:     config.Cfg.Clickhouse.Port,
+		UserName: config.Cfg.Clickhouse.User,
+		Password: config.Cfg.Clickhouse.Password,
+		DB:       "default",
+		Context:  ctx,
 	}
-	return jsonData, debug, err
+	queryIDs, err := chClient.KillQuery(queryUUID, orgID, userID, isAdmin)
+	if chClient.Debug != nil {
+		debugInfo := &client.DebugInfo{Debug: []client.Debug{*chClient.Debug}}
+		debug = debugInfo.Get()
+	}
+	if err != nil {
+		return nil, debug, err
+	}
+	return map[string]interface{}{"query_uuid": queryUUID, "query_ids": queryIDs}, debug, nil
 }
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+	"github.com/deepflowio/deepflow/server/querier/config"
+	"github.com/deepflowio/deepflow/server/querier/governance"
+)
+
+func TestExecuteConcurrencyLimit(t *testing.T) {
+	config.Cfg = &config.QuerierConfig{
+		QueryGovernance: config.QueryGovernance{
+			Enabled: true,
+			Default: config.QueryLimit{MaxConcurrentQueries: 1},
+		},
+	}
+	defer func() { config.Cfg = nil }()
+
+	running := governance.NewTracker("1", "")
+	if err := running.Acquire(); err != nil {
+		t.Fatal(err)
+	}
+	defer running.Release()
+	for _, simpleSql := range []bool{true, false} {
+		args := &common.QuerierParams{
+			DB:        "flow_log",
+			Sql:       "SELECT 1",
+			SimpleSql: simpleSql,
+			ORGID:     "1",
+			Context:   context.Background(),
+		}
+		_, _, err := Execute(args)
+		if serviceErr, ok := err.(*common.ServiceError); !ok || serviceErr.Status != common.TOO_MANY_QUERIES {
+			t.Errorf("Execute with simple_sql %v should fail with %s, get %v", simpleSql, common.TOO_MANY_QUERIES, err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/ipc"
+	"github.com/apache/arrow/go/v11/arrow/memory"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+// ArrowWriter writes rows as Arrow IPC stream, one record batch per WriteRows.
+// Field types are built from the ClickHouse types of the columns, a value that does not match the type of its
+// field fails the write.
+type ArrowWriter struct {
+	base
+	schema *arrow.Schema
+	writer *ipc.Writer
+	mem    memory.Allocator
+}
+
+func newArrowWriter(w http.ResponseWriter) *ArrowWriter {
+	return &ArrowWriter{
+		base: base{w: w, contentType: "application/vnd.apache.arrow.stream"},
+		mem:  memory.NewGoAllocator(),
+	}
+}
+
+func (a *ArrowWriter) WriteHeader(columns []interface{}, schemas common.ColumnSchemas) error {
+	a.open(columns, schemas)
+	a.writeHeader()
+	return nil
+}
+
+func (a *ArrowWriter) WriteRows(values []interface{}) error {
+	builder := array.NewRecordBuilder(a.mem, a.schema)
+	defer builder.Release()
+	for _, value := range values {
+		row := value.([]interface{})
+		for i, field := range builder.Fields() {
+			var v interface{}
+			if i < len(row) {
+				v = deref(row[i])
+			}
+			if err := appendValue(field, v); err != nil {
+				return fmt.Errorf("column %s: %s", a.schema.Field(i).Name, err)
+			}
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+	if err := a.writer.Write(record); err != nil {
+		return err
+	}
+	a.flush()
+	return nil
+}
+
+func (a *ArrowWriter) Close() error {
+	if a.writer == nil {
+		a.open(nil, nil)
+	}
+	return a.writer.Close()
+}
+
+func (a *ArrowWriter) open(columns []interface{}, schemas common.ColumnSchemas) {
+	fields := make([]arrow.Field, 0, len(columns))
+	for i, column := range columns {
+		name, _ := column.(string)
+		var dataType arrow.DataType
+		if i < len(schemas) && schemas[i] != nil {
+			dataType = clickhouseDataType(schemas[i].ValueType)
+		}
+		if dataType == nil {
+			dataType = arrow.BinaryTypes.String
+		}
+		fields = append(fields, arrow.Field{Name: name, Type: dataType, Nullable: true})
+	}
+	a.schema = arrow.NewSchema(fields, nil)
+	a.writer = ipc.NewWriter(a.w, ipc.WithSchema(a.schema), ipc.WithAllocator(a.mem))
+}
+
+func clickhouseDataType(valueType string) arrow.DataType {
+	for _, wrapper := range []string{"Nullable(", "LowCardinality("} {
+		if strings.HasPrefix(valueType, wrapper) {
+			valueType = strings.TrimSuffix(strings.TrimPrefix(valueType, wrapper), ")")
+		}
+	}
+	switch {
+	case valueType == "":
+		return nil
+	case valueType == "Bool":
+		return arrow.FixedWidthTypes.Boolean
+	case strings.HasPrefix(valueType, "Int"):
+		return arrow.PrimitiveTypes.Int64
+	case strings.HasPrefix(valueType, "UInt"):
+		return arrow.PrimitiveTypes.Uint64
+	case strings.HasPrefix(valueType, "Float"):
+		return arrow.PrimitiveTypes.Float64
+	case strings.HasPrefix(valueType, "DateTime"):
+		return arrow.FixedWidthTypes.Timestamp_ns
+	default:
+		return arrow.BinaryTypes.String
+	}
+}
+
+func appendValue(builder array.Builder, value interface{}) error {
+	if value == nil {
+		builder.AppendNull()
+		return nil
+	}
+	switch b := builder.(type) {
+	case *array.BooleanBuilder:
+		if v, ok := value.(bool); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.Int64Builder:
+		switch v := value.(type) {
+		case int8:
+			b.Append(int64(v))
+			return nil
+		case int16:
+			b.Append(int64(v))
+			return nil
+		case int32:
+			b.Append(int64(v))
+			return nil
+		case int64:
+			b.Append(v)
+			return nil
+		case int:
+			b.Append(int64(v))
+			return nil
+		}
+	case *array.Uint64Builder:
+		switch v := value.(type) {
+		case uint8:
+			b.Append(uint64(v))
+			return nil
+		case uint16:
+			b.Append(uint64(v))
+			return nil
+		case uint32:
+			b.Append(uint64(v))
+			return nil
+		case uint64:
+			b.Append(v)
+			return nil
+		case uint:
+			b.Append(uint64(v))
+			return nil
+		}
+	case *array.Float64Builder:
+		// metrics filled by callbacks may be integers
+		switch v := value.(type) {
+		case float32:
+			b.Append(float64(v))
+			return nil
+		case float64:
+			b.Append(v)
+			return nil
+		case int:
+			b.Append(float64(v))
+			return nil
+		case int64:
+			b.Append(float64(v))
+			return nil
+		case uint64:
+			b.Append(float64(v))
+			return nil
+		}
+	case *array.TimestampBuilder:
+		if v, ok := value.(time.Time); ok {
+			b.Append(arrow.Timestamp(v.UnixNano()))
+			return nil
+		}
+	case *array.StringBuilder:
+		if v, ok := value.(net.IP); ok {
+			b.Append(v.String())
+		} else {
+			b.Append(formatValue(value))
+		}
+		return nil
+	}
+	return fmt.Errorf("value %v of type %T does not match %s", value, value, builder.Type())
+}
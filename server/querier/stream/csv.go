@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"encoding/csv"
+	"net/http"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+// CSVWriter writes column names as the first line and then one line per row
+type CSVWriter struct {
+	base
+	writer *csv.Writer
+}
+
+func newCSVWriter(w http.ResponseWriter) *CSVWriter {
+	return &CSVWriter{
+		base:   base{w: w, contentType: "text/csv; charset=utf-8"},
+		writer: csv.NewWriter(w),
+	}
+}
+
+func (c *CSVWriter) WriteHeader(columns []interface{}, schemas common.ColumnSchemas) error {
+	c.writeHeader()
+	record := make([]string, 0, len(columns))
+	for _, column := range columns {
+		record = append(record, formatValue(column))
+	}
+	return c.writer.Write(record)
+}
+
+func (c *CSVWriter) WriteRows(values []interface{}) error {
+	for _, value := range values {
+		row := value.([]interface{})
+		record := make([]string, 0, len(row))
+		for _, v := range row {
+			record = append(record, formatValue(v))
+		}
+		if err := c.writer.Write(record); err != nil {
+			return err
+		}
+	}
+	c.writer.Flush()
+	c.flush()
+	return c.writer.Error()
+}
+
+func (c *CSVWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+// NDJSONWriter writes every row as a json object keyed by column names, one object per line
+type NDJSONWriter struct {
+	base
+	keys [][]byte
+}
+
+func (n *NDJSONWriter) WriteHeader(columns []interface{}, schemas common.ColumnSchemas) error {
+	n.keys = make([][]byte, 0, len(columns))
+	for _, column := range columns {
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		n.keys = append(n.keys, key)
+	}
+	n.writeHeader()
+	return nil
+}
+
+func (n *NDJSONWriter) WriteRows(values []interface{}) error {
+	buf := bytes.Buffer{}
+	for _, value := range values {
+		row := value.([]interface{})
+		buf.WriteByte('{')
+		for i, v := range row {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(n.keys[i])
+			buf.WriteByte(':')
+			bytes, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			buf.Write(bytes)
+		}
+		buf.WriteString("}\n")
+	}
+	if _, err := n.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	n.flush()
+	return nil
+}
+
+func (n *NDJSONWriter) Close() error {
+	return nil
+}
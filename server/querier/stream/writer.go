@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+const (
+	FORMAT_JSON   = "json"
+	FORMAT_NDJSON = "ndjson"
+	FORMAT_CSV    = "csv"
+	FORMAT_ARROW  = "arrow"
+)
+
+// NewResultWriter returns a common.ResultWriter which writes rows to w in format as soon as they are queried.
+// A nil writer is returned for the json format, which is responded as a whole.
+func NewResultWriter(format string, w http.ResponseWriter) (common.ResultWriter, error) {
+	switch format {
+	case "", FORMAT_JSON:
+		return nil, nil
+	case FORMAT_NDJSON:
+		return &NDJSONWriter{base: base{w: w, contentType: "application/x-ndjson"}}, nil
+	case FORMAT_CSV:
+		return newCSVWriter(w), nil
+	case FORMAT_ARROW:
+		return newArrowWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %s, supported: %s, %s, %s, %s", format, FORMAT_JSON, FORMAT_NDJSON, FORMAT_CSV, FORMAT_ARROW)
+	}
+}
+
+type base struct {
+	w             http.ResponseWriter
+	contentType   string
+	headerWritten bool
+}
+
+func (b *base) HeaderWritten() bool {
+	return b.headerWritten
+}
+
+// writeHeader sets the content type before the first byte of the body is written
+func (b *base) writeHeader() {
+	b.w.Header().Set("Content-Type", b.contentType)
+	b.w.WriteHeader(http.StatusOK)
+	b.headerWritten = true
+}
+
+func (b *base) flush() {
+	if flusher, ok := b.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// deref returns the value pointed by nullable values, or nil for null
+func deref(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// formatValue formats a value to the same text as the json output, except that strings are not quoted
+func formatValue(value interface{}) string {
+	switch v := deref(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case net.IP:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case int8, int16, int32, int64, int, uint8, uint16, uint32, uint64, uint, bool:
+		return fmt.Sprint(v)
+	default:
+		bytes, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(bytes)
+	}
+}
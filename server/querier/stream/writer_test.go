@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2024 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/ipc"
+
+	"github.com/deepflowio/deepflow/server/querier/common"
+)
+
+var (
+	testColumns = []interface{}{"ip", "byte", "time", "server_port"}
+	testSchemas = common.ColumnSchemas{
+		&common.ColumnSchema{Name: "ip", ValueType: "String"},
+		&common.ColumnSchema{Name: "byte", ValueType: "UInt64"},
+		&common.ColumnSchema{Name: "time", ValueType: "DateTime"},
+		&common.ColumnSchema{Name: "server_port", ValueType: "Nullable(UInt16)"},
+	}
+	testTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	testRows = []interface{}{
+		[]interface{}{net.ParseIP("10.1.1.1"), uint64(100), testTime, nil},
+		[]interface{}{"a,b", uint64(200), testTime, uint16(80)},
+	}
+)
+
+func writeTestRows(t *testing.T, format string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	writer, err := NewResultWriter(format, recorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if writer.HeaderWritten() {
+		t.Fatal("header should not be written before WriteHeader")
+	}
+	if err := writer.WriteHeader(testColumns, testSchemas); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range testRows {
+		if err := writer.WriteRows([]interface{}{row}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return recorder
+}
+
+func TestNewResultWriter(t *testing.T) {
+	if writer, err := NewResultWriter(FORMAT_JSON, httptest.NewRecorder()); writer != nil || err != nil {
+		t.Errorf("json format should not be streamed")
+	}
+	if _, err := NewResultWriter("xml", httptest.NewRecorder()); err == nil {
+		t.Errorf("xml format should not be supported")
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	recorder := writeTestRows(t, FORMAT_NDJSON)
+	want := `{"ip":"10.1.1.1","byte":100,"time":"2024-01-02T03:04:05Z","server_port":null}` + "\n" +
+		`{"ip":"a,b","byte":200,"time":"2024-01-02T03:04:05Z","server_port":80}` + "\n"
+	if got := recorder.Body.String(); got != want {
+		t.Errorf("get:\n%s\nwant:\n%s", got, want)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("Content-Type = %s", contentType)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	recorder := writeTestRows(t, FORMAT_CSV)
+	want := "ip,byte,time,server_port\n" +
+		"10.1.1.1,100,2024-01-02T03:04:05Z,\n" +
+		"\"a,b\",200,2024-01-02T03:04:05Z,80\n"
+	if got := recorder.Body.String(); got != want {
+		t.Errorf("get:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestArrowWriter(t *testing.T) {
+	recorder := writeTestRows(t, FORMAT_ARROW)
+	reader, err := ipc.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Release()
+	wantTypes := []string{"utf8", "uint64", "timestamp[ns, tz=UTC]", "uint64"}
+	for i, field := range reader.Schema().Fields() {
+		if field.Name != testColumns[i] || field.Type.String() != wantTypes[i] {
+			t.Errorf("field %d = %s %s, want %s %s", i, field.Name, field.Type, testColumns[i], wantTypes[i])
+		}
+	}
+	rows := 0
+	for reader.Next() {
+		record := reader.Record()
+		rows += int(record.NumRows())
+		if rows == 2 {
+			if ip := record.Column(0).(*array.String).Value(0); ip != "a,b" {
+				t.Errorf("ip = %s", ip)
+			}
+			if port := record.Column(3).(*array.Uint64).Value(0); port != 80 {
+				t.Errorf("server_port = %d", port)
+			}
+		}
+	}
+	if rows != len(testRows) {
+		t.Errorf("rows = %d, want %d", rows, len(testRows))
+	}
+}
+
+func TestArrowWriterTypeMismatch(t *testing.T) {
+	writer, err := NewResultWriter(FORMAT_ARROW, httptest.NewRecorder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteHeader(testColumns, testSchemas); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteRows([]interface{}{[]interface{}{"10.1.1.1", "100", testTime, nil}}); err == nil {
+		t.Errorf("string value of UInt64 column byte should fail the write")
+	}
+}
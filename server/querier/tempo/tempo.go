@@ -24,7 +24,7 @@ import (
 
 	"github.com/deepflowio/deepflow/server/querier/common"
 	"github.com/deepflowio/deepflow/server/querier/config"
-	"github.com/deepflowio/deepflow/server/querier/engine/clickhouse"
+	"github.com/deepflowio/deepflow/server/querier/service"
 
 	/* "github.com/grafana/tempo/pkg/tempopb"
 	v1 "github.com/grafana/tempo/pkg/tempopb/common/v1"
@@ -293,9 +293,7 @@ func ShowTags(args *common.TempoParams) (resp map[string][]interface{}, debug ma
 		QueryUUID:  query_uuid.String(),
 		Context:    args.Context,
 	}
-	ckEngine := &clickhouse.CHEngine{DB: querierArgs.DB, DataSource: querierArgs.DataSource}
-	ckEngine.Init()
-	result, debug, err := ckEngine.ExecuteQuery(&querierArgs)
+	result, debug, err := service.StreamExecute(&querierArgs)
 	if err != nil {
 		// TODO
 		log.Errorf("%v %v", debug, err)
@@ -330,9 +328,7 @@ func ShowTagValues(args *common.TempoParams) (resp map[string][]interface{}, deb
 		QueryUUID:  query_uuid.String(),
 		Context:    args.Context,
 	}
-	ckEngine := &clickhouse.CHEngine{DB: querierArgs.DB, DataSource: querierArgs.DataSource}
-	ckEngine.Init()
-	result, debug, err := ckEngine.ExecuteQuery(&querierArgs)
+	result, debug, err := service.StreamExecute(&querierArgs)
 	if err != nil {
 		// TODO
 		log.Errorf("%v %v", debug, err)
@@ -406,10 +402,8 @@ func TraceSearch(args *common.TempoParams) (resp map[string]interface{}, debug m
 		QueryUUID:  query_uuid.String(),
 		Context:    args.Context,
 	}
-	ckEngine := &clickhouse.CHEngine{DB: querierArgs.DB, DataSource: querierArgs.DataSource}
-	ckEngine.Init()
 	//fmt.Println(sql)
-	result, debug, err := ckEngine.ExecuteQuery(&querierArgs)
+	result, debug, err := service.StreamExecute(&querierArgs)
 	if err != nil {
 		// TODO
 		//log.Errorf("%v %v", debug, err)